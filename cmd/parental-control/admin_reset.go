@@ -0,0 +1,259 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+
+	"parental-control/internal/auth"
+	"parental-control/internal/config"
+	"parental-control/internal/database"
+	"parental-control/internal/models"
+	"parental-control/internal/privilege"
+)
+
+// runAdminReset handles the "admin-reset" subcommand, the offline recovery
+// path for a forgotten or compromised admin password. It stores a new
+// bcrypt-hashed admin password in the database and the configuration file,
+// clears any lockout and two-factor enrollment for the account, revokes its
+// API keys and records the reset in the audit log.
+func runAdminReset(args []string) int {
+	fs := flag.NewFlagSet("admin-reset", flag.ContinueOnError)
+	var (
+		configPath = fs.String("config", "", "Path to configuration file")
+		username   = fs.String("username", "", "Admin username to create or reset")
+	)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *username == "" {
+		fmt.Fprintln(os.Stderr, "Usage: parental-control admin-reset [--config <path>] --username <name>")
+		return 2
+	}
+
+	if !privilege.IsElevated() {
+		fmt.Fprintln(os.Stderr, "admin-reset must be run as root or Administrator")
+		return 1
+	}
+
+	// The password is written back to the file, so one must exist
+	appConfig, path, err := config.Load(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
+		return 1
+	}
+
+	password, err := readNewPassword(os.Stdin)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	hasher := auth.NewPasswordHasher(auth.ConvertSecurityConfig(appConfig.Security).Password)
+	hash, err := hasher.HashPassword(password)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	// Stored users take precedence over the configured admin, so the
+	// database is updated first
+	result, err := recordAdminReset(appConfig.GetDatabaseConfig(), *username, hash)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to reset the stored admin account: %v\n", err)
+		return 1
+	}
+
+	if err := config.UpdateFile(path, func(c *config.Config) {
+		c.Security.AdminUsername = *username
+		c.Security.AdminPassword = hash
+	}); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to update configuration: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("Admin password for %q written to %s\n", *username, path)
+	if result.disabledTwoFactor {
+		fmt.Println("  two-factor authentication turned off; enroll again after signing in")
+	}
+	if result.revokedKeys > 0 {
+		fmt.Printf("  %d API key(s) of the account revoked\n", result.revokedKeys)
+	}
+	for _, name := range []string{"PC_SECURITY_ADMIN_USERNAME", "PC_SECURITY_ADMIN_PASSWORD"} {
+		if os.Getenv(name) != "" {
+			fmt.Printf("  note: %s is set and overrides the configuration file\n", name)
+		}
+	}
+	if !appConfig.Security.EnableAuth {
+		fmt.Println("  note: authentication is disabled (security.enable_auth)")
+	}
+	fmt.Println("Restart the service to apply; all existing sessions end on restart.")
+	return 0
+}
+
+// readNewPassword prompts for a new password and its confirmation. Input
+// from a terminal is not echoed; piped input is read a line at a time.
+func readNewPassword(in *os.File) (string, error) {
+	reader := bufio.NewReader(in)
+	read := func(prompt string) (string, error) {
+		fmt.Print(prompt)
+		if fd := int(in.Fd()); term.IsTerminal(fd) {
+			password, err := term.ReadPassword(fd)
+			fmt.Println()
+			if err != nil {
+				return "", fmt.Errorf("failed to read password: %w", err)
+			}
+			return string(password), nil
+		}
+		line, err := reader.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			return "", fmt.Errorf("failed to read password: %w", err)
+		}
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+
+	password, err := read("New password: ")
+	if err != nil {
+		return "", err
+	}
+	confirm, err := read("Confirm password: ")
+	if err != nil {
+		return "", err
+	}
+	if password != confirm {
+		return "", fmt.Errorf("passwords do not match")
+	}
+	return password, nil
+}
+
+// adminResetResult describes what admin-reset changed besides the password
+type adminResetResult struct {
+	disabledTwoFactor bool
+	revokedKeys       int
+}
+
+// recordAdminReset stores passwordHash for username, creating the admin if no
+// such user is stored, clears its persisted lockout and two-factor enrollment,
+// revokes its API keys and adds a system event to the audit log
+func recordAdminReset(dbConfig database.Config, username, passwordHash string) (adminResetResult, error) {
+	var result adminResetResult
+
+	db, err := database.New(dbConfig)
+	if err != nil {
+		return result, err
+	}
+	defer db.Close()
+	if err := db.InitializeSchema(); err != nil {
+		return result, err
+	}
+
+	ctx := context.Background()
+	admin, err := resetStoredAdmin(ctx, database.NewUserRepository(db.Connection()), username, passwordHash, &result)
+	if err != nil {
+		return result, err
+	}
+	if err := database.NewLockoutStateRepository(db.Connection()).Delete(ctx, username); err != nil {
+		return result, fmt.Errorf("failed to clear lockout state: %w", err)
+	}
+	if result.revokedKeys, err = revokeAdminAPIKeys(ctx, database.NewAPIKeyRepository(db.Connection()), admin.ID); err != nil {
+		return result, err
+	}
+
+	operator := "unknown"
+	if current, err := user.Current(); err == nil {
+		operator = current.Username
+	}
+
+	now := time.Now()
+	entry := &models.AuditLog{
+		Timestamp:   now,
+		EventType:   "system_event",
+		TargetType:  models.TargetTypeAccount,
+		TargetValue: username,
+		Action:      models.ActionTypeAdminReset,
+		CreatedAt:   now,
+	}
+	if err := entry.SetDetailsMap(map[string]interface{}{
+		"severity": "warning",
+		"details": map[string]interface{}{
+			"username":           username,
+			"operator":           operator,
+			"two_factor_removed": result.disabledTwoFactor,
+			"api_keys_revoked":   result.revokedKeys,
+		},
+	}); err != nil {
+		return result, err
+	}
+	return result, database.NewAuditLogRepository(db.Connection()).Create(ctx, entry)
+}
+
+// resetStoredAdmin sets the password of a stored user, unlocking and
+// reactivating it as an admin without two-factor authentication, or creates
+// the admin when it is not stored
+func resetStoredAdmin(ctx context.Context, users models.UserRepository, username, passwordHash string, result *adminResetResult) (*models.User, error) {
+	stored, err := users.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for i := range stored {
+		user := &stored[i]
+		if user.Username != username {
+			continue
+		}
+		// Whoever took over the account may have enrolled their own device
+		result.disabledTwoFactor = user.TwoFactorEnabled || user.TOTPSecret != ""
+		user.PasswordHash = passwordHash
+		user.PasswordChangedAt = now
+		user.FailedAttempts = 0
+		user.LockedUntil = nil
+		user.IsActive = true
+		user.IsAdmin = true
+		user.TwoFactorEnabled = false
+		user.TOTPSecret = ""
+		user.LastTOTPStep = 0
+		return user, users.Update(ctx, user)
+	}
+
+	admin := &models.User{
+		Username:     username,
+		PasswordHash: passwordHash,
+		IsActive:     true,
+		IsAdmin:      true,
+	}
+	return admin, users.Create(ctx, admin)
+}
+
+// revokeAdminAPIKeys revokes every unrevoked API key of the user and returns
+// how many it revoked. Keys are looked up on each request, so the running
+// service stops accepting them right away.
+func revokeAdminAPIKeys(ctx context.Context, keys models.APIKeyRepository, userID int) (int, error) {
+	stored, err := keys.GetAll(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	revoked := 0
+	now := time.Now()
+	for i := range stored {
+		key := &stored[i]
+		if key.UserID != userID || key.RevokedAt != nil {
+			continue
+		}
+		key.RevokedAt = &now
+		if err := keys.Update(ctx, key); err != nil {
+			return revoked, fmt.Errorf("failed to revoke API key %s: %w", key.Prefix, err)
+		}
+		revoked++
+	}
+	return revoked, nil
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"parental-control/internal/config"
+)
+
+// runValidate handles the "validate" subcommand. It loads the configuration
+// file with environment overrides applied, the same way the service does, and
+// reports every validation problem.
+func runValidate(args []string) int {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	configPath := fs.String("config", "", "Path to configuration file (default: $PC_CONFIG_FILE, then the search path)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	appConfig, path, err := config.Resolve(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", displayPath(path, *configPath), err)
+		return 1
+	}
+
+	if err := appConfig.Validate(); err != nil {
+		var validation *config.ValidationError
+		if !errors.As(err, &validation) {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+			return 1
+		}
+		fmt.Fprintf(os.Stderr, "%s: validation failed:\n", path)
+		for _, problem := range validation.Problems {
+			fmt.Fprintf(os.Stderr, "  - %s\n", problem)
+		}
+		return 1
+	}
+
+	fmt.Printf("%s: OK\n", path)
+	return 0
+}
+
+// runConfig handles the "config" subcommand
+func runConfig(args []string) int {
+	if len(args) == 0 || args[0] != "dump" {
+		fmt.Fprintln(os.Stderr, "Usage: parental-control config dump [options]")
+		return 2
+	}
+
+	fs := flag.NewFlagSet("config dump", flag.ContinueOnError)
+	var (
+		configPath  = fs.String("config", "", "Path to configuration file (default: $PC_CONFIG_FILE, then the search path)")
+		showSecrets = fs.Bool("show-secrets", false, "Print passwords, tokens and other secrets instead of redacting them")
+	)
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	// Like the service, fall back to the built-in defaults when the file
+	// cannot be used, but say so since that is rarely what was intended
+	appConfig, path, err := config.Resolve(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %s: %v\n", displayPath(path, *configPath), err)
+		fmt.Fprintln(os.Stderr, "Warning: the service would start with the built-in defaults shown below")
+		appConfig = config.Default()
+	} else if err := appConfig.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %s: %v\n", path, err)
+		fmt.Fprintln(os.Stderr, "Warning: the service would reject this configuration and start with the built-in defaults")
+	}
+
+	if !*showSecrets {
+		appConfig = appConfig.Redacted()
+	}
+	if err := appConfig.WriteYAML(os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write configuration: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// displayPath names the configuration file in messages when the search
+// path did not resolve to one
+func displayPath(resolved, requested string) string {
+	switch {
+	case resolved != "":
+		return resolved
+	case requested != "":
+		return requested
+	default:
+		return "configuration"
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"parental-control/internal/config"
+	"parental-control/internal/diagnostics"
+)
+
+// runDiagnostics handles the "diagnostics" subcommand
+func runDiagnostics(args []string) int {
+	if len(args) == 0 || args[0] != "bundle" {
+		fmt.Fprintln(os.Stderr, "Usage: parental-control diagnostics bundle [options]")
+		return 2
+	}
+
+	fs := flag.NewFlagSet("diagnostics bundle", flag.ContinueOnError)
+	var (
+		configPath = fs.String("config", "", "Path to configuration file")
+		output     = fs.String("o", "diagnostics.zip", "Path of the archive to write")
+		scrub      = fs.String("scrub", string(diagnostics.ScrubHash), "Scrub level for domains, IPs and usernames: none, hash or redact")
+		logLines   = fs.Int("log-lines", diagnostics.DefaultLogLines, "Number of recent log lines to include")
+		perfURL    = fs.String("performance-url", "", "URL of a running instance's performance report to include")
+	)
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	level, err := diagnostics.ParseScrubLevel(*scrub)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	appConfig, err := config.LoadFromFile(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not load configuration, using defaults: %v\n", err)
+		appConfig = config.Default()
+	}
+
+	f, err := os.OpenFile(*output, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create %s: %v\n", *output, err)
+		return 1
+	}
+
+	manifest, err := diagnostics.WriteBundle(context.Background(), f, diagnostics.BundleOptions{
+		Config:         appConfig,
+		Version:        Version,
+		ScrubLevel:     level,
+		LogLines:       *logLines,
+		PerformanceURL: *perfURL,
+	})
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(*output)
+		fmt.Fprintf(os.Stderr, "Failed to write diagnostics bundle: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("Diagnostics bundle written to %s (scrub level: %s)\n", *output, manifest.ScrubLevel)
+	for _, note := range manifest.Notes {
+		fmt.Printf("  note: %s\n", note)
+	}
+	fmt.Println("Please review the archive before sharing it.")
+	return 0
+}
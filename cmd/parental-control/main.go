@@ -20,9 +20,32 @@ var (
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "diagnostics":
+			os.Exit(runDiagnostics(os.Args[2:]))
+		case "admin-reset":
+			os.Exit(runAdminReset(os.Args[2:]))
+		case "install-service":
+			os.Exit(runInstallService(os.Args[2:]))
+		case "uninstall-service":
+			os.Exit(runUninstallService(os.Args[2:]))
+		case "status":
+			os.Exit(runStatus(os.Args[2:]))
+		case "validate":
+			os.Exit(runValidate(os.Args[2:]))
+		case "config":
+			os.Exit(runConfig(os.Args[2:]))
+		case "migrate":
+			os.Exit(runMigrate(os.Args[2:]))
+		case "rules":
+			os.Exit(runRules(os.Args[2:]))
+		}
+	}
+
 	var (
 		showVersion = flag.Bool("version", false, "Show version information")
-		configPath  = flag.String("config", "", "Path to configuration file")
+		configPath  = flag.String("config", "", "Path to configuration file (default: $PC_CONFIG_FILE, then the search path)")
 		noElevate   = flag.Bool("no-elevate", false, "Skip privilege elevation (for testing)")
 	)
 	flag.Parse()
@@ -35,6 +58,13 @@ func main() {
 		os.Exit(0)
 	}
 
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Windows services are stopped by the service control manager, not a signal
+	ctx, serviceDone := runUnderServiceManager(ctx)
+	defer serviceDone()
+
 	// Initialize application using startup orchestrator
 	startup := app.NewStartupOrchestrator(app.StartupConfig{
 		ConfigPath:    *configPath,
@@ -47,9 +77,6 @@ func main() {
 		logging.Fatal("Failed to initialize application", logging.Err(err))
 	}
 
-	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
-	defer stop()
-
 	if err := application.Start(ctx); err != nil {
 		logging.Fatal("Failed to start application", logging.Err(err))
 	}
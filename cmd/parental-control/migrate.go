@@ -0,0 +1,125 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"parental-control/internal/config"
+	"parental-control/internal/database"
+)
+
+// runMigrate handles the "migrate" subcommand. "up" (the default) applies
+// pending schema migrations, "down" rolls back the newest ones before
+// installing an older release, and "status" lists what is pending. The
+// service applies pending migrations itself when it starts.
+func runMigrate(args []string) int {
+	action := "up"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		action, args = args[0], args[1:]
+	}
+	if action != "up" && action != "down" && action != "status" {
+		fmt.Fprintln(os.Stderr, "Usage: parental-control migrate [up|down|status] [options]")
+		return 2
+	}
+
+	fs := flag.NewFlagSet("migrate "+action, flag.ContinueOnError)
+	var (
+		configPath = fs.String("config", "", "Path to configuration file")
+		steps      = fs.Int("steps", 0, "Number of migrations to apply or roll back (up: default all, down: default 1)")
+		dryRun     = fs.Bool("dry-run", false, "Print the migrations that would run without changing the database")
+	)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *steps < 0 {
+		fmt.Fprintln(os.Stderr, "--steps cannot be negative")
+		return 2
+	}
+	if action == "down" && *steps == 0 {
+		*steps = 1
+	}
+
+	// Migrating the defaults' database by mistake would be worse than failing
+	appConfig, _, err := config.Load(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
+		return 1
+	}
+
+	dbPath := appConfig.Database.Path
+	if _, err := os.Stat(dbPath); errors.Is(err, os.ErrNotExist) && (action != "up" || *dryRun) {
+		fmt.Printf("No database at %s; the service creates it with the full schema on first start\n", dbPath)
+		return 0
+	}
+
+	writes := action != "status" && !*dryRun
+	if writes {
+		if pid, err := readPIDFile(appConfig.Service.PIDFile); err == nil && processAlive(pid) {
+			fmt.Fprintf(os.Stderr, "The service is running (PID %d); stop it before migrating the database\n", pid)
+			return 1
+		}
+	}
+
+	db, err := database.New(appConfig.GetDatabaseConfig())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to open database: %v\n", err)
+		return 1
+	}
+	defer db.Close()
+
+	status, err := db.MigrationStatus()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read schema version: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Database %s is at schema version %d; this release supports version %d\n", dbPath, status.Current, status.Latest)
+
+	switch {
+	case action == "status":
+		printMigrations("Pending migrations:", status.Pending)
+		return 0
+	case action == "up" && !writes:
+		printMigrations("Would apply:", status.UpPlan(*steps))
+		return 0
+	case action == "down" && !writes:
+		printMigrations("Would roll back:", status.DownPlan(*steps))
+		return 0
+	}
+
+	var done []database.Migration
+	verb := "Applied"
+	if action == "up" {
+		done, err = db.MigrateUp(*steps)
+	} else {
+		verb = "Rolled back"
+		done, err = db.MigrateDown(*steps)
+	}
+
+	if len(done) > 0 || err == nil {
+		printMigrations(verb+":", done)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Migration failed: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func printMigrations(heading string, migrations []database.Migration) {
+	if len(migrations) == 0 {
+		fmt.Println(heading, "none")
+		return
+	}
+
+	fmt.Println(heading)
+	for _, migration := range migrations {
+		note := ""
+		if !migration.Reversible() {
+			note = " (cannot be rolled back)"
+		}
+		fmt.Printf("  %s%s\n", migration, note)
+	}
+}
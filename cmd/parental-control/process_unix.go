@@ -0,0 +1,15 @@
+//go:build !windows
+
+package main
+
+import (
+	"errors"
+	"syscall"
+)
+
+// processAlive reports whether a process with the given ID exists
+func processAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	// EPERM means it exists but belongs to another user, e.g. root
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
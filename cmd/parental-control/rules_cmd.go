@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"parental-control/internal/config"
+	"parental-control/internal/database"
+	"parental-control/internal/logging"
+	"parental-control/internal/models"
+	"parental-control/internal/service"
+)
+
+// runRules handles the "rules" subcommand, which exports the lists, entries,
+// time rules and quota rules to a rule bundle file or imports one, like the
+// /api/v1/config/rules endpoints
+func runRules(args []string) int {
+	if len(args) == 0 || (args[0] != "export" && args[0] != "import") {
+		fmt.Fprintln(os.Stderr, "Usage: parental-control rules export [--output FILE] [options]")
+		fmt.Fprintln(os.Stderr, "       parental-control rules import [--mode merge|replace] [options] FILE")
+		return 2
+	}
+	action := args[0]
+
+	fs := flag.NewFlagSet("rules "+action, flag.ContinueOnError)
+	var (
+		configPath = fs.String("config", "", "Path to configuration file")
+		output     = fs.String("output", "", "File to write the bundle to (export; default: standard output)")
+		modeFlag   = fs.String("mode", "merge", "Import mode: merge adds to the existing rules, replace deletes them first")
+	)
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	mode, err := service.ParseRuleImportMode(*modeFlag)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if action == "import" && fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: parental-control rules import [--mode merge|replace] [options] FILE (- for standard input)")
+		return 2
+	}
+
+	appConfig, _, err := config.Load(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
+		return 1
+	}
+
+	// The running service would keep enforcing the rules it has loaded
+	if action == "import" {
+		if pid, err := readPIDFile(appConfig.Service.PIDFile); err == nil && processAlive(pid) {
+			fmt.Fprintf(os.Stderr, "The service is running (PID %d); stop it or use POST /api/v1/config/rules/import\n", pid)
+			return 1
+		}
+	}
+
+	if _, err := os.Stat(appConfig.Database.Path); errors.Is(err, os.ErrNotExist) {
+		fmt.Fprintf(os.Stderr, "No database at %s\n", appConfig.Database.Path)
+		return 1
+	}
+	db, err := database.New(appConfig.GetDatabaseConfig())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to open database: %v\n", err)
+		return 1
+	}
+	defer db.Close()
+
+	status, err := db.MigrationStatus()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read schema version: %v\n", err)
+		return 1
+	}
+	if status.Current != status.Latest {
+		fmt.Fprintf(os.Stderr, "Database %s is at schema version %d but this release uses %d; run 'parental-control migrate' first\n",
+			appConfig.Database.Path, status.Current, status.Latest)
+		return 1
+	}
+
+	repos := &models.RepositoryManager{
+		List:      database.NewListRepository(db.Connection()),
+		ListEntry: database.NewListEntryRepository(db.Connection()),
+		TimeRule:  database.NewTimeRuleRepository(db.Connection()),
+		QuotaRule: database.NewQuotaRuleRepository(db.Connection()),
+	}
+	bundles := service.NewRuleBundleService(repos, logging.NewDefault())
+
+	if action == "export" {
+		return exportRules(bundles, *output)
+	}
+
+	backups := service.NewDatabaseBackupService(db, logging.NewDefault(), appConfig.ToBackupConfig())
+	return importRules(bundles, backups, fs.Arg(0), mode)
+}
+
+func exportRules(bundles *service.RuleBundleService, output string) int {
+	bundle, err := bundles.Export(context.Background())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Export failed: %v\n", err)
+		return 1
+	}
+
+	var w io.Writer = os.Stdout
+	if output != "" {
+		// Notes and patterns can be personal, like the database itself
+		file, err := os.OpenFile(output, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to create %s: %v\n", output, err)
+			return 1
+		}
+		defer file.Close()
+		w = file
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(bundle); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write bundle: %v\n", err)
+		return 1
+	}
+
+	if output != "" {
+		entries := 0
+		for _, list := range bundle.Lists {
+			entries += len(list.Entries)
+		}
+		fmt.Printf("Exported %d lists and %d entries to %s\n", len(bundle.Lists), entries, output)
+	}
+	return 0
+}
+
+func importRules(bundles *service.RuleBundleService, backups *service.DatabaseBackupService, path string, mode service.RuleImportMode) int {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to open %s: %v\n", path, err)
+			return 1
+		}
+		defer file.Close()
+		r = file
+	}
+
+	bundle, err := service.ReadRuleBundle(r)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	ctx := context.Background()
+	if mode == service.RuleImportReplace {
+		if backup, err := backups.Backup(ctx, service.BackupReasonImport); err == nil {
+			fmt.Printf("Backed up the database to %s\n", backup.Path)
+		} else if !errors.Is(err, service.ErrBackupsDisabled) {
+			fmt.Fprintf(os.Stderr, "Database backup before replacing the rules failed: %v\n", err)
+			return 1
+		}
+	}
+
+	result, err := bundles.Import(ctx, bundle, mode)
+	var bundleErr *service.RuleBundleError
+	if errors.As(err, &bundleErr) {
+		fmt.Fprintln(os.Stderr, "The rule bundle is invalid; nothing was imported:")
+		for _, problem := range bundleErr.Problems {
+			fmt.Fprintf(os.Stderr, "  - %s\n", problem)
+		}
+		return 1
+	}
+
+	if result != nil {
+		fmt.Printf("Lists: %d created, %d merged, %d deleted\n", result.ListsCreated, result.ListsMerged, result.ListsDeleted)
+		fmt.Printf("Entries: %d created, %d already present\n", result.EntriesCreated, result.EntriesSkipped)
+		fmt.Printf("Time rules: %d created; quota rules: %d created\n", result.TimeRulesCreated, result.QuotaRulesCreated)
+		for _, skip := range result.Skipped {
+			name := skip.List
+			if skip.Name != "" {
+				name += "/" + skip.Name
+			}
+			fmt.Printf("Skipped %s %s: %s\n", skip.RuleType, name, skip.Reason)
+		}
+		if result.Validation != nil {
+			for _, conflict := range result.Validation.Conflicts {
+				fmt.Printf("Conflict (%s): %s - %s\n", conflict.Severity, conflict.Title, conflict.Description)
+			}
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Import failed part way through: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"parental-control/internal/config"
+	"parental-control/internal/privilege"
+)
+
+// defaultServiceName names the installed systemd unit or Windows service
+const defaultServiceName = "parental-control"
+
+// serviceSpec describes the service to install
+type serviceSpec struct {
+	Name       string
+	Executable string
+	ConfigPath string
+}
+
+// runInstallService handles the "install-service" subcommand. It registers
+// this binary to start at boot with the system service manager: a systemd
+// unit on Linux, a service in the service control manager on Windows.
+func runInstallService(args []string) int {
+	fs := flag.NewFlagSet("install-service", flag.ContinueOnError)
+	var (
+		configPath = fs.String("config", "", "Path to configuration file the service runs with")
+		name       = fs.String("name", defaultServiceName, "Service name")
+	)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if !privilege.IsElevated() {
+		fmt.Fprintln(os.Stderr, "install-service must be run as root or Administrator")
+		return 1
+	}
+
+	// The service starts unattended, so its configuration has to load now
+	_, path, err := config.Load(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
+		fmt.Fprintln(os.Stderr, "Pass the file the service should use with --config")
+		return 1
+	}
+
+	spec, err := newServiceSpec(*name, path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	location, err := installService(spec)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to install service: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("Installed service %s: %s\n", spec.Name, location)
+	fmt.Println("Next steps:")
+	for _, step := range serviceNextSteps(spec) {
+		fmt.Printf("  %s\n", step)
+	}
+	return 0
+}
+
+// runUninstallService handles the "uninstall-service" subcommand
+func runUninstallService(args []string) int {
+	fs := flag.NewFlagSet("uninstall-service", flag.ContinueOnError)
+	name := fs.String("name", defaultServiceName, "Service name")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if !privilege.IsElevated() {
+		fmt.Fprintln(os.Stderr, "uninstall-service must be run as root or Administrator")
+		return 1
+	}
+
+	location, err := uninstallService(*name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to uninstall service: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("Stopped and removed service %s: %s\n", *name, location)
+	return 0
+}
+
+// newServiceSpec resolves the absolute paths the service runs with
+func newServiceSpec(name, configPath string) (serviceSpec, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return serviceSpec{}, fmt.Errorf("failed to get executable path: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(executable); err == nil {
+		executable = resolved
+	}
+
+	configPath, err = filepath.Abs(configPath)
+	if err != nil {
+		return serviceSpec{}, fmt.Errorf("failed to resolve configuration path: %w", err)
+	}
+
+	return serviceSpec{Name: name, Executable: executable, ConfigPath: configPath}, nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// systemdUnitDir holds locally installed system units
+var systemdUnitDir = "/etc/systemd/system"
+
+// systemdUnit renders the unit file for a service
+func systemdUnit(spec serviceSpec) string {
+	return fmt.Sprintf(`[Unit]
+Description=Parental Control Service
+After=network-online.target
+Wants=network-online.target
+
+[Service]
+Type=simple
+ExecStart=%s -config %s
+Restart=always
+RestartSec=5
+
+[Install]
+WantedBy=multi-user.target
+`, systemdQuote(spec.Executable), systemdQuote(spec.ConfigPath))
+}
+
+// systemdQuote quotes a path for ExecStart, escaping % so systemd does not
+// read it as a specifier
+func systemdQuote(path string) string {
+	return strings.ReplaceAll(strconv.Quote(path), "%", "%%")
+}
+
+func systemdUnitPath(name string) string {
+	return filepath.Join(systemdUnitDir, name+".service")
+}
+
+// installService writes a systemd unit and reloads systemd
+func installService(spec serviceSpec) (string, error) {
+	path := systemdUnitPath(spec.Name)
+	if _, err := os.Stat(path); err == nil {
+		return "", fmt.Errorf("%s already exists; run uninstall-service first", path)
+	}
+
+	if err := os.WriteFile(path, []byte(systemdUnit(spec)), 0644); err != nil {
+		return "", err
+	}
+
+	if err := systemctl("daemon-reload"); err != nil {
+		os.Remove(path)
+		return "", err
+	}
+	return path, nil
+}
+
+// uninstallService stops and disables the unit, then removes it
+func uninstallService(name string) (string, error) {
+	path := systemdUnitPath(name)
+	if _, err := os.Stat(path); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("%s does not exist", path)
+		}
+		return "", err
+	}
+
+	// Stopping fails harmlessly if the unit was never started
+	systemctl("disable", "--now", name+".service")
+
+	if err := os.Remove(path); err != nil {
+		return "", err
+	}
+	// Without a reload systemd still forgets the unit on its next one
+	systemctl("daemon-reload")
+	return path, nil
+}
+
+func serviceNextSteps(spec serviceSpec) []string {
+	return []string{
+		fmt.Sprintf("sudo systemctl enable --now %s.service", spec.Name),
+		fmt.Sprintf("systemctl status %s.service", spec.Name),
+		fmt.Sprintf("journalctl -u %s.service -f", spec.Name),
+	}
+}
+
+func systemctl(args ...string) error {
+	out, err := exec.Command("systemctl", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("systemctl %v failed: %w: %s", args, err, out)
+	}
+	return nil
+}
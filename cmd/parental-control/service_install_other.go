@@ -0,0 +1,19 @@
+//go:build !linux && !windows
+
+package main
+
+import "errors"
+
+var errServiceUnsupported = errors.New("service installation is only supported on Linux (systemd) and Windows")
+
+func installService(spec serviceSpec) (string, error) {
+	return "", errServiceUnsupported
+}
+
+func uninstallService(name string) (string, error) {
+	return "", errServiceUnsupported
+}
+
+func serviceNextSteps(spec serviceSpec) []string {
+	return nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// installService registers the service with the service control manager,
+// starting automatically and restarting after failures
+func installService(spec serviceSpec) (string, error) {
+	m, err := mgr.Connect()
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to the service control manager: %w", err)
+	}
+	defer m.Disconnect()
+
+	if s, err := m.OpenService(spec.Name); err == nil {
+		s.Close()
+		return "", fmt.Errorf("service %s already exists; run uninstall-service first", spec.Name)
+	}
+
+	s, err := m.CreateService(spec.Name, spec.Executable, mgr.Config{
+		StartType:   mgr.StartAutomatic,
+		DisplayName: "Parental Control Service",
+		Description: "Enforces parental control rules for applications and websites",
+	}, "-config", spec.ConfigPath)
+	if err != nil {
+		return "", err
+	}
+	defer s.Close()
+
+	restart := mgr.RecoveryAction{Type: mgr.ServiceRestart, Delay: 5 * time.Second}
+	if err := s.SetRecoveryActions([]mgr.RecoveryAction{restart, restart, restart}, uint32((24 * time.Hour).Seconds())); err != nil {
+		return "", fmt.Errorf("service created but setting restart policy failed: %w", err)
+	}
+
+	return serviceLocation(spec.Name), nil
+}
+
+// uninstallService stops the service if it runs and deletes it
+func uninstallService(name string) (string, error) {
+	m, err := mgr.Connect()
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to the service control manager: %w", err)
+	}
+	defer m.Disconnect()
+
+	s, err := m.OpenService(name)
+	if err != nil {
+		return "", fmt.Errorf("service %s is not installed", name)
+	}
+	defer s.Close()
+
+	// Stopping fails harmlessly if the service is not running
+	s.Control(svc.Stop)
+
+	if err := s.Delete(); err != nil {
+		return "", err
+	}
+	return serviceLocation(name), nil
+}
+
+func serviceLocation(name string) string {
+	return `HKLM\SYSTEM\CurrentControlSet\Services\` + name
+}
+
+func serviceNextSteps(spec serviceSpec) []string {
+	return []string{
+		fmt.Sprintf("sc.exe start %s", spec.Name),
+		fmt.Sprintf("sc.exe query %s", spec.Name),
+	}
+}
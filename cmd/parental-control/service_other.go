@@ -0,0 +1,11 @@
+//go:build !windows
+
+package main
+
+import "context"
+
+// runUnderServiceManager is a no-op outside Windows, where service managers
+// stop the process with a signal
+func runUnderServiceManager(parent context.Context) (context.Context, func()) {
+	return parent, func() {}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+
+	"golang.org/x/sys/windows/svc"
+)
+
+// runUnderServiceManager reports to the service control manager when the
+// process was started as a Windows service. The returned context is cancelled
+// when the service is asked to stop; call done once shutdown has finished.
+func runUnderServiceManager(parent context.Context) (ctx context.Context, done func()) {
+	isService, err := svc.IsWindowsService()
+	if err != nil || !isService {
+		return parent, func() {}
+	}
+
+	ctx, cancel := context.WithCancel(parent)
+	handler := &serviceHandler{stop: cancel, finished: make(chan struct{})}
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		// The name is ignored for services that run in their own process
+		svc.Run(defaultServiceName, handler)
+		cancel()
+	}()
+
+	return ctx, func() {
+		close(handler.finished)
+		<-exited
+	}
+}
+
+// serviceHandler answers service control requests
+type serviceHandler struct {
+	stop     context.CancelFunc
+	finished chan struct{}
+}
+
+func (h *serviceHandler) Execute(args []string, requests <-chan svc.ChangeRequest, status chan<- svc.Status) (bool, uint32) {
+	status <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+
+	for {
+		select {
+		case <-h.finished:
+			return false, 0
+		case req := <-requests:
+			switch req.Cmd {
+			case svc.Interrogate:
+				status <- req.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				status <- svc.Status{State: svc.StopPending}
+				h.stop()
+				<-h.finished
+				return false, 0
+			}
+		}
+	}
+}
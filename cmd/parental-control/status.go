@@ -0,0 +1,298 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"parental-control/internal/config"
+	"parental-control/internal/models"
+	"parental-control/internal/service"
+)
+
+// statusNotRunning is the exit code when the service is not running, as
+// init scripts use for "program is not running"
+const statusNotRunning = 3
+
+// statusSummary is what the status subcommand reports
+type statusSummary struct {
+	Running        bool                   `json:"running"`
+	PID            int                    `json:"pid,omitempty"`
+	API            string                 `json:"api"`
+	Uptime         string                 `json:"uptime,omitempty"`
+	Enforcement    *enforcementSummary    `json:"enforcement,omitempty"`
+	Rules          *models.DashboardStats `json:"rules,omitempty"`
+	ActiveSessions *int                   `json:"active_sessions,omitempty"`
+	RecentBlocks   []blockedItem          `json:"recent_blocks"`
+	// Unavailable lists sections the API did not return, e.g. without an API key
+	Unavailable []string `json:"unavailable,omitempty"`
+	Error       string   `json:"error,omitempty"`
+}
+
+type enforcementSummary struct {
+	Running          bool     `json:"running"`
+	Healthy          bool     `json:"healthy"`
+	Problems         []string `json:"problems,omitempty"`
+	NetworkFiltering bool     `json:"network_filtering"`
+	EmergencyMode    bool     `json:"emergency_mode"`
+	LoadedRules      int      `json:"loaded_rules"`
+}
+
+type blockedItem struct {
+	Time   time.Time `json:"time"`
+	Type   string    `json:"type"`
+	Target string    `json:"target"`
+}
+
+// runStatus handles the "status" subcommand. It checks the PID file, then
+// asks the running instance's API for a summary.
+func runStatus(args []string) int {
+	fs := flag.NewFlagSet("status", flag.ContinueOnError)
+	var (
+		configPath = fs.String("config", "", "Path to configuration file")
+		asJSON     = fs.Bool("json", false, "Print the summary as JSON")
+		apiKey     = fs.String("api-key", os.Getenv("PC_API_KEY"), "Admin API key when authentication is enabled (default: $PC_API_KEY)")
+		blocks     = fs.Int("blocks", 5, "Number of recent blocks to show")
+		timeout    = fs.Duration("timeout", 5*time.Second, "Time to wait for the API")
+	)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	appConfig, _, err := config.Load(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not load configuration, using defaults: %v\n", err)
+		appConfig = config.Default()
+	}
+
+	client := newStatusClient(appConfig.Web, *apiKey, *timeout)
+	summary := statusSummary{API: client.baseURL, RecentBlocks: []blockedItem{}}
+
+	code := collectStatus(&summary, client, appConfig.Service.PIDFile, *blocks)
+	if *asJSON {
+		json.NewEncoder(os.Stdout).Encode(summary)
+	} else {
+		printStatus(os.Stdout, summary)
+	}
+	return code
+}
+
+// collectStatus fills in the summary and returns the exit code
+func collectStatus(summary *statusSummary, client *statusClient, pidFile string, blocks int) int {
+	pid, err := readPIDFile(pidFile)
+	if err != nil {
+		summary.Error = fmt.Sprintf("service is not running: %v", err)
+		return statusNotRunning
+	}
+	if !processAlive(pid) {
+		summary.Error = fmt.Sprintf("service is not running: process %d from %s has exited", pid, pidFile)
+		return statusNotRunning
+	}
+	summary.PID = pid
+
+	var health struct {
+		Uptime string `json:"uptime"`
+	}
+	if err := client.get("/health", &health); err != nil {
+		summary.Error = fmt.Sprintf("process %d is running but its API is not reachable: %v", pid, err)
+		return 1
+	}
+	summary.Running = true
+	if uptime, err := time.ParseDuration(health.Uptime); err == nil {
+		summary.Uptime = uptime.Round(time.Second).String()
+	}
+
+	var enforcement service.EnforcementStatus
+	err = client.get("/api/v1/enforcement/status?actions="+strconv.Itoa(maxStatusActions(blocks)), &enforcement)
+	switch {
+	case errors.Is(err, errNotFound):
+		// The enforcement API is only registered when enforcement is enabled
+		summary.Enforcement = &enforcementSummary{Problems: []string{"enforcement is disabled"}}
+	case err != nil:
+		summary.Unavailable = append(summary.Unavailable, "enforcement: "+err.Error())
+	default:
+		summary.Enforcement = &enforcementSummary{
+			Running:          enforcement.Running,
+			Healthy:          enforcement.Healthy,
+			Problems:         enforcement.Problems,
+			NetworkFiltering: enforcement.NetworkFiltering,
+			EmergencyMode:    enforcement.EmergencyMode,
+			LoadedRules:      enforcement.RuleCount,
+		}
+		for _, action := range enforcement.RecentActions {
+			if action.Action != models.ActionTypeBlock || len(summary.RecentBlocks) >= blocks {
+				continue
+			}
+			summary.RecentBlocks = append(summary.RecentBlocks, blockedItem{
+				Time:   action.Timestamp,
+				Type:   string(action.TargetType),
+				Target: action.TargetValue,
+			})
+		}
+	}
+
+	var rules models.DashboardStats
+	if err := client.get("/api/v1/dashboard/stats", &rules); err != nil {
+		summary.Unavailable = append(summary.Unavailable, "rules: "+err.Error())
+	} else {
+		summary.Rules = &rules
+	}
+
+	var security struct {
+		ActiveSessions int `json:"active_sessions"`
+	}
+	if err := client.get("/api/v1/auth/security/stats", &security); err == nil {
+		summary.ActiveSessions = &security.ActiveSessions
+	} else if !errors.Is(err, errNotFound) {
+		summary.Unavailable = append(summary.Unavailable, "sessions: "+err.Error())
+	}
+
+	return 0
+}
+
+// maxStatusActions asks for more actions than blocks since allows are mixed in
+func maxStatusActions(blocks int) int {
+	return min(max(blocks*4, 20), 100)
+}
+
+func printStatus(w io.Writer, summary statusSummary) {
+	if summary.Error != "" {
+		fmt.Fprintf(w, "Parental Control: %s\n", summary.Error)
+		return
+	}
+
+	fmt.Fprintf(w, "Parental Control is running (PID %d, up %s)\n", summary.PID, summary.Uptime)
+	fmt.Fprintf(w, "  API:             %s\n", summary.API)
+
+	if e := summary.Enforcement; e != nil {
+		state := "inactive"
+		if e.Running {
+			state = "active"
+		}
+		if e.NetworkFiltering {
+			state += ", network filtering on"
+		}
+		if e.EmergencyMode {
+			state += ", EMERGENCY MODE"
+		}
+		fmt.Fprintf(w, "  Enforcement:     %s (%d rules loaded)\n", state, e.LoadedRules)
+		for _, problem := range e.Problems {
+			fmt.Fprintf(w, "    problem: %s\n", problem)
+		}
+	}
+	if r := summary.Rules; r != nil {
+		fmt.Fprintf(w, "  Rules:           %d lists enabled of %d, %d entries\n", r.ActiveRules, r.TotalLists, r.TotalEntries)
+		fmt.Fprintf(w, "  Today:           %d blocked, %d allowed\n", r.TodayBlocks, r.TodayAllows)
+	}
+	if summary.ActiveSessions != nil {
+		fmt.Fprintf(w, "  Active sessions: %d\n", *summary.ActiveSessions)
+	}
+
+	if len(summary.RecentBlocks) > 0 {
+		fmt.Fprintln(w, "  Recent blocks:")
+		for _, block := range summary.RecentBlocks {
+			fmt.Fprintf(w, "    %s  %-10s  %s\n", block.Time.Local().Format("2006-01-02 15:04:05"), block.Type, block.Target)
+		}
+	}
+	for _, section := range summary.Unavailable {
+		fmt.Fprintf(w, "  (unavailable) %s\n", section)
+	}
+}
+
+// readPIDFile returns the process ID recorded by the running service
+func readPIDFile(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, fmt.Errorf("no PID file at %s", path)
+		}
+		return 0, err
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 {
+		return 0, fmt.Errorf("invalid PID file %s", path)
+	}
+	return pid, nil
+}
+
+var errNotFound = errors.New("not found")
+
+// statusClient calls the local instance's API
+type statusClient struct {
+	http    *http.Client
+	baseURL string
+	apiKey  string
+}
+
+// newStatusClient connects to the web interface as configured: its Unix
+// socket, or the HTTPS or HTTP port on the local machine
+func newStatusClient(web config.WebConfig, apiKey string, timeout time.Duration) *statusClient {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	client := &statusClient{http: &http.Client{Timeout: timeout, Transport: transport}, apiKey: apiKey}
+
+	if socket := web.UnixSocket(); socket != "" {
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+		}
+		client.baseURL = "http://unix"
+		return client
+	}
+
+	host := web.Host
+	switch host {
+	case "", "0.0.0.0", "::":
+		host = "127.0.0.1"
+	}
+
+	if web.TLSEnabled {
+		port := web.HTTPSPort
+		if port == 0 {
+			port = 8443
+		}
+		// Generated certificates are self-signed and name the LAN hostname
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: web.TLSAutoGenerate}
+		client.baseURL = "https://" + net.JoinHostPort(host, strconv.Itoa(port))
+		return client
+	}
+
+	client.baseURL = "http://" + net.JoinHostPort(host, strconv.Itoa(web.Port))
+	return client
+}
+
+// get fetches a JSON endpoint into out
+func (c *statusClient) get(path string, out interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("not authorized (pass --api-key or set PC_API_KEY)")
+	case resp.StatusCode == http.StatusNotFound:
+		return errNotFound
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -3,12 +3,18 @@ module parental-control
 go 1.24.4
 
 require (
+	github.com/BurntSushi/toml v1.6.0
 	github.com/mattn/go-sqlite3 v1.14.28
 	github.com/miekg/dns v1.1.66
 	gopkg.in/yaml.v3 v3.0.1
 )
 
-require golang.org/x/crypto v0.39.0
+require (
+	github.com/klauspost/compress v1.18.0
+	golang.org/x/crypto v0.39.0
+	golang.org/x/sys v0.33.0
+	golang.org/x/term v0.32.0
+)
 
 require (
 	git.sr.ht/~jackmordaunt/go-toast v1.1.2 // indirect
@@ -24,6 +30,5 @@ require (
 	golang.org/x/mod v0.24.0 // indirect
 	golang.org/x/net v0.39.0 // indirect
 	golang.org/x/sync v0.13.0 // indirect
-	golang.org/x/sys v0.33.0 // indirect
 	golang.org/x/tools v0.32.0 // indirect
 )
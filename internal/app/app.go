@@ -6,21 +6,32 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"parental-control/internal/auth"
 	"parental-control/internal/config"
 	"parental-control/internal/logging"
+	"parental-control/internal/metrics"
+	"parental-control/internal/models"
 	"parental-control/internal/server"
 	"parental-control/internal/service"
 )
 
 // Config holds the application configuration
 type Config struct {
-	Service  service.Config
-	Web      config.WebConfig
-	Security config.SecurityConfig
+	Service    service.Config
+	Web        config.WebConfig
+	Security   config.SecurityConfig
+	Monitoring config.MonitoringConfig
+
+	// ConfigFile is the configuration file to watch for live changes; empty
+	// disables reloading. FileConfig is the configuration loaded from it.
+	ConfigFile string
+	FileConfig *config.Config
 }
 
 // DefaultConfig returns application configuration with sensible defaults
@@ -38,9 +49,10 @@ func DefaultConfig() Config {
 	
 
 	return Config{
-		Service:  serviceConfig,
-		Web:      defaultConfig.Web,
-		Security: defaultConfig.Security,
+		Service:    serviceConfig,
+		Web:        defaultConfig.Web,
+		Security:   defaultConfig.Security,
+		Monitoring: defaultConfig.Monitoring,
 	}
 }
 
@@ -63,22 +75,109 @@ func convertConfigToServerConfig(webConfig config.WebConfig) server.Config {
 		MinTLSVersion: 0x0303,               // TLS 1.2
 		RedirectHTTP:  webConfig.TLSRedirectHTTP,
 		HTTPPort:      webConfig.Port,
+		HTTPSPort:     webConfig.HTTPSPort,
 	}
 
 	return server.Config{
-		Port:              webConfig.Port,
-		BindToLAN:         false,
-		AllowedInterfaces: []string{},
-		ReadTimeout:       15 * time.Second,
-		WriteTimeout:      15 * time.Second,
-		IdleTimeout:       60 * time.Second,
-		MaxHeaderBytes:    1 << 20, // 1 MB
-		StaticFileRoot:    webConfig.StaticDir,
-		EnableCompression: true,
-		TLS:               tlsConfig,
+		Port:               webConfig.Port,
+		BindToLAN:          false,
+		AllowedInterfaces:  []string{},
+		ReadTimeout:        15 * time.Second,
+		WriteTimeout:       15 * time.Second,
+		IdleTimeout:        60 * time.Second,
+		MaxHeaderBytes:     1 << 20, // 1 MB
+		StaticFileRoot:     webConfig.StaticDir,
+		EnableCompression:  true,
+		TLS:                tlsConfig,
+		MaxBodyBytes:       webConfig.MaxRequestBodyBytes,
+		MaxImportBodyBytes: webConfig.MaxImportBodyBytes,
+		SocketPath:         webConfig.UnixSocket(),
+		SocketMode:         socketMode(webConfig.SocketMode),
 	}
 }
 
+// socketMode parses the configured octal socket mode; validation has already
+// rejected bad values, so anything unparsable falls back to the default
+func socketMode(mode string) os.FileMode {
+	parsed, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil {
+		return server.DefaultSocketMode
+	}
+	return os.FileMode(parsed)
+}
+
+// convertCookieConfig converts security config cookie settings to server format
+func convertCookieConfig(securityConfig config.SecurityConfig) server.CookieConfig {
+	cookieConfig := server.DefaultCookieConfig()
+	cookieConfig.Domain = securityConfig.CookieDomain
+	if securityConfig.CookiePath != "" {
+		cookieConfig.Path = securityConfig.CookiePath
+	}
+	if sameSite, err := server.ParseSameSite(securityConfig.CookieSameSite); err == nil {
+		cookieConfig.SameSite = sameSite
+	} else {
+		logging.Warn("Invalid cookie SameSite setting, using strict", logging.Err(err))
+	}
+	if securityConfig.CookieSecure != "" {
+		cookieConfig.Secure = server.CookieSecureMode(securityConfig.CookieSecure)
+	}
+	cookieConfig.TrustForwardedProto = securityConfig.TrustForwardedProto
+	cookieConfig.TrustedProxies = securityConfig.TrustedProxies
+	return cookieConfig
+}
+
+// convertSecurityHeadersConfig converts security config header settings to server format
+func convertSecurityHeadersConfig(securityConfig config.SecurityConfig) server.SecurityHeadersConfig {
+	headers := server.DefaultSecurityHeadersConfig()
+	headers.HSTSMaxAge = securityConfig.HSTSMaxAge
+	headers.HSTSIncludeSubdomains = securityConfig.HSTSIncludeSubdomains
+	headers.HSTSPreload = securityConfig.HSTSPreload
+	if securityConfig.ContentSecurityPolicy != "" {
+		headers.ContentSecurityPolicy = securityConfig.ContentSecurityPolicy
+	}
+	if securityConfig.FrameOptions != "" {
+		headers.FrameOptions = strings.ToUpper(securityConfig.FrameOptions)
+		if headers.FrameOptions == "OFF" {
+			headers.FrameOptions = ""
+		}
+	}
+	if securityConfig.ReferrerPolicy != "" {
+		headers.ReferrerPolicy = securityConfig.ReferrerPolicy
+	}
+	return headers
+}
+
+// convertCORSConfig converts security config CORS settings to server format
+func convertCORSConfig(securityConfig config.SecurityConfig) server.CORSConfig {
+	cors := server.DefaultCORSConfig()
+	cors.AllowedOrigins = securityConfig.CORSAllowedOrigins
+	if len(securityConfig.CORSAllowedMethods) > 0 {
+		cors.AllowedMethods = securityConfig.CORSAllowedMethods
+	}
+	if len(securityConfig.CORSAllowedHeaders) > 0 {
+		cors.AllowedHeaders = securityConfig.CORSAllowedHeaders
+	}
+	cors.AllowCredentials = securityConfig.CORSAllowCredentials
+	cors.MaxAge = securityConfig.CORSMaxAge
+	return cors
+}
+
+// convertRateLimits converts security config rate limits to server route groups
+func convertRateLimits(securityConfig config.SecurityConfig) []server.RateLimitGroup {
+	groups := server.DefaultRateLimitGroups()
+	for i := range groups {
+		switch groups[i].Name {
+		case "auth":
+			groups[i].Limit = securityConfig.AuthRateLimit
+			groups[i].Window = securityConfig.AuthRateLimitWindow
+		case "api":
+			groups[i].Limit = securityConfig.APIRateLimit
+			groups[i].Window = securityConfig.APIRateLimitWindow
+		}
+	}
+	return groups
+}
+
 // SecurityServiceAdapter adapts auth.SecurityService to implement server.AuthService interface
 type SecurityServiceAdapter struct {
 	securityService *auth.SecurityService
@@ -109,6 +208,35 @@ func (a *SecurityServiceAdapter) GetSession(sessionID string) (server.AuthSessio
 	return session, nil
 }
 
+// IsAPIKey reports whether a bearer token is an API key rather than a session
+func (a *SecurityServiceAdapter) IsAPIKey(token string) bool {
+	return auth.IsAPIKey(token)
+}
+
+// AuthenticateAPIKey resolves an API key to its user and the key's scope
+func (a *SecurityServiceAdapter) AuthenticateAPIKey(key, ipAddress, userAgent string) (server.AuthUser, server.AuthAPIKey, error) {
+	user, apiKey, err := a.securityService.AuthenticateAPIKey(key, ipAddress, userAgent)
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, apiKey, nil
+}
+
+// newAPIServer creates the API server. With a security service, auth is
+// enabled: the returned middleware guards protected routes and the auth
+// handlers serve /api/v1/auth/.
+func newAPIServer(repos models.RepositoryManager, securityService *auth.SecurityService) (*server.APIServer, *server.AuthMiddleware) {
+	apiServer := server.NewAPIServer(repos, securityService != nil)
+	if securityService == nil {
+		return apiServer, nil
+	}
+
+	authMiddleware := server.NewAuthMiddleware(NewSecurityServiceAdapter(securityService))
+	apiServer.SetAuthMiddleware(authMiddleware)
+	apiServer.SetAuthHandlers(auth.NewAuthHandlers(securityService))
+	return apiServer, authMiddleware
+}
+
 // App represents the main application
 type App struct {
 	mu              sync.Mutex
@@ -116,12 +244,43 @@ type App struct {
 	service         *service.Service
 	securityService *auth.SecurityService
 	httpServer      *server.Server
+	configWatcher   *config.Watcher
+
+	// Prometheus endpoint and its performance sampler, when monitoring is enabled
+	metricsServer      *metrics.Server
+	performanceMonitor *service.PerformanceMonitor
 }
 
 // New creates a new application instance
-func New(config Config) *App {
-	return &App{
-		config: config,
+func New(appConfig Config) *App {
+	a := &App{
+		config: appConfig,
+	}
+
+	if appConfig.ConfigFile != "" && appConfig.FileConfig != nil {
+		a.configWatcher = config.NewWatcher(appConfig.ConfigFile, appConfig.FileConfig, config.DefaultWatcherConfig())
+		a.configWatcher.OnChange(a.applyConfigChange)
+	}
+
+	return a
+}
+
+// applyConfigChange pushes reloaded settings into the running services
+func (a *App) applyConfigChange(oldConfig, newConfig *config.Config) {
+	if reflect.DeepEqual(oldConfig.Notifications, newConfig.Notifications) {
+		return
+	}
+
+	a.mu.Lock()
+	svc := a.service
+	a.mu.Unlock()
+	if svc == nil {
+		return
+	}
+
+	if notificationService := svc.GetNotificationService(); notificationService != nil {
+		notificationConfig := newConfig.Notifications.ToServiceNotificationConfig()
+		notificationService.UpdateConfig(&notificationConfig)
 	}
 }
 
@@ -137,9 +296,14 @@ func (a *App) Start(ctx context.Context) error {
 		authConfig := auth.ConvertSecurityConfig(a.config.Security)
 		a.securityService = auth.NewSecurityService(authConfig)
 
-		// Create initial admin if enabled
-		if err := a.securityService.CreateInitialAdmin("admin", a.config.Security.AdminPassword, "admin@example.com"); err != nil {
-			logging.Warn("Failed to create initial admin", logging.Err(err))
+		// A configured admin password is a bootstrap credential that seeds
+		// the first admin; without one the admin is created through setup
+		if a.config.Security.AdminPassword != "" {
+			if err := a.securityService.SeedAdminFromConfig(a.config.Security.AdminUsername, a.config.Security.AdminPassword, "admin@example.com"); err != nil {
+				logging.Warn("Failed to create initial admin", logging.Err(err))
+			}
+		} else {
+			logging.Warn("No admin password configured, create the first admin with POST /api/v1/auth/setup")
 		}
 	}
 
@@ -149,22 +313,52 @@ func (a *App) Start(ctx context.Context) error {
 		return fmt.Errorf("failed to start service: %w", err)
 	}
 
+	// Persist users, API keys and lockout escalation so a restart does not
+	// reset them
+	if a.securityService != nil {
+		if err := a.securityService.SetUserStore(a.service.GetRepositoryManager().User); err != nil {
+			logging.Warn("Failed to restore user accounts", logging.Err(err))
+		}
+		a.securityService.SetAPIKeyStore(a.service.GetRepositoryManager().APIKey)
+		if err := a.securityService.SetLockoutStore(a.service.GetRepositoryManager().LockoutState); err != nil {
+			logging.Warn("Failed to restore account lockout state", logging.Err(err))
+		}
+
+		// Alert a parent at the computer when an account locks
+		if notifications := a.service.GetNotificationService(); notifications != nil {
+			a.securityService.AddLockoutNotifier(&auth.SystemAlertLockoutNotifier{Alerter: notifications})
+		}
+	}
+
 	// Initialize HTTP server
 	serverConfig := convertConfigToServerConfig(a.config.Web)
+	serverConfig.Cookie = convertCookieConfig(a.config.Security)
+	serverConfig.SecurityHeaders = convertSecurityHeadersConfig(a.config.Security)
+	serverConfig.CORS = convertCORSConfig(a.config.Security)
+	serverConfig.RateLimits = convertRateLimits(a.config.Security)
 	a.httpServer = server.New(serverConfig)
 
+	// Refuse API writes while backups or migrations hold the database
+	if db := a.service.GetDatabase(); db != nil {
+		db.SetMaintenanceHook(a.httpServer.Maintenance().Begin)
+	}
+
 	// Initialize API server
 	repos := a.service.GetRepositoryManager()
 
-	// Initialize authentication middleware if auth is enabled
-	var authMiddleware *server.AuthMiddleware
-	if a.config.Security.EnableAuth {
-		securityAdapter := NewSecurityServiceAdapter(a.securityService)
-		authMiddleware = server.NewAuthMiddleware(securityAdapter)
-	}
-
 	// Register API routes
-	apiServer := server.NewAPIServer(*repos, a.config.Security.EnableAuth)
+	apiServer, authMiddleware := newAPIServer(*repos, a.securityService)
+	if auditService := a.service.GetAuditService(); auditService != nil {
+		apiServer.SetAuditService(auditService)
+	}
+	if notifications := a.service.GetNotificationService(); notifications != nil {
+		apiServer.SetNotificationService(notifications)
+	}
+	apiServer.SetEventBus(a.service.GetEventBus())
+	if backupService := a.service.GetBackupService(); backupService != nil {
+		apiServer.SetBackupService(backupService)
+	}
+	apiServer.SetBlockListService(a.service.GetBlockListService())
 
 	// Set enforcement service if available
 	if enforcementService := a.service.GetEnforcementService(); enforcementService != nil {
@@ -187,35 +381,115 @@ func (a *App) Start(ctx context.Context) error {
 		return fmt.Errorf("failed to start HTTP server: %w", err)
 	}
 
+	// Metrics are optional; a failure to serve them must not stop enforcement
+	if a.config.Monitoring.Enabled {
+		if err := a.startMetrics(ctx); err != nil {
+			logging.Error("Metrics endpoint disabled", logging.Err(err))
+		}
+	}
+
+	// Watch the configuration file for settings that can change live
+	if a.configWatcher != nil {
+		if err := a.configWatcher.Start(); err != nil {
+			logging.Warn("Configuration reloading disabled", logging.Err(err))
+		}
+	}
+
 	logging.Info("Application started successfully")
 	return nil
 }
 
+// startMetrics serves Prometheus metrics for the running services on the
+// monitoring port (mutex must be held)
+func (a *App) startMetrics(ctx context.Context) error {
+	registry := metrics.NewRegistry()
+	registry.Register(metrics.RuntimeCollector())
+
+	a.performanceMonitor = service.NewPerformanceMonitor(service.DefaultPerformanceConfig(),
+		logging.NewDefault(), a.service.GetAuditService(), nil, nil)
+	if repos := a.service.GetRepositoryManager(); repos != nil && repos.PerformanceHistory != nil {
+		a.performanceMonitor.SetHistoryStore(repos.PerformanceHistory)
+	}
+	if notifications := a.service.GetNotificationService(); notifications != nil {
+		a.performanceMonitor.SetAlerter(notifications)
+	}
+	if db := a.service.GetDatabase(); db != nil {
+		a.performanceMonitor.SetDatabase(db)
+	}
+	if err := a.performanceMonitor.Start(ctx); err != nil {
+		return fmt.Errorf("failed to start performance monitor: %w", err)
+	}
+	registry.Register(metrics.PerformanceCollector(a.performanceMonitor))
+
+	if notifications := a.service.GetNotificationService(); notifications != nil {
+		registry.Register(metrics.NotificationCollector(notifications))
+	}
+	if enforcementService := a.service.GetEnforcementService(); enforcementService != nil {
+		registry.Register(metrics.EnforcementCollector(enforcementService))
+	}
+	if maintenance := a.service.GetDatabaseMaintenanceService(); maintenance != nil {
+		registry.Register(metrics.DatabaseMaintenanceCollector(maintenance))
+	}
+
+	monitoring := a.config.Monitoring
+	a.metricsServer = metrics.NewServer(metrics.ServerConfig{
+		Host:            monitoring.MetricsHost,
+		Port:            monitoring.MetricsPort,
+		Path:            monitoring.MetricsPath,
+		HealthCheckPath: monitoring.HealthCheckPath,
+		Auth: server.MetricsAuthConfig{
+			Mode:     server.MetricsAuthMode(monitoring.MetricsAuth),
+			Token:    monitoring.MetricsToken,
+			Username: monitoring.MetricsUsername,
+			Password: monitoring.MetricsPassword,
+		},
+	}, registry)
+	if err := a.metricsServer.Start(ctx); err != nil {
+		a.performanceMonitor.Stop()
+		a.performanceMonitor = nil
+		a.metricsServer = nil
+		return err
+	}
+	return nil
+}
+
+// stopMetrics stops the metrics endpoint and the performance monitor
+func (a *App) stopMetrics(ctx context.Context) error {
+	err := a.metricsServer.Stop(ctx)
+	if a.performanceMonitor != nil {
+		a.performanceMonitor.Stop()
+	}
+	return err
+}
+
 // Stop gracefully shuts down all components
 func (a *App) Stop(ctx context.Context) error {
+	// Stop reloads first, outside the lock the reload callback takes
+	if a.configWatcher != nil {
+		a.configWatcher.Stop()
+	}
+
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
 	logging.Info("Stopping application")
 
-	var stopErrors []error
-
-	// Stop HTTP server first
+	// Avoid passing typed nil pointers as interfaces
+	var api httpStopper
 	if a.httpServer != nil {
-		if err := a.httpServer.Stop(ctx); err != nil {
-			logging.Error("Error stopping HTTP server", logging.Err(err))
-			stopErrors = append(stopErrors, err)
-		}
+		api = a.httpServer
 	}
-
-	// Stop service
+	var svc stagedService
 	if a.service != nil {
-		if err := a.service.Stop(ctx); err != nil {
-			logging.Error("Error stopping service", logging.Err(err))
-			stopErrors = append(stopErrors, err)
-		}
+		svc = a.service
+	}
+
+	stages := shutdownStages(api, svc)
+	if a.metricsServer != nil {
+		stages = append([]shutdownStage{{name: "metrics", stop: a.stopMetrics}}, stages...)
 	}
 
+	stopErrors := runShutdown(ctx, stages)
 	if len(stopErrors) > 0 {
 		return fmt.Errorf("errors during shutdown: %v", stopErrors)
 	}
@@ -260,6 +534,13 @@ func (a *App) GetStatus() map[string]interface{} {
 		}
 	}
 
+	if a.metricsServer != nil {
+		status["metrics_server"] = map[string]interface{}{
+			"address": a.metricsServer.Address(),
+			"path":    a.config.Monitoring.MetricsPath,
+		}
+	}
+
 	if a.securityService != nil {
 		status["auth"] = map[string]interface{}{
 			"enabled": a.config.Security.EnableAuth,
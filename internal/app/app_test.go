@@ -0,0 +1,163 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"parental-control/internal/auth"
+	"parental-control/internal/database"
+	"parental-control/internal/models"
+	"parental-control/internal/server"
+)
+
+func TestAPIServer_ServesAuthHandlers(t *testing.T) {
+	config := auth.DefaultAuthConfig()
+	config.Password.BcryptCost = 4
+	config.SessionSecret = "test-session-secret"
+	securityService := auth.NewSecurityService(config)
+	t.Cleanup(securityService.Stop)
+	if err := securityService.CreateInitialAdmin("admin", "AdminPassword123!", "admin@example.com"); err != nil {
+		t.Fatalf("Failed to create admin: %v", err)
+	}
+
+	httpServer := server.New(server.DefaultConfig())
+	apiServer, _ := newAPIServer(models.RepositoryManager{}, securityService)
+	apiServer.RegisterRoutes(httpServer)
+	handler := httpServer.Handler()
+
+	serve := func(method, path, body string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		for _, cookie := range cookies {
+			req.AddCookie(cookie)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := serve(http.MethodPost, "/api/v1/auth/password/reset", `{"token":"wrong","new_password":"NewPassword123!"}`); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for a bad recovery token, got %d", rec.Code)
+	}
+	for _, route := range []struct{ method, path string }{
+		{http.MethodPost, "/api/v1/auth/2fa/enroll"},
+		{http.MethodPost, "/api/v1/auth/2fa/verify"},
+		{http.MethodPost, "/api/v1/auth/users/1/unlock"},
+		{http.MethodDelete, "/api/v1/auth/users/1"},
+		{http.MethodPost, "/api/v1/auth/logout-all"},
+		{http.MethodGet, "/api/v1/auth/api-keys"},
+	} {
+		if rec := serve(route.method, route.path, `{}`); rec.Code != http.StatusUnauthorized {
+			t.Errorf("Expected 401 for an anonymous %s %s, got %d", route.method, route.path, rec.Code)
+		}
+	}
+
+	rec := serve(http.MethodPost, "/api/v1/auth/login", `{"username":"admin","password":"AdminPassword123!"}`)
+	var session *http.Cookie
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.Name == "session_id" {
+			session = cookie
+		}
+	}
+	if rec.Code != http.StatusOK || session == nil {
+		t.Fatalf("Expected a session from login, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	if rec := serve(http.MethodGet, "/api/v1/auth/me", "", session); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"admin"`) {
+		t.Errorf("Expected the signed-in admin, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := serve(http.MethodGet, "/api/v1/auth/sessions", "", session); rec.Code != http.StatusOK {
+		t.Errorf("Expected the session list, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := serve(http.MethodPost, "/api/v1/auth/2fa/enroll", "", session); rec.Code != http.StatusOK {
+		t.Errorf("Expected two-factor enrollment, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestAPIServer_AuthenticatesAPIKeys(t *testing.T) {
+	dbConfig := database.DefaultConfig()
+	dbConfig.Path = filepath.Join(t.TempDir(), "app.db")
+	db, err := database.New(dbConfig)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.InitializeSchema(); err != nil {
+		t.Fatalf("Failed to initialize schema: %v", err)
+	}
+
+	config := auth.DefaultAuthConfig()
+	config.Password.BcryptCost = 4
+	securityService := auth.NewSecurityService(config)
+	t.Cleanup(securityService.Stop)
+	if err := securityService.CreateInitialAdmin("admin", "AdminPassword123!", "admin@example.com"); err != nil {
+		t.Fatalf("Failed to create admin: %v", err)
+	}
+	securityService.SetAPIKeyStore(database.NewAPIKeyRepository(db.Connection()))
+	readOnly, err := securityService.CreateAPIKey(1, auth.APIKeyRequest{Name: "dashboard"})
+	if err != nil {
+		t.Fatalf("Failed to create API key: %v", err)
+	}
+	adminKey, err := securityService.CreateAPIKey(1, auth.APIKeyRequest{Name: "ci", Scope: auth.APIKeyScopeAdmin})
+	if err != nil {
+		t.Fatalf("Failed to create API key: %v", err)
+	}
+
+	httpServer := server.New(server.DefaultConfig())
+	apiServer, _ := newAPIServer(models.RepositoryManager{}, securityService)
+	apiServer.RegisterRoutes(httpServer)
+	handler := httpServer.Handler()
+
+	serve := func(method, key, body string) int {
+		req := httptest.NewRequest(method, "/api/v1/admin/maintenance", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+key)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := serve(http.MethodGet, readOnly.Key, ""); code != http.StatusOK {
+		t.Errorf("Expected a read-only key to read maintenance status, got %d", code)
+	}
+	if code := serve(http.MethodPost, readOnly.Key, `{"enabled":true}`); code != http.StatusForbidden {
+		t.Errorf("Expected a read-only key to be refused a POST, got %d", code)
+	}
+	if httpServer.Maintenance().IsActive() {
+		t.Error("A read-only key should not enable maintenance mode")
+	}
+	if code := serve(http.MethodGet, "pc_unknown", ""); code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for an unknown key, got %d", code)
+	}
+	if code := serve(http.MethodPost, adminKey.Key, `{"enabled":true}`); code != http.StatusOK || !httpServer.Maintenance().IsActive() {
+		t.Errorf("Expected an admin key to enable maintenance mode, got %d", code)
+	}
+
+	if err := securityService.RevokeAPIKey(adminKey.APIKey.ID); err != nil {
+		t.Fatalf("Failed to revoke API key: %v", err)
+	}
+	if code := serve(http.MethodGet, adminKey.Key, ""); code != http.StatusUnauthorized {
+		t.Errorf("Expected a revoked key to be refused, got %d", code)
+	}
+}
+
+func TestAPIServer_BlockListRequiresAdmin(t *testing.T) {
+	config := auth.DefaultAuthConfig()
+	config.Password.BcryptCost = 4
+	securityService := auth.NewSecurityService(config)
+	t.Cleanup(securityService.Stop)
+
+	httpServer := server.New(server.DefaultConfig())
+	apiServer, _ := newAPIServer(models.RepositoryManager{}, securityService)
+	apiServer.RegisterRoutes(httpServer)
+
+	// Subscribing makes the service fetch any URL, so it must not be public
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/lists/1/blocklist", strings.NewReader(`{"url":"http://169.254.169.254/"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	httpServer.Handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without credentials, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,69 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"parental-control/internal/logging"
+)
+
+// httpStopper is the part of the HTTP server driven during shutdown
+type httpStopper interface {
+	Stop(ctx context.Context) error
+}
+
+// stagedService is the part of the service driven during shutdown
+type stagedService interface {
+	StopSchedulers(ctx context.Context) error
+	FlushAudit(ctx context.Context) error
+	StopEnforcement(ctx context.Context) error
+	Stop(ctx context.Context) error
+}
+
+// shutdownStage is one step of the ordered application shutdown
+type shutdownStage struct {
+	name string
+	stop func(ctx context.Context) error
+}
+
+// shutdownStages returns the shutdown order: stop taking API requests, stop
+// background schedulers, flush audit logs, then stop enforcement and DNS last
+// so protection lasts as long as possible. The service itself stops at the
+// end to close the database once the final events are written.
+func shutdownStages(api httpStopper, svc stagedService) []shutdownStage {
+	var stages []shutdownStage
+	if api != nil {
+		stages = append(stages, shutdownStage{name: "api", stop: api.Stop})
+	}
+	if svc != nil {
+		stages = append(stages,
+			shutdownStage{name: "schedulers", stop: svc.StopSchedulers},
+			shutdownStage{name: "audit", stop: svc.FlushAudit},
+			shutdownStage{name: "enforcement", stop: svc.StopEnforcement},
+			shutdownStage{name: "service", stop: svc.Stop},
+		)
+	}
+	return stages
+}
+
+// runShutdown runs every stage in order, continuing past failures
+func runShutdown(ctx context.Context, stages []shutdownStage) []error {
+	var stopErrors []error
+	for _, stage := range stages {
+		start := time.Now()
+		logging.Info("Shutdown stage starting", logging.String("stage", stage.name))
+
+		if err := stage.stop(ctx); err != nil {
+			logging.Error("Shutdown stage failed",
+				logging.String("stage", stage.name),
+				logging.Err(err))
+			stopErrors = append(stopErrors, err)
+			continue
+		}
+
+		logging.Info("Shutdown stage completed",
+			logging.String("stage", stage.name),
+			logging.String("duration", time.Since(start).String()))
+	}
+	return stopErrors
+}
@@ -0,0 +1,84 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// recorder collects the order components are stopped in
+type recorder struct {
+	calls []string
+}
+
+type fakeHTTPServer struct {
+	rec *recorder
+}
+
+func (f *fakeHTTPServer) Stop(ctx context.Context) error {
+	f.rec.calls = append(f.rec.calls, "api")
+	return nil
+}
+
+type fakeService struct {
+	rec       *recorder
+	flushErr  error
+	stopped   bool
+	enforcing bool
+}
+
+func (f *fakeService) StopSchedulers(ctx context.Context) error {
+	f.rec.calls = append(f.rec.calls, "schedulers")
+	return nil
+}
+
+func (f *fakeService) FlushAudit(ctx context.Context) error {
+	f.rec.calls = append(f.rec.calls, "audit")
+	return f.flushErr
+}
+
+func (f *fakeService) StopEnforcement(ctx context.Context) error {
+	f.rec.calls = append(f.rec.calls, "enforcement")
+	f.enforcing = false
+	return nil
+}
+
+func (f *fakeService) Stop(ctx context.Context) error {
+	f.rec.calls = append(f.rec.calls, "service")
+	f.stopped = true
+	return nil
+}
+
+func TestShutdownOrder(t *testing.T) {
+	rec := &recorder{}
+	svc := &fakeService{rec: rec, enforcing: true}
+
+	errs := runShutdown(context.Background(), shutdownStages(&fakeHTTPServer{rec: rec}, svc))
+	if len(errs) != 0 {
+		t.Fatalf("Unexpected shutdown errors: %v", errs)
+	}
+
+	expected := []string{"api", "schedulers", "audit", "enforcement", "service"}
+	if !reflect.DeepEqual(rec.calls, expected) {
+		t.Errorf("Expected stop order %v, got %v", expected, rec.calls)
+	}
+	if svc.enforcing || !svc.stopped {
+		t.Error("Expected enforcement and service to be stopped")
+	}
+}
+
+func TestShutdownContinuesAfterFailure(t *testing.T) {
+	rec := &recorder{}
+	svc := &fakeService{rec: rec, flushErr: errors.New("database busy")}
+
+	errs := runShutdown(context.Background(), shutdownStages(nil, svc))
+	if len(errs) != 1 {
+		t.Fatalf("Expected one shutdown error, got %v", errs)
+	}
+
+	expected := []string{"schedulers", "audit", "enforcement", "service"}
+	if !reflect.DeepEqual(rec.calls, expected) {
+		t.Errorf("Expected enforcement to stop despite the flush failure, got %v", rec.calls)
+	}
+}
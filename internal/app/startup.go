@@ -3,6 +3,9 @@ package app
 import (
 	"context"
 	"fmt"
+	"net"
+	"strconv"
+	"strings"
 	"time"
 
 	"parental-control/internal/config"
@@ -22,6 +25,9 @@ type StartupConfig struct {
 type StartupOrchestrator struct {
 	config StartupConfig
 	logger *logging.ConcreteLogger
+
+	// loadedPath is the configuration file read at startup, if any
+	loadedPath string
 }
 
 // NewStartupOrchestrator creates a new startup orchestrator
@@ -43,6 +49,7 @@ func (so *StartupOrchestrator) InitializeApplication() (*App, *config.Config, er
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to load configuration: %w", err)
 	}
+	so.logConfigSummary(appConfig)
 
 	// Handle privilege elevation
 	if err := so.ensurePrivileges(appConfig); err != nil {
@@ -54,14 +61,20 @@ func (so *StartupOrchestrator) InitializeApplication() (*App, *config.Config, er
 		Service: service.Config{
 			PIDFile:             appConfig.Service.PIDFile,
 			ShutdownTimeout:     appConfig.Service.ShutdownTimeout,
-			DatabaseConfig:      appConfig.Database,
+			DatabaseConfig:      appConfig.GetDatabaseConfig(),
 			HealthCheckInterval: appConfig.Service.HealthCheckInterval,
 			EnforcementConfig:   appConfig.Enforcement.ToEnforcementConfig(),
 			EnforcementEnabled:  appConfig.Enforcement.Enabled,
 			NotificationConfig:  appConfig.Notifications.ToServiceNotificationConfig(),
+			PolicySeed:          appConfig.Retention.ToPolicySeedConfig(appConfig.Logging.Output),
+			Backup:              appConfig.ToBackupConfig(),
+			Maintenance:         appConfig.ToDatabaseMaintenanceConfig(),
 		},
-		Web:      appConfig.Web,
-		Security: appConfig.Security,
+		Web:        appConfig.Web,
+		Security:   appConfig.Security,
+		Monitoring: appConfig.Monitoring,
+		ConfigFile: so.loadedPath,
+		FileConfig: appConfig,
 	})
 
 	return application, appConfig, nil
@@ -69,17 +82,93 @@ func (so *StartupOrchestrator) InitializeApplication() (*App, *config.Config, er
 
 // loadConfiguration loads and validates the application configuration
 func (so *StartupOrchestrator) loadConfiguration() (*config.Config, error) {
-	appConfig, err := config.LoadFromFile(so.config.ConfigPath)
+	appConfig, path, err := config.Load(so.config.ConfigPath)
 	if err != nil {
 		so.logger.Warn("Could not load config file, using defaults",
-			logging.String("path", so.config.ConfigPath),
+			logging.String("path", path),
 			logging.Err(err))
 		appConfig = config.Default()
+	} else {
+		so.logger.Info("Loaded configuration file", logging.String("path", path))
+		so.loadedPath = path
 	}
 
 	return appConfig, nil
 }
 
+// logConfigSummary logs the effective operating mode so the security posture
+// can be confirmed at a glance. Secrets are never logged.
+func (so *StartupOrchestrator) logConfigSummary(appConfig *config.Config) {
+	summary := appConfig.Summary()
+	so.logger.Info("Effective configuration",
+		logging.Bool("auth_enabled", summary.AuthEnabled),
+		logging.String("admin_password", summary.AdminPassword),
+		logging.String("session_secret", summary.SessionSecret),
+		logging.String("session_timeout", summary.SessionTimeout),
+		logging.String("cookie_secure", summary.CookieSecure),
+		logging.Bool("enforcement_enabled", summary.EnforcementEnabled),
+		logging.Bool("network_filtering", summary.NetworkFiltering),
+		logging.String("dns_mode", summary.DNSMode),
+		logging.Bool("emergency_mode", summary.EmergencyMode),
+		logging.Bool("web_enabled", summary.WebEnabled),
+		logging.String("web_address", summary.WebAddress),
+		logging.String("tls_mode", summary.TLSMode),
+		logging.String("metrics_address", summary.MetricsAddress),
+		logging.String("metrics_auth", summary.MetricsAuth),
+		logging.Bool("notifications_enabled", summary.NotificationsOn),
+		logging.String("log_level", summary.LogLevel),
+		logging.String("database_path", summary.DatabasePath))
+
+	// List every non-default setting and where it came from
+	for _, change := range config.Diff(config.Default(), appConfig.Redacted()) {
+		so.logger.Info("Configuration override",
+			logging.String("path", change.Path),
+			logging.String("value", change.New),
+			logging.String("default", change.Old),
+			logging.String("source", string(change.Source)))
+	}
+
+	for _, warning := range appConfig.InsecureWarnings() {
+		so.logger.Warn("INSECURE CONFIGURATION: " + warning)
+	}
+}
+
+// privilegedFeatures returns the enabled enforcement features that need
+// privileges
+func privilegedFeatures(appConfig *config.Config) []string {
+	if !appConfig.Enforcement.Enabled {
+		return nil
+	}
+
+	features := []string{privilege.FeatureProcessControl}
+	if appConfig.Enforcement.EnableNetworkFiltering {
+		features = append(features, privilege.FeatureNetworkFiltering)
+		if dnsListensOnPrivilegedPort(appConfig.Enforcement.DNSListenAddr) {
+			features = append(features, privilege.FeatureDNSSinkhole)
+		}
+	}
+	return features
+}
+
+// dnsListensOnPrivilegedPort reports whether any DNS listen address uses a
+// port below 1024; addresses without a port use 53
+func dnsListensOnPrivilegedPort(listenAddr string) bool {
+	for _, addr := range strings.Split(listenAddr, ",") {
+		addr = strings.TrimSpace(addr)
+		if addr == "" {
+			continue
+		}
+		_, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return true
+		}
+		if n, err := strconv.Atoi(port); err != nil || n < 1024 {
+			return true
+		}
+	}
+	return false
+}
+
 // ensurePrivileges handles privilege elevation if needed
 func (so *StartupOrchestrator) ensurePrivileges(appConfig *config.Config) error {
 	if so.config.SkipElevation || appConfig.Privilege.SkipElevationCheck {
@@ -102,6 +191,8 @@ func (so *StartupOrchestrator) ensurePrivileges(appConfig *config.Config) error
 		privConfig.Method = privilege.ElevationMethodSudo
 	case "pkexec":
 		privConfig.Method = privilege.ElevationMethodPkexec
+	case "doas":
+		privConfig.Method = privilege.ElevationMethodDoas
 	default:
 		privConfig.Method = privilege.ElevationMethodAuto
 	}
@@ -113,6 +204,25 @@ func (so *StartupOrchestrator) ensurePrivileges(appConfig *config.Config) error
 		return nil
 	}
 
+	// A binary granted the right capabilities does not need root
+	features := privilegedFeatures(appConfig)
+	if len(features) == 0 {
+		so.logger.Info("No enabled enforcement feature needs elevated privileges")
+		return nil
+	}
+	report := privManager.CheckCapabilities()
+	if report.Satisfied(features...) {
+		so.logger.Info("Running without elevation; required capabilities are held",
+			logging.String("features", strings.Join(features, ",")))
+		return nil
+	}
+	for _, missing := range report.Missing(features...) {
+		so.logger.Debug("Missing capability",
+			logging.String("feature", missing.Feature),
+			logging.String("capability", missing.Capability),
+			logging.String("reason", missing.Reason))
+	}
+
 	if !privManager.CanElevate() {
 		return fmt.Errorf("privilege elevation is not available on this system")
 	}
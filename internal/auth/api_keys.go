@@ -0,0 +1,284 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"parental-control/internal/logging"
+	"parental-control/internal/models"
+)
+
+const (
+	// apiKeyPrefix marks a bearer token as an API key rather than a session
+	apiKeyPrefix = "pc_"
+
+	// apiKeyDisplayLength is how much of a key is kept in APIKey.Prefix
+	apiKeyDisplayLength = len(apiKeyPrefix) + 8
+
+	// apiKeyLastUsedInterval is how often the last use of a key is written
+	// back, so busy keys do not cost a database write per request
+	apiKeyLastUsedInterval = time.Minute
+)
+
+// IsAPIKey reports whether token has the form of an API key
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, apiKeyPrefix)
+}
+
+// hashAPIKey returns the stored form of a key. Keys are random and long, so
+// a fast hash is enough to keep them from being recovered.
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// SetAPIKeyStore sets where API keys are kept. Keys are only ever stored
+// there, so none can be created or used until it is set.
+func (ss *SecurityService) SetAPIKeyStore(store models.APIKeyRepository) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	ss.apiKeyStore = store
+}
+
+// CreateAPIKey mints an API key for a user. Only the hash of the returned key
+// is stored, so it cannot be retrieved again.
+func (ss *SecurityService) CreateAPIKey(userID int, req APIKeyRequest) (*APIKeyCreateResponse, error) {
+	if req.Scope == "" {
+		req.Scope = APIKeyScopeReadOnly
+	}
+	if req.Scope != APIKeyScopeReadOnly && req.Scope != APIKeyScopeAdmin {
+		return nil, fmt.Errorf("invalid scope %q: must be %s or %s", req.Scope, APIKeyScopeReadOnly, APIKeyScopeAdmin)
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("expiry must be in the future")
+	}
+
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return nil, fmt.Errorf("failed to generate API key: %w", err)
+	}
+	key := apiKeyPrefix + hex.EncodeToString(bytes)
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	if ss.apiKeyStore == nil {
+		return nil, ErrAPIKeysUnavailable
+	}
+
+	user := ss.findUserByID(userID)
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	apiKey := &APIKey{
+		UserID:    user.ID,
+		Name:      req.Name,
+		Prefix:    key[:apiKeyDisplayLength],
+		KeyHash:   hashAPIKey(key),
+		Scope:     req.Scope,
+		ExpiresAt: req.ExpiresAt,
+		CreatedAt: time.Now(),
+	}
+	record := apiKey.record()
+	if err := ss.apiKeyStore.Create(context.Background(), record); err != nil {
+		return nil, fmt.Errorf("failed to save API key: %w", err)
+	}
+	apiKey.ID = record.ID
+
+	ss.logSecurityEvent(&SecurityEvent{
+		UserID:      &user.ID,
+		EventType:   EventTypeAPIKeyCreated,
+		Description: fmt.Sprintf("API key %s (%s, %s) created", apiKey.Name, apiKey.Prefix, apiKey.Scope),
+		Severity:    SeverityMedium,
+		Timestamp:   time.Now(),
+	})
+
+	return &APIKeyCreateResponse{Key: key, APIKey: *apiKey}, nil
+}
+
+// ListAPIKeys returns every API key, including revoked and expired ones,
+// ordered by ID
+func (ss *SecurityService) ListAPIKeys() ([]APIKey, error) {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+
+	if ss.apiKeyStore == nil {
+		return []APIKey{}, nil
+	}
+
+	records, err := ss.apiKeyStore.GetAll(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load API keys: %w", err)
+	}
+
+	keys := make([]APIKey, 0, len(records))
+	for i := range records {
+		keys = append(keys, *apiKeyFromRecord(&records[i]))
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey stops an API key from authenticating
+func (ss *SecurityService) RevokeAPIKey(id int) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	if ss.apiKeyStore == nil {
+		return ErrAPIKeyNotFound
+	}
+
+	record, err := ss.apiKeyStore.GetByID(context.Background(), id)
+	if err != nil {
+		return ErrAPIKeyNotFound
+	}
+	apiKey := apiKeyFromRecord(record)
+	if apiKey.RevokedAt != nil {
+		return nil
+	}
+	return ss.revokeAPIKeyInternal(apiKey, "revoked")
+}
+
+// AuthenticateAPIKey resolves an API key to its user. Every use is recorded
+// as an EventTypeAPIKeyUsed security event. The key is looked up and its last
+// use written outside the service mutex, so API requests do not hold up
+// sessions and logins while the database is busy.
+func (ss *SecurityService) AuthenticateAPIKey(key, ipAddress, userAgent string) (*User, *APIKey, error) {
+	ss.mu.RLock()
+	store := ss.apiKeyStore
+	ss.mu.RUnlock()
+
+	if store == nil {
+		return nil, nil, ErrInvalidAPIKey
+	}
+
+	record, err := store.GetByHash(context.Background(), hashAPIKey(key))
+	if err != nil {
+		return nil, nil, ErrInvalidAPIKey
+	}
+	apiKey := apiKeyFromRecord(record)
+	if !apiKey.IsUsable() {
+		return nil, nil, ErrInvalidAPIKey
+	}
+
+	ss.mu.RLock()
+	user := ss.findUserByID(apiKey.UserID)
+	usable := user != nil && user.IsActive && !user.IsLocked()
+	ss.mu.RUnlock()
+	if !usable {
+		return nil, nil, ErrInvalidAPIKey
+	}
+
+	now := time.Now()
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) >= apiKeyLastUsedInterval {
+		if err := store.UpdateLastUsed(context.Background(), apiKey.ID, now); err != nil {
+			logging.Warn("Failed to record API key use",
+				logging.String("prefix", apiKey.Prefix),
+				logging.Err(err))
+		} else {
+			apiKey.LastUsedAt = &now
+		}
+	}
+
+	ss.logSecurityEvent(&SecurityEvent{
+		UserID:      &user.ID,
+		EventType:   EventTypeAPIKeyUsed,
+		Description: fmt.Sprintf("API key %s (%s) used", apiKey.Name, apiKey.Prefix),
+		IPAddress:   ipAddress,
+		UserAgent:   userAgent,
+		Severity:    SeverityLow,
+		Timestamp:   now,
+	})
+
+	return user, apiKey, nil
+}
+
+// revokeUserAPIKeysInternal revokes every API key belonging to a user (mutex
+// must be held)
+func (ss *SecurityService) revokeUserAPIKeysInternal(userID int, reason string) {
+	if ss.apiKeyStore == nil {
+		return
+	}
+
+	records, err := ss.apiKeyStore.GetAll(context.Background())
+	if err != nil {
+		logging.Warn("Failed to load API keys to revoke",
+			logging.Int("user_id", userID),
+			logging.Err(err))
+		return
+	}
+
+	for i := range records {
+		apiKey := apiKeyFromRecord(&records[i])
+		if apiKey.UserID != userID || apiKey.RevokedAt != nil {
+			continue
+		}
+		if err := ss.revokeAPIKeyInternal(apiKey, reason); err != nil {
+			logging.Warn("Failed to revoke API key",
+				logging.String("prefix", apiKey.Prefix),
+				logging.Err(err))
+		}
+	}
+}
+
+// revokeAPIKeyInternal marks a key revoked in the store and records it
+// (mutex must be held)
+func (ss *SecurityService) revokeAPIKeyInternal(apiKey *APIKey, reason string) error {
+	now := time.Now()
+	apiKey.RevokedAt = &now
+	if err := ss.apiKeyStore.Update(context.Background(), apiKey.record()); err != nil {
+		return fmt.Errorf("failed to revoke API key: %w", err)
+	}
+
+	ss.logSecurityEvent(&SecurityEvent{
+		UserID:      &apiKey.UserID,
+		EventType:   EventTypeAPIKeyRevoked,
+		Description: fmt.Sprintf("API key %s (%s) %s", apiKey.Name, apiKey.Prefix, reason),
+		Severity:    SeverityMedium,
+		Timestamp:   now,
+	})
+
+	logging.Info("API key revoked",
+		logging.String("prefix", apiKey.Prefix),
+		logging.Int("user_id", apiKey.UserID),
+		logging.String("reason", reason))
+	return nil
+}
+
+// record returns the stored form of the API key
+func (k *APIKey) record() *models.APIKey {
+	return &models.APIKey{
+		ID:         k.ID,
+		UserID:     k.UserID,
+		Name:       k.Name,
+		Prefix:     k.Prefix,
+		KeyHash:    k.KeyHash,
+		Scope:      string(k.Scope),
+		ExpiresAt:  k.ExpiresAt,
+		LastUsedAt: k.LastUsedAt,
+		RevokedAt:  k.RevokedAt,
+		CreatedAt:  k.CreatedAt,
+	}
+}
+
+// apiKeyFromRecord returns the API key a stored record describes
+func apiKeyFromRecord(record *models.APIKey) *APIKey {
+	return &APIKey{
+		ID:         record.ID,
+		UserID:     record.UserID,
+		Name:       record.Name,
+		Prefix:     record.Prefix,
+		KeyHash:    record.KeyHash,
+		Scope:      APIKeyScope(record.Scope),
+		ExpiresAt:  record.ExpiresAt,
+		LastUsedAt: record.LastUsedAt,
+		RevokedAt:  record.RevokedAt,
+		CreatedAt:  record.CreatedAt,
+	}
+}
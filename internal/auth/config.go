@@ -24,9 +24,30 @@ func ConvertSecurityConfig(securityConfig config.SecurityConfig) AuthConfig {
 		MaxFailedAttempts:     securityConfig.MaxFailedAttempts,
 		LockoutDuration:       securityConfig.LockoutDuration,
 		LoginRateLimit:        securityConfig.LoginRateLimit,
+		RecoveryTokenTTL:      securityConfig.RecoveryTokenTTL,
+		RecoveryMaxAttempts:   securityConfig.RecoveryMaxAttempts,
 		RequireTwoFactor:      false, // Not implemented yet
 		AllowMultipleSessions: securityConfig.AllowMultipleSessions,
 		MaxSessions:           securityConfig.MaxSessions,
+
+		LoginAttemptHistorySize: securityConfig.LoginAttemptHistorySize,
+		EventHistorySize:        securityConfig.EventHistorySize,
+
+		RevokeAllSessionsOnPasswordChange: securityConfig.RevokeAllSessionsOnPasswordChange,
+
+		LockoutEscalation:       securityConfig.LockoutEscalation,
+		LockoutEscalationFactor: securityConfig.LockoutEscalationFactor,
+		LockoutMaxDuration:      securityConfig.LockoutMaxDuration,
+		LockoutEscalationReset:  securityConfig.LockoutEscalationReset,
+		LockoutNotify: LockoutNotifyConfig{
+			WebhookURL:   securityConfig.LockoutNotifyWebhookURL,
+			EmailTo:      securityConfig.LockoutNotifyEmail,
+			EmailFrom:    securityConfig.SMTPFrom,
+			SMTPHost:     securityConfig.SMTPHost,
+			SMTPPort:     securityConfig.SMTPPort,
+			SMTPUsername: securityConfig.SMTPUsername,
+			SMTPPassword: securityConfig.SMTPPassword,
+		},
 	}
 }
 
@@ -3,8 +3,11 @@ package auth
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/mail"
+	"strconv"
 	"strings"
 	"time"
 
@@ -16,46 +19,54 @@ import (
 type authHandlerContextKey string
 
 const (
-	userContextKey authHandlerContextKey = "user"
+	userContextKey    authHandlerContextKey = "user"
+	sessionContextKey authHandlerContextKey = "session_id"
+	apiKeyContextKey  authHandlerContextKey = "api_key"
 )
 
+// defaultPageSize is the page size of the admin user and session listings
+const defaultPageSize = 50
+
 // AuthHandlers contains HTTP handlers for authentication endpoints
 type AuthHandlers struct {
 	securityService *SecurityService
+	cookies         server.CookieConfig
 }
 
 // NewAuthHandlers creates new authentication handlers
 func NewAuthHandlers(securityService *SecurityService) *AuthHandlers {
 	return &AuthHandlers{
 		securityService: securityService,
+		cookies:         server.DefaultCookieConfig(),
 	}
 }
 
 // RegisterRoutes registers authentication routes with the server
 func (ah *AuthHandlers) RegisterRoutes(srv *server.Server) {
-	// Authentication middleware for protected endpoints
+	ah.cookies = srv.GetCookieConfig()
+
+	maxBodyBytes := srv.MaxBodyBytes()
+
+	// Authentication middleware for protected endpoints. Request IDs, panic
+	// recovery and security headers are applied to every route by the server.
 	authMiddleware := server.NewMiddlewareChain(
-		server.RequestIDMiddleware(),
 		server.LoggingMiddleware(),
-		server.RecoveryMiddleware(),
-		server.SecurityHeadersMiddleware(),
 		server.JSONMiddleware(),
-		server.ContentLengthMiddleware(1024*1024), // 1MB limit
+		server.ContentLengthMiddleware(maxBodyBytes),
 	)
 
 	// Public endpoints (no authentication required)
 	srv.AddHandler("/api/v1/auth/login", authMiddleware.ThenFunc(ah.handleLogin))
 	srv.AddHandler("/api/v1/auth/logout", authMiddleware.ThenFunc(ah.handleLogout))
 	srv.AddHandler("/api/v1/auth/password/strength", authMiddleware.ThenFunc(ah.handlePasswordStrength))
+	srv.AddHandler("/api/v1/auth/password/recovery", authMiddleware.ThenFunc(ah.handlePasswordRecovery))
+	srv.AddHandler("/api/v1/auth/password/reset", authMiddleware.ThenFunc(ah.handlePasswordReset))
 
 	// Protected endpoints (require authentication)
 	protectedMiddleware := server.NewMiddlewareChain(
-		server.RequestIDMiddleware(),
 		server.LoggingMiddleware(),
-		server.RecoveryMiddleware(),
-		server.SecurityHeadersMiddleware(),
 		server.JSONMiddleware(),
-		server.ContentLengthMiddleware(1024*1024),
+		server.ContentLengthMiddleware(maxBodyBytes),
 		ah.AuthenticationMiddleware(), // Add auth middleware
 	)
 
@@ -67,20 +78,23 @@ func (ah *AuthHandlers) RegisterRoutes(srv *server.Server) {
 	srv.AddHandler("/api/v1/auth/sessions", protectedMiddleware.ThenFunc(ah.handleSessions))
 	srv.AddHandler("/api/v1/auth/sessions/refresh", protectedMiddleware.ThenFunc(ah.handleSessionRefresh))
 	srv.AddHandler("/api/v1/auth/sessions/revoke", protectedMiddleware.ThenFunc(ah.handleSessionRevoke))
+	srv.AddHandler("/api/v1/auth/logout-all", protectedMiddleware.ThenFunc(ah.handleLogoutAll))
+	srv.AddHandler("/api/v1/auth/2fa/enroll", protectedMiddleware.ThenFunc(ah.handleTwoFactorEnroll))
+	srv.AddHandler("/api/v1/auth/2fa/verify", protectedMiddleware.ThenFunc(ah.handleTwoFactorVerify))
 
 	// Admin-only endpoints
 	adminMiddleware := server.NewMiddlewareChain(
-		server.RequestIDMiddleware(),
 		server.LoggingMiddleware(),
-		server.RecoveryMiddleware(),
-		server.SecurityHeadersMiddleware(),
 		server.JSONMiddleware(),
-		server.ContentLengthMiddleware(1024*1024),
+		server.ContentLengthMiddleware(maxBodyBytes),
 		ah.AuthenticationMiddleware(),
 		ah.AdminMiddleware(), // Require admin privileges
 	)
 
 	srv.AddHandler("/api/v1/auth/users", adminMiddleware.ThenFunc(ah.handleUsers))
+	srv.AddHandler("/api/v1/auth/users/", adminMiddleware.ThenFunc(ah.handleUser))
+	srv.AddHandler("/api/v1/auth/api-keys", adminMiddleware.ThenFunc(ah.handleAPIKeys))
+	srv.AddHandler("/api/v1/auth/api-keys/", adminMiddleware.ThenFunc(ah.handleAPIKey))
 	srv.AddHandler("/api/v1/auth/security/stats", adminMiddleware.ThenFunc(ah.handleSecurityStats))
 	srv.AddHandler("/api/v1/auth/sessions/admin", adminMiddleware.ThenFunc(ah.handleAdminSessions))
 	srv.AddHandler("/api/v1/auth/sessions/analytics", adminMiddleware.ThenFunc(ah.handleSessionAnalytics))
@@ -104,8 +118,14 @@ func (ah *AuthHandlers) handleLogin(w http.ResponseWriter, r *http.Request) {
 	ipAddress := getClientIP(r)
 	userAgent := r.UserAgent()
 
-	// Authenticate user
-	response, err := ah.securityService.Authenticate(req.Username, req.Password, ipAddress, userAgent)
+	// Authenticate user, or finish a login waiting for a two-factor code
+	var response *LoginResponse
+	var err error
+	if req.ChallengeToken != "" {
+		response, err = ah.securityService.CompleteTwoFactorLogin(req.ChallengeToken, req.TOTPCode, ipAddress, userAgent)
+	} else {
+		response, err = ah.securityService.Authenticate(req.Username, req.Password, ipAddress, userAgent)
+	}
 	if err != nil {
 		logging.Error("Authentication error", logging.Err(err))
 		server.WriteErrorResponse(w, http.StatusInternalServerError, "Authentication failed")
@@ -114,20 +134,79 @@ func (ah *AuthHandlers) handleLogin(w http.ResponseWriter, r *http.Request) {
 
 	// Set session cookie if login successful
 	if response.Success {
-		http.SetCookie(w, &http.Cookie{
-			Name:     "session_id",
-			Value:    response.SessionID,
-			Path:     "/",
-			MaxAge:   int(ah.securityService.config.SessionTimeout.Seconds()),
-			HttpOnly: true,
-			Secure:   r.TLS != nil, // Only secure over HTTPS
-			SameSite: http.SameSiteStrictMode,
-		})
+		http.SetCookie(w, ah.cookies.SessionCookie(r, response.SessionID, ah.securityService.config.SessionTimeout))
 	}
 
 	server.WriteJSONResponse(w, http.StatusOK, response)
 }
 
+// handleTwoFactorEnroll generates a TOTP secret for the current user to add
+// to an authenticator app
+func (ah *AuthHandlers) handleTwoFactorEnroll(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		server.WriteErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	user := r.Context().Value(userContextKey).(*User)
+
+	enrollment, err := ah.securityService.EnrollTwoFactor(user.ID)
+	if err != nil {
+		ah.writeTwoFactorError(w, err)
+		return
+	}
+
+	server.WriteJSONResponse(w, http.StatusOK, enrollment)
+}
+
+// handleTwoFactorVerify turns on two-factor authentication for the current
+// user once a code from the enrolled secret checks out
+func (ah *AuthHandlers) handleTwoFactorVerify(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		server.WriteErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	user := r.Context().Value(userContextKey).(*User)
+
+	var req TwoFactorVerifyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		server.WriteErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.Code == "" {
+		server.WriteErrorResponse(w, http.StatusBadRequest, "Code is required")
+		return
+	}
+
+	if err := ah.securityService.VerifyTwoFactor(user.ID, req.Code); err != nil {
+		ah.writeTwoFactorError(w, err)
+		return
+	}
+
+	server.WriteJSONResponse(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Two-factor authentication enabled",
+	})
+}
+
+// writeTwoFactorError maps a two-factor enrollment error to a response
+func (ah *AuthHandlers) writeTwoFactorError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidTwoFactorCode):
+		server.WriteErrorResponse(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, ErrTwoFactorAlreadyEnabled), errors.Is(err, ErrTwoFactorNotEnrolled):
+		server.WriteErrorResponse(w, http.StatusConflict, err.Error())
+	case errors.Is(err, ErrUserNotFound):
+		server.WriteErrorResponse(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, ErrTwoFactorUnavailable):
+		server.WriteErrorResponse(w, http.StatusServiceUnavailable, err.Error())
+	default:
+		logging.Error("Two-factor enrollment failed", logging.Err(err))
+		server.WriteErrorResponse(w, http.StatusInternalServerError, "Two-factor enrollment failed")
+	}
+}
+
 // handleLogout processes logout requests
 func (ah *AuthHandlers) handleLogout(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -151,15 +230,7 @@ func (ah *AuthHandlers) handleLogout(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Clear cookie
-	http.SetCookie(w, &http.Cookie{
-		Name:     "session_id",
-		Value:    "",
-		Path:     "/",
-		MaxAge:   -1,
-		HttpOnly: true,
-		Secure:   r.TLS != nil,
-		SameSite: http.SameSiteStrictMode,
-	})
+	http.SetCookie(w, ah.cookies.ClearSessionCookie(r))
 
 	server.WriteJSONResponse(w, http.StatusOK, map[string]interface{}{
 		"success": true,
@@ -167,6 +238,25 @@ func (ah *AuthHandlers) handleLogout(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// handleLogoutAll revokes every session for the current user
+func (ah *AuthHandlers) handleLogoutAll(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		server.WriteErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	user := r.Context().Value(userContextKey).(*User)
+	revoked := ah.securityService.LogoutAll(user.ID)
+
+	http.SetCookie(w, ah.cookies.ClearSessionCookie(r))
+
+	server.WriteJSONResponse(w, http.StatusOK, map[string]interface{}{
+		"success":          true,
+		"message":          "Logged out from all sessions",
+		"revoked_sessions": revoked,
+	})
+}
+
 // handleMe returns current user information
 func (ah *AuthHandlers) handleMe(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -174,7 +264,7 @@ func (ah *AuthHandlers) handleMe(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	user := r.Context().Value("user").(*User)
+	user := r.Context().Value(userContextKey).(*User)
 
 	userInfo := &UserInfo{
 		ID:          user.ID,
@@ -209,7 +299,7 @@ func (ah *AuthHandlers) handlePasswordChange(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	user := r.Context().Value("user").(*User)
+	user := r.Context().Value(userContextKey).(*User)
 
 	// Support both frontend and backend request formats
 	var requestBody map[string]interface{}
@@ -237,7 +327,8 @@ func (ah *AuthHandlers) handlePasswordChange(w http.ResponseWriter, r *http.Requ
 	}
 
 	// Change password
-	err := ah.securityService.ChangePassword(user.Username, currentPassword, newPassword)
+	currentSessionID, _ := r.Context().Value(sessionContextKey).(string)
+	err := ah.securityService.ChangePassword(user.Username, currentPassword, newPassword, currentSessionID)
 	if err != nil {
 		server.WriteJSONResponse(w, http.StatusBadRequest, ChangePasswordResponse{
 			Success: false,
@@ -278,23 +369,98 @@ func (ah *AuthHandlers) handlePasswordStrength(w http.ResponseWriter, r *http.Re
 		Feedback: []string{},
 	}
 
-	if err != nil {
-		response.Feedback = strings.Split(err.Error(), "; ")
-		if len(response.Feedback) > 0 && strings.Contains(response.Feedback[0], "password strength validation failed:") {
-			response.Feedback[0] = strings.TrimPrefix(response.Feedback[0], "password strength validation failed: ")
-		}
+	var strengthErr *PasswordStrengthError
+	if errors.As(err, &strengthErr) {
+		response.Feedback = strengthErr.Feedback
 	}
 
 	server.WriteJSONResponse(w, http.StatusOK, response)
 }
 
-// handleInitialSetup handles initial admin setup
-func (ah *AuthHandlers) handleInitialSetup(w http.ResponseWriter, r *http.Request) {
+// handlePasswordRecovery issues a recovery token for a user. The token is
+// printed to the service output rather than returned, and the response is
+// the same whether or not the user exists.
+func (ah *AuthHandlers) handlePasswordRecovery(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		server.WriteErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req RecoveryTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Username == "" {
+		server.WriteErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	err := ah.securityService.IssueRecoveryToken(req.Username, getClientIP(r), r.UserAgent())
+	if errors.Is(err, ErrRateLimitExceeded) {
+		server.WriteErrorResponse(w, http.StatusTooManyRequests, "Too many attempts. Please try again later.")
+		return
+	}
+	if err != nil {
+		logging.Error("Failed to issue recovery token", logging.Err(err))
+		server.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to issue recovery token")
+		return
+	}
+
+	server.WriteJSONResponse(w, http.StatusAccepted, map[string]interface{}{
+		"success": true,
+		"message": "If the account exists, a recovery token has been written to the service log on the host",
+	})
+}
+
+// handlePasswordReset sets a new password with a recovery token
+func (ah *AuthHandlers) handlePasswordReset(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		server.WriteErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
+	var req PasswordResetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Token == "" {
+		server.WriteErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	err := ah.securityService.ResetPasswordWithToken(req.Token, req.NewPassword, getClientIP(r), r.UserAgent())
+	switch {
+	case err == nil:
+		server.WriteJSONResponse(w, http.StatusOK, ChangePasswordResponse{
+			Success: true,
+			Message: "Password reset successfully",
+		})
+	case errors.Is(err, ErrRateLimitExceeded):
+		server.WriteErrorResponse(w, http.StatusTooManyRequests, "Too many attempts. Please try again later.")
+	case errors.Is(err, ErrInvalidRecoveryToken):
+		server.WriteErrorResponse(w, http.StatusUnauthorized, err.Error())
+	default:
+		server.WriteJSONResponse(w, http.StatusBadRequest, ChangePasswordResponse{
+			Success: false,
+			Message: err.Error(),
+		})
+	}
+}
+
+// handleInitialSetup reports whether setup is needed (GET) and creates the
+// first admin (POST) while no users exist
+func (ah *AuthHandlers) handleInitialSetup(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		server.WriteJSONResponse(w, http.StatusOK, map[string]interface{}{
+			"setup_required": ah.securityService.NeedsInitialSetup(),
+		})
+		return
+	case http.MethodPost:
+	default:
+		server.WriteErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	if !ah.securityService.NeedsInitialSetup() {
+		server.WriteErrorResponse(w, http.StatusConflict, "Initial setup has already been completed")
+		return
+	}
+
 	var req struct {
 		Username string `json:"username"`
 		Password string `json:"password"`
@@ -336,7 +502,7 @@ func (ah *AuthHandlers) handleSessions(w http.ResponseWriter, r *http.Request) {
 
 // handleGetUserSessions returns all sessions for the current user
 func (ah *AuthHandlers) handleGetUserSessions(w http.ResponseWriter, r *http.Request) {
-	user := r.Context().Value("user").(*User)
+	user := r.Context().Value(userContextKey).(*User)
 	currentSessionID := ah.getCurrentSessionID(r)
 
 	sessionList, err := ah.securityService.GetUserSessionsInfo(user.ID, currentSessionID)
@@ -351,7 +517,7 @@ func (ah *AuthHandlers) handleGetUserSessions(w http.ResponseWriter, r *http.Req
 
 // handleRevokeAllUserSessions revokes all sessions for the current user except current
 func (ah *AuthHandlers) handleRevokeAllUserSessions(w http.ResponseWriter, r *http.Request) {
-	user := r.Context().Value("user").(*User)
+	user := r.Context().Value(userContextKey).(*User)
 	currentSessionID := ah.getCurrentSessionID(r)
 
 	// Get all user sessions
@@ -435,7 +601,7 @@ func (ah *AuthHandlers) handleSessionRevoke(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	user := r.Context().Value("user").(*User)
+	user := r.Context().Value(userContextKey).(*User)
 	currentSessionID := ah.getCurrentSessionID(r)
 
 	// Verify the session belongs to the current user
@@ -481,18 +647,88 @@ func (ah *AuthHandlers) handleAdminSessions(w http.ResponseWriter, r *http.Reque
 	}
 }
 
-// handleGetAllSessions returns all active sessions (admin only)
+// handleGetAllSessions returns a page of active sessions across all users
+// (admin only), most recently active first. The page is selected with the
+// limit (default 50) and offset query parameters.
 func (ah *AuthHandlers) handleGetAllSessions(w http.ResponseWriter, r *http.Request) {
-	// Get session analytics which includes session counts
-	analytics := ah.securityService.GetSessionAnalytics()
+	page, err := server.ParsePageParams(r, defaultPageSize)
+	if err != nil {
+		server.WriteErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	currentSessionID, _ := r.Context().Value(sessionContextKey).(string)
+	sessions := ah.securityService.GetAllSessions(currentSessionID)
+	server.WriteJSONResponse(w, http.StatusOK, server.Paginate(sessions, page))
+}
+
+// handleAPIKeys lists or creates API keys (admin only). New keys belong to
+// the admin creating them.
+func (ah *AuthHandlers) handleAPIKeys(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		keys, err := ah.securityService.ListAPIKeys()
+		if err != nil {
+			server.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to list API keys")
+			return
+		}
+		server.WriteJSONResponse(w, http.StatusOK, map[string]interface{}{
+			"api_keys": keys,
+		})
+	case http.MethodPost:
+		user := r.Context().Value(userContextKey).(*User)
+
+		var req APIKeyRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			server.WriteErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		if strings.TrimSpace(req.Name) == "" {
+			server.WriteErrorResponse(w, http.StatusBadRequest, "Name is required")
+			return
+		}
+
+		created, err := ah.securityService.CreateAPIKey(user.ID, req)
+		if errors.Is(err, ErrUserNotFound) {
+			server.WriteErrorResponse(w, http.StatusNotFound, err.Error())
+			return
+		}
+		if errors.Is(err, ErrAPIKeysUnavailable) {
+			server.WriteErrorResponse(w, http.StatusServiceUnavailable, err.Error())
+			return
+		}
+		if err != nil {
+			server.WriteErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		server.WriteJSONResponse(w, http.StatusCreated, created)
+	default:
+		server.WriteErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// handleAPIKey revokes an API key (admin only)
+func (ah *AuthHandlers) handleAPIKey(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		server.WriteErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/api/v1/auth/api-keys/"))
+	if err != nil {
+		server.WriteErrorResponse(w, http.StatusBadRequest, "Invalid API key ID")
+		return
+	}
+
+	if err := ah.securityService.RevokeAPIKey(id); err != nil {
+		server.WriteErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
 
-	// For now, return basic analytics data
-	// In a full implementation, this would return detailed session list
 	server.WriteJSONResponse(w, http.StatusOK, map[string]interface{}{
-		"total_sessions":   analytics.TotalSessions,
-		"active_sessions":  analytics.ActiveSessions,
-		"expired_sessions": analytics.ExpiredSessions,
-		"message":          "Detailed session listing not implemented yet",
+		"success": true,
+		"message": "API key revoked successfully",
 	})
 }
 
@@ -542,12 +778,17 @@ func (ah *AuthHandlers) handleUsers(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// handleGetUsers returns list of users (admin only)
+// handleGetUsers returns a page of users (admin only). The page is selected
+// with the limit (default 50) and offset query parameters.
 func (ah *AuthHandlers) handleGetUsers(w http.ResponseWriter, r *http.Request) {
-	// Return placeholder for now
-	server.WriteJSONResponse(w, http.StatusOK, map[string]interface{}{
-		"users": []interface{}{},
-	})
+	page, err := server.ParsePageParams(r, defaultPageSize)
+	if err != nil {
+		server.WriteErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	users, total := ah.securityService.ListUsers(page.Offset, page.Limit)
+	server.WriteJSONResponse(w, http.StatusOK, server.NewPagedResponse(users, total, page))
 }
 
 // handleCreateUser creates a new user (admin only)
@@ -558,13 +799,140 @@ func (ah *AuthHandlers) handleCreateUser(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Placeholder - would implement user creation
+	req.Username = strings.TrimSpace(req.Username)
+	req.Email = strings.TrimSpace(req.Email)
+	switch {
+	case req.Username == "":
+		server.WriteErrorResponse(w, http.StatusBadRequest, "Username is required")
+		return
+	case req.Password == "":
+		server.WriteErrorResponse(w, http.StatusBadRequest, "Password is required")
+		return
+	case req.Email == "":
+		server.WriteErrorResponse(w, http.StatusBadRequest, "Email is required")
+		return
+	}
+	if _, err := mail.ParseAddress(req.Email); err != nil {
+		server.WriteErrorResponse(w, http.StatusBadRequest, "Invalid email address")
+		return
+	}
+
+	user, err := ah.securityService.CreateUser(req)
+	if err != nil {
+		var strengthErr *PasswordStrengthError
+		switch {
+		case errors.Is(err, ErrUserExists):
+			server.WriteErrorResponse(w, http.StatusConflict, "Username already exists")
+		case errors.As(err, &strengthErr):
+			server.WriteJSONResponse(w, http.StatusBadRequest, map[string]interface{}{
+				"success":  false,
+				"message":  "Password does not meet requirements",
+				"feedback": strengthErr.Feedback,
+			})
+		default:
+			logging.Error("Failed to create user", logging.Err(err))
+			server.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to create user")
+		}
+		return
+	}
+
+	server.WriteJSONResponse(w, http.StatusCreated, map[string]interface{}{
+		"success": true,
+		"message": "User created successfully",
+		"user":    user,
+	})
+}
+
+// handleUser handles /api/v1/auth/users/{id}: DELETE removes the user and
+// PATCH updates is_active; POST /api/v1/auth/users/{id}/unlock lifts a
+// lockout (admin only)
+func (ah *AuthHandlers) handleUser(w http.ResponseWriter, r *http.Request) {
+	idPart, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/v1/auth/users/"), "/")
+	id, err := strconv.Atoi(idPart)
+	if err != nil {
+		server.WriteErrorResponse(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	switch action {
+	case "":
+	case "unlock":
+		ah.handleUnlockUser(w, r, id)
+		return
+	default:
+		server.WriteErrorResponse(w, http.StatusNotFound, "Not found")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodDelete:
+		if err := ah.securityService.DeleteUser(id); err != nil {
+			ah.writeUserUpdateError(w, err)
+			return
+		}
+		server.WriteJSONResponse(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"message": "User deleted successfully",
+		})
+	case http.MethodPatch:
+		var req AdminUserUpdateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			server.WriteErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		if req.IsActive == nil {
+			server.WriteErrorResponse(w, http.StatusBadRequest, "is_active is required")
+			return
+		}
+
+		user, err := ah.securityService.SetUserActive(id, *req.IsActive)
+		if err != nil {
+			ah.writeUserUpdateError(w, err)
+			return
+		}
+		server.WriteJSONResponse(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"message": "User updated successfully",
+			"user":    user,
+		})
+	default:
+		server.WriteErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// handleUnlockUser lifts a user's lockout (admin only)
+func (ah *AuthHandlers) handleUnlockUser(w http.ResponseWriter, r *http.Request, id int) {
+	if r.Method != http.MethodPost {
+		server.WriteErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	admin := r.Context().Value(userContextKey).(*User)
+	user, err := ah.securityService.UnlockUser(id, admin.Username, getClientIP(r), r.UserAgent())
+	if err != nil {
+		ah.writeUserUpdateError(w, err)
+		return
+	}
 	server.WriteJSONResponse(w, http.StatusOK, map[string]interface{}{
 		"success": true,
-		"message": "User creation not yet implemented",
+		"message": "User unlocked successfully",
+		"user":    user,
 	})
 }
 
+// writeUserUpdateError maps errors from deleting or updating a user to a response
+func (ah *AuthHandlers) writeUserUpdateError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrUserNotFound):
+		server.WriteErrorResponse(w, http.StatusNotFound, "User not found")
+	case errors.Is(err, ErrLastAdmin):
+		server.WriteErrorResponse(w, http.StatusConflict, "Cannot delete or deactivate the last active admin account")
+	default:
+		logging.Error("Failed to update user", logging.Err(err))
+		server.WriteErrorResponse(w, http.StatusInternalServerError, "Failed to update user")
+	}
+}
+
 // handleSecurityStats returns security statistics (admin only)
 func (ah *AuthHandlers) handleSecurityStats(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -576,10 +944,29 @@ func (ah *AuthHandlers) handleSecurityStats(w http.ResponseWriter, r *http.Reque
 	server.WriteJSONResponse(w, http.StatusOK, stats)
 }
 
-// AuthenticationMiddleware validates session and adds user to context
+// AuthenticationMiddleware validates session and adds user to context.
+// Requests with an "Authorization: Bearer pc_..." header are authenticated
+// with that API key instead, without a session.
 func (ah *AuthHandlers) AuthenticationMiddleware() server.Middleware {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "); IsAPIKey(token) {
+				user, apiKey, err := ah.securityService.AuthenticateAPIKey(token, getClientIP(r), r.UserAgent())
+				if err != nil {
+					server.WriteErrorResponse(w, http.StatusUnauthorized, "Invalid or expired API key")
+					return
+				}
+				if !apiKey.Allows(r.Method) {
+					server.WriteErrorResponse(w, http.StatusForbidden, "API key is read-only")
+					return
+				}
+
+				ctx := context.WithValue(r.Context(), userContextKey, user)
+				ctx = context.WithValue(ctx, apiKeyContextKey, apiKey)
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
 			// Get session ID from cookie
 			cookie, err := r.Cookie("session_id")
 			if err != nil {
@@ -594,9 +981,13 @@ func (ah *AuthHandlers) AuthenticationMiddleware() server.Middleware {
 				return
 			}
 
+			// Track where the session is being used from
+			ah.securityService.RecordSessionActivity(cookie.Value, getClientIP(r), r.UserAgent())
+
 			// Add user to context
 			ctx := r.Context()
 			ctx = context.WithValue(ctx, userContextKey, user)
+			ctx = context.WithValue(ctx, sessionContextKey, cookie.Value)
 			r = r.WithContext(ctx)
 
 			next.ServeHTTP(w, r)
@@ -636,11 +1027,7 @@ func getClientIP(r *http.Request) string {
 	}
 
 	// Use remote address
-	ip := r.RemoteAddr
-	if colon := strings.LastIndex(ip, ":"); colon != -1 {
-		ip = ip[:colon]
-	}
-	return ip
+	return server.RemoteHost(r.RemoteAddr)
 }
 
 // calculatePasswordScore calculates a simple password strength score (0-100)
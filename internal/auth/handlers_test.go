@@ -0,0 +1,719 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"parental-control/internal/database"
+	"parental-control/internal/server"
+)
+
+func TestAuthHandlers_InitialSetupWithoutUsers(t *testing.T) {
+	service := NewSecurityService(testAuthConfig())
+	handlers := NewAuthHandlers(service)
+
+	setup := func(method, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/v1/auth/setup", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		handlers.handleInitialSetup(rec, req)
+		return rec
+	}
+	setupRequired := func() bool {
+		rec := setup(http.MethodGet, "")
+		var status struct {
+			SetupRequired bool `json:"setup_required"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&status); err != nil {
+			t.Fatalf("Failed to decode setup status: %v", err)
+		}
+		return status.SetupRequired
+	}
+
+	// Auth is enabled but no admin password seeded the first user
+	if !setupRequired() {
+		t.Fatal("Expected setup to be required with no users")
+	}
+
+	if rec := setup(http.MethodPost, `{"username":"parent","password":"weak"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a weak password, got %d", rec.Code)
+	}
+	if !setupRequired() {
+		t.Fatal("Expected setup to still be required after a rejected attempt")
+	}
+
+	body := `{"username":"parent","password":"SetupPassword123!","email":"parent@example.com"}`
+	if rec := setup(http.MethodPost, body); rec.Code != http.StatusOK {
+		t.Fatalf("Expected setup to succeed, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if setupRequired() {
+		t.Error("Expected setup to be complete once an admin exists")
+	}
+	if rec := setup(http.MethodPost, body); rec.Code != http.StatusConflict {
+		t.Errorf("Expected 409 for a second setup, got %d", rec.Code)
+	}
+
+	if _, err := service.Authenticate("parent", "SetupPassword123!", "192.168.1.1", "test-agent"); err != nil {
+		t.Fatalf("Failed to authenticate as the new admin: %v", err)
+	}
+}
+
+func TestAuthHandlers_AdminUsers(t *testing.T) {
+	service := NewSecurityService(testAuthConfig())
+	handlers := NewAuthHandlers(service)
+	if err := service.CreateInitialAdmin("admin", "AdminPassword123!", "admin@example.com"); err != nil {
+		t.Fatalf("Failed to create admin: %v", err)
+	}
+
+	create := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/users", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		handlers.handleUsers(rec, req)
+		return rec
+	}
+	list := func(query string) (*httptest.ResponseRecorder, server.PagedResponse[UserInfo]) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/auth/users"+query, nil)
+		rec := httptest.NewRecorder()
+		handlers.handleUsers(rec, req)
+		var page server.PagedResponse[UserInfo]
+		if rec.Code == http.StatusOK {
+			if err := json.NewDecoder(rec.Body).Decode(&page); err != nil {
+				t.Fatalf("Failed to decode user list: %v", err)
+			}
+		}
+		return rec, page
+	}
+
+	rec := create(`{"username":"child","email":"child@example.com","password":"weak"}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("Expected 400 for a weak password, got %d", rec.Code)
+	}
+	var weak struct {
+		Feedback []string `json:"feedback"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&weak); err != nil || len(weak.Feedback) == 0 {
+		t.Errorf("Expected strength feedback, got %v (%v)", weak.Feedback, err)
+	}
+
+	if rec := create(`{"username":"child","email":"not-an-email","password":"ChildPassword123!"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid email, got %d", rec.Code)
+	}
+
+	rec = create(`{"username":"child","email":"child@example.com","password":"ChildPassword123!"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if strings.Contains(rec.Body.String(), "$2a$") {
+		t.Error("Response must not include the password hash")
+	}
+	if rec := create(`{"username":"child","email":"other@example.com","password":"ChildPassword123!"}`); rec.Code != http.StatusConflict {
+		t.Errorf("Expected 409 for a duplicate username, got %d", rec.Code)
+	}
+	if rec := create(`{"username":"parent2","email":"parent2@example.com","password":"ParentPassword123!","is_admin":true}`); rec.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	_, page := list("")
+	if page.Total != 3 || len(page.Items) != 3 {
+		t.Fatalf("Expected 3 users, got %d of %d", len(page.Items), page.Total)
+	}
+	if page.Items[1].Username != "child" || page.Items[1].IsAdmin || !page.Items[1].IsActive {
+		t.Errorf("Unexpected child user: %+v", page.Items[1])
+	}
+	if !page.Items[2].IsAdmin {
+		t.Errorf("Expected parent2 to be an admin: %+v", page.Items[2])
+	}
+
+	_, page = list("?limit=1&offset=2")
+	if page.Total != 3 || len(page.Items) != 1 || page.Items[0].Username != "parent2" {
+		t.Errorf("Unexpected page: %+v", page)
+	}
+	_, page = list("?offset=10")
+	if len(page.Items) != 0 {
+		t.Errorf("Expected an empty page past the end, got %d users", len(page.Items))
+	}
+	if rec, _ := list("?limit=0"); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid limit, got %d", rec.Code)
+	}
+
+	if _, err := service.Authenticate("child", "ChildPassword123!", "192.168.1.1", "test-agent"); err != nil {
+		t.Errorf("Failed to authenticate as the new user: %v", err)
+	}
+}
+
+func TestAuthHandlers_DeleteAndDeactivateUsers(t *testing.T) {
+	service := NewSecurityService(testAuthConfig())
+	handlers := NewAuthHandlers(service)
+	if err := service.CreateInitialAdmin("admin", "AdminPassword123!", "admin@example.com"); err != nil {
+		t.Fatalf("Failed to create admin: %v", err)
+	}
+	child, err := service.CreateUser(AdminUserRequest{Username: "child", Email: "child@example.com", Password: "ChildPassword123!"})
+	if err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	user := func(method string, id int, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, fmt.Sprintf("/api/v1/auth/users/%d", id), strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		handlers.handleUser(rec, req)
+		return rec
+	}
+
+	// The only admin can be neither deactivated nor deleted
+	if rec := user(http.MethodPatch, 1, `{"is_active":false}`); rec.Code != http.StatusConflict {
+		t.Errorf("Expected 409 deactivating the last admin, got %d", rec.Code)
+	}
+	if rec := user(http.MethodDelete, 1, ""); rec.Code != http.StatusConflict {
+		t.Errorf("Expected 409 deleting the last admin, got %d", rec.Code)
+	}
+	if rec := user(http.MethodPatch, child.ID, `{}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 without is_active, got %d", rec.Code)
+	}
+	if rec := user(http.MethodDelete, 99, ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown user, got %d", rec.Code)
+	}
+
+	// Deactivating ends the user's sessions and blocks logins
+	login, err := service.Authenticate("child", "ChildPassword123!", "192.168.1.1", "test-agent")
+	if err != nil || !login.Success {
+		t.Fatalf("Failed to authenticate: %v", err)
+	}
+	if rec := user(http.MethodPatch, child.ID, `{"is_active":false}`); rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200 deactivating a user, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if _, err := service.ValidateSession(login.SessionID); err == nil {
+		t.Error("Expected the session to be revoked on deactivation")
+	}
+	if response, _ := service.Authenticate("child", "ChildPassword123!", "192.168.1.1", "test-agent"); response.Success {
+		t.Error("Expected a deactivated user to be unable to log in")
+	}
+	if rec := user(http.MethodPatch, child.ID, `{"is_active":true}`); rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200 reactivating a user, got %d", rec.Code)
+	}
+
+	// Deleting revokes sessions and removes the user
+	login, err = service.Authenticate("child", "ChildPassword123!", "192.168.1.1", "test-agent")
+	if err != nil || !login.Success {
+		t.Fatalf("Failed to authenticate after reactivation: %v", err)
+	}
+	if rec := user(http.MethodDelete, child.ID, ""); rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200 deleting a user, got %d", rec.Code)
+	}
+	if _, err := service.ValidateSession(login.SessionID); err == nil {
+		t.Error("Expected the session to be revoked on deletion")
+	}
+	if _, total := service.ListUsers(0, 0); total != 1 {
+		t.Errorf("Expected 1 user left, got %d", total)
+	}
+
+	// With a second active admin the first can be deactivated
+	if _, err := service.CreateUser(AdminUserRequest{Username: "parent2", Email: "parent2@example.com", Password: "ParentPassword123!", IsAdmin: true}); err != nil {
+		t.Fatalf("Failed to create admin: %v", err)
+	}
+	if rec := user(http.MethodPatch, 1, `{"is_active":false}`); rec.Code != http.StatusOK {
+		t.Errorf("Expected 200 deactivating one of two admins, got %d", rec.Code)
+	}
+}
+
+func TestAuthHandlers_UnlockUser(t *testing.T) {
+	service := NewSecurityService(testAuthConfig())
+	handlers := NewAuthHandlers(service)
+	if err := service.CreateInitialAdmin("admin", "AdminPassword123!", "admin@example.com"); err != nil {
+		t.Fatalf("Failed to create admin: %v", err)
+	}
+	child, err := service.CreateUser(AdminUserRequest{Username: "child", Email: "child@example.com", Password: "ChildPassword123!"})
+	if err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	admin := service.users["admin"]
+
+	for i := 0; i < service.config.MaxFailedAttempts; i++ {
+		service.Authenticate("child", "wrong-password", "10.0.0.5", "test-agent")
+	}
+	if !service.users["child"].IsLocked() {
+		t.Fatal("Expected the user to be locked")
+	}
+
+	unlock := func(method string, id int) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, fmt.Sprintf("/api/v1/auth/users/%d/unlock", id), nil)
+		req.RemoteAddr = "192.168.1.10:1234"
+		req = req.WithContext(context.WithValue(req.Context(), userContextKey, admin))
+		rec := httptest.NewRecorder()
+		handlers.handleUser(rec, req)
+		return rec
+	}
+
+	if rec := unlock(http.MethodGet, child.ID); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405 for GET, got %d", rec.Code)
+	}
+	if rec := unlock(http.MethodPost, 99); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown user, got %d", rec.Code)
+	}
+	if rec := unlock(http.MethodPost, child.ID); rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200 unlocking a user, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	locked := service.users["child"]
+	if locked.IsLocked() || locked.FailedAttempts != 0 {
+		t.Errorf("Expected the lockout cleared, got locked_until=%v attempts=%d", locked.LockedUntil, locked.FailedAttempts)
+	}
+	if !hasEventType(service.securityEvents, EventTypeAccountUnlocked) {
+		t.Error("Expected an account_unlocked event")
+	}
+	if response, _ := service.Authenticate("child", "ChildPassword123!", "10.0.0.5", "test-agent"); !response.Success {
+		t.Error("Expected the user to log in after unlocking")
+	}
+}
+
+func TestAuthHandlers_GetAllSessions(t *testing.T) {
+	service := NewSecurityService(testAuthConfig())
+	handlers := NewAuthHandlers(service)
+	if err := service.CreateInitialAdmin("admin", "AdminPassword123!", "admin@example.com"); err != nil {
+		t.Fatalf("Failed to create admin: %v", err)
+	}
+	if _, err := service.CreateUser(AdminUserRequest{Username: "child", Email: "child@example.com", Password: "ChildPassword123!"}); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	admin, err := service.Authenticate("admin", "AdminPassword123!", "192.168.1.1", "admin-agent")
+	if err != nil || !admin.Success {
+		t.Fatalf("Failed to authenticate admin: %v", err)
+	}
+	child, err := service.Authenticate("child", "ChildPassword123!", "192.168.1.2", "child-agent")
+	if err != nil || !child.Success {
+		t.Fatalf("Failed to authenticate child: %v", err)
+	}
+	service.RecordSessionActivity(child.SessionID, "10.0.0.7", "child-agent")
+
+	list := func(query string) (*httptest.ResponseRecorder, server.PagedResponse[SessionInfo]) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/auth/sessions/admin"+query, nil)
+		req = req.WithContext(context.WithValue(req.Context(), sessionContextKey, admin.SessionID))
+		rec := httptest.NewRecorder()
+		handlers.handleAdminSessions(rec, req)
+		var page server.PagedResponse[SessionInfo]
+		if rec.Code == http.StatusOK {
+			if err := json.NewDecoder(rec.Body).Decode(&page); err != nil {
+				t.Fatalf("Failed to decode session list: %v", err)
+			}
+		}
+		return rec, page
+	}
+
+	rec, page := list("")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if page.Total != 2 || len(page.Items) != 2 {
+		t.Fatalf("Expected 2 sessions, got %d of %d", len(page.Items), page.Total)
+	}
+
+	sessions := make(map[string]SessionInfo)
+	for _, session := range page.Items {
+		sessions[session.Username] = session
+	}
+	if s := sessions["admin"]; s.ID != admin.SessionID || !s.IsCurrent || s.IPChanged || s.UserAgent != "admin-agent" {
+		t.Errorf("Unexpected admin session: %+v", s)
+	}
+	if s := sessions["child"]; s.ID != child.SessionID || s.IsCurrent || !s.IPChanged || s.IPAddress != "10.0.0.7" || len(s.IPAddresses) != 2 {
+		t.Errorf("Expected the child session to be flagged for an IP change: %+v", s)
+	}
+	if page.Items[0].Username != "child" {
+		t.Errorf("Expected the most recently active session first, got %s", page.Items[0].Username)
+	}
+
+	if _, page := list("?limit=1&offset=1"); len(page.Items) != 1 || page.Total != 2 || page.Items[0].Username != "admin" {
+		t.Errorf("Expected the second page to hold the admin session, got %+v", page)
+	}
+	if _, page := list("?offset=5"); len(page.Items) != 0 || page.Total != 2 {
+		t.Errorf("Expected an empty page past the end, got %+v", page)
+	}
+	if rec, _ := list("?limit=0"); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid limit, got %d", rec.Code)
+	}
+}
+
+func TestAuthHandlers_TwoFactorLogin(t *testing.T) {
+	config := testAuthConfig()
+	config.SessionSecret = "a-session-secret-that-is-long-enough"
+	service := NewSecurityService(config)
+	handlers := NewAuthHandlers(service)
+	if err := service.CreateInitialAdmin("admin", "AdminPassword123!", "admin@example.com"); err != nil {
+		t.Fatalf("Failed to create admin: %v", err)
+	}
+	admin := service.users["admin"]
+
+	post := func(handler http.HandlerFunc, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req = req.WithContext(context.WithValue(req.Context(), userContextKey, admin))
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		return rec
+	}
+	login := func(body string) (*httptest.ResponseRecorder, LoginResponse) {
+		rec := post(handlers.handleLogin, body)
+		var response LoginResponse
+		if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode login response: %v", err)
+		}
+		return rec, response
+	}
+
+	if rec := post(handlers.handleTwoFactorVerify, `{"code":"123456"}`); rec.Code != http.StatusConflict {
+		t.Errorf("Expected 409 verifying before enrolling, got %d", rec.Code)
+	}
+
+	rec := post(handlers.handleTwoFactorEnroll, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200 enrolling, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var enrollment TwoFactorEnrollResponse
+	if err := json.NewDecoder(rec.Body).Decode(&enrollment); err != nil {
+		t.Fatalf("Failed to decode enrollment: %v", err)
+	}
+	if !strings.HasPrefix(enrollment.OTPAuthURI, "otpauth://totp/") || enrollment.QRPayload != enrollment.OTPAuthURI {
+		t.Errorf("Unexpected enrollment: %+v", enrollment)
+	}
+	if strings.Contains(admin.PendingTOTPSecret, enrollment.Secret) {
+		t.Error("Expected the stored secret to be encrypted")
+	}
+	secret, err := totpEncoding.DecodeString(enrollment.Secret)
+	if err != nil {
+		t.Fatalf("Failed to decode secret: %v", err)
+	}
+
+	// Logins are unaffected until a code is verified
+	if _, response := login(`{"username":"admin","password":"AdminPassword123!"}`); !response.Success {
+		t.Fatalf("Expected login without a code before verification: %+v", response)
+	}
+
+	step := totpStep(time.Now())
+	if rec := post(handlers.handleTwoFactorVerify, `{"code":"000000x"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a wrong code, got %d", rec.Code)
+	}
+	if rec := post(handlers.handleTwoFactorVerify, fmt.Sprintf(`{"code":%q}`, totpCode(secret, step))); rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200 verifying, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if !admin.TwoFactorEnabled || !admin.Info().TwoFactorEnabled {
+		t.Fatal("Expected two-factor authentication to be enabled")
+	}
+	if rec := post(handlers.handleTwoFactorEnroll, ""); rec.Code != http.StatusConflict {
+		t.Errorf("Expected 409 enrolling again, got %d", rec.Code)
+	}
+
+	// The password alone now leaves the login pending without a cookie
+	rec, pending := login(`{"username":"admin","password":"AdminPassword123!"}`)
+	if pending.Success || !pending.TwoFactorRequired || pending.ChallengeToken == "" || pending.SessionID != "" {
+		t.Fatalf("Expected a pending two-factor login: %+v", pending)
+	}
+	if len(rec.Result().Cookies()) != 0 {
+		t.Error("Expected no session cookie before the code is entered")
+	}
+
+	// The code used for verification cannot be replayed
+	if _, response := login(fmt.Sprintf(`{"challenge_token":%q,"totp_code":%q}`, pending.ChallengeToken, totpCode(secret, step))); response.Success {
+		t.Error("Expected a used code to be rejected")
+	}
+	if _, response := login(`{"challenge_token":"unknown","totp_code":"123456"}`); response.Success {
+		t.Error("Expected an unknown challenge to be rejected")
+	}
+
+	rec, response := login(fmt.Sprintf(`{"challenge_token":%q,"totp_code":%q}`, pending.ChallengeToken, totpCode(secret, step+1)))
+	if !response.Success || response.SessionID == "" {
+		t.Fatalf("Expected the login to complete: %+v", response)
+	}
+	if len(rec.Result().Cookies()) != 1 {
+		t.Error("Expected a session cookie once the code is entered")
+	}
+	if _, response := login(fmt.Sprintf(`{"challenge_token":%q,"totp_code":%q}`, pending.ChallengeToken, totpCode(secret, step+1))); response.Success {
+		t.Error("Expected the challenge to be single use")
+	}
+}
+
+func TestAuthHandlers_ServedLoginRequiresTOTP(t *testing.T) {
+	config := testAuthConfig()
+	config.SessionSecret = "a-session-secret-that-is-long-enough"
+	service := NewSecurityService(config)
+	if err := service.CreateInitialAdmin("admin", "AdminPassword123!", "admin@example.com"); err != nil {
+		t.Fatalf("Failed to create admin: %v", err)
+	}
+	admin := service.users["admin"]
+
+	enrollment, err := service.EnrollTwoFactor(admin.ID)
+	if err != nil {
+		t.Fatalf("Failed to enroll: %v", err)
+	}
+	secret, err := totpEncoding.DecodeString(enrollment.Secret)
+	if err != nil {
+		t.Fatalf("Failed to decode secret: %v", err)
+	}
+	step := totpStep(time.Now())
+	if err := service.VerifyTwoFactor(admin.ID, totpCode(secret, step)); err != nil {
+		t.Fatalf("Failed to verify: %v", err)
+	}
+
+	srv := server.New(server.DefaultConfig())
+	NewAuthHandlers(service).RegisterRoutes(srv)
+	login := func(body string) (*httptest.ResponseRecorder, LoginResponse) {
+		rec := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", strings.NewReader(body)))
+		var response LoginResponse
+		if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode login response: %v", err)
+		}
+		return rec, response
+	}
+	noSession := func(rec *httptest.ResponseRecorder, response LoginResponse) bool {
+		return !response.Success && response.SessionID == "" && response.Token == "" && len(rec.Result().Cookies()) == 0
+	}
+
+	rec, pending := login(`{"username":"admin","password":"AdminPassword123!"}`)
+	if !noSession(rec, pending) || !pending.TwoFactorRequired {
+		t.Fatalf("Expected the password alone to leave the login pending: %+v", pending)
+	}
+	if rec, response := login(`{"username":"admin","password":"AdminPassword123!","totp_code":"123456"}`); !noSession(rec, response) {
+		t.Errorf("Expected a code without the challenge to be ignored: %+v", response)
+	}
+	if rec, response := login(fmt.Sprintf(`{"challenge_token":%q,"totp_code":"000000"}`, pending.ChallengeToken)); !noSession(rec, response) {
+		t.Errorf("Expected a wrong code to be rejected: %+v", response)
+	}
+
+	rec, response := login(fmt.Sprintf(`{"challenge_token":%q,"totp_code":%q}`, pending.ChallengeToken, totpCode(secret, step+1)))
+	if !response.Success || len(rec.Result().Cookies()) != 1 {
+		t.Fatalf("Expected a session once the code is entered: %+v", response)
+	}
+}
+
+func TestAuthHandlers_ConfiguredSecurityHeaders(t *testing.T) {
+	config := server.DefaultConfig()
+	config.SecurityHeaders.ContentSecurityPolicy = "default-src 'none'"
+	config.SecurityHeaders.FrameOptions = "SAMEORIGIN"
+	srv := server.New(config)
+	NewAuthHandlers(NewSecurityService(testAuthConfig())).RegisterRoutes(srv)
+
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/auth/password/strength", strings.NewReader(`{"password":"x"}`)))
+
+	// The auth routes must not put the default headers back
+	if csp := rec.Header().Get("Content-Security-Policy"); csp != "default-src 'none'" {
+		t.Errorf("Expected the configured CSP, got %q", csp)
+	}
+	if frame := rec.Header().Get("X-Frame-Options"); frame != "SAMEORIGIN" {
+		t.Errorf("Expected the configured frame options, got %q", frame)
+	}
+}
+
+func TestAuthHandlers_APIKeys(t *testing.T) {
+	service := NewSecurityService(testAuthConfig())
+	handlers := NewAuthHandlers(service)
+	if err := service.CreateInitialAdmin("admin", "AdminPassword123!", "admin@example.com"); err != nil {
+		t.Fatalf("Failed to create admin: %v", err)
+	}
+	admin := service.users["admin"]
+	db := newTestDatabase(t)
+	store := database.NewAPIKeyRepository(db.Connection())
+
+	create := func(body string) (*httptest.ResponseRecorder, APIKeyCreateResponse) {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/api-keys", strings.NewReader(body))
+		req = req.WithContext(context.WithValue(req.Context(), userContextKey, admin))
+		rec := httptest.NewRecorder()
+		handlers.handleAPIKeys(rec, req)
+		var created APIKeyCreateResponse
+		if rec.Code == http.StatusCreated {
+			if err := json.NewDecoder(rec.Body).Decode(&created); err != nil {
+				t.Fatalf("Failed to decode API key: %v", err)
+			}
+		}
+		return rec, created
+	}
+
+	protected := handlers.AuthenticationMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := r.Context().Value(userContextKey).(*User)
+		server.WriteJSONResponse(w, http.StatusOK, map[string]string{"username": user.Username})
+	}))
+	call := func(method, key string) int {
+		req := httptest.NewRequest(method, "/api/v1/rules", nil)
+		req.Header.Set("Authorization", "Bearer "+key)
+		rec := httptest.NewRecorder()
+		protected.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if rec, _ := create(`{"name":"ci"}`); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 without a store for the keys, got %d", rec.Code)
+	}
+	service.SetAPIKeyStore(store)
+
+	if rec, _ := create(`{"name":"ci","scope":"owner"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown scope, got %d", rec.Code)
+	}
+	if rec, _ := create(`{"name":"ci","expires_at":"2000-01-01T00:00:00Z"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an expiry in the past, got %d", rec.Code)
+	}
+
+	rec, readOnly := create(`{"name":"dashboard"}`)
+	if rec.Code != http.StatusCreated || !strings.HasPrefix(readOnly.Key, "pc_") || readOnly.APIKey.Scope != APIKeyScopeReadOnly {
+		t.Fatalf("Expected a read-only key, got %d: %+v", rec.Code, readOnly)
+	}
+	if strings.Contains(rec.Body.String(), hashAPIKey(readOnly.Key)) || !strings.HasPrefix(readOnly.Key, readOnly.APIKey.Prefix) {
+		t.Error("Expected only the key and its prefix to be returned")
+	}
+	_, adminKey := create(`{"name":"ci","scope":"admin"}`)
+
+	if code := call(http.MethodGet, readOnly.Key); code != http.StatusOK {
+		t.Errorf("Expected a read-only key to allow GET, got %d", code)
+	}
+	if code := call(http.MethodPost, readOnly.Key); code != http.StatusForbidden {
+		t.Errorf("Expected a read-only key to reject POST, got %d", code)
+	}
+	if code := call(http.MethodPost, adminKey.Key); code != http.StatusOK {
+		t.Errorf("Expected an admin key to allow POST, got %d", code)
+	}
+	if code := call(http.MethodGet, "pc_unknown"); code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for an unknown key, got %d", code)
+	}
+
+	keys, err := service.ListAPIKeys()
+	if err != nil || len(keys) != 2 || keys[0].LastUsedAt == nil {
+		t.Errorf("Expected 2 keys with usage recorded, got %+v (%v)", keys, err)
+	}
+
+	// Only the hash is stored, and the keys outlive a restart
+	var storedKey int
+	if err := db.Connection().QueryRow(`SELECT COUNT(*) FROM api_keys WHERE key_hash = ? OR prefix = ?`,
+		readOnly.Key, readOnly.Key).Scan(&storedKey); err != nil || storedKey != 0 {
+		t.Errorf("Expected the plain key not to be stored, found %d (%v)", storedKey, err)
+	}
+	restarted := NewSecurityService(testAuthConfig())
+	if err := restarted.CreateInitialAdmin("admin", "AdminPassword123!", "admin@example.com"); err != nil {
+		t.Fatalf("Failed to create admin: %v", err)
+	}
+	restarted.SetAPIKeyStore(store)
+	if user, apiKey, err := restarted.AuthenticateAPIKey(adminKey.Key, "192.168.1.1", "test-agent"); err != nil ||
+		user.Username != "admin" || apiKey.Scope != APIKeyScopeAdmin {
+		t.Errorf("Expected the admin key to work after a restart, got %v", err)
+	}
+	service.historyMu.Lock()
+	used := hasEventType(service.securityEvents, EventTypeAPIKeyUsed)
+	service.historyMu.Unlock()
+	if !used {
+		t.Error("Expected API key use to be logged as a security event")
+	}
+
+	req := httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/api/v1/auth/api-keys/%d", readOnly.APIKey.ID), nil)
+	rec = httptest.NewRecorder()
+	handlers.handleAPIKey(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200 revoking a key, got %d", rec.Code)
+	}
+	if code := call(http.MethodGet, readOnly.Key); code != http.StatusUnauthorized {
+		t.Errorf("Expected a revoked key to be rejected, got %d", code)
+	}
+	if err := service.RevokeAPIKey(99); err != ErrAPIKeyNotFound {
+		t.Errorf("Expected ErrAPIKeyNotFound, got %v", err)
+	}
+
+	// Keys stop working when their user is deactivated
+	if _, err := service.CreateUser(AdminUserRequest{Username: "parent2", Email: "parent2@example.com", Password: "ParentPassword123!", IsAdmin: true}); err != nil {
+		t.Fatalf("Failed to create admin: %v", err)
+	}
+	if _, err := service.SetUserActive(admin.ID, false); err != nil {
+		t.Fatalf("Failed to deactivate admin: %v", err)
+	}
+	if code := call(http.MethodGet, adminKey.Key); code != http.StatusUnauthorized {
+		t.Errorf("Expected a deactivated user's key to be rejected, got %d", code)
+	}
+}
+
+func hasEventType(events []SecurityEvent, eventType string) bool {
+	for _, event := range events {
+		if event.EventType == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func TestAuthHandlers_PasswordRecovery(t *testing.T) {
+	config := testAuthConfig()
+	config.LoginRateLimit = 100
+	config.RecoveryMaxAttempts = 2
+	service := NewSecurityService(config)
+	handlers := NewAuthHandlers(service)
+	if err := service.CreateInitialAdmin("admin", "AdminPassword123!", "admin@example.com"); err != nil {
+		t.Fatalf("Failed to create admin: %v", err)
+	}
+	var output strings.Builder
+	service.recoveryOutput = &output
+
+	post := func(handler http.HandlerFunc, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		return rec
+	}
+	issue := func() string {
+		output.Reset()
+		if rec := post(handlers.handlePasswordRecovery, `{"username":"admin"}`); rec.Code != http.StatusAccepted {
+			t.Fatalf("Expected 202 requesting a token, got %d: %s", rec.Code, rec.Body.String())
+		}
+		fields := strings.Fields(output.String())
+		if len(fields) == 0 {
+			t.Fatal("Expected the token to be printed")
+		}
+		return fields[len(fields)-1]
+	}
+	reset := func(token, password string) *httptest.ResponseRecorder {
+		return post(handlers.handlePasswordReset, fmt.Sprintf(`{"token":%q,"new_password":%q}`, token, password))
+	}
+
+	// Unknown users get the same response and no token
+	if rec := post(handlers.handlePasswordRecovery, `{"username":"nobody"}`); rec.Code != http.StatusAccepted || output.Len() != 0 {
+		t.Errorf("Expected 202 and no token for an unknown user, got %d with %q", rec.Code, output.String())
+	}
+
+	token := issue()
+	if strings.Contains(fmt.Sprintf("%+v", *service.recoveryToken), token) {
+		t.Error("Expected only the hash of the token to be stored")
+	}
+	if rec := reset("wrong", "NewPassword123!"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for a wrong token, got %d", rec.Code)
+	}
+	if rec := reset(token, "weak"); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a weak password, got %d", rec.Code)
+	}
+	if rec := reset(token, "NewPassword123!"); rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200 resetting, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if response, _ := service.Authenticate("admin", "NewPassword123!", "127.0.0.1", "test"); !response.Success {
+		t.Errorf("Expected login with the new password: %+v", response)
+	}
+	if rec := reset(token, "OtherPassword123!"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 reusing a token, got %d", rec.Code)
+	}
+
+	// Too many wrong guesses invalidate the token
+	token = issue()
+	reset("wrong", "OtherPassword123!")
+	reset("wrong", "OtherPassword123!")
+	if rec := reset(token, "OtherPassword123!"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 after too many attempts, got %d", rec.Code)
+	}
+
+	// Expired tokens are rejected
+	token = issue()
+	service.recoveryToken.expiresAt = time.Now().Add(-time.Second)
+	if rec := reset(token, "OtherPassword123!"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for an expired token, got %d", rec.Code)
+	}
+
+	for _, eventType := range []string{EventTypeRecoveryIssued, EventTypeRecoveryFailed, EventTypePasswordReset} {
+		if !hasEventType(service.securityEvents, eventType) {
+			t.Errorf("Expected a %s event", eventType)
+		}
+	}
+}
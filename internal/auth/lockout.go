@@ -0,0 +1,409 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"net/smtp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"parental-control/internal/logging"
+	"parental-control/internal/models"
+)
+
+// LockoutNotifyConfig selects where account lockout alerts are sent
+type LockoutNotifyConfig struct {
+	// WebhookURL receives a JSON POST for every lockout
+	WebhookURL string `json:"webhook_url" yaml:"webhook_url"`
+
+	// EmailTo recipients of lockout alerts
+	EmailTo []string `json:"email_to" yaml:"email_to"`
+	// EmailFrom sender address for lockout alerts
+	EmailFrom string `json:"email_from" yaml:"email_from"`
+
+	// SMTP server used to deliver e-mail alerts
+	SMTPHost     string `json:"smtp_host" yaml:"smtp_host"`
+	SMTPPort     int    `json:"smtp_port" yaml:"smtp_port"`
+	SMTPUsername string `json:"smtp_username" yaml:"smtp_username"`
+	SMTPPassword string `json:"-" yaml:"smtp_password"` // Never expose in JSON
+}
+
+// LockoutEvent describes an account lockout for notifiers
+type LockoutEvent struct {
+	Username       string        `json:"username"`
+	LockedAt       time.Time     `json:"locked_at"`
+	LockedUntil    time.Time     `json:"locked_until"`
+	Duration       time.Duration `json:"duration"`
+	LockoutCount   int           `json:"lockout_count"`
+	FailedAttempts int           `json:"failed_attempts"`
+	SourceIPs      []string      `json:"source_ips"`
+	TriggerIP      string        `json:"trigger_ip"` // Address of the attempt that locked the account
+}
+
+// LockoutNotifier delivers lockout alerts to an administrator
+type LockoutNotifier interface {
+	NotifyLockout(ctx context.Context, event LockoutEvent) error
+}
+
+// NewLockoutNotifiers builds the notifiers enabled by the configuration
+func NewLockoutNotifiers(config LockoutNotifyConfig) []LockoutNotifier {
+	var notifiers []LockoutNotifier
+	if config.WebhookURL != "" {
+		notifiers = append(notifiers, &WebhookLockoutNotifier{
+			URL:    config.WebhookURL,
+			client: &http.Client{Timeout: 10 * time.Second},
+		})
+	}
+	if len(config.EmailTo) > 0 && config.SMTPHost != "" {
+		notifiers = append(notifiers, &EmailLockoutNotifier{config: config})
+	}
+	return notifiers
+}
+
+// WebhookLockoutNotifier posts lockout events as JSON
+type WebhookLockoutNotifier struct {
+	URL    string
+	client *http.Client
+}
+
+// NotifyLockout posts the event to the webhook URL
+func (n *WebhookLockoutNotifier) NotifyLockout(ctx context.Context, event LockoutEvent) error {
+	payload, err := json.Marshal(map[string]interface{}{
+		"event":           EventTypeAccountLocked,
+		"username":        event.Username,
+		"locked_at":       event.LockedAt,
+		"locked_until":    event.LockedUntil,
+		"duration":        event.Duration.String(),
+		"lockout_count":   event.LockoutCount,
+		"failed_attempts": event.FailedAttempts,
+		"source_ips":      event.SourceIPs,
+		"trigger_ip":      event.TriggerIP,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode lockout webhook: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create lockout webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := n.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send lockout webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("lockout webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// EmailLockoutNotifier sends lockout alerts over SMTP
+type EmailLockoutNotifier struct {
+	config LockoutNotifyConfig
+}
+
+// NotifyLockout e-mails the event to the configured recipients
+func (n *EmailLockoutNotifier) NotifyLockout(ctx context.Context, event LockoutEvent) error {
+	port := n.config.SMTPPort
+	if port == 0 {
+		port = 587
+	}
+	addr := net.JoinHostPort(n.config.SMTPHost, strconv.Itoa(port))
+
+	var auth smtp.Auth
+	if n.config.SMTPUsername != "" {
+		auth = smtp.PlainAuth("", n.config.SMTPUsername, n.config.SMTPPassword, n.config.SMTPHost)
+	}
+
+	from := n.config.EmailFrom
+	if from == "" {
+		from = n.config.SMTPUsername
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "From: %s\r\n", from)
+	fmt.Fprintf(&body, "To: %s\r\n", strings.Join(n.config.EmailTo, ", "))
+	fmt.Fprintf(&body, "Subject: Parental control account %q locked\r\n", event.Username)
+	body.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	fmt.Fprintf(&body, "The account %q was locked after %d failed login attempts.\r\n\r\n", event.Username, event.FailedAttempts)
+	fmt.Fprintf(&body, "Locked at:    %s\r\n", event.LockedAt.Format(time.RFC1123))
+	fmt.Fprintf(&body, "Unlocks at:   %s (%s)\r\n", event.LockedUntil.Format(time.RFC1123), event.Duration)
+	fmt.Fprintf(&body, "Lockout no.:  %d\r\n", event.LockoutCount)
+	fmt.Fprintf(&body, "Source IPs:   %s\r\n", strings.Join(event.SourceIPs, ", "))
+	fmt.Fprintf(&body, "Locked by:    %s\r\n", event.TriggerIP)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(addr, auth, from, n.config.EmailTo, []byte(body.String()))
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("failed to send lockout e-mail: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("failed to send lockout e-mail: %w", ctx.Err())
+	}
+}
+
+// SystemAlerter raises a system alert, such as a desktop notification
+type SystemAlerter interface {
+	NotifySystemAlert(ctx context.Context, title string, message string, details map[string]interface{}) error
+}
+
+// SystemAlertLockoutNotifier raises a system alert for every lockout, so a
+// parent at the computer notices possible tampering
+type SystemAlertLockoutNotifier struct {
+	Alerter SystemAlerter
+}
+
+// NotifyLockout raises the alert
+func (n *SystemAlertLockoutNotifier) NotifyLockout(ctx context.Context, event LockoutEvent) error {
+	message := fmt.Sprintf("The account %q was locked for %s after %d failed login attempts from %s.",
+		event.Username, event.Duration, event.FailedAttempts, event.TriggerIP)
+	return n.Alerter.NotifySystemAlert(ctx, "Account locked", message, map[string]interface{}{
+		"username":      event.Username,
+		"locked_until":  event.LockedUntil,
+		"lockout_count": event.LockoutCount,
+		"trigger_ip":    event.TriggerIP,
+		"source_ips":    event.SourceIPs,
+	})
+}
+
+// SetLockoutStore persists lockout escalation state and restores any state
+// saved before a restart for users that already exist
+func (ss *SecurityService) SetLockoutStore(store models.LockoutStateRepository) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	ss.lockoutStore = store
+	if store == nil {
+		return nil
+	}
+
+	states, err := store.GetAll(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to load lockout state: %w", err)
+	}
+
+	for _, state := range states {
+		ss.lockoutStates[state.Username] = state
+		if user, exists := ss.users[state.Username]; exists && state.LockedUntil != nil {
+			lockedUntil := *state.LockedUntil
+			user.LockedUntil = &lockedUntil
+		}
+	}
+
+	logging.Info("Restored account lockout state", logging.Int("accounts", len(states)))
+	return nil
+}
+
+// SetLockoutNotifiers replaces the notifiers alerted when an account locks
+func (ss *SecurityService) SetLockoutNotifiers(notifiers ...LockoutNotifier) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	ss.lockoutNotifiers = notifiers
+}
+
+// AddLockoutNotifier adds a notifier alerted when an account locks
+func (ss *SecurityService) AddLockoutNotifier(notifier LockoutNotifier) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	ss.lockoutNotifiers = append(ss.lockoutNotifiers, notifier)
+}
+
+// UnlockUser lifts a lockout before it expires and resets the user's failed
+// attempts. The escalation history is kept, so a new lockout soon after is
+// still longer.
+func (ss *SecurityService) UnlockUser(userID int, unlockedBy, ipAddress, userAgent string) (*UserInfo, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	user := ss.findUserByID(userID)
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	wasLocked := user.IsLocked()
+	user.FailedAttempts = 0
+	user.LockedUntil = nil
+	user.UpdatedAt = time.Now()
+	ss.saveUser(user)
+
+	if state, exists := ss.lockoutStates[user.Username]; exists && state.LockedUntil != nil {
+		state.LockedUntil = nil
+		state.UpdatedAt = time.Now()
+		ss.lockoutStates[user.Username] = state
+		ss.saveLockoutState(state)
+	}
+
+	description := fmt.Sprintf("Account %s unlocked by %s", user.Username, unlockedBy)
+	if !wasLocked {
+		description = fmt.Sprintf("Failed login attempts for %s reset by %s", user.Username, unlockedBy)
+	}
+	ss.logSecurityEvent(&SecurityEvent{
+		UserID:      &user.ID,
+		EventType:   EventTypeAccountUnlocked,
+		Description: description,
+		IPAddress:   ipAddress,
+		UserAgent:   userAgent,
+		Severity:    SeverityMedium,
+		Timestamp:   time.Now(),
+	})
+
+	logging.Info("Account unlocked",
+		logging.String("username", user.Username),
+		logging.String("unlocked_by", unlockedBy),
+		logging.Bool("was_locked", wasLocked))
+
+	info := user.Info()
+	return &info, nil
+}
+
+// nextLockoutDuration returns how long the next lockout lasts. With
+// escalation enabled every lockout within the reset window multiplies the
+// base duration, up to the configured maximum.
+func (ss *SecurityService) nextLockoutDuration(count int) time.Duration {
+	base := ss.config.LockoutDuration
+	if !ss.config.LockoutEscalation || count <= 1 {
+		return base
+	}
+
+	factor := ss.config.LockoutEscalationFactor
+	if factor < 1 {
+		factor = 2
+	}
+
+	duration := time.Duration(float64(base) * math.Pow(factor, float64(count-1)))
+	if maxDuration := ss.config.LockoutMaxDuration; maxDuration > 0 && (duration > maxDuration || duration <= 0) {
+		duration = maxDuration
+	}
+	return duration
+}
+
+// lockAccount locks the user and records the escalation state (mutex must be held)
+func (ss *SecurityService) lockAccount(user *User, ipAddress string, now time.Time) LockoutEvent {
+	state := ss.lockoutStates[user.Username]
+	state.Username = user.Username
+	if state.LastLockoutAt != nil && ss.config.LockoutEscalationReset > 0 &&
+		now.Sub(*state.LastLockoutAt) > ss.config.LockoutEscalationReset {
+		state.LockoutCount = 0
+	}
+	state.LockoutCount++
+
+	duration := ss.nextLockoutDuration(state.LockoutCount)
+	lockUntil := now.Add(duration)
+	user.LockedUntil = &lockUntil
+
+	lockedAt := now
+	state.LockedUntil = &lockUntil
+	state.LastLockoutAt = &lockedAt
+	state.UpdatedAt = now
+	ss.lockoutStates[user.Username] = state
+	ss.saveLockoutState(state)
+
+	return LockoutEvent{
+		Username:       user.Username,
+		LockedAt:       now,
+		LockedUntil:    lockUntil,
+		Duration:       duration,
+		LockoutCount:   state.LockoutCount,
+		FailedAttempts: user.FailedAttempts,
+		SourceIPs:      ss.recentFailureIPs(user.Username),
+		TriggerIP:      ipAddress,
+	}
+}
+
+// clearLockoutState forgets the escalation history of a user (mutex must be held)
+func (ss *SecurityService) clearLockoutState(username string) {
+	if _, exists := ss.lockoutStates[username]; !exists {
+		return
+	}
+	delete(ss.lockoutStates, username)
+
+	if ss.lockoutStore != nil {
+		if err := ss.lockoutStore.Delete(context.Background(), username); err != nil {
+			logging.Warn("Failed to clear lockout state",
+				logging.String("username", username),
+				logging.Err(err))
+		}
+	}
+}
+
+func (ss *SecurityService) saveLockoutState(state models.LockoutState) {
+	if ss.lockoutStore == nil {
+		return
+	}
+	if err := ss.lockoutStore.Save(context.Background(), &state); err != nil {
+		logging.Warn("Failed to persist lockout state",
+			logging.String("username", state.Username),
+			logging.Err(err))
+	}
+}
+
+// recentFailureIPs lists the addresses of failed attempts since the user's
+// last successful login
+func (ss *SecurityService) recentFailureIPs(username string) []string {
+	ss.historyMu.Lock()
+	defer ss.historyMu.Unlock()
+
+	seen := make(map[string]bool)
+	for i := len(ss.loginAttempts) - 1; i >= 0; i-- {
+		attempt := ss.loginAttempts[i]
+		if attempt.Username != username {
+			continue
+		}
+		if attempt.Success {
+			break
+		}
+		if attempt.IPAddress != "" {
+			seen[attempt.IPAddress] = true
+		}
+	}
+
+	ips := make([]string, 0, len(seen))
+	for ip := range seen {
+		ips = append(ips, ip)
+	}
+	sort.Strings(ips)
+	return ips
+}
+
+// notifyLockout sends the event to every notifier without holding the lock
+func (ss *SecurityService) notifyLockout(notifiers []LockoutNotifier, event LockoutEvent) {
+	if len(notifiers) == 0 {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		for _, notifier := range notifiers {
+			if err := notifier.NotifyLockout(ctx, event); err != nil {
+				logging.Error("Failed to send lockout notification",
+					logging.String("username", event.Username),
+					logging.Err(err))
+			}
+		}
+	}()
+}
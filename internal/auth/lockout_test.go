@@ -0,0 +1,205 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"parental-control/internal/models"
+)
+
+type memoryLockoutStore struct {
+	states map[string]models.LockoutState
+}
+
+func newMemoryLockoutStore() *memoryLockoutStore {
+	return &memoryLockoutStore{states: make(map[string]models.LockoutState)}
+}
+
+func (m *memoryLockoutStore) GetAll(ctx context.Context) ([]models.LockoutState, error) {
+	var states []models.LockoutState
+	for _, state := range m.states {
+		states = append(states, state)
+	}
+	return states, nil
+}
+
+func (m *memoryLockoutStore) Save(ctx context.Context, state *models.LockoutState) error {
+	m.states[state.Username] = *state
+	return nil
+}
+
+func (m *memoryLockoutStore) Delete(ctx context.Context, username string) error {
+	delete(m.states, username)
+	return nil
+}
+
+func newLockoutTestService(t *testing.T, config AuthConfig) *SecurityService {
+	t.Helper()
+	ss := NewSecurityService(config)
+	if err := ss.CreateInitialAdmin("admin", "ValidPass123!", "admin@example.com"); err != nil {
+		t.Fatalf("Failed to create admin: %v", err)
+	}
+	return ss
+}
+
+func failLogins(ss *SecurityService, count int, ip string) {
+	for i := 0; i < count; i++ {
+		ss.Authenticate("admin", "wrong-password", ip, "test")
+	}
+}
+
+func TestSecurityService_GraduatedLockout(t *testing.T) {
+	config := testSessionConfig()
+	config.LoginRateLimit = 100
+	config.LockoutEscalation = true
+	config.LockoutEscalationFactor = 2
+	config.LockoutMaxDuration = 40 * time.Minute
+	config.LockoutEscalationReset = 24 * time.Hour
+
+	ss := newLockoutTestService(t, config)
+	store := newMemoryLockoutStore()
+	if err := ss.SetLockoutStore(store); err != nil {
+		t.Fatalf("Failed to set lockout store: %v", err)
+	}
+
+	expected := []time.Duration{15 * time.Minute, 30 * time.Minute, 40 * time.Minute}
+	for i, duration := range expected {
+		before := time.Now()
+		failLogins(ss, config.MaxFailedAttempts, "192.168.1.50")
+
+		user := ss.users["admin"]
+		if user.LockedUntil == nil {
+			t.Fatalf("Lockout %d: expected account to be locked", i+1)
+		}
+		if got := user.LockedUntil.Sub(before).Round(time.Minute); got != duration {
+			t.Errorf("Lockout %d: expected %v, got %v", i+1, duration, got)
+		}
+		if state := store.states["admin"]; state.LockoutCount != i+1 {
+			t.Errorf("Lockout %d: expected persisted count %d, got %d", i+1, i+1, state.LockoutCount)
+		}
+
+		// Let the lockout expire so the next round can fail again
+		expired := time.Now().Add(-time.Second)
+		user.LockedUntil = &expired
+	}
+
+	// Escalation and lock survive a restart
+	restarted := newLockoutTestService(t, config)
+	if err := restarted.SetLockoutStore(store); err != nil {
+		t.Fatalf("Failed to restore lockout store: %v", err)
+	}
+	if !restarted.users["admin"].IsLocked() {
+		t.Fatal("Expected the lockout to survive a restart")
+	}
+
+	expired := time.Now().Add(-time.Second)
+	restarted.users["admin"].LockedUntil = &expired
+	failLogins(restarted, config.MaxFailedAttempts, "192.168.1.50")
+	if got := restarted.lockoutStates["admin"].LockoutCount; got != 4 {
+		t.Errorf("Expected escalation to continue after restart, got count %d", got)
+	}
+}
+
+func TestSecurityService_LockoutNotification(t *testing.T) {
+	received := make(chan map[string]interface{}, 1)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&payload)
+		received <- payload
+	}))
+	defer webhook.Close()
+
+	config := testSessionConfig()
+	config.LoginRateLimit = 100
+	config.LockoutNotify.WebhookURL = webhook.URL
+
+	ss := newLockoutTestService(t, config)
+	ss.Authenticate("admin", "wrong-password", "10.0.0.2", "test")
+	failLogins(ss, config.MaxFailedAttempts-1, "10.0.0.1")
+
+	select {
+	case payload := <-received:
+		if payload["username"] != "admin" {
+			t.Errorf("Expected username admin, got %v", payload["username"])
+		}
+		ips, _ := payload["source_ips"].([]interface{})
+		if len(ips) != 2 || ips[0] != "10.0.0.1" || ips[1] != "10.0.0.2" {
+			t.Errorf("Expected both source IPs, got %v", payload["source_ips"])
+		}
+		if payload["trigger_ip"] != "10.0.0.1" {
+			t.Errorf("Expected trigger IP 10.0.0.1, got %v", payload["trigger_ip"])
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected a lockout webhook")
+	}
+}
+
+type stubSystemAlerter struct {
+	alerts chan map[string]interface{}
+}
+
+func (s *stubSystemAlerter) NotifySystemAlert(ctx context.Context, title string, message string, details map[string]interface{}) error {
+	s.alerts <- details
+	return nil
+}
+
+func TestSecurityService_LockoutSystemAlertAndUnlock(t *testing.T) {
+	config := testSessionConfig()
+	config.LoginRateLimit = 100
+
+	ss := newLockoutTestService(t, config)
+	alerter := &stubSystemAlerter{alerts: make(chan map[string]interface{}, 1)}
+	ss.AddLockoutNotifier(&SystemAlertLockoutNotifier{Alerter: alerter})
+	store := newMemoryLockoutStore()
+	if err := ss.SetLockoutStore(store); err != nil {
+		t.Fatalf("Failed to set lockout store: %v", err)
+	}
+
+	failLogins(ss, config.MaxFailedAttempts, "10.0.0.9")
+
+	select {
+	case details := <-alerter.alerts:
+		if details["username"] != "admin" || details["trigger_ip"] != "10.0.0.9" {
+			t.Errorf("Unexpected alert details: %v", details)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected a system alert for the lockout")
+	}
+
+	admin := ss.users["admin"]
+	if info := admin.Info(); info.LockedUntil == nil {
+		t.Fatal("Expected the account to be locked")
+	}
+
+	info, err := ss.UnlockUser(admin.ID, "parent", "10.0.0.1", "test")
+	if err != nil {
+		t.Fatalf("Failed to unlock: %v", err)
+	}
+	if info.LockedUntil != nil || admin.IsLocked() || admin.FailedAttempts != 0 {
+		t.Errorf("Expected the account to be unlocked: %+v", info)
+	}
+	if state := store.states["admin"]; state.LockedUntil != nil || state.LockoutCount != 1 {
+		t.Errorf("Expected the persisted lock cleared and the escalation kept: %+v", state)
+	}
+	if response, _ := ss.Authenticate("admin", "ValidPass123!", "10.0.0.1", "test"); !response.Success {
+		t.Errorf("Expected login after unlocking: %+v", response)
+	}
+
+	var unlocked *SecurityEvent
+	for i := range ss.securityEvents {
+		if ss.securityEvents[i].EventType == EventTypeAccountUnlocked {
+			unlocked = &ss.securityEvents[i]
+		}
+	}
+	if unlocked == nil || unlocked.IPAddress != "10.0.0.1" {
+		t.Errorf("Expected an account_unlocked event from 10.0.0.1, got %+v", unlocked)
+	}
+
+	if _, err := ss.UnlockUser(999, "parent", "10.0.0.1", "test"); err != ErrUserNotFound {
+		t.Errorf("Expected ErrUserNotFound, got %v", err)
+	}
+}
@@ -2,6 +2,7 @@ package auth
 
 import (
 	"errors"
+	"net/http"
 	"time"
 )
 
@@ -17,6 +18,19 @@ var (
 	ErrRateLimitExceeded  = errors.New("rate limit exceeded")
 	ErrPasswordTooWeak    = errors.New("password does not meet requirements")
 	ErrPasswordReused     = errors.New("password was recently used")
+	ErrLastAdmin          = errors.New("cannot remove the last active admin account")
+
+	ErrTwoFactorUnavailable    = errors.New("a session secret must be configured to use two-factor authentication")
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnrolled    = errors.New("two-factor enrollment has not been started")
+	ErrInvalidTwoFactorCode    = errors.New("invalid two-factor code")
+
+	ErrInvalidAPIKey  = errors.New("invalid or expired API key")
+	ErrAPIKeyNotFound = errors.New("API key not found")
+
+	ErrAPIKeysUnavailable = errors.New("API keys cannot be used without a database to store them")
+
+	ErrInvalidRecoveryToken = errors.New("invalid or expired recovery token")
 )
 
 // User represents an authenticated user account
@@ -33,6 +47,14 @@ type User struct {
 	LockedUntil       *time.Time `json:"locked_until" db:"locked_until"`
 	CreatedAt         time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt         time.Time  `json:"updated_at" db:"updated_at"`
+
+	// Two-factor authentication. Secrets are stored encrypted with a key
+	// derived from the session secret; the pending secret is the one being
+	// enrolled until a code from it is verified.
+	TwoFactorEnabled  bool   `json:"two_factor_enabled" db:"two_factor_enabled"`
+	TOTPSecret        string `json:"-" db:"totp_secret"` // Never expose in JSON
+	PendingTOTPSecret string `json:"-" db:"-"`
+	LastTOTPStep      int64  `json:"-" db:"last_totp_step"` // Rejects reuse of a code
 }
 
 // IsLocked returns true if the account is currently locked
@@ -43,6 +65,26 @@ func (u *User) IsLocked() bool {
 	return time.Now().Before(*u.LockedUntil)
 }
 
+// Info returns the public view of the user
+func (u *User) Info() UserInfo {
+	var lockedUntil *time.Time
+	if u.IsLocked() {
+		until := *u.LockedUntil
+		lockedUntil = &until
+	}
+	return UserInfo{
+		ID:               u.ID,
+		Username:         u.Username,
+		Email:            u.Email,
+		IsAdmin:          u.IsAdmin,
+		IsActive:         u.IsActive,
+		TwoFactorEnabled: u.TwoFactorEnabled,
+		LastLoginAt:      u.LastLoginAt,
+		LockedUntil:      lockedUntil,
+		CreatedAt:        u.CreatedAt,
+	}
+}
+
 // PasswordExpired returns true if the password has expired
 func (u *User) PasswordExpired(expireDays int) bool {
 	if expireDays <= 0 {
@@ -141,6 +183,17 @@ const (
 	EventTypeAccountLocked      = "account_locked"
 	EventTypeAccountUnlocked    = "account_unlocked"
 	EventTypePasswordReset      = "password_reset"
+	EventTypeRecoveryIssued     = "recovery_token_issued"
+	EventTypeRecoveryFailed     = "recovery_token_failed"
+	EventTypeUserCreated        = "user_created"
+	EventTypeUserDeleted        = "user_deleted"
+	EventTypeUserActivated      = "user_activated"
+	EventTypeUserDeactivated    = "user_deactivated"
+	EventTypeTwoFactorEnabled   = "two_factor_enabled"
+	EventTypeTwoFactorFailed    = "two_factor_failed"
+	EventTypeAPIKeyCreated      = "api_key_created"
+	EventTypeAPIKeyRevoked      = "api_key_revoked"
+	EventTypeAPIKeyUsed         = "api_key_used"
 	EventTypeSessionExpired     = "session_expired"
 	EventTypeSessionRevoked     = "session_revoked"
 	EventTypeBruteForce         = "brute_force_detected"
@@ -155,6 +208,47 @@ const (
 	SeverityCritical = "CRITICAL"
 )
 
+// APIKeyScope limits what requests authenticated with an API key may do
+type APIKeyScope string
+
+const (
+	// APIKeyScopeReadOnly allows only GET and HEAD requests
+	APIKeyScopeReadOnly APIKeyScope = "read_only"
+	// APIKeyScopeAdmin allows every request the key's user may make
+	APIKeyScopeAdmin APIKeyScope = "admin"
+)
+
+// APIKey is a long-lived credential for headless automation. Only a hash of
+// the key is kept; the key itself is shown once when it is created.
+type APIKey struct {
+	ID         int         `json:"id" db:"id"`
+	UserID     int         `json:"user_id" db:"user_id"`
+	Name       string      `json:"name" db:"name"`
+	Prefix     string      `json:"prefix" db:"prefix"` // Start of the key, to tell keys apart
+	KeyHash    string      `json:"-" db:"key_hash"`    // Never expose in JSON
+	Scope      APIKeyScope `json:"scope" db:"scope"`
+	ExpiresAt  *time.Time  `json:"expires_at,omitempty" db:"expires_at"`
+	LastUsedAt *time.Time  `json:"last_used_at,omitempty" db:"last_used_at"`
+	RevokedAt  *time.Time  `json:"revoked_at,omitempty" db:"revoked_at"`
+	CreatedAt  time.Time   `json:"created_at" db:"created_at"`
+}
+
+// IsUsable returns true if the key is neither revoked nor expired
+func (k *APIKey) IsUsable() bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || time.Now().Before(*k.ExpiresAt)
+}
+
+// Allows reports whether the key's scope permits a request with method
+func (k *APIKey) Allows(method string) bool {
+	if k.Scope == APIKeyScopeAdmin {
+		return true
+	}
+	return method == http.MethodGet || method == http.MethodHead
+}
+
 // AuthConfig represents authentication configuration
 type AuthConfig struct {
 	// Password configuration
@@ -169,54 +263,137 @@ type AuthConfig struct {
 	MaxFailedAttempts int           `json:"max_failed_attempts" yaml:"max_failed_attempts"`
 	LockoutDuration   time.Duration `json:"lockout_duration" yaml:"lockout_duration"`
 
+	// Graduated lockout: each lockout within LockoutEscalationReset of the
+	// previous one lasts LockoutEscalationFactor times longer, up to LockoutMaxDuration
+	LockoutEscalation       bool          `json:"lockout_escalation" yaml:"lockout_escalation"`
+	LockoutEscalationFactor float64       `json:"lockout_escalation_factor" yaml:"lockout_escalation_factor"`
+	LockoutMaxDuration      time.Duration `json:"lockout_max_duration" yaml:"lockout_max_duration"`
+	LockoutEscalationReset  time.Duration `json:"lockout_escalation_reset" yaml:"lockout_escalation_reset"`
+
+	// LockoutNotify sends an alert to the administrator whenever an account locks
+	LockoutNotify LockoutNotifyConfig `json:"lockout_notify" yaml:"lockout_notify"`
+
 	// Rate limiting configuration
 	LoginRateLimit int `json:"login_rate_limit" yaml:"login_rate_limit"` // attempts per minute
 
+	// Password recovery: a token expires after RecoveryTokenTTL and is
+	// invalidated after RecoveryMaxAttempts wrong guesses
+	RecoveryTokenTTL    time.Duration `json:"recovery_token_ttl" yaml:"recovery_token_ttl"`
+	RecoveryMaxAttempts int           `json:"recovery_max_attempts" yaml:"recovery_max_attempts"`
+
+	// Sizes of the in-memory login attempt and security event histories;
+	// zero or less uses defaultHistorySize
+	LoginAttemptHistorySize int `json:"login_attempt_history_size" yaml:"login_attempt_history_size"`
+	EventHistorySize        int `json:"event_history_size" yaml:"event_history_size"`
+
 	// Security configuration
 	RequireTwoFactor      bool `json:"require_two_factor" yaml:"require_two_factor"`
 	AllowMultipleSessions bool `json:"allow_multiple_sessions" yaml:"allow_multiple_sessions"`
 	MaxSessions           int  `json:"max_sessions" yaml:"max_sessions"`
+
+	// RevokeAllSessionsOnPasswordChange also revokes the session that changed the password
+	RevokeAllSessionsOnPasswordChange bool `json:"revoke_all_sessions_on_password_change" yaml:"revoke_all_sessions_on_password_change"`
+}
+
+// defaultHistorySize is used for the in-memory login attempt and security
+// event histories when no size is configured
+const defaultHistorySize = 1000
+
+// historySize returns configured, or defaultHistorySize when it is not positive
+func historySize(configured int) int {
+	if configured <= 0 {
+		return defaultHistorySize
+	}
+	return configured
 }
 
 // DefaultAuthConfig returns default authentication configuration
 func DefaultAuthConfig() AuthConfig {
 	return AuthConfig{
-		Password:              DefaultPasswordConfig(),
-		SessionTimeout:        24 * time.Hour,
-		RememberMeDuration:    30 * 24 * time.Hour, // 30 days
-		MaxFailedAttempts:     5,
-		LockoutDuration:       15 * time.Minute,
-		LoginRateLimit:        10, // 10 attempts per minute
-		RequireTwoFactor:      false,
-		AllowMultipleSessions: false,
-		MaxSessions:           1,
+		Password:                DefaultPasswordConfig(),
+		SessionTimeout:          24 * time.Hour,
+		RememberMeDuration:      30 * 24 * time.Hour, // 30 days
+		MaxFailedAttempts:       5,
+		LockoutDuration:         15 * time.Minute,
+		LockoutEscalationFactor: 2,
+		LockoutMaxDuration:      24 * time.Hour,
+		LockoutEscalationReset:  24 * time.Hour,
+		LoginRateLimit:          10, // 10 attempts per minute
+		RecoveryTokenTTL:        defaultRecoveryTokenTTL,
+		RecoveryMaxAttempts:     defaultRecoveryMaxAttempts,
+		LoginAttemptHistorySize: defaultHistorySize,
+		EventHistorySize:        defaultHistorySize,
+		RequireTwoFactor:        false,
+		AllowMultipleSessions:   false,
+		MaxSessions:             1,
 	}
 }
 
-// LoginRequest represents a login request
+// LoginRequest represents a login request. Users with two-factor
+// authentication complete the login with a second request carrying the
+// challenge token from the first response and a code instead of a password.
 type LoginRequest struct {
-	Username   string `json:"username" binding:"required"`
-	Password   string `json:"password" binding:"required"`
-	RememberMe bool   `json:"remember_me"`
+	Username       string `json:"username" binding:"required"`
+	Password       string `json:"password" binding:"required"`
+	RememberMe     bool   `json:"remember_me"`
+	ChallengeToken string `json:"challenge_token,omitempty"`
+	TOTPCode       string `json:"totp_code,omitempty"`
 }
 
-// LoginResponse represents a login response
+// LoginResponse represents a login response. When TwoFactorRequired is set
+// the password was accepted but no session exists yet; the login is finished
+// by sending ChallengeToken with a code before ExpiresAt.
 type LoginResponse struct {
-	Success   bool      `json:"success"`
-	Message   string    `json:"message"`
-	SessionID string    `json:"session_id,omitempty"`
-	ExpiresAt time.Time `json:"expires_at,omitempty"`
-	User      *UserInfo `json:"user,omitempty"`
+	Success           bool      `json:"success"`
+	Message           string    `json:"message"`
+	SessionID         string    `json:"session_id,omitempty"`
+	Token             string    `json:"token,omitempty"` // SessionID again, for the web client's bearer header
+	ExpiresAt         time.Time `json:"expires_at,omitempty"`
+	User              *UserInfo `json:"user,omitempty"`
+	TwoFactorRequired bool      `json:"two_factor_required,omitempty"`
+	ChallengeToken    string    `json:"challenge_token,omitempty"`
 }
 
 // UserInfo represents public user information (no sensitive data)
 type UserInfo struct {
-	ID          int        `json:"id"`
-	Username    string     `json:"username"`
-	Email       string     `json:"email"`
-	IsAdmin     bool       `json:"is_admin"`
-	LastLoginAt *time.Time `json:"last_login_at"`
-	CreatedAt   time.Time  `json:"created_at"`
+	ID               int        `json:"id"`
+	Username         string     `json:"username"`
+	Email            string     `json:"email"`
+	IsAdmin          bool       `json:"is_admin"`
+	IsActive         bool       `json:"is_active"`
+	TwoFactorEnabled bool       `json:"two_factor_enabled"`
+	LastLoginAt      *time.Time `json:"last_login_at"`
+	LockedUntil      *time.Time `json:"locked_until,omitempty"` // Set while the account is locked
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+// TwoFactorEnrollResponse carries a new TOTP secret for an authenticator
+// app. QRPayload is the text to render as a QR code for scanning; Secret is
+// for entering by hand.
+type TwoFactorEnrollResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+	QRPayload  string `json:"qr_payload"`
+}
+
+// TwoFactorVerifyRequest activates two-factor authentication with a code
+// from the newly enrolled secret
+type TwoFactorVerifyRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// APIKeyRequest represents a request to create an API key (admin only).
+// Scope defaults to read-only and the key never expires without ExpiresAt.
+type APIKeyRequest struct {
+	Name      string      `json:"name" binding:"required"`
+	Scope     APIKeyScope `json:"scope"`
+	ExpiresAt *time.Time  `json:"expires_at"`
+}
+
+// APIKeyCreateResponse carries a new API key. Key is only ever returned here.
+type APIKeyCreateResponse struct {
+	Key    string `json:"key"`
+	APIKey APIKey `json:"api_key"`
 }
 
 // ChangePasswordRequest represents a password change request
@@ -231,6 +408,18 @@ type ChangePasswordResponse struct {
 	Message string `json:"message"`
 }
 
+// RecoveryTokenRequest asks for a password recovery token for a user. The
+// token is only written to the service output, never to the response.
+type RecoveryTokenRequest struct {
+	Username string `json:"username" binding:"required"`
+}
+
+// PasswordResetRequest sets a new password with a recovery token
+type PasswordResetRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
 // PasswordStrengthResponse represents password strength validation response
 type PasswordStrengthResponse struct {
 	Valid    bool     `json:"valid"`
@@ -238,13 +427,19 @@ type PasswordStrengthResponse struct {
 	Feedback []string `json:"feedback"` // Validation messages
 }
 
-// AdminUserRequest represents a request to create/update a user (admin only)
+// AdminUserRequest represents a request to create/update a user (admin only).
+// IsActive defaults to true when omitted.
 type AdminUserRequest struct {
 	Username string `json:"username" binding:"required"`
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password,omitempty"`
 	IsAdmin  bool   `json:"is_admin"`
-	IsActive bool   `json:"is_active"`
+	IsActive *bool  `json:"is_active,omitempty"`
+}
+
+// AdminUserUpdateRequest represents a partial user update (admin only)
+type AdminUserUpdateRequest struct {
+	IsActive *bool `json:"is_active"`
 }
 
 // SecurityStatsResponse represents security statistics
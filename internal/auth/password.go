@@ -64,6 +64,11 @@ func (ph *PasswordHasher) HashPassword(password string) (string, error) {
 		return "", fmt.Errorf("password validation failed: %w", err)
 	}
 
+	return ph.generateHash(password)
+}
+
+// generateHash hashes password at the configured cost without validating it
+func (ph *PasswordHasher) generateHash(password string) (string, error) {
 	hash, err := bcrypt.GenerateFromPassword([]byte(password), ph.config.BcryptCost)
 	if err != nil {
 		return "", fmt.Errorf("failed to hash password: %w", err)
@@ -72,6 +77,13 @@ func (ph *PasswordHasher) HashPassword(password string) (string, error) {
 	return string(hash), nil
 }
 
+// NeedsRehash reports whether hash was made with a lower bcrypt cost than
+// the one currently configured
+func (ph *PasswordHasher) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err == nil && cost < ph.config.BcryptCost
+}
+
 // VerifyPassword compares a password with its hash
 func (ph *PasswordHasher) VerifyPassword(password, hash string) error {
 	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
@@ -84,6 +96,13 @@ func (ph *PasswordHasher) VerifyPassword(password, hash string) error {
 	return nil
 }
 
+// IsPasswordHash reports whether value is a bcrypt hash rather than a plain
+// text password
+func IsPasswordHash(value string) bool {
+	_, err := bcrypt.Cost([]byte(value))
+	return err == nil
+}
+
 // ValidatePasswordStrength checks if password meets strength requirements
 func (ph *PasswordHasher) ValidatePasswordStrength(password string) error {
 	var errors []string
@@ -127,12 +146,27 @@ func (ph *PasswordHasher) ValidatePasswordStrength(password string) error {
 	}
 
 	if len(errors) > 0 {
-		return fmt.Errorf("password strength validation failed: %s", strings.Join(errors, "; "))
+		return &PasswordStrengthError{Feedback: errors}
 	}
 
 	return nil
 }
 
+// PasswordStrengthError lists the strength requirements a password failed.
+// It matches ErrPasswordTooWeak with errors.Is.
+type PasswordStrengthError struct {
+	Feedback []string
+}
+
+func (e *PasswordStrengthError) Error() string {
+	return "password strength validation failed: " + strings.Join(e.Feedback, "; ")
+}
+
+// Is reports whether target is ErrPasswordTooWeak
+func (e *PasswordStrengthError) Is(target error) bool {
+	return target == ErrPasswordTooWeak
+}
+
 // GenerateSecurePassword generates a cryptographically secure random password
 func (ph *PasswordHasher) GenerateSecurePassword(length int) (string, error) {
 	if length < ph.config.MinLength {
@@ -295,6 +329,28 @@ func (pm *PasswordManager) VerifyPassword(password, currentHash string) error {
 	return pm.hasher.VerifyPassword(password, currentHash)
 }
 
+// Rehash re-hashes a verified password at the configured cost when
+// currentHash used a lower one. It returns the hash to store and whether it
+// changed. The plaintext is unchanged, so neither the strength policy nor the
+// password history is checked; the history entry is updated in place.
+func (pm *PasswordManager) Rehash(password, currentHash string) (string, bool, error) {
+	if !pm.hasher.NeedsRehash(currentHash) {
+		return currentHash, false, nil
+	}
+
+	hash, err := pm.hasher.generateHash(password)
+	if err != nil {
+		return currentHash, false, err
+	}
+
+	for i := range pm.history {
+		if pm.history[i].Hash == currentHash {
+			pm.history[i].Hash = hash
+		}
+	}
+	return hash, true, nil
+}
+
 // GeneratePassword generates a secure password
 func (pm *PasswordManager) GeneratePassword(length int) (string, error) {
 	return pm.hasher.GenerateSecurePassword(length)
@@ -1,7 +1,11 @@
 package auth
 
 import (
+	"fmt"
+	"sync"
 	"testing"
+
+	"golang.org/x/crypto/bcrypt"
 )
 
 // testPasswordConfig returns a password config optimized for testing (faster bcrypt)
@@ -183,6 +187,36 @@ func TestPasswordManager_PasswordHistory(t *testing.T) {
 	}
 }
 
+func TestPasswordManager_Rehash(t *testing.T) {
+	oldConfig := testPasswordConfig()
+	oldConfig.BcryptCost = 4
+	oldManager := NewPasswordManager(oldConfig)
+	oldHash, err := oldManager.SetPassword("RehashPassword123!")
+	if err != nil {
+		t.Fatalf("Failed to set password: %v", err)
+	}
+
+	newConfig := oldConfig
+	newConfig.BcryptCost = 5
+	manager := NewPasswordManager(newConfig)
+	manager.LoadHistory(oldManager.GetHistory())
+
+	hash, changed, err := manager.Rehash("RehashPassword123!", oldHash)
+	if err != nil || !changed {
+		t.Fatalf("Expected the hash to be upgraded, got changed=%v err=%v", changed, err)
+	}
+	if cost, _ := bcrypt.Cost([]byte(hash)); cost != 5 {
+		t.Errorf("Expected cost 5, got %d", cost)
+	}
+	if history := manager.GetHistory(); len(history) != 1 || history[0].Hash != hash {
+		t.Errorf("Expected the history entry to be replaced, got %+v", history)
+	}
+
+	if _, changed, _ := manager.Rehash("RehashPassword123!", hash); changed {
+		t.Error("Expected no rehash at the configured cost")
+	}
+}
+
 func TestSecurityService_CreateInitialAdmin(t *testing.T) {
 	config := testAuthConfig()
 	service := NewSecurityService(config)
@@ -199,6 +233,57 @@ func TestSecurityService_CreateInitialAdmin(t *testing.T) {
 	}
 }
 
+func TestSecurityService_SeedAdminFromConfigHash(t *testing.T) {
+	config := testAuthConfig()
+	hash, err := NewPasswordHasher(config.Password).HashPassword("ResetPassword123!")
+	if err != nil {
+		t.Fatalf("Failed to hash password: %v", err)
+	}
+	if !IsPasswordHash(hash) || IsPasswordHash("ResetPassword123!") {
+		t.Fatal("IsPasswordHash did not tell hashes from passwords")
+	}
+
+	service := NewSecurityService(config)
+	if err := service.SeedAdminFromConfig("parent", hash, "parent@example.com"); err != nil {
+		t.Fatalf("Failed to create admin from hash: %v", err)
+	}
+	if response, err := service.Authenticate("parent", "ResetPassword123!", "192.168.1.1", "test-agent"); err != nil || !response.Success {
+		t.Fatalf("Failed to authenticate with the hashed password: %v %+v", err, response)
+	}
+
+	// Setup treats a hash as a plain password, so it cannot skip validation
+	service = NewSecurityService(config)
+	service.CreateInitialAdmin("parent", hash, "parent@example.com")
+	if response, _ := service.Authenticate("parent", "ResetPassword123!", "192.168.1.1", "test-agent"); response != nil && response.Success {
+		t.Error("Expected setup not to store a precomputed hash")
+	}
+}
+
+func TestSecurityService_AuthenticateRehashesOldCost(t *testing.T) {
+	config := testAuthConfig()
+	oldHash, err := NewPasswordHasher(config.Password).HashPassword("RehashPassword123!")
+	if err != nil {
+		t.Fatalf("Failed to hash password: %v", err)
+	}
+
+	// The cost was raised after the hash was made
+	config.Password.BcryptCost = 5
+	service := NewSecurityService(config)
+	if err := service.SeedAdminFromConfig("admin", oldHash, "admin@example.com"); err != nil {
+		t.Fatalf("Failed to create admin: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		response, err := service.Authenticate("admin", "RehashPassword123!", "192.168.1.1", "test-agent")
+		if err != nil || !response.Success {
+			t.Fatalf("Login %d failed: %v %+v", i+1, err, response)
+		}
+	}
+	if cost, _ := bcrypt.Cost([]byte(service.users["admin"].PasswordHash)); cost != 5 {
+		t.Errorf("Expected the stored hash to use cost 5, got %d", cost)
+	}
+}
+
 func TestSecurityService_Authenticate(t *testing.T) {
 	config := testAuthConfig()
 	service := NewSecurityService(config)
@@ -345,3 +430,141 @@ func TestSecurityService_RateLimit(t *testing.T) {
 		}
 	}
 }
+
+func TestSecurityService_HistoryConcurrency(t *testing.T) {
+	config := testAuthConfig()
+	config.LoginRateLimit = 10000
+	config.MaxFailedAttempts = 10000
+	config.AllowMultipleSessions = true
+	config.MaxSessions = 10000
+	config.LoginAttemptHistorySize = 50
+	config.EventHistorySize = 20
+	service := NewSecurityService(config)
+	if err := service.CreateInitialAdmin("admin", "AdminPassword123!", "admin@example.com"); err != nil {
+		t.Fatalf("Failed to create admin: %v", err)
+	}
+
+	const workers, iterations = 8, 25
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			ip := fmt.Sprintf("192.168.1.%d", w)
+			for i := 0; i < iterations; i++ {
+				password := "wrong"
+				if i%5 == 0 {
+					password = "AdminPassword123!"
+				}
+				response, err := service.Authenticate("admin", password, ip, "test-agent")
+				if err != nil {
+					t.Errorf("Unexpected error during authentication: %v", err)
+					return
+				}
+				// Session manager revocations log events without holding mu
+				if response.Success {
+					service.RevokeSession(response.SessionID)
+				}
+				service.GetSecurityStats()
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	service.historyMu.Lock()
+	defer service.historyMu.Unlock()
+	if len(service.loginAttempts) != config.LoginAttemptHistorySize {
+		t.Errorf("Expected %d login attempts kept, got %d", config.LoginAttemptHistorySize, len(service.loginAttempts))
+	}
+	if len(service.securityEvents) != config.EventHistorySize {
+		t.Errorf("Expected %d security events kept, got %d", config.EventHistorySize, len(service.securityEvents))
+	}
+	for i := 1; i < len(service.loginAttempts); i++ {
+		if service.loginAttempts[i].ID <= service.loginAttempts[i-1].ID {
+			t.Fatalf("Login attempt IDs are not increasing: %d then %d", service.loginAttempts[i-1].ID, service.loginAttempts[i].ID)
+		}
+	}
+	if last := service.loginAttempts[len(service.loginAttempts)-1].ID; last != workers*iterations {
+		t.Errorf("Expected the last attempt ID to be %d, got %d", workers*iterations, last)
+	}
+}
+
+func TestSecurityService_ChangePasswordRevokesOtherSessions(t *testing.T) {
+	config := testAuthConfig()
+	config.AllowMultipleSessions = true
+	config.MaxSessions = 5
+	service := NewSecurityService(config)
+
+	if err := service.CreateInitialAdmin("admin", "AdminPassword123!", "admin@example.com"); err != nil {
+		t.Fatalf("Failed to create initial admin: %v", err)
+	}
+
+	current, err := service.Authenticate("admin", "AdminPassword123!", "192.168.1.1", "current-agent")
+	if err != nil || !current.Success {
+		t.Fatalf("Failed to authenticate: %v", err)
+	}
+	other, err := service.Authenticate("admin", "AdminPassword123!", "192.168.1.2", "other-agent")
+	if err != nil || !other.Success {
+		t.Fatalf("Failed to authenticate: %v", err)
+	}
+
+	if err := service.ChangePassword("admin", "AdminPassword123!", "NewPassword456!", current.SessionID); err != nil {
+		t.Fatalf("Failed to change password: %v", err)
+	}
+
+	if _, err := service.ValidateSession(current.SessionID); err != nil {
+		t.Fatalf("Current session should remain valid: %v", err)
+	}
+	if _, err := service.ValidateSession(other.SessionID); err == nil {
+		t.Fatal("Other session should have been revoked")
+	}
+}
+
+func TestSecurityService_ChangePasswordRevokeAll(t *testing.T) {
+	config := testAuthConfig()
+	config.AllowMultipleSessions = true
+	config.MaxSessions = 5
+	config.RevokeAllSessionsOnPasswordChange = true
+	service := NewSecurityService(config)
+
+	if err := service.CreateInitialAdmin("admin", "AdminPassword123!", "admin@example.com"); err != nil {
+		t.Fatalf("Failed to create initial admin: %v", err)
+	}
+
+	current, err := service.Authenticate("admin", "AdminPassword123!", "192.168.1.1", "current-agent")
+	if err != nil || !current.Success {
+		t.Fatalf("Failed to authenticate: %v", err)
+	}
+
+	if err := service.ChangePassword("admin", "AdminPassword123!", "NewPassword456!", current.SessionID); err != nil {
+		t.Fatalf("Failed to change password: %v", err)
+	}
+
+	if _, err := service.ValidateSession(current.SessionID); err == nil {
+		t.Fatal("Current session should have been revoked")
+	}
+}
+
+func TestSecurityService_ResetPasswordRevokesAllSessions(t *testing.T) {
+	config := testAuthConfig()
+	config.AllowMultipleSessions = true
+	config.MaxSessions = 5
+	service := NewSecurityService(config)
+
+	if err := service.CreateInitialAdmin("admin", "AdminPassword123!", "admin@example.com"); err != nil {
+		t.Fatalf("Failed to create initial admin: %v", err)
+	}
+
+	first, _ := service.Authenticate("admin", "AdminPassword123!", "192.168.1.1", "agent-1")
+	second, _ := service.Authenticate("admin", "AdminPassword123!", "192.168.1.2", "agent-2")
+
+	if err := service.ResetPassword("admin", "ResetPassword789!"); err != nil {
+		t.Fatalf("Failed to reset password: %v", err)
+	}
+
+	for _, sessionID := range []string{first.SessionID, second.SessionID} {
+		if _, err := service.ValidateSession(sessionID); err == nil {
+			t.Fatalf("Session %s should have been revoked", sessionID)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"parental-control/internal/logging"
+)
+
+// Used when AuthConfig leaves the recovery settings unset
+const (
+	defaultRecoveryTokenTTL    = 15 * time.Minute
+	defaultRecoveryMaxAttempts = 5
+)
+
+// recoveryToken is an outstanding password recovery token. Only its hash is
+// kept; the token itself is printed once to the service output.
+type recoveryToken struct {
+	userID    int
+	hash      [sha256.Size]byte
+	expiresAt time.Time
+	attempts  int
+}
+
+// IssueRecoveryToken creates a single-use token that resets username's
+// password and prints it to the service output, so only someone with access
+// to the host can use it. Issuing a token replaces any outstanding one.
+// Unknown users get no token, but no error either, so the endpoint cannot be
+// used to discover usernames.
+func (ss *SecurityService) IssueRecoveryToken(username, ipAddress, userAgent string) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	if !ss.checkRateLimit(ipAddress) {
+		return ErrRateLimitExceeded
+	}
+
+	user, exists := ss.users[username]
+	if !exists || !user.IsActive {
+		ss.logSecurityEvent(&SecurityEvent{
+			EventType:   EventTypeRecoveryFailed,
+			Description: fmt.Sprintf("Recovery token requested for unknown or inactive user %s", username),
+			IPAddress:   ipAddress,
+			UserAgent:   userAgent,
+			Severity:    SeverityHigh,
+			Timestamp:   time.Now(),
+		})
+		return nil
+	}
+
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return fmt.Errorf("failed to generate recovery token: %w", err)
+	}
+	token := hex.EncodeToString(bytes)
+
+	ttl := ss.config.RecoveryTokenTTL
+	if ttl <= 0 {
+		ttl = defaultRecoveryTokenTTL
+	}
+	ss.recoveryToken = &recoveryToken{
+		userID:    user.ID,
+		hash:      sha256.Sum256([]byte(token)),
+		expiresAt: time.Now().Add(ttl),
+	}
+
+	ss.logSecurityEvent(&SecurityEvent{
+		UserID:      &user.ID,
+		EventType:   EventTypeRecoveryIssued,
+		Description: "Password recovery token issued",
+		IPAddress:   ipAddress,
+		UserAgent:   userAgent,
+		Severity:    SeverityHigh,
+		Timestamp:   time.Now(),
+	})
+
+	// The token goes to stdout only; the structured log may be shipped off the host
+	logging.Warn("Password recovery token issued",
+		logging.String("username", username),
+		logging.Duration("valid_for", ttl))
+	fmt.Fprintf(ss.recoveryOutput, "Password recovery token for %q (valid for %s, single use): %s\n", username, ttl, token)
+
+	return nil
+}
+
+// ResetPasswordWithToken consumes a recovery token to set a new password.
+// A wrong token counts against the outstanding one, which is invalidated
+// after the configured number of attempts. A new password that fails the
+// strength checks leaves the token usable.
+func (ss *SecurityService) ResetPasswordWithToken(token, newPassword, ipAddress, userAgent string) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	if !ss.checkRateLimit(ipAddress) {
+		return ErrRateLimitExceeded
+	}
+
+	pending := ss.recoveryToken
+	if pending == nil || time.Now().After(pending.expiresAt) {
+		ss.recoveryToken = nil
+		ss.logRecoveryFailure(nil, "no valid recovery token outstanding", ipAddress, userAgent)
+		return ErrInvalidRecoveryToken
+	}
+
+	hash := sha256.Sum256([]byte(token))
+	if subtle.ConstantTimeCompare(hash[:], pending.hash[:]) != 1 {
+		pending.attempts++
+		maxAttempts := ss.config.RecoveryMaxAttempts
+		if maxAttempts <= 0 {
+			maxAttempts = defaultRecoveryMaxAttempts
+		}
+		reason := "wrong recovery token"
+		if pending.attempts >= maxAttempts {
+			ss.recoveryToken = nil
+			reason = "wrong recovery token; token invalidated after too many attempts"
+		}
+		ss.logRecoveryFailure(&pending.userID, reason, ipAddress, userAgent)
+		return ErrInvalidRecoveryToken
+	}
+
+	user := ss.findUserByID(pending.userID)
+	if user == nil || !user.IsActive {
+		ss.recoveryToken = nil
+		ss.logRecoveryFailure(&pending.userID, "user no longer active", ipAddress, userAgent)
+		return ErrInvalidRecoveryToken
+	}
+
+	if err := ss.resetPasswordInternal(user, newPassword, ipAddress, userAgent); err != nil {
+		return err
+	}
+	ss.recoveryToken = nil
+
+	return nil
+}
+
+// logRecoveryFailure records a failed recovery attempt (mutex must be held)
+func (ss *SecurityService) logRecoveryFailure(userID *int, reason, ipAddress, userAgent string) {
+	ss.logSecurityEvent(&SecurityEvent{
+		UserID:      userID,
+		EventType:   EventTypeRecoveryFailed,
+		Description: "Password reset failed: " + reason,
+		IPAddress:   ipAddress,
+		UserAgent:   userAgent,
+		Severity:    SeverityHigh,
+		Timestamp:   time.Now(),
+	})
+
+	logging.Warn("Password reset with recovery token failed",
+		logging.String("reason", reason),
+		logging.String("ip_address", ipAddress))
+}
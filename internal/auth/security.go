@@ -1,11 +1,17 @@
 package auth
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"parental-control/internal/logging"
+	"parental-control/internal/models"
 )
 
 // SecurityService handles authentication security features
@@ -14,15 +20,42 @@ type SecurityService struct {
 	passwordManager *PasswordManager
 	sessionManager  *SessionManager
 
-	// In-memory stores (would be replaced with database in production)
-	users          map[string]*User    // username -> user
-	sessions       map[string]*Session // session_id -> session (legacy, migrating to SessionManager)
+	// Users by username, persisted to userStore when one is set
+	users     map[string]*User
+	userStore models.UserRepository
+
+	sessions map[string]*Session // session_id -> session (legacy, migrating to SessionManager)
+
+	// Recent login attempts and security events, capped at the configured
+	// history sizes. They have their own lock because they are appended from
+	// paths that hold mu for reading or not at all; historyMu is always taken
+	// after mu, never before.
 	loginAttempts  []LoginAttempt
 	securityEvents []SecurityEvent
+	nextAttemptID  int
+	nextEventID    int
+	historyMu      sync.Mutex
 
 	// Rate limiting
 	rateLimiter map[string]*rateLimitEntry // IP -> rate limit data
 
+	// Lockout escalation and alerting
+	lockoutStates    map[string]models.LockoutState // username -> escalation state
+	lockoutStore     models.LockoutStateRepository
+	lockoutNotifiers []LockoutNotifier
+
+	// Logins that passed the password check and are waiting for a
+	// two-factor code, by challenge token
+	twoFactorChallenges map[string]*twoFactorChallenge
+
+	// Where API keys are kept, by hash of the key
+	apiKeyStore models.APIKeyRepository
+
+	// The outstanding password recovery token, if any, and where new tokens
+	// are printed
+	recoveryToken  *recoveryToken
+	recoveryOutput io.Writer
+
 	mu sync.RWMutex
 }
 
@@ -35,34 +68,80 @@ type rateLimitEntry struct {
 // NewSecurityService creates a new security service
 func NewSecurityService(config AuthConfig) *SecurityService {
 	return &SecurityService{
-		config:          config,
-		passwordManager: NewPasswordManager(config.Password),
-		sessionManager:  NewSessionManager(config),
-		users:           make(map[string]*User),
-		sessions:        make(map[string]*Session),
-		loginAttempts:   make([]LoginAttempt, 0),
-		securityEvents:  make([]SecurityEvent, 0),
-		rateLimiter:     make(map[string]*rateLimitEntry),
+		config:           config,
+		passwordManager:  NewPasswordManager(config.Password),
+		sessionManager:   NewSessionManager(config),
+		users:            make(map[string]*User),
+		sessions:         make(map[string]*Session),
+		loginAttempts:    make([]LoginAttempt, 0),
+		securityEvents:   make([]SecurityEvent, 0),
+		rateLimiter:      make(map[string]*rateLimitEntry),
+		lockoutStates:    make(map[string]models.LockoutState),
+		lockoutNotifiers: NewLockoutNotifiers(config.LockoutNotify),
+
+		twoFactorChallenges: make(map[string]*twoFactorChallenge),
+		recoveryOutput:      os.Stdout,
 	}
 }
 
-// CreateInitialAdmin creates the initial admin user if no users exist
+// NeedsInitialSetup reports whether no users exist yet, so the first admin
+// still has to be created through initial setup
+func (ss *SecurityService) NeedsInitialSetup() bool {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+	return len(ss.users) == 0
+}
+
+// CreateInitialAdmin creates the initial admin user if no users exist. The
+// password must meet the strength requirements.
 func (ss *SecurityService) CreateInitialAdmin(username, password, email string) error {
 	ss.mu.Lock()
 	defer ss.mu.Unlock()
 
-	// Check if any users exist
-	if len(ss.users) > 0 {
-		return fmt.Errorf("users already exist, cannot create initial admin")
+	if err := ss.checkInitialAdmin(username, password); err != nil {
+		return err
 	}
 
-	// Create admin user
-	now := time.Now()
 	passwordHash, err := ss.passwordManager.SetPassword(password)
 	if err != nil {
 		return fmt.Errorf("failed to hash admin password: %w", err)
 	}
+	return ss.createInitialAdminInternal(username, passwordHash, email)
+}
+
+// SeedAdminFromConfig creates the initial admin from the configured
+// credentials if no users exist. Unlike CreateInitialAdmin it accepts a
+// stored bcrypt hash, as written by admin-reset, and uses it as is.
+func (ss *SecurityService) SeedAdminFromConfig(username, password, email string) error {
+	if !IsPasswordHash(password) {
+		return ss.CreateInitialAdmin(username, password, email)
+	}
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	if err := ss.checkInitialAdmin(username, password); err != nil {
+		return err
+	}
+	return ss.createInitialAdminInternal(username, password, email)
+}
+
+// checkInitialAdmin reports why an initial admin cannot be created (mutex
+// must be held)
+func (ss *SecurityService) checkInitialAdmin(username, password string) error {
+	if len(ss.users) > 0 {
+		return fmt.Errorf("users already exist, cannot create initial admin")
+	}
+	if username == "" || password == "" {
+		return fmt.Errorf("username and password are required")
+	}
+	return nil
+}
 
+// createInitialAdminInternal stores the first admin with an already hashed
+// password (mutex must be held)
+func (ss *SecurityService) createInitialAdminInternal(username, passwordHash, email string) error {
+	now := time.Now()
 	admin := &User{
 		ID:                1, // First user gets ID 1
 		Username:          username,
@@ -75,6 +154,14 @@ func (ss *SecurityService) CreateInitialAdmin(username, password, email string)
 		UpdatedAt:         now,
 	}
 
+	if state, exists := ss.lockoutStates[username]; exists && state.LockedUntil != nil {
+		lockedUntil := *state.LockedUntil
+		admin.LockedUntil = &lockedUntil
+	}
+
+	if err := ss.createStoredUser(admin); err != nil {
+		return err
+	}
 	ss.users[username] = admin
 
 	// Log security event
@@ -144,7 +231,7 @@ func (ss *SecurityService) Authenticate(username, password, ipAddress, userAgent
 
 	// Verify password
 	if err := ss.passwordManager.VerifyPassword(password, user.PasswordHash); err != nil {
-		ss.handleFailedLogin(user, ipAddress, userAgent)
+		ss.handleFailedLogin(user, ipAddress, userAgent, "invalid password")
 		return &LoginResponse{
 			Success: false,
 			Message: "Invalid username or password",
@@ -160,12 +247,22 @@ func (ss *SecurityService) Authenticate(username, password, ipAddress, userAgent
 		}, nil
 	}
 
+	// Upgrade hashes made before the bcrypt cost was raised
+	ss.rehashPassword(user, password)
+
+	// Hold the session back until the second factor is checked
+	if user.TwoFactorEnabled {
+		return ss.startTwoFactorChallenge(user)
+	}
+
 	// Successful login
 	return ss.handleSuccessfulLogin(user, ipAddress, userAgent)
 }
 
-// ChangePassword changes a user's password
-func (ss *SecurityService) ChangePassword(username, currentPassword, newPassword string) error {
+// ChangePassword changes a user's password. Other sessions belonging to the
+// user are revoked; currentSessionID is kept unless the configuration asks for
+// every session to be revoked.
+func (ss *SecurityService) ChangePassword(username, currentPassword, newPassword, currentSessionID string) error {
 	ss.mu.Lock()
 	defer ss.mu.Unlock()
 
@@ -196,6 +293,7 @@ func (ss *SecurityService) ChangePassword(username, currentPassword, newPassword
 	user.PasswordHash = newHash
 	user.PasswordChangedAt = time.Now()
 	user.UpdatedAt = time.Now()
+	ss.saveUser(user)
 
 	// Log security event
 	ss.logSecurityEvent(&SecurityEvent{
@@ -208,9 +306,272 @@ func (ss *SecurityService) ChangePassword(username, currentPassword, newPassword
 
 	logging.Info("Password changed", logging.String("username", username))
 
+	keepSessionID := currentSessionID
+	if ss.config.RevokeAllSessionsOnPasswordChange {
+		keepSessionID = ""
+	}
+	ss.revokeSessionsInternal(user, keepSessionID, "password change")
+
 	return nil
 }
 
+// ListUsers returns up to limit users ordered by ID, starting at offset, and
+// the total number of users. A limit of zero or less returns all of them.
+func (ss *SecurityService) ListUsers(offset, limit int) ([]UserInfo, int) {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+
+	users := make([]UserInfo, 0, len(ss.users))
+	for _, user := range ss.users {
+		users = append(users, user.Info())
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+
+	total := len(users)
+	if offset > total {
+		offset = total
+	}
+	end := total
+	if limit > 0 && offset+limit < total {
+		end = offset + limit
+	}
+	return users[offset:end], total
+}
+
+// CreateUser adds a user after checking the password against the configured
+// strength policy. It returns ErrUserExists for a taken username and an error
+// matching ErrPasswordTooWeak for a weak password.
+func (ss *SecurityService) CreateUser(req AdminUserRequest) (*UserInfo, error) {
+	if req.Username == "" || req.Password == "" {
+		return nil, fmt.Errorf("username and password are required")
+	}
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	if _, exists := ss.users[req.Username]; exists {
+		return nil, ErrUserExists
+	}
+
+	if err := ss.passwordManager.hasher.ValidatePasswordStrength(req.Password); err != nil {
+		return nil, err
+	}
+	passwordHash, err := ss.passwordManager.hasher.generateHash(req.Password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	nextID := 1
+	for _, user := range ss.users {
+		if user.ID >= nextID {
+			nextID = user.ID + 1
+		}
+	}
+
+	now := time.Now()
+	user := &User{
+		ID:                nextID,
+		Username:          req.Username,
+		PasswordHash:      passwordHash,
+		Email:             req.Email,
+		IsActive:          req.IsActive == nil || *req.IsActive,
+		IsAdmin:           req.IsAdmin,
+		PasswordChangedAt: now,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+	if err := ss.createStoredUser(user); err != nil {
+		return nil, err
+	}
+	ss.users[user.Username] = user
+
+	ss.logSecurityEvent(&SecurityEvent{
+		UserID:      &user.ID,
+		EventType:   EventTypeUserCreated,
+		Description: "User created",
+		Severity:    SeverityMedium,
+		Timestamp:   now,
+	})
+
+	logging.Info("User created",
+		logging.String("username", user.Username),
+		logging.Bool("is_admin", user.IsAdmin))
+
+	info := user.Info()
+	return &info, nil
+}
+
+// DeleteUser removes a user and revokes all of their sessions. It returns
+// ErrLastAdmin rather than remove the only remaining active admin.
+func (ss *SecurityService) DeleteUser(userID int) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	user := ss.findUserByID(userID)
+	if user == nil {
+		return ErrUserNotFound
+	}
+	if ss.isLastActiveAdmin(user) {
+		return ErrLastAdmin
+	}
+
+	if ss.userStore != nil {
+		if err := ss.userStore.Delete(context.Background(), user.ID); err != nil {
+			return fmt.Errorf("failed to delete stored user: %w", err)
+		}
+	}
+	delete(ss.users, user.Username)
+	ss.clearLockoutState(user.Username)
+	ss.revokeUserAPIKeysInternal(user.ID, "revoked with its deleted user")
+	if err := ss.RevokeUserSessions(user.ID); err != nil {
+		logging.Warn("Failed to revoke sessions of deleted user",
+			logging.String("username", user.Username),
+			logging.Err(err))
+	}
+	for _, session := range ss.sessions {
+		if session.UserID == user.ID {
+			session.IsActive = false
+			session.UpdatedAt = time.Now()
+		}
+	}
+
+	ss.logSecurityEvent(&SecurityEvent{
+		UserID:      &user.ID,
+		EventType:   EventTypeUserDeleted,
+		Description: fmt.Sprintf("User %s deleted", user.Username),
+		Severity:    SeverityHigh,
+		Timestamp:   time.Now(),
+	})
+
+	return nil
+}
+
+// SetUserActive activates or deactivates a user. Deactivating revokes the
+// user's sessions, and returns ErrLastAdmin for the only remaining active admin.
+func (ss *SecurityService) SetUserActive(userID int, active bool) (*UserInfo, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	user := ss.findUserByID(userID)
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	if user.IsActive != active {
+		if !active && ss.isLastActiveAdmin(user) {
+			return nil, ErrLastAdmin
+		}
+
+		user.IsActive = active
+		user.UpdatedAt = time.Now()
+		ss.saveUser(user)
+
+		event := &SecurityEvent{
+			UserID:      &user.ID,
+			EventType:   EventTypeUserActivated,
+			Description: fmt.Sprintf("User %s activated", user.Username),
+			Severity:    SeverityMedium,
+			Timestamp:   time.Now(),
+		}
+		if !active {
+			event.EventType = EventTypeUserDeactivated
+			event.Description = fmt.Sprintf("User %s deactivated", user.Username)
+		}
+		ss.logSecurityEvent(event)
+
+		if !active {
+			ss.revokeSessionsInternal(user, "", "account deactivation")
+		}
+	}
+
+	info := user.Info()
+	return &info, nil
+}
+
+// findUserByID returns the user with the given ID, or nil (mutex must be held)
+func (ss *SecurityService) findUserByID(userID int) *User {
+	for _, user := range ss.users {
+		if user.ID == userID {
+			return user
+		}
+	}
+	return nil
+}
+
+// isLastActiveAdmin reports whether user is the only active admin (mutex
+// must be held)
+func (ss *SecurityService) isLastActiveAdmin(user *User) bool {
+	if !user.IsAdmin || !user.IsActive {
+		return false
+	}
+	for _, other := range ss.users {
+		if other != user && other.IsAdmin && other.IsActive {
+			return false
+		}
+	}
+	return true
+}
+
+// ResetPassword sets a new password for a user without verifying the current
+// one. All of the user's sessions are revoked unconditionally.
+func (ss *SecurityService) ResetPassword(username, newPassword string) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	user, exists := ss.users[username]
+	if !exists {
+		return ErrUserNotFound
+	}
+
+	return ss.resetPasswordInternal(user, newPassword, "", "")
+}
+
+// resetPasswordInternal sets a new password, clears any lockout and revokes
+// every session of the user (mutex must be held)
+func (ss *SecurityService) resetPasswordInternal(user *User, newPassword, ipAddress, userAgent string) error {
+	newHash, err := ss.passwordManager.SetPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	user.PasswordHash = newHash
+	user.PasswordChangedAt = time.Now()
+	user.FailedAttempts = 0
+	user.LockedUntil = nil
+	user.UpdatedAt = time.Now()
+	ss.saveUser(user)
+	ss.clearLockoutState(user.Username)
+
+	ss.logSecurityEvent(&SecurityEvent{
+		UserID:      &user.ID,
+		EventType:   EventTypePasswordReset,
+		Description: "Password reset",
+		IPAddress:   ipAddress,
+		UserAgent:   userAgent,
+		Severity:    SeverityHigh,
+		Timestamp:   time.Now(),
+	})
+
+	logging.Info("Password reset", logging.String("username", user.Username))
+
+	ss.revokeSessionsInternal(user, "", "password reset")
+
+	return nil
+}
+
+// LogoutAll revokes every session belonging to the user, including the current one
+func (ss *SecurityService) LogoutAll(userID int) int {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	for _, user := range ss.users {
+		if user.ID == userID {
+			return ss.revokeSessionsInternal(user, "", "logout from all devices")
+		}
+	}
+	return 0
+}
+
 // CreateSession creates a new session for the user using the enhanced session manager
 func (ss *SecurityService) CreateSession(userID int, ipAddress, userAgent string, rememberMe bool) (*Session, error) {
 	return ss.sessionManager.CreateSession(userID, ipAddress, userAgent, rememberMe)
@@ -326,6 +687,13 @@ func (ss *SecurityService) RevokeUserSessions(userID int) error {
 	return ss.sessionManager.RevokeUserSessions(userID)
 }
 
+// RecordSessionActivity records the IP address and user agent a session was
+// used from, so sessions that move between addresses can be flagged. Legacy
+// sessions are not tracked.
+func (ss *SecurityService) RecordSessionActivity(sessionID, ipAddress, userAgent string) {
+	ss.sessionManager.UpdateSessionActivity(sessionID, ipAddress, userAgent)
+}
+
 // GetUserSessions returns all active sessions for a user
 func (ss *SecurityService) GetUserSessions(userID int) ([]*Session, error) {
 	return ss.sessionManager.GetUserSessions(userID)
@@ -383,6 +751,12 @@ func (ss *SecurityService) CleanupExpiredSessions() int {
 	return totalCleaned
 }
 
+// SessionStats returns the number of users, active sessions and locked accounts
+func (ss *SecurityService) SessionStats() (users, activeSessions, lockedAccounts int) {
+	stats := ss.GetSecurityStats()
+	return stats.TotalUsers, stats.ActiveSessions, stats.LockedAccounts
+}
+
 // GetSecurityStats returns security statistics including enhanced session data
 func (ss *SecurityService) GetSecurityStats() SecurityStatsResponse {
 	ss.mu.RLock()
@@ -412,6 +786,9 @@ func (ss *SecurityService) GetSecurityStats() SecurityStatsResponse {
 		}
 	}
 
+	ss.historyMu.Lock()
+	defer ss.historyMu.Unlock()
+
 	// Count recent login attempts (last hour)
 	recentTime := time.Now().Add(-time.Hour)
 	for _, attempt := range ss.loginAttempts {
@@ -439,12 +816,14 @@ func (ss *SecurityService) Stop() {
 // Helper methods
 
 func (ss *SecurityService) handleSuccessfulLogin(user *User, ipAddress, userAgent string) (*LoginResponse, error) {
-	// Reset failed attempts
+	// Reset failed attempts and lockout escalation
 	user.FailedAttempts = 0
 	user.LockedUntil = nil
+	ss.clearLockoutState(user.Username)
 	user.LastLoginAt = &time.Time{}
 	*user.LastLoginAt = time.Now()
 	user.UpdatedAt = time.Now()
+	ss.saveUser(user)
 
 	// Create session using internal method (mutex already locked)
 	session, err := ss.createSessionInternal(user.ID, ipAddress, userAgent, false)
@@ -466,35 +845,54 @@ func (ss *SecurityService) handleSuccessfulLogin(user *User, ipAddress, userAgen
 		Timestamp:   time.Now(),
 	})
 
+	info := user.Info()
 	return &LoginResponse{
 		Success:   true,
 		Message:   "Login successful",
 		SessionID: session.ID,
+		Token:     session.ID,
 		ExpiresAt: session.ExpiresAt,
-		User: &UserInfo{
-			ID:          user.ID,
-			Username:    user.Username,
-			Email:       user.Email,
-			IsAdmin:     user.IsAdmin,
-			LastLoginAt: user.LastLoginAt,
-			CreatedAt:   user.CreatedAt,
-		},
+		User:      &info,
 	}, nil
 }
 
-func (ss *SecurityService) handleFailedLogin(user *User, ipAddress, userAgent string) {
+// rehashPassword replaces the user's stored hash when it was made with a
+// lower bcrypt cost than configured. Failures are logged and leave the old
+// hash in place, so they never block a login.
+func (ss *SecurityService) rehashPassword(user *User, password string) {
+	hash, changed, err := ss.passwordManager.Rehash(password, user.PasswordHash)
+	if err != nil {
+		logging.Warn("Failed to upgrade password hash",
+			logging.String("username", user.Username),
+			logging.Err(err))
+		return
+	}
+	if !changed {
+		return
+	}
+
+	user.PasswordHash = hash
+	user.UpdatedAt = time.Now()
+	ss.saveUser(user)
+	logging.Info("Upgraded password hash to the configured bcrypt cost",
+		logging.String("username", user.Username),
+		logging.Int("bcrypt_cost", ss.config.Password.BcryptCost))
+}
+
+func (ss *SecurityService) handleFailedLogin(user *User, ipAddress, userAgent, reason string) {
 	user.FailedAttempts++
 	user.UpdatedAt = time.Now()
 
+	ss.recordLoginAttempt(user.Username, ipAddress, userAgent, false, reason)
+
 	// Check if account should be locked
 	if user.FailedAttempts >= ss.config.MaxFailedAttempts {
-		lockUntil := time.Now().Add(ss.config.LockoutDuration)
-		user.LockedUntil = &lockUntil
+		event := ss.lockAccount(user, ipAddress, time.Now())
 
 		ss.logSecurityEvent(&SecurityEvent{
 			UserID:      &user.ID,
 			EventType:   EventTypeAccountLocked,
-			Description: fmt.Sprintf("Account locked after %d failed attempts", user.FailedAttempts),
+			Description: fmt.Sprintf("Account locked for %s after %d failed attempts", event.Duration, user.FailedAttempts),
 			IPAddress:   ipAddress,
 			UserAgent:   userAgent,
 			Severity:    SeverityHigh,
@@ -503,15 +901,28 @@ func (ss *SecurityService) handleFailedLogin(user *User, ipAddress, userAgent st
 
 		logging.Warn("Account locked due to failed login attempts",
 			logging.String("username", user.Username),
-			logging.Int("attempts", user.FailedAttempts))
+			logging.Int("attempts", user.FailedAttempts),
+			logging.Int("lockout_count", event.LockoutCount),
+			logging.String("locked_until", event.LockedUntil.Format(time.RFC3339)),
+			logging.String("source_ips", strings.Join(event.SourceIPs, ",")),
+			logging.String("trigger_ip", ipAddress))
+
+		// Start counting afresh once the lockout expires
+		user.FailedAttempts = 0
+
+		ss.notifyLockout(ss.lockoutNotifiers, event)
 	}
 
-	ss.recordLoginAttempt(user.Username, ipAddress, userAgent, false, "invalid password")
+	ss.saveUser(user)
 }
 
 func (ss *SecurityService) recordLoginAttempt(username, ipAddress, userAgent string, success bool, failReason string) {
+	ss.historyMu.Lock()
+	defer ss.historyMu.Unlock()
+
+	ss.nextAttemptID++
 	attempt := LoginAttempt{
-		ID:         len(ss.loginAttempts) + 1,
+		ID:         ss.nextAttemptID,
 		Username:   username,
 		IPAddress:  ipAddress,
 		UserAgent:  userAgent,
@@ -522,20 +933,23 @@ func (ss *SecurityService) recordLoginAttempt(username, ipAddress, userAgent str
 
 	ss.loginAttempts = append(ss.loginAttempts, attempt)
 
-	// Keep only recent attempts (last 1000)
-	if len(ss.loginAttempts) > 1000 {
-		ss.loginAttempts = ss.loginAttempts[len(ss.loginAttempts)-1000:]
+	// Keep only recent attempts
+	if limit := historySize(ss.config.LoginAttemptHistorySize); len(ss.loginAttempts) > limit {
+		ss.loginAttempts = ss.loginAttempts[len(ss.loginAttempts)-limit:]
 	}
 }
 
 func (ss *SecurityService) logSecurityEvent(event *SecurityEvent) {
-	event.ID = len(ss.securityEvents) + 1
+	ss.historyMu.Lock()
+	ss.nextEventID++
+	event.ID = ss.nextEventID
 	ss.securityEvents = append(ss.securityEvents, *event)
 
-	// Keep only recent events (last 1000)
-	if len(ss.securityEvents) > 1000 {
-		ss.securityEvents = ss.securityEvents[len(ss.securityEvents)-1000:]
+	// Keep only recent events
+	if limit := historySize(ss.config.EventHistorySize); len(ss.securityEvents) > limit {
+		ss.securityEvents = ss.securityEvents[len(ss.securityEvents)-limit:]
 	}
+	ss.historyMu.Unlock()
 
 	// Log to system logger based on severity
 	switch event.Severity {
@@ -556,6 +970,36 @@ func (ss *SecurityService) logSecurityEvent(event *SecurityEvent) {
 	}
 }
 
+// revokeSessionsInternal revokes the user's sessions other than keepSessionID
+// and records a security event (mutex must be held)
+func (ss *SecurityService) revokeSessionsInternal(user *User, keepSessionID, reason string) int {
+	revoked := ss.sessionManager.RevokeUserSessionsExcept(user.ID, keepSessionID)
+
+	// Legacy sessions are deactivated as well
+	for id, session := range ss.sessions {
+		if session.UserID == user.ID && id != keepSessionID && session.IsActive {
+			session.IsActive = false
+			session.UpdatedAt = time.Now()
+			revoked++
+		}
+	}
+
+	description := fmt.Sprintf("Revoked %d session(s) after %s", revoked, reason)
+	if keepSessionID != "" {
+		description += " (current session kept)"
+	}
+
+	ss.logSecurityEvent(&SecurityEvent{
+		UserID:      &user.ID,
+		EventType:   EventTypeSessionRevoked,
+		Description: description,
+		Severity:    SeverityMedium,
+		Timestamp:   time.Now(),
+	})
+
+	return revoked
+}
+
 func (ss *SecurityService) checkRateLimit(ipAddress string) bool {
 	now := time.Now()
 	entry, exists := ss.rateLimiter[ipAddress]
@@ -597,9 +1041,12 @@ type SessionListResponse struct {
 	Total    int           `json:"total"`
 }
 
-// SessionInfo represents public session information
+// SessionInfo represents public session information. The owner and IP
+// history are only filled in for the admin listing of all sessions.
 type SessionInfo struct {
 	ID           string    `json:"id"`
+	UserID       int       `json:"user_id,omitempty"`
+	Username     string    `json:"username,omitempty"`
 	IPAddress    string    `json:"ip_address"`
 	UserAgent    string    `json:"user_agent"`
 	CreatedAt    time.Time `json:"created_at"`
@@ -607,6 +1054,28 @@ type SessionInfo struct {
 	ExpiresAt    time.Time `json:"expires_at"`
 	IsActive     bool      `json:"is_active"`
 	IsCurrent    bool      `json:"is_current"`
+	IPAddresses  []string  `json:"ip_addresses,omitempty"`
+	IPChanged    bool      `json:"ip_changed"`
+}
+
+// GetAllSessions returns every active session across all users with the
+// owning username resolved, most recently active first. Sessions used from
+// more than one IP address are flagged with IPChanged.
+func (ss *SecurityService) GetAllSessions(currentSessionID string) []SessionInfo {
+	sessions := ss.sessionManager.GetAllSessions()
+
+	ss.mu.RLock()
+	usernames := make(map[int]string, len(ss.users))
+	for _, user := range ss.users {
+		usernames[user.ID] = user.Username
+	}
+	ss.mu.RUnlock()
+
+	for i := range sessions {
+		sessions[i].Username = usernames[sessions[i].UserID]
+		sessions[i].IsCurrent = sessions[i].ID == currentSessionID
+	}
+	return sessions
 }
 
 // GetUserSessionsInfo returns formatted session information for a user
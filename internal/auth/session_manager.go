@@ -230,6 +230,35 @@ func (sm *SessionManager) RevokeUserSessions(userID int) error {
 	return nil
 }
 
+// RevokeUserSessionsExcept revokes all sessions for a user other than keepSessionID
+// and returns the number of sessions revoked
+func (sm *SessionManager) RevokeUserSessionsExcept(userID int, keepSessionID string) int {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	sessionIDs, exists := sm.userSessions[userID]
+	if !exists {
+		return 0
+	}
+
+	revoked := 0
+	for _, sessionID := range append([]string(nil), sessionIDs...) {
+		if sessionID == keepSessionID {
+			continue
+		}
+		if err := sm.removeSessionInternal(sessionID); err == nil {
+			revoked++
+		}
+	}
+
+	logging.Info("User sessions revoked",
+		logging.Int("user_id", userID),
+		logging.Int("session_count", revoked),
+		logging.Bool("kept_current", keepSessionID != ""))
+
+	return revoked
+}
+
 // GetUserSessions returns all active sessions for a user
 func (sm *SessionManager) GetUserSessions(userID int) ([]*Session, error) {
 	sm.mu.RLock()
@@ -250,6 +279,47 @@ func (sm *SessionManager) GetUserSessions(userID int) ([]*Session, error) {
 	return sessions, nil
 }
 
+// GetAllSessions returns every active session across all users, most
+// recently active first. Usernames are left for the caller to resolve.
+func (sm *SessionManager) GetAllSessions() []SessionInfo {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	sessions := make([]SessionInfo, 0, len(sm.sessions))
+	for _, session := range sm.sessions {
+		if !session.IsValid() {
+			continue
+		}
+
+		info := SessionInfo{
+			ID:           session.ID,
+			UserID:       session.UserID,
+			IPAddress:    session.IPAddress,
+			UserAgent:    session.UserAgent,
+			CreatedAt:    session.CreatedAt,
+			LastActivity: session.UpdatedAt,
+			ExpiresAt:    session.ExpiresAt,
+			IsActive:     session.IsActive,
+			IPAddresses:  []string{session.IPAddress},
+		}
+		if metrics, exists := sm.sessionMetrics[session.ID]; exists {
+			info.LastActivity = metrics.LastActivity
+			info.IPAddresses = append([]string(nil), metrics.IPAddresses...)
+		}
+		info.IPChanged = len(info.IPAddresses) > 1
+
+		sessions = append(sessions, info)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		if !sessions[i].LastActivity.Equal(sessions[j].LastActivity) {
+			return sessions[i].LastActivity.After(sessions[j].LastActivity)
+		}
+		return sessions[i].ID < sessions[j].ID
+	})
+	return sessions
+}
+
 // UpdateSessionActivity updates session activity with new IP/User-Agent
 func (sm *SessionManager) UpdateSessionActivity(sessionID, ipAddress, userAgent string) error {
 	sm.mu.Lock()
@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTOTPCode(t *testing.T) {
+	// RFC 6238 appendix B SHA-1 vectors, truncated to six digits
+	secret := []byte("12345678901234567890")
+	tests := []struct {
+		unix     int64
+		expected string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, tt := range tests {
+		if got := totpCode(secret, totpStep(time.Unix(tt.unix, 0))); got != tt.expected {
+			t.Errorf("totpCode at %d = %s, want %s", tt.unix, got, tt.expected)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret := []byte("12345678901234567890")
+	now := time.Unix(1234567890, 0)
+	current := totpStep(now)
+
+	if step, ok := validateTOTP(secret, totpCode(secret, current), now, 0); !ok || step != current {
+		t.Errorf("Expected the current code to validate, got step %d, %v", step, ok)
+	}
+	if _, ok := validateTOTP(secret, totpCode(secret, current-1), now, 0); !ok {
+		t.Error("Expected the previous period's code to be accepted for clock drift")
+	}
+	if _, ok := validateTOTP(secret, totpCode(secret, current-2), now, 0); ok {
+		t.Error("Expected a code two periods old to be rejected")
+	}
+	if _, ok := validateTOTP(secret, totpCode(secret, current), now, current); ok {
+		t.Error("Expected a code that was already used to be rejected")
+	}
+	if _, ok := validateTOTP(secret, "", now, 0); ok {
+		t.Error("Expected an empty code to be rejected")
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri, err := url.Parse(totpURI("parent", []byte("12345678901234567890")))
+	if err != nil {
+		t.Fatalf("Failed to parse URI: %v", err)
+	}
+
+	if uri.Scheme != "otpauth" || uri.Host != "totp" || uri.Path != "/Parental Control:parent" {
+		t.Errorf("Unexpected URI: %s", uri)
+	}
+	if secret := uri.Query().Get("secret"); secret != "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ" {
+		t.Errorf("Unexpected secret: %s", secret)
+	}
+	if issuer := uri.Query().Get("issuer"); issuer != "Parental Control" {
+		t.Errorf("Unexpected issuer: %s", issuer)
+	}
+}
+
+func TestEncryptTOTPSecret(t *testing.T) {
+	key, err := totpKey("a-session-secret-that-is-long-enough")
+	if err != nil {
+		t.Fatalf("Failed to derive key: %v", err)
+	}
+	secret := []byte("12345678901234567890")
+
+	encrypted, err := encryptTOTPSecret(key, secret)
+	if err != nil {
+		t.Fatalf("Failed to encrypt: %v", err)
+	}
+	if strings.Contains(encrypted, string(secret)) {
+		t.Fatal("Encrypted secret should not contain the plaintext")
+	}
+
+	decrypted, err := decryptTOTPSecret(key, encrypted)
+	if err != nil || string(decrypted) != string(secret) {
+		t.Fatalf("Expected the secret back, got %q, %v", decrypted, err)
+	}
+
+	otherKey, _ := totpKey("a-different-session-secret-value")
+	if _, err := decryptTOTPSecret(otherKey, encrypted); err == nil {
+		t.Error("Expected decryption with another key to fail")
+	}
+	if _, err := totpKey(""); err != ErrTwoFactorUnavailable {
+		t.Errorf("Expected ErrTwoFactorUnavailable without a session secret, got %v", err)
+	}
+}
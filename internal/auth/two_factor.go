@@ -0,0 +1,229 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"parental-control/internal/logging"
+)
+
+const (
+	// twoFactorChallengeTTL is how long a user has to enter a code after
+	// their password is accepted
+	twoFactorChallengeTTL = 5 * time.Minute
+
+	// maxTwoFactorAttempts is how many wrong codes a challenge accepts before
+	// the password has to be entered again
+	maxTwoFactorAttempts = 5
+)
+
+// twoFactorChallenge is a login that passed the password check and is
+// waiting for a two-factor code
+type twoFactorChallenge struct {
+	userID    int
+	expiresAt time.Time
+	attempts  int
+}
+
+// EnrollTwoFactor starts two-factor enrollment for a user by generating a
+// new TOTP secret. The secret only protects logins once VerifyTwoFactor has
+// confirmed a code from it; enrolling again before then replaces it.
+func (ss *SecurityService) EnrollTwoFactor(userID int) (*TwoFactorEnrollResponse, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	user := ss.findUserByID(userID)
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	if user.TwoFactorEnabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	key, err := totpKey(ss.config.SessionSecret)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := encryptTOTPSecret(key, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	user.PendingTOTPSecret = encrypted
+	user.UpdatedAt = time.Now()
+
+	uri := totpURI(user.Username, secret)
+	return &TwoFactorEnrollResponse{
+		Secret:     totpEncoding.EncodeToString(secret),
+		OTPAuthURI: uri,
+		QRPayload:  uri,
+	}, nil
+}
+
+// VerifyTwoFactor activates two-factor authentication for a user once they
+// prove their authenticator app produces codes for the enrolled secret
+func (ss *SecurityService) VerifyTwoFactor(userID int, code string) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	user := ss.findUserByID(userID)
+	if user == nil {
+		return ErrUserNotFound
+	}
+	if user.TwoFactorEnabled {
+		return ErrTwoFactorAlreadyEnabled
+	}
+	if user.PendingTOTPSecret == "" {
+		return ErrTwoFactorNotEnrolled
+	}
+
+	key, err := totpKey(ss.config.SessionSecret)
+	if err != nil {
+		return err
+	}
+	secret, err := decryptTOTPSecret(key, user.PendingTOTPSecret)
+	if err != nil {
+		return err
+	}
+
+	step, ok := validateTOTP(secret, code, time.Now(), 0)
+	if !ok {
+		return ErrInvalidTwoFactorCode
+	}
+
+	user.TOTPSecret = user.PendingTOTPSecret
+	user.PendingTOTPSecret = ""
+	user.TwoFactorEnabled = true
+	user.LastTOTPStep = step
+	user.UpdatedAt = time.Now()
+	ss.saveUser(user)
+
+	ss.logSecurityEvent(&SecurityEvent{
+		UserID:      &user.ID,
+		EventType:   EventTypeTwoFactorEnabled,
+		Description: "Two-factor authentication enabled",
+		Severity:    SeverityMedium,
+		Timestamp:   time.Now(),
+	})
+
+	logging.Info("Two-factor authentication enabled", logging.String("username", user.Username))
+
+	return nil
+}
+
+// CompleteTwoFactorLogin finishes a login that Authenticate left waiting for
+// a two-factor code, creating the session if the code is valid
+func (ss *SecurityService) CompleteTwoFactorLogin(challengeToken, code, ipAddress, userAgent string) (*LoginResponse, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	if !ss.checkRateLimit(ipAddress) {
+		return &LoginResponse{
+			Success: false,
+			Message: "Too many login attempts. Please try again later.",
+		}, nil
+	}
+
+	challenge, exists := ss.twoFactorChallenges[challengeToken]
+	if !exists || time.Now().After(challenge.expiresAt) {
+		delete(ss.twoFactorChallenges, challengeToken)
+		return &LoginResponse{
+			Success: false,
+			Message: "Login has expired. Please enter your password again.",
+		}, nil
+	}
+
+	// The account may have changed since the password was accepted
+	user := ss.findUserByID(challenge.userID)
+	if user == nil || !user.IsActive || !user.TwoFactorEnabled {
+		delete(ss.twoFactorChallenges, challengeToken)
+		return &LoginResponse{
+			Success: false,
+			Message: "Login has expired. Please enter your password again.",
+		}, nil
+	}
+	if user.IsLocked() {
+		delete(ss.twoFactorChallenges, challengeToken)
+		ss.recordLoginAttempt(user.Username, ipAddress, userAgent, false, "account locked")
+		return &LoginResponse{
+			Success: false,
+			Message: "Account is temporarily locked. Please try again later.",
+		}, nil
+	}
+
+	key, err := totpKey(ss.config.SessionSecret)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := decryptTOTPSecret(key, user.TOTPSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	step, ok := validateTOTP(secret, code, time.Now(), user.LastTOTPStep)
+	if !ok {
+		challenge.attempts++
+		if challenge.attempts >= maxTwoFactorAttempts {
+			delete(ss.twoFactorChallenges, challengeToken)
+		}
+
+		ss.logSecurityEvent(&SecurityEvent{
+			UserID:      &user.ID,
+			EventType:   EventTypeTwoFactorFailed,
+			Description: "Invalid two-factor code",
+			IPAddress:   ipAddress,
+			UserAgent:   userAgent,
+			Severity:    SeverityMedium,
+			Timestamp:   time.Now(),
+		})
+		ss.handleFailedLogin(user, ipAddress, userAgent, "invalid two-factor code")
+
+		return &LoginResponse{
+			Success:           false,
+			Message:           "Invalid two-factor code",
+			TwoFactorRequired: challenge.attempts < maxTwoFactorAttempts,
+		}, nil
+	}
+
+	delete(ss.twoFactorChallenges, challengeToken)
+	user.LastTOTPStep = step
+
+	return ss.handleSuccessfulLogin(user, ipAddress, userAgent)
+}
+
+// startTwoFactorChallenge records a login waiting for a two-factor code and
+// returns the pending response (mutex must be held)
+func (ss *SecurityService) startTwoFactorChallenge(user *User) (*LoginResponse, error) {
+	now := time.Now()
+	for token, challenge := range ss.twoFactorChallenges {
+		if now.After(challenge.expiresAt) {
+			delete(ss.twoFactorChallenges, token)
+		}
+	}
+
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return nil, fmt.Errorf("failed to generate challenge token: %w", err)
+	}
+	token := hex.EncodeToString(bytes)
+
+	challenge := &twoFactorChallenge{
+		userID:    user.ID,
+		expiresAt: now.Add(twoFactorChallengeTTL),
+	}
+	ss.twoFactorChallenges[token] = challenge
+
+	return &LoginResponse{
+		Success:           false,
+		Message:           "Two-factor code required",
+		ExpiresAt:         challenge.expiresAt,
+		TwoFactorRequired: true,
+		ChallengeToken:    token,
+	}, nil
+}
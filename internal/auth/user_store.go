@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+
+	"parental-control/internal/logging"
+	"parental-control/internal/models"
+)
+
+// SetUserStore persists user accounts. Stored users replace the ones in
+// memory; while none are stored, the users in memory (the admin seeded from
+// the configuration) are saved as the first ones.
+func (ss *SecurityService) SetUserStore(store models.UserRepository) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	ss.userStore = store
+	if store == nil {
+		return nil
+	}
+
+	ctx := context.Background()
+	stored, err := store.GetAll(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load users: %w", err)
+	}
+
+	if len(stored) == 0 {
+		for _, user := range ss.users {
+			if err := ss.createStoredUser(user); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	ss.users = make(map[string]*User, len(stored))
+	for i := range stored {
+		user := userFromRecord(&stored[i])
+		ss.users[user.Username] = user
+	}
+
+	logging.Info("Restored user accounts", logging.Int("users", len(stored)))
+	return nil
+}
+
+// createStoredUser saves a new user and takes the ID the store assigns. It
+// does nothing without a store (mutex must be held).
+func (ss *SecurityService) createStoredUser(user *User) error {
+	if ss.userStore == nil {
+		return nil
+	}
+
+	record := user.record()
+	if err := ss.userStore.Create(context.Background(), record); err != nil {
+		return fmt.Errorf("failed to save user: %w", err)
+	}
+	user.ID = record.ID
+	return nil
+}
+
+// saveUser writes a changed user to the store. Failures are logged; the
+// change still applies until a restart (mutex must be held).
+func (ss *SecurityService) saveUser(user *User) {
+	if ss.userStore == nil {
+		return
+	}
+	if err := ss.userStore.Update(context.Background(), user.record()); err != nil {
+		logging.Warn("Failed to persist user",
+			logging.String("username", user.Username),
+			logging.Err(err))
+	}
+}
+
+// record returns the stored form of the user. The pending TOTP secret of an
+// unfinished enrollment is not kept.
+func (u *User) record() *models.User {
+	return &models.User{
+		ID:                u.ID,
+		Username:          u.Username,
+		PasswordHash:      u.PasswordHash,
+		Email:             u.Email,
+		IsActive:          u.IsActive,
+		IsAdmin:           u.IsAdmin,
+		LastLoginAt:       u.LastLoginAt,
+		PasswordChangedAt: u.PasswordChangedAt,
+		FailedAttempts:    u.FailedAttempts,
+		LockedUntil:       u.LockedUntil,
+		TwoFactorEnabled:  u.TwoFactorEnabled,
+		TOTPSecret:        u.TOTPSecret,
+		LastTOTPStep:      u.LastTOTPStep,
+		CreatedAt:         u.CreatedAt,
+		UpdatedAt:         u.UpdatedAt,
+	}
+}
+
+// userFromRecord returns the user a stored record describes
+func userFromRecord(record *models.User) *User {
+	return &User{
+		ID:                record.ID,
+		Username:          record.Username,
+		PasswordHash:      record.PasswordHash,
+		Email:             record.Email,
+		IsActive:          record.IsActive,
+		IsAdmin:           record.IsAdmin,
+		LastLoginAt:       record.LastLoginAt,
+		PasswordChangedAt: record.PasswordChangedAt,
+		FailedAttempts:    record.FailedAttempts,
+		LockedUntil:       record.LockedUntil,
+		TwoFactorEnabled:  record.TwoFactorEnabled,
+		TOTPSecret:        record.TOTPSecret,
+		LastTOTPStep:      record.LastTOTPStep,
+		CreatedAt:         record.CreatedAt,
+		UpdatedAt:         record.UpdatedAt,
+	}
+}
@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"parental-control/internal/database"
+)
+
+// newTestDatabase returns a fresh SQLite database with the full schema
+func newTestDatabase(t *testing.T) *database.DB {
+	t.Helper()
+
+	config := database.DefaultConfig()
+	config.Path = filepath.Join(t.TempDir(), "auth.db")
+	db, err := database.New(config)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.InitializeSchema(); err != nil {
+		t.Fatalf("Failed to initialize schema: %v", err)
+	}
+	return db
+}
+
+func TestSecurityService_UserStore(t *testing.T) {
+	db := newTestDatabase(t)
+	store := database.NewUserRepository(db.Connection())
+
+	// The admin seeded before the store is set becomes the first stored user
+	service := NewSecurityService(testAuthConfig())
+	if err := service.CreateInitialAdmin("admin", "AdminPassword123!", "admin@example.com"); err != nil {
+		t.Fatalf("Failed to create admin: %v", err)
+	}
+	if err := service.SetUserStore(store); err != nil {
+		t.Fatalf("Failed to set user store: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	NewAuthHandlers(service).handleUsers(rec, httptest.NewRequest(http.MethodPost, "/api/v1/auth/users",
+		strings.NewReader(`{"username":"parent2","email":"parent2@example.com","password":"ParentPassword123!"}`)))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected 201 creating a user, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if err := service.ChangePassword("parent2", "ParentPassword123!", "NewParentPassword456!", ""); err != nil {
+		t.Fatalf("Failed to change password: %v", err)
+	}
+
+	// After a restart the stored users win over the configured admin
+	restarted := NewSecurityService(testAuthConfig())
+	if err := restarted.CreateInitialAdmin("admin", "OtherPassword123!", "admin@example.com"); err != nil {
+		t.Fatalf("Failed to create admin: %v", err)
+	}
+	if err := restarted.SetUserStore(store); err != nil {
+		t.Fatalf("Failed to restore users: %v", err)
+	}
+	users, total := restarted.ListUsers(0, 0)
+	if total != 2 || users[0].Username != "admin" || users[1].Username != "parent2" {
+		t.Fatalf("Expected admin and parent2 to be restored, got %+v", users)
+	}
+	if response, err := restarted.Authenticate("parent2", "NewParentPassword456!", "192.168.1.1", "test-agent"); err != nil || !response.Success {
+		t.Errorf("Expected the changed password to survive the restart: %+v (%v)", response, err)
+	}
+	if response, err := restarted.Authenticate("admin", "AdminPassword123!", "192.168.1.1", "test-agent"); err != nil || !response.Success {
+		t.Errorf("Expected the stored admin password to be kept: %+v (%v)", response, err)
+	}
+
+	if err := restarted.DeleteUser(users[1].ID); err != nil {
+		t.Fatalf("Failed to delete user: %v", err)
+	}
+	if stored, err := store.GetAll(t.Context()); err != nil || len(stored) != 1 {
+		t.Errorf("Expected the deleted user to be removed from the store, got %+v (%v)", stored, err)
+	}
+}
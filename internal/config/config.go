@@ -2,6 +2,10 @@ package config
 
 import (
 	"fmt"
+	"io"
+	"net"
+	"net/mail"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -9,8 +13,6 @@ import (
 	"time"
 
 	"parental-control/internal/database"
-
-	"gopkg.in/yaml.v3"
 )
 
 // Config represents the complete application configuration
@@ -41,6 +43,12 @@ type Config struct {
 
 	// Privilege configuration
 	Privilege PrivilegeConfig `yaml:"privilege" json:"privilege"`
+
+	// Retention configuration
+	Retention RetentionConfig `yaml:"retention" json:"retention"`
+
+	// Database backup configuration
+	Backup BackupConfig `yaml:"backup" json:"backup"`
 }
 
 // ServiceConfig holds service-specific settings
@@ -119,14 +127,46 @@ type WebConfig struct {
 
 	// HTTPSPort port for HTTPS server (when different from HTTP)
 	HTTPSPort int `yaml:"https_port" json:"https_port"`
+
+	// MaxRequestBodyBytes limits request bodies, counted as they are read
+	MaxRequestBodyBytes int64 `yaml:"max_request_body_bytes" json:"max_request_body_bytes"`
+
+	// MaxImportBodyBytes limits uploaded block lists
+	MaxImportBodyBytes int64 `yaml:"max_import_body_bytes" json:"max_import_body_bytes"`
+
+	// SocketPath serves the web interface on a Unix socket instead of a TCP
+	// port. A host of the form unix:/path does the same.
+	SocketPath string `yaml:"socket_path" json:"socket_path"`
+
+	// SocketMode is the octal file mode of the socket, e.g. "0660"
+	SocketMode string `yaml:"socket_mode" json:"socket_mode"`
+}
+
+// UnixSocket returns the Unix socket the web interface listens on, from
+// socket_path or a unix:/path host, or "" when it listens on TCP
+func (w WebConfig) UnixSocket() string {
+	if w.SocketPath != "" {
+		return w.SocketPath
+	}
+	if strings.HasPrefix(w.Host, unixHostPrefix) {
+		return strings.TrimPrefix(w.Host, unixHostPrefix)
+	}
+	return ""
 }
 
+// unixHostPrefix marks a web host that is a Unix socket path
+const unixHostPrefix = "unix:"
+
 // SecurityConfig holds security-related settings
 type SecurityConfig struct {
 	// EnableAuth indicates if authentication is required
 	EnableAuth bool `yaml:"enable_auth" json:"enable_auth"`
 
-	// AdminPassword for admin access (should be hashed)
+	// AdminUsername is the username of the admin account
+	AdminUsername string `yaml:"admin_username" json:"admin_username"`
+
+	// AdminPassword for admin access, either plain text or a bcrypt hash as
+	// written by the admin-reset command
 	AdminPassword string `yaml:"admin_password" json:"admin_password"`
 
 	// SessionSecret for session management
@@ -141,6 +181,31 @@ type SecurityConfig struct {
 	// LockoutDuration for account lockout
 	LockoutDuration time.Duration `yaml:"lockout_duration" json:"lockout_duration"`
 
+	// LockoutEscalation makes each repeated lockout last longer than the previous one
+	LockoutEscalation bool `yaml:"lockout_escalation" json:"lockout_escalation"`
+
+	// LockoutEscalationFactor multiplies the lockout duration for every repeated lockout
+	LockoutEscalationFactor float64 `yaml:"lockout_escalation_factor" json:"lockout_escalation_factor"`
+
+	// LockoutMaxDuration caps escalated lockouts
+	LockoutMaxDuration time.Duration `yaml:"lockout_max_duration" json:"lockout_max_duration"`
+
+	// LockoutEscalationReset forgets earlier lockouts after this long without a new one
+	LockoutEscalationReset time.Duration `yaml:"lockout_escalation_reset" json:"lockout_escalation_reset"`
+
+	// LockoutNotifyWebhookURL receives a JSON POST whenever an account locks
+	LockoutNotifyWebhookURL string `yaml:"lockout_notify_webhook_url" json:"lockout_notify_webhook_url"`
+
+	// LockoutNotifyEmail recipients alerted whenever an account locks
+	LockoutNotifyEmail []string `yaml:"lockout_notify_email" json:"lockout_notify_email"`
+
+	// SMTP server used for lockout alert e-mails
+	SMTPHost     string `yaml:"smtp_host" json:"smtp_host"`
+	SMTPPort     int    `yaml:"smtp_port" json:"smtp_port"`
+	SMTPUsername string `yaml:"smtp_username" json:"smtp_username"`
+	SMTPPassword string `yaml:"smtp_password" json:"smtp_password"`
+	SMTPFrom     string `yaml:"smtp_from" json:"smtp_from"`
+
 	// Password configuration
 	BcryptCost          int  `yaml:"bcrypt_cost" json:"bcrypt_cost"`
 	MinPasswordLength   int  `yaml:"min_password_length" json:"min_password_length"`
@@ -154,10 +219,83 @@ type SecurityConfig struct {
 	// Rate limiting
 	LoginRateLimit int `yaml:"login_rate_limit" json:"login_rate_limit"`
 
+	// AuthRateLimit caps requests per client IP to /api/v1/auth/ endpoints
+	// (setup, password strength, recovery...) per AuthRateLimitWindow; 0 disables
+	AuthRateLimit       int           `yaml:"auth_rate_limit" json:"auth_rate_limit"`
+	AuthRateLimitWindow time.Duration `yaml:"auth_rate_limit_window" json:"auth_rate_limit_window"`
+
+	// APIRateLimit caps requests per client IP to the rest of the API per
+	// APIRateLimitWindow; 0 disables
+	APIRateLimit       int           `yaml:"api_rate_limit" json:"api_rate_limit"`
+	APIRateLimitWindow time.Duration `yaml:"api_rate_limit_window" json:"api_rate_limit_window"`
+
+	// RecoveryTokenTTL is how long a password recovery token stays valid
+	RecoveryTokenTTL time.Duration `yaml:"recovery_token_ttl" json:"recovery_token_ttl"`
+
+	// RecoveryMaxAttempts invalidates a recovery token after this many wrong guesses
+	RecoveryMaxAttempts int `yaml:"recovery_max_attempts" json:"recovery_max_attempts"`
+
+	// LoginAttemptHistorySize caps the recent login attempts kept in memory
+	// for statistics and lockout alerts
+	LoginAttemptHistorySize int `yaml:"login_attempt_history_size" json:"login_attempt_history_size"`
+
+	// EventHistorySize caps the recent security events kept in memory
+	EventHistorySize int `yaml:"event_history_size" json:"event_history_size"`
+
 	// Session management
 	RememberMeDuration    time.Duration `yaml:"remember_me_duration" json:"remember_me_duration"`
 	AllowMultipleSessions bool          `yaml:"allow_multiple_sessions" json:"allow_multiple_sessions"`
 	MaxSessions           int           `yaml:"max_sessions" json:"max_sessions"`
+
+	// RevokeAllSessionsOnPasswordChange also signs out the session that changed the password
+	RevokeAllSessionsOnPasswordChange bool `yaml:"revoke_all_sessions_on_password_change" json:"revoke_all_sessions_on_password_change"`
+
+	// Session cookie attributes
+	CookieDomain   string `yaml:"cookie_domain" json:"cookie_domain"`
+	CookiePath     string `yaml:"cookie_path" json:"cookie_path"`
+	CookieSameSite string `yaml:"cookie_same_site" json:"cookie_same_site"` // strict, lax or none
+	CookieSecure   string `yaml:"cookie_secure" json:"cookie_secure"`       // auto, always or never
+
+	// TrustForwardedProto honors X-Forwarded-Proto when deciding if a request arrived over HTTPS
+	TrustForwardedProto bool `yaml:"trust_forwarded_proto" json:"trust_forwarded_proto"`
+
+	// TrustedProxies limits which peers may set X-Forwarded-Proto (IPs or CIDRs, empty trusts any).
+	// Rate limits key on X-Forwarded-For only for requests from these peers.
+	TrustedProxies []string `yaml:"trusted_proxies" json:"trusted_proxies"`
+
+	// HSTSMaxAge for the Strict-Transport-Security header sent over HTTPS (0 disables HSTS)
+	HSTSMaxAge time.Duration `yaml:"hsts_max_age" json:"hsts_max_age"`
+
+	// HSTSIncludeSubdomains extends HSTS to every subdomain of the UI host
+	HSTSIncludeSubdomains bool `yaml:"hsts_include_subdomains" json:"hsts_include_subdomains"`
+
+	// HSTSPreload adds the preload directive (requires include_subdomains and a max age of at least a year)
+	HSTSPreload bool `yaml:"hsts_preload" json:"hsts_preload"`
+
+	// ContentSecurityPolicy overrides the default policy, e.g. to allow embedding the UI
+	ContentSecurityPolicy string `yaml:"content_security_policy" json:"content_security_policy"`
+
+	// FrameOptions is the X-Frame-Options value: DENY, SAMEORIGIN or off
+	FrameOptions string `yaml:"frame_options" json:"frame_options"`
+
+	// ReferrerPolicy is the Referrer-Policy header value
+	ReferrerPolicy string `yaml:"referrer_policy" json:"referrer_policy"`
+
+	// CORSAllowedOrigins may call the API from a browser on another origin,
+	// e.g. "https://dashboard.lan:3000" (empty disables CORS)
+	CORSAllowedOrigins []string `yaml:"cors_allowed_origins" json:"cors_allowed_origins"`
+
+	// CORSAllowedMethods answered to preflight requests (empty uses the defaults)
+	CORSAllowedMethods []string `yaml:"cors_allowed_methods" json:"cors_allowed_methods"`
+
+	// CORSAllowedHeaders answered to preflight requests (empty uses the defaults)
+	CORSAllowedHeaders []string `yaml:"cors_allowed_headers" json:"cors_allowed_headers"`
+
+	// CORSAllowCredentials lets allowed origins send the session cookie
+	CORSAllowCredentials bool `yaml:"cors_allow_credentials" json:"cors_allow_credentials"`
+
+	// CORSMaxAge is how long browsers may cache a preflight answer
+	CORSMaxAge time.Duration `yaml:"cors_max_age" json:"cors_max_age"`
 }
 
 // MonitoringConfig holds monitoring settings
@@ -173,6 +311,19 @@ type MonitoringConfig struct {
 
 	// HealthCheckPath for health check endpoint
 	HealthCheckPath string `yaml:"health_check_path" json:"health_check_path"`
+
+	// MetricsHost to bind the metrics endpoint to (localhost keeps it off the network)
+	MetricsHost string `yaml:"metrics_host" json:"metrics_host"`
+
+	// MetricsAuth protects the metrics endpoint: none, bearer or basic
+	MetricsAuth string `yaml:"metrics_auth" json:"metrics_auth"`
+
+	// MetricsToken is the bearer token required when metrics_auth is bearer
+	MetricsToken string `yaml:"metrics_token" json:"metrics_token"`
+
+	// MetricsUsername and MetricsPassword are required when metrics_auth is basic
+	MetricsUsername string `yaml:"metrics_username" json:"metrics_username"`
+	MetricsPassword string `yaml:"metrics_password" json:"metrics_password"`
 }
 
 // EnforcementConfig holds enforcement engine settings
@@ -205,12 +356,32 @@ type EnforcementConfig struct {
 	EmergencyWhitelist []string `yaml:"emergency_whitelist" json:"emergency_whitelist"`
 
 	// DNS configuration
-	DNSListenAddr      string        `yaml:"dns_listen_addr" json:"dns_listen_addr"`
+	DNSListenAddr      string        `yaml:"dns_listen_addr" json:"dns_listen_addr"` // comma-separated IPs, optionally with ports
+	DNSListenInterface string        `yaml:"dns_listen_interface" json:"dns_listen_interface"`
 	DNSBlockIPv4       string        `yaml:"dns_block_ipv4" json:"dns_block_ipv4"`
 	DNSBlockIPv6       string        `yaml:"dns_block_ipv6" json:"dns_block_ipv6"`
+	DNSBlockResponse   string        `yaml:"dns_block_response" json:"dns_block_response"` // sinkhole, nxdomain or refused; a block page needs sinkhole
 	DNSUpstreamServers []string      `yaml:"dns_upstream_servers" json:"dns_upstream_servers"`
 	DNSCacheTTL        time.Duration `yaml:"dns_cache_ttl" json:"dns_cache_ttl"`
 	DNSEnableLogging   bool          `yaml:"dns_enable_logging" json:"dns_enable_logging"`
+
+	// DNSECSMode controls the EDNS Client Subnet sent to upstreams: strip
+	// (default, private), passthrough (better CDN locality, leaks the client
+	// subnet) or fixed (send DNSECSSubnet instead)
+	DNSECSMode   string `yaml:"dns_ecs_mode" json:"dns_ecs_mode"`
+	DNSECSSubnet string `yaml:"dns_ecs_subnet" json:"dns_ecs_subnet"`
+
+	// BlockEncryptedDNS blocks DoH/DoT providers so devices cannot skip the
+	// local resolver: their host names at the DNS layer and, on Linux when
+	// running as root, the DoT port and their addresses. Lookups are audited
+	// as bypass_attempt events. The lists extend the built-in providers.
+	BlockEncryptedDNS bool     `yaml:"block_encrypted_dns" json:"block_encrypted_dns"`
+	EncryptedDNSHosts []string `yaml:"encrypted_dns_hosts" json:"encrypted_dns_hosts"`
+	EncryptedDNSIPs   []string `yaml:"encrypted_dns_ips" json:"encrypted_dns_ips"`
+
+	// DNSContinueOnBindFailure keeps the service running with DNS filtering
+	// disabled when the DNS port is already in use, instead of failing startup
+	DNSContinueOnBindFailure bool `yaml:"dns_continue_on_bind_failure" json:"dns_continue_on_bind_failure"`
 }
 
 // NotificationConfig holds notification settings
@@ -235,11 +406,99 @@ type NotificationConfig struct {
 	// Notification behavior
 	ShowProcessDetails  bool          `yaml:"show_process_details" json:"show_process_details"`
 	NotificationTimeout time.Duration `yaml:"notification_timeout" json:"notification_timeout"`
+
+	// CoalesceWindow folds repeated block notifications for the same app or
+	// site into one summary per window; 0 sends each one
+	CoalesceWindow time.Duration `yaml:"coalesce_window" json:"coalesce_window"`
+
+	// Sinks selects where notifications are delivered: desktop, webhook,
+	// ntfy and/or email. A headless install would drop desktop.
+	Sinks []string `yaml:"sinks" json:"sinks"`
+
+	// Settings for the webhook, ntfy and email sinks
+	Webhook NotificationWebhookConfig `yaml:"webhook" json:"webhook"`
+	Ntfy    NotificationNtfyConfig    `yaml:"ntfy" json:"ntfy"`
+	Email   NotificationEmailConfig   `yaml:"email" json:"email"`
+}
+
+// NotificationWebhookConfig configures the webhook notification sink
+type NotificationWebhookConfig struct {
+	// URL receives a JSON POST per notification
+	URL string `yaml:"url" json:"url"`
+	// Headers are added to every request, e.g. an Authorization header
+	Headers map[string]string `yaml:"headers" json:"headers"`
+}
+
+// NotificationNtfyConfig configures the ntfy notification sink
+type NotificationNtfyConfig struct {
+	// URL of the topic, e.g. https://ntfy.sh/my-family-alerts
+	URL string `yaml:"url" json:"url"`
+	// Token is an access token for protected topics
+	Token string `yaml:"token" json:"token"`
+}
+
+// NotificationEmailConfig configures the email notification sink
+type NotificationEmailConfig struct {
+	To   []string `yaml:"to" json:"to"`
+	From string   `yaml:"from" json:"from"`
+
+	SMTPHost     string `yaml:"smtp_host" json:"smtp_host"`
+	SMTPPort     int    `yaml:"smtp_port" json:"smtp_port"`
+	SMTPUsername string `yaml:"smtp_username" json:"smtp_username"`
+	SMTPPassword string `yaml:"smtp_password" json:"smtp_password"`
+}
+
+// RetentionConfig holds the retention and log rotation policies seeded on first run
+type RetentionConfig struct {
+	// SeedDefaults creates default policies when none exist
+	SeedDefaults bool `yaml:"seed_defaults" json:"seed_defaults"`
+
+	// EnableSeeded creates the seeded policies enabled instead of disabled
+	EnableSeeded bool `yaml:"enable_seeded" json:"enable_seeded"`
+
+	// AuditMaxAge keeps audit entries for this long (0 disables the age limit)
+	AuditMaxAge time.Duration `yaml:"audit_max_age" json:"audit_max_age"`
+
+	// AuditMaxEntries keeps at most this many audit entries (0 disables the count limit)
+	AuditMaxEntries int64 `yaml:"audit_max_entries" json:"audit_max_entries"`
+
+	// LogMaxFileSize rotates the application log past this size in bytes (0 rotates daily only)
+	LogMaxFileSize int64 `yaml:"log_max_file_size" json:"log_max_file_size"`
+
+	// LogRetainDuration keeps rotated application logs for this long
+	LogRetainDuration time.Duration `yaml:"log_retain_duration" json:"log_retain_duration"`
+}
+
+// BackupConfig holds automatic database backup settings
+type BackupConfig struct {
+	// Enabled allows backups, both automatic and requested through the API
+	Enabled bool `yaml:"enabled" json:"enabled"`
+
+	// Directory holds the backup files (default: <service.data_directory>/backups)
+	Directory string `yaml:"directory" json:"directory"`
+
+	// Keep is how many backup files to keep; older ones are deleted
+	Keep int `yaml:"keep" json:"keep"`
+
+	// BeforeMigrations backs up an existing database before schema migrations
+	BeforeMigrations bool `yaml:"before_migrations" json:"before_migrations"`
+
+	// RetentionThreshold backs up before retention runs estimated to delete
+	// at least this many audit entries (0 disables)
+	RetentionThreshold int64 `yaml:"retention_threshold" json:"retention_threshold"`
+}
+
+// BackupDirectory returns where database backups are stored
+func (c *Config) BackupDirectory() string {
+	if c.Backup.Directory != "" {
+		return c.Backup.Directory
+	}
+	return filepath.Join(c.Service.DataDirectory, "backups")
 }
 
 // PrivilegeConfig holds privilege escalation settings
 type PrivilegeConfig struct {
-	// ElevationMethod specifies the preferred elevation method (auto, uac, sudo, pkexec)
+	// ElevationMethod specifies the preferred elevation method (auto, uac, sudo, pkexec, doas)
 	ElevationMethod string `yaml:"elevation_method" json:"elevation_method"`
 
 	// TimeoutSeconds for privilege elevation requests
@@ -248,7 +507,7 @@ type PrivilegeConfig struct {
 	// AllowFallback enables fallback to other elevation methods if preferred fails
 	AllowFallback bool `yaml:"allow_fallback" json:"allow_fallback"`
 
-	// PreferredElevator specifies preferred tool (pkexec, sudo, gksudo, etc.)
+	// PreferredElevator specifies preferred tool (pkexec, sudo, doas, gksudo, etc.)
 	PreferredElevator string `yaml:"preferred_elevator" json:"preferred_elevator"`
 
 	// RestartOnElevation whether to restart the application with elevated privileges
@@ -277,44 +536,79 @@ func Default() *Config {
 			EnableCaller:    false,
 		},
 		Web: WebConfig{
-			Enabled:         true,
-			Port:            8080,
-			Host:            "localhost",
-			StaticDir:       "./web/build",
-			TLSEnabled:      false,
-			TLSCertFile:     "",
-			TLSKeyFile:      "",
-			TLSAutoGenerate: true,
-			TLSCertDir:      "./certs",
-			TLSHostname:     "localhost",
-			TLSRedirectHTTP: false,
-			HTTPSPort:       8443,
+			Enabled:             true,
+			Port:                8080,
+			Host:                "localhost",
+			StaticDir:           "./web/build",
+			TLSEnabled:          false,
+			TLSCertFile:         "",
+			TLSKeyFile:          "",
+			TLSAutoGenerate:     true,
+			TLSCertDir:          "./certs",
+			TLSHostname:         "localhost",
+			TLSRedirectHTTP:     false,
+			HTTPSPort:           8443,
+			MaxRequestBodyBytes: 1 << 20,  // 1 MB
+			MaxImportBodyBytes:  32 << 20, // 32 MB
+			SocketMode:          "0660",
 		},
 		Security: SecurityConfig{
-			EnableAuth:            false, // Disabled by default for easier setup
-			AdminPassword:         "",
-			SessionSecret:         "",
-			SessionTimeout:        24 * time.Hour,
-			MaxFailedAttempts:     5,
-			LockoutDuration:       15 * time.Minute,
-			BcryptCost:            12, // Good balance of security and performance
-			MinPasswordLength:     8,
-			RequireUppercase:      true,
-			RequireLowercase:      true,
-			RequireNumbers:        true,
-			RequireSpecialChars:   false, // Optional for easier setup
-			PasswordHistorySize:   5,
-			PasswordExpireDays:    0,                   // No expiration by default
-			LoginRateLimit:        10,                  // 10 attempts per minute
-			RememberMeDuration:    30 * 24 * time.Hour, // 30 days
-			AllowMultipleSessions: false,
-			MaxSessions:           1,
+			EnableAuth:              false, // Disabled by default for easier setup
+			AdminUsername:           "admin",
+			AdminPassword:           "",
+			SessionSecret:           "",
+			SessionTimeout:          24 * time.Hour,
+			MaxFailedAttempts:       5,
+			LockoutDuration:         15 * time.Minute,
+			LockoutEscalation:       false,
+			LockoutEscalationFactor: 2,
+			LockoutMaxDuration:      24 * time.Hour,
+			LockoutEscalationReset:  24 * time.Hour,
+			LockoutNotifyEmail:      []string{},
+			SMTPPort:                587,
+			BcryptCost:              12, // Good balance of security and performance
+			MinPasswordLength:       8,
+			RequireUppercase:        true,
+			RequireLowercase:        true,
+			RequireNumbers:          true,
+			RequireSpecialChars:     false, // Optional for easier setup
+			PasswordHistorySize:     5,
+			PasswordExpireDays:      0,  // No expiration by default
+			LoginRateLimit:          10, // 10 attempts per minute
+			AuthRateLimit:           30,
+			AuthRateLimitWindow:     time.Minute,
+			APIRateLimit:            600,
+			APIRateLimitWindow:      time.Minute,
+			LoginAttemptHistorySize: 1000,
+			EventHistorySize:        1000,
+			RememberMeDuration:      30 * 24 * time.Hour, // 30 days
+			AllowMultipleSessions:   false,
+			MaxSessions:             1,
+			RecoveryTokenTTL:        15 * time.Minute,
+			RecoveryMaxAttempts:     5,
+			CookieDomain:            "",
+			CookiePath:              "/",
+			CookieSameSite:          "strict",
+			CookieSecure:            "auto", // Secure whenever the request arrived over HTTPS
+			TrustForwardedProto:     false,
+			TrustedProxies:          []string{},
+			HSTSMaxAge:              365 * 24 * time.Hour,
+			HSTSIncludeSubdomains:   true,
+			HSTSPreload:             false,
+			ContentSecurityPolicy:   "", // Empty uses the built-in policy for the web UI
+			FrameOptions:            "DENY",
+			ReferrerPolicy:          "strict-origin-when-cross-origin",
+			CORSAllowedOrigins:      []string{}, // CORS disabled
+			CORSAllowCredentials:    false,
+			CORSMaxAge:              10 * time.Minute,
 		},
 		Monitoring: MonitoringConfig{
 			Enabled:         true,
 			MetricsPort:     9090,
 			MetricsPath:     "/metrics",
 			HealthCheckPath: "/health",
+			MetricsHost:     "127.0.0.1",
+			MetricsAuth:     "none",
 		},
 		Enforcement: EnforcementConfig{
 			Enabled:                true,
@@ -326,12 +620,16 @@ func Default() *Config {
 			LogAllActivity:         true,
 			EnableEmergencyMode:    false,
 			EmergencyWhitelist:     []string{"192.168.1.1", "2001:db8::1"},
-			DNSListenAddr:          "0.0.0.0",
+			DNSListenAddr:          "0.0.0.0,::",
 			DNSBlockIPv4:           "0.0.0.0",
 			DNSBlockIPv6:           "::",
+			DNSBlockResponse:       "sinkhole",
 			DNSUpstreamServers:     []string{"8.8.8.8", "2001:4860:4860::8888"},
 			DNSCacheTTL:            300 * time.Second,
 			DNSEnableLogging:       true,
+			DNSECSMode:             "strip",
+			// Fail closed: a missing DNS filter should stop startup by default
+			DNSContinueOnBindFailure: false,
 		},
 		Notifications: NotificationConfig{
 			Enabled:                   true,
@@ -345,23 +643,49 @@ func Default() *Config {
 			EnableSystemAlerts:        false,
 			ShowProcessDetails:        true,
 			NotificationTimeout:       5 * time.Second,
+			Sinks:                     []string{"desktop"},
+			Email:                     NotificationEmailConfig{SMTPPort: 587},
 		},
 		Privilege: PrivilegeConfig{
-			ElevationMethod:     "auto",
-			TimeoutSeconds:      120,
-			AllowFallback:       true,
-			PreferredElevator:   "",
-			RestartOnElevation:  true,
-			SkipElevationCheck:  false,
+			ElevationMethod:    "auto",
+			TimeoutSeconds:     120,
+			AllowFallback:      true,
+			PreferredElevator:  "",
+			RestartOnElevation: true,
+			SkipElevationCheck: false,
+		},
+		Retention: RetentionConfig{
+			SeedDefaults:      true,
+			EnableSeeded:      false, // Present but off until the user opts in
+			AuditMaxAge:       90 * 24 * time.Hour,
+			AuditMaxEntries:   1000000,
+			LogMaxFileSize:    50 * 1024 * 1024, // 50 MB
+			LogRetainDuration: 7 * 24 * time.Hour,
+		},
+		Backup: BackupConfig{
+			Enabled:            true,
+			Keep:               7,
+			BeforeMigrations:   true,
+			RetentionThreshold: 10000,
 		},
 	}
 }
 
-// LoadFromFile loads configuration from a YAML file
+// LoadFromFile loads configuration from a YAML, JSON or TOML file, chosen by
+// extension (YAML when there is none). An empty path is resolved with
+// FindConfigFile; use Load to also learn which file was read.
 func LoadFromFile(path string) (*Config, error) {
 	// Start with defaults
 	config := Default()
 
+	if path == "" {
+		resolved, err := FindConfigFile(path)
+		if err != nil {
+			return config, err
+		}
+		path = resolved
+	}
+
 	// Check if file exists
 	if _, err := os.Stat(path); os.IsNotExist(err) {
 		return config, fmt.Errorf("configuration file not found: %s", path)
@@ -373,14 +697,15 @@ func LoadFromFile(path string) (*Config, error) {
 		return nil, fmt.Errorf("failed to read configuration file: %w", err)
 	}
 
-	// Parse YAML
-	if err := yaml.Unmarshal(data, config); err != nil {
-		return nil, fmt.Errorf("failed to parse configuration file: %w", err)
-	}
+	return parseConfigData(path, data)
+}
 
-	// Apply environment variable overrides
-	if err := applyEnvironmentOverrides(config); err != nil {
-		return nil, fmt.Errorf("failed to apply environment overrides: %w", err)
+// parseConfigData parses the contents of the configuration file at path over
+// the defaults, applies environment overrides and validates the result
+func parseConfigData(path string, data []byte) (*Config, error) {
+	config, err := decodeConfigData(path, data)
+	if err != nil {
+		return nil, err
 	}
 
 	// Validate configuration
@@ -391,6 +716,25 @@ func LoadFromFile(path string) (*Config, error) {
 	return config, nil
 }
 
+// decodeConfigData parses the contents of the configuration file at path over
+// the defaults and applies environment overrides, without validating
+func decodeConfigData(path string, data []byte) (*Config, error) {
+	config := Default()
+
+	// Parse as YAML, JSON or TOML depending on the extension
+	format := formatFromPath(path)
+	if err := decodeConfig(data, format, config); err != nil {
+		return nil, fmt.Errorf("failed to parse configuration file as %s: %w", format, err)
+	}
+
+	// Apply environment variable overrides
+	if err := applyEnvironmentOverrides(config); err != nil {
+		return nil, fmt.Errorf("failed to apply environment overrides: %w", err)
+	}
+
+	return config, nil
+}
+
 // LoadFromEnvironment loads configuration from environment variables only
 func LoadFromEnvironment() (*Config, error) {
 	config := Default()
@@ -446,6 +790,55 @@ func applyEnvironmentOverrides(config *Config) error {
 	if val := os.Getenv("PC_DATABASE_ENABLE_WAL"); val != "" {
 		config.Database.EnableWAL = strings.ToLower(val) == "true"
 	}
+	if val := os.Getenv("PC_DATABASE_READ_REPLICA"); val != "" {
+		config.Database.ReadReplica = strings.ToLower(val) == "true"
+	}
+	if val := os.Getenv("PC_DATABASE_READ_MAX_OPEN_CONNS"); val != "" {
+		if parsed, err := parseIntFromEnv(val); err == nil {
+			config.Database.ReadMaxOpenConns = parsed
+		}
+	}
+	if val := os.Getenv("PC_DATABASE_STARTUP_RETRY_WINDOW"); val != "" {
+		if duration, err := time.ParseDuration(val); err == nil {
+			config.Database.StartupRetryWindow = duration
+		}
+	}
+	if val := os.Getenv("PC_DATABASE_STARTUP_RETRY_INTERVAL"); val != "" {
+		if duration, err := time.ParseDuration(val); err == nil {
+			config.Database.StartupRetryInterval = duration
+		}
+	}
+	if val := os.Getenv("PC_DATABASE_SLOW_QUERY_THRESHOLD"); val != "" {
+		if duration, err := time.ParseDuration(val); err == nil {
+			config.Database.SlowQueryThreshold = duration
+		}
+	}
+	if val := os.Getenv("PC_DATABASE_MAINTENANCE_ENABLED"); val != "" {
+		if enabled, err := strconv.ParseBool(val); err == nil {
+			config.Database.MaintenanceEnabled = enabled
+		}
+	}
+	if val := os.Getenv("PC_DATABASE_MAINTENANCE_CHECKPOINT_INTERVAL"); val != "" {
+		if duration, err := time.ParseDuration(val); err == nil {
+			config.Database.MaintenanceCheckpointInterval = duration
+		}
+	}
+	if val := os.Getenv("PC_DATABASE_MAINTENANCE_VACUUM_INTERVAL"); val != "" {
+		if duration, err := time.ParseDuration(val); err == nil {
+			config.Database.MaintenanceVacuumInterval = duration
+		}
+	}
+	if val := os.Getenv("PC_DATABASE_MAINTENANCE_VACUUM_MIN_FREE_PERCENT"); val != "" {
+		if parsed, err := strconv.ParseFloat(val, 64); err == nil {
+			config.Database.MaintenanceVacuumMinFreePercent = parsed
+		}
+	}
+	if val, ok := os.LookupEnv("PC_DATABASE_MAINTENANCE_WINDOW_START"); ok {
+		config.Database.MaintenanceWindowStart = val
+	}
+	if val, ok := os.LookupEnv("PC_DATABASE_MAINTENANCE_WINDOW_END"); ok {
+		config.Database.MaintenanceWindowEnd = val
+	}
 
 	// Logging configuration
 	if val := os.Getenv("PC_LOGGING_LEVEL"); val != "" {
@@ -513,11 +906,30 @@ func applyEnvironmentOverrides(config *Config) error {
 			config.Web.HTTPSPort = port
 		}
 	}
+	if val := os.Getenv("PC_WEB_MAX_REQUEST_BODY_BYTES"); val != "" {
+		if parsed, err := strconv.ParseInt(val, 10, 64); err == nil {
+			config.Web.MaxRequestBodyBytes = parsed
+		}
+	}
+	if val := os.Getenv("PC_WEB_MAX_IMPORT_BODY_BYTES"); val != "" {
+		if parsed, err := strconv.ParseInt(val, 10, 64); err == nil {
+			config.Web.MaxImportBodyBytes = parsed
+		}
+	}
+	if val := os.Getenv("PC_WEB_SOCKET_PATH"); val != "" {
+		config.Web.SocketPath = val
+	}
+	if val := os.Getenv("PC_WEB_SOCKET_MODE"); val != "" {
+		config.Web.SocketMode = val
+	}
 
 	// Security configuration
 	if val := os.Getenv("PC_SECURITY_ENABLE_AUTH"); val != "" {
 		config.Security.EnableAuth = strings.ToLower(val) == "true"
 	}
+	if val := os.Getenv("PC_SECURITY_ADMIN_USERNAME"); val != "" {
+		config.Security.AdminUsername = val
+	}
 	if val := os.Getenv("PC_SECURITY_ADMIN_PASSWORD"); val != "" {
 		config.Security.AdminPassword = val
 	}
@@ -556,6 +968,46 @@ func applyEnvironmentOverrides(config *Config) error {
 			config.Security.LoginRateLimit = parsed
 		}
 	}
+	if val := os.Getenv("PC_SECURITY_AUTH_RATE_LIMIT"); val != "" {
+		if parsed, err := parseIntFromEnv(val); err == nil && parsed >= 0 {
+			config.Security.AuthRateLimit = parsed
+		}
+	}
+	if val := os.Getenv("PC_SECURITY_AUTH_RATE_LIMIT_WINDOW"); val != "" {
+		if duration, err := time.ParseDuration(val); err == nil {
+			config.Security.AuthRateLimitWindow = duration
+		}
+	}
+	if val := os.Getenv("PC_SECURITY_API_RATE_LIMIT"); val != "" {
+		if parsed, err := parseIntFromEnv(val); err == nil && parsed >= 0 {
+			config.Security.APIRateLimit = parsed
+		}
+	}
+	if val := os.Getenv("PC_SECURITY_API_RATE_LIMIT_WINDOW"); val != "" {
+		if duration, err := time.ParseDuration(val); err == nil {
+			config.Security.APIRateLimitWindow = duration
+		}
+	}
+	if val := os.Getenv("PC_SECURITY_RECOVERY_TOKEN_TTL"); val != "" {
+		if duration, err := time.ParseDuration(val); err == nil {
+			config.Security.RecoveryTokenTTL = duration
+		}
+	}
+	if val := os.Getenv("PC_SECURITY_RECOVERY_MAX_ATTEMPTS"); val != "" {
+		if parsed, err := parseIntFromEnv(val); err == nil && parsed > 0 {
+			config.Security.RecoveryMaxAttempts = parsed
+		}
+	}
+	if val := os.Getenv("PC_SECURITY_LOGIN_ATTEMPT_HISTORY_SIZE"); val != "" {
+		if parsed, err := parseIntFromEnv(val); err == nil && parsed > 0 {
+			config.Security.LoginAttemptHistorySize = parsed
+		}
+	}
+	if val := os.Getenv("PC_SECURITY_EVENT_HISTORY_SIZE"); val != "" {
+		if parsed, err := parseIntFromEnv(val); err == nil && parsed > 0 {
+			config.Security.EventHistorySize = parsed
+		}
+	}
 	if val := os.Getenv("PC_SECURITY_MAX_FAILED_ATTEMPTS"); val != "" {
 		if parsed, err := parseIntFromEnv(val); err == nil {
 			config.Security.MaxFailedAttempts = parsed
@@ -566,6 +1018,105 @@ func applyEnvironmentOverrides(config *Config) error {
 			config.Security.LockoutDuration = duration
 		}
 	}
+	if val := os.Getenv("PC_SECURITY_LOCKOUT_ESCALATION"); val != "" {
+		config.Security.LockoutEscalation = strings.ToLower(val) == "true"
+	}
+	if val := os.Getenv("PC_SECURITY_LOCKOUT_ESCALATION_FACTOR"); val != "" {
+		if parsed, err := strconv.ParseFloat(val, 64); err == nil {
+			config.Security.LockoutEscalationFactor = parsed
+		}
+	}
+	if val := os.Getenv("PC_SECURITY_LOCKOUT_MAX_DURATION"); val != "" {
+		if duration, err := time.ParseDuration(val); err == nil {
+			config.Security.LockoutMaxDuration = duration
+		}
+	}
+	if val := os.Getenv("PC_SECURITY_LOCKOUT_ESCALATION_RESET"); val != "" {
+		if duration, err := time.ParseDuration(val); err == nil {
+			config.Security.LockoutEscalationReset = duration
+		}
+	}
+	if val := os.Getenv("PC_SECURITY_LOCKOUT_NOTIFY_WEBHOOK_URL"); val != "" {
+		config.Security.LockoutNotifyWebhookURL = val
+	}
+	if val := os.Getenv("PC_SECURITY_LOCKOUT_NOTIFY_EMAIL"); val != "" {
+		config.Security.LockoutNotifyEmail = strings.Split(val, ",")
+	}
+	if val := os.Getenv("PC_SECURITY_SMTP_HOST"); val != "" {
+		config.Security.SMTPHost = val
+	}
+	if val := os.Getenv("PC_SECURITY_SMTP_PORT"); val != "" {
+		if parsed, err := parseIntFromEnv(val); err == nil {
+			config.Security.SMTPPort = parsed
+		}
+	}
+	if val := os.Getenv("PC_SECURITY_SMTP_USERNAME"); val != "" {
+		config.Security.SMTPUsername = val
+	}
+	if val := os.Getenv("PC_SECURITY_SMTP_PASSWORD"); val != "" {
+		config.Security.SMTPPassword = val
+	}
+	if val := os.Getenv("PC_SECURITY_SMTP_FROM"); val != "" {
+		config.Security.SMTPFrom = val
+	}
+	if val := os.Getenv("PC_SECURITY_REVOKE_ALL_SESSIONS_ON_PASSWORD_CHANGE"); val != "" {
+		config.Security.RevokeAllSessionsOnPasswordChange = strings.ToLower(val) == "true"
+	}
+	if val := os.Getenv("PC_SECURITY_COOKIE_DOMAIN"); val != "" {
+		config.Security.CookieDomain = val
+	}
+	if val := os.Getenv("PC_SECURITY_COOKIE_PATH"); val != "" {
+		config.Security.CookiePath = val
+	}
+	if val := os.Getenv("PC_SECURITY_COOKIE_SAME_SITE"); val != "" {
+		config.Security.CookieSameSite = strings.ToLower(val)
+	}
+	if val := os.Getenv("PC_SECURITY_COOKIE_SECURE"); val != "" {
+		config.Security.CookieSecure = strings.ToLower(val)
+	}
+	if val := os.Getenv("PC_SECURITY_TRUST_FORWARDED_PROTO"); val != "" {
+		config.Security.TrustForwardedProto = strings.ToLower(val) == "true"
+	}
+	if val := os.Getenv("PC_SECURITY_TRUSTED_PROXIES"); val != "" {
+		config.Security.TrustedProxies = strings.Split(val, ",")
+	}
+	if val := os.Getenv("PC_SECURITY_HSTS_MAX_AGE"); val != "" {
+		if duration, err := time.ParseDuration(val); err == nil {
+			config.Security.HSTSMaxAge = duration
+		}
+	}
+	if val := os.Getenv("PC_SECURITY_HSTS_INCLUDE_SUBDOMAINS"); val != "" {
+		config.Security.HSTSIncludeSubdomains = strings.ToLower(val) == "true"
+	}
+	if val := os.Getenv("PC_SECURITY_HSTS_PRELOAD"); val != "" {
+		config.Security.HSTSPreload = strings.ToLower(val) == "true"
+	}
+	if val := os.Getenv("PC_SECURITY_CONTENT_SECURITY_POLICY"); val != "" {
+		config.Security.ContentSecurityPolicy = val
+	}
+	if val := os.Getenv("PC_SECURITY_FRAME_OPTIONS"); val != "" {
+		config.Security.FrameOptions = strings.ToUpper(val)
+	}
+	if val := os.Getenv("PC_SECURITY_REFERRER_POLICY"); val != "" {
+		config.Security.ReferrerPolicy = strings.ToLower(val)
+	}
+	if val := os.Getenv("PC_SECURITY_CORS_ALLOWED_ORIGINS"); val != "" {
+		config.Security.CORSAllowedOrigins = strings.Split(val, ",")
+	}
+	if val := os.Getenv("PC_SECURITY_CORS_ALLOWED_METHODS"); val != "" {
+		config.Security.CORSAllowedMethods = strings.Split(strings.ToUpper(val), ",")
+	}
+	if val := os.Getenv("PC_SECURITY_CORS_ALLOWED_HEADERS"); val != "" {
+		config.Security.CORSAllowedHeaders = strings.Split(val, ",")
+	}
+	if val := os.Getenv("PC_SECURITY_CORS_ALLOW_CREDENTIALS"); val != "" {
+		config.Security.CORSAllowCredentials = strings.ToLower(val) == "true"
+	}
+	if val := os.Getenv("PC_SECURITY_CORS_MAX_AGE"); val != "" {
+		if duration, err := time.ParseDuration(val); err == nil {
+			config.Security.CORSMaxAge = duration
+		}
+	}
 
 	// Monitoring configuration
 	if val := os.Getenv("PC_MONITORING_ENABLED"); val != "" {
@@ -582,6 +1133,21 @@ func applyEnvironmentOverrides(config *Config) error {
 	if val := os.Getenv("PC_MONITORING_HEALTH_CHECK_PATH"); val != "" {
 		config.Monitoring.HealthCheckPath = val
 	}
+	if val := os.Getenv("PC_MONITORING_METRICS_HOST"); val != "" {
+		config.Monitoring.MetricsHost = val
+	}
+	if val := os.Getenv("PC_MONITORING_METRICS_AUTH"); val != "" {
+		config.Monitoring.MetricsAuth = val
+	}
+	if val := os.Getenv("PC_MONITORING_METRICS_TOKEN"); val != "" {
+		config.Monitoring.MetricsToken = val
+	}
+	if val := os.Getenv("PC_MONITORING_METRICS_USERNAME"); val != "" {
+		config.Monitoring.MetricsUsername = val
+	}
+	if val := os.Getenv("PC_MONITORING_METRICS_PASSWORD"); val != "" {
+		config.Monitoring.MetricsPassword = val
+	}
 
 	// Enforcement configuration
 	if val := os.Getenv("PC_ENFORCEMENT_ENABLED"); val != "" {
@@ -630,12 +1196,18 @@ func applyEnvironmentOverrides(config *Config) error {
 	if val := os.Getenv("PC_ENFORCEMENT_DNS_LISTEN_ADDR"); val != "" {
 		config.Enforcement.DNSListenAddr = val
 	}
+	if val := os.Getenv("PC_ENFORCEMENT_DNS_LISTEN_INTERFACE"); val != "" {
+		config.Enforcement.DNSListenInterface = val
+	}
 	if val := os.Getenv("PC_ENFORCEMENT_DNS_BLOCK_IPv4"); val != "" {
 		config.Enforcement.DNSBlockIPv4 = val
 	}
 	if val := os.Getenv("PC_ENFORCEMENT_DNS_BLOCK_IPv6"); val != "" {
 		config.Enforcement.DNSBlockIPv6 = val
 	}
+	if val := os.Getenv("PC_ENFORCEMENT_DNS_BLOCK_RESPONSE"); val != "" {
+		config.Enforcement.DNSBlockResponse = val
+	}
 	if val := os.Getenv("PC_ENFORCEMENT_DNS_UPSTREAM_SERVERS"); val != "" {
 		config.Enforcement.DNSUpstreamServers = strings.Split(val, ",")
 	}
@@ -649,6 +1221,28 @@ func applyEnvironmentOverrides(config *Config) error {
 			config.Enforcement.DNSEnableLogging = enabled
 		}
 	}
+	if val := os.Getenv("PC_ENFORCEMENT_DNS_ECS_MODE"); val != "" {
+		config.Enforcement.DNSECSMode = val
+	}
+	if val := os.Getenv("PC_ENFORCEMENT_DNS_ECS_SUBNET"); val != "" {
+		config.Enforcement.DNSECSSubnet = val
+	}
+	if val := os.Getenv("PC_ENFORCEMENT_BLOCK_ENCRYPTED_DNS"); val != "" {
+		if enabled, err := strconv.ParseBool(val); err == nil {
+			config.Enforcement.BlockEncryptedDNS = enabled
+		}
+	}
+	if val := os.Getenv("PC_ENFORCEMENT_ENCRYPTED_DNS_HOSTS"); val != "" {
+		config.Enforcement.EncryptedDNSHosts = strings.Split(val, ",")
+	}
+	if val := os.Getenv("PC_ENFORCEMENT_ENCRYPTED_DNS_IPS"); val != "" {
+		config.Enforcement.EncryptedDNSIPs = strings.Split(val, ",")
+	}
+	if val := os.Getenv("PC_ENFORCEMENT_DNS_CONTINUE_ON_BIND_FAILURE"); val != "" {
+		if enabled, err := strconv.ParseBool(val); err == nil {
+			config.Enforcement.DNSContinueOnBindFailure = enabled
+		}
+	}
 
 	// Notification configuration
 	if val := os.Getenv("PC_NOTIFICATIONS_ENABLED"); val != "" {
@@ -702,6 +1296,43 @@ func applyEnvironmentOverrides(config *Config) error {
 			config.Notifications.NotificationTimeout = duration
 		}
 	}
+	if val := os.Getenv("PC_NOTIFICATIONS_COALESCE_WINDOW"); val != "" {
+		if duration, err := time.ParseDuration(val); err == nil {
+			config.Notifications.CoalesceWindow = duration
+		}
+	}
+	if val := os.Getenv("PC_NOTIFICATIONS_SINKS"); val != "" {
+		config.Notifications.Sinks = strings.Split(val, ",")
+	}
+	if val := os.Getenv("PC_NOTIFICATIONS_WEBHOOK_URL"); val != "" {
+		config.Notifications.Webhook.URL = val
+	}
+	if val := os.Getenv("PC_NOTIFICATIONS_NTFY_URL"); val != "" {
+		config.Notifications.Ntfy.URL = val
+	}
+	if val := os.Getenv("PC_NOTIFICATIONS_NTFY_TOKEN"); val != "" {
+		config.Notifications.Ntfy.Token = val
+	}
+	if val := os.Getenv("PC_NOTIFICATIONS_EMAIL_TO"); val != "" {
+		config.Notifications.Email.To = strings.Split(val, ",")
+	}
+	if val := os.Getenv("PC_NOTIFICATIONS_EMAIL_FROM"); val != "" {
+		config.Notifications.Email.From = val
+	}
+	if val := os.Getenv("PC_NOTIFICATIONS_EMAIL_SMTP_HOST"); val != "" {
+		config.Notifications.Email.SMTPHost = val
+	}
+	if val := os.Getenv("PC_NOTIFICATIONS_EMAIL_SMTP_PORT"); val != "" {
+		if parsed, err := strconv.Atoi(val); err == nil {
+			config.Notifications.Email.SMTPPort = parsed
+		}
+	}
+	if val := os.Getenv("PC_NOTIFICATIONS_EMAIL_SMTP_USERNAME"); val != "" {
+		config.Notifications.Email.SMTPUsername = val
+	}
+	if val := os.Getenv("PC_NOTIFICATIONS_EMAIL_SMTP_PASSWORD"); val != "" {
+		config.Notifications.Email.SMTPPassword = val
+	}
 
 	// Privilege configuration
 	if val := os.Getenv("PC_PRIVILEGE_ELEVATION_METHOD"); val != "" {
@@ -731,6 +1362,63 @@ func applyEnvironmentOverrides(config *Config) error {
 		}
 	}
 
+	// Retention configuration
+	if val := os.Getenv("PC_RETENTION_SEED_DEFAULTS"); val != "" {
+		if seed, err := strconv.ParseBool(val); err == nil {
+			config.Retention.SeedDefaults = seed
+		}
+	}
+	if val := os.Getenv("PC_RETENTION_ENABLE_SEEDED"); val != "" {
+		if enable, err := strconv.ParseBool(val); err == nil {
+			config.Retention.EnableSeeded = enable
+		}
+	}
+	if val := os.Getenv("PC_RETENTION_AUDIT_MAX_AGE"); val != "" {
+		if duration, err := time.ParseDuration(val); err == nil {
+			config.Retention.AuditMaxAge = duration
+		}
+	}
+	if val := os.Getenv("PC_RETENTION_AUDIT_MAX_ENTRIES"); val != "" {
+		if parsed, err := strconv.ParseInt(val, 10, 64); err == nil {
+			config.Retention.AuditMaxEntries = parsed
+		}
+	}
+	if val := os.Getenv("PC_RETENTION_LOG_MAX_FILE_SIZE"); val != "" {
+		if parsed, err := strconv.ParseInt(val, 10, 64); err == nil {
+			config.Retention.LogMaxFileSize = parsed
+		}
+	}
+	if val := os.Getenv("PC_RETENTION_LOG_RETAIN_DURATION"); val != "" {
+		if duration, err := time.ParseDuration(val); err == nil {
+			config.Retention.LogRetainDuration = duration
+		}
+	}
+
+	// Backup configuration
+	if val := os.Getenv("PC_BACKUP_ENABLED"); val != "" {
+		if enabled, err := strconv.ParseBool(val); err == nil {
+			config.Backup.Enabled = enabled
+		}
+	}
+	if val := os.Getenv("PC_BACKUP_DIRECTORY"); val != "" {
+		config.Backup.Directory = val
+	}
+	if val := os.Getenv("PC_BACKUP_KEEP"); val != "" {
+		if keep, err := parseIntFromEnv(val); err == nil {
+			config.Backup.Keep = keep
+		}
+	}
+	if val := os.Getenv("PC_BACKUP_BEFORE_MIGRATIONS"); val != "" {
+		if before, err := strconv.ParseBool(val); err == nil {
+			config.Backup.BeforeMigrations = before
+		}
+	}
+	if val := os.Getenv("PC_BACKUP_RETENTION_THRESHOLD"); val != "" {
+		if parsed, err := strconv.ParseInt(val, 10, 64); err == nil {
+			config.Backup.RetentionThreshold = parsed
+		}
+	}
+
 	return nil
 }
 
@@ -768,6 +1456,40 @@ func (c *Config) Validate() error {
 	if c.Database.MaxIdleConns > c.Database.MaxOpenConns {
 		errors = append(errors, "database.max_idle_conns cannot exceed max_open_conns")
 	}
+	if c.Database.ReadReplica && c.Database.ReadMaxOpenConns < 0 {
+		errors = append(errors, "database.read_max_open_conns cannot be negative")
+	}
+	if c.Database.StartupRetryWindow < 0 {
+		errors = append(errors, "database.startup_retry_window cannot be negative")
+	}
+	if c.Database.StartupRetryWindow > 0 && c.Database.StartupRetryInterval <= 0 {
+		errors = append(errors, "database.startup_retry_interval must be positive when startup_retry_window is set")
+	}
+	if c.Database.SlowQueryThreshold < 0 {
+		errors = append(errors, "database.slow_query_threshold cannot be negative")
+	}
+	if c.Database.MaintenanceEnabled {
+		if c.Database.MaintenanceCheckpointInterval <= 0 {
+			errors = append(errors, "database.maintenance_checkpoint_interval must be positive")
+		}
+		if c.Database.MaintenanceVacuumInterval < 0 {
+			errors = append(errors, "database.maintenance_vacuum_interval cannot be negative")
+		}
+		if c.Database.MaintenanceVacuumMinFreePercent < 0 || c.Database.MaintenanceVacuumMinFreePercent > 100 {
+			errors = append(errors, "database.maintenance_vacuum_min_free_percent must be between 0 and 100")
+		}
+		if (c.Database.MaintenanceWindowStart == "") != (c.Database.MaintenanceWindowEnd == "") {
+			errors = append(errors, "database.maintenance_window_start and maintenance_window_end must be set together")
+		}
+		for _, window := range [][2]string{
+			{"maintenance_window_start", c.Database.MaintenanceWindowStart},
+			{"maintenance_window_end", c.Database.MaintenanceWindowEnd},
+		} {
+			if _, err := time.Parse("15:04", window[1]); window[1] != "" && err != nil {
+				errors = append(errors, fmt.Sprintf("database.%s must be a time of day as HH:MM, got %q", window[0], window[1]))
+			}
+		}
+	}
 
 	// Validate logging configuration
 	validLogLevels := map[string]bool{
@@ -784,12 +1506,34 @@ func (c *Config) Validate() error {
 
 	// Validate web configuration
 	if c.Web.Enabled {
-		if c.Web.Port <= 0 || c.Web.Port > 65535 {
+		socket := c.Web.UnixSocket()
+		if socket == "" && (c.Web.Port <= 0 || c.Web.Port > 65535) {
 			errors = append(errors, "web.port must be between 1 and 65535")
 		}
 		if c.Web.Host == "" {
 			errors = append(errors, "web.host cannot be empty when web interface is enabled")
 		}
+		if socket != "" {
+			// The socket replaces TCP; a TCP host beside it would be ignored
+			switch {
+			case c.Web.SocketPath == "" || isLoopbackHost(c.Web.Host):
+			case !strings.HasPrefix(c.Web.Host, unixHostPrefix):
+				errors = append(errors, fmt.Sprintf("web.host %q and web.socket_path are both set; use one of TCP or a Unix socket", c.Web.Host))
+			case strings.TrimPrefix(c.Web.Host, unixHostPrefix) != c.Web.SocketPath:
+				errors = append(errors, "web.host and web.socket_path name different Unix sockets")
+			}
+			if !filepath.IsAbs(socket) {
+				errors = append(errors, "web Unix socket path must be absolute")
+			}
+			if c.Web.TLSEnabled {
+				errors = append(errors, "web.tls_enabled cannot be used with a Unix socket; terminate TLS at the proxy")
+			}
+		}
+		if c.Web.SocketMode != "" {
+			if mode, err := strconv.ParseUint(c.Web.SocketMode, 8, 32); err != nil || mode > 0777 {
+				errors = append(errors, "web.socket_mode must be an octal file mode such as 0660")
+			}
+		}
 		if c.Web.TLSEnabled {
 			// Only require cert/key files if auto-generation is disabled
 			if !c.Web.TLSAutoGenerate {
@@ -808,22 +1552,31 @@ func (c *Config) Validate() error {
 					errors = append(errors, "web.tls_hostname is required when TLS auto-generation is enabled")
 				}
 			}
-			// Validate HTTPS port if different from HTTP port
-			if c.Web.TLSRedirectHTTP && c.Web.HTTPSPort > 0 {
-				if c.Web.HTTPSPort <= 0 || c.Web.HTTPSPort > 65535 {
+			// HTTPS is served on its own port beside plain HTTP; 0 uses 8443
+			if c.Web.HTTPSPort != 0 {
+				if c.Web.HTTPSPort < 0 || c.Web.HTTPSPort > 65535 {
 					errors = append(errors, "web.https_port must be between 1 and 65535")
 				}
 				if c.Web.HTTPSPort == c.Web.Port {
-					errors = append(errors, "web.https_port cannot be the same as web.port when redirect is enabled")
+					errors = append(errors, "web.https_port cannot be the same as web.port when TLS is enabled")
 				}
 			}
 		}
 	}
 
+	if c.Web.MaxRequestBodyBytes < 0 {
+		errors = append(errors, "web.max_request_body_bytes cannot be negative")
+	}
+	if c.Web.MaxImportBodyBytes < 0 {
+		errors = append(errors, "web.max_import_body_bytes cannot be negative")
+	}
+
 	// Validate security configuration
 	if c.Security.EnableAuth {
-		if c.Security.AdminPassword == "" {
-			errors = append(errors, "security.admin_password is required when authentication is enabled")
+		// Without an admin password the first admin is created through
+		// initial setup instead
+		if c.Security.AdminPassword != "" && c.Security.AdminUsername == "" {
+			errors = append(errors, "security.admin_username is required when security.admin_password is set")
 		}
 		if c.Security.SessionSecret == "" {
 			errors = append(errors, "security.session_secret is required when authentication is enabled")
@@ -841,6 +1594,32 @@ func (c *Config) Validate() error {
 	if c.Security.LockoutDuration <= 0 {
 		errors = append(errors, "security.lockout_duration must be positive")
 	}
+	if c.Security.LockoutEscalation {
+		if c.Security.LockoutEscalationFactor < 1 {
+			errors = append(errors, "security.lockout_escalation_factor must be at least 1")
+		}
+		if c.Security.LockoutMaxDuration < c.Security.LockoutDuration {
+			errors = append(errors, "security.lockout_max_duration must not be shorter than lockout_duration")
+		}
+	}
+	if c.Security.LockoutNotifyWebhookURL != "" {
+		if u, err := url.Parse(c.Security.LockoutNotifyWebhookURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errors = append(errors, "security.lockout_notify_webhook_url must be an http or https URL")
+		}
+	}
+	if len(c.Security.LockoutNotifyEmail) > 0 {
+		if c.Security.SMTPHost == "" {
+			errors = append(errors, "security.lockout_notify_email requires smtp_host")
+		}
+		for _, address := range c.Security.LockoutNotifyEmail {
+			if _, err := mail.ParseAddress(strings.TrimSpace(address)); err != nil {
+				errors = append(errors, fmt.Sprintf("security.lockout_notify_email contains invalid address: %s", address))
+			}
+		}
+	}
+	if c.Security.SMTPHost != "" && (c.Security.SMTPPort <= 0 || c.Security.SMTPPort > 65535) {
+		errors = append(errors, "security.smtp_port must be between 1 and 65535")
+	}
 
 	// Validate password configuration
 	if c.Security.BcryptCost < 4 || c.Security.BcryptCost > 31 {
@@ -858,12 +1637,91 @@ func (c *Config) Validate() error {
 	if c.Security.LoginRateLimit <= 0 {
 		errors = append(errors, "security.login_rate_limit must be positive")
 	}
+	if c.Security.AuthRateLimit < 0 {
+		errors = append(errors, "security.auth_rate_limit cannot be negative")
+	}
+	if c.Security.AuthRateLimit > 0 && c.Security.AuthRateLimitWindow <= 0 {
+		errors = append(errors, "security.auth_rate_limit_window must be positive when auth_rate_limit is set")
+	}
+	if c.Security.APIRateLimit < 0 {
+		errors = append(errors, "security.api_rate_limit cannot be negative")
+	}
+	if c.Security.APIRateLimit > 0 && c.Security.APIRateLimitWindow <= 0 {
+		errors = append(errors, "security.api_rate_limit_window must be positive when api_rate_limit is set")
+	}
+	if c.Security.RecoveryTokenTTL <= 0 {
+		errors = append(errors, "security.recovery_token_ttl must be positive")
+	}
+	if c.Security.RecoveryMaxAttempts <= 0 {
+		errors = append(errors, "security.recovery_max_attempts must be positive")
+	}
+	if c.Security.LoginAttemptHistorySize <= 0 {
+		errors = append(errors, "security.login_attempt_history_size must be positive")
+	}
+	if c.Security.EventHistorySize <= 0 {
+		errors = append(errors, "security.event_history_size must be positive")
+	}
 	if c.Security.RememberMeDuration <= 0 {
 		errors = append(errors, "security.remember_me_duration must be positive")
 	}
 	if c.Security.MaxSessions <= 0 {
 		errors = append(errors, "security.max_sessions must be positive")
 	}
+	if c.Security.CookiePath != "" && !strings.HasPrefix(c.Security.CookiePath, "/") {
+		errors = append(errors, "security.cookie_path must start with /")
+	}
+	switch c.Security.CookieSameSite {
+	case "", "strict", "lax", "none":
+	default:
+		errors = append(errors, "security.cookie_same_site must be one of: strict, lax, none")
+	}
+	switch c.Security.CookieSecure {
+	case "", "auto", "always", "never":
+	default:
+		errors = append(errors, "security.cookie_secure must be one of: auto, always, never")
+	}
+	if c.Security.CookieSameSite == "none" && c.Security.CookieSecure == "never" {
+		errors = append(errors, "security.cookie_same_site none requires cookie_secure auto or always")
+	}
+	for _, proxy := range c.Security.TrustedProxies {
+		if !isValidIPOrCIDR(strings.TrimSpace(proxy)) {
+			errors = append(errors, fmt.Sprintf("security.trusted_proxies contains invalid address: %s", proxy))
+		}
+	}
+	if c.Security.HSTSMaxAge < 0 {
+		errors = append(errors, "security.hsts_max_age cannot be negative")
+	}
+	if c.Security.HSTSPreload && (!c.Security.HSTSIncludeSubdomains || c.Security.HSTSMaxAge < 365*24*time.Hour) {
+		errors = append(errors, "security.hsts_preload requires hsts_include_subdomains and an hsts_max_age of at least 8760h")
+	}
+	switch strings.ToUpper(c.Security.FrameOptions) {
+	case "", "DENY", "SAMEORIGIN", "OFF":
+	default:
+		errors = append(errors, "security.frame_options must be one of: DENY, SAMEORIGIN, off")
+	}
+	switch c.Security.ReferrerPolicy {
+	case "", "no-referrer", "no-referrer-when-downgrade", "origin", "origin-when-cross-origin",
+		"same-origin", "strict-origin", "strict-origin-when-cross-origin", "unsafe-url":
+	default:
+		errors = append(errors, "security.referrer_policy must be a valid Referrer-Policy value")
+	}
+	if strings.ContainsAny(c.Security.ContentSecurityPolicy, "\r\n") {
+		errors = append(errors, "security.content_security_policy must be a single line")
+	}
+	for _, origin := range c.Security.CORSAllowedOrigins {
+		if origin == "*" {
+			if c.Security.CORSAllowCredentials {
+				errors = append(errors, "security.cors_allowed_origins cannot contain * when cors_allow_credentials is enabled")
+			}
+			continue
+		}
+		if !isValidOrigin(origin) {
+			errors = append(errors, fmt.Sprintf("security.cors_allowed_origins contains invalid origin: %s (expected scheme://host[:port])", origin))
+		}
+	}
+	if c.Security.CORSMaxAge < 0 {
+		errors = append(errors, "security.cors_max_age cannot be negative")
+	}
 
 	// Validate monitoring configuration
 	if c.Monitoring.Enabled {
@@ -876,8 +1734,21 @@ func (c *Config) Validate() error {
 		if c.Monitoring.HealthCheckPath == "" {
 			errors = append(errors, "monitoring.health_check_path cannot be empty when monitoring is enabled")
 		}
+		switch c.Monitoring.MetricsAuth {
+		case "", "none":
+		case "bearer":
+			if len(c.Monitoring.MetricsToken) < 16 {
+				errors = append(errors, "monitoring.metrics_token must be at least 16 characters when metrics_auth is bearer")
+			}
+		case "basic":
+			if c.Monitoring.MetricsUsername == "" || c.Monitoring.MetricsPassword == "" {
+				errors = append(errors, "monitoring.metrics_username and monitoring.metrics_password are required when metrics_auth is basic")
+			}
+		default:
+			errors = append(errors, "monitoring.metrics_auth must be one of: none, bearer, basic")
+		}
 		// Check for port conflicts
-		if c.Web.Enabled && c.Web.Port == c.Monitoring.MetricsPort {
+		if c.Web.Enabled && c.Web.UnixSocket() == "" && c.Web.Port == c.Monitoring.MetricsPort {
 			errors = append(errors, "web.port and monitoring.metrics_port cannot be the same")
 		}
 	}
@@ -913,6 +1784,41 @@ func (c *Config) Validate() error {
 		if c.Enforcement.EnableEmergencyMode && c.Enforcement.DNSListenAddr == "" {
 			errors = append(errors, "enforcement.dns_listen_addr is required when emergency mode is enabled")
 		}
+		switch c.Enforcement.DNSBlockResponse {
+		case "", "sinkhole", "nxdomain", "refused":
+		default:
+			errors = append(errors, "enforcement.dns_block_response must be one of: sinkhole, nxdomain, refused")
+		}
+		switch c.Enforcement.DNSECSMode {
+		case "", "strip", "passthrough":
+		case "fixed":
+			if _, _, err := net.ParseCIDR(c.Enforcement.DNSECSSubnet); err != nil {
+				errors = append(errors, "enforcement.dns_ecs_subnet must be a valid CIDR when dns_ecs_mode is fixed")
+			}
+		default:
+			errors = append(errors, "enforcement.dns_ecs_mode must be one of: strip, passthrough, fixed")
+		}
+		for _, server := range c.Enforcement.DNSUpstreamServers {
+			if err := validateDNSUpstream(server); err != "" {
+				errors = append(errors, err)
+			}
+		}
+		for _, ip := range c.Enforcement.EncryptedDNSIPs {
+			if net.ParseIP(strings.TrimSpace(ip)) == nil {
+				errors = append(errors, fmt.Sprintf("enforcement.encrypted_dns_ips must contain IP addresses, got %q", ip))
+			}
+		}
+		if ip := c.Enforcement.DNSBlockIPv4; ip != "" && (net.ParseIP(ip) == nil || net.ParseIP(ip).To4() == nil) {
+			errors = append(errors, fmt.Sprintf("enforcement.dns_block_ipv4 must be a valid IPv4 address, got %q", ip))
+		}
+		if ip := c.Enforcement.DNSBlockIPv6; ip != "" && (net.ParseIP(ip) == nil || net.ParseIP(ip).To4() != nil) {
+			errors = append(errors, fmt.Sprintf("enforcement.dns_block_ipv6 must be a valid IPv6 address, got %q", ip))
+		}
+		if c.Enforcement.EnableNetworkFiltering {
+			if err := validateDNSListen(c.Enforcement.DNSListenAddr, c.Enforcement.DNSListenInterface); err != "" {
+				errors = append(errors, err)
+			}
+		}
 	}
 
 	// Validate notification configuration
@@ -926,27 +1832,92 @@ func (c *Config) Validate() error {
 		if c.Notifications.NotificationTimeout < 0 {
 			errors = append(errors, "notifications.notification_timeout cannot be negative")
 		}
+		if c.Notifications.CoalesceWindow < 0 {
+			errors = append(errors, "notifications.coalesce_window cannot be negative")
+		}
 		if c.Notifications.AppName == "" {
 			errors = append(errors, "notifications.app_name cannot be empty when notifications are enabled")
 		}
+		for _, sink := range c.Notifications.Sinks {
+			switch strings.ToLower(strings.TrimSpace(sink)) {
+			case "desktop":
+			case "webhook":
+				if u, err := url.Parse(c.Notifications.Webhook.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+					errors = append(errors, "notifications.webhook.url must be an http or https URL when the webhook sink is enabled")
+				}
+			case "ntfy":
+				if u, err := url.Parse(c.Notifications.Ntfy.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || strings.Trim(u.Path, "/") == "" {
+					errors = append(errors, "notifications.ntfy.url must be an http or https topic URL when the ntfy sink is enabled")
+				}
+			case "email":
+				if c.Notifications.Email.SMTPHost == "" || len(c.Notifications.Email.To) == 0 {
+					errors = append(errors, "notifications.email requires smtp_host and to when the email sink is enabled")
+				}
+				for _, address := range c.Notifications.Email.To {
+					if _, err := mail.ParseAddress(strings.TrimSpace(address)); err != nil {
+						errors = append(errors, fmt.Sprintf("notifications.email.to contains invalid address: %s", address))
+					}
+				}
+				if c.Notifications.Email.SMTPPort <= 0 || c.Notifications.Email.SMTPPort > 65535 {
+					errors = append(errors, "notifications.email.smtp_port must be between 1 and 65535")
+				}
+			default:
+				errors = append(errors, fmt.Sprintf("notifications.sinks contains unknown sink %q (expected desktop, webhook, ntfy or email)", sink))
+			}
+		}
+	}
+
+	// Validate retention configuration
+	if c.Retention.SeedDefaults {
+		if c.Retention.AuditMaxAge < 0 || c.Retention.AuditMaxEntries < 0 {
+			errors = append(errors, "retention.audit_max_age and audit_max_entries cannot be negative")
+		}
+		if c.Retention.AuditMaxAge == 0 && c.Retention.AuditMaxEntries == 0 {
+			errors = append(errors, "retention.audit_max_age or audit_max_entries must be set when seeding defaults")
+		}
+		if c.Retention.LogMaxFileSize < 0 {
+			errors = append(errors, "retention.log_max_file_size cannot be negative")
+		}
+		if c.Retention.LogRetainDuration <= 0 {
+			errors = append(errors, "retention.log_retain_duration must be positive")
+		}
+	}
+
+	// Validate backup configuration
+	if c.Backup.Enabled {
+		if c.Backup.Keep < 1 {
+			errors = append(errors, "backup.keep must be at least 1")
+		}
+		if c.Backup.RetentionThreshold < 0 {
+			errors = append(errors, "backup.retention_threshold cannot be negative")
+		}
 	}
 
 	if len(errors) > 0 {
-		return fmt.Errorf("validation errors: %s", strings.Join(errors, "; "))
+		return &ValidationError{Problems: errors}
 	}
 
 	return nil
 }
 
-// SaveToFile saves the configuration to a YAML file
+// ValidationError lists every problem Validate found
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("validation errors: %s", strings.Join(e.Problems, "; "))
+}
+
+// SaveToFile saves the configuration in the format matching the file extension
 func (c *Config) SaveToFile(path string) error {
 	// Create directory if it doesn't exist
 	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
 		return fmt.Errorf("failed to create config directory: %w", err)
 	}
 
-	// Marshal to YAML
-	data, err := yaml.Marshal(c)
+	// Marshal in the format matching the extension
+	data, err := encodeConfig(c, formatFromPath(path))
 	if err != nil {
 		return fmt.Errorf("failed to marshal configuration: %w", err)
 	}
@@ -959,6 +1930,39 @@ func (c *Config) SaveToFile(path string) error {
 	return nil
 }
 
+// WriteYAML writes the configuration as YAML, as SaveToFile does for .yaml files
+func (c *Config) WriteYAML(w io.Writer) error {
+	data, err := encodeConfig(c, formatYAML)
+	if err != nil {
+		return fmt.Errorf("failed to marshal configuration: %w", err)
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// UpdateFile applies update to the configuration file at path and writes it
+// back in the same format. Environment overrides are not applied, so values
+// only set in the environment are not persisted to the file.
+func UpdateFile(path string, update func(*Config)) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read configuration file: %w", err)
+	}
+
+	config := Default()
+	format := formatFromPath(path)
+	if err := decodeConfig(data, format, config); err != nil {
+		return fmt.Errorf("failed to parse configuration file as %s: %w", format, err)
+	}
+
+	update(config)
+	if err := config.Validate(); err != nil {
+		return fmt.Errorf("configuration validation failed: %w", err)
+	}
+
+	return config.SaveToFile(path)
+}
+
 // Clone creates a deep copy of the configuration
 func (c *Config) Clone() *Config {
 	clone := *c
@@ -970,9 +1974,14 @@ func (c *Config) GetServiceConfig() ServiceConfig {
 	return c.Service
 }
 
-// GetDatabaseConfig returns the database configuration
+// GetDatabaseConfig returns the database configuration, including where
+// to back up before migrations when the backup settings ask for it
 func (c *Config) GetDatabaseConfig() database.Config {
-	return c.Database
+	dbConfig := c.Database
+	if c.Backup.Enabled && c.Backup.BeforeMigrations {
+		dbConfig.MigrationBackupDir = c.BackupDirectory()
+	}
+	return dbConfig
 }
 
 // GetLoggingConfig returns the logging configuration
@@ -1020,27 +2029,121 @@ func parseIntFromEnv(val string) (int, error) {
 	return result, nil
 }
 
+// isValidIPOrCIDR checks whether a value is a plain IP address or a CIDR block
+func isValidIPOrCIDR(val string) bool {
+	if net.ParseIP(val) != nil {
+		return true
+	}
+	_, _, err := net.ParseCIDR(val)
+	return err == nil
+}
+
+// isValidOrigin checks whether a value is a browser origin: an http or https
+// scheme and a host with an optional port, without a path
+func isValidOrigin(val string) bool {
+	u, err := url.Parse(strings.TrimSuffix(strings.TrimSpace(val), "/"))
+	if err != nil || u.Host == "" || u.Path != "" || u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+		return false
+	}
+	return u.Scheme == "http" || u.Scheme == "https"
+}
+
+// validateDNSListen checks the DNS listen address list and interface. It
+// returns an empty string when the settings are usable on this host.
+func validateDNSListen(listenAddr, iface string) string {
+	for _, item := range strings.Split(listenAddr, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		host, port, err := net.SplitHostPort(item)
+		if err != nil {
+			host, port = strings.Trim(item, "[]"), "53"
+		}
+		if p, err := strconv.Atoi(port); err != nil || p < 0 || p > 65535 {
+			return fmt.Sprintf("enforcement.dns_listen_addr has an invalid port in %q", item)
+		}
+		if host == "" {
+			continue
+		}
+		if net.ParseIP(host) == nil {
+			return fmt.Sprintf("enforcement.dns_listen_addr must contain IP addresses, got %q", item)
+		}
+		if iface != "" && !net.ParseIP(host).IsUnspecified() {
+			return "enforcement.dns_listen_addr may only set a port when dns_listen_interface is set"
+		}
+	}
+
+	if iface != "" {
+		if _, err := net.InterfaceByName(iface); err != nil {
+			return fmt.Sprintf("enforcement.dns_listen_interface %q does not exist", iface)
+		}
+	}
+	return ""
+}
+
+// validateDNSUpstream checks a single upstream DNS server, given as an IP
+// address with an optional port. It returns an empty string when valid.
+func validateDNSUpstream(server string) string {
+	host, port, err := net.SplitHostPort(server)
+	if err != nil {
+		host, port = strings.Trim(server, "[]"), "53"
+	}
+	if p, err := strconv.Atoi(port); err != nil || p < 1 || p > 65535 {
+		return fmt.Sprintf("enforcement.dns_upstream_servers has an invalid port in %q", server)
+	}
+	if net.ParseIP(host) == nil {
+		return fmt.Sprintf("enforcement.dns_upstream_servers must contain IP addresses, got %q", server)
+	}
+	return ""
+}
+
 // DefaultSecurityConfig returns default security configuration
 func DefaultSecurityConfig() SecurityConfig {
 	return SecurityConfig{
-		EnableAuth:            false, // Disabled by default for easier setup
-		AdminPassword:         "",
-		SessionSecret:         "",
-		SessionTimeout:        24 * time.Hour,
-		MaxFailedAttempts:     5,
-		LockoutDuration:       15 * time.Minute,
-		BcryptCost:            12, // Good balance of security and performance
-		MinPasswordLength:     8,
-		RequireUppercase:      true,
-		RequireLowercase:      true,
-		RequireNumbers:        true,
-		RequireSpecialChars:   false, // Optional for easier setup
-		PasswordHistorySize:   5,
-		PasswordExpireDays:    0,                   // No expiration by default
-		LoginRateLimit:        10,                  // 10 attempts per minute
-		RememberMeDuration:    30 * 24 * time.Hour, // 30 days
-		AllowMultipleSessions: false,
-		MaxSessions:           1,
+		EnableAuth:              false, // Disabled by default for easier setup
+		AdminUsername:           "admin",
+		AdminPassword:           "",
+		SessionSecret:           "",
+		SessionTimeout:          24 * time.Hour,
+		MaxFailedAttempts:       5,
+		LockoutDuration:         15 * time.Minute,
+		LockoutEscalationFactor: 2,
+		LockoutMaxDuration:      24 * time.Hour,
+		LockoutEscalationReset:  24 * time.Hour,
+		LockoutNotifyEmail:      []string{},
+		SMTPPort:                587,
+		BcryptCost:              12, // Good balance of security and performance
+		MinPasswordLength:       8,
+		RequireUppercase:        true,
+		RequireLowercase:        true,
+		RequireNumbers:          true,
+		RequireSpecialChars:     false, // Optional for easier setup
+		PasswordHistorySize:     5,
+		PasswordExpireDays:      0,  // No expiration by default
+		LoginRateLimit:          10, // 10 attempts per minute
+		AuthRateLimit:           30,
+		AuthRateLimitWindow:     time.Minute,
+		APIRateLimit:            600,
+		APIRateLimitWindow:      time.Minute,
+		LoginAttemptHistorySize: 1000,
+		EventHistorySize:        1000,
+		RememberMeDuration:      30 * 24 * time.Hour, // 30 days
+		AllowMultipleSessions:   false,
+		MaxSessions:             1,
+		RecoveryTokenTTL:        15 * time.Minute,
+		RecoveryMaxAttempts:     5,
+		CookiePath:              "/",
+		CookieSameSite:          "strict",
+		CookieSecure:            "auto",
+		TrustedProxies:          []string{},
+		HSTSMaxAge:              365 * 24 * time.Hour,
+		HSTSIncludeSubdomains:   true,
+		FrameOptions:            "DENY",
+		ReferrerPolicy:          "strict-origin-when-cross-origin",
+		CORSAllowedOrigins:      []string{},
+		CORSMaxAge:              10 * time.Minute,
 	}
 }
 
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -98,13 +99,23 @@ func TestValidation(t *testing.T) {
 			errorText:   "web.tls_cert_file is required when TLS is enabled",
 		},
 		{
-			name: "auth enabled without password",
+			name: "auth enabled without password uses initial setup",
 			modify: func(c *Config) {
 				c.Security.EnableAuth = true
 				c.Security.AdminPassword = ""
+				c.Security.SessionSecret = "this-is-a-very-long-secret-key-that-is-at-least-32-chars"
+			},
+			expectError: false,
+		},
+		{
+			name: "auth enabled without admin username",
+			modify: func(c *Config) {
+				c.Security.EnableAuth = true
+				c.Security.AdminUsername = ""
+				c.Security.AdminPassword = "password"
 			},
 			expectError: true,
-			errorText:   "security.admin_password is required when authentication is enabled",
+			errorText:   "security.admin_username is required when security.admin_password is set",
 		},
 		{
 			name: "session secret too short",
@@ -125,6 +136,337 @@ func TestValidation(t *testing.T) {
 			expectError: true,
 			errorText:   "web.port and monitoring.metrics_port cannot be the same",
 		},
+		{
+			name: "database retry window without interval",
+			modify: func(c *Config) {
+				c.Database.StartupRetryInterval = 0
+			},
+			expectError: true,
+			errorText:   "database.startup_retry_interval must be positive",
+		},
+		{
+			name: "database retries disabled",
+			modify: func(c *Config) {
+				c.Database.StartupRetryWindow = 0
+				c.Database.StartupRetryInterval = 0
+			},
+			expectError: false,
+		},
+		{
+			name: "negative slow query threshold",
+			modify: func(c *Config) {
+				c.Database.SlowQueryThreshold = -time.Second
+			},
+			expectError: true,
+			errorText:   "database.slow_query_threshold cannot be negative",
+		},
+		{
+			name: "maintenance window with only a start",
+			modify: func(c *Config) {
+				c.Database.MaintenanceWindowEnd = ""
+			},
+			expectError: true,
+			errorText:   "database.maintenance_window_start and maintenance_window_end must be set together",
+		},
+		{
+			name: "maintenance window not a time of day",
+			modify: func(c *Config) {
+				c.Database.MaintenanceWindowStart = "2am"
+			},
+			expectError: true,
+			errorText:   `database.maintenance_window_start must be a time of day as HH:MM, got "2am"`,
+		},
+		{
+			name: "maintenance window over midnight",
+			modify: func(c *Config) {
+				c.Database.MaintenanceWindowStart = "23:30"
+				c.Database.MaintenanceWindowEnd = "04:00"
+			},
+			expectError: false,
+		},
+		{
+			name: "maintenance disabled ignores its settings",
+			modify: func(c *Config) {
+				c.Database.MaintenanceEnabled = false
+				c.Database.MaintenanceCheckpointInterval = 0
+			},
+			expectError: false,
+		},
+		{
+			name: "invalid cookie same site",
+			modify: func(c *Config) {
+				c.Security.CookieSameSite = "loose"
+			},
+			expectError: true,
+			errorText:   "security.cookie_same_site must be one of",
+		},
+		{
+			name: "same site none without secure",
+			modify: func(c *Config) {
+				c.Security.CookieSameSite = "none"
+				c.Security.CookieSecure = "never"
+			},
+			expectError: true,
+			errorText:   "security.cookie_same_site none requires",
+		},
+		{
+			name: "invalid trusted proxy",
+			modify: func(c *Config) {
+				c.Security.TrustForwardedProto = true
+				c.Security.TrustedProxies = []string{"not-an-ip"}
+			},
+			expectError: true,
+			errorText:   "security.trusted_proxies contains invalid address",
+		},
+		{
+			name: "lockout email without smtp host",
+			modify: func(c *Config) {
+				c.Security.LockoutNotifyEmail = []string{"parent@example.com"}
+			},
+			expectError: true,
+			errorText:   "security.lockout_notify_email requires smtp_host",
+		},
+		{
+			name: "lockout escalation factor below one",
+			modify: func(c *Config) {
+				c.Security.LockoutEscalation = true
+				c.Security.LockoutEscalationFactor = 0.5
+			},
+			expectError: true,
+			errorText:   "security.lockout_escalation_factor must be at least 1",
+		},
+		{
+			name: "zero login attempt history size",
+			modify: func(c *Config) {
+				c.Security.LoginAttemptHistorySize = 0
+			},
+			expectError: true,
+			errorText:   "security.login_attempt_history_size must be positive",
+		},
+		{
+			name: "negative event history size",
+			modify: func(c *Config) {
+				c.Security.EventHistorySize = -1
+			},
+			expectError: true,
+			errorText:   "security.event_history_size must be positive",
+		},
+		{
+			name: "hsts preload without subdomains",
+			modify: func(c *Config) {
+				c.Security.HSTSPreload = true
+				c.Security.HSTSIncludeSubdomains = false
+			},
+			expectError: true,
+			errorText:   "security.hsts_preload requires",
+		},
+		{
+			name: "invalid frame options",
+			modify: func(c *Config) {
+				c.Security.FrameOptions = "ALLOW-FROM https://example.com"
+			},
+			expectError: true,
+			errorText:   "security.frame_options must be one of",
+		},
+		{
+			name: "api rate limit without window",
+			modify: func(c *Config) {
+				c.Security.APIRateLimitWindow = 0
+			},
+			expectError: true,
+			errorText:   "security.api_rate_limit_window must be positive",
+		},
+		{
+			name: "cors wildcard with credentials",
+			modify: func(c *Config) {
+				c.Security.CORSAllowedOrigins = []string{"*"}
+				c.Security.CORSAllowCredentials = true
+			},
+			expectError: true,
+			errorText:   "security.cors_allowed_origins cannot contain *",
+		},
+		{
+			name: "cors origin with a path",
+			modify: func(c *Config) {
+				c.Security.CORSAllowedOrigins = []string{"https://dashboard.lan/app"}
+			},
+			expectError: true,
+			errorText:   "security.cors_allowed_origins contains invalid origin",
+		},
+		{
+			name: "unix socket host without a port",
+			modify: func(c *Config) {
+				c.Web.Host = "unix:/run/parental-control/web.sock"
+				c.Web.Port = 0
+			},
+			expectError: false,
+		},
+		{
+			name: "socket path beside a tcp host",
+			modify: func(c *Config) {
+				c.Web.Host = "0.0.0.0"
+				c.Web.SocketPath = "/run/parental-control/web.sock"
+			},
+			expectError: true,
+			errorText:   "use one of TCP or a Unix socket",
+		},
+		{
+			name: "socket path and unix host differ",
+			modify: func(c *Config) {
+				c.Web.Host = "unix:/run/a.sock"
+				c.Web.SocketPath = "/run/b.sock"
+			},
+			expectError: true,
+			errorText:   "name different Unix sockets",
+		},
+		{
+			name: "tls on a unix socket",
+			modify: func(c *Config) {
+				c.Web.SocketPath = "/run/parental-control/web.sock"
+				c.Web.TLSEnabled = true
+			},
+			expectError: true,
+			errorText:   "web.tls_enabled cannot be used with a Unix socket",
+		},
+		{
+			name: "invalid socket mode",
+			modify: func(c *Config) {
+				c.Web.SocketMode = "rw-rw----"
+			},
+			expectError: true,
+			errorText:   "web.socket_mode must be an octal file mode",
+		},
+		{
+			name: "bearer metrics auth without token",
+			modify: func(c *Config) {
+				c.Monitoring.MetricsAuth = "bearer"
+			},
+			expectError: true,
+			errorText:   "monitoring.metrics_token must be at least 16 characters",
+		},
+		{
+			name: "basic metrics auth without credentials",
+			modify: func(c *Config) {
+				c.Monitoring.MetricsAuth = "basic"
+				c.Monitoring.MetricsUsername = "prometheus"
+			},
+			expectError: true,
+			errorText:   "monitoring.metrics_username and monitoring.metrics_password are required",
+		},
+		{
+			name: "invalid dns listen address",
+			modify: func(c *Config) {
+				c.Enforcement.DNSListenAddr = "lan.example.com"
+			},
+			expectError: true,
+			errorText:   "enforcement.dns_listen_addr must contain IP addresses",
+		},
+		{
+			name: "truncated dns upstream",
+			modify: func(c *Config) {
+				c.Enforcement.DNSUpstreamServers = []string{"1.1.1.1", "8.8.8"}
+			},
+			expectError: true,
+			errorText:   "enforcement.dns_upstream_servers must contain IP addresses, got \"8.8.8\"",
+		},
+		{
+			name: "dns upstream with invalid port",
+			modify: func(c *Config) {
+				c.Enforcement.DNSUpstreamServers = []string{"[2001:4860:4860::8888]:0"}
+			},
+			expectError: true,
+			errorText:   "enforcement.dns_upstream_servers has an invalid port",
+		},
+		{
+			name: "dns upstreams with ports",
+			modify: func(c *Config) {
+				c.Enforcement.DNSUpstreamServers = []string{"9.9.9.9:5353", "[2620:fe::fe]:53"}
+			},
+			expectError: false,
+		},
+		{
+			name: "ipv6 dns block address",
+			modify: func(c *Config) {
+				c.Enforcement.DNSBlockIPv4 = "::1"
+			},
+			expectError: true,
+			errorText:   "enforcement.dns_block_ipv4 must be a valid IPv4 address",
+		},
+		{
+			name: "ipv4 dns block ipv6 address",
+			modify: func(c *Config) {
+				c.Enforcement.DNSBlockIPv6 = "127.0.0.1"
+			},
+			expectError: true,
+			errorText:   "enforcement.dns_block_ipv6 must be a valid IPv6 address",
+		},
+		{
+			name: "unknown dns block response",
+			modify: func(c *Config) {
+				c.Enforcement.DNSBlockResponse = "drop"
+			},
+			expectError: true,
+			errorText:   "enforcement.dns_block_response must be one of",
+		},
+		{
+			name: "invalid encrypted dns ip",
+			modify: func(c *Config) {
+				c.Enforcement.EncryptedDNSIPs = []string{"dns.example.com"}
+			},
+			expectError: true,
+			errorText:   "enforcement.encrypted_dns_ips must contain IP addresses",
+		},
+		{
+			name: "unknown notification sink",
+			modify: func(c *Config) {
+				c.Notifications.Sinks = []string{"desktop", "pager"}
+			},
+			expectError: true,
+			errorText:   "notifications.sinks contains unknown sink",
+		},
+		{
+			name: "webhook notification sink without url",
+			modify: func(c *Config) {
+				c.Notifications.Sinks = []string{"webhook"}
+			},
+			expectError: true,
+			errorText:   "notifications.webhook.url must be an http or https URL",
+		},
+		{
+			name: "fixed ecs mode without subnet",
+			modify: func(c *Config) {
+				c.Enforcement.DNSECSMode = "fixed"
+			},
+			expectError: true,
+			errorText:   "enforcement.dns_ecs_subnet must be a valid CIDR",
+		},
+		{
+			name: "missing dns listen interface",
+			modify: func(c *Config) {
+				c.Enforcement.DNSListenAddr = ":53"
+				c.Enforcement.DNSListenInterface = "no-such-iface0"
+			},
+			expectError: true,
+			errorText:   "enforcement.dns_listen_interface \"no-such-iface0\" does not exist",
+		},
+		{
+			name: "retention without audit limits",
+			modify: func(c *Config) {
+				c.Retention.AuditMaxAge = 0
+				c.Retention.AuditMaxEntries = 0
+			},
+			expectError: true,
+			errorText:   "retention.audit_max_age or audit_max_entries must be set",
+		},
+		{
+			name: "retention limits ignored when not seeding",
+			modify: func(c *Config) {
+				c.Retention.SeedDefaults = false
+				c.Retention.AuditMaxAge = 0
+				c.Retention.AuditMaxEntries = 0
+			},
+			expectError: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -290,6 +632,183 @@ func TestSaveToFile(t *testing.T) {
 	}
 }
 
+func TestUpdateFile(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	if err := Default().SaveToFile(configPath); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+	t.Setenv("PC_LOGGING_LEVEL", "DEBUG")
+
+	err := UpdateFile(configPath, func(c *Config) {
+		c.Security.AdminUsername = "parent"
+	})
+	if err != nil {
+		t.Fatalf("Failed to update config: %v", err)
+	}
+
+	loaded, err := LoadFromFile(configPath)
+	if err != nil {
+		t.Fatalf("Failed to load updated config: %v", err)
+	}
+	if loaded.Security.AdminUsername != "parent" {
+		t.Errorf("Expected admin username 'parent', got %q", loaded.Security.AdminUsername)
+	}
+
+	// Environment overrides are not written to the file
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("Failed to read config: %v", err)
+	}
+	if strings.Contains(string(data), "DEBUG") {
+		t.Error("Expected the environment override not to be persisted")
+	}
+
+	// An update that fails validation leaves the file untouched
+	err = UpdateFile(configPath, func(c *Config) { c.Web.Port = 0 })
+	if err == nil {
+		t.Fatal("Expected validation error")
+	}
+	if after, _ := os.ReadFile(configPath); string(after) != string(data) {
+		t.Error("Expected the file to be unchanged after a failed update")
+	}
+}
+
+func TestSaveAndLoadFormats(t *testing.T) {
+	config := Default()
+	config.Security.EnableAuth = false
+	config.Monitoring.Enabled = false
+	config.Service.PIDFile = "./format \"test\".pid"
+	config.Service.ShutdownTimeout = 45 * time.Second
+	config.Retention.AuditMaxEntries = 52428800
+
+	expected, err := encodeConfig(config, formatYAML)
+	if err != nil {
+		t.Fatalf("Failed to encode config: %v", err)
+	}
+
+	for _, name := range []string{"config.yaml", "config.yml", "config.json", "config.toml", "config"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			if err := config.SaveToFile(path); err != nil {
+				t.Fatalf("Failed to save config: %v", err)
+			}
+
+			loaded, err := LoadFromFile(path)
+			if err != nil {
+				t.Fatalf("Failed to load saved config: %v", err)
+			}
+
+			actual, err := encodeConfig(loaded, formatYAML)
+			if err != nil {
+				t.Fatalf("Failed to encode loaded config: %v", err)
+			}
+			if string(actual) != string(expected) {
+				t.Errorf("Config changed after %s round trip", name)
+			}
+		})
+	}
+}
+
+func TestLoadFromFileTOML(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+	content := `
+# Parental control settings
+[service]
+pid_file = "./toml.pid"
+shutdown_timeout = "1m"
+
+[logging]
+level = 'DEBUG'
+
+[web]
+enabled = false
+port = 9_090
+
+[security]
+enable_auth = false
+lockout_notify_email = ["a@example.com", "b@example.com"]
+smtp_host = "smtp.example.com"
+
+[monitoring]
+enabled = false
+`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write test config file: %v", err)
+	}
+
+	config, err := LoadFromFile(configPath)
+	if err != nil {
+		t.Fatalf("Failed to load TOML config: %v", err)
+	}
+
+	if config.Service.PIDFile != "./toml.pid" || config.Service.ShutdownTimeout != time.Minute {
+		t.Errorf("Unexpected service config: %+v", config.Service)
+	}
+	if config.Logging.Level != "DEBUG" || config.Web.Port != 9090 {
+		t.Errorf("Expected DEBUG logging on port 9090, got %s on %d", config.Logging.Level, config.Web.Port)
+	}
+	if len(config.Security.LockoutNotifyEmail) != 2 {
+		t.Errorf("Expected two lockout e-mail recipients, got %v", config.Security.LockoutNotifyEmail)
+	}
+}
+
+func TestLoadFromFileStrictFormats(t *testing.T) {
+	tests := []struct {
+		name      string
+		file      string
+		content   string
+		errorText string
+	}{
+		{
+			name:      "YAML in a JSON file",
+			file:      "config.json",
+			content:   "service:\n  pid_file: ./test.pid\n",
+			errorText: "invalid JSON",
+		},
+		{
+			name:      "unknown JSON key",
+			file:      "config.json",
+			content:   `{"service": {"pid_fle": "./test.pid"}}`,
+			errorText: "pid_fle",
+		},
+		{
+			name:      "trailing JSON data",
+			file:      "config.json",
+			content:   `{"service": {}} {}`,
+			errorText: "unexpected data",
+		},
+		{
+			name:      "unknown TOML key",
+			file:      "config.toml",
+			content:   "[web]\nprot = 8080\n",
+			errorText: "prot",
+		},
+		{
+			name:      "unquoted TOML string",
+			file:      "config.toml",
+			content:   "[logging]\nlevel = DEBUG\n",
+			errorText: "line 2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to write test config file: %v", err)
+			}
+
+			_, err := LoadFromFile(path)
+			if err == nil {
+				t.Fatal("Expected a parse error")
+			}
+			if !strings.Contains(err.Error(), tt.errorText) {
+				t.Errorf("Expected error containing %q, got: %v", tt.errorText, err)
+			}
+		})
+	}
+}
+
 func TestClone(t *testing.T) {
 	original := Default()
 	clone := original.Clone()
@@ -441,3 +960,51 @@ logging:
 		t.Errorf("Expected environment override 'DEBUG', got %s", config.Logging.Level)
 	}
 }
+
+func TestSummaryRedactsSecrets(t *testing.T) {
+	config := Default()
+	config.Security.AdminPassword = "hunter2"
+	config.Security.SessionSecret = "super-secret-value"
+
+	summary := config.Summary()
+	if summary.AdminPassword != redacted || summary.SessionSecret != redacted {
+		t.Errorf("Expected secrets to be redacted, got %q and %q", summary.AdminPassword, summary.SessionSecret)
+	}
+
+	encoded := fmt.Sprintf("%+v", summary)
+	if strings.Contains(encoded, "hunter2") || strings.Contains(encoded, "super-secret-value") {
+		t.Errorf("Summary leaked a secret: %s", encoded)
+	}
+
+	config.Security.SessionSecret = ""
+	if got := config.Summary().SessionSecret; got != "unset" {
+		t.Errorf("Expected unset session secret, got %q", got)
+	}
+}
+
+func TestInsecureWarnings(t *testing.T) {
+	config := Default()
+	config.Security.EnableAuth = true
+	config.Security.AdminPassword = "AdminPassword123!"
+	config.Enforcement.Enabled = true
+	config.Enforcement.EnableEmergencyMode = false
+	config.Web.Host = "localhost"
+
+	if warnings := config.InsecureWarnings(); len(warnings) != 0 {
+		t.Errorf("Expected no warnings for a secure config, got %v", warnings)
+	}
+
+	// Until the first admin exists, the setup endpoint is open
+	config.Security.AdminPassword = ""
+	if warnings := config.InsecureWarnings(); len(warnings) != 1 {
+		t.Errorf("Expected a setup warning, got %v", warnings)
+	}
+
+	config.Security.EnableAuth = false
+	config.Enforcement.Enabled = false
+	config.Enforcement.EnableEmergencyMode = true
+
+	if warnings := config.InsecureWarnings(); len(warnings) != 3 {
+		t.Errorf("Expected 3 warnings, got %d: %v", len(warnings), warnings)
+	}
+}
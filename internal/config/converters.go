@@ -16,6 +16,19 @@ func (cfg EnforcementConfig) ToEnforcementConfig() enforcement.EnforcementConfig
 		LogAllActivity:         cfg.LogAllActivity,
 		EnableEmergencyMode:    cfg.EnableEmergencyMode,
 		EmergencyWhitelist:     cfg.EmergencyWhitelist,
+		DNSListenAddr:          cfg.DNSListenAddr,
+		DNSListenInterface:     cfg.DNSListenInterface,
+		DNSBlockIPv4:           cfg.DNSBlockIPv4,
+		DNSBlockIPv6:           cfg.DNSBlockIPv6,
+		DNSBlockResponse:       cfg.DNSBlockResponse,
+		DNSUpstreamServers:     cfg.DNSUpstreamServers,
+		DNSCacheTTL:            cfg.DNSCacheTTL,
+		DNSECSMode:             cfg.DNSECSMode,
+		DNSECSSubnet:           cfg.DNSECSSubnet,
+		BlockEncryptedDNS:      cfg.BlockEncryptedDNS,
+		EncryptedDNSHosts:      cfg.EncryptedDNSHosts,
+		EncryptedDNSIPs:        cfg.EncryptedDNSIPs,
+		ContinueWithoutDNS:     cfg.DNSContinueOnBindFailure,
 	}
 }
 
@@ -33,5 +46,68 @@ func (cfg NotificationConfig) ToServiceNotificationConfig() service.Notification
 		EnableSystemAlerts:        cfg.EnableSystemAlerts,
 		ShowProcessDetails:        cfg.ShowProcessDetails,
 		NotificationTimeout:       cfg.NotificationTimeout,
+		CoalesceWindow:            cfg.CoalesceWindow,
+		Sinks:                     cfg.Sinks,
+		Webhook: service.WebhookSinkConfig{
+			URL:     cfg.Webhook.URL,
+			Headers: cfg.Webhook.Headers,
+		},
+		Ntfy: service.NtfySinkConfig{
+			URL:   cfg.Ntfy.URL,
+			Token: cfg.Ntfy.Token,
+		},
+		Email: service.EmailSinkConfig{
+			To:           cfg.Email.To,
+			From:         cfg.Email.From,
+			SMTPHost:     cfg.Email.SMTPHost,
+			SMTPPort:     cfg.Email.SMTPPort,
+			SMTPUsername: cfg.Email.SMTPUsername,
+			SMTPPassword: cfg.Email.SMTPPassword,
+		},
 	}
-}
\ No newline at end of file
+}
+
+// ToPolicySeedConfig converts config.RetentionConfig to service.PolicySeedConfig.
+// The seeded rotation policy targets the application's log file when logging
+// goes to a file.
+func (cfg RetentionConfig) ToPolicySeedConfig(logOutput string) service.PolicySeedConfig {
+	logFiles := []string{"logs/*.log"}
+	switch logOutput {
+	case "", "stdout", "stderr":
+	default:
+		logFiles = []string{logOutput}
+	}
+
+	return service.PolicySeedConfig{
+		Enabled:           cfg.SeedDefaults,
+		EnablePolicies:    cfg.EnableSeeded,
+		AuditMaxAge:       cfg.AuditMaxAge,
+		AuditMaxEntries:   cfg.AuditMaxEntries,
+		LogFiles:          logFiles,
+		LogMaxFileSize:    cfg.LogMaxFileSize,
+		LogRetainDuration: cfg.LogRetainDuration,
+	}
+}
+
+// ToBackupConfig converts config.BackupConfig to service.BackupConfig
+func (c *Config) ToBackupConfig() service.BackupConfig {
+	return service.BackupConfig{
+		Enabled:            c.Backup.Enabled,
+		Directory:          c.BackupDirectory(),
+		Keep:               c.Backup.Keep,
+		RetentionThreshold: c.Backup.RetentionThreshold,
+	}
+}
+
+// ToDatabaseMaintenanceConfig converts the database.maintenance_* settings to
+// service.DatabaseMaintenanceConfig
+func (c *Config) ToDatabaseMaintenanceConfig() service.DatabaseMaintenanceConfig {
+	return service.DatabaseMaintenanceConfig{
+		Enabled:              c.Database.MaintenanceEnabled,
+		CheckpointInterval:   c.Database.MaintenanceCheckpointInterval,
+		VacuumInterval:       c.Database.MaintenanceVacuumInterval,
+		VacuumMinFreePercent: c.Database.MaintenanceVacuumMinFreePercent,
+		WindowStart:          c.Database.MaintenanceWindowStart,
+		WindowEnd:            c.Database.MaintenanceWindowEnd,
+	}
+}
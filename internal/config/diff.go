@@ -0,0 +1,140 @@
+package config
+
+import (
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ChangeSource says where a changed configuration value came from
+type ChangeSource string
+
+const (
+	// SourceFile values were set in the configuration file
+	SourceFile ChangeSource = "file"
+	// SourceEnvironment values were set by a PC_ environment variable
+	SourceEnvironment ChangeSource = "environment"
+)
+
+// FieldChange is one setting that differs between two configurations
+type FieldChange struct {
+	// Path is the dotted YAML key, such as "security.session_timeout"
+	Path string `json:"path"`
+	// Old and New are the values as they would be written in YAML; lists
+	// are shown whole, such as "[1.1.1.1, 8.8.8.8]"
+	Old string `json:"old"`
+	New string `json:"new"`
+	// Source is the environment when the field's PC_ variable is set,
+	// otherwise the configuration file
+	Source ChangeSource `json:"source"`
+}
+
+// envVarNames lists the environment variables whose names do not follow the
+// PC_<SECTION>_<KEY> pattern
+var envVarNames = map[string]string{
+	"database.enablewal":                         "PC_DATABASE_ENABLE_WAL",
+	"database.maxidleconns":                      "PC_DATABASE_MAX_IDLE_CONNS",
+	"database.maxopenconns":                      "PC_DATABASE_MAX_OPEN_CONNS",
+	"database.readmaxopenconns":                  "PC_DATABASE_READ_MAX_OPEN_CONNS",
+	"database.readreplica":                       "PC_DATABASE_READ_REPLICA",
+	"database.startupretryinterval":              "PC_DATABASE_STARTUP_RETRY_INTERVAL",
+	"database.startupretrywindow":                "PC_DATABASE_STARTUP_RETRY_WINDOW",
+	"notifications.max_notifications_per_minute": "PC_NOTIFICATIONS_MAX_PER_MINUTE",
+	"notifications.notification_timeout":         "PC_NOTIFICATIONS_TIMEOUT",
+}
+
+// Diff returns every field whose value differs between base and loaded,
+// sorted by path. Diff(Default(), loaded) lists each non-default setting.
+// Secrets are compared and returned as-is; diff Redacted copies before
+// logging the result.
+func Diff(base, loaded *Config) []FieldChange {
+	oldFields := flattenConfig(base)
+	newFields := flattenConfig(loaded)
+
+	var changes []FieldChange
+	for path, value := range newFields {
+		if old, ok := oldFields[path]; !ok || old != value {
+			changes = append(changes, FieldChange{Path: path, Old: old, New: value, Source: changeSource(path)})
+		}
+	}
+	for path, old := range oldFields {
+		if _, ok := newFields[path]; !ok {
+			changes = append(changes, FieldChange{Path: path, Old: old, Source: changeSource(path)})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+// changeSource guesses where the value at path came from
+func changeSource(path string) ChangeSource {
+	name, ok := envVarNames[path]
+	if !ok {
+		name = "PC_" + strings.ToUpper(strings.ReplaceAll(path, ".", "_"))
+	}
+	if os.Getenv(name) != "" {
+		return SourceEnvironment
+	}
+	return SourceFile
+}
+
+// flattenConfig maps each leaf field's dotted path to its rendered value.
+// Lists are treated as a single value.
+func flattenConfig(config *Config) map[string]string {
+	fields := make(map[string]string)
+	if config == nil {
+		return fields
+	}
+
+	// Marshalling a Config cannot fail; every field is a plain value
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		return fields
+	}
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil || len(node.Content) == 0 {
+		return fields
+	}
+
+	flattenNode("", node.Content[0], fields)
+	return fields
+}
+
+// flattenNode adds the leaves under node to fields
+func flattenNode(prefix string, node *yaml.Node, fields map[string]string) {
+	if node.Kind != yaml.MappingNode {
+		fields[prefix] = renderNode(node)
+		return
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		path := node.Content[i].Value
+		if prefix != "" {
+			path = prefix + "." + path
+		}
+		flattenNode(path, node.Content[i+1], fields)
+	}
+}
+
+// renderNode formats a value on one line in YAML flow style
+func renderNode(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.SequenceNode:
+		items := make([]string, len(node.Content))
+		for i, item := range node.Content {
+			items[i] = renderNode(item)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case yaml.MappingNode:
+		pairs := make([]string, 0, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			pairs = append(pairs, node.Content[i].Value+": "+renderNode(node.Content[i+1]))
+		}
+		return "{" + strings.Join(pairs, ", ") + "}"
+	default:
+		return node.Value
+	}
+}
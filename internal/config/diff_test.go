@@ -0,0 +1,69 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	content := `
+security:
+  session_timeout: 2h
+enforcement:
+  dns_upstream_servers: ["9.9.9.9", "149.112.112.112"]
+  emergency_whitelist: ["school.example.com"]
+`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	t.Setenv("PC_LOGGING_LEVEL", "DEBUG")
+	t.Setenv("PC_DATABASE_MAX_OPEN_CONNS", "20")
+
+	loaded, err := LoadFromFile(configPath)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+
+	changes := make(map[string]FieldChange)
+	for _, change := range Diff(Default(), loaded) {
+		changes[change.Path] = change
+	}
+
+	expected := map[string]FieldChange{
+		"security.session_timeout":         {Old: "24h0m0s", New: "2h0m0s", Source: SourceFile},
+		"enforcement.dns_upstream_servers": {New: "[9.9.9.9, 149.112.112.112]", Source: SourceFile},
+		"enforcement.emergency_whitelist":  {New: "[school.example.com]", Source: SourceFile},
+		"logging.level":                    {New: "DEBUG", Source: SourceEnvironment},
+		"database.maxopenconns":            {New: "20", Source: SourceEnvironment},
+	}
+	if len(changes) != len(expected) {
+		t.Errorf("Expected %d changes, got %d: %+v", len(expected), len(changes), changes)
+	}
+	for path, want := range expected {
+		got, ok := changes[path]
+		if !ok {
+			t.Errorf("Expected a change for %s", path)
+			continue
+		}
+		if got.New != want.New || got.Source != want.Source || (want.Old != "" && got.Old != want.Old) {
+			t.Errorf("%s: expected %+v, got %+v", path, want, got)
+		}
+	}
+
+	if changes := Diff(loaded, loaded); len(changes) != 0 {
+		t.Errorf("Expected no changes between identical configs, got %+v", changes)
+	}
+}
+
+func TestDiffRedacted(t *testing.T) {
+	loaded := Default()
+	loaded.Security.SessionSecret = "super-secret-value"
+
+	for _, change := range Diff(Default(), loaded.Redacted()) {
+		if change.Path == "security.session_secret" && change.New != redacted {
+			t.Errorf("Expected redacted secret, got %q", change.New)
+		}
+	}
+}
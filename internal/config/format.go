@@ -0,0 +1,221 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// fileFormat is the encoding of a configuration file
+type fileFormat string
+
+const (
+	formatYAML fileFormat = "yaml"
+	formatJSON fileFormat = "json"
+	formatTOML fileFormat = "toml"
+)
+
+// formatFromPath picks the file format from the extension. Files without a
+// recognized extension are treated as YAML.
+func formatFromPath(path string) fileFormat {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return formatJSON
+	case ".toml":
+		return formatTOML
+	default:
+		return formatYAML
+	}
+}
+
+// decodeConfig parses data in the given format into config. YAML keeps its
+// lenient parsing; JSON and TOML are parsed strictly and reject unknown keys.
+// All formats share the YAML key names, so durations are written as strings
+// such as "30s" everywhere.
+func decodeConfig(data []byte, format fileFormat, config *Config) error {
+	switch format {
+	case formatJSON:
+		value, err := decodeStrictJSON(data)
+		if err != nil {
+			return err
+		}
+		return decodeGeneric(value, config)
+	case formatTOML:
+		value, err := decodeTOML(data)
+		if err != nil {
+			return err
+		}
+		return decodeGeneric(value, config)
+	default:
+		return yaml.Unmarshal(data, config)
+	}
+}
+
+// encodeConfig renders config in the given format
+func encodeConfig(config *Config, format fileFormat) ([]byte, error) {
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	if format == formatYAML {
+		return data, nil
+	}
+
+	// Re-encode from the YAML node tree so key names and duration strings
+	// match the YAML output
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+
+	if format == formatTOML {
+		return encodeTOML(&node)
+	}
+
+	var buf bytes.Buffer
+	if err := writeJSONNode(&buf, &node); err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, buf.Bytes(), "", "  "); err != nil {
+		return nil, err
+	}
+	out.WriteByte('\n')
+	return out.Bytes(), nil
+}
+
+// decodeStrictJSON parses a single JSON object, keeping integers exact
+func decodeStrictJSON(data []byte) (map[string]interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value map[string]interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, fmt.Errorf("invalid JSON: unexpected data after the top-level object")
+	}
+
+	converted, err := convertJSONNumbers(value)
+	if err != nil {
+		return nil, err
+	}
+	return converted.(map[string]interface{}), nil
+}
+
+// convertJSONNumbers replaces json.Number with int64 or float64
+func convertJSONNumbers(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i, nil
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return nil, fmt.Errorf("invalid JSON number %s", v)
+		}
+		return f, nil
+	case map[string]interface{}:
+		for key, item := range v {
+			converted, err := convertJSONNumbers(item)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = converted
+		}
+		return v, nil
+	case []interface{}:
+		for i, item := range v {
+			converted, err := convertJSONNumbers(item)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = converted
+		}
+		return v, nil
+	default:
+		return value, nil
+	}
+}
+
+// decodeGeneric decodes a generic document into config through YAML, so the
+// yaml struct tags and duration parsing apply; unknown keys are errors
+func decodeGeneric(value map[string]interface{}, config *Config) error {
+	data, err := yaml.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(config); err != nil && err != io.EOF {
+		return err
+	}
+	return nil
+}
+
+// writeJSONNode writes a YAML node tree as compact JSON
+func writeJSONNode(buf *bytes.Buffer, node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			buf.WriteString("null")
+			return nil
+		}
+		return writeJSONNode(buf, node.Content[0])
+	case yaml.MappingNode:
+		buf.WriteByte('{')
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			key, _ := json.Marshal(node.Content[i].Value)
+			buf.Write(key)
+			buf.WriteByte(':')
+			if err := writeJSONNode(buf, node.Content[i+1]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case yaml.SequenceNode:
+		buf.WriteByte('[')
+		for i, item := range node.Content {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeJSONNode(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case yaml.ScalarNode:
+		switch node.Tag {
+		case "!!null":
+			buf.WriteString("null")
+		case "!!bool", "!!int":
+			buf.WriteString(node.Value)
+		case "!!float":
+			var f float64
+			if err := node.Decode(&f); err != nil {
+				return err
+			}
+			encoded, err := json.Marshal(f)
+			if err != nil {
+				return fmt.Errorf("value %q cannot be written as JSON: %w", node.Value, err)
+			}
+			buf.Write(encoded)
+		default:
+			encoded, _ := json.Marshal(node.Value)
+			buf.Write(encoded)
+		}
+	default:
+		return fmt.Errorf("unsupported YAML node kind %d", node.Kind)
+	}
+	return nil
+}
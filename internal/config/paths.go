@@ -0,0 +1,76 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// ConfigFileEnv names the environment variable that points at the
+// configuration file when no path is given explicitly
+const ConfigFileEnv = "PC_CONFIG_FILE"
+
+// SearchPaths returns the locations checked, in order, when neither an
+// explicit path nor PC_CONFIG_FILE is set
+func SearchPaths() []string {
+	paths := []string{filepath.Join("config", "config.yaml")}
+	if dir, err := os.UserConfigDir(); err == nil {
+		paths = append(paths, filepath.Join(dir, "parental-control", "config.yaml"))
+	}
+	if runtime.GOOS != "windows" {
+		paths = append(paths, "/etc/parental-control/config.yaml")
+	}
+	return paths
+}
+
+// FindConfigFile returns the configuration file to load: path when set,
+// otherwise PC_CONFIG_FILE, otherwise the first existing entry of
+// SearchPaths. Explicit paths are returned even if they do not exist.
+func FindConfigFile(path string) (string, error) {
+	if path != "" {
+		return path, nil
+	}
+	if path = os.Getenv(ConfigFileEnv); path != "" {
+		return path, nil
+	}
+
+	searched := SearchPaths()
+	for _, candidate := range searched {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("configuration file not found in search path: %s", strings.Join(searched, ", "))
+}
+
+// Load finds the configuration file with FindConfigFile and loads it. It
+// returns the path of the file it loaded, or the path it tried on error.
+func Load(path string) (*Config, string, error) {
+	resolved, err := FindConfigFile(path)
+	if err != nil {
+		return Default(), "", err
+	}
+
+	config, err := LoadFromFile(resolved)
+	return config, resolved, err
+}
+
+// Resolve finds the configuration file like Load, parses it and applies
+// environment overrides, but does not validate the result. Use it to inspect
+// a configuration that Load would reject.
+func Resolve(path string) (*Config, string, error) {
+	resolved, err := FindConfigFile(path)
+	if err != nil {
+		return nil, "", err
+	}
+
+	data, err := os.ReadFile(resolved)
+	if err != nil {
+		return nil, resolved, fmt.Errorf("failed to read configuration file: %w", err)
+	}
+
+	config, err := decodeConfigData(resolved, data)
+	return config, resolved, err
+}
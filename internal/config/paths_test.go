@@ -0,0 +1,96 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFindConfigFile(t *testing.T) {
+	workDir := t.TempDir()
+	xdgDir := t.TempDir()
+	t.Chdir(workDir)
+	t.Setenv("XDG_CONFIG_HOME", xdgDir)
+	t.Setenv(ConfigFileEnv, "")
+
+	if _, err := os.Stat("/etc/parental-control/config.yaml"); err == nil {
+		t.Skip("system configuration file present")
+	}
+
+	// Nothing in the search path
+	if _, err := FindConfigFile(""); err == nil || !strings.Contains(err.Error(), "configuration file not found") {
+		t.Fatalf("Expected not found error, got %v", err)
+	}
+	config, path, err := Load("")
+	if err == nil || path != "" || config == nil {
+		t.Fatalf("Expected defaults and an error when nothing is found, got %q (%v)", path, err)
+	}
+
+	xdgConfig := filepath.Join(xdgDir, "parental-control", "config.yaml")
+	if err := Default().SaveToFile(xdgConfig); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+	if path, err := FindConfigFile(""); err != nil || path != xdgConfig {
+		t.Errorf("Expected %s, got %q (%v)", xdgConfig, path, err)
+	}
+
+	// The working directory takes precedence over the user config directory
+	localConfig := filepath.Join("config", "config.yaml")
+	updated := Default()
+	updated.Web.Port = 9999
+	if err := updated.SaveToFile(localConfig); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+	config, path, err = Load("")
+	if err != nil || path != localConfig {
+		t.Fatalf("Expected %s, got %q (%v)", localConfig, path, err)
+	}
+	if config.Web.Port != 9999 {
+		t.Errorf("Expected the local config to be loaded, got port %d", config.Web.Port)
+	}
+
+	// PC_CONFIG_FILE wins over the search path, and an explicit path over both
+	t.Setenv(ConfigFileEnv, xdgConfig)
+	if path, _ := FindConfigFile(""); path != xdgConfig {
+		t.Errorf("Expected %s from %s, got %q", xdgConfig, ConfigFileEnv, path)
+	}
+	if path, _ := FindConfigFile("explicit.yaml"); path != "explicit.yaml" {
+		t.Errorf("Expected the explicit path, got %q", path)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	invalid := Default()
+	invalid.Web.Port = 0
+	invalid.Service.PIDFile = ""
+	if err := invalid.SaveToFile(path); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+	t.Setenv("PC_WEB_HOST", "127.0.0.2")
+
+	// Load rejects the file with every problem listed
+	if _, _, err := Load(path); err == nil {
+		t.Fatal("Expected Load to reject the invalid configuration")
+	} else {
+		var validation *ValidationError
+		if !errors.As(err, &validation) || len(validation.Problems) != 2 {
+			t.Fatalf("Expected a ValidationError with 2 problems, got %v", err)
+		}
+	}
+
+	// Resolve returns it with environment overrides for inspection
+	config, resolved, err := Resolve(path)
+	if err != nil || resolved != path {
+		t.Fatalf("Expected %s to resolve, got %q (%v)", path, resolved, err)
+	}
+	if config.Web.Port != 0 || config.Web.Host != "127.0.0.2" {
+		t.Errorf("Expected the file and environment values, got %s:%d", config.Web.Host, config.Web.Port)
+	}
+
+	if _, _, err := Resolve(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
@@ -0,0 +1,170 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// redacted replaces secret values in the operating summary
+const redacted = "[redacted]"
+
+// OperatingSummary describes the effective operating mode of the service.
+// It never contains secret values; secrets are reported only as set or unset.
+type OperatingSummary struct {
+	AuthEnabled        bool   `json:"auth_enabled"`
+	AdminPassword      string `json:"admin_password"`
+	SessionSecret      string `json:"session_secret"`
+	SessionTimeout     string `json:"session_timeout"`
+	CookieSecure       string `json:"cookie_secure"`
+	EnforcementEnabled bool   `json:"enforcement_enabled"`
+	NetworkFiltering   bool   `json:"network_filtering"`
+	DNSMode            string `json:"dns_mode"`
+	EmergencyMode      bool   `json:"emergency_mode"`
+	WebEnabled         bool   `json:"web_enabled"`
+	WebAddress         string `json:"web_address"`
+	TLSMode            string `json:"tls_mode"`
+	MetricsAddress     string `json:"metrics_address"`
+	MetricsAuth        string `json:"metrics_auth"`
+	NotificationsOn    bool   `json:"notifications_enabled"`
+	LogLevel           string `json:"log_level"`
+	DatabasePath       string `json:"database_path"`
+}
+
+// Summary returns the effective operating mode with secrets redacted
+func (c *Config) Summary() OperatingSummary {
+	summary := OperatingSummary{
+		AuthEnabled:        c.Security.EnableAuth,
+		AdminPassword:      secretState(c.Security.AdminPassword),
+		SessionSecret:      secretState(c.Security.SessionSecret),
+		SessionTimeout:     c.Security.SessionTimeout.String(),
+		CookieSecure:       c.Security.CookieSecure,
+		EnforcementEnabled: c.Enforcement.Enabled,
+		NetworkFiltering:   c.Enforcement.Enabled && c.Enforcement.EnableNetworkFiltering,
+		EmergencyMode:      c.Enforcement.EnableEmergencyMode,
+		WebEnabled:         c.Web.Enabled,
+		WebAddress:         c.webAddress(),
+		NotificationsOn:    c.Notifications.Enabled,
+		MetricsAuth:        c.Monitoring.MetricsAuth,
+		LogLevel:           c.Logging.Level,
+		DatabasePath:       c.Database.Path,
+	}
+
+	switch {
+	case !summary.NetworkFiltering:
+		summary.DNSMode = "disabled"
+	case c.Enforcement.DNSListenInterface != "":
+		summary.DNSMode = fmt.Sprintf("sinkhole on interface %s via %s",
+			c.Enforcement.DNSListenInterface, strings.Join(c.Enforcement.DNSUpstreamServers, ","))
+	default:
+		summary.DNSMode = fmt.Sprintf("sinkhole on %s via %s",
+			c.Enforcement.DNSListenAddr, strings.Join(c.Enforcement.DNSUpstreamServers, ","))
+	}
+
+	summary.MetricsAddress = "disabled"
+	if c.Monitoring.Enabled {
+		summary.MetricsAddress = fmt.Sprintf("%s:%d", c.Monitoring.MetricsHost, c.Monitoring.MetricsPort)
+	}
+
+	switch {
+	case !c.Web.TLSEnabled:
+		summary.TLSMode = "disabled"
+	case c.Web.TLSAutoGenerate:
+		summary.TLSMode = "self-signed"
+	default:
+		summary.TLSMode = "certificate"
+	}
+
+	return summary
+}
+
+// Redacted returns a copy of the configuration with every secret replaced,
+// suitable for sharing in bug reports
+func (c *Config) Redacted() *Config {
+	clone := c.Clone()
+	clone.Security.TrustedProxies = append([]string(nil), c.Security.TrustedProxies...)
+	clone.Security.LockoutNotifyEmail = append([]string(nil), c.Security.LockoutNotifyEmail...)
+	clone.Enforcement.EmergencyWhitelist = append([]string(nil), c.Enforcement.EmergencyWhitelist...)
+	clone.Enforcement.DNSUpstreamServers = append([]string(nil), c.Enforcement.DNSUpstreamServers...)
+	clone.Notifications.Sinks = append([]string(nil), c.Notifications.Sinks...)
+	clone.Notifications.Email.To = append([]string(nil), c.Notifications.Email.To...)
+	if c.Notifications.Webhook.Headers != nil {
+		clone.Notifications.Webhook.Headers = make(map[string]string, len(c.Notifications.Webhook.Headers))
+		for name := range c.Notifications.Webhook.Headers {
+			clone.Notifications.Webhook.Headers[name] = redacted
+		}
+	}
+
+	for _, secret := range []*string{
+		&clone.Security.AdminPassword,
+		&clone.Security.SessionSecret,
+		&clone.Security.SMTPPassword,
+		&clone.Security.LockoutNotifyWebhookURL,
+		&clone.Monitoring.MetricsToken,
+		&clone.Monitoring.MetricsPassword,
+		&clone.Notifications.Webhook.URL,
+		&clone.Notifications.Ntfy.URL,
+		&clone.Notifications.Ntfy.Token,
+		&clone.Notifications.Email.SMTPPassword,
+	} {
+		if *secret != "" {
+			*secret = redacted
+		}
+	}
+	return clone
+}
+
+// InsecureWarnings lists configuration choices that weaken the security
+// posture. An empty result means no insecure settings were detected.
+func (c *Config) InsecureWarnings() []string {
+	var warnings []string
+
+	if c.Web.Enabled && !c.Security.EnableAuth {
+		warnings = append(warnings, "authentication is DISABLED; anyone who can reach the web interface has full control")
+	}
+	if c.Web.Enabled && c.Security.EnableAuth && c.Security.AdminPassword == "" {
+		warnings = append(warnings, "no admin password is configured; anyone who reaches /api/v1/auth/setup first becomes admin")
+	}
+	if !c.Enforcement.Enabled {
+		warnings = append(warnings, "enforcement is DISABLED; no applications or sites will be blocked")
+	}
+	if c.Enforcement.EnableEmergencyMode {
+		warnings = append(warnings, "emergency mode is ON; blocking is bypassed for the emergency whitelist")
+	}
+	if c.Web.Enabled && !c.Web.TLSEnabled && c.Web.UnixSocket() == "" && !isLoopbackHost(c.Web.Host) {
+		warnings = append(warnings, fmt.Sprintf("web interface is served over plain HTTP on non-loopback host %q", c.Web.Host))
+	}
+	if c.Monitoring.Enabled && !isLoopbackHost(c.Monitoring.MetricsHost) &&
+		(c.Monitoring.MetricsAuth == "" || c.Monitoring.MetricsAuth == "none") {
+		warnings = append(warnings, fmt.Sprintf("metrics endpoint is exposed on %q without authentication", c.Monitoring.MetricsHost))
+	}
+	if c.Security.CookieSecure == "never" {
+		warnings = append(warnings, "session cookies are never marked Secure")
+	}
+
+	return warnings
+}
+
+// secretState reports whether a secret is configured without revealing it
+func secretState(value string) string {
+	if value == "" {
+		return "unset"
+	}
+	return redacted
+}
+
+// webAddress describes where the web interface listens
+func (c *Config) webAddress() string {
+	if socket := c.Web.UnixSocket(); socket != "" {
+		return unixHostPrefix + socket
+	}
+	return fmt.Sprintf("%s:%d", c.Web.Host, c.Web.Port)
+}
+
+func isLoopbackHost(host string) bool {
+	switch strings.ToLower(host) {
+	case "localhost", "127.0.0.1", "::1":
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,34 @@
+package config
+
+import (
+	"bytes"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// decodeTOML parses a TOML document into generic maps, slices and scalars
+func decodeTOML(data []byte) (map[string]interface{}, error) {
+	var value map[string]interface{}
+	if _, err := toml.Decode(string(data), &value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// encodeTOML writes a YAML mapping node as TOML. The encoder sorts keys
+// within each table and omits null values, since TOML has no null.
+func encodeTOML(node *yaml.Node) ([]byte, error) {
+	var value map[string]interface{}
+	if err := node.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	encoder := toml.NewEncoder(&buf)
+	encoder.Indent = ""
+	if err := encoder.Encode(value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,277 @@
+package config
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"parental-control/internal/logging"
+)
+
+// WatcherConfig controls how the configuration file is watched
+type WatcherConfig struct {
+	// PollInterval is how often the file is checked for changes
+	PollInterval time.Duration
+
+	// Debounce is how long the file must stay unchanged before it is
+	// reloaded, so a burst of editor saves triggers a single reload
+	Debounce time.Duration
+}
+
+// DefaultWatcherConfig returns watcher settings suitable for hand-edited files
+func DefaultWatcherConfig() WatcherConfig {
+	return WatcherConfig{
+		PollInterval: 500 * time.Millisecond,
+		Debounce:     time.Second,
+	}
+}
+
+// ChangeCallback receives the previous and newly applied configuration
+type ChangeCallback func(oldConfig, newConfig *Config)
+
+// RestartRequiredError lists changed fields that were not applied because
+// they only take effect after a restart
+type RestartRequiredError struct {
+	Fields []string
+}
+
+// Error implements the error interface
+func (e *RestartRequiredError) Error() string {
+	return fmt.Sprintf("configuration changes require a restart: %s", strings.Join(e.Fields, ", "))
+}
+
+// liveSection is a part of the configuration that can change at runtime
+type liveSection struct {
+	path  string
+	apply func(dst, src *Config)
+}
+
+// liveSections lists what a reload may apply; every other change is reported
+// as requiring a restart
+var liveSections = []liveSection{
+	{path: "notifications", apply: func(dst, src *Config) { dst.Notifications = src.Notifications }},
+}
+
+// fileState identifies a version of the watched file on disk
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// Watcher reloads the configuration file when it changes and passes the
+// runtime-safe changes to registered callbacks. The file is polled rather
+// than watched with inotify so it keeps working across editors that replace
+// the file on save and on platforms without file notifications.
+type Watcher struct {
+	path   string
+	config WatcherConfig
+
+	mu        sync.Mutex
+	current   *Config
+	callbacks []ChangeCallback
+	lastHash  [sha256.Size]byte
+	hashed    bool
+
+	stopCh chan struct{}
+	doneCh chan struct{}
+}
+
+// NewWatcher creates a watcher for the file at path, starting from the
+// configuration currently in use
+func NewWatcher(path string, current *Config, config WatcherConfig) *Watcher {
+	defaults := DefaultWatcherConfig()
+	if config.PollInterval <= 0 {
+		config.PollInterval = defaults.PollInterval
+	}
+	if config.Debounce < 0 {
+		config.Debounce = 0
+	}
+
+	return &Watcher{
+		path:    path,
+		config:  config,
+		current: current,
+	}
+}
+
+// OnChange registers a callback run after each reload that applied changes
+func (w *Watcher) OnChange(callback ChangeCallback) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.callbacks = append(w.callbacks, callback)
+}
+
+// Current returns the configuration with all applied reloads
+func (w *Watcher) Current() *Config {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.current
+}
+
+// Start begins watching the file in the background
+func (w *Watcher) Start() error {
+	state, err := statFile(w.path)
+	if err != nil {
+		return fmt.Errorf("failed to watch configuration file: %w", err)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.stopCh != nil {
+		return fmt.Errorf("configuration watcher already started")
+	}
+	if data, err := os.ReadFile(w.path); err == nil {
+		w.lastHash = sha256.Sum256(data)
+		w.hashed = true
+	}
+
+	w.stopCh = make(chan struct{})
+	w.doneCh = make(chan struct{})
+	go w.run(state, w.stopCh, w.doneCh)
+	return nil
+}
+
+// Stop stops watching and waits for any reload in progress to finish
+func (w *Watcher) Stop() {
+	w.mu.Lock()
+	stopCh, doneCh := w.stopCh, w.doneCh
+	w.stopCh, w.doneCh = nil, nil
+	w.mu.Unlock()
+
+	if stopCh == nil {
+		return
+	}
+	close(stopCh)
+	<-doneCh
+}
+
+// run polls the file and reloads once it has been stable for the debounce
+// period after a change
+func (w *Watcher) run(last fileState, stopCh <-chan struct{}, doneCh chan<- struct{}) {
+	defer close(doneCh)
+
+	ticker := time.NewTicker(w.config.PollInterval)
+	defer ticker.Stop()
+
+	var changedAt time.Time
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+		}
+
+		// Editors may briefly remove the file while replacing it
+		state, err := statFile(w.path)
+		if err != nil {
+			continue
+		}
+		if state != last {
+			last = state
+			changedAt = time.Now()
+		}
+		if changedAt.IsZero() || time.Since(changedAt) < w.config.Debounce {
+			continue
+		}
+		changedAt = time.Time{}
+
+		var restart *RestartRequiredError
+		if _, err := w.Reload(); errors.As(err, &restart) {
+			logging.Warn("Configuration changes not applied until restart",
+				logging.String("path", w.path),
+				logging.String("fields", strings.Join(restart.Fields, ", ")))
+		} else if err != nil {
+			logging.Error("Failed to reload configuration, keeping current settings",
+				logging.String("path", w.path),
+				logging.Err(err))
+		}
+	}
+}
+
+// Reload re-reads and validates the file, applies the runtime-safe changes
+// and runs the callbacks. It returns the configuration now in use. Changes to
+// other fields are left unapplied and reported in a *RestartRequiredError;
+// an invalid file leaves the current configuration untouched.
+func (w *Watcher) Reload() (*Config, error) {
+	data, err := os.ReadFile(w.path)
+	if err != nil {
+		return w.Current(), fmt.Errorf("failed to read configuration file: %w", err)
+	}
+
+	w.mu.Lock()
+	hash := sha256.Sum256(data)
+	if w.hashed && hash == w.lastHash {
+		current := w.current
+		w.mu.Unlock()
+		return current, nil
+	}
+	w.mu.Unlock()
+
+	loaded, err := parseConfigData(w.path, data)
+	if err != nil {
+		return w.Current(), err
+	}
+
+	w.mu.Lock()
+	w.lastHash, w.hashed = hash, true
+	old := w.current
+
+	applied := *old
+	var live, restart []string
+	for _, change := range Diff(old, loaded) {
+		field := change.Path
+		section := liveSectionFor(field)
+		if section == nil {
+			restart = append(restart, field)
+			continue
+		}
+		section.apply(&applied, loaded)
+		live = append(live, field)
+	}
+
+	next := old
+	callbacks := append([]ChangeCallback(nil), w.callbacks...)
+	if len(live) > 0 {
+		next = &applied
+		w.current = next
+	}
+	w.mu.Unlock()
+
+	if len(live) > 0 {
+		logging.Info("Configuration reloaded",
+			logging.String("path", w.path),
+			logging.String("fields", strings.Join(live, ", ")))
+		for _, callback := range callbacks {
+			callback(old, next)
+		}
+	}
+
+	if len(restart) > 0 {
+		return next, &RestartRequiredError{Fields: restart}
+	}
+	return next, nil
+}
+
+// liveSectionFor returns the live section containing the dotted field path
+func liveSectionFor(field string) *liveSection {
+	for i := range liveSections {
+		section := &liveSections[i]
+		if field == section.path || strings.HasPrefix(field, section.path+".") {
+			return section
+		}
+	}
+	return nil
+}
+
+// statFile returns the current on-disk state of path
+func statFile(path string) (fileState, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileState{}, err
+	}
+	return fileState{modTime: info.ModTime(), size: info.Size()}, nil
+}
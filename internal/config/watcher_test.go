@@ -0,0 +1,140 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// writeWatchedConfig saves config to path and returns it reloaded from disk
+func writeWatchedConfig(t *testing.T, path string, config *Config) *Config {
+	t.Helper()
+	if err := config.SaveToFile(path); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+	loaded, err := LoadFromFile(path)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	return loaded
+}
+
+func TestWatcherReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	current := writeWatchedConfig(t, path, Default())
+
+	watcher := NewWatcher(path, current, DefaultWatcherConfig())
+	var calls int
+	var oldSeen, newSeen *Config
+	watcher.OnChange(func(oldConfig, newConfig *Config) {
+		calls++
+		oldSeen, newSeen = oldConfig, newConfig
+	})
+
+	// Reloading an unchanged file is a no-op
+	if _, err := watcher.Reload(); err != nil || calls != 0 {
+		t.Fatalf("Expected no-op reload, got %d calls (%v)", calls, err)
+	}
+
+	updated := Default()
+	updated.Notifications.MaxNotificationsPerMinute = 3
+	updated.Web.Port = current.Web.Port + 1
+	if err := updated.SaveToFile(path); err != nil {
+		t.Fatalf("Failed to save config: %v", err)
+	}
+
+	applied, err := watcher.Reload()
+	var restart *RestartRequiredError
+	if !errors.As(err, &restart) || len(restart.Fields) != 1 || restart.Fields[0] != "web.port" {
+		t.Fatalf("Expected web.port to require a restart, got %v", err)
+	}
+	if calls != 1 || oldSeen != current || newSeen != applied {
+		t.Fatalf("Expected one callback with old and new config, got %d", calls)
+	}
+	if applied.Notifications.MaxNotificationsPerMinute != 3 {
+		t.Errorf("Expected notification change applied, got %d", applied.Notifications.MaxNotificationsPerMinute)
+	}
+	if applied.Web.Port != current.Web.Port {
+		t.Errorf("Expected web.port to stay %d, got %d", current.Web.Port, applied.Web.Port)
+	}
+	if watcher.Current() != applied {
+		t.Error("Expected Current to return the applied config")
+	}
+}
+
+func TestWatcherReloadInvalidKeepsCurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	current := writeWatchedConfig(t, path, Default())
+
+	watcher := NewWatcher(path, current, DefaultWatcherConfig())
+	watcher.OnChange(func(oldConfig, newConfig *Config) {
+		t.Error("Callback should not run for an invalid file")
+	})
+
+	if err := os.WriteFile(path, []byte("web:\n  port: 0\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	applied, err := watcher.Reload()
+	if err == nil {
+		t.Fatal("Expected validation error")
+	}
+	if applied != current || watcher.Current() != current {
+		t.Error("Expected the current config to be kept")
+	}
+}
+
+func TestWatcherDebouncesRapidSaves(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	current := writeWatchedConfig(t, path, Default())
+
+	watcher := NewWatcher(path, current, WatcherConfig{
+		PollInterval: 10 * time.Millisecond,
+		Debounce:     150 * time.Millisecond,
+	})
+
+	var mu sync.Mutex
+	var reloads []int
+	watcher.OnChange(func(oldConfig, newConfig *Config) {
+		mu.Lock()
+		defer mu.Unlock()
+		reloads = append(reloads, newConfig.Notifications.MaxNotificationsPerMinute)
+	})
+
+	if err := watcher.Start(); err != nil {
+		t.Fatalf("Failed to start watcher: %v", err)
+	}
+	defer watcher.Stop()
+
+	// A burst of saves, as an editor writing several times in a row
+	for i := 1; i <= 5; i++ {
+		updated := Default()
+		updated.Notifications.MaxNotificationsPerMinute = i * 10
+		if err := updated.SaveToFile(path); err != nil {
+			t.Fatalf("Failed to save config: %v", err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		mu.Lock()
+		done := len(reloads) > 0
+		mu.Unlock()
+		if done {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	// Leave time for any extra reloads to show up
+	time.Sleep(300 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(reloads) != 1 || reloads[0] != 50 {
+		t.Errorf("Expected a single reload with the last save, got %v", reloads)
+	}
+}
@@ -0,0 +1,233 @@
+// Package cron parses cron schedule expressions and computes their next
+// activation time.
+//
+// Expressions use the standard five fields, minute hour day-of-month month
+// day-of-week, each of which may be *, a value, a range (1-5), a list
+// (1,15) or a step (*/15, 0-30/10). Months and weekdays also accept
+// three-letter names (jan, mon); Sunday is 0 or 7. As in Vixie cron, when
+// both day-of-month and day-of-week are restricted, a day matching either
+// one matches. The macros @yearly (@annually), @monthly, @weekly, @daily
+// (@midnight) and @hourly are supported.
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// searchYears bounds the search for the next activation, so a schedule
+// such as February 30th ends instead of looping forever
+const searchYears = 5
+
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var (
+	monthNames = map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}
+	weekdayNames = map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}
+)
+
+// field describes the values allowed in one schedule field
+type field struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var fields = [5]field{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: monthNames},
+	{name: "day of week", min: 0, max: 7, names: weekdayNames},
+}
+
+// Schedule is a parsed cron expression. Each field is a bit set of the
+// values it matches.
+type Schedule struct {
+	spec   string
+	minute uint64
+	hour   uint64
+	dom    uint64
+	month  uint64
+	dow    uint64
+
+	// domAny and dowAny record an unrestricted (*) day field, which decides
+	// how the two day fields combine
+	domAny bool
+	dowAny bool
+}
+
+// Parse parses a five-field cron expression or macro
+func Parse(spec string) (*Schedule, error) {
+	expr := strings.TrimSpace(spec)
+	if expr == "" {
+		return nil, fmt.Errorf("empty cron expression")
+	}
+	if strings.HasPrefix(expr, "@") {
+		expanded, ok := macros[strings.ToLower(expr)]
+		if !ok {
+			return nil, fmt.Errorf("unknown cron macro %q", expr)
+		}
+		expr = expanded
+	}
+
+	parts := strings.Fields(expr)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields, got %d", spec, len(parts))
+	}
+
+	var sets [5]uint64
+	for i, part := range parts {
+		set, err := parseField(part, fields[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %w", spec, err)
+		}
+		sets[i] = set
+	}
+
+	// Sunday may be written as 7
+	if sets[4]&(1<<7) != 0 {
+		sets[4] = sets[4]&^(1<<7) | 1
+	}
+
+	schedule := &Schedule{
+		spec:   spec,
+		minute: sets[0],
+		hour:   sets[1],
+		dom:    sets[2],
+		month:  sets[3],
+		dow:    sets[4],
+		domAny: strings.HasPrefix(parts[2], "*"),
+		dowAny: strings.HasPrefix(parts[4], "*"),
+	}
+
+	reference := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+	if schedule.Next(reference).IsZero() {
+		return nil, fmt.Errorf("cron expression %q never matches", spec)
+	}
+	return schedule, nil
+}
+
+// String returns the expression the schedule was parsed from
+func (s *Schedule) String() string {
+	return s.spec
+}
+
+// Next returns the first activation strictly after t, in t's location. It
+// returns the zero time if there is none within the next few years.
+func (s *Schedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, loc).Add(time.Minute)
+	limit := t.Year() + searchYears
+
+	for t.Year() <= limit {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// dayMatches applies the day-of-month and day-of-week fields
+func (s *Schedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domAny || s.dowAny {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// parseField parses a comma separated list of values, ranges and steps
+func parseField(expr string, f field) (uint64, error) {
+	var set uint64
+	for _, item := range strings.Split(expr, ",") {
+		rangeExpr, stepExpr, hasStep := strings.Cut(item, "/")
+
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepExpr)
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("%s: invalid step %q", f.name, stepExpr)
+			}
+		}
+
+		var low, high int
+		switch {
+		case rangeExpr == "*":
+			low, high = f.min, f.max
+		case strings.Contains(rangeExpr, "-"):
+			lowExpr, highExpr, _ := strings.Cut(rangeExpr, "-")
+			var err error
+			if low, err = parseValue(lowExpr, f); err != nil {
+				return 0, err
+			}
+			if high, err = parseValue(highExpr, f); err != nil {
+				return 0, err
+			}
+			if low > high {
+				return 0, fmt.Errorf("%s: range %q is backwards", f.name, rangeExpr)
+			}
+		default:
+			value, err := parseValue(rangeExpr, f)
+			if err != nil {
+				return 0, err
+			}
+			// A step after a single value, e.g. 5/15, runs to the maximum
+			low, high = value, value
+			if hasStep {
+				high = f.max
+			}
+		}
+
+		for v := low; v <= high; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+	return set, nil
+}
+
+// parseValue parses a number or name within the field's bounds
+func parseValue(expr string, f field) (int, error) {
+	if value, ok := f.names[strings.ToLower(expr)]; ok {
+		return value, nil
+	}
+	value, err := strconv.Atoi(expr)
+	if err != nil {
+		return 0, fmt.Errorf("%s: invalid value %q", f.name, expr)
+	}
+	if value < f.min || value > f.max {
+		return 0, fmt.Errorf("%s: %d is out of range %d-%d", f.name, value, f.min, f.max)
+	}
+	return value, nil
+}
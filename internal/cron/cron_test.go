@@ -0,0 +1,78 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	// Wednesday 15 May 2024, 10:30
+	from := time.Date(2024, time.May, 15, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"0 2 * * *", time.Date(2024, time.May, 16, 2, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2024, time.May, 16, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2024, time.May, 15, 11, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, time.May, 15, 10, 45, 0, 0, time.UTC)},
+		{"30 10 * * *", time.Date(2024, time.May, 16, 10, 30, 0, 0, time.UTC)},
+		{"0 9-17/4 * * mon-fri", time.Date(2024, time.May, 15, 13, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, time.May, 19, 0, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2024, time.May, 19, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 jan *", time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		// Day of month or day of week when both are restricted
+		{"0 0 20 * mon", time.Date(2024, time.May, 20, 0, 0, 0, 0, time.UTC)},
+		{"0 0 17 * mon", time.Date(2024, time.May, 17, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		schedule, err := Parse(tt.spec)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", tt.spec, err)
+			continue
+		}
+		if got := schedule.Next(from); !got.Equal(tt.want) {
+			t.Errorf("Next(%q) = %v, want %v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestNextIsStrictlyAfter(t *testing.T) {
+	schedule, err := Parse("0 2 * * *")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	at := time.Date(2024, time.May, 15, 2, 0, 0, 0, time.UTC)
+	if got := schedule.Next(at); !got.Equal(at.Add(24 * time.Hour)) {
+		t.Errorf("Expected the following day, got %v", got)
+	}
+	if got := schedule.Next(at.Add(-time.Second)); !got.Equal(at) {
+		t.Errorf("Expected %v, got %v", at, got)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"@sometimes",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"0 0 30 feb *",
+	} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("Expected Parse(%q) to fail", spec)
+		}
+	}
+}
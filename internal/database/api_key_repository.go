@@ -0,0 +1,185 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"parental-control/internal/models"
+)
+
+// APIKeyRepository implements the models.APIKeyRepository interface
+type APIKeyRepository struct {
+	db *sql.DB
+}
+
+// NewAPIKeyRepository creates a new API key repository
+func NewAPIKeyRepository(db *sql.DB) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+// apiKeyColumns are the columns scanned by scanAPIKey, in order
+const apiKeyColumns = `id, user_id, name, prefix, key_hash, scope, expires_at, last_used_at, revoked_at, created_at`
+
+// GetAll retrieves every API key, revoked ones included, ordered by ID
+func (r *APIKeyRepository) GetAll(ctx context.Context) ([]models.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys ORDER BY id ASC`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query API keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []models.APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan API key: %w", err)
+		}
+		keys = append(keys, *key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over API keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// GetByID retrieves an API key by ID
+func (r *APIKeyRepository) GetByID(ctx context.Context, id int) (*models.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE id = ?`
+
+	key, err := scanAPIKey(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("API key with ID %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+
+	return key, nil
+}
+
+// GetByHash retrieves an API key by the hash of the key
+func (r *APIKeyRepository) GetByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE key_hash = ?`
+
+	key, err := scanAPIKey(r.db.QueryRowContext(ctx, query, keyHash))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("API key not found")
+		}
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+
+	return key, nil
+}
+
+// Create creates a new API key and sets its ID
+func (r *APIKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	query := `
+		INSERT INTO api_keys (user_id, name, prefix, key_hash, scope, expires_at, last_used_at, revoked_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	if key.CreatedAt.IsZero() {
+		key.CreatedAt = time.Now()
+	}
+
+	result, err := r.db.ExecContext(ctx, query,
+		key.UserID,
+		key.Name,
+		key.Prefix,
+		key.KeyHash,
+		key.Scope,
+		key.ExpiresAt,
+		key.LastUsedAt,
+		key.RevokedAt,
+		key.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create API key: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get API key ID: %w", err)
+	}
+
+	key.ID = int(id)
+	return nil
+}
+
+// Update updates the usage and revocation of an existing API key. The key
+// itself, its owner and its scope never change.
+func (r *APIKeyRepository) Update(ctx context.Context, key *models.APIKey) error {
+	query := `UPDATE api_keys SET name = ?, expires_at = ?, last_used_at = ?, revoked_at = ? WHERE id = ?`
+
+	result, err := r.db.ExecContext(ctx, query,
+		key.Name,
+		key.ExpiresAt,
+		key.LastUsedAt,
+		key.RevokedAt,
+		key.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update API key: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get update result: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("API key with ID %d not found", key.ID)
+	}
+
+	return nil
+}
+
+// UpdateLastUsed records when an API key was last used without touching the
+// rest of the key, so it cannot undo a revocation made in the meantime
+func (r *APIKeyRepository) UpdateLastUsed(ctx context.Context, id int, usedAt time.Time) error {
+	query := `UPDATE api_keys SET last_used_at = ? WHERE id = ?`
+
+	if _, err := r.db.ExecContext(ctx, query, usedAt, id); err != nil {
+		return fmt.Errorf("failed to update API key last use: %w", err)
+	}
+	return nil
+}
+
+// scanAPIKey reads the apiKeyColumns of a row into an API key
+func scanAPIKey(row scanner) (*models.APIKey, error) {
+	key := &models.APIKey{}
+	var expiresAt, lastUsedAt, revokedAt sql.NullTime
+	err := row.Scan(
+		&key.ID,
+		&key.UserID,
+		&key.Name,
+		&key.Prefix,
+		&key.KeyHash,
+		&key.Scope,
+		&expiresAt,
+		&lastUsedAt,
+		&revokedAt,
+		&key.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if expiresAt.Valid {
+		key.ExpiresAt = &expiresAt.Time
+	}
+	if lastUsedAt.Valid {
+		key.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+	return key, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"math"
 	"strings"
 	"time"
 
@@ -13,11 +14,22 @@ import (
 // AuditLogRepository implements the models.AuditLogRepository interface
 type AuditLogRepository struct {
 	db *sql.DB
+	// readDB serves reporting queries (listings, counts, search)
+	readDB *sql.DB
 }
 
 // NewAuditLogRepository creates a new audit log repository
 func NewAuditLogRepository(db *sql.DB) *AuditLogRepository {
-	return &AuditLogRepository{db: db}
+	return &AuditLogRepository{db: db, readDB: db}
+}
+
+// NewAuditLogRepositoryWithReader creates an audit log repository that sends
+// reporting queries to a separate read-only connection
+func NewAuditLogRepositoryWithReader(db, readDB *sql.DB) *AuditLogRepository {
+	if readDB == nil {
+		readDB = db
+	}
+	return &AuditLogRepository{db: db, readDB: readDB}
 }
 
 // Create creates a new audit log entry
@@ -91,7 +103,7 @@ func (r *AuditLogRepository) GetAll(ctx context.Context, limit, offset int) ([]m
 		LIMIT ? OFFSET ?
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, limit, offset)
+	rows, err := r.readDB.QueryContext(ctx, query, limit, offset)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query audit logs: %w", err)
 	}
@@ -135,7 +147,7 @@ func (r *AuditLogRepository) GetByTimeRange(ctx context.Context, start, end time
 		LIMIT ? OFFSET ?
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, start, end, limit, offset)
+	rows, err := r.readDB.QueryContext(ctx, query, start, end, limit, offset)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query audit logs by time range: %w", err)
 	}
@@ -179,7 +191,7 @@ func (r *AuditLogRepository) GetByAction(ctx context.Context, action models.Acti
 		LIMIT ? OFFSET ?
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, action, limit, offset)
+	rows, err := r.readDB.QueryContext(ctx, query, action, limit, offset)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query audit logs by action: %w", err)
 	}
@@ -223,7 +235,7 @@ func (r *AuditLogRepository) GetByTargetType(ctx context.Context, targetType mod
 		LIMIT ? OFFSET ?
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, targetType, limit, offset)
+	rows, err := r.readDB.QueryContext(ctx, query, targetType, limit, offset)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query audit logs by target type: %w", err)
 	}
@@ -264,13 +276,13 @@ func (r *AuditLogRepository) GetTodayStats(ctx context.Context) (allows int, blo
 
 	query := `
 		SELECT 
-			SUM(CASE WHEN action = 'allow' THEN 1 ELSE 0 END) as allows,
-			SUM(CASE WHEN action = 'block' THEN 1 ELSE 0 END) as blocks
+			COALESCE(SUM(CASE WHEN action = 'allow' THEN 1 ELSE 0 END), 0) as allows,
+			COALESCE(SUM(CASE WHEN action = 'block' THEN 1 ELSE 0 END), 0) as blocks
 		FROM audit_log
 		WHERE timestamp >= ? AND timestamp < ?
 	`
 
-	err = r.db.QueryRowContext(ctx, query, today, tomorrow).Scan(&allows, &blocks)
+	err = r.readDB.QueryRowContext(ctx, query, today, tomorrow).Scan(&allows, &blocks)
 	if err != nil {
 		return 0, 0, fmt.Errorf("failed to get today's stats: %w", err)
 	}
@@ -301,12 +313,113 @@ func (r *AuditLogRepository) CleanupOldLogs(ctx context.Context, before time.Tim
 	return nil
 }
 
+// auditRowSize estimates the bytes an audit log row takes up: its text
+// columns plus a fixed allowance for the integer and timestamp columns
+const auditRowSize = `(48 + length(CAST(event_type AS BLOB)) + length(CAST(target_type AS BLOB))
+	+ length(CAST(target_value AS BLOB)) + length(CAST(action AS BLOB))
+	+ ifnull(length(CAST(rule_type AS BLOB)), 0) + ifnull(length(CAST(details AS BLOB)), 0))`
+
+// cleanupConditions builds the WHERE clause selecting entries before the
+// cutoff that match filter
+func cleanupConditions(before time.Time, filter models.AuditLogCleanupFilter) (string, []interface{}) {
+	conditions := []string{"timestamp < ?"}
+	args := []interface{}{before}
+
+	if len(filter.EventTypes) > 0 {
+		conditions = append(conditions, "event_type IN ("+placeholders(len(filter.EventTypes))+")")
+		for _, eventType := range filter.EventTypes {
+			args = append(args, eventType)
+		}
+	}
+	if len(filter.Actions) > 0 {
+		conditions = append(conditions, "action IN ("+placeholders(len(filter.Actions))+")")
+		for _, action := range filter.Actions {
+			args = append(args, action)
+		}
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
+
+// placeholders returns n comma separated SQL placeholders
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?,", n), ",")
+}
+
+// CleanupOldLogsFiltered deletes entries before the cutoff matching filter,
+// oldest first and at most limit of them when limit > 0
+func (r *AuditLogRepository) CleanupOldLogsFiltered(ctx context.Context, before time.Time, filter models.AuditLogCleanupFilter, limit int) (int64, int64, error) {
+	where, args := cleanupConditions(before, filter)
+	selection := `SELECT id FROM audit_log WHERE ` + where + ` ORDER BY timestamp ASC, id ASC`
+	if limit > 0 {
+		selection += ` LIMIT ?`
+		args = append(args, limit)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to begin cleanup transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var bytesFreed int64
+	sizeQuery := `SELECT COALESCE(SUM(` + auditRowSize + `), 0) FROM audit_log WHERE id IN (` + selection + `)`
+	if err := tx.QueryRowContext(ctx, sizeQuery, args...).Scan(&bytesFreed); err != nil {
+		return 0, 0, fmt.Errorf("failed to measure logs to cleanup: %w", err)
+	}
+
+	result, err := tx.ExecContext(ctx, `DELETE FROM audit_log WHERE id IN (`+selection+`)`, args...)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to cleanup old logs: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get cleanup result: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("failed to commit cleanup: %w", err)
+	}
+
+	return deleted, bytesFreed, nil
+}
+
+// CountOldLogsFiltered counts the entries before the cutoff matching filter
+// and estimates their size in bytes
+func (r *AuditLogRepository) CountOldLogsFiltered(ctx context.Context, before time.Time, filter models.AuditLogCleanupFilter) (int, int64, error) {
+	where, args := cleanupConditions(before, filter)
+	query := `SELECT COUNT(*), COALESCE(SUM(` + auditRowSize + `), 0) FROM audit_log WHERE ` + where
+
+	var count int
+	var bytes int64
+	if err := r.readDB.QueryRowContext(ctx, query, args...).Scan(&count, &bytes); err != nil {
+		return 0, 0, fmt.Errorf("failed to count old logs: %w", err)
+	}
+
+	return count, bytes, nil
+}
+
+// AverageEntrySize estimates the average size in bytes of an audit log entry
+// from the most recent sampleSize entries
+func (r *AuditLogRepository) AverageEntrySize(ctx context.Context, sampleSize int) (int64, error) {
+	query := `SELECT COALESCE(AVG(size), 0) FROM (
+		SELECT ` + auditRowSize + ` AS size FROM audit_log ORDER BY id DESC LIMIT ?
+	)`
+
+	var average float64
+	if err := r.readDB.QueryRowContext(ctx, query, sampleSize).Scan(&average); err != nil {
+		return 0, fmt.Errorf("failed to sample audit log entry size: %w", err)
+	}
+
+	return int64(math.Round(average)), nil
+}
+
 // Count returns the total number of audit log entries
 func (r *AuditLogRepository) Count(ctx context.Context) (int, error) {
 	query := `SELECT COUNT(*) FROM audit_log`
 
 	var count int
-	err := r.db.QueryRowContext(ctx, query).Scan(&count)
+	err := r.readDB.QueryRowContext(ctx, query).Scan(&count)
 	if err != nil {
 		return 0, fmt.Errorf("failed to count audit logs: %w", err)
 	}
@@ -319,7 +432,7 @@ func (r *AuditLogRepository) CountByTimeRange(ctx context.Context, start, end ti
 	query := `SELECT COUNT(*) FROM audit_log WHERE timestamp >= ? AND timestamp <= ?`
 
 	var count int
-	err := r.db.QueryRowContext(ctx, query, start, end).Scan(&count)
+	err := r.readDB.QueryRowContext(ctx, query, start, end).Scan(&count)
 	if err != nil {
 		return 0, fmt.Errorf("failed to count audit logs by time range: %w", err)
 	}
@@ -327,17 +440,18 @@ func (r *AuditLogRepository) CountByTimeRange(ctx context.Context, start, end ti
 	return count, nil
 }
 
-// GetByFilters retrieves audit log entries with advanced filtering
-func (r *AuditLogRepository) GetByFilters(ctx context.Context, filters AuditLogFilters) ([]models.AuditLog, error) {
+// auditProcessName is the process an audit entry concerns: the target of an
+// executable entry, or the process_name detail of any other. It matches the
+// expression index created by the audit query index migration.
+const auditProcessName = `(CASE WHEN target_type = 'executable' THEN target_value
+	WHEN json_valid(details) THEN json_extract(details, '$.process_name') END)`
+
+// filterConditions builds the WHERE clause selecting entries that match
+// filters, or "" when nothing is filtered
+func filterConditions(filters AuditLogFilters) (string, []interface{}) {
 	var conditions []string
 	var args []interface{}
 
-	baseQuery := `
-		SELECT id, timestamp, event_type, target_type, target_value, action, rule_type, rule_id, details, created_at
-		FROM audit_log
-	`
-
-	// Build WHERE conditions
 	if filters.Action != nil {
 		conditions = append(conditions, "action = ?")
 		args = append(args, *filters.Action)
@@ -353,6 +467,11 @@ func (r *AuditLogRepository) GetByFilters(ctx context.Context, filters AuditLogF
 		args = append(args, filters.EventType)
 	}
 
+	if filters.ProcessName != "" {
+		conditions = append(conditions, auditProcessName+" = ?")
+		args = append(args, filters.ProcessName)
+	}
+
 	if filters.StartTime != nil {
 		conditions = append(conditions, "timestamp >= ?")
 		args = append(args, *filters.StartTime)
@@ -369,30 +488,54 @@ func (r *AuditLogRepository) GetByFilters(ctx context.Context, filters AuditLogF
 		args = append(args, searchPattern, searchPattern)
 	}
 
-	// Combine conditions
-	if len(conditions) > 0 {
-		baseQuery += " WHERE " + strings.Join(conditions, " AND ")
+	if len(conditions) == 0 {
+		return "", nil
 	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
 
-	// Add ordering and pagination
-	baseQuery += " ORDER BY timestamp DESC"
+// GetByFilters retrieves audit log entries with advanced filtering
+func (r *AuditLogRepository) GetByFilters(ctx context.Context, filters AuditLogFilters) ([]models.AuditLog, error) {
+	var logs []models.AuditLog
+	err := r.EachByFilters(ctx, filters, func(log *models.AuditLog) error {
+		logs = append(logs, *log)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
+
+// EachByFilters calls fn for every audit log entry matching filters, newest
+// first, reading one row at a time so large results are never held in
+// memory. It stops at the first error fn returns.
+func (r *AuditLogRepository) EachByFilters(ctx context.Context, filters AuditLogFilters, fn func(*models.AuditLog) error) error {
+	where, args := filterConditions(filters)
+	query := `
+		SELECT id, timestamp, event_type, target_type, target_value, action, rule_type, rule_id, details, created_at
+		FROM audit_log
+	` + where + " ORDER BY timestamp DESC, id DESC"
 	if filters.Limit > 0 {
-		baseQuery += " LIMIT ?"
+		query += " LIMIT ?"
 		args = append(args, filters.Limit)
 
 		if filters.Offset > 0 {
-			baseQuery += " OFFSET ?"
+			query += " OFFSET ?"
 			args = append(args, filters.Offset)
 		}
 	}
+	return r.eachRow(ctx, query, args, fn)
+}
 
-	rows, err := r.db.QueryContext(ctx, baseQuery, args...)
+// eachRow runs an audit log query and calls fn for each row
+func (r *AuditLogRepository) eachRow(ctx context.Context, query string, args []interface{}, fn func(*models.AuditLog) error) error {
+	rows, err := r.readDB.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query audit logs with filters: %w", err)
+		return fmt.Errorf("failed to query audit logs with filters: %w", err)
 	}
 	defer rows.Close()
 
-	var logs []models.AuditLog
 	for rows.Next() {
 		var log models.AuditLog
 		err := rows.Scan(
@@ -408,26 +551,41 @@ func (r *AuditLogRepository) GetByFilters(ctx context.Context, filters AuditLogF
 			&log.CreatedAt,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan audit log: %w", err)
+			return fmt.Errorf("failed to scan audit log: %w", err)
+		}
+		if err := fn(&log); err != nil {
+			return err
 		}
-		logs = append(logs, log)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error iterating audit logs: %w", err)
+		return fmt.Errorf("error iterating audit logs: %w", err)
 	}
 
-	return logs, nil
+	return nil
+}
+
+// CountByFilters counts the audit log entries matching filters, ignoring
+// their limit and offset
+func (r *AuditLogRepository) CountByFilters(ctx context.Context, filters AuditLogFilters) (int, error) {
+	where, args := filterConditions(filters)
+
+	var count int
+	if err := r.readDB.QueryRowContext(ctx, "SELECT COUNT(*) FROM audit_log"+where, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count audit logs with filters: %w", err)
+	}
+	return count, nil
 }
 
 // AuditLogFilters represents filtering options for audit log queries
 type AuditLogFilters struct {
-	Action     *models.ActionType
-	TargetType *models.TargetType
-	EventType  string
-	StartTime  *time.Time
-	EndTime    *time.Time
-	Search     string
-	Limit      int
-	Offset     int
+	Action      *models.ActionType
+	TargetType  *models.TargetType
+	EventType   string
+	ProcessName string
+	StartTime   *time.Time
+	EndTime     *time.Time
+	Search      string
+	Limit       int
+	Offset      int
 }
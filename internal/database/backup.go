@@ -0,0 +1,215 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"parental-control/internal/logging"
+)
+
+// Backup file names sort by the time they were taken, e.g.
+// "parental-control-20261016T094405.123Z-manual.db"
+const (
+	backupPrefix     = "parental-control-"
+	backupSuffix     = ".db"
+	backupTimeFormat = "20060102T150405.000Z"
+)
+
+// BackupReasonMigration names backups taken before schema migrations
+const BackupReasonMigration = "pre-migration"
+
+// BackupFile describes a backup in a backup directory
+type BackupFile struct {
+	Name      string    `json:"name"`
+	Path      string    `json:"path"`
+	Reason    string    `json:"reason"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BackupFileName names a backup taken at t for reason
+func BackupFileName(t time.Time, reason string) string {
+	return backupPrefix + t.UTC().Format(backupTimeFormat) + "-" + reason + backupSuffix
+}
+
+// parseBackupFileName returns the time and reason encoded by BackupFileName
+func parseBackupFileName(name string) (time.Time, string, bool) {
+	rest, found := strings.CutPrefix(name, backupPrefix)
+	if !found || !strings.HasSuffix(rest, backupSuffix) {
+		return time.Time{}, "", false
+	}
+	rest = strings.TrimSuffix(rest, backupSuffix)
+
+	stamp, reason, _ := strings.Cut(rest, "-")
+	t, err := time.Parse(backupTimeFormat, stamp)
+	if err != nil {
+		return time.Time{}, "", false
+	}
+	return t, reason, true
+}
+
+// Backup copies the database to path with SQLite's online backup API, which
+// reads a consistent snapshot even while WAL writers keep going; copying the
+// file could miss changes still in the WAL. The copy is checked and then
+// renamed into place, so path only ever holds a complete backup.
+func (db *DB) Backup(ctx context.Context, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	endMaintenance := db.beginMaintenance("database backup")
+	defer endMaintenance()
+
+	tmp := path + ".tmp"
+	os.Remove(tmp)
+	if err := db.backupTo(ctx, tmp); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	// The backup holds password hashes and browsing history
+	if err := os.Chmod(tmp, 0600); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to restrict backup permissions: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to move backup into place: %w", err)
+	}
+	return nil
+}
+
+// backupTo runs the online backup into a new database file at path
+func (db *DB) backupTo(ctx context.Context, path string) error {
+	dest, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return fmt.Errorf("failed to create backup file: %w", err)
+	}
+	defer dest.Close()
+
+	destConn, err := dest.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to open backup file: %w", err)
+	}
+	defer destConn.Close()
+
+	srcConn, err := db.conn.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get database connection: %w", err)
+	}
+	defer srcConn.Close()
+
+	err = destConn.Raw(func(destDriver interface{}) error {
+		return srcConn.Raw(func(srcDriver interface{}) error {
+			destSQLite, ok := unwrapConn(destDriver)
+			srcSQLite, ok2 := unwrapConn(srcDriver)
+			if !ok || !ok2 {
+				return fmt.Errorf("online backup requires the sqlite3 driver")
+			}
+
+			backup, err := destSQLite.Backup("main", srcSQLite, "main")
+			if err != nil {
+				return err
+			}
+			// Copy every page in one step; stepping in chunks restarts
+			// whenever another connection writes in between
+			if _, err := backup.Step(-1); err != nil {
+				backup.Finish()
+				return err
+			}
+			return backup.Finish()
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("online backup failed: %w", err)
+	}
+
+	var result string
+	if err := destConn.QueryRowContext(ctx, "PRAGMA quick_check").Scan(&result); err != nil {
+		return fmt.Errorf("failed to check backup: %w", err)
+	}
+	if result != "ok" {
+		return fmt.Errorf("backup failed integrity check: %s", result)
+	}
+	return nil
+}
+
+// ListBackups returns the backups in dir, newest first. A missing directory
+// has no backups.
+func ListBackups(dir string) ([]BackupFile, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read backup directory: %w", err)
+	}
+
+	var backups []BackupFile
+	for _, entry := range entries {
+		createdAt, reason, ok := parseBackupFileName(entry.Name())
+		if !ok || entry.IsDir() {
+			continue
+		}
+		backup := BackupFile{
+			Name:      entry.Name(),
+			Path:      filepath.Join(dir, entry.Name()),
+			Reason:    reason,
+			CreatedAt: createdAt,
+		}
+		if info, err := entry.Info(); err == nil {
+			backup.Size = info.Size()
+		}
+		backups = append(backups, backup)
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].CreatedAt.After(backups[j].CreatedAt)
+	})
+	return backups, nil
+}
+
+// PruneBackups deletes all but the newest keep backups in dir and returns
+// the names it deleted. Files not named by BackupFileName are left alone.
+func PruneBackups(dir string, keep int) ([]string, error) {
+	backups, err := ListBackups(dir)
+	if err != nil {
+		return nil, err
+	}
+	if keep < 0 {
+		keep = 0
+	}
+
+	var pruned []string
+	for _, backup := range backups[min(keep, len(backups)):] {
+		if err := os.Remove(backup.Path); err != nil && !os.IsNotExist(err) {
+			return pruned, fmt.Errorf("failed to delete old backup %s: %w", backup.Name, err)
+		}
+		pruned = append(pruned, backup.Name)
+	}
+	return pruned, nil
+}
+
+// backupBeforeMigrating backs up a database that already has data before
+// migrations change it, when a migration backup directory is configured
+func (db *DB) backupBeforeMigrating(status *MigrationStatus) error {
+	if db.migrationBackupDir == "" || status.Current == 0 {
+		return nil
+	}
+
+	path := filepath.Join(db.migrationBackupDir, BackupFileName(time.Now(), BackupReasonMigration))
+	if err := db.Backup(context.Background(), path); err != nil {
+		return fmt.Errorf("failed to back up the database before migrating: %w", err)
+	}
+
+	logging.Info("Backed up database before migrating",
+		logging.String("path", path),
+		logging.Int("schema_version", status.Current))
+	return nil
+}
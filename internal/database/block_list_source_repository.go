@@ -0,0 +1,139 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"parental-control/internal/models"
+)
+
+// BlockListSourceRepository implements the models.BlockListSourceRepository interface
+type BlockListSourceRepository struct {
+	db *sql.DB
+}
+
+// NewBlockListSourceRepository creates a new block list source repository
+func NewBlockListSourceRepository(db *sql.DB) *BlockListSourceRepository {
+	return &BlockListSourceRepository{db: db}
+}
+
+// GetAll retrieves every block list source
+func (r *BlockListSourceRepository) GetAll(ctx context.Context) ([]models.BlockListSource, error) {
+	query := `
+		SELECT list_id, url, format, refresh_interval_seconds, etag, last_modified,
+		       last_checked_at, last_error, created_at, updated_at
+		FROM block_list_sources
+		ORDER BY list_id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query block list sources: %w", err)
+	}
+	defer rows.Close()
+
+	var sources []models.BlockListSource
+	for rows.Next() {
+		source, err := scanBlockListSource(rows)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, *source)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating block list sources: %w", err)
+	}
+
+	return sources, nil
+}
+
+// GetByListID retrieves the source of a list
+func (r *BlockListSourceRepository) GetByListID(ctx context.Context, listID int) (*models.BlockListSource, error) {
+	query := `
+		SELECT list_id, url, format, refresh_interval_seconds, etag, last_modified,
+		       last_checked_at, last_error, created_at, updated_at
+		FROM block_list_sources
+		WHERE list_id = ?
+	`
+
+	source, err := scanBlockListSource(r.db.QueryRowContext(ctx, query, listID))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("block list source for list %d not found", listID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return source, nil
+}
+
+// Save creates or replaces the source of a list
+func (r *BlockListSourceRepository) Save(ctx context.Context, source *models.BlockListSource) error {
+	query := `
+		INSERT INTO block_list_sources (list_id, url, format, refresh_interval_seconds, etag,
+			last_modified, last_checked_at, last_error, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(list_id) DO UPDATE SET
+			url = excluded.url,
+			format = excluded.format,
+			refresh_interval_seconds = excluded.refresh_interval_seconds,
+			etag = excluded.etag,
+			last_modified = excluded.last_modified,
+			last_checked_at = excluded.last_checked_at,
+			last_error = excluded.last_error,
+			updated_at = excluded.updated_at
+	`
+
+	now := time.Now()
+	if source.CreatedAt.IsZero() {
+		source.CreatedAt = now
+	}
+	source.UpdatedAt = now
+
+	_, err := r.db.ExecContext(ctx, query,
+		source.ListID, source.URL, source.Format, int64(source.RefreshInterval/time.Second),
+		source.ETag, source.LastModified, source.LastCheckedAt, source.LastError,
+		source.CreatedAt, source.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save block list source: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes the source of a list
+func (r *BlockListSourceRepository) Delete(ctx context.Context, listID int) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM block_list_sources WHERE list_id = ?`, listID)
+	if err != nil {
+		return fmt.Errorf("failed to delete block list source: %w", err)
+	}
+
+	return nil
+}
+
+// scanBlockListSource scans one block list source row
+func scanBlockListSource(row interface{ Scan(...interface{}) error }) (*models.BlockListSource, error) {
+	var source models.BlockListSource
+	var intervalSeconds int64
+	var lastCheckedAt sql.NullTime
+
+	err := row.Scan(&source.ListID, &source.URL, &source.Format, &intervalSeconds,
+		&source.ETag, &source.LastModified, &lastCheckedAt, &source.LastError,
+		&source.CreatedAt, &source.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan block list source: %w", err)
+	}
+
+	source.RefreshInterval = time.Duration(intervalSeconds) * time.Second
+	if lastCheckedAt.Valid {
+		source.LastCheckedAt = &lastCheckedAt.Time
+	}
+
+	return &source, nil
+}
@@ -1,11 +1,14 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"embed"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 
 	"parental-control/internal/logging"
@@ -17,10 +20,24 @@ import (
 //go:embed migrations/*.sql
 var migrationsFS embed.FS
 
+// MaintenanceHook is called before an operation that needs a consistent
+// database and returns a function to call when the operation finishes
+type MaintenanceHook func(reason string) func()
+
 // DB wraps the sql.DB connection with additional functionality
 type DB struct {
-	conn *sql.DB
-	path string
+	conn     *sql.DB
+	readConn *sql.DB // read-only pool for reporting queries, nil when disabled
+	path     string
+
+	maintenanceHook MaintenanceHook
+	hookMu          sync.RWMutex
+
+	// Where migrations back up an existing database first, "" to skip
+	migrationBackupDir string
+
+	// Times statements on both pools, nil when slow query logging is off
+	slowQueries *slowQueryLog
 }
 
 // Config holds database configuration
@@ -37,17 +54,114 @@ type Config struct {
 	EnableWAL bool
 	// Timeout for database operations
 	Timeout time.Duration
+	// ReadReplica opens a second, read-only connection pool for reporting
+	// queries so dashboards and exports do not queue behind enforcement
+	// writes. Requires WAL mode, where readers never block the writer.
+	ReadReplica bool
+	// ReadMaxOpenConns limits the read-only connection pool
+	ReadMaxOpenConns int
+	// StartupRetryWindow is how long Open keeps retrying when the database
+	// cannot be opened, e.g. while its volume is still being mounted at
+	// boot. Zero fails on the first error.
+	StartupRetryWindow time.Duration
+	// StartupRetryInterval is the delay before the first retry. It doubles
+	// after each failed attempt, up to maxStartupRetryInterval.
+	StartupRetryInterval time.Duration
+	// SlowQueryThreshold logs statements that take at least this long, with
+	// their duration. Zero turns slow query logging off.
+	SlowQueryThreshold time.Duration `yaml:"slow_query_threshold" json:"slow_query_threshold"`
+	// MigrationBackupDir is where an existing database is backed up before
+	// migrations change it. Empty skips the backup. It is set from the
+	// backup settings rather than read from the database section.
+	MigrationBackupDir string `yaml:"-" json:"-"`
+
+	// The service's maintenance job checkpoints the WAL and vacuums the
+	// database file with these settings
+	MaintenanceEnabled bool `yaml:"maintenance_enabled" json:"maintenance_enabled"`
+	// MaintenanceCheckpointInterval is how often the WAL is checkpointed and
+	// truncated, and how often a due VACUUM is considered
+	MaintenanceCheckpointInterval time.Duration `yaml:"maintenance_checkpoint_interval" json:"maintenance_checkpoint_interval"`
+	// MaintenanceVacuumInterval is the least time between VACUUMs; zero
+	// never vacuums
+	MaintenanceVacuumInterval time.Duration `yaml:"maintenance_vacuum_interval" json:"maintenance_vacuum_interval"`
+	// MaintenanceVacuumMinFreePercent skips a due VACUUM while less than this
+	// share of the file is free pages, since it would reclaim little
+	MaintenanceVacuumMinFreePercent float64 `yaml:"maintenance_vacuum_min_free_percent" json:"maintenance_vacuum_min_free_percent"`
+	// MaintenanceWindowStart and MaintenanceWindowEnd limit VACUUM to a
+	// quiet time of day, as local HH:MM; the window may cross midnight.
+	// Empty allows any time. Checkpoints are cheap and run regardless.
+	MaintenanceWindowStart string `yaml:"maintenance_window_start" json:"maintenance_window_start"`
+	MaintenanceWindowEnd   string `yaml:"maintenance_window_end" json:"maintenance_window_end"`
 }
 
+// maxStartupRetryInterval caps the backoff between startup retries
+const maxStartupRetryInterval = 30 * time.Second
+
 // DefaultConfig returns a configuration with sensible defaults
 func DefaultConfig() Config {
 	return Config{
-		Path:            "./data/parental-control.db",
-		MaxOpenConns:    10,
-		MaxIdleConns:    5,
-		ConnMaxLifetime: time.Hour,
-		EnableWAL:       true,
-		Timeout:         30 * time.Second,
+		Path:             "./data/parental-control.db",
+		MaxOpenConns:     10,
+		MaxIdleConns:     5,
+		ConnMaxLifetime:  time.Hour,
+		EnableWAL:        true,
+		Timeout:          30 * time.Second,
+		ReadReplica:      true,
+		ReadMaxOpenConns: 4,
+		// Ride out a data volume that mounts shortly after boot
+		StartupRetryWindow:   2 * time.Minute,
+		StartupRetryInterval: time.Second,
+		// Vacuum weekly in the small hours, when nobody is online
+		MaintenanceEnabled:              true,
+		MaintenanceCheckpointInterval:   30 * time.Minute,
+		MaintenanceVacuumInterval:       7 * 24 * time.Hour,
+		MaintenanceVacuumMinFreePercent: 10,
+		MaintenanceWindowStart:          "02:00",
+		MaintenanceWindowEnd:            "05:00",
+	}
+}
+
+// Open connects to the database and initializes its schema. Failures are
+// retried with exponential backoff until config.StartupRetryWindow has
+// passed or ctx is cancelled; the last error is returned.
+func Open(ctx context.Context, config Config) (*DB, error) {
+	deadline := time.Now().Add(config.StartupRetryWindow)
+	delay := config.StartupRetryInterval
+
+	for attempt := 1; ; attempt++ {
+		db, err := New(config)
+		if err == nil {
+			if err = db.InitializeSchema(); err == nil {
+				return db, nil
+			}
+			db.Close()
+			err = fmt.Errorf("failed to initialize database schema: %w", err)
+		}
+
+		remaining := time.Until(deadline)
+		if config.StartupRetryWindow <= 0 || delay <= 0 || remaining <= 0 {
+			return nil, err
+		}
+		if delay > remaining {
+			delay = remaining
+		}
+
+		logging.Warn("Database unavailable, retrying",
+			logging.String("path", config.Path),
+			logging.Int("attempt", attempt),
+			logging.String("retry_in", delay.String()),
+			logging.Err(err))
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("gave up waiting for database: %w", err)
+		case <-time.After(delay):
+		}
+
+		delay *= 2
+		if delay > maxStartupRetryInterval {
+			delay = maxStartupRetryInterval
+		}
 	}
 }
 
@@ -67,8 +181,13 @@ func New(config Config) (*DB, error) {
 		dsn += "?_foreign_keys=1"
 	}
 
+	var slow *slowQueryLog
+	if config.SlowQueryThreshold > 0 {
+		slow = &slowQueryLog{threshold: config.SlowQueryThreshold}
+	}
+
 	// Open database connection
-	conn, err := sql.Open("sqlite3", dsn)
+	conn, err := openPool(dsn, "write", slow)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
@@ -79,8 +198,10 @@ func New(config Config) (*DB, error) {
 	conn.SetConnMaxLifetime(config.ConnMaxLifetime)
 
 	db := &DB{
-		conn: conn,
-		path: config.Path,
+		conn:               conn,
+		path:               config.Path,
+		migrationBackupDir: config.MigrationBackupDir,
+		slowQueries:        slow,
 	}
 
 	// Test the connection
@@ -89,11 +210,55 @@ func New(config Config) (*DB, error) {
 		return nil, fmt.Errorf("database connection test failed: %w", err)
 	}
 
-	logging.Info("Database connection established", logging.String("path", config.Path))
+	if config.ReadReplica {
+		readConn, err := openReadConnection(config, slow)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+		db.readConn = readConn
+	}
+
+	logging.Info("Database connection established",
+		logging.String("path", config.Path),
+		logging.Bool("read_replica", db.readConn != nil))
 
 	return db, nil
 }
 
+// openReadConnection opens the read-only pool used for reporting queries.
+// It returns nil when a separate pool would not help or would not see the
+// same data (rollback journal mode or an in-memory database).
+func openReadConnection(config Config, slow *slowQueryLog) (*sql.DB, error) {
+	if !config.EnableWAL {
+		logging.Info("Read replica disabled: it requires WAL mode")
+		return nil, nil
+	}
+	if config.Path == ":memory:" || strings.Contains(config.Path, "mode=memory") {
+		return nil, nil
+	}
+
+	dsn := "file:" + config.Path + "?mode=ro&_query_only=1&_foreign_keys=1"
+	readConn, err := openPool(dsn, "read", slow)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open read-only database: %w", err)
+	}
+
+	maxOpen := config.ReadMaxOpenConns
+	if maxOpen <= 0 {
+		maxOpen = 4
+	}
+	readConn.SetMaxOpenConns(maxOpen)
+	readConn.SetMaxIdleConns(maxOpen)
+	readConn.SetConnMaxLifetime(config.ConnMaxLifetime)
+
+	if err := readConn.Ping(); err != nil {
+		readConn.Close()
+		return nil, fmt.Errorf("read-only database connection test failed: %w", err)
+	}
+	return readConn, nil
+}
+
 // Ping tests the database connection
 func (db *DB) Ping() error {
 	return db.conn.Ping()
@@ -101,6 +266,9 @@ func (db *DB) Ping() error {
 
 // Close closes the database connection
 func (db *DB) Close() error {
+	if db.readConn != nil {
+		db.readConn.Close()
+	}
 	if db.conn != nil {
 		logging.Info("Closing database connection")
 		return db.conn.Close()
@@ -113,100 +281,72 @@ func (db *DB) Connection() *sql.DB {
 	return db.conn
 }
 
-// Path returns the database file path
-func (db *DB) Path() string {
-	return db.path
-}
-
-// InitializeSchema runs all pending migrations to set up the database schema
-func (db *DB) InitializeSchema() error {
-	logging.Info("Initializing database schema")
-
-	// Get current schema version
-	currentVersion, err := db.getCurrentSchemaVersion()
-	if err != nil {
-		return fmt.Errorf("failed to get current schema version: %w", err)
-	}
-
-	// Apply migrations
-	if err := db.applyMigrations(currentVersion); err != nil {
-		return fmt.Errorf("failed to apply migrations: %w", err)
+// ReadConnection returns the read-only pool for reporting queries, or the
+// main connection when no read replica is open
+func (db *DB) ReadConnection() *sql.DB {
+	if db.readConn != nil {
+		return db.readConn
 	}
+	return db.conn
+}
 
-	logging.Info("Database schema initialization complete")
-	return nil
+// scanner is a *sql.Row or *sql.Rows, so one function can scan a repository
+// row from either
+type scanner interface {
+	Scan(dest ...interface{}) error
 }
 
-// getCurrentSchemaVersion returns the current schema version
-func (db *DB) getCurrentSchemaVersion() (int, error) {
-	// Check if schema_versions table exists
-	var exists bool
-	err := db.conn.QueryRow(`
-		SELECT COUNT(*) > 0 
-		FROM sqlite_master 
-		WHERE type='table' AND name='schema_versions'
-	`).Scan(&exists)
+// execer is a *sql.DB or *sql.Tx, so writes can run alone or in a batch
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
 
-	if err != nil {
-		return 0, err
-	}
+// SetMaintenanceHook registers a hook invoked around backups and migrations
+func (db *DB) SetMaintenanceHook(hook MaintenanceHook) {
+	db.hookMu.Lock()
+	defer db.hookMu.Unlock()
+	db.maintenanceHook = hook
+}
 
-	if !exists {
-		return 0, nil // No schema version table means version 0
-	}
+// beginMaintenance invokes the maintenance hook if one is registered
+func (db *DB) beginMaintenance(reason string) func() {
+	db.hookMu.RLock()
+	hook := db.maintenanceHook
+	db.hookMu.RUnlock()
 
-	// Get the latest version
-	var version int
-	err = db.conn.QueryRow("SELECT MAX(version) FROM schema_versions").Scan(&version)
-	if err != nil {
-		return 0, err
+	if hook == nil {
+		return func() {}
 	}
+	return hook(reason)
+}
 
-	return version, nil
+// Path returns the database file path
+func (db *DB) Path() string {
+	return db.path
 }
 
-// applyMigrations applies all migrations newer than the current version
-func (db *DB) applyMigrations(currentVersion int) error {
-	// Read migration files
-	entries, err := migrationsFS.ReadDir("migrations")
+// InitializeSchema runs all pending migrations to set up the database schema
+func (db *DB) InitializeSchema() error {
+	logging.Info("Initializing database schema")
+
+	status, err := db.MigrationStatus()
 	if err != nil {
-		return fmt.Errorf("failed to read migrations directory: %w", err)
+		return fmt.Errorf("failed to get current schema version: %w", err)
 	}
 
-	// Apply each migration file
-	for _, entry := range entries {
-		if entry.IsDir() {
-			continue
-		}
-
-		filename := entry.Name()
-
-		// Read migration content
-		content, err := migrationsFS.ReadFile("migrations/" + filename)
-		if err != nil {
-			return fmt.Errorf("failed to read migration file %s: %w", filename, err)
-		}
-
-		logging.Info("Applying migration", logging.String("file", filename))
-
-		// Execute migration in a transaction
-		tx, err := db.conn.Begin()
-		if err != nil {
-			return fmt.Errorf("failed to start transaction for migration %s: %w", filename, err)
-		}
-
-		if _, err := tx.Exec(string(content)); err != nil {
-			tx.Rollback()
-			return fmt.Errorf("failed to execute migration %s: %w", filename, err)
-		}
-
-		if err := tx.Commit(); err != nil {
-			return fmt.Errorf("failed to commit migration %s: %w", filename, err)
-		}
+	// An older binary on a newer database cannot know what changed; carry on
+	// so the service still starts, since most migrations only add things
+	if status.Current > status.Latest {
+		logging.Warn("Database schema is newer than this version supports; roll it back with the newer release's migrate down",
+			logging.Int("schema_version", status.Current),
+			logging.Int("supported_version", status.Latest))
+	}
 
-		logging.Info("Migration applied successfully", logging.String("file", filename))
+	if _, err := db.MigrateUp(0); err != nil {
+		return fmt.Errorf("failed to apply migrations: %w", err)
 	}
 
+	logging.Info("Database schema initialization complete", logging.Int("schema_version", max(status.Current, status.Latest)))
 	return nil
 }
 
@@ -263,11 +403,24 @@ func (db *DB) GetStats() (map[string]interface{}, error) {
 	stats["max_idle_closed"] = dbStats.MaxIdleClosed
 	stats["max_lifetime_closed"] = dbStats.MaxLifetimeClosed
 
+	// Read replica pool stats, to compare reporting load against writes
+	stats["read_replica"] = db.readConn != nil
+	if db.readConn != nil {
+		readStats := db.readConn.Stats()
+		stats["read_open_connections"] = readStats.OpenConnections
+		stats["read_in_use"] = readStats.InUse
+		stats["read_idle"] = readStats.Idle
+		stats["read_wait_count"] = readStats.WaitCount
+		stats["read_wait_duration"] = readStats.WaitDuration.String()
+	}
+
 	// Database file info
 	if info, err := os.Stat(db.path); err == nil {
 		stats["file_size"] = info.Size()
 		stats["modified_time"] = info.ModTime()
 	}
+	stats["wal_file_size"] = fileSize(db.walPath())
+	stats["slow_queries"] = db.SlowQueries()
 
 	// Schema version
 	if version, err := db.getCurrentSchemaVersion(); err == nil {
@@ -1,10 +1,16 @@
 package database
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
+
+	"parental-control/internal/models"
 )
 
 func TestDefaultConfig(t *testing.T) {
@@ -61,6 +67,72 @@ func TestNew(t *testing.T) {
 	}
 }
 
+func TestOpenRetriesUntilAvailable(t *testing.T) {
+	// A file where the data directory should be stands in for a volume that
+	// is not mounted yet
+	mountPoint := filepath.Join(t.TempDir(), "volume")
+	if err := os.WriteFile(mountPoint, nil, 0644); err != nil {
+		t.Fatalf("Failed to create placeholder: %v", err)
+	}
+
+	config := Config{
+		Path:                 filepath.Join(mountPoint, "test.db"),
+		MaxOpenConns:         5,
+		MaxIdleConns:         2,
+		EnableWAL:            true,
+		StartupRetryWindow:   5 * time.Second,
+		StartupRetryInterval: 20 * time.Millisecond,
+	}
+
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		os.Remove(mountPoint)
+	}()
+
+	start := time.Now()
+	db, err := Open(context.Background(), config)
+	if err != nil {
+		t.Fatalf("Expected the database to open once available: %v", err)
+	}
+	defer db.Close()
+
+	if time.Since(start) < 200*time.Millisecond {
+		t.Error("Expected Open to wait for the database to become available")
+	}
+	if version, err := db.getCurrentSchemaVersion(); err != nil || version == 0 {
+		t.Errorf("Expected the schema to be initialized, got version %d (%v)", version, err)
+	}
+}
+
+func TestOpenGivesUpAfterRetryWindow(t *testing.T) {
+	blocked := filepath.Join(t.TempDir(), "volume")
+	if err := os.WriteFile(blocked, nil, 0644); err != nil {
+		t.Fatalf("Failed to create placeholder: %v", err)
+	}
+
+	config := Config{
+		Path:                 filepath.Join(blocked, "test.db"),
+		MaxOpenConns:         1,
+		StartupRetryWindow:   150 * time.Millisecond,
+		StartupRetryInterval: 20 * time.Millisecond,
+	}
+
+	start := time.Now()
+	if _, err := Open(context.Background(), config); err == nil {
+		t.Fatal("Expected an error once the retry window passed")
+	}
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond || elapsed > 2*time.Second {
+		t.Errorf("Expected to give up after the retry window, took %v", elapsed)
+	}
+
+	// Without a retry window the first failure is returned
+	config.StartupRetryWindow = 0
+	start = time.Now()
+	if _, err := Open(context.Background(), config); err == nil || time.Since(start) > 100*time.Millisecond {
+		t.Errorf("Expected an immediate error without retries, got %v", err)
+	}
+}
+
 func TestInitializeSchema(t *testing.T) {
 	// Create temporary directory for test database
 	tempDir := t.TempDir()
@@ -84,14 +156,19 @@ func TestInitializeSchema(t *testing.T) {
 		t.Fatalf("Failed to initialize schema: %v", err)
 	}
 
-	// Verify schema version (should be 3: 001_initial_schema, 002_retention_policies, 003_log_rotation)
+	// Verify schema version (should be 18: 001_initial_schema, 002_retention_policies, 003_log_rotation, 004_lockout_state, 005_list_entry_lookup, 006_list_entry_unique, 007_list_metadata, 008_port_patterns, 009_performance_history, 010_block_list_sources, 011_regex_patterns, 012_cidr_patterns, 013_time_rule_timezone, 014_quota_carry_over, 015_audit_query_indexes, 016_users, 017_api_keys, 018_audit_admin_actions)
 	version, err := db.getCurrentSchemaVersion()
 	if err != nil {
 		t.Errorf("Failed to get schema version: %v", err)
 	}
 
-	if version != 3 {
-		t.Errorf("Expected schema version 3, got %d", version)
+	if version != 18 {
+		t.Errorf("Expected schema version 18, got %d", version)
+	}
+
+	// Applied migrations are skipped on the next start
+	if err := db.InitializeSchema(); err != nil {
+		t.Fatalf("Failed to re-initialize schema: %v", err)
 	}
 
 	// Verify that all expected tables exist (including new rotation tables)
@@ -99,6 +176,7 @@ func TestInitializeSchema(t *testing.T) {
 		"config", "lists", "list_entries", "time_rules", "quota_rules", "quota_usage",
 		"audit_log", "retention_policies", "retention_policy_executions",
 		"log_rotation_policies", "log_rotation_executions", "schema_versions",
+		"lockout_state", "performance_snapshots", "performance_alerts", "users", "api_keys",
 	}
 
 	for _, table := range expectedTables {
@@ -195,9 +273,9 @@ func TestGetStats(t *testing.T) {
 		}
 	}
 
-	// Verify schema version (should be 3: 001_initial_schema, 002_retention_policies, 003_log_rotation)
-	if stats["schema_version"] != 3 {
-		t.Errorf("Expected schema version 3, got %v", stats["schema_version"])
+	// Verify schema version (should be 18: 001_initial_schema, 002_retention_policies, 003_log_rotation, 004_lockout_state, 005_list_entry_lookup, 006_list_entry_unique, 007_list_metadata, 008_port_patterns, 009_performance_history, 010_block_list_sources, 011_regex_patterns, 012_cidr_patterns, 013_time_rule_timezone, 014_quota_carry_over, 015_audit_query_indexes, 016_users, 017_api_keys, 018_audit_admin_actions)
+	if stats["schema_version"] != 18 {
+		t.Errorf("Expected schema version 18, got %v", stats["schema_version"])
 	}
 }
 
@@ -307,9 +385,9 @@ func TestLogRotationTablesCreation(t *testing.T) {
 		t.Errorf("Failed to query log_rotation_policies table: %v", err)
 	}
 
-	// Should have 2 default policies (Default Log Rotation and Emergency Disk Space Protection)
-	if count != 2 {
-		t.Errorf("Expected 2 default log rotation policies, got %d", count)
+	// Default policies are seeded by the service, not by migrations
+	if count != 0 {
+		t.Errorf("Expected no log rotation policies after migration, got %d", count)
 	}
 
 	// Test log_rotation_executions table structure
@@ -322,39 +400,1321 @@ func TestLogRotationTablesCreation(t *testing.T) {
 	if count != 0 {
 		t.Errorf("Expected 0 log rotation executions initially, got %d", count)
 	}
+}
+
+func TestLogRotationExecutionStats(t *testing.T) {
+	config := DefaultConfig()
+	config.Path = filepath.Join(t.TempDir(), "test.db")
+
+	db, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.InitializeSchema(); err != nil {
+		t.Fatalf("Failed to initialize schema: %v", err)
+	}
+
+	ctx := context.Background()
+	policy := &models.LogRotationPolicy{Name: "policy", Enabled: true, ExecutionSchedule: "@daily"}
+	if err := NewLogRotationPolicyRepository(db.Connection()).Create(ctx, policy); err != nil {
+		t.Fatalf("Failed to create policy: %v", err)
+	}
+
+	repo := NewLogRotationExecutionRepository(db.Connection())
+	for _, filesRotated := range []int{2, 3, 7} {
+		execution := &models.LogRotationExecution{
+			PolicyID:      policy.ID,
+			ExecutionTime: time.Now().UTC().Truncate(time.Second),
+			Status:        models.ExecutionStatusCompleted,
+			TriggerReason: models.TriggerManual,
+			FilesRotated:  filesRotated,
+			Details:       "{}",
+		}
+		if err := repo.Create(ctx, execution); err != nil {
+			t.Fatalf("Failed to create execution: %v", err)
+		}
+	}
+
+	stats, err := repo.GetStats(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get stats: %v", err)
+	}
+	if stats.TotalExecutions != 3 {
+		t.Errorf("Expected 3 executions, got %d", stats.TotalExecutions)
+	}
+	if stats.TotalFilesRotated != 12 {
+		t.Errorf("Expected 12 files rotated, got %d", stats.TotalFilesRotated)
+	}
+	if stats.LastRotationTime.IsZero() {
+		t.Error("Expected the last rotation time to be set")
+	}
+}
+
+func TestLockoutStateRepository(t *testing.T) {
+	config := DefaultConfig()
+	config.Path = filepath.Join(t.TempDir(), "test.db")
+
+	db, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.InitializeSchema(); err != nil {
+		t.Fatalf("Failed to initialize schema: %v", err)
+	}
+
+	ctx := context.Background()
+	repo := NewLockoutStateRepository(db.Connection())
+
+	lockedUntil := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	state := &models.LockoutState{Username: "admin", LockoutCount: 1, LockedUntil: &lockedUntil, LastLockoutAt: &lockedUntil}
+	if err := repo.Save(ctx, state); err != nil {
+		t.Fatalf("Failed to save lockout state: %v", err)
+	}
+
+	state.LockoutCount = 2
+	if err := repo.Save(ctx, state); err != nil {
+		t.Fatalf("Failed to update lockout state: %v", err)
+	}
+
+	states, err := repo.GetAll(ctx)
+	if err != nil {
+		t.Fatalf("Failed to load lockout state: %v", err)
+	}
+	if len(states) != 1 || states[0].LockoutCount != 2 {
+		t.Fatalf("Expected one state with count 2, got %+v", states)
+	}
+	if states[0].LockedUntil == nil || !states[0].LockedUntil.Equal(lockedUntil) {
+		t.Errorf("Expected locked until %v, got %v", lockedUntil, states[0].LockedUntil)
+	}
+
+	if err := repo.Delete(ctx, "admin"); err != nil {
+		t.Fatalf("Failed to delete lockout state: %v", err)
+	}
+	if states, _ := repo.GetAll(ctx); len(states) != 0 {
+		t.Errorf("Expected no states after delete, got %d", len(states))
+	}
+}
+
+func TestUserRepository(t *testing.T) {
+	config := DefaultConfig()
+	config.Path = filepath.Join(t.TempDir(), "test.db")
+
+	db, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.InitializeSchema(); err != nil {
+		t.Fatalf("Failed to initialize schema: %v", err)
+	}
+
+	ctx := context.Background()
+	repo := NewUserRepository(db.Connection())
+
+	admin := &models.User{Username: "admin", PasswordHash: "$2a$04$hash", Email: "admin@example.com", IsActive: true, IsAdmin: true}
+	if err := repo.Create(ctx, admin); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	if admin.ID == 0 {
+		t.Fatal("Expected the user to get an ID")
+	}
+	if err := repo.Create(ctx, &models.User{Username: "admin", PasswordHash: "$2a$04$other"}); err == nil {
+		t.Error("Expected a duplicate username to be rejected")
+	}
+
+	lockedUntil := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	admin.FailedAttempts = 5
+	admin.LockedUntil = &lockedUntil
+	admin.TwoFactorEnabled = true
+	admin.TOTPSecret = "encrypted"
+	admin.LastTOTPStep = 42
+	if err := repo.Update(ctx, admin); err != nil {
+		t.Fatalf("Failed to update user: %v", err)
+	}
+
+	stored, err := repo.GetByUsername(ctx, "admin")
+	if err != nil {
+		t.Fatalf("Failed to get user: %v", err)
+	}
+	if stored.ID != admin.ID || stored.PasswordHash != admin.PasswordHash || !stored.IsAdmin || stored.FailedAttempts != 5 ||
+		!stored.TwoFactorEnabled || stored.TOTPSecret != "encrypted" || stored.LastTOTPStep != 42 || stored.LastLoginAt != nil {
+		t.Errorf("Expected the update to round-trip, got %+v", stored)
+	}
+	if stored.LockedUntil == nil || !stored.LockedUntil.Equal(lockedUntil) {
+		t.Errorf("Expected locked until %v, got %v", lockedUntil, stored.LockedUntil)
+	}
+
+	if err := repo.Create(ctx, &models.User{Username: "parent", PasswordHash: "$2a$04$parent", IsActive: true}); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	users, err := repo.GetAll(ctx)
+	if err != nil || len(users) != 2 || users[0].Username != "admin" || users[1].Username != "parent" {
+		t.Fatalf("Expected both users in ID order, got %+v (%v)", users, err)
+	}
+
+	if err := repo.Delete(ctx, admin.ID); err != nil {
+		t.Fatalf("Failed to delete user: %v", err)
+	}
+	if _, err := repo.GetByUsername(ctx, "admin"); err == nil {
+		t.Error("Expected the deleted user to be gone")
+	}
+	if err := repo.Delete(ctx, admin.ID); err == nil {
+		t.Error("Expected deleting a missing user to fail")
+	}
+}
+
+func TestAPIKeyRepository(t *testing.T) {
+	config := DefaultConfig()
+	config.Path = filepath.Join(t.TempDir(), "test.db")
+
+	db, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.InitializeSchema(); err != nil {
+		t.Fatalf("Failed to initialize schema: %v", err)
+	}
+
+	ctx := context.Background()
+	repo := NewAPIKeyRepository(db.Connection())
+
+	key := &models.APIKey{UserID: 1, Name: "dashboard", Prefix: "pc_abcd", KeyHash: "hash-1", Scope: "read_only"}
+	if err := repo.Create(ctx, key); err != nil {
+		t.Fatalf("Failed to create API key: %v", err)
+	}
+	if key.ID == 0 {
+		t.Fatal("Expected the API key to get an ID")
+	}
+	if err := repo.Create(ctx, &models.APIKey{UserID: 1, Name: "copy", Prefix: "pc_abcd", KeyHash: "hash-1", Scope: "read_only"}); err == nil {
+		t.Error("Expected a duplicate key hash to be rejected")
+	}
+	if err := repo.Create(ctx, &models.APIKey{UserID: 1, Name: "bad", Prefix: "pc_efgh", KeyHash: "hash-2", Scope: "owner"}); err == nil {
+		t.Error("Expected an unknown scope to be rejected")
+	}
+
+	usedAt := time.Now().UTC().Truncate(time.Second)
+	key.LastUsedAt = &usedAt
+	key.RevokedAt = &usedAt
+	if err := repo.Update(ctx, key); err != nil {
+		t.Fatalf("Failed to update API key: %v", err)
+	}
+
+	stored, err := repo.GetByHash(ctx, "hash-1")
+	if err != nil {
+		t.Fatalf("Failed to get API key: %v", err)
+	}
+	if stored.ID != key.ID || stored.Name != "dashboard" || stored.Scope != "read_only" || stored.ExpiresAt != nil {
+		t.Errorf("Expected the key to round-trip, got %+v", stored)
+	}
+	if stored.LastUsedAt == nil || !stored.LastUsedAt.Equal(usedAt) || stored.RevokedAt == nil || !stored.RevokedAt.Equal(usedAt) {
+		t.Errorf("Expected used and revoked at %v, got %v and %v", usedAt, stored.LastUsedAt, stored.RevokedAt)
+	}
+	if _, err := repo.GetByID(ctx, key.ID); err != nil {
+		t.Errorf("Failed to get API key by ID: %v", err)
+	}
+	if _, err := repo.GetByHash(ctx, "missing"); err == nil {
+		t.Error("Expected an unknown hash to fail")
+	}
+
+	// Recording a use leaves the revocation alone
+	laterUse := usedAt.Add(time.Minute)
+	if err := repo.UpdateLastUsed(ctx, key.ID, laterUse); err != nil {
+		t.Fatalf("Failed to record API key use: %v", err)
+	}
+	if stored, err = repo.GetByID(ctx, key.ID); err != nil || !stored.LastUsedAt.Equal(laterUse) || stored.RevokedAt == nil {
+		t.Errorf("Expected only the last use to change, got %+v (%v)", stored, err)
+	}
+
+	keys, err := repo.GetAll(ctx)
+	if err != nil || len(keys) != 1 {
+		t.Fatalf("Expected the revoked key to still be listed, got %+v (%v)", keys, err)
+	}
+
+	key.ID = 999
+	if err := repo.Update(ctx, key); err == nil {
+		t.Error("Expected updating a missing key to fail")
+	}
+}
+
+func TestBlockListSourceRepository(t *testing.T) {
+	config := DefaultConfig()
+	config.Path = filepath.Join(t.TempDir(), "test.db")
+
+	db, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.InitializeSchema(); err != nil {
+		t.Fatalf("Failed to initialize schema: %v", err)
+	}
+
+	ctx := context.Background()
+	list := &models.List{Name: "Ads", Type: models.ListTypeBlacklist, Enabled: true}
+	if err := NewListRepository(db.Connection()).Create(ctx, list); err != nil {
+		t.Fatalf("Failed to create list: %v", err)
+	}
+
+	repo := NewBlockListSourceRepository(db.Connection())
+	if _, err := repo.GetByListID(ctx, list.ID); err == nil {
+		t.Error("Expected an error for a list without a source")
+	}
+
+	checkedAt := time.Now().UTC().Truncate(time.Second)
+	source := &models.BlockListSource{ListID: list.ID, URL: "https://example.com/hosts", Format: "hosts", RefreshInterval: 24 * time.Hour}
+	if err := repo.Save(ctx, source); err != nil {
+		t.Fatalf("Failed to save source: %v", err)
+	}
+
+	source.ETag = `"abc"`
+	source.LastCheckedAt = &checkedAt
+	if err := repo.Save(ctx, source); err != nil {
+		t.Fatalf("Failed to update source: %v", err)
+	}
+
+	got, err := repo.GetByListID(ctx, list.ID)
+	if err != nil {
+		t.Fatalf("Failed to load source: %v", err)
+	}
+	if got.ETag != `"abc"` || got.RefreshInterval != 24*time.Hour || got.LastCheckedAt == nil || !got.LastCheckedAt.Equal(checkedAt) {
+		t.Errorf("Unexpected source %+v", got)
+	}
+
+	if err := repo.Delete(ctx, list.ID); err != nil {
+		t.Fatalf("Failed to delete source: %v", err)
+	}
+	if sources, _ := repo.GetAll(ctx); len(sources) != 0 {
+		t.Errorf("Expected no sources after delete, got %d", len(sources))
+	}
+}
+
+func TestTimeRuleRepository(t *testing.T) {
+	config := DefaultConfig()
+	config.Path = filepath.Join(t.TempDir(), "test.db")
+
+	db, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.InitializeSchema(); err != nil {
+		t.Fatalf("Failed to initialize schema: %v", err)
+	}
+
+	ctx := context.Background()
+	list := &models.List{Name: "Games", Type: models.ListTypeBlacklist, Enabled: true}
+	if err := NewListRepository(db.Connection()).Create(ctx, list); err != nil {
+		t.Fatalf("Failed to create list: %v", err)
+	}
+
+	repo := NewTimeRuleRepository(db.Connection())
+	rule := &models.TimeRule{ListID: list.ID, Name: "School nights", RuleType: models.RuleTypeBlockDuring,
+		DaysOfWeek: []int{0, 1, 2, 3, 4}, StartTime: "21:00", EndTime: "07:00", Timezone: "America/Chicago", Enabled: true}
+	if err := repo.Create(ctx, rule); err != nil {
+		t.Fatalf("Failed to create time rule: %v", err)
+	}
+
+	got, err := repo.GetByID(ctx, rule.ID)
+	if err != nil {
+		t.Fatalf("Failed to load time rule: %v", err)
+	}
+	if got.Timezone != "America/Chicago" || got.StartTime != "21:00" || got.EndTime != "07:00" ||
+		got.RuleType != models.RuleTypeBlockDuring || len(got.DaysOfWeek) != 5 || got.DaysOfWeek[4] != 4 {
+		t.Errorf("Unexpected time rule %+v", got)
+	}
+
+	// The window is on Chicago's wall clock: 04:00 UTC on a Tuesday is
+	// 22:00 Monday there
+	inWindow := time.Date(2026, 3, 3, 4, 0, 0, 0, time.UTC)
+	outOfWindow := time.Date(2026, 3, 3, 18, 0, 0, 0, time.UTC)
+	if active, err := repo.GetActiveRules(ctx, inWindow); err != nil || len(active) != 1 {
+		t.Errorf("Expected the rule to be active at %s, got %v (%v)", inWindow, active, err)
+	}
+	if active, err := repo.GetActiveRules(ctx, outOfWindow); err != nil || len(active) != 0 {
+		t.Errorf("Expected no active rules at %s, got %v (%v)", outOfWindow, active, err)
+	}
+
+	got.Timezone = "Europe/Berlin"
+	got.Enabled = false
+	if err := repo.UpdateBatch(ctx, []models.TimeRule{*got}); err != nil {
+		t.Fatalf("Failed to update time rules: %v", err)
+	}
+	rules, err := repo.GetByListID(ctx, list.ID)
+	if err != nil || len(rules) != 1 || rules[0].Timezone != "Europe/Berlin" || rules[0].Enabled {
+		t.Errorf("Expected the updated zone and state to round-trip, got %+v (%v)", rules, err)
+	}
+	if enabled, _ := repo.GetEnabled(ctx); len(enabled) != 0 {
+		t.Errorf("Expected no enabled rules, got %d", len(enabled))
+	}
+
+	// A failed batch leaves every rule unchanged
+	missing := *got
+	missing.ID = rule.ID + 100
+	got.Name = "Renamed"
+	if err := repo.UpdateBatch(ctx, []models.TimeRule{*got, missing}); err == nil {
+		t.Error("Expected a batch with a missing rule to fail")
+	}
+	if reloaded, _ := repo.GetByID(ctx, rule.ID); reloaded.Name != "School nights" {
+		t.Errorf("Expected the failed batch to be rolled back, got %q", reloaded.Name)
+	}
+
+	if err := repo.DeleteByListID(ctx, list.ID); err != nil {
+		t.Fatalf("Failed to delete time rules: %v", err)
+	}
+	if count, _ := repo.Count(ctx); count != 0 {
+		t.Errorf("Expected no time rules after delete, got %d", count)
+	}
+}
+
+func TestQuotaUsageRepository(t *testing.T) {
+	config := DefaultConfig()
+	config.Path = filepath.Join(t.TempDir(), "test.db")
+
+	db, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.InitializeSchema(); err != nil {
+		t.Fatalf("Failed to initialize schema: %v", err)
+	}
+
+	ctx := context.Background()
+	list := &models.List{Name: "Games", Type: models.ListTypeBlacklist, Enabled: true}
+	if err := NewListRepository(db.Connection()).Create(ctx, list); err != nil {
+		t.Fatalf("Failed to create list: %v", err)
+	}
+	rule := &models.QuotaRule{ListID: list.ID, Name: "Daily games", QuotaType: models.QuotaTypeDaily, LimitSeconds: 3600, Enabled: true}
+	if err := NewQuotaRuleRepository(db.Connection()).Create(ctx, rule); err != nil {
+		t.Fatalf("Failed to create quota rule: %v", err)
+	}
 
-	// Verify that the default policies have the expected properties
-	var name string
-	var enabled bool
-	var priority int
-	err = db.Connection().QueryRow("SELECT name, enabled, priority FROM log_rotation_policies WHERE id = 1").Scan(&name, &enabled, &priority)
+	// Windows written in one zone are found from another
+	berlin, err := time.LoadLocation("Europe/Berlin")
 	if err != nil {
-		t.Errorf("Failed to query default rotation policy: %v", err)
+		t.Skipf("Time zone data not available: %v", err)
+	}
+	repo := NewQuotaUsageRepository(db.Connection())
+	day := time.Date(2026, 3, 2, 0, 0, 0, 0, berlin)
+	for i, used := range []int{600, 1200} {
+		start := day.AddDate(0, 0, i)
+		usage := &models.QuotaUsage{QuotaRuleID: rule.ID, PeriodStart: start, PeriodEnd: start.AddDate(0, 0, 1).Add(-time.Nanosecond),
+			UsedSeconds: used, CarriedSeconds: 300 * i}
+		if err := repo.Create(ctx, usage); err != nil {
+			t.Fatalf("Failed to create usage: %v", err)
+		}
+	}
+
+	noon := time.Date(2026, 3, 3, 11, 0, 0, 0, time.UTC)
+	current, err := repo.GetCurrentUsage(ctx, rule.ID, noon)
+	if err != nil || current.UsedSeconds != 1200 || current.CarriedSeconds != 300 || !current.PeriodStart.Equal(day.AddDate(0, 0, 1)) {
+		t.Fatalf("Expected the second window to be current, got %+v (%v)", current, err)
+	}
+	if err := repo.UpdateUsage(ctx, rule.ID, 60, noon); err != nil {
+		t.Fatalf("Failed to update usage: %v", err)
+	}
+	if current, _ = repo.GetByID(ctx, current.ID); current.UsedSeconds != 1260 {
+		t.Errorf("Expected 1260 seconds used, got %d", current.UsedSeconds)
 	}
+	if err := repo.UpdateUsage(ctx, rule.ID, 60, noon.AddDate(0, 0, 7)); err == nil {
+		t.Error("Expected an error updating usage outside any window")
+	}
+
+	previous, err := repo.GetUsageInPeriod(ctx, rule.ID, day, day.AddDate(0, 0, 1).Add(-time.Nanosecond))
+	if err != nil || previous.UsedSeconds != 600 {
+		t.Errorf("Expected the first window, got %+v (%v)", previous, err)
+	}
+
+	if err := repo.CleanupExpiredUsage(ctx, day.AddDate(0, 0, 1)); err != nil {
+		t.Fatalf("Failed to clean up usage: %v", err)
+	}
+	if usages, _ := repo.GetByQuotaRuleID(ctx, rule.ID); len(usages) != 1 || usages[0].ID != current.ID {
+		t.Errorf("Expected only the second window to remain, got %+v", usages)
+	}
+}
+
+func TestPerformanceHistoryRepository(t *testing.T) {
+	config := DefaultConfig()
+	config.Path = filepath.Join(t.TempDir(), "test.db")
 
-	if name != "Default Log Rotation" {
-		t.Errorf("Expected default policy name 'Default Log Rotation', got '%s'", name)
+	db, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
 	}
-	if !enabled {
-		t.Error("Expected default policy to be enabled")
+	defer db.Close()
+
+	if err := db.InitializeSchema(); err != nil {
+		t.Fatalf("Failed to initialize schema: %v", err)
 	}
-	if priority != 100 {
-		t.Errorf("Expected default policy priority 100, got %d", priority)
+
+	ctx := context.Background()
+	repo := NewPerformanceHistoryRepository(db.Connection())
+
+	now := time.Now().UTC().Truncate(time.Second)
+	for i := 3; i >= 0; i-- {
+		snapshot := &models.PerformanceSnapshot{Timestamp: now.Add(-time.Duration(i) * time.Hour), Metrics: `{"cpu_usage_percent":1}`}
+		if err := repo.SaveSnapshot(ctx, snapshot); err != nil {
+			t.Fatalf("Failed to save snapshot: %v", err)
+		}
 	}
 
-	// Verify emergency policy
-	err = db.Connection().QueryRow("SELECT name, enabled, priority FROM log_rotation_policies WHERE id = 2").Scan(&name, &enabled, &priority)
+	// The limit keeps the most recent snapshots, returned oldest first
+	snapshots, err := repo.GetSnapshotsByTimeRange(ctx, now.Add(-150*time.Minute), now, 2)
 	if err != nil {
-		t.Errorf("Failed to query emergency rotation policy: %v", err)
+		t.Fatalf("Failed to query snapshots: %v", err)
+	}
+	if len(snapshots) != 2 || !snapshots[0].Timestamp.Equal(now.Add(-time.Hour)) || !snapshots[1].Timestamp.Equal(now) {
+		t.Fatalf("Expected the last two snapshots oldest first, got %+v", snapshots)
 	}
 
-	if name != "Emergency Disk Space Protection" {
-		t.Errorf("Expected emergency policy name 'Emergency Disk Space Protection', got '%s'", name)
+	if err := repo.CleanupOldSnapshots(ctx, now.Add(-90*time.Minute)); err != nil {
+		t.Fatalf("Failed to cleanup snapshots: %v", err)
+	}
+	if snapshots, _ := repo.GetSnapshotsByTimeRange(ctx, time.Time{}, now, 0); len(snapshots) != 2 {
+		t.Errorf("Expected 2 snapshots after cleanup, got %d", len(snapshots))
+	}
+
+	open := &models.PerformanceAlertRecord{
+		ID: "high_cpu_usage_1", ThresholdName: "high_cpu_usage", Threshold: "{}",
+		Severity: "warning", CurrentValue: 90, Message: "CPU high", TriggeredAt: now.Add(-48 * time.Hour),
+	}
+	resolvedAt := now.Add(-47 * time.Hour)
+	resolved := &models.PerformanceAlertRecord{
+		ID: "high_disk_usage_1", ThresholdName: "high_disk_usage", Threshold: "{}",
+		Severity: "critical", CurrentValue: 95, Message: "Disk high", TriggeredAt: now.Add(-48 * time.Hour),
+		Resolved: true, ResolvedAt: &resolvedAt,
 	}
-	if !enabled {
-		t.Error("Expected emergency policy to be enabled")
+	for _, alert := range []*models.PerformanceAlertRecord{open, resolved} {
+		if err := repo.SaveAlert(ctx, alert); err != nil {
+			t.Fatalf("Failed to save alert: %v", err)
+		}
+	}
+
+	open.CurrentValue = 97
+	if err := repo.SaveAlert(ctx, open); err != nil {
+		t.Fatalf("Failed to update alert: %v", err)
+	}
+
+	// Unresolved alerts are returned however old they are
+	alerts, err := repo.GetAlerts(ctx, now.Add(-24*time.Hour))
+	if err != nil {
+		t.Fatalf("Failed to query alerts: %v", err)
+	}
+	if len(alerts) != 1 || alerts[0].ID != open.ID || alerts[0].CurrentValue != 97 {
+		t.Fatalf("Expected the updated open alert only, got %+v", alerts)
+	}
+
+	if err := repo.CleanupOldAlerts(ctx, now.Add(-24*time.Hour)); err != nil {
+		t.Fatalf("Failed to cleanup alerts: %v", err)
+	}
+	alerts, _ = repo.GetAlerts(ctx, time.Time{})
+	if len(alerts) != 1 || alerts[0].ID != open.ID {
+		t.Errorf("Expected cleanup to keep only the open alert, got %+v", alerts)
+	}
+}
+
+func TestListEntryUniqueness(t *testing.T) {
+	config := DefaultConfig()
+	config.Path = filepath.Join(t.TempDir(), "test.db")
+
+	db, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.InitializeSchema(); err != nil {
+		t.Fatalf("Failed to initialize schema: %v", err)
+	}
+
+	ctx := context.Background()
+	lists := NewListRepository(db.Connection())
+	entries := NewListEntryRepository(db.Connection())
+
+	list := &models.List{Name: "Blocked", Type: models.ListTypeBlacklist, Enabled: true}
+	if err := lists.Create(ctx, list); err != nil {
+		t.Fatalf("Failed to create list: %v", err)
+	}
+
+	newEntry := func() *models.ListEntry {
+		return &models.ListEntry{ListID: list.ID, EntryType: models.EntryTypeURL, Pattern: "ads.example.com", PatternType: models.PatternTypeDomain, Enabled: true}
+	}
+	if err := entries.Create(ctx, newEntry()); err != nil {
+		t.Fatalf("Failed to create entry: %v", err)
+	}
+
+	err = entries.Create(ctx, newEntry())
+	if !errors.Is(err, models.ErrDuplicateEntry) {
+		t.Fatalf("Expected ErrDuplicateEntry for a duplicate, got %v", err)
+	}
+
+	// The same pattern is allowed as a different entry type
+	executable := newEntry()
+	executable.EntryType = models.EntryTypeExecutable
+	executable.PatternType = models.PatternTypeExact
+	if err := entries.Create(ctx, executable); err != nil {
+		t.Errorf("Expected the same pattern as an executable to be allowed: %v", err)
+	}
+
+	// Duplicates left by older versions are removed by the migration
+	if _, err := db.Connection().Exec(`DROP INDEX idx_list_entries_unique`); err != nil {
+		t.Fatalf("Failed to drop unique index: %v", err)
+	}
+	if err := entries.Create(ctx, newEntry()); err != nil {
+		t.Fatalf("Failed to insert legacy duplicate: %v", err)
+	}
+	// A row differing only in pattern type is not a duplicate
+	exact := newEntry()
+	exact.PatternType = models.PatternTypeExact
+	if err := entries.Create(ctx, exact); err != nil {
+		t.Fatalf("Failed to insert exact entry: %v", err)
+	}
+	migration, err := migrationsFS.ReadFile("migrations/006_list_entry_unique.sql")
+	if err != nil {
+		t.Fatalf("Failed to read migration: %v", err)
+	}
+	if _, err := db.Connection().Exec(string(migration)); err != nil {
+		t.Fatalf("Failed to re-run migration: %v", err)
+	}
+	if count, _ := entries.CountByListID(ctx, list.ID); count != 3 {
+		t.Errorf("Expected duplicates to be removed leaving 3 entries, got %d", count)
+	}
+	kept, err := entries.GetByListID(ctx, list.ID)
+	if err != nil {
+		t.Fatalf("Failed to get entries: %v", err)
+	}
+	patternTypes := make(map[models.PatternType]bool)
+	for _, entry := range kept {
+		if entry.EntryType == models.EntryTypeURL && entry.Pattern == "ads.example.com" {
+			patternTypes[entry.PatternType] = true
+		}
+	}
+	if !patternTypes[models.PatternTypeDomain] || !patternTypes[models.PatternTypeExact] {
+		t.Errorf("Expected the domain and exact entries to both survive, got %v", patternTypes)
+	}
+
+	// Port entries are allowed by the rebuilt table, which keeps its unique index
+	port := &models.ListEntry{ListID: list.ID, EntryType: models.EntryTypeURL, Pattern: "udp/27015", PatternType: models.PatternTypePort, Enabled: true}
+	if err := entries.Create(ctx, port); err != nil {
+		t.Fatalf("Failed to create port entry: %v", err)
+	}
+	duplicate := *port
+	duplicate.ID = 0
+	if err := entries.Create(ctx, &duplicate); !errors.Is(err, models.ErrDuplicateEntry) {
+		t.Errorf("Expected ErrDuplicateEntry for a duplicate port entry, got %v", err)
+	}
+}
+
+func TestRegexListEntries(t *testing.T) {
+	config := DefaultConfig()
+	config.Path = filepath.Join(t.TempDir(), "test.db")
+
+	db, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.InitializeSchema(); err != nil {
+		t.Fatalf("Failed to initialize schema: %v", err)
+	}
+
+	ctx := context.Background()
+	list := &models.List{Name: "Blocked", Type: models.ListTypeBlacklist, Enabled: true}
+	if err := NewListRepository(db.Connection()).Create(ctx, list); err != nil {
+		t.Fatalf("Failed to create list: %v", err)
+	}
+
+	entries := NewListEntryRepository(db.Connection())
+	entry := &models.ListEntry{ListID: list.ID, EntryType: models.EntryTypeURL, Pattern: `^ads\d+\.example\.com$`, PatternType: models.PatternTypeRegex, Enabled: true}
+	if err := entries.Create(ctx, entry); err != nil {
+		t.Fatalf("Failed to create regex entry: %v", err)
+	}
+	cidr := &models.ListEntry{ListID: list.ID, EntryType: models.EntryTypeURL, Pattern: "10.0.0.0/8", PatternType: models.PatternTypeCIDR, Enabled: true}
+	if err := entries.Create(ctx, cidr); err != nil {
+		t.Fatalf("Failed to create CIDR entry: %v", err)
+	}
+	port := &models.ListEntry{ListID: list.ID, EntryType: models.EntryTypeURL, Pattern: "udp/27015", PatternType: models.PatternTypePort, Enabled: true}
+	if err := entries.Create(ctx, port); err != nil {
+		t.Fatalf("Failed to create port entry: %v", err)
+	}
+
+	// The rebuilt table keeps its unique index and timestamp trigger
+	duplicate := *entry
+	if err := entries.Create(ctx, &duplicate); !errors.Is(err, models.ErrDuplicateEntry) {
+		t.Errorf("Expected ErrDuplicateEntry for a duplicate regex, got %v", err)
+	}
+	for _, name := range []string{"idx_list_entries_unique", "idx_list_entries_source", "update_list_entries_timestamp"} {
+		var count int
+		if err := db.Connection().QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name = ?`, name).Scan(&count); err != nil || count != 1 {
+			t.Errorf("Expected %s to exist after the rebuild (count %d, err %v)", name, count, err)
+		}
+	}
+
+	// Unknown pattern types are still rejected
+	bad := &models.ListEntry{ListID: list.ID, EntryType: models.EntryTypeURL, Pattern: "x.example.com", PatternType: "glob", Enabled: true}
+	if err := entries.Create(ctx, bad); err == nil {
+		t.Error("Expected an unknown pattern type to be rejected")
+	}
+}
+
+func TestListMetadata(t *testing.T) {
+	config := DefaultConfig()
+	config.Path = filepath.Join(t.TempDir(), "test.db")
+
+	db, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.InitializeSchema(); err != nil {
+		t.Fatalf("Failed to initialize schema: %v", err)
+	}
+
+	ctx := context.Background()
+	lists := NewListRepository(db.Connection())
+	entries := NewListEntryRepository(db.Connection())
+
+	list := &models.List{Name: "Games", Type: models.ListTypeBlacklist, Label: "school nights", Notes: "Agreed with the kids in March", Enabled: true}
+	if err := lists.Create(ctx, list); err != nil {
+		t.Fatalf("Failed to create list: %v", err)
+	}
+	stored, err := lists.GetByID(ctx, list.ID)
+	if err != nil {
+		t.Fatalf("Failed to get list: %v", err)
+	}
+	if stored.Label != list.Label || stored.Notes != list.Notes {
+		t.Errorf("Expected list label and notes to round trip, got %q and %q", stored.Label, stored.Notes)
+	}
+
+	entry := &models.ListEntry{ListID: list.ID, EntryType: models.EntryTypeExecutable, Pattern: "steam", PatternType: models.PatternTypeExact, Label: "games", Notes: "Homework first", Enabled: true}
+	if err := entries.Create(ctx, entry); err != nil {
+		t.Fatalf("Failed to create entry: %v", err)
+	}
+	storedEntry, err := entries.GetByID(ctx, entry.ID)
+	if err != nil {
+		t.Fatalf("Failed to get entry: %v", err)
+	}
+	if storedEntry.Label != "games" || storedEntry.Notes != "Homework first" || storedEntry.Source != models.EntrySourceManual {
+		t.Errorf("Expected label, notes and manual source, got %+v", storedEntry)
+	}
+
+	batch := []models.ListEntry{{ListID: list.ID, EntryType: models.EntryTypeURL, Pattern: "games.example.com", PatternType: models.PatternTypeDomain, Source: models.EntrySourceImport, Enabled: true}}
+	if _, err := entries.CreateBatch(ctx, batch); err != nil {
+		t.Fatalf("Failed to create batch: %v", err)
+	}
+	imported, err := entries.GetByPattern(ctx, "games.example.com", models.EntryTypeURL)
+	if err != nil || len(imported) != 1 || imported[0].Source != models.EntrySourceImport {
+		t.Errorf("Expected one imported entry, got %+v (%v)", imported, err)
+	}
+}
+
+func TestReadReplicaIsReadOnly(t *testing.T) {
+	config := DefaultConfig()
+	config.Path = filepath.Join(t.TempDir(), "test.db")
+
+	db, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.InitializeSchema(); err != nil {
+		t.Fatalf("Failed to initialize schema: %v", err)
+	}
+
+	if db.ReadConnection() == db.Connection() {
+		t.Fatal("Expected a separate read connection in WAL mode")
+	}
+
+	if _, err := db.ReadConnection().Exec("INSERT INTO config (key, value) VALUES ('replica_test', 'x')"); err == nil {
+		t.Error("Expected writes through the read connection to fail")
+	}
+
+	// Committed writes are visible to the read pool
+	if _, err := db.Connection().Exec("INSERT INTO config (key, value) VALUES ('replica_test', 'x')"); err != nil {
+		t.Fatalf("Failed to write through the main connection: %v", err)
+	}
+	var value string
+	if err := db.ReadConnection().QueryRow("SELECT value FROM config WHERE key = 'replica_test'").Scan(&value); err != nil || value != "x" {
+		t.Errorf("Expected the read connection to see committed data, got %q (%v)", value, err)
+	}
+
+	stats, _ := db.GetStats()
+	if stats["read_replica"] != true {
+		t.Error("Expected read replica to be reported in stats")
+	}
+
+	config.Path = filepath.Join(t.TempDir(), "rollback.db")
+	config.EnableWAL = false
+	plain, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer plain.Close()
+	if plain.ReadConnection() != plain.Connection() {
+		t.Error("Expected reads to share the main connection without WAL")
+	}
+}
+
+func TestAuditLogCleanupFiltered(t *testing.T) {
+	config := DefaultConfig()
+	config.Path = filepath.Join(t.TempDir(), "test.db")
+
+	db, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.InitializeSchema(); err != nil {
+		t.Fatalf("Failed to initialize schema: %v", err)
+	}
+
+	ctx := context.Background()
+	repo := NewAuditLogRepository(db.Connection())
+
+	now := time.Now().UTC()
+	for i, action := range []models.ActionType{models.ActionTypeAllow, models.ActionTypeBlock, models.ActionTypeAllow, models.ActionTypeAllow} {
+		log := &models.AuditLog{
+			Timestamp:   now.Add(-time.Duration(40-i) * 24 * time.Hour),
+			EventType:   "enforcement_action",
+			TargetType:  models.TargetTypeURL,
+			TargetValue: "example.com",
+			Action:      action,
+		}
+		if i == 3 {
+			log.Timestamp = now
+		}
+		if err := repo.Create(ctx, log); err != nil {
+			t.Fatalf("Failed to create audit log: %v", err)
+		}
+	}
+
+	cutoff := now.Add(-30 * 24 * time.Hour)
+	filter := models.AuditLogCleanupFilter{Actions: []string{string(models.ActionTypeAllow)}}
+
+	count, bytes, err := repo.CountOldLogsFiltered(ctx, cutoff, filter)
+	if err != nil {
+		t.Fatalf("Failed to count old logs: %v", err)
+	}
+	if count != 2 || bytes <= 0 {
+		t.Fatalf("Expected 2 old allow entries with a positive size, got %d (%d bytes)", count, bytes)
+	}
+
+	average, err := repo.AverageEntrySize(ctx, 100)
+	if err != nil {
+		t.Fatalf("Failed to sample entry size: %v", err)
+	}
+	if average != bytes/2 {
+		t.Errorf("Expected equal sized entries to average %d bytes, got %d", bytes/2, average)
+	}
+
+	// The limit deletes the oldest matching entry only
+	deleted, freed, err := repo.CleanupOldLogsFiltered(ctx, cutoff, filter, 1)
+	if err != nil {
+		t.Fatalf("Failed to cleanup old logs: %v", err)
+	}
+	if deleted != 1 || freed != bytes/2 {
+		t.Errorf("Expected 1 entry and %d bytes freed, got %d and %d", bytes/2, deleted, freed)
+	}
+
+	deleted, freed, err = repo.CleanupOldLogsFiltered(ctx, cutoff, filter, 0)
+	if err != nil {
+		t.Fatalf("Failed to cleanup old logs: %v", err)
+	}
+	if deleted != 1 || freed != bytes/2 {
+		t.Errorf("Expected 1 entry and %d bytes freed, got %d and %d", bytes/2, deleted, freed)
+	}
+
+	// The old block entry and the recent allow entry are kept
+	logs, err := repo.GetAll(ctx, 10, 0)
+	if err != nil {
+		t.Fatalf("Failed to list audit logs: %v", err)
+	}
+	if len(logs) != 2 {
+		t.Fatalf("Expected 2 entries left, got %d", len(logs))
+	}
+	for _, log := range logs {
+		if log.Action == models.ActionTypeAllow && log.Timestamp.Before(cutoff) {
+			t.Errorf("Expected old allow entries to be deleted, found %+v", log)
+		}
+	}
+}
+
+func TestAuditLogQueryFilters(t *testing.T) {
+	config := DefaultConfig()
+	config.Path = filepath.Join(t.TempDir(), "test.db")
+
+	db, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.InitializeSchema(); err != nil {
+		t.Fatalf("Failed to initialize schema: %v", err)
+	}
+
+	ctx := context.Background()
+	repo := NewAuditLogRepository(db.Connection())
+
+	now := time.Now().UTC()
+	logs := []models.AuditLog{
+		{TargetType: models.TargetTypeExecutable, TargetValue: "steam", Action: models.ActionTypeBlock},
+		{TargetType: models.TargetTypeURL, TargetValue: "games.example.com", Action: models.ActionTypeBlock,
+			Details: `{"process_name":"steam"}`},
+		{TargetType: models.TargetTypeURL, TargetValue: "news.example.com", Action: models.ActionTypeAllow,
+			Details: `{"process_name":"firefox"}`},
+		{TargetType: models.TargetTypeURL, TargetValue: "games.example.org", Action: models.ActionTypeBlock,
+			Details: "not json"},
+	}
+	for i := range logs {
+		logs[i].Timestamp = now.Add(-time.Duration(len(logs)-i) * time.Hour)
+		logs[i].EventType = "enforcement_action"
+		if err := repo.Create(ctx, &logs[i]); err != nil {
+			t.Fatalf("Failed to create audit log: %v", err)
+		}
+	}
+
+	block := models.ActionTypeBlock
+	since := now.Add(-3 * time.Hour)
+	tests := []struct {
+		name    string
+		filters AuditLogFilters
+		want    []string // target values, newest first
+	}{
+		{"process name from target or details", AuditLogFilters{ProcessName: "steam"}, []string{"games.example.com", "steam"}},
+		{"action and search", AuditLogFilters{Action: &block, Search: "games"}, []string{"games.example.org", "games.example.com"}},
+		{"time range", AuditLogFilters{StartTime: &since}, []string{"games.example.org", "news.example.com", "games.example.com"}},
+		{"paged", AuditLogFilters{Action: &block, Limit: 1, Offset: 1}, []string{"games.example.com"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found, err := repo.GetByFilters(ctx, tt.filters)
+			if err != nil {
+				t.Fatalf("GetByFilters failed: %v", err)
+			}
+			var got []string
+			for _, log := range found {
+				got = append(got, log.TargetValue)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	// The total ignores paging
+	count, err := repo.CountByFilters(ctx, AuditLogFilters{Action: &block, Limit: 1, Offset: 1})
+	if err != nil {
+		t.Fatalf("CountByFilters failed: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("Expected 3 block entries, got %d", count)
+	}
+
+	// Filtering by process name uses the expression index
+	var plan, detail string
+	var id, parent, notUsed int
+	rows, err := db.Connection().QueryContext(ctx, "EXPLAIN QUERY PLAN SELECT id FROM audit_log WHERE "+auditProcessName+" = ?", "steam")
+	if err != nil {
+		t.Fatalf("Failed to explain query: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		if err := rows.Scan(&id, &parent, &notUsed, &detail); err != nil {
+			t.Fatalf("Failed to scan query plan: %v", err)
+		}
+		plan += detail + "\n"
+	}
+	if !strings.Contains(plan, "idx_audit_log_process_name") {
+		t.Errorf("Expected the process name index to be used, got plan:\n%s", plan)
+	}
+}
+
+func TestAuditLogAdminActions(t *testing.T) {
+	config := DefaultConfig()
+	config.Path = filepath.Join(t.TempDir(), "test.db")
+
+	db, err := New(config)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.InitializeSchema(); err != nil {
+		t.Fatalf("Failed to initialize schema: %v", err)
+	}
+
+	ctx := context.Background()
+	repo := NewAuditLogRepository(db.Connection())
+
+	reset := &models.AuditLog{Timestamp: time.Now(), EventType: "system_event",
+		TargetType: models.TargetTypeAccount, TargetValue: "admin", Action: models.ActionTypeAdminReset}
+	if err := repo.Create(ctx, reset); err != nil {
+		t.Fatalf("Failed to record an admin reset: %v", err)
+	}
+	if err := repo.Create(ctx, &models.AuditLog{Timestamp: time.Now(), EventType: "system_event",
+		TargetType: models.TargetTypeAccount, TargetValue: "admin", Action: "delete"}); err == nil {
+		t.Error("Expected an unknown action to be rejected")
+	}
+
+	action := models.ActionTypeAdminReset
+	account := models.TargetTypeAccount
+	found, err := repo.GetByFilters(ctx, AuditLogFilters{Action: &action, TargetType: &account})
+	if err != nil || len(found) != 1 || found[0].TargetValue != "admin" {
+		t.Errorf("Expected the admin reset to be found, got %+v (%v)", found, err)
+	}
+}
+
+func TestMigrations(t *testing.T) {
+	migrations, err := Migrations()
+	if err != nil {
+		t.Fatalf("Failed to read migrations: %v", err)
+	}
+
+	// Versions are contiguous from 1 and only the initial schema is one-way
+	for i, migration := range migrations {
+		if migration.Version != i+1 {
+			t.Fatalf("Expected migration version %d, got %s", i+1, migration)
+		}
+		if reversible := migration.Version != 1; migration.Reversible() != reversible {
+			t.Errorf("Expected %s reversible=%v", migration, reversible)
+		}
+	}
+}
+
+func TestMigrateDownAndUp(t *testing.T) {
+	db, err := New(Config{Path: filepath.Join(t.TempDir(), "test.db"), MaxOpenConns: 1, EnableWAL: true})
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.InitializeSchema(); err != nil {
+		t.Fatalf("Failed to initialize schema: %v", err)
+	}
+	status, err := db.MigrationStatus()
+	if err != nil {
+		t.Fatalf("Failed to get migration status: %v", err)
+	}
+	if status.Current != status.Latest || len(status.Pending) != 0 {
+		t.Fatalf("Expected a fully migrated database, got %d of %d", status.Current, status.Latest)
+	}
+
+	schema := func() string {
+		rows, err := db.Connection().Query(`SELECT type, name, COALESCE(sql, '') FROM sqlite_master
+			WHERE name NOT LIKE 'sqlite_%' ORDER BY type, name`)
+		if err != nil {
+			t.Fatalf("Failed to read schema: %v", err)
+		}
+		defer rows.Close()
+
+		var b strings.Builder
+		for rows.Next() {
+			var kind, name, sql string
+			if err := rows.Scan(&kind, &name, &sql); err != nil {
+				t.Fatalf("Failed to scan schema: %v", err)
+			}
+			fmt.Fprintf(&b, "%s %s: %s\n", kind, name, sql)
+		}
+		return b.String()
+	}
+	migrated := schema()
+
+	// The initial schema cannot be rolled back, so nothing changes
+	if _, err := db.MigrateDown(status.Latest); err == nil || !strings.Contains(err.Error(), "cannot be rolled back") {
+		t.Fatalf("Expected the initial schema to be irreversible, got %v", err)
+	}
+	if version, _ := db.getCurrentSchemaVersion(); version != status.Latest {
+		t.Fatalf("Expected a refused rollback to leave version %d, got %d", status.Latest, version)
+	}
+
+	reverted, err := db.MigrateDown(status.Latest - 1)
+	if err != nil {
+		t.Fatalf("Failed to roll back: %v", err)
+	}
+	if len(reverted) != status.Latest-1 || reverted[0].Version != status.Latest {
+		t.Fatalf("Expected newest-first rollback of %d migrations, got %v", status.Latest-1, reverted)
+	}
+	if version, _ := db.getCurrentSchemaVersion(); version != 1 {
+		t.Fatalf("Expected schema version 1, got %d", version)
+	}
+
+	applied, err := db.MigrateUp(2)
+	if err != nil || len(applied) != 2 || applied[0].Version != 2 {
+		t.Fatalf("Expected migrations 2 and 3 to be applied, got %v (%v)", applied, err)
+	}
+	if _, err := db.MigrateUp(0); err != nil {
+		t.Fatalf("Failed to migrate up: %v", err)
+	}
+
+	if got := schema(); got != migrated {
+		t.Errorf("Schema after rolling back and reapplying differs:\n%s\nexpected:\n%s", got, migrated)
+	}
+}
+
+func TestBackup(t *testing.T) {
+	dir := t.TempDir()
+	db, err := New(Config{Path: filepath.Join(dir, "test.db"), MaxOpenConns: 2, EnableWAL: true})
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.InitializeSchema(); err != nil {
+		t.Fatalf("Failed to initialize schema: %v", err)
+	}
+	if _, err := db.Connection().Exec(`INSERT INTO config (key, value) VALUES ('backup_test', 'kept')`); err != nil {
+		t.Fatalf("Failed to insert data: %v", err)
+	}
+
+	backupDir := filepath.Join(dir, "backups")
+	base := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	for i, reason := range []string{"manual", "pre-migration", "manual"} {
+		path := filepath.Join(backupDir, BackupFileName(base.Add(time.Duration(i)*time.Hour), reason))
+		if err := db.Backup(context.Background(), path); err != nil {
+			t.Fatalf("Failed to back up: %v", err)
+		}
+	}
+	// Unrelated files are neither listed nor pruned
+	if err := os.WriteFile(filepath.Join(backupDir, "notes.txt"), nil, 0600); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	backups, err := ListBackups(backupDir)
+	if err != nil {
+		t.Fatalf("Failed to list backups: %v", err)
+	}
+	if len(backups) != 3 || !backups[0].CreatedAt.Equal(base.Add(2*time.Hour)) || backups[1].Reason != "pre-migration" {
+		t.Fatalf("Expected 3 backups newest first, got %+v", backups)
+	}
+
+	info, err := os.Stat(backups[0].Path)
+	if err != nil {
+		t.Fatalf("Failed to stat backup: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected backup mode 0600, got %v", info.Mode().Perm())
+	}
+
+	// The backup is a complete database, including data still in the WAL
+	restored, err := New(Config{Path: backups[0].Path, MaxOpenConns: 1})
+	if err != nil {
+		t.Fatalf("Failed to open backup: %v", err)
+	}
+	var value string
+	err = restored.Connection().QueryRow(`SELECT value FROM config WHERE key = 'backup_test'`).Scan(&value)
+	restored.Close()
+	if err != nil || value != "kept" {
+		t.Errorf("Expected the backup to contain the inserted row, got %q (%v)", value, err)
+	}
+
+	pruned, err := PruneBackups(backupDir, 1)
+	if err != nil {
+		t.Fatalf("Failed to prune backups: %v", err)
+	}
+	if len(pruned) != 2 || pruned[0] != backups[1].Name {
+		t.Errorf("Expected the 2 oldest backups to be pruned, got %v", pruned)
+	}
+	if remaining, _ := ListBackups(backupDir); len(remaining) != 1 || remaining[0].Name != backups[0].Name {
+		t.Errorf("Expected only the newest backup to remain, got %+v", remaining)
+	}
+	if _, err := os.Stat(filepath.Join(backupDir, "notes.txt")); err != nil {
+		t.Errorf("Expected unrelated files to be left alone: %v", err)
+	}
+}
+
+func TestBackupBeforeMigrating(t *testing.T) {
+	dir := t.TempDir()
+	backupDir := filepath.Join(dir, "backups")
+	db, err := New(Config{Path: filepath.Join(dir, "test.db"), MaxOpenConns: 1, EnableWAL: true, MigrationBackupDir: backupDir})
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	// A new database has nothing to lose
+	if err := db.InitializeSchema(); err != nil {
+		t.Fatalf("Failed to initialize schema: %v", err)
+	}
+	if backups, _ := ListBackups(backupDir); len(backups) != 0 {
+		t.Fatalf("Expected no backup of an empty database, got %+v", backups)
+	}
+
+	if _, err := db.MigrateDown(1); err != nil {
+		t.Fatalf("Failed to roll back: %v", err)
+	}
+	if _, err := db.MigrateUp(0); err != nil {
+		t.Fatalf("Failed to migrate up: %v", err)
+	}
+	// Nothing pending, so no backup
+	if _, err := db.MigrateUp(0); err != nil {
+		t.Fatalf("Failed to migrate up: %v", err)
+	}
+
+	backups, err := ListBackups(backupDir)
+	if err != nil {
+		t.Fatalf("Failed to list backups: %v", err)
+	}
+	if len(backups) != 2 {
+		t.Fatalf("Expected a backup before each migration run, got %+v", backups)
+	}
+	for _, backup := range backups {
+		if backup.Reason != BackupReasonMigration {
+			t.Errorf("Expected reason %q, got %q", BackupReasonMigration, backup.Reason)
+		}
+	}
+}
+
+func TestCheckpointAndVacuum(t *testing.T) {
+	dir := t.TempDir()
+	// Idle connections keep the WAL open; the last one to close removes it
+	db, err := New(Config{Path: filepath.Join(dir, "test.db"), MaxOpenConns: 2, MaxIdleConns: 2, EnableWAL: true})
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.InitializeSchema(); err != nil {
+		t.Fatalf("Failed to initialize schema: %v", err)
+	}
+
+	ctx := context.Background()
+	value := strings.Repeat("x", 2000)
+	for i := 0; i < 500; i++ {
+		if _, err := db.Connection().Exec(`INSERT INTO config (key, value) VALUES (?, ?)`, fmt.Sprintf("filler_%d", i), value); err != nil {
+			t.Fatalf("Failed to insert data: %v", err)
+		}
+	}
+
+	checkpoint, err := db.Checkpoint(ctx)
+	if err != nil {
+		t.Fatalf("Checkpoint failed: %v", err)
+	}
+	if checkpoint.Busy || checkpoint.WALSizeBefore == 0 || checkpoint.CheckpointedFrames != checkpoint.WALFrames {
+		t.Errorf("Expected the whole WAL to be checkpointed, got %+v", checkpoint)
+	}
+	if size := fileSize(db.walPath()); size != 0 {
+		t.Errorf("Expected the WAL to be truncated, got %d bytes", size)
+	}
+
+	if _, err := db.Connection().Exec(`DELETE FROM config WHERE key LIKE 'filler_%'`); err != nil {
+		t.Fatalf("Failed to delete data: %v", err)
+	}
+	free, total, err := db.FreePages(ctx)
+	if err != nil {
+		t.Fatalf("Failed to read free pages: %v", err)
+	}
+	if free == 0 || free >= total {
+		t.Fatalf("Expected the deletion to free pages, got %d of %d", free, total)
+	}
+
+	var reasons []string
+	db.SetMaintenanceHook(func(reason string) func() {
+		reasons = append(reasons, reason)
+		return func() {}
+	})
+
+	vacuum, err := db.Vacuum(ctx)
+	if err != nil {
+		t.Fatalf("Vacuum failed: %v", err)
+	}
+	if vacuum.SizeAfter >= vacuum.SizeBefore {
+		t.Errorf("Expected the vacuum to shrink the database, got %+v", vacuum)
+	}
+	if free, _, _ := db.FreePages(ctx); free != 0 {
+		t.Errorf("Expected no free pages after the vacuum, got %d", free)
+	}
+	if len(reasons) != 1 || reasons[0] != "database vacuum" {
+		t.Errorf("Expected the maintenance hook to be told about the vacuum, got %v", reasons)
+	}
+}
+
+func TestSlowQueryLogging(t *testing.T) {
+	dir := t.TempDir()
+	// Every statement is slower than a nanosecond
+	db, err := New(Config{Path: filepath.Join(dir, "test.db"), MaxOpenConns: 2, EnableWAL: true,
+		ReadReplica: true, SlowQueryThreshold: time.Nanosecond})
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.InitializeSchema(); err != nil {
+		t.Fatalf("Failed to initialize schema: %v", err)
+	}
+
+	ctx := context.Background()
+	count := func(t *testing.T, what string, want int64, run func() error) {
+		t.Helper()
+		before := db.SlowQueries()
+		if err := run(); err != nil {
+			t.Fatalf("%s failed: %v", what, err)
+		}
+		if got := db.SlowQueries() - before; got != want {
+			t.Errorf("Expected %s to count %d slow statements, got %d", what, want, got)
+		}
+	}
+
+	count(t, "an exec", 1, func() error {
+		_, err := db.Connection().ExecContext(ctx, `INSERT INTO config (key, value) VALUES ('slow_test', 'x')`)
+		return err
+	})
+	count(t, "a query on the read pool", 1, func() error {
+		rows, err := db.ReadConnection().QueryContext(ctx, `SELECT key, value FROM config`)
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+		}
+		return rows.Close()
+	})
+	count(t, "a prepared statement run twice", 2, func() error {
+		tx, err := db.Connection().BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+		stmt, err := tx.PrepareContext(ctx, `UPDATE config SET value = ? WHERE key = 'slow_test'`)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+		for _, value := range []string{"y", "z"} {
+			if _, err := stmt.ExecContext(ctx, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	// The online backup reaches the sqlite3 connection under the wrapper
+	if err := db.Backup(ctx, filepath.Join(dir, "backup.db")); err != nil {
+		t.Errorf("Backup through the slow query pool failed: %v", err)
+	}
+	if stats, _ := db.GetStats(); stats["slow_queries"].(int64) == 0 {
+		t.Errorf("Expected the stats to report slow queries, got %v", stats["slow_queries"])
+	}
+}
+
+func TestCompactQuery(t *testing.T) {
+	if got := compactQuery("\n\t\tSELECT id\n\t\tFROM audit_log\n\t\tWHERE timestamp < ?\n\t"); got != "SELECT id FROM audit_log WHERE timestamp < ?" {
+		t.Errorf("Expected the query on one line, got %q", got)
 	}
-	if priority != 200 {
-		t.Errorf("Expected emergency policy priority 200, got %d", priority)
+	if got := compactQuery(strings.Repeat("x", 600)); len(got) != maxLoggedQueryLength+3 || !strings.HasSuffix(got, "...") {
+		t.Errorf("Expected a long query to be truncated, got %d characters", len(got))
 	}
 }
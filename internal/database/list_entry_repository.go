@@ -3,9 +3,12 @@ package database
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/mattn/go-sqlite3"
+
 	"parental-control/internal/models"
 )
 
@@ -22,13 +25,16 @@ func NewListEntryRepository(db *sql.DB) *ListEntryRepository {
 // Create creates a new list entry
 func (r *ListEntryRepository) Create(ctx context.Context, entry *models.ListEntry) error {
 	query := `
-		INSERT INTO list_entries (list_id, entry_type, pattern, pattern_type, description, enabled, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO list_entries (list_id, entry_type, pattern, pattern_type, description, label, notes, source, enabled, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	now := time.Now()
 	entry.CreatedAt = now
 	entry.UpdatedAt = now
+	if entry.Source == "" {
+		entry.Source = models.EntrySourceManual
+	}
 
 	result, err := r.db.ExecContext(ctx, query,
 		entry.ListID,
@@ -36,12 +42,15 @@ func (r *ListEntryRepository) Create(ctx context.Context, entry *models.ListEntr
 		entry.Pattern,
 		entry.PatternType,
 		entry.Description,
+		entry.Label,
+		entry.Notes,
+		entry.Source,
 		entry.Enabled,
 		entry.CreatedAt,
 		entry.UpdatedAt,
 	)
 	if err != nil {
-		return fmt.Errorf("failed to create list entry: %w", err)
+		return fmt.Errorf("failed to create list entry: %w", duplicateEntryError(err))
 	}
 
 	id, err := result.LastInsertId()
@@ -53,10 +62,77 @@ func (r *ListEntryRepository) Create(ctx context.Context, entry *models.ListEntr
 	return nil
 }
 
+// CreateBatch inserts entries in a single transaction. Entries whose
+// pattern, pattern type and entry type already exist in the same list are
+// skipped.
+func (r *ListEntryRepository) CreateBatch(ctx context.Context, entries []models.ListEntry) (int, error) {
+	if len(entries) == 0 {
+		return 0, nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin list entry batch: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO list_entries (list_id, entry_type, pattern, pattern_type, description, label, notes, source, enabled, created_at, updated_at)
+		SELECT ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+		WHERE NOT EXISTS (
+			SELECT 1 FROM list_entries WHERE pattern = ? AND list_id = ? AND entry_type = ? AND pattern_type = ?
+		)
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare list entry batch: %w", err)
+	}
+	defer stmt.Close()
+
+	now := time.Now()
+	inserted := 0
+	for _, entry := range entries {
+		if entry.Source == "" {
+			entry.Source = models.EntrySourceManual
+		}
+		result, err := stmt.ExecContext(ctx,
+			entry.ListID,
+			entry.EntryType,
+			entry.Pattern,
+			entry.PatternType,
+			entry.Description,
+			entry.Label,
+			entry.Notes,
+			entry.Source,
+			entry.Enabled,
+			now,
+			now,
+			entry.Pattern,
+			entry.ListID,
+			entry.EntryType,
+			entry.PatternType,
+		)
+		if err != nil {
+			return 0, fmt.Errorf("failed to insert list entry %q: %w", entry.Pattern, err)
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return 0, fmt.Errorf("failed to get affected rows: %w", err)
+		}
+		inserted += int(affected)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit list entry batch: %w", err)
+	}
+
+	return inserted, nil
+}
+
 // GetByID retrieves a list entry by ID
 func (r *ListEntryRepository) GetByID(ctx context.Context, id int) (*models.ListEntry, error) {
 	query := `
-		SELECT id, list_id, entry_type, pattern, pattern_type, description, enabled, created_at, updated_at
+		SELECT id, list_id, entry_type, pattern, pattern_type, description, label, notes, source, enabled, created_at, updated_at
 		FROM list_entries
 		WHERE id = ?
 	`
@@ -69,6 +145,9 @@ func (r *ListEntryRepository) GetByID(ctx context.Context, id int) (*models.List
 		&entry.Pattern,
 		&entry.PatternType,
 		&entry.Description,
+		&entry.Label,
+		&entry.Notes,
+		&entry.Source,
 		&entry.Enabled,
 		&entry.CreatedAt,
 		&entry.UpdatedAt,
@@ -87,7 +166,7 @@ func (r *ListEntryRepository) GetByID(ctx context.Context, id int) (*models.List
 // GetByListID retrieves all entries for a specific list
 func (r *ListEntryRepository) GetByListID(ctx context.Context, listID int) ([]models.ListEntry, error) {
 	query := `
-		SELECT id, list_id, entry_type, pattern, pattern_type, description, enabled, created_at, updated_at
+		SELECT id, list_id, entry_type, pattern, pattern_type, description, label, notes, source, enabled, created_at, updated_at
 		FROM list_entries
 		WHERE list_id = ?
 		ORDER BY pattern ASC
@@ -99,7 +178,7 @@ func (r *ListEntryRepository) GetByListID(ctx context.Context, listID int) ([]mo
 // GetByPattern retrieves entries by pattern and type
 func (r *ListEntryRepository) GetByPattern(ctx context.Context, pattern string, entryType models.EntryType) ([]models.ListEntry, error) {
 	query := `
-		SELECT id, list_id, entry_type, pattern, pattern_type, description, enabled, created_at, updated_at
+		SELECT id, list_id, entry_type, pattern, pattern_type, description, label, notes, source, enabled, created_at, updated_at
 		FROM list_entries
 		WHERE pattern = ? AND entry_type = ?
 		ORDER BY pattern ASC
@@ -111,7 +190,7 @@ func (r *ListEntryRepository) GetByPattern(ctx context.Context, pattern string,
 // GetEnabled retrieves all enabled list entries
 func (r *ListEntryRepository) GetEnabled(ctx context.Context) ([]models.ListEntry, error) {
 	query := `
-		SELECT id, list_id, entry_type, pattern, pattern_type, description, enabled, created_at, updated_at
+		SELECT id, list_id, entry_type, pattern, pattern_type, description, label, notes, source, enabled, created_at, updated_at
 		FROM list_entries
 		WHERE enabled = 1
 		ORDER BY pattern ASC
@@ -124,7 +203,7 @@ func (r *ListEntryRepository) GetEnabled(ctx context.Context) ([]models.ListEntr
 func (r *ListEntryRepository) Update(ctx context.Context, entry *models.ListEntry) error {
 	query := `
 		UPDATE list_entries SET
-			pattern = ?, pattern_type = ?, description = ?, enabled = ?, updated_at = ?
+			pattern = ?, pattern_type = ?, description = ?, label = ?, notes = ?, enabled = ?, updated_at = ?
 		WHERE id = ?
 	`
 
@@ -134,12 +213,14 @@ func (r *ListEntryRepository) Update(ctx context.Context, entry *models.ListEntr
 		entry.Pattern,
 		entry.PatternType,
 		entry.Description,
+		entry.Label,
+		entry.Notes,
 		entry.Enabled,
 		entry.UpdatedAt,
 		entry.ID,
 	)
 	if err != nil {
-		return fmt.Errorf("failed to update list entry: %w", err)
+		return fmt.Errorf("failed to update list entry: %w", duplicateEntryError(err))
 	}
 
 	rowsAffected, err := result.RowsAffected()
@@ -231,6 +312,9 @@ func (r *ListEntryRepository) queryEntries(ctx context.Context, query string, ar
 			&entry.Pattern,
 			&entry.PatternType,
 			&entry.Description,
+			&entry.Label,
+			&entry.Notes,
+			&entry.Source,
 			&entry.Enabled,
 			&entry.CreatedAt,
 			&entry.UpdatedAt,
@@ -247,3 +331,13 @@ func (r *ListEntryRepository) queryEntries(ctx context.Context, query string, ar
 
 	return entries, nil
 }
+
+// duplicateEntryError maps a unique constraint violation to
+// models.ErrDuplicateEntry and returns other errors unchanged
+func duplicateEntryError(err error) error {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+		return models.ErrDuplicateEntry
+	}
+	return err
+}
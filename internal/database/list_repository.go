@@ -22,8 +22,8 @@ func NewListRepository(db *sql.DB) *ListRepository {
 // Create creates a new list
 func (r *ListRepository) Create(ctx context.Context, list *models.List) error {
 	query := `
-		INSERT INTO lists (name, type, description, enabled, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?)
+		INSERT INTO lists (name, type, description, label, notes, enabled, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	now := time.Now()
@@ -34,6 +34,8 @@ func (r *ListRepository) Create(ctx context.Context, list *models.List) error {
 		list.Name,
 		list.Type,
 		list.Description,
+		list.Label,
+		list.Notes,
 		list.Enabled,
 		list.CreatedAt,
 		list.UpdatedAt,
@@ -54,7 +56,7 @@ func (r *ListRepository) Create(ctx context.Context, list *models.List) error {
 // GetByID retrieves a list by ID
 func (r *ListRepository) GetByID(ctx context.Context, id int) (*models.List, error) {
 	query := `
-		SELECT id, name, type, description, enabled, created_at, updated_at
+		SELECT id, name, type, description, label, notes, enabled, created_at, updated_at
 		FROM lists
 		WHERE id = ?
 	`
@@ -65,6 +67,8 @@ func (r *ListRepository) GetByID(ctx context.Context, id int) (*models.List, err
 		&list.Name,
 		&list.Type,
 		&list.Description,
+		&list.Label,
+		&list.Notes,
 		&list.Enabled,
 		&list.CreatedAt,
 		&list.UpdatedAt,
@@ -83,7 +87,7 @@ func (r *ListRepository) GetByID(ctx context.Context, id int) (*models.List, err
 // GetByName retrieves a list by name
 func (r *ListRepository) GetByName(ctx context.Context, name string) (*models.List, error) {
 	query := `
-		SELECT id, name, type, description, enabled, created_at, updated_at
+		SELECT id, name, type, description, label, notes, enabled, created_at, updated_at
 		FROM lists
 		WHERE name = ?
 	`
@@ -94,6 +98,8 @@ func (r *ListRepository) GetByName(ctx context.Context, name string) (*models.Li
 		&list.Name,
 		&list.Type,
 		&list.Description,
+		&list.Label,
+		&list.Notes,
 		&list.Enabled,
 		&list.CreatedAt,
 		&list.UpdatedAt,
@@ -112,7 +118,7 @@ func (r *ListRepository) GetByName(ctx context.Context, name string) (*models.Li
 // GetAll retrieves all lists
 func (r *ListRepository) GetAll(ctx context.Context) ([]models.List, error) {
 	query := `
-		SELECT id, name, type, description, enabled, created_at, updated_at
+		SELECT id, name, type, description, label, notes, enabled, created_at, updated_at
 		FROM lists
 		ORDER BY name ASC
 	`
@@ -123,7 +129,7 @@ func (r *ListRepository) GetAll(ctx context.Context) ([]models.List, error) {
 // GetByType retrieves lists by type
 func (r *ListRepository) GetByType(ctx context.Context, listType models.ListType) ([]models.List, error) {
 	query := `
-		SELECT id, name, type, description, enabled, created_at, updated_at
+		SELECT id, name, type, description, label, notes, enabled, created_at, updated_at
 		FROM lists
 		WHERE type = ?
 		ORDER BY name ASC
@@ -135,7 +141,7 @@ func (r *ListRepository) GetByType(ctx context.Context, listType models.ListType
 // GetEnabled retrieves all enabled lists
 func (r *ListRepository) GetEnabled(ctx context.Context) ([]models.List, error) {
 	query := `
-		SELECT id, name, type, description, enabled, created_at, updated_at
+		SELECT id, name, type, description, label, notes, enabled, created_at, updated_at
 		FROM lists
 		WHERE enabled = 1
 		ORDER BY name ASC
@@ -148,7 +154,7 @@ func (r *ListRepository) GetEnabled(ctx context.Context) ([]models.List, error)
 func (r *ListRepository) Update(ctx context.Context, list *models.List) error {
 	query := `
 		UPDATE lists SET
-			name = ?, type = ?, description = ?, enabled = ?, updated_at = ?
+			name = ?, type = ?, description = ?, label = ?, notes = ?, enabled = ?, updated_at = ?
 		WHERE id = ?
 	`
 
@@ -158,6 +164,8 @@ func (r *ListRepository) Update(ctx context.Context, list *models.List) error {
 		list.Name,
 		list.Type,
 		list.Description,
+		list.Label,
+		list.Notes,
 		list.Enabled,
 		list.UpdatedAt,
 		list.ID,
@@ -228,6 +236,8 @@ func (r *ListRepository) queryLists(ctx context.Context, query string, args ...i
 			&list.Name,
 			&list.Type,
 			&list.Description,
+			&list.Label,
+			&list.Notes,
 			&list.Enabled,
 			&list.CreatedAt,
 			&list.UpdatedAt,
@@ -0,0 +1,86 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"parental-control/internal/models"
+)
+
+// LockoutStateRepository implements the models.LockoutStateRepository interface
+type LockoutStateRepository struct {
+	db *sql.DB
+}
+
+// NewLockoutStateRepository creates a new lockout state repository
+func NewLockoutStateRepository(db *sql.DB) *LockoutStateRepository {
+	return &LockoutStateRepository{db: db}
+}
+
+// GetAll retrieves the lockout state of every account
+func (r *LockoutStateRepository) GetAll(ctx context.Context) ([]models.LockoutState, error) {
+	query := `
+		SELECT username, lockout_count, locked_until, last_lockout_at, updated_at
+		FROM lockout_state
+		ORDER BY username
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query lockout state: %w", err)
+	}
+	defer rows.Close()
+
+	var states []models.LockoutState
+	for rows.Next() {
+		var state models.LockoutState
+		var lockedUntil, lastLockoutAt sql.NullTime
+		if err := rows.Scan(&state.Username, &state.LockoutCount, &lockedUntil, &lastLockoutAt, &state.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan lockout state: %w", err)
+		}
+		if lockedUntil.Valid {
+			state.LockedUntil = &lockedUntil.Time
+		}
+		if lastLockoutAt.Valid {
+			state.LastLockoutAt = &lastLockoutAt.Time
+		}
+		states = append(states, state)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating lockout state: %w", err)
+	}
+
+	return states, nil
+}
+
+// Save creates or replaces the lockout state of an account
+func (r *LockoutStateRepository) Save(ctx context.Context, state *models.LockoutState) error {
+	query := `
+		INSERT INTO lockout_state (username, lockout_count, locked_until, last_lockout_at, updated_at)
+		VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(username) DO UPDATE SET
+			lockout_count = excluded.lockout_count,
+			locked_until = excluded.locked_until,
+			last_lockout_at = excluded.last_lockout_at,
+			updated_at = CURRENT_TIMESTAMP
+	`
+
+	_, err := r.db.ExecContext(ctx, query, state.Username, state.LockoutCount, state.LockedUntil, state.LastLockoutAt)
+	if err != nil {
+		return fmt.Errorf("failed to save lockout state: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes the lockout state of an account
+func (r *LockoutStateRepository) Delete(ctx context.Context, username string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM lockout_state WHERE username = ?`, username)
+	if err != nil {
+		return fmt.Errorf("failed to delete lockout state: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,101 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+)
+
+// CheckpointResult describes a WAL checkpoint
+type CheckpointResult struct {
+	// Busy is set when a reader or writer kept the checkpoint from finishing;
+	// a later checkpoint copies the remaining frames
+	Busy bool `json:"busy"`
+	// WALFrames is the number of frames the WAL held
+	WALFrames int `json:"wal_frames"`
+	// CheckpointedFrames is how many of them were copied into the database
+	CheckpointedFrames int `json:"checkpointed_frames"`
+	// WALSizeBefore is the size of the WAL file before the checkpoint
+	WALSizeBefore int64 `json:"wal_size_before"`
+}
+
+// Checkpoint copies the WAL into the database file and truncates it. SQLite
+// checkpoints on its own, but only resets the WAL when no reader is using
+// it, so a busy service can let the file grow for days. Outside WAL mode
+// there is nothing to do and the frame counts are -1.
+func (db *DB) Checkpoint(ctx context.Context) (*CheckpointResult, error) {
+	result := &CheckpointResult{WALSizeBefore: fileSize(db.walPath())}
+
+	var busy int
+	err := db.conn.QueryRowContext(ctx, "PRAGMA wal_checkpoint(TRUNCATE)").
+		Scan(&busy, &result.WALFrames, &result.CheckpointedFrames)
+	if err != nil {
+		return nil, fmt.Errorf("WAL checkpoint failed: %w", err)
+	}
+	result.Busy = busy != 0
+	return result, nil
+}
+
+// FreePages returns the number of unused pages in the database file and the
+// file's total page count. Deletions leave pages free until a VACUUM.
+func (db *DB) FreePages(ctx context.Context) (free, total int64, err error) {
+	if err := db.conn.QueryRowContext(ctx, "PRAGMA freelist_count").Scan(&free); err != nil {
+		return 0, 0, fmt.Errorf("failed to read free page count: %w", err)
+	}
+	if err := db.conn.QueryRowContext(ctx, "PRAGMA page_count").Scan(&total); err != nil {
+		return 0, 0, fmt.Errorf("failed to read page count: %w", err)
+	}
+	return free, total, nil
+}
+
+// VacuumResult describes a VACUUM
+type VacuumResult struct {
+	// SizeBefore and SizeAfter count the database and WAL files together
+	SizeBefore int64         `json:"size_before"`
+	SizeAfter  int64         `json:"size_after"`
+	Duration   time.Duration `json:"duration"`
+}
+
+// Vacuum rebuilds the database file to hand the pages freed by deletions
+// back to the filesystem. It holds an exclusive lock while it runs, so
+// writers wait; the maintenance hook is told first. In WAL mode the rebuilt
+// pages go through the WAL, so it is checkpointed afterwards for the file to
+// shrink.
+func (db *DB) Vacuum(ctx context.Context) (*VacuumResult, error) {
+	endMaintenance := db.beginMaintenance("database vacuum")
+	defer endMaintenance()
+
+	start := time.Now()
+	result := &VacuumResult{SizeBefore: db.diskSize()}
+
+	if _, err := db.conn.ExecContext(ctx, "VACUUM"); err != nil {
+		return nil, fmt.Errorf("vacuum failed: %w", err)
+	}
+	if _, err := db.Checkpoint(ctx); err != nil {
+		return nil, err
+	}
+
+	result.SizeAfter = db.diskSize()
+	result.Duration = time.Since(start)
+	return result, nil
+}
+
+// walPath returns the path of the database's write-ahead log
+func (db *DB) walPath() string {
+	return db.path + "-wal"
+}
+
+// diskSize returns the size of the database and WAL files together
+func (db *DB) diskSize() int64 {
+	return fileSize(db.path) + fileSize(db.walPath())
+}
+
+// fileSize returns the size of the file at path, or 0 if it cannot be read
+func fileSize(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
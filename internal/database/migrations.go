@@ -0,0 +1,297 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"parental-control/internal/logging"
+)
+
+// Migrations are the files in migrations/, named NNN_description.sql and
+// applied in version order. A NNN_description.down.sql file reverses the
+// migration of the same version; migrations without one cannot be rolled
+// back. Every applied version is recorded in schema_versions, so each
+// migration runs once per database.
+
+// downSuffix marks the file that reverses a migration
+const downSuffix = ".down.sql"
+
+// Migration is one versioned schema change
+type Migration struct {
+	Version int    `json:"version"`
+	Name    string `json:"name"`
+
+	up   string
+	down string
+}
+
+// Reversible reports whether the migration can be rolled back
+func (m Migration) Reversible() bool {
+	return m.down != ""
+}
+
+func (m Migration) String() string {
+	return fmt.Sprintf("%03d_%s", m.Version, m.Name)
+}
+
+// MigrationStatus compares a database's schema with the migrations built
+// into this binary
+type MigrationStatus struct {
+	// Current is the schema version of the database, 0 when it is empty
+	Current int `json:"current"`
+	// Latest is the newest version this binary knows
+	Latest  int         `json:"latest"`
+	Applied []Migration `json:"applied"`
+	Pending []Migration `json:"pending"`
+}
+
+// UpPlan returns the pending migrations MigrateUp(steps) would apply, in order
+func (s *MigrationStatus) UpPlan(steps int) []Migration {
+	if steps <= 0 || steps > len(s.Pending) {
+		return s.Pending
+	}
+	return s.Pending[:steps]
+}
+
+// DownPlan returns the applied migrations MigrateDown(steps) would roll
+// back, newest first
+func (s *MigrationStatus) DownPlan(steps int) []Migration {
+	var plan []Migration
+	for i := len(s.Applied) - 1; i >= 0 && len(plan) < steps; i-- {
+		plan = append(plan, s.Applied[i])
+	}
+	return plan
+}
+
+// Migrations returns the migrations built into this binary in version order
+func Migrations() ([]Migration, error) {
+	entries, err := migrationsFS.ReadDir("migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations directory: %w", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		filename := entry.Name()
+		version, err := migrationVersion(filename)
+		if err != nil {
+			return nil, err
+		}
+
+		content, err := migrationsFS.ReadFile("migrations/" + filename)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration file %s: %w", filename, err)
+		}
+
+		migration := byVersion[version]
+		if migration == nil {
+			migration = &Migration{Version: version}
+			byVersion[version] = migration
+		}
+
+		if strings.HasSuffix(filename, downSuffix) {
+			if migration.down != "" {
+				return nil, fmt.Errorf("migration version %d has more than one down file", version)
+			}
+			migration.down = string(content)
+			continue
+		}
+
+		if migration.up != "" {
+			return nil, fmt.Errorf("migration version %d is used by both %s and %s", version, migration, filename)
+		}
+		_, name, _ := strings.Cut(strings.TrimSuffix(filename, ".sql"), "_")
+		migration.Name = name
+		migration.up = string(content)
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for version, migration := range byVersion {
+		if migration.up == "" {
+			return nil, fmt.Errorf("down migration for version %d has no matching migration", version)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// MigrationStatus reports which migrations the database has and which are pending
+func (db *DB) MigrationStatus() (*MigrationStatus, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := db.getCurrentSchemaVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	status := &MigrationStatus{Current: current, Applied: []Migration{}, Pending: []Migration{}}
+	for _, migration := range migrations {
+		// Versions at or below the current one are skipped even if they were
+		// never recorded; ALTER TABLE statements cannot be re-run safely
+		if migration.Version <= current {
+			status.Applied = append(status.Applied, migration)
+		} else {
+			status.Pending = append(status.Pending, migration)
+		}
+		status.Latest = migration.Version
+	}
+	return status, nil
+}
+
+// MigrateUp applies pending migrations in order, at most steps of them or
+// all when steps is 0, and returns the ones it applied. It stops at the
+// first failure; that migration is rolled back and the earlier ones remain.
+func (db *DB) MigrateUp(steps int) ([]Migration, error) {
+	status, err := db.MigrationStatus()
+	if err != nil {
+		return nil, err
+	}
+
+	plan := status.UpPlan(steps)
+	if len(plan) == 0 {
+		return nil, nil
+	}
+	if err := db.backupBeforeMigrating(status); err != nil {
+		return nil, err
+	}
+
+	endMaintenance := db.beginMaintenance("schema migration")
+	defer endMaintenance()
+
+	var applied []Migration
+	for _, migration := range plan {
+		logging.Info("Applying migration", logging.String("migration", migration.String()))
+		if err := db.runMigration(migration.up, migration,
+			"INSERT OR IGNORE INTO schema_versions (version, description) VALUES (?, ?)",
+			migration.Version, migration.Name); err != nil {
+			return applied, err
+		}
+		logging.Info("Migration applied successfully", logging.String("migration", migration.String()))
+		applied = append(applied, migration)
+	}
+	return applied, nil
+}
+
+// MigrateDown rolls back the newest steps applied migrations and returns the
+// ones it rolled back. Nothing is changed unless every one of them is
+// reversible. Down migrations can lose data that only the newer schema
+// could hold, such as list entries of a pattern type added later.
+func (db *DB) MigrateDown(steps int) ([]Migration, error) {
+	if steps <= 0 {
+		return nil, fmt.Errorf("number of migrations to roll back must be positive")
+	}
+
+	status, err := db.MigrationStatus()
+	if err != nil {
+		return nil, err
+	}
+	if status.Current > status.Latest {
+		return nil, fmt.Errorf("schema version %d is newer than this binary knows (%d); use the release that applied it", status.Current, status.Latest)
+	}
+
+	plan := status.DownPlan(steps)
+	for _, migration := range plan {
+		if !migration.Reversible() {
+			return nil, fmt.Errorf("migration %s cannot be rolled back", migration)
+		}
+	}
+	if len(plan) == 0 {
+		return nil, nil
+	}
+	if err := db.backupBeforeMigrating(status); err != nil {
+		return nil, err
+	}
+
+	endMaintenance := db.beginMaintenance("schema migration")
+	defer endMaintenance()
+
+	var reverted []Migration
+	for _, migration := range plan {
+		logging.Info("Rolling back migration", logging.String("migration", migration.String()))
+		if err := db.runMigration(migration.down, migration,
+			"DELETE FROM schema_versions WHERE version = ?", migration.Version); err != nil {
+			return reverted, err
+		}
+		logging.Info("Migration rolled back", logging.String("migration", migration.String()))
+		reverted = append(reverted, migration)
+	}
+	return reverted, nil
+}
+
+// runMigration executes a migration script and updates schema_versions with
+// record in a single transaction
+func (db *DB) runMigration(script string, migration Migration, record string, args ...interface{}) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction for migration %s: %w", migration, err)
+	}
+
+	if _, err := tx.Exec(script); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to execute migration %s: %w", migration, err)
+	}
+	if _, err := tx.Exec(record, args...); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to record migration %s: %w", migration, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration %s: %w", migration, err)
+	}
+	return nil
+}
+
+// getCurrentSchemaVersion returns the current schema version
+func (db *DB) getCurrentSchemaVersion() (int, error) {
+	// Check if schema_versions table exists
+	var exists bool
+	err := db.conn.QueryRow(`
+		SELECT COUNT(*) > 0
+		FROM sqlite_master
+		WHERE type='table' AND name='schema_versions'
+	`).Scan(&exists)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if !exists {
+		return 0, nil // No schema version table means version 0
+	}
+
+	// Get the latest version; an empty table, e.g. after rolling everything
+	// back, is version 0 too
+	var version int
+	err = db.conn.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_versions").Scan(&version)
+	if err != nil {
+		return 0, err
+	}
+
+	return version, nil
+}
+
+// migrationVersion parses the version number prefix of a migration file name,
+// such as 3 for "003_log_rotation.sql"
+func migrationVersion(filename string) (int, error) {
+	prefix, _, found := strings.Cut(filename, "_")
+	if !found {
+		return 0, fmt.Errorf("migration file %s has no version prefix", filename)
+	}
+	version, err := strconv.Atoi(prefix)
+	if err != nil {
+		return 0, fmt.Errorf("migration file %s has an invalid version prefix: %w", filename, err)
+	}
+	return version, nil
+}
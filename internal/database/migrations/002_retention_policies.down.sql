@@ -0,0 +1,7 @@
+-- Retention Policies Rollback
+-- Version: 002
+-- Description: Remove the retention policy tables and their execution history
+
+DROP TRIGGER IF EXISTS update_retention_policies_timestamp;
+DROP TABLE IF EXISTS retention_policy_executions;
+DROP TABLE IF EXISTS retention_policies;
@@ -63,22 +63,8 @@ CREATE TRIGGER IF NOT EXISTS update_retention_policies_timestamp
         UPDATE retention_policies SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
     END;
 
--- Insert default retention policy
-INSERT OR IGNORE INTO retention_policies (
-    name, 
-    description, 
-    enabled, 
-    priority,
-    time_based_rule,
-    execution_schedule
-) VALUES (
-    'Default 30-Day Retention',
-    'Default policy to retain audit logs for 30 days',
-    1,
-    100,
-    '{"max_age": "720h", "grace_period": "24h"}', -- 30 days + 1 day grace
-    '0 2 * * *' -- Daily at 2 AM
-);
+-- Default policies are seeded by the service on first run (see
+-- service.SeedDefaultPolicies) so user edits are never overwritten here
 
 -- Update schema version
 INSERT OR IGNORE INTO schema_versions (version, description) 
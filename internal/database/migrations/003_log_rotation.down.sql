@@ -0,0 +1,6 @@
+-- Log Rotation Rollback
+-- Version: 003
+-- Description: Remove the log rotation tables and their execution history
+
+DROP TABLE IF EXISTS log_rotation_executions;
+DROP TABLE IF EXISTS log_rotation_policies;
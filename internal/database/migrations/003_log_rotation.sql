@@ -74,57 +74,9 @@ CREATE INDEX IF NOT EXISTS idx_log_rotation_executions_status ON log_rotation_ex
 CREATE INDEX IF NOT EXISTS idx_log_rotation_executions_trigger ON log_rotation_executions(trigger_reason);
 CREATE INDEX IF NOT EXISTS idx_log_rotation_executions_policy_time ON log_rotation_executions(policy_id, execution_time DESC);
 
--- Insert default log rotation policy
-INSERT OR REPLACE INTO log_rotation_policies (
-    id,
-    name,
-    description,
-    enabled,
-    priority,
-    size_based_rotation,
-    time_based_rotation,
-    archival_policy,
-    target_log_files,
-    execution_schedule,
-    next_execution
-) VALUES (
-    1,
-    'Default Log Rotation',
-    'Default log rotation policy for system logs with daily rotation and 7-day retention',
-    1,
-    100,
-    '{"max_file_size": 104857600, "max_total_size": 1073741824, "rotation_threshold": 0.8}',
-    '{"rotation_interval": "24h0m0s", "retain_duration": "168h0m0s", "archive_older_than": "72h0m0s"}',
-    '{"enable_compression": true, "compression_format": "gzip", "archive_location": "data/archives", "max_archive_size": 2147483648, "archive_retention": "720h0m0s", "compression_level": 6, "encrypt_archives": false}',
-    '["*.log", "data/*.db-wal", "logs/*"]',
-    '0 2 * * *',
-    datetime('now', '+1 day')
-);
-
--- Insert emergency cleanup policy
-INSERT OR REPLACE INTO log_rotation_policies (
-    id,
-    name,
-    description,
-    enabled,
-    priority,
-    size_based_rotation,
-    emergency_config,
-    target_log_files,
-    execution_schedule,
-    next_execution
-) VALUES (
-    2,
-    'Emergency Disk Space Protection',
-    'Emergency policy to prevent disk space exhaustion',
-    1,
-    200,
-    '{"max_file_size": 52428800, "max_total_size": 536870912, "rotation_threshold": 0.9}',
-    '{"disk_space_threshold": 0.85, "emergency_actions": [{"priority": 1, "action_type": "delete_oldest", "target_files": ["*.log.*", "data/*.db-wal"], "max_delete": 100, "description": "Delete oldest rotated log files"}], "monitoring_interval": "5m0s", "alert_thresholds": [{"disk_usage_percent": 0.8, "alert_level": "warning", "notification_channels": ["system"]}, {"disk_usage_percent": 0.9, "alert_level": "critical", "notification_channels": ["system"]}]}',
-    '["*.log.*", "data/*.db-wal", "logs/*"]',
-    '*/5 * * * *', -- Every 5 minutes
-    datetime('now', '+5 minutes')
-);
+-- Default policies are seeded by the service on first run (see
+-- service.SeedDefaultPolicies). Migrations run on every start, so seeding
+-- here would restore deleted policies and overwrite user edits.
 
 -- Update schema version
 INSERT OR IGNORE INTO schema_versions (version, description) 
@@ -0,0 +1,5 @@
+-- Lockout State Rollback
+-- Version: 004
+-- Description: Remove persisted lockout escalation state
+
+DROP TABLE IF EXISTS lockout_state;
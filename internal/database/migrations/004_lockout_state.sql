@@ -0,0 +1,15 @@
+-- Lockout State Migration
+-- Version: 004
+-- Description: Persist account lockout escalation across restarts
+
+CREATE TABLE IF NOT EXISTS lockout_state (
+    username TEXT PRIMARY KEY,
+    lockout_count INTEGER NOT NULL DEFAULT 0,
+    locked_until DATETIME,
+    last_lockout_at DATETIME,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Update schema version
+INSERT OR IGNORE INTO schema_versions (version, description)
+VALUES (4, 'Add account lockout escalation state');
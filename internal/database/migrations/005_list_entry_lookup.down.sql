@@ -0,0 +1,5 @@
+-- List Entry Lookup Rollback
+-- Version: 005
+-- Description: Remove the list entry lookup index
+
+DROP INDEX IF EXISTS idx_list_entries_lookup;
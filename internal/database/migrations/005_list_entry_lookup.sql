@@ -0,0 +1,10 @@
+-- List Entry Lookup Migration
+-- Version: 005
+-- Description: Index list entries by list, type and pattern so bulk imports
+-- can skip existing entries without scanning the whole list
+
+CREATE INDEX IF NOT EXISTS idx_list_entries_lookup ON list_entries(list_id, entry_type, pattern);
+
+-- Update schema version
+INSERT OR IGNORE INTO schema_versions (version, description)
+VALUES (5, 'Add list entry lookup index');
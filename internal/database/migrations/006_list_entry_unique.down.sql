@@ -0,0 +1,6 @@
+-- List Entry Uniqueness Rollback
+-- Version: 006
+-- Description: Allow duplicate list entry patterns again. Duplicates removed
+-- by the migration are not restored.
+
+DROP INDEX IF EXISTS idx_list_entries_unique;
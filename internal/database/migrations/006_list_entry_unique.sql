@@ -0,0 +1,16 @@
+-- List Entry Uniqueness Migration
+-- Version: 006
+-- Description: Remove duplicate list entries and enforce one entry per
+-- pattern, pattern type and entry type in each list. Duplicates keep the
+-- oldest entry.
+
+DELETE FROM list_entries
+WHERE id NOT IN (
+    SELECT MIN(id) FROM list_entries GROUP BY list_id, entry_type, pattern, pattern_type
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_list_entries_unique ON list_entries(list_id, entry_type, pattern, pattern_type);
+
+-- Update schema version
+INSERT OR IGNORE INTO schema_versions (version, description)
+VALUES (6, 'Enforce unique list entry patterns');
@@ -0,0 +1,10 @@
+-- List Metadata Rollback
+-- Version: 007
+-- Description: Remove list and entry labels, notes and entry source
+
+DROP INDEX IF EXISTS idx_list_entries_source;
+ALTER TABLE list_entries DROP COLUMN source;
+ALTER TABLE list_entries DROP COLUMN notes;
+ALTER TABLE list_entries DROP COLUMN label;
+ALTER TABLE lists DROP COLUMN notes;
+ALTER TABLE lists DROP COLUMN label;
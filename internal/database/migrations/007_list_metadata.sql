@@ -0,0 +1,17 @@
+-- List Metadata Migration
+-- Version: 007
+-- Description: Add labels and notes to lists and entries, and record whether
+-- each entry was added by hand or by an import
+
+ALTER TABLE lists ADD COLUMN label TEXT NOT NULL DEFAULT '';
+ALTER TABLE lists ADD COLUMN notes TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE list_entries ADD COLUMN label TEXT NOT NULL DEFAULT '';
+ALTER TABLE list_entries ADD COLUMN notes TEXT NOT NULL DEFAULT '';
+ALTER TABLE list_entries ADD COLUMN source TEXT NOT NULL DEFAULT 'manual';
+
+CREATE INDEX IF NOT EXISTS idx_list_entries_source ON list_entries(list_id, source);
+
+-- Update schema version
+INSERT OR IGNORE INTO schema_versions (version, description)
+VALUES (7, 'Add list and entry labels, notes and entry source');
@@ -0,0 +1,43 @@
+-- Port Patterns Rollback
+-- Version: 008
+-- Description: Rebuild list_entries without the port pattern type. Entries
+-- using it cannot be represented in the older schema and are deleted.
+
+DELETE FROM list_entries WHERE pattern_type = 'port';
+
+CREATE TABLE list_entries_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    list_id INTEGER NOT NULL REFERENCES lists(id) ON DELETE CASCADE,
+    entry_type TEXT NOT NULL CHECK (entry_type IN ('executable', 'url')),
+    pattern TEXT NOT NULL,
+    pattern_type TEXT NOT NULL CHECK (pattern_type IN ('exact', 'wildcard', 'domain')),
+    description TEXT,
+    enabled BOOLEAN NOT NULL DEFAULT 1,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    label TEXT NOT NULL DEFAULT '',
+    notes TEXT NOT NULL DEFAULT '',
+    source TEXT NOT NULL DEFAULT 'manual'
+);
+
+INSERT INTO list_entries_new (id, list_id, entry_type, pattern, pattern_type, description,
+    enabled, created_at, updated_at, label, notes, source)
+SELECT id, list_id, entry_type, pattern, pattern_type, description,
+    enabled, created_at, updated_at, label, notes, source
+FROM list_entries;
+
+DROP TABLE list_entries;
+ALTER TABLE list_entries_new RENAME TO list_entries;
+
+CREATE INDEX IF NOT EXISTS idx_list_entries_list_id ON list_entries(list_id);
+CREATE INDEX IF NOT EXISTS idx_list_entries_type ON list_entries(entry_type);
+CREATE INDEX IF NOT EXISTS idx_list_entries_pattern ON list_entries(pattern);
+CREATE INDEX IF NOT EXISTS idx_list_entries_lookup ON list_entries(list_id, entry_type, pattern);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_list_entries_unique ON list_entries(list_id, entry_type, pattern, pattern_type);
+CREATE INDEX IF NOT EXISTS idx_list_entries_source ON list_entries(list_id, source);
+
+CREATE TRIGGER IF NOT EXISTS update_list_entries_timestamp
+    AFTER UPDATE ON list_entries
+    BEGIN
+        UPDATE list_entries SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
+    END;
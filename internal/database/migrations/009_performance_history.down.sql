@@ -0,0 +1,6 @@
+-- Performance History Rollback
+-- Version: 009
+-- Description: Remove stored performance snapshots and alerts
+
+DROP TABLE IF EXISTS performance_alerts;
+DROP TABLE IF EXISTS performance_snapshots;
@@ -0,0 +1,30 @@
+-- Performance History Migration
+-- Version: 009
+-- Description: Persist performance trend snapshots and alerts across restarts
+
+CREATE TABLE IF NOT EXISTS performance_snapshots (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    timestamp DATETIME NOT NULL,
+    metrics TEXT NOT NULL -- JSON encoded SystemMetrics
+);
+
+CREATE INDEX IF NOT EXISTS idx_performance_snapshots_timestamp ON performance_snapshots(timestamp);
+
+CREATE TABLE IF NOT EXISTS performance_alerts (
+    id TEXT PRIMARY KEY,
+    threshold_name TEXT NOT NULL,
+    threshold TEXT NOT NULL, -- JSON encoded PerformanceThreshold
+    severity TEXT NOT NULL,
+    current_value REAL NOT NULL,
+    message TEXT NOT NULL,
+    triggered_at DATETIME NOT NULL,
+    resolved BOOLEAN NOT NULL DEFAULT 0,
+    resolved_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_performance_alerts_threshold ON performance_alerts(threshold_name, resolved);
+CREATE INDEX IF NOT EXISTS idx_performance_alerts_triggered_at ON performance_alerts(triggered_at);
+
+-- Update schema version
+INSERT OR IGNORE INTO schema_versions (version, description)
+VALUES (9, 'Add performance history');
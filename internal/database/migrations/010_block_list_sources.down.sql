@@ -0,0 +1,6 @@
+-- Block List Sources Rollback
+-- Version: 010
+-- Description: Remove block list subscriptions. Their lists and imported
+-- entries are kept as ordinary lists.
+
+DROP TABLE IF EXISTS block_list_sources;
@@ -0,0 +1,22 @@
+-- Block List Sources Migration
+-- Version: 010
+-- Description: Remember where imported block lists came from so they can be
+-- refreshed, with the validators needed for conditional requests
+
+CREATE TABLE IF NOT EXISTS block_list_sources (
+    list_id INTEGER PRIMARY KEY,
+    url TEXT NOT NULL,
+    format TEXT NOT NULL,
+    refresh_interval_seconds INTEGER NOT NULL DEFAULT 0,
+    etag TEXT NOT NULL DEFAULT '',
+    last_modified TEXT NOT NULL DEFAULT '',
+    last_checked_at DATETIME,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (list_id) REFERENCES lists(id) ON DELETE CASCADE
+);
+
+-- Update schema version
+INSERT OR IGNORE INTO schema_versions (version, description)
+VALUES (10, 'Add block list sources');
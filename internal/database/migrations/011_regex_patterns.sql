@@ -0,0 +1,46 @@
+-- Regex Patterns Migration
+-- Version: 011
+-- Description: Allow regular expression list entries. SQLite cannot change a
+-- CHECK constraint in place, so list_entries is rebuilt with its indexes and
+-- trigger.
+
+CREATE TABLE list_entries_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    list_id INTEGER NOT NULL REFERENCES lists(id) ON DELETE CASCADE,
+    entry_type TEXT NOT NULL CHECK (entry_type IN ('executable', 'url')),
+    pattern TEXT NOT NULL,
+    pattern_type TEXT NOT NULL CHECK (pattern_type IN ('exact', 'wildcard', 'domain', 'regex', 'port')),
+    description TEXT,
+    enabled BOOLEAN NOT NULL DEFAULT 1,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    label TEXT NOT NULL DEFAULT '',
+    notes TEXT NOT NULL DEFAULT '',
+    source TEXT NOT NULL DEFAULT 'manual'
+);
+
+INSERT INTO list_entries_new (id, list_id, entry_type, pattern, pattern_type, description,
+    enabled, created_at, updated_at, label, notes, source)
+SELECT id, list_id, entry_type, pattern, pattern_type, description,
+    enabled, created_at, updated_at, label, notes, source
+FROM list_entries;
+
+DROP TABLE list_entries;
+ALTER TABLE list_entries_new RENAME TO list_entries;
+
+CREATE INDEX IF NOT EXISTS idx_list_entries_list_id ON list_entries(list_id);
+CREATE INDEX IF NOT EXISTS idx_list_entries_type ON list_entries(entry_type);
+CREATE INDEX IF NOT EXISTS idx_list_entries_pattern ON list_entries(pattern);
+CREATE INDEX IF NOT EXISTS idx_list_entries_lookup ON list_entries(list_id, entry_type, pattern);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_list_entries_unique ON list_entries(list_id, entry_type, pattern, pattern_type);
+CREATE INDEX IF NOT EXISTS idx_list_entries_source ON list_entries(list_id, source);
+
+CREATE TRIGGER IF NOT EXISTS update_list_entries_timestamp
+    AFTER UPDATE ON list_entries
+    BEGIN
+        UPDATE list_entries SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
+    END;
+
+-- Update schema version
+INSERT OR IGNORE INTO schema_versions (version, description)
+VALUES (11, 'Allow regex list entry patterns');
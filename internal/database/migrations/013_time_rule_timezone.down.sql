@@ -0,0 +1,5 @@
+-- Time Rule Timezone Rollback
+-- Version: 013
+-- Description: Remove the time rule timezone; rules use local time again
+
+ALTER TABLE time_rules DROP COLUMN timezone;
@@ -0,0 +1,10 @@
+-- Time Rule Timezone Migration
+-- Version: 013
+-- Description: Let time rules name the IANA zone their window is meant in.
+-- Existing rules keep an empty zone, which is the server's local zone.
+
+ALTER TABLE time_rules ADD COLUMN timezone TEXT NOT NULL DEFAULT '';
+
+-- Update schema version
+INSERT OR IGNORE INTO schema_versions (version, description)
+VALUES (13, 'Add time rule timezone');
@@ -0,0 +1,7 @@
+-- Quota Carry-Over Rollback
+-- Version: 014
+-- Description: Remove quota carry-over; every window starts fresh again
+
+ALTER TABLE quota_usage DROP COLUMN carried_seconds;
+ALTER TABLE quota_rules DROP COLUMN carry_over_max_seconds;
+ALTER TABLE quota_rules DROP COLUMN carry_over;
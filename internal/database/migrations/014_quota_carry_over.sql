@@ -0,0 +1,16 @@
+-- Quota Carry-Over Migration
+-- Version: 014
+-- Description: Let quota rules roll unused time into the next window, up to
+-- a cap. Existing rules keep the 'none' policy and start every window fresh.
+
+ALTER TABLE quota_rules ADD COLUMN carry_over TEXT NOT NULL DEFAULT 'none'
+    CHECK (carry_over IN ('none', 'unused-rolls-forward-capped'));
+ALTER TABLE quota_rules ADD COLUMN carry_over_max_seconds INTEGER NOT NULL DEFAULT 0
+    CHECK (carry_over_max_seconds >= 0);
+
+-- Seconds carried into a window on top of the rule's limit
+ALTER TABLE quota_usage ADD COLUMN carried_seconds INTEGER NOT NULL DEFAULT 0;
+
+-- Update schema version
+INSERT OR IGNORE INTO schema_versions (version, description)
+VALUES (14, 'Add quota carry-over');
@@ -0,0 +1,11 @@
+-- Audit Query Indexes Rollback
+-- Version: 015
+-- Description: Restore the original single-column audit log indexes
+
+DROP INDEX IF EXISTS idx_audit_log_process_name;
+DROP INDEX IF EXISTS idx_audit_log_event_type_timestamp;
+DROP INDEX IF EXISTS idx_audit_log_target_type_timestamp;
+DROP INDEX IF EXISTS idx_audit_log_action_timestamp;
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_event_type ON audit_log(event_type);
+CREATE INDEX IF NOT EXISTS idx_audit_log_action ON audit_log(action);
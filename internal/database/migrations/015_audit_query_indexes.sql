@@ -0,0 +1,23 @@
+-- Audit Query Indexes Migration
+-- Version: 015
+-- Description: Index the columns the audit log query filters on so large
+-- logs stay responsive. Each index leads with the filtered column and ends
+-- with timestamp, which serves both time ranges and newest-first ordering.
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_action_timestamp ON audit_log(action, timestamp);
+CREATE INDEX IF NOT EXISTS idx_audit_log_target_type_timestamp ON audit_log(target_type, timestamp);
+CREATE INDEX IF NOT EXISTS idx_audit_log_event_type_timestamp ON audit_log(event_type, timestamp);
+
+-- Superseded by the indexes above; dropped to keep writes cheap
+DROP INDEX IF EXISTS idx_audit_log_action;
+DROP INDEX IF EXISTS idx_audit_log_event_type;
+
+-- The process an entry concerns; must match the repository's filter expression
+CREATE INDEX IF NOT EXISTS idx_audit_log_process_name ON audit_log(
+    (CASE WHEN target_type = 'executable' THEN target_value
+        WHEN json_valid(details) THEN json_extract(details, '$.process_name') END)
+);
+
+-- Update schema version
+INSERT OR IGNORE INTO schema_versions (version, description)
+VALUES (15, 'Add audit log query indexes');
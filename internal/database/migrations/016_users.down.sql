@@ -0,0 +1,5 @@
+-- Users Rollback
+-- Version: 016
+-- Description: Remove persisted user accounts
+
+DROP TABLE IF EXISTS users;
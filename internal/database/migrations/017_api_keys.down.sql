@@ -0,0 +1,6 @@
+-- API Keys Rollback
+-- Version: 017
+-- Description: Remove persisted API keys
+
+DROP INDEX IF EXISTS idx_api_keys_user_id;
+DROP TABLE IF EXISTS api_keys;
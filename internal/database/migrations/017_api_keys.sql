@@ -0,0 +1,23 @@
+-- API Keys Migration
+-- Version: 017
+-- Description: Persist API keys. Only the SHA-256 hash of each key is
+-- stored; revoked keys are kept so their use can still be audited.
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    prefix TEXT NOT NULL,
+    key_hash TEXT NOT NULL UNIQUE,
+    scope TEXT NOT NULL DEFAULT 'read_only' CHECK (scope IN ('read_only', 'admin')),
+    expires_at DATETIME,
+    last_used_at DATETIME,
+    revoked_at DATETIME,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON api_keys(user_id);
+
+-- Update schema version
+INSERT OR IGNORE INTO schema_versions (version, description)
+VALUES (17, 'Add API keys');
@@ -0,0 +1,38 @@
+-- Audit Admin Actions Rollback
+-- Version: 018
+-- Description: Rebuild audit_log without the admin_reset action. Entries
+-- using it cannot be represented in the older schema and are deleted.
+
+DELETE FROM audit_log WHERE action = 'admin_reset';
+
+CREATE TABLE audit_log_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
+    event_type TEXT NOT NULL,
+    target_type TEXT NOT NULL, -- 'executable' or 'url'
+    target_value TEXT NOT NULL,
+    action TEXT NOT NULL CHECK (action IN ('allow', 'block')),
+    rule_type TEXT, -- which type of rule triggered (time, quota, list)
+    rule_id INTEGER, -- ID of the specific rule that triggered
+    details TEXT, -- JSON object with additional details
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO audit_log_new (id, timestamp, event_type, target_type, target_value, action,
+    rule_type, rule_id, details, created_at)
+SELECT id, timestamp, event_type, target_type, target_value, action,
+    rule_type, rule_id, details, created_at
+FROM audit_log;
+
+DROP TABLE audit_log;
+ALTER TABLE audit_log_new RENAME TO audit_log;
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_timestamp ON audit_log(timestamp);
+CREATE INDEX IF NOT EXISTS idx_audit_log_target ON audit_log(target_type, target_value);
+CREATE INDEX IF NOT EXISTS idx_audit_log_action_timestamp ON audit_log(action, timestamp);
+CREATE INDEX IF NOT EXISTS idx_audit_log_target_type_timestamp ON audit_log(target_type, timestamp);
+CREATE INDEX IF NOT EXISTS idx_audit_log_event_type_timestamp ON audit_log(event_type, timestamp);
+CREATE INDEX IF NOT EXISTS idx_audit_log_process_name ON audit_log(
+    (CASE WHEN target_type = 'executable' THEN target_value
+        WHEN json_valid(details) THEN json_extract(details, '$.process_name') END)
+);
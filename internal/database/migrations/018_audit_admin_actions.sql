@@ -0,0 +1,41 @@
+-- Audit Admin Actions Migration
+-- Version: 018
+-- Description: Allow the admin_reset audit action, recorded against user
+-- accounts by the admin-reset command. SQLite cannot change a CHECK
+-- constraint in place, so audit_log is rebuilt with its indexes.
+
+CREATE TABLE audit_log_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
+    event_type TEXT NOT NULL,
+    target_type TEXT NOT NULL, -- 'executable', 'url' or 'account'
+    target_value TEXT NOT NULL,
+    action TEXT NOT NULL CHECK (action IN ('allow', 'block', 'admin_reset')),
+    rule_type TEXT, -- which type of rule triggered (time, quota, list)
+    rule_id INTEGER, -- ID of the specific rule that triggered
+    details TEXT, -- JSON object with additional details
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO audit_log_new (id, timestamp, event_type, target_type, target_value, action,
+    rule_type, rule_id, details, created_at)
+SELECT id, timestamp, event_type, target_type, target_value, action,
+    rule_type, rule_id, details, created_at
+FROM audit_log;
+
+DROP TABLE audit_log;
+ALTER TABLE audit_log_new RENAME TO audit_log;
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_timestamp ON audit_log(timestamp);
+CREATE INDEX IF NOT EXISTS idx_audit_log_target ON audit_log(target_type, target_value);
+CREATE INDEX IF NOT EXISTS idx_audit_log_action_timestamp ON audit_log(action, timestamp);
+CREATE INDEX IF NOT EXISTS idx_audit_log_target_type_timestamp ON audit_log(target_type, timestamp);
+CREATE INDEX IF NOT EXISTS idx_audit_log_event_type_timestamp ON audit_log(event_type, timestamp);
+CREATE INDEX IF NOT EXISTS idx_audit_log_process_name ON audit_log(
+    (CASE WHEN target_type = 'executable' THEN target_value
+        WHEN json_valid(details) THEN json_extract(details, '$.process_name') END)
+);
+
+-- Update schema version
+INSERT OR IGNORE INTO schema_versions (version, description)
+VALUES (18, 'Allow admin audit actions');
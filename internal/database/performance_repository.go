@@ -0,0 +1,161 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"parental-control/internal/models"
+)
+
+// PerformanceHistoryRepository implements the models.PerformanceHistoryRepository interface
+type PerformanceHistoryRepository struct {
+	db *sql.DB
+}
+
+// NewPerformanceHistoryRepository creates a new performance history repository
+func NewPerformanceHistoryRepository(db *sql.DB) *PerformanceHistoryRepository {
+	return &PerformanceHistoryRepository{db: db}
+}
+
+// SaveSnapshot stores a performance snapshot
+func (r *PerformanceHistoryRepository) SaveSnapshot(ctx context.Context, snapshot *models.PerformanceSnapshot) error {
+	result, err := r.db.ExecContext(ctx,
+		`INSERT INTO performance_snapshots (timestamp, metrics) VALUES (?, ?)`,
+		snapshot.Timestamp, snapshot.Metrics)
+	if err != nil {
+		return fmt.Errorf("failed to save performance snapshot: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get performance snapshot ID: %w", err)
+	}
+	snapshot.ID = int(id)
+
+	return nil
+}
+
+// GetSnapshotsByTimeRange retrieves snapshots taken between start and end,
+// oldest first. With a limit, the most recent snapshots in the range are kept.
+func (r *PerformanceHistoryRepository) GetSnapshotsByTimeRange(ctx context.Context, start, end time.Time, limit int) ([]models.PerformanceSnapshot, error) {
+	query := `
+		SELECT id, timestamp, metrics FROM (
+			SELECT id, timestamp, metrics
+			FROM performance_snapshots
+			WHERE timestamp >= ? AND timestamp <= ?
+			ORDER BY timestamp DESC
+			LIMIT ?
+		)
+		ORDER BY timestamp ASC
+	`
+	if limit <= 0 {
+		limit = -1
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, start, end, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query performance snapshots: %w", err)
+	}
+	defer rows.Close()
+
+	var snapshots []models.PerformanceSnapshot
+	for rows.Next() {
+		var snapshot models.PerformanceSnapshot
+		if err := rows.Scan(&snapshot.ID, &snapshot.Timestamp, &snapshot.Metrics); err != nil {
+			return nil, fmt.Errorf("failed to scan performance snapshot: %w", err)
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating performance snapshots: %w", err)
+	}
+
+	return snapshots, nil
+}
+
+// CleanupOldSnapshots removes snapshots taken before the cutoff
+func (r *PerformanceHistoryRepository) CleanupOldSnapshots(ctx context.Context, before time.Time) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM performance_snapshots WHERE timestamp < ?`, before)
+	if err != nil {
+		return fmt.Errorf("failed to cleanup old performance snapshots: %w", err)
+	}
+
+	return nil
+}
+
+// SaveAlert creates or updates a performance alert
+func (r *PerformanceHistoryRepository) SaveAlert(ctx context.Context, alert *models.PerformanceAlertRecord) error {
+	query := `
+		INSERT INTO performance_alerts (
+			id, threshold_name, threshold, severity, current_value, message,
+			triggered_at, resolved, resolved_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			threshold = excluded.threshold,
+			severity = excluded.severity,
+			current_value = excluded.current_value,
+			message = excluded.message,
+			resolved = excluded.resolved,
+			resolved_at = excluded.resolved_at
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		alert.ID, alert.ThresholdName, alert.Threshold, alert.Severity, alert.CurrentValue,
+		alert.Message, alert.TriggeredAt, alert.Resolved, alert.ResolvedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save performance alert: %w", err)
+	}
+
+	return nil
+}
+
+// GetAlerts retrieves unresolved alerts and alerts triggered since the
+// given time, oldest first
+func (r *PerformanceHistoryRepository) GetAlerts(ctx context.Context, since time.Time) ([]models.PerformanceAlertRecord, error) {
+	query := `
+		SELECT id, threshold_name, threshold, severity, current_value, message,
+		       triggered_at, resolved, resolved_at
+		FROM performance_alerts
+		WHERE resolved = 0 OR triggered_at >= ?
+		ORDER BY triggered_at ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query performance alerts: %w", err)
+	}
+	defer rows.Close()
+
+	var alerts []models.PerformanceAlertRecord
+	for rows.Next() {
+		var alert models.PerformanceAlertRecord
+		var resolvedAt sql.NullTime
+		if err := rows.Scan(&alert.ID, &alert.ThresholdName, &alert.Threshold, &alert.Severity,
+			&alert.CurrentValue, &alert.Message, &alert.TriggeredAt, &alert.Resolved, &resolvedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan performance alert: %w", err)
+		}
+		if resolvedAt.Valid {
+			alert.ResolvedAt = &resolvedAt.Time
+		}
+		alerts = append(alerts, alert)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating performance alerts: %w", err)
+	}
+
+	return alerts, nil
+}
+
+// CleanupOldAlerts removes resolved alerts triggered before the cutoff
+func (r *PerformanceHistoryRepository) CleanupOldAlerts(ctx context.Context, before time.Time) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM performance_alerts WHERE resolved = 1 AND triggered_at < ?`, before)
+	if err != nil {
+		return fmt.Errorf("failed to cleanup old performance alerts: %w", err)
+	}
+
+	return nil
+}
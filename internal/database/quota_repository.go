@@ -0,0 +1,493 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"parental-control/internal/models"
+)
+
+// QuotaRuleRepository implements the models.QuotaRuleRepository interface
+type QuotaRuleRepository struct {
+	db *sql.DB
+}
+
+// NewQuotaRuleRepository creates a new quota rule repository
+func NewQuotaRuleRepository(db *sql.DB) *QuotaRuleRepository {
+	return &QuotaRuleRepository{db: db}
+}
+
+// quotaRuleColumns are the columns scanned by scanQuotaRule, in order
+const quotaRuleColumns = `id, list_id, name, quota_type, limit_seconds, enabled, carry_over, carry_over_max_seconds, created_at, updated_at`
+
+// Create creates a new quota rule
+func (r *QuotaRuleRepository) Create(ctx context.Context, rule *models.QuotaRule) error {
+	query := `
+		INSERT INTO quota_rules (list_id, name, quota_type, limit_seconds, enabled, carry_over, carry_over_max_seconds, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	now := time.Now()
+	rule.CreatedAt = now
+	rule.UpdatedAt = now
+
+	result, err := r.db.ExecContext(ctx, query,
+		rule.ListID,
+		rule.Name,
+		rule.QuotaType,
+		rule.LimitSeconds,
+		rule.Enabled,
+		carryOverPolicy(rule.CarryOver),
+		rule.MaxCarryOverSeconds,
+		rule.CreatedAt,
+		rule.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create quota rule: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get quota rule ID: %w", err)
+	}
+
+	rule.ID = int(id)
+	return nil
+}
+
+// GetByID retrieves a quota rule by ID
+func (r *QuotaRuleRepository) GetByID(ctx context.Context, id int) (*models.QuotaRule, error) {
+	query := `SELECT ` + quotaRuleColumns + ` FROM quota_rules WHERE id = ?`
+
+	rule, err := scanQuotaRule(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("quota rule with ID %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get quota rule: %w", err)
+	}
+
+	return rule, nil
+}
+
+// GetByListID retrieves the quota rules of a list
+func (r *QuotaRuleRepository) GetByListID(ctx context.Context, listID int) ([]models.QuotaRule, error) {
+	query := `SELECT ` + quotaRuleColumns + ` FROM quota_rules WHERE list_id = ? ORDER BY id ASC`
+
+	return r.queryQuotaRules(ctx, query, listID)
+}
+
+// GetEnabled retrieves all enabled quota rules
+func (r *QuotaRuleRepository) GetEnabled(ctx context.Context) ([]models.QuotaRule, error) {
+	query := `SELECT ` + quotaRuleColumns + ` FROM quota_rules WHERE enabled = 1 ORDER BY id ASC`
+
+	return r.queryQuotaRules(ctx, query)
+}
+
+// Update updates an existing quota rule
+func (r *QuotaRuleRepository) Update(ctx context.Context, rule *models.QuotaRule) error {
+	return r.update(ctx, r.db, rule)
+}
+
+// UpdateBatch updates rules in a single transaction
+func (r *QuotaRuleRepository) UpdateBatch(ctx context.Context, rules []models.QuotaRule) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin quota rule batch: %w", err)
+	}
+	defer tx.Rollback()
+
+	for i := range rules {
+		if err := r.update(ctx, tx, &rules[i]); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit quota rule batch: %w", err)
+	}
+	return nil
+}
+
+// update writes a rule with db, which is the connection or a transaction
+func (r *QuotaRuleRepository) update(ctx context.Context, db execer, rule *models.QuotaRule) error {
+	query := `
+		UPDATE quota_rules SET
+			list_id = ?, name = ?, quota_type = ?, limit_seconds = ?, enabled = ?,
+			carry_over = ?, carry_over_max_seconds = ?, updated_at = ?
+		WHERE id = ?
+	`
+
+	rule.UpdatedAt = time.Now()
+
+	result, err := db.ExecContext(ctx, query,
+		rule.ListID,
+		rule.Name,
+		rule.QuotaType,
+		rule.LimitSeconds,
+		rule.Enabled,
+		carryOverPolicy(rule.CarryOver),
+		rule.MaxCarryOverSeconds,
+		rule.UpdatedAt,
+		rule.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update quota rule: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get update result: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("quota rule with ID %d not found", rule.ID)
+	}
+
+	return nil
+}
+
+// Delete deletes a quota rule by ID. Its usage is deleted with it.
+func (r *QuotaRuleRepository) Delete(ctx context.Context, id int) error {
+	query := `DELETE FROM quota_rules WHERE id = ?`
+
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete quota rule: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get delete result: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("quota rule with ID %d not found", id)
+	}
+
+	return nil
+}
+
+// DeleteByListID deletes all quota rules of a list
+func (r *QuotaRuleRepository) DeleteByListID(ctx context.Context, listID int) error {
+	query := `DELETE FROM quota_rules WHERE list_id = ?`
+
+	if _, err := r.db.ExecContext(ctx, query, listID); err != nil {
+		return fmt.Errorf("failed to delete quota rules: %w", err)
+	}
+
+	return nil
+}
+
+// Count returns the total number of quota rules
+func (r *QuotaRuleRepository) Count(ctx context.Context) (int, error) {
+	query := `SELECT COUNT(*) FROM quota_rules`
+
+	var count int
+	err := r.db.QueryRowContext(ctx, query).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count quota rules: %w", err)
+	}
+
+	return count, nil
+}
+
+// Helper method to execute queries that return multiple quota rules
+func (r *QuotaRuleRepository) queryQuotaRules(ctx context.Context, query string, args ...interface{}) ([]models.QuotaRule, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query quota rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []models.QuotaRule
+	for rows.Next() {
+		rule, err := scanQuotaRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan quota rule: %w", err)
+		}
+		rules = append(rules, *rule)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over quota rules: %w", err)
+	}
+
+	return rules, nil
+}
+
+// scanQuotaRule reads the quotaRuleColumns of a row into a quota rule
+func scanQuotaRule(row scanner) (*models.QuotaRule, error) {
+	rule := &models.QuotaRule{}
+	err := row.Scan(
+		&rule.ID,
+		&rule.ListID,
+		&rule.Name,
+		&rule.QuotaType,
+		&rule.LimitSeconds,
+		&rule.Enabled,
+		&rule.CarryOver,
+		&rule.MaxCarryOverSeconds,
+		&rule.CreatedAt,
+		&rule.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// carryOverPolicy stores an unset carry-over policy as none, which the
+// column requires
+func carryOverPolicy(carryOver models.QuotaCarryOver) models.QuotaCarryOver {
+	if carryOver == "" {
+		return models.CarryOverNone
+	}
+	return carryOver
+}
+
+// QuotaUsageRepository implements the models.QuotaUsageRepository
+// interface. Window bounds are stored in UTC so they compare correctly as
+// text whatever the local zone's offset was when they were written.
+type QuotaUsageRepository struct {
+	db *sql.DB
+}
+
+// NewQuotaUsageRepository creates a new quota usage repository
+func NewQuotaUsageRepository(db *sql.DB) *QuotaUsageRepository {
+	return &QuotaUsageRepository{db: db}
+}
+
+// quotaUsageColumns are the columns scanned by scanQuotaUsage, in order
+const quotaUsageColumns = `id, quota_rule_id, period_start, period_end, used_seconds, carried_seconds, created_at, updated_at`
+
+// Create records a usage window
+func (r *QuotaUsageRepository) Create(ctx context.Context, usage *models.QuotaUsage) error {
+	query := `
+		INSERT INTO quota_usage (quota_rule_id, period_start, period_end, used_seconds, carried_seconds, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+
+	now := time.Now()
+	usage.CreatedAt = now
+	usage.UpdatedAt = now
+
+	result, err := r.db.ExecContext(ctx, query,
+		usage.QuotaRuleID,
+		usage.PeriodStart.UTC(),
+		usage.PeriodEnd.UTC(),
+		usage.UsedSeconds,
+		usage.CarriedSeconds,
+		usage.CreatedAt,
+		usage.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create quota usage: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get quota usage ID: %w", err)
+	}
+
+	usage.ID = int(id)
+	return nil
+}
+
+// GetByID retrieves a usage window by ID
+func (r *QuotaUsageRepository) GetByID(ctx context.Context, id int) (*models.QuotaUsage, error) {
+	query := `SELECT ` + quotaUsageColumns + ` FROM quota_usage WHERE id = ?`
+
+	usage, err := scanQuotaUsage(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("quota usage with ID %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get quota usage: %w", err)
+	}
+
+	return usage, nil
+}
+
+// GetByQuotaRuleID retrieves a rule's usage windows, newest first
+func (r *QuotaUsageRepository) GetByQuotaRuleID(ctx context.Context, quotaRuleID int) ([]models.QuotaUsage, error) {
+	query := `SELECT ` + quotaUsageColumns + ` FROM quota_usage WHERE quota_rule_id = ? ORDER BY period_start DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, quotaRuleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query quota usage: %w", err)
+	}
+	defer rows.Close()
+
+	var usages []models.QuotaUsage
+	for rows.Next() {
+		usage, err := scanQuotaUsage(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan quota usage: %w", err)
+		}
+		usages = append(usages, *usage)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over quota usage: %w", err)
+	}
+
+	return usages, nil
+}
+
+// GetCurrentUsage retrieves the usage window containing now. It returns an
+// error if no usage has been recorded in that window.
+func (r *QuotaUsageRepository) GetCurrentUsage(ctx context.Context, quotaRuleID int, now time.Time) (*models.QuotaUsage, error) {
+	query := `
+		SELECT ` + quotaUsageColumns + `
+		FROM quota_usage
+		WHERE quota_rule_id = ? AND period_start <= ? AND period_end >= ?
+		ORDER BY period_start DESC
+		LIMIT 1
+	`
+
+	usage, err := scanQuotaUsage(r.db.QueryRowContext(ctx, query, quotaRuleID, now.UTC(), now.UTC()))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("no usage for quota rule %d at %s", quotaRuleID, now.Format(time.RFC3339))
+		}
+		return nil, fmt.Errorf("failed to get current quota usage: %w", err)
+	}
+
+	return usage, nil
+}
+
+// UpdateUsage adds seconds to the usage window containing now. The window
+// must already exist.
+func (r *QuotaUsageRepository) UpdateUsage(ctx context.Context, quotaRuleID int, additionalSeconds int, now time.Time) error {
+	query := `
+		UPDATE quota_usage SET used_seconds = used_seconds + ?, updated_at = ?
+		WHERE quota_rule_id = ? AND period_start <= ? AND period_end >= ?
+	`
+
+	result, err := r.db.ExecContext(ctx, query, additionalSeconds, time.Now(), quotaRuleID, now.UTC(), now.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to update quota usage: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get update result: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("no usage window for quota rule %d at %s", quotaRuleID, now.Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+// GetUsageInPeriod retrieves the latest usage window starting between start
+// and end
+func (r *QuotaUsageRepository) GetUsageInPeriod(ctx context.Context, quotaRuleID int, start, end time.Time) (*models.QuotaUsage, error) {
+	query := `
+		SELECT ` + quotaUsageColumns + `
+		FROM quota_usage
+		WHERE quota_rule_id = ? AND period_start >= ? AND period_start <= ?
+		ORDER BY period_start DESC
+		LIMIT 1
+	`
+
+	usage, err := scanQuotaUsage(r.db.QueryRowContext(ctx, query, quotaRuleID, start.UTC(), end.UTC()))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("no usage for quota rule %d between %s and %s",
+				quotaRuleID, start.Format(time.RFC3339), end.Format(time.RFC3339))
+		}
+		return nil, fmt.Errorf("failed to get quota usage: %w", err)
+	}
+
+	return usage, nil
+}
+
+// CleanupExpiredUsage deletes usage windows that ended before the cutoff
+func (r *QuotaUsageRepository) CleanupExpiredUsage(ctx context.Context, before time.Time) error {
+	query := `DELETE FROM quota_usage WHERE period_end < ?`
+
+	if _, err := r.db.ExecContext(ctx, query, before.UTC()); err != nil {
+		return fmt.Errorf("failed to clean up quota usage: %w", err)
+	}
+
+	return nil
+}
+
+// Update updates an existing usage window
+func (r *QuotaUsageRepository) Update(ctx context.Context, usage *models.QuotaUsage) error {
+	query := `
+		UPDATE quota_usage SET
+			period_start = ?, period_end = ?, used_seconds = ?, carried_seconds = ?, updated_at = ?
+		WHERE id = ?
+	`
+
+	usage.UpdatedAt = time.Now()
+
+	result, err := r.db.ExecContext(ctx, query,
+		usage.PeriodStart.UTC(),
+		usage.PeriodEnd.UTC(),
+		usage.UsedSeconds,
+		usage.CarriedSeconds,
+		usage.UpdatedAt,
+		usage.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update quota usage: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get update result: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("quota usage with ID %d not found", usage.ID)
+	}
+
+	return nil
+}
+
+// Delete deletes a usage window by ID
+func (r *QuotaUsageRepository) Delete(ctx context.Context, id int) error {
+	query := `DELETE FROM quota_usage WHERE id = ?`
+
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete quota usage: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get delete result: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("quota usage with ID %d not found", id)
+	}
+
+	return nil
+}
+
+// scanQuotaUsage reads the quotaUsageColumns of a row into a usage window
+func scanQuotaUsage(row scanner) (*models.QuotaUsage, error) {
+	usage := &models.QuotaUsage{}
+	err := row.Scan(
+		&usage.ID,
+		&usage.QuotaRuleID,
+		&usage.PeriodStart,
+		&usage.PeriodEnd,
+		&usage.UsedSeconds,
+		&usage.CarriedSeconds,
+		&usage.CreatedAt,
+		&usage.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
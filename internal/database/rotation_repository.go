@@ -573,23 +573,28 @@ func (r *LogRotationExecutionRepository) GetStats(ctx context.Context) (*models.
 			COALESCE(SUM(files_rotated), 0) as total_files_rotated,
 			COALESCE(SUM(bytes_freed), 0) as total_bytes_freed,
 			COALESCE(SUM(bytes_compressed), 0) as total_bytes_compressed,
-			COALESCE(AVG(compression_ratio), 0) as avg_compression_ratio,
-			MAX(execution_time) as last_rotation_time
+			COALESCE(AVG(compression_ratio), 0) as avg_compression_ratio
 		FROM log_rotation_executions
 		WHERE status = 'completed'
 	`
 
-	var lastRotationTime sql.NullTime
 	err := r.db.QueryRowContext(ctx, query).Scan(
-		&stats.TotalFilesRotated, &stats.TotalFilesRotated, &stats.TotalBytesFreed,
-		&stats.TotalBytesCompressed, &stats.AverageCompressionRatio, &lastRotationTime,
+		&stats.TotalExecutions, &stats.TotalFilesRotated, &stats.TotalBytesFreed,
+		&stats.TotalBytesCompressed, &stats.AverageCompressionRatio,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get rotation stats: %w", err)
 	}
 
-	if lastRotationTime.Valid {
-		stats.LastRotationTime = lastRotationTime.Time
+	// Select the row rather than MAX() so the driver still parses the timestamp
+	lastRotationQuery := `
+		SELECT execution_time FROM log_rotation_executions
+		WHERE status = 'completed'
+		ORDER BY execution_time DESC LIMIT 1
+	`
+	err = r.db.QueryRowContext(ctx, lastRotationQuery).Scan(&stats.LastRotationTime)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get last rotation time: %w", err)
 	}
 
 	// Get policy count
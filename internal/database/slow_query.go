@@ -0,0 +1,199 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	sqlite3 "github.com/mattn/go-sqlite3"
+
+	"parental-control/internal/logging"
+)
+
+// maxLoggedQueryLength truncates statements in slow query logs; bulk
+// inserts can be long
+const maxLoggedQueryLength = 500
+
+// slowQueryLog logs statements slower than threshold and counts them
+type slowQueryLog struct {
+	threshold time.Duration
+	count     atomic.Int64
+}
+
+// observe logs the statement if it has been running longer than the
+// threshold since start. Arguments are left out; they can hold password
+// hashes and browsing history.
+func (l *slowQueryLog) observe(pool, query string, start time.Time, err error) {
+	duration := time.Since(start)
+	if duration < l.threshold {
+		return
+	}
+	l.count.Add(1)
+
+	fields := []logging.Field{
+		logging.Duration("duration", duration),
+		logging.String("pool", pool),
+		logging.String("query", compactQuery(query)),
+	}
+	if err != nil {
+		fields = append(fields, logging.Err(err))
+	}
+	logging.Warn("Slow database query", fields...)
+}
+
+// compactQuery folds a statement's whitespace onto one line and truncates it
+func compactQuery(query string) string {
+	query = strings.Join(strings.Fields(query), " ")
+	if len(query) > maxLoggedQueryLength {
+		query = query[:maxLoggedQueryLength] + "..."
+	}
+	return query
+}
+
+// openPool opens a connection pool for dsn, timing its statements when slow
+// is set
+func openPool(dsn, pool string, slow *slowQueryLog) (*sql.DB, error) {
+	if slow == nil {
+		return sql.Open("sqlite3", dsn)
+	}
+	return sql.OpenDB(&slowQueryConnector{
+		driver: &sqlite3.SQLiteDriver{},
+		dsn:    dsn,
+		pool:   pool,
+		slow:   slow,
+	}), nil
+}
+
+// slowQueryConnector opens sqlite3 connections that time their statements
+type slowQueryConnector struct {
+	driver *sqlite3.SQLiteDriver
+	dsn    string
+	pool   string
+	slow   *slowQueryLog
+}
+
+func (c *slowQueryConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.driver.Open(c.dsn)
+	if err != nil {
+		return nil, err
+	}
+	sqliteConn, ok := conn.(*sqlite3.SQLiteConn)
+	if !ok {
+		conn.Close()
+		return nil, fmt.Errorf("unexpected sqlite3 connection type %T", conn)
+	}
+	return &slowQueryConn{SQLiteConn: sqliteConn, pool: c.pool, slow: c.slow}, nil
+}
+
+func (c *slowQueryConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// slowQueryConn times statements run directly on the connection or through
+// statements it prepares. SQLite does most of a query's work as its rows are
+// read, so queries are timed until their rows are closed.
+type slowQueryConn struct {
+	*sqlite3.SQLiteConn
+	pool string
+	slow *slowQueryLog
+}
+
+func (c *slowQueryConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	start := time.Now()
+	result, err := c.SQLiteConn.ExecContext(ctx, query, args)
+	c.slow.observe(c.pool, query, start, err)
+	return result, err
+}
+
+func (c *slowQueryConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	start := time.Now()
+	rows, err := c.SQLiteConn.QueryContext(ctx, query, args)
+	if err != nil {
+		c.slow.observe(c.pool, query, start, err)
+		return nil, err
+	}
+	return c.timeRows(rows, query, start), nil
+}
+
+func (c *slowQueryConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	stmt, err := c.SQLiteConn.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	sqliteStmt, ok := stmt.(*sqlite3.SQLiteStmt)
+	if !ok {
+		return stmt, nil
+	}
+	return &slowQueryStmt{SQLiteStmt: sqliteStmt, conn: c, query: query}, nil
+}
+
+// timeRows observes the query when its rows are closed
+func (c *slowQueryConn) timeRows(rows driver.Rows, query string, start time.Time) driver.Rows {
+	sqliteRows, ok := rows.(*sqlite3.SQLiteRows)
+	if !ok {
+		c.slow.observe(c.pool, query, start, nil)
+		return rows
+	}
+	return &slowQueryRows{SQLiteRows: sqliteRows, conn: c, query: query, start: start}
+}
+
+// slowQueryStmt times each execution of a prepared statement
+type slowQueryStmt struct {
+	*sqlite3.SQLiteStmt
+	conn  *slowQueryConn
+	query string
+}
+
+func (s *slowQueryStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	start := time.Now()
+	result, err := s.SQLiteStmt.ExecContext(ctx, args)
+	s.conn.slow.observe(s.conn.pool, s.query, start, err)
+	return result, err
+}
+
+func (s *slowQueryStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	start := time.Now()
+	rows, err := s.SQLiteStmt.QueryContext(ctx, args)
+	if err != nil {
+		s.conn.slow.observe(s.conn.pool, s.query, start, err)
+		return nil, err
+	}
+	return s.conn.timeRows(rows, s.query, start), nil
+}
+
+// slowQueryRows observes its query once the caller is done with the rows
+type slowQueryRows struct {
+	*sqlite3.SQLiteRows
+	conn  *slowQueryConn
+	query string
+	start time.Time
+}
+
+func (r *slowQueryRows) Close() error {
+	err := r.SQLiteRows.Close()
+	r.conn.slow.observe(r.conn.pool, r.query, r.start, nil)
+	return err
+}
+
+// unwrapConn returns the sqlite3 connection under a driver connection from
+// either kind of pool
+func unwrapConn(driverConn interface{}) (*sqlite3.SQLiteConn, bool) {
+	if conn, ok := driverConn.(*slowQueryConn); ok {
+		return conn.SQLiteConn, true
+	}
+	conn, ok := driverConn.(*sqlite3.SQLiteConn)
+	return conn, ok
+}
+
+// SlowQueries returns how many statements have taken longer than the slow
+// query threshold, or 0 when slow query logging is off
+func (db *DB) SlowQueries() int64 {
+	if db.slowQueries == nil {
+		return 0
+	}
+	return db.slowQueries.count.Load()
+}
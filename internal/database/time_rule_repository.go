@@ -0,0 +1,276 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"parental-control/internal/models"
+)
+
+// TimeRuleRepository implements the models.TimeRuleRepository interface
+type TimeRuleRepository struct {
+	db *sql.DB
+}
+
+// NewTimeRuleRepository creates a new time rule repository
+func NewTimeRuleRepository(db *sql.DB) *TimeRuleRepository {
+	return &TimeRuleRepository{db: db}
+}
+
+// timeRuleColumns are the columns scanned by scanTimeRule, in order
+const timeRuleColumns = `id, list_id, name, rule_type, days_of_week, start_time, end_time, timezone, enabled, created_at, updated_at`
+
+// Create creates a new time rule
+func (r *TimeRuleRepository) Create(ctx context.Context, rule *models.TimeRule) error {
+	query := `
+		INSERT INTO time_rules (list_id, name, rule_type, days_of_week, start_time, end_time, timezone, enabled, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	days, err := rule.MarshalDaysOfWeek()
+	if err != nil {
+		return fmt.Errorf("failed to encode days of week: %w", err)
+	}
+
+	now := time.Now()
+	rule.CreatedAt = now
+	rule.UpdatedAt = now
+
+	result, err := r.db.ExecContext(ctx, query,
+		rule.ListID,
+		rule.Name,
+		rule.RuleType,
+		days,
+		rule.StartTime,
+		rule.EndTime,
+		rule.Timezone,
+		rule.Enabled,
+		rule.CreatedAt,
+		rule.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create time rule: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get time rule ID: %w", err)
+	}
+
+	rule.ID = int(id)
+	return nil
+}
+
+// GetByID retrieves a time rule by ID
+func (r *TimeRuleRepository) GetByID(ctx context.Context, id int) (*models.TimeRule, error) {
+	query := `SELECT ` + timeRuleColumns + ` FROM time_rules WHERE id = ?`
+
+	rule, err := scanTimeRule(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("time rule with ID %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get time rule: %w", err)
+	}
+
+	return rule, nil
+}
+
+// GetByListID retrieves the time rules of a list
+func (r *TimeRuleRepository) GetByListID(ctx context.Context, listID int) ([]models.TimeRule, error) {
+	query := `SELECT ` + timeRuleColumns + ` FROM time_rules WHERE list_id = ? ORDER BY id ASC`
+
+	return r.queryTimeRules(ctx, query, listID)
+}
+
+// GetEnabled retrieves all enabled time rules
+func (r *TimeRuleRepository) GetEnabled(ctx context.Context) ([]models.TimeRule, error) {
+	query := `SELECT ` + timeRuleColumns + ` FROM time_rules WHERE enabled = 1 ORDER BY id ASC`
+
+	return r.queryTimeRules(ctx, query)
+}
+
+// GetActiveRules retrieves the enabled time rules whose window contains now.
+// Windows are on the wall clock of each rule's zone, so they are checked
+// after loading rather than in SQL.
+func (r *TimeRuleRepository) GetActiveRules(ctx context.Context, now time.Time) ([]models.TimeRule, error) {
+	rules, err := r.GetEnabled(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var active []models.TimeRule
+	for _, rule := range rules {
+		if rule.ActiveAt(now) {
+			active = append(active, rule)
+		}
+	}
+	return active, nil
+}
+
+// Update updates an existing time rule
+func (r *TimeRuleRepository) Update(ctx context.Context, rule *models.TimeRule) error {
+	return r.update(ctx, r.db, rule)
+}
+
+// UpdateBatch updates rules in a single transaction
+func (r *TimeRuleRepository) UpdateBatch(ctx context.Context, rules []models.TimeRule) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin time rule batch: %w", err)
+	}
+	defer tx.Rollback()
+
+	for i := range rules {
+		if err := r.update(ctx, tx, &rules[i]); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit time rule batch: %w", err)
+	}
+	return nil
+}
+
+// update writes a rule with db, which is the connection or a transaction
+func (r *TimeRuleRepository) update(ctx context.Context, db execer, rule *models.TimeRule) error {
+	query := `
+		UPDATE time_rules SET
+			list_id = ?, name = ?, rule_type = ?, days_of_week = ?, start_time = ?, end_time = ?,
+			timezone = ?, enabled = ?, updated_at = ?
+		WHERE id = ?
+	`
+
+	days, err := rule.MarshalDaysOfWeek()
+	if err != nil {
+		return fmt.Errorf("failed to encode days of week: %w", err)
+	}
+
+	rule.UpdatedAt = time.Now()
+
+	result, err := db.ExecContext(ctx, query,
+		rule.ListID,
+		rule.Name,
+		rule.RuleType,
+		days,
+		rule.StartTime,
+		rule.EndTime,
+		rule.Timezone,
+		rule.Enabled,
+		rule.UpdatedAt,
+		rule.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update time rule: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get update result: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("time rule with ID %d not found", rule.ID)
+	}
+
+	return nil
+}
+
+// Delete deletes a time rule by ID
+func (r *TimeRuleRepository) Delete(ctx context.Context, id int) error {
+	query := `DELETE FROM time_rules WHERE id = ?`
+
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete time rule: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get delete result: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("time rule with ID %d not found", id)
+	}
+
+	return nil
+}
+
+// DeleteByListID deletes all time rules of a list
+func (r *TimeRuleRepository) DeleteByListID(ctx context.Context, listID int) error {
+	query := `DELETE FROM time_rules WHERE list_id = ?`
+
+	if _, err := r.db.ExecContext(ctx, query, listID); err != nil {
+		return fmt.Errorf("failed to delete time rules: %w", err)
+	}
+
+	return nil
+}
+
+// Count returns the total number of time rules
+func (r *TimeRuleRepository) Count(ctx context.Context) (int, error) {
+	query := `SELECT COUNT(*) FROM time_rules`
+
+	var count int
+	err := r.db.QueryRowContext(ctx, query).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count time rules: %w", err)
+	}
+
+	return count, nil
+}
+
+// Helper method to execute queries that return multiple time rules
+func (r *TimeRuleRepository) queryTimeRules(ctx context.Context, query string, args ...interface{}) ([]models.TimeRule, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query time rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []models.TimeRule
+	for rows.Next() {
+		rule, err := scanTimeRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan time rule: %w", err)
+		}
+		rules = append(rules, *rule)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over time rules: %w", err)
+	}
+
+	return rules, nil
+}
+
+// scanTimeRule reads the timeRuleColumns of a row into a time rule
+func scanTimeRule(row scanner) (*models.TimeRule, error) {
+	rule := &models.TimeRule{}
+	var days string
+	err := row.Scan(
+		&rule.ID,
+		&rule.ListID,
+		&rule.Name,
+		&rule.RuleType,
+		&days,
+		&rule.StartTime,
+		&rule.EndTime,
+		&rule.Timezone,
+		&rule.Enabled,
+		&rule.CreatedAt,
+		&rule.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := rule.UnmarshalDaysOfWeek(days); err != nil {
+		return nil, fmt.Errorf("invalid days of week for time rule %d: %w", rule.ID, err)
+	}
+	return rule, nil
+}
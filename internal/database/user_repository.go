@@ -0,0 +1,208 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"parental-control/internal/models"
+)
+
+// UserRepository implements the models.UserRepository interface
+type UserRepository struct {
+	db *sql.DB
+}
+
+// NewUserRepository creates a new user repository
+func NewUserRepository(db *sql.DB) *UserRepository {
+	return &UserRepository{db: db}
+}
+
+// userColumns are the columns scanned by scanUser, in order
+const userColumns = `id, username, password_hash, email, is_active, is_admin, last_login_at, password_changed_at,
+	failed_attempts, locked_until, two_factor_enabled, totp_secret, last_totp_step, created_at, updated_at`
+
+// GetAll retrieves every user ordered by ID
+func (r *UserRepository) GetAll(ctx context.Context) ([]models.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users ORDER BY id ASC`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []models.User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, *user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over users: %w", err)
+	}
+
+	return users, nil
+}
+
+// GetByUsername retrieves a user by username
+func (r *UserRepository) GetByUsername(ctx context.Context, username string) (*models.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE username = ?`
+
+	user, err := scanUser(r.db.QueryRowContext(ctx, query, username))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("user %s not found", username)
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return user, nil
+}
+
+// Create creates a new user and sets its ID
+func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
+	query := `
+		INSERT INTO users (username, password_hash, email, is_active, is_admin, last_login_at, password_changed_at,
+			failed_attempts, locked_until, two_factor_enabled, totp_secret, last_totp_step, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	now := time.Now()
+	if user.CreatedAt.IsZero() {
+		user.CreatedAt = now
+	}
+	if user.PasswordChangedAt.IsZero() {
+		user.PasswordChangedAt = now
+	}
+	user.UpdatedAt = now
+
+	result, err := r.db.ExecContext(ctx, query,
+		user.Username,
+		user.PasswordHash,
+		user.Email,
+		user.IsActive,
+		user.IsAdmin,
+		user.LastLoginAt,
+		user.PasswordChangedAt,
+		user.FailedAttempts,
+		user.LockedUntil,
+		user.TwoFactorEnabled,
+		user.TOTPSecret,
+		user.LastTOTPStep,
+		user.CreatedAt,
+		user.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get user ID: %w", err)
+	}
+
+	user.ID = int(id)
+	return nil
+}
+
+// Update updates an existing user
+func (r *UserRepository) Update(ctx context.Context, user *models.User) error {
+	query := `
+		UPDATE users SET
+			username = ?, password_hash = ?, email = ?, is_active = ?, is_admin = ?, last_login_at = ?,
+			password_changed_at = ?, failed_attempts = ?, locked_until = ?, two_factor_enabled = ?,
+			totp_secret = ?, last_totp_step = ?, updated_at = ?
+		WHERE id = ?
+	`
+
+	user.UpdatedAt = time.Now()
+
+	result, err := r.db.ExecContext(ctx, query,
+		user.Username,
+		user.PasswordHash,
+		user.Email,
+		user.IsActive,
+		user.IsAdmin,
+		user.LastLoginAt,
+		user.PasswordChangedAt,
+		user.FailedAttempts,
+		user.LockedUntil,
+		user.TwoFactorEnabled,
+		user.TOTPSecret,
+		user.LastTOTPStep,
+		user.UpdatedAt,
+		user.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get update result: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("user with ID %d not found", user.ID)
+	}
+
+	return nil
+}
+
+// Delete deletes a user by ID
+func (r *UserRepository) Delete(ctx context.Context, id int) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM users WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get delete result: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("user with ID %d not found", id)
+	}
+
+	return nil
+}
+
+// scanUser reads the userColumns of a row into a user
+func scanUser(row scanner) (*models.User, error) {
+	user := &models.User{}
+	var lastLoginAt, lockedUntil sql.NullTime
+	err := row.Scan(
+		&user.ID,
+		&user.Username,
+		&user.PasswordHash,
+		&user.Email,
+		&user.IsActive,
+		&user.IsAdmin,
+		&lastLoginAt,
+		&user.PasswordChangedAt,
+		&user.FailedAttempts,
+		&lockedUntil,
+		&user.TwoFactorEnabled,
+		&user.TOTPSecret,
+		&user.LastTOTPStep,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if lastLoginAt.Valid {
+		user.LastLoginAt = &lastLoginAt.Time
+	}
+	if lockedUntil.Valid {
+		user.LockedUntil = &lockedUntil.Time
+	}
+	return user, nil
+}
@@ -0,0 +1,240 @@
+package diagnostics
+
+import (
+	"archive/zip"
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/user"
+	"runtime"
+	"time"
+
+	"parental-control/internal/config"
+	"parental-control/internal/database"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultLogLines is how many trailing log lines a bundle includes
+const DefaultLogLines = 500
+
+// BundleOptions controls what goes into a diagnostics bundle
+type BundleOptions struct {
+	// Config is the effective configuration to include (secrets are always redacted)
+	Config *config.Config
+	// Version of the running binary
+	Version string
+	// ScrubLevel for domains, IPs and usernames
+	ScrubLevel ScrubLevel
+	// LogLines limits how much of the log file is included
+	LogLines int
+	// PerformanceURL is fetched for a live performance report when set
+	PerformanceURL string
+}
+
+// Manifest describes the contents of a diagnostics bundle
+type Manifest struct {
+	CreatedAt  time.Time  `json:"created_at"`
+	Version    string     `json:"version"`
+	GoVersion  string     `json:"go_version"`
+	OS         string     `json:"os"`
+	Arch       string     `json:"arch"`
+	NumCPU     int        `json:"num_cpu"`
+	ScrubLevel ScrubLevel `json:"scrub_level"`
+	Files      []string   `json:"files"`
+	Notes      []string   `json:"notes,omitempty"`
+}
+
+// WriteBundle collects the redacted effective config, recent logs, database
+// schema information and a performance report into a zip archive
+func WriteBundle(ctx context.Context, w io.Writer, opts BundleOptions) (*Manifest, error) {
+	if opts.Config == nil {
+		return nil, fmt.Errorf("configuration is required")
+	}
+	if opts.LogLines <= 0 {
+		opts.LogLines = DefaultLogLines
+	}
+
+	scrubber, err := NewScrubber(opts.ScrubLevel, localIdentifiers()...)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &Manifest{
+		CreatedAt:  time.Now().UTC(),
+		Version:    opts.Version,
+		GoVersion:  runtime.Version(),
+		OS:         runtime.GOOS,
+		Arch:       runtime.GOARCH,
+		NumCPU:     runtime.NumCPU(),
+		ScrubLevel: scrubber.Level(),
+	}
+
+	archive := zip.NewWriter(w)
+	add := func(name string, content []byte) error {
+		f, err := archive.Create(name)
+		if err != nil {
+			return fmt.Errorf("failed to add %s to bundle: %w", name, err)
+		}
+		if _, err := f.Write([]byte(scrubber.Scrub(string(content)))); err != nil {
+			return fmt.Errorf("failed to write %s to bundle: %w", name, err)
+		}
+		manifest.Files = append(manifest.Files, name)
+		return nil
+	}
+
+	// Effective configuration with secrets removed
+	configYAML, err := yaml.Marshal(opts.Config.Redacted())
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode configuration: %w", err)
+	}
+	if err := add("config.yaml", configYAML); err != nil {
+		return nil, err
+	}
+
+	summary, _ := json.MarshalIndent(map[string]interface{}{
+		"summary":           opts.Config.Summary(),
+		"insecure_warnings": opts.Config.InsecureWarnings(),
+	}, "", "  ")
+	if err := add("summary.json", summary); err != nil {
+		return nil, err
+	}
+
+	// Recent application logs
+	if logs, note := tailLogFile(opts.Config.Logging.Output, opts.LogLines); logs != nil {
+		if err := add("logs.txt", logs); err != nil {
+			return nil, err
+		}
+	} else {
+		manifest.Notes = append(manifest.Notes, note)
+	}
+
+	// Database schema version and size
+	if stats, note := databaseStats(opts.Config.Database); stats != nil {
+		if err := add("database.json", stats); err != nil {
+			return nil, err
+		}
+	} else {
+		manifest.Notes = append(manifest.Notes, note)
+	}
+
+	// Live performance report from a running instance
+	if opts.PerformanceURL != "" {
+		if report, err := fetchPerformanceReport(ctx, opts.PerformanceURL); err == nil {
+			if err := add("performance.json", report); err != nil {
+				return nil, err
+			}
+		} else {
+			manifest.Notes = append(manifest.Notes, fmt.Sprintf("performance report unavailable: %v", err))
+		}
+	}
+
+	manifestJSON, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	if err := add("manifest.json", manifestJSON); err != nil {
+		return nil, err
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finalize bundle: %w", err)
+	}
+	return manifest, nil
+}
+
+// localIdentifiers returns the current username and host name so they are
+// scrubbed from paths and log lines
+func localIdentifiers() []string {
+	var identifiers []string
+	if current, err := user.Current(); err == nil {
+		identifiers = append(identifiers, current.Username)
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		identifiers = append(identifiers, hostname)
+	}
+	return identifiers
+}
+
+// tailLogFile returns the last lines of a file-based log output
+func tailLogFile(output string, lines int) ([]byte, string) {
+	switch output {
+	case "", "stdout", "stderr":
+		return nil, fmt.Sprintf("logs are written to %q; attach them from the service manager (e.g. journalctl)", output)
+	}
+
+	f, err := os.Open(output)
+	if err != nil {
+		return nil, fmt.Sprintf("log file unavailable: %v", err)
+	}
+	defer f.Close()
+
+	ring := make([]string, 0, lines)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if len(ring) == lines {
+			ring = ring[1:]
+		}
+		ring = append(ring, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Sprintf("failed to read log file: %v", err)
+	}
+
+	var out []byte
+	for _, line := range ring {
+		out = append(out, line...)
+		out = append(out, '\n')
+	}
+	return out, ""
+}
+
+// databaseStats reports the schema version and size without creating a
+// database that does not exist yet
+func databaseStats(dbConfig database.Config) ([]byte, string) {
+	if _, err := os.Stat(dbConfig.Path); err != nil {
+		return nil, fmt.Sprintf("database unavailable: %v", err)
+	}
+
+	db, err := database.New(dbConfig)
+	if err != nil {
+		return nil, fmt.Sprintf("database unavailable: %v", err)
+	}
+	defer db.Close()
+
+	stats, err := db.GetStats()
+	if err != nil {
+		return nil, fmt.Sprintf("failed to read database stats: %v", err)
+	}
+	encoded, err := json.MarshalIndent(stats, "", "  ")
+	if err != nil {
+		return nil, fmt.Sprintf("failed to encode database stats: %v", err)
+	}
+	return encoded, ""
+}
+
+// fetchPerformanceReport asks a running instance for its performance report
+func fetchPerformanceReport(ctx context.Context, url string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 10*1024*1024))
+}
@@ -0,0 +1,140 @@
+package diagnostics
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ScrubLevel controls how personal details are treated in a diagnostics bundle
+type ScrubLevel string
+
+const (
+	// ScrubNone leaves collected data untouched apart from secret redaction
+	ScrubNone ScrubLevel = "none"
+	// ScrubHash replaces identifying values with salted hashes. The same value
+	// always maps to the same token within one bundle, so relationships such
+	// as "this domain appears in both the config and the logs" survive.
+	ScrubHash ScrubLevel = "hash"
+	// ScrubRedact replaces identifying values with a fixed placeholder
+	ScrubRedact ScrubLevel = "redact"
+)
+
+// ParseScrubLevel validates a scrub level name
+func ParseScrubLevel(value string) (ScrubLevel, error) {
+	switch ScrubLevel(strings.ToLower(value)) {
+	case ScrubNone:
+		return ScrubNone, nil
+	case "", ScrubHash:
+		return ScrubHash, nil
+	case ScrubRedact:
+		return ScrubRedact, nil
+	default:
+		return "", fmt.Errorf("invalid scrub level %q (expected none, hash or redact)", value)
+	}
+}
+
+var (
+	emailPattern  = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	ipv4Pattern   = regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`)
+	ipv6Pattern   = regexp.MustCompile(`\[?[0-9A-Fa-f]{0,4}(?::[0-9A-Fa-f]{0,4}){2,7}\]?`)
+	domainPattern = regexp.MustCompile(`\b(?:[A-Za-z0-9](?:[A-Za-z0-9-]{0,61}[A-Za-z0-9])?\.)+[A-Za-z]{2,63}\b`)
+)
+
+// fileExtensions are dotted names that look like domains but are file names
+var fileExtensions = map[string]bool{
+	".yaml": true, ".yml": true, ".json": true, ".toml": true, ".go": true, ".db": true,
+	".pid": true, ".log": true, ".txt": true, ".pem": true, ".crt": true,
+	".key": true, ".sock": true, ".sql": true, ".zip": true, ".gz": true,
+	".service": true, ".conf": true, ".exe": true,
+}
+
+// Scrubber removes domains, IP addresses, e-mail addresses and known
+// sensitive terms such as usernames from free text
+type Scrubber struct {
+	level     ScrubLevel
+	salt      []byte
+	sensitive []string
+}
+
+// NewScrubber creates a scrubber. Sensitive terms (usernames, host names)
+// are always replaced wherever they appear.
+func NewScrubber(level ScrubLevel, sensitive ...string) (*Scrubber, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate scrub salt: %w", err)
+	}
+
+	terms := make([]string, 0, len(sensitive))
+	for _, term := range sensitive {
+		if len(term) >= 3 {
+			terms = append(terms, term)
+		}
+	}
+	// Replace longer terms first so substrings do not break them up
+	sort.Slice(terms, func(i, j int) bool { return len(terms[i]) > len(terms[j]) })
+
+	return &Scrubber{level: level, salt: salt, sensitive: terms}, nil
+}
+
+// Level returns the scrub level in use
+func (s *Scrubber) Level() ScrubLevel {
+	return s.level
+}
+
+// Scrub returns text with identifying values replaced
+func (s *Scrubber) Scrub(text string) string {
+	if s.level == ScrubNone {
+		return text
+	}
+
+	text = emailPattern.ReplaceAllStringFunc(text, func(match string) string {
+		return s.replace("user", match)
+	})
+	for _, term := range s.sensitive {
+		text = strings.ReplaceAll(text, term, s.replace("user", term))
+	}
+	text = ipv6Pattern.ReplaceAllStringFunc(text, func(match string) string {
+		ip := net.ParseIP(strings.Trim(match, "[]"))
+		if ip == nil || ip.To4() != nil || keepIP(ip) {
+			return match
+		}
+		return s.replace("ip", match)
+	})
+	text = ipv4Pattern.ReplaceAllStringFunc(text, func(match string) string {
+		ip := net.ParseIP(match)
+		if ip == nil || keepIP(ip) {
+			return match
+		}
+		return s.replace("ip", match)
+	})
+	text = domainPattern.ReplaceAllStringFunc(text, func(match string) string {
+		if fileExtensions[strings.ToLower(filepath.Ext(match))] {
+			return match
+		}
+		return s.replace("domain", match)
+	})
+
+	return text
+}
+
+// replace produces the placeholder for a scrubbed value
+func (s *Scrubber) replace(kind, value string) string {
+	if s.level == ScrubRedact {
+		return "[" + kind + "]"
+	}
+
+	sum := sha256.Sum256(append(append([]byte{}, s.salt...), strings.ToLower(value)...))
+	return kind + "-" + hex.EncodeToString(sum[:4])
+}
+
+// keepIP reports whether an address is generic enough to leave in place
+func keepIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsUnspecified()
+}
@@ -0,0 +1,118 @@
+package diagnostics
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"parental-control/internal/config"
+)
+
+func TestScrubber_HashIsConsistent(t *testing.T) {
+	scrubber, err := NewScrubber(ScrubHash, "alice")
+	if err != nil {
+		t.Fatalf("Failed to create scrubber: %v", err)
+	}
+
+	input := "alice blocked games.example.com from 192.168.1.20 and 2001:db8::7; again games.example.com; config.yaml on 127.0.0.1"
+	out := scrubber.Scrub(input)
+
+	for _, leaked := range []string{"alice", "games.example.com", "192.168.1.20", "2001:db8::7"} {
+		if strings.Contains(out, leaked) {
+			t.Errorf("Scrubbed output still contains %q: %s", leaked, out)
+		}
+	}
+	for _, kept := range []string{"config.yaml", "127.0.0.1"} {
+		if !strings.Contains(out, kept) {
+			t.Errorf("Expected %q to be kept: %s", kept, out)
+		}
+	}
+
+	token := scrubber.Scrub("games.example.com")
+	if strings.Count(out, token) != 2 {
+		t.Errorf("Expected the same domain to hash to the same token twice, got %s", out)
+	}
+}
+
+func TestScrubber_Levels(t *testing.T) {
+	none, _ := NewScrubber(ScrubNone)
+	if got := none.Scrub("example.com 10.0.0.1"); got != "example.com 10.0.0.1" {
+		t.Errorf("Expected no scrubbing, got %q", got)
+	}
+
+	redact, _ := NewScrubber(ScrubRedact)
+	if got := redact.Scrub("example.com 10.0.0.1 kid@example.org"); got != "[domain] [ip] [user]" {
+		t.Errorf("Unexpected redacted output %q", got)
+	}
+
+	if _, err := ParseScrubLevel("bogus"); err == nil {
+		t.Error("Expected an invalid scrub level to be rejected")
+	}
+}
+
+func TestWriteBundle_RedactsSecrets(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "app.log")
+	if err := os.WriteFile(logPath, []byte("first line\nquery for school.example.net\n"), 0600); err != nil {
+		t.Fatalf("Failed to write log file: %v", err)
+	}
+
+	cfg := config.Default()
+	cfg.Security.AdminPassword = "hunter2-admin"
+	cfg.Security.SessionSecret = "session-secret-value"
+	cfg.Logging.Output = logPath
+	cfg.Database.Path = filepath.Join(dir, "missing.db")
+
+	var buf bytes.Buffer
+	manifest, err := WriteBundle(context.Background(), &buf, BundleOptions{
+		Config:     cfg,
+		Version:    "test",
+		ScrubLevel: ScrubRedact,
+		LogLines:   1,
+	})
+	if err != nil {
+		t.Fatalf("WriteBundle failed: %v", err)
+	}
+	if len(manifest.Notes) == 0 {
+		t.Error("Expected a note about the missing database")
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Bundle is not a valid zip: %v", err)
+	}
+
+	contents := make(map[string]string)
+	for _, f := range reader.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("Failed to open %s: %v", f.Name, err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		contents[f.Name] = string(data)
+	}
+
+	for _, name := range []string{"config.yaml", "summary.json", "logs.txt", "manifest.json"} {
+		if _, ok := contents[name]; !ok {
+			t.Errorf("Expected %s in bundle", name)
+		}
+	}
+
+	for name, data := range contents {
+		if strings.Contains(data, "hunter2-admin") || strings.Contains(data, "session-secret-value") {
+			t.Errorf("%s leaked a secret", name)
+		}
+	}
+	if strings.Contains(contents["logs.txt"], "first line") {
+		t.Error("Expected only the last log line to be included")
+	}
+	if strings.Contains(contents["logs.txt"], "school.example.net") {
+		t.Error("Expected domains in logs to be scrubbed")
+	}
+}
@@ -0,0 +1,73 @@
+package enforcement
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/miekg/dns"
+)
+
+// BlockResponse controls how the DNS blocker answers a blocked lookup.
+//
+// A sinkhole answer points the client at BlockIPv4/BlockIPv6, which is what
+// lets a block page be shown, but some apps then retry or wait for a
+// connection to time out. NXDOMAIN fails fast and looks like a missing site;
+// REFUSED fails fast too, though some clients fall back to another resolver
+// when they see it.
+type BlockResponse string
+
+const (
+	// BlockResponseSinkhole answers with the configured block addresses
+	BlockResponseSinkhole BlockResponse = "sinkhole"
+	// BlockResponseNXDomain answers that the domain does not exist
+	BlockResponseNXDomain BlockResponse = "nxdomain"
+	// BlockResponseRefused refuses the query
+	BlockResponseRefused BlockResponse = "refused"
+)
+
+// ParseBlockResponse validates a block response name. Empty selects
+// BlockResponseSinkhole.
+func ParseBlockResponse(value string) (BlockResponse, error) {
+	switch BlockResponse(value) {
+	case "", BlockResponseSinkhole:
+		return BlockResponseSinkhole, nil
+	case BlockResponseNXDomain, BlockResponseRefused:
+		return BlockResponse(value), nil
+	default:
+		return "", fmt.Errorf("invalid DNS block response %q (expected sinkhole, nxdomain or refused)", value)
+	}
+}
+
+// blockedReply builds the answer to a blocked query r
+func (b *DNSBlocker) blockedReply(r *dns.Msg) *dns.Msg {
+	msg := new(dns.Msg)
+	msg.SetReply(r)
+	msg.Authoritative = true
+
+	switch b.config.BlockResponse {
+	case BlockResponseNXDomain:
+		msg.Rcode = dns.RcodeNameError
+		return msg
+	case BlockResponseRefused:
+		msg.Authoritative = false
+		msg.Rcode = dns.RcodeRefused
+		return msg
+	}
+
+	q := r.Question[0]
+	blockIPv4 := net.ParseIP(b.config.BlockIPv4)
+	blockIPv6 := net.ParseIP(b.config.BlockIPv6)
+
+	if q.Qtype == dns.TypeA && blockIPv4 != nil {
+		msg.Answer = append(msg.Answer, &dns.A{
+			Hdr: dns.RR_Header{Name: q.Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 60},
+			A:   blockIPv4,
+		})
+	} else if q.Qtype == dns.TypeAAAA && blockIPv6 != nil {
+		msg.Answer = append(msg.Answer, &dns.AAAA{
+			Hdr:  dns.RR_Header{Name: q.Name, Rrtype: dns.TypeAAAA, Class: dns.ClassINET, Ttl: 60},
+			AAAA: blockIPv6,
+		})
+	}
+	return msg
+}
@@ -2,10 +2,13 @@ package enforcement
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"parental-control/internal/logging"
@@ -13,21 +16,37 @@ import (
 	"github.com/miekg/dns"
 )
 
+// ErrDNSPortInUse is returned when another process already owns the DNS listen address
+var ErrDNSPortInUse = errors.New("DNS listen address is already in use")
+
 // DNSBlocker intercepts DNS queries and blocks requests based on rules.
 type DNSBlocker struct {
 	config  *DNSBlockerConfig
 	logger  logging.Logger
 	manager *DNSManager
-	rules   map[string]*FilterRule
-	rulesMu sync.RWMutex
 
-	server4   *dns.Server
-	server6   *dns.Server
+	// rules is an immutable pattern -> rule map, replaced whole on every
+	// change so queries read it without locking. rulesMu serializes writers
+	// and guards onBypassAttempt.
+	rules   atomic.Pointer[map[string]*FilterRule]
+	rulesMu sync.Mutex
+
+	servers   []*dns.Server
 	running   bool
 	runningMu sync.RWMutex
 
-	stats   DNSBlockerStats
-	statsMu sync.Mutex
+	// ecsOption is the client subnet sent upstream in fixed ECS mode
+	ecsOption *dns.EDNS0_SUBNET
+
+	// Encrypted DNS provider hosts blocked when BlockEncryptedDNS is set, and
+	// who to tell about lookups of them
+	encryptedDNSHosts []string
+	onBypassAttempt   func(BypassAttempt)
+	bypassReported    map[string]time.Time // client|domain -> last report, guarded by statsMu
+
+	stats      DNSBlockerStats
+	ruleBlocks map[string]int64 // rule ID -> queries it blocked, guarded by statsMu
+	statsMu    sync.Mutex
 
 	// Rate limiting for DNS error logging
 	lastDNSErrorLog time.Time
@@ -36,12 +55,23 @@ type DNSBlocker struct {
 
 // DNSBlockerConfig holds configuration for the DNSBlocker.
 type DNSBlockerConfig struct {
-	ListenAddr    string        `json:"listen_addr"`
-	BlockIPv4     string        `json:"block_ipv4"`
-	BlockIPv6     string        `json:"block_ipv6"`
-	UpstreamDNS   []string      `json:"upstream_dns"`
-	CacheTTL      time.Duration `json:"cache_ttl"`
-	EnableLogging bool          `json:"enable_logging"`
+	ListenAddr      string        `json:"listen_addr"`      // comma-separated IPs, optionally with ports
+	ListenInterface string        `json:"listen_interface"` // restrict listeners to one interface
+	BlockIPv4       string        `json:"block_ipv4"`
+	BlockIPv6       string        `json:"block_ipv6"`
+	BlockResponse   BlockResponse `json:"block_response"` // sinkhole (default), nxdomain or refused
+	UpstreamDNS     []string      `json:"upstream_dns"`
+	CacheTTL        time.Duration `json:"cache_ttl"`
+	EnableLogging   bool          `json:"enable_logging"`
+	ECSMode         ECSMode       `json:"ecs_mode"`   // strip (default), passthrough or fixed
+	ECSSubnet       string        `json:"ecs_subnet"` // CIDR sent upstream in fixed mode
+
+	// BlockEncryptedDNS blocks lookups of known DoH/DoT providers and, where
+	// possible, connections to them; the lists are extended with
+	// EncryptedDNSHosts and EncryptedDNSIPs
+	BlockEncryptedDNS bool     `json:"block_encrypted_dns"`
+	EncryptedDNSHosts []string `json:"encrypted_dns_hosts"`
+	EncryptedDNSIPs   []string `json:"encrypted_dns_ips"`
 }
 
 // DNSBlockerStats holds statistics about DNS blocking activities.
@@ -51,12 +81,14 @@ type DNSBlockerStats struct {
 	AllowedQueries  int64 `json:"allowed_queries"`
 	UpstreamLookups int64 `json:"upstream_lookups"`
 	CacheHits       int64 `json:"cache_hits"`
+	TCPFallbacks    int64 `json:"tcp_fallbacks"`
+	BypassAttempts  int64 `json:"bypass_attempts"`
 	Errors          int64 `json:"errors"`
 }
 
 // NewDNSBlocker creates a new DNSBlocker.
 func NewDNSBlocker(config *DNSBlockerConfig, logger logging.Logger) (*DNSBlocker, error) {
-	if config.ListenAddr == "" {
+	if config.ListenAddr == "" && config.ListenInterface == "" {
 		config.ListenAddr = ":53"
 	}
 	if config.BlockIPv4 == "" {
@@ -68,13 +100,49 @@ func NewDNSBlocker(config *DNSBlockerConfig, logger logging.Logger) (*DNSBlocker
 	if len(config.UpstreamDNS) == 0 {
 		config.UpstreamDNS = []string{"8.8.8.8:53", "1.1.1.1:53"}
 	}
+	for i, upstream := range config.UpstreamDNS {
+		if _, _, err := net.SplitHostPort(upstream); err != nil {
+			config.UpstreamDNS[i] = net.JoinHostPort(strings.Trim(upstream, "[]"), "53")
+		}
+	}
+
+	blockResponse, err := ParseBlockResponse(string(config.BlockResponse))
+	if err != nil {
+		return nil, err
+	}
+	config.BlockResponse = blockResponse
 
-	return &DNSBlocker{
-		config:  config,
-		logger:  logger,
-		manager: NewDNSManager(logger),
-		rules:   make(map[string]*FilterRule),
-	}, nil
+	ecsMode, err := ParseECSMode(string(config.ECSMode))
+	if err != nil {
+		return nil, err
+	}
+	config.ECSMode = ecsMode
+
+	var ecsOption *dns.EDNS0_SUBNET
+	if ecsMode == ECSModeFixed {
+		if ecsOption, err = newECSOption(config.ECSSubnet); err != nil {
+			return nil, err
+		}
+	}
+
+	manager := NewDNSManager(logger)
+	var encryptedDNSHosts []string
+	if config.BlockEncryptedDNS {
+		encryptedDNSHosts = encryptedDNSHostList(config.EncryptedDNSHosts)
+		manager.encryptedDNSIPs = EncryptedDNSIPs(config.EncryptedDNSIPs)
+	}
+
+	blocker := &DNSBlocker{
+		config:            config,
+		logger:            logger,
+		manager:           manager,
+		ecsOption:         ecsOption,
+		encryptedDNSHosts: encryptedDNSHosts,
+		bypassReported:    make(map[string]time.Time),
+		ruleBlocks:        make(map[string]int64),
+	}
+	blocker.rules.Store(&map[string]*FilterRule{})
+	return blocker, nil
 }
 
 // Start starts the DNS blocker server.
@@ -85,43 +153,128 @@ func (b *DNSBlocker) Start(ctx context.Context) error {
 		return fmt.Errorf("DNS blocker is already running")
 	}
 
+	listenAddrs, err := resolveDNSListenAddrs(b.config.ListenAddr, b.config.ListenInterface)
+	if err != nil {
+		b.runningMu.Unlock()
+		return fmt.Errorf("invalid DNS listen configuration: %w", err)
+	}
+
+	// Bind before touching system DNS settings so a port conflict is reported
+	// immediately instead of from a background goroutine. Every address gets
+	// a UDP and a TCP listener on the same port; TCP carries responses too
+	// large for UDP.
+	var servers []*dns.Server
+	closeAll := func() {
+		for _, server := range servers {
+			if server.PacketConn != nil {
+				server.PacketConn.Close()
+			}
+			if server.Listener != nil {
+				server.Listener.Close()
+			}
+		}
+	}
+	for _, addr := range listenAddrs {
+		conn, err := net.ListenPacket(addr.Network, addr.Addr)
+		if err != nil {
+			if addr.wildcard && !isAddrInUse(err) && len(listenAddrs) > 1 {
+				// The address family may be disabled on the host
+				b.logger.Warn("DNS listener unavailable, continuing without it",
+					logging.String("network", addr.Network),
+					logging.String("address", addr.Addr),
+					logging.Err(err))
+				continue
+			}
+			closeAll()
+			b.runningMu.Unlock()
+			return b.bindError(addr.Network, addr.Addr, err)
+		}
+		servers = append(servers, &dns.Server{PacketConn: conn})
+
+		// Reuse the bound UDP port so an ephemeral port matches on both protocols
+		tcpNetwork := strings.Replace(addr.Network, "udp", "tcp", 1)
+		host, _, _ := net.SplitHostPort(addr.Addr)
+		_, port, _ := net.SplitHostPort(conn.LocalAddr().String())
+		tcpAddr := net.JoinHostPort(host, port)
+
+		listener, err := net.Listen(tcpNetwork, tcpAddr)
+		if err != nil {
+			closeAll()
+			b.runningMu.Unlock()
+			return b.bindError(tcpNetwork, tcpAddr, err)
+		}
+		servers = append(servers, &dns.Server{Listener: listener})
+	}
+	if len(servers) == 0 {
+		b.runningMu.Unlock()
+		return fmt.Errorf("failed to bind any DNS listener")
+	}
+
 	if err := b.manager.Setup(); err != nil {
 		b.logger.Error("Failed to set up DNS manager, running without automatic DNS configuration.", logging.Err(err))
 	}
 
 	dns.HandleFunc(".", b.handleDNSRequest)
 
-	b.server4 = &dns.Server{Addr: b.config.ListenAddr, Net: "udp4"}
-	b.server6 = &dns.Server{Addr: b.config.ListenAddr, Net: "udp6"}
-
+	b.servers = servers
 	b.running = true
 	b.runningMu.Unlock()
 
-	b.logger.Info("Starting DNS blocker", logging.String("address", b.config.ListenAddr))
-
-	go func() {
-		if err := b.server6.ListenAndServe(); err != nil {
-			b.runningMu.RLock()
-			if b.running {
-				b.logger.Error("IPv6 DNS blocker failed", logging.Err(err))
-			}
-			b.runningMu.RUnlock()
+	for _, server := range b.servers {
+		network, addr := "udp", ""
+		if server.Listener != nil {
+			network, addr = "tcp", server.Listener.Addr().String()
+		} else {
+			addr = server.PacketConn.LocalAddr().String()
 		}
-	}()
-
-	go func() {
-		if err := b.server4.ListenAndServe(); err != nil {
-			b.runningMu.RLock()
-			if b.running {
-				b.logger.Error("IPv4 DNS blocker failed", logging.Err(err))
+		b.logger.Info("Starting DNS blocker",
+			logging.String("network", network),
+			logging.String("address", addr))
+
+		go func(server *dns.Server, addr string) {
+			if err := server.ActivateAndServe(); err != nil {
+				b.runningMu.RLock()
+				if b.running {
+					b.logger.Error("DNS blocker listener failed", logging.String("address", addr), logging.Err(err))
+				}
+				b.runningMu.RUnlock()
 			}
-			b.runningMu.RUnlock()
-		}
-	}()
+		}(server, addr)
+	}
 
 	return nil
 }
 
+// bindError turns a listener error into an actionable message. Port conflicts
+// are almost always caused by systemd-resolved's stub listener on 127.0.0.53.
+func (b *DNSBlocker) bindError(network, addr string, err error) error {
+	if isAddrInUse(err) {
+		return fmt.Errorf("%w: cannot listen on %s %s because another resolver owns the port "+
+			"(most likely systemd-resolved; set DNSStubListener=no in /etc/systemd/resolved.conf "+
+			"and run 'systemctl restart systemd-resolved', or stop dnsmasq/named): %v",
+			ErrDNSPortInUse, network, addr, err)
+	}
+	return fmt.Errorf("failed to listen on %s %s: %w", network, addr, err)
+}
+
+// isAddrInUse reports whether a listen error means the address is taken
+func isAddrInUse(err error) bool {
+	if errors.Is(err, syscall.EADDRINUSE) {
+		return true
+	}
+	// Windows reports WSAEADDRINUSE, which does not match syscall.EADDRINUSE
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "address already in use") ||
+		strings.Contains(msg, "only one usage of each socket address")
+}
+
+// IsRunning reports whether the DNS listeners are serving
+func (b *DNSBlocker) IsRunning() bool {
+	b.runningMu.RLock()
+	defer b.runningMu.RUnlock()
+	return b.running
+}
+
 // Stop stops the DNS blocker server.
 func (b *DNSBlocker) Stop(ctx context.Context) error {
 	b.runningMu.Lock()
@@ -136,57 +289,91 @@ func (b *DNSBlocker) Stop(ctx context.Context) error {
 	}
 
 	b.running = false
-	if b.server4 != nil {
-		if err := b.server4.Shutdown(); err != nil {
-			b.logger.Error("Error stopping IPv4 DNS blocker", logging.Err(err))
-		}
-	}
-	if b.server6 != nil {
-		if err := b.server6.Shutdown(); err != nil {
-			b.logger.Error("Error stopping IPv6 DNS blocker", logging.Err(err))
+	for _, server := range b.servers {
+		if err := server.Shutdown(); err != nil {
+			b.logger.Error("Error stopping DNS blocker listener", logging.Err(err))
 		}
 	}
+	b.servers = nil
 
 	b.logger.Info("DNS blocker stopped")
 	return nil
 }
 
-// AddRule adds a filtering rule.
-func (b *DNSBlocker) AddRule(rule *FilterRule) error {
+// currentRules returns the rule set in force. It must not be modified.
+func (b *DNSBlocker) currentRules() map[string]*FilterRule {
+	return *b.rules.Load()
+}
+
+// updateRules replaces the rule set with a modified copy of it
+func (b *DNSBlocker) updateRules(update func(rules map[string]*FilterRule) error) error {
 	b.rulesMu.Lock()
 	defer b.rulesMu.Unlock()
 
+	current := b.currentRules()
+	rules := make(map[string]*FilterRule, len(current)+1)
+	for pattern, rule := range current {
+		rules[pattern] = rule
+	}
+	if err := update(rules); err != nil {
+		return err
+	}
+	b.rules.Store(&rules)
+	return nil
+}
+
+// AddRule adds a filtering rule.
+func (b *DNSBlocker) AddRule(rule *FilterRule) error {
 	if rule.ID == "" {
 		return fmt.Errorf("rule ID cannot be empty")
 	}
 
-	b.rules[rule.Pattern] = rule
-	if b.config.EnableLogging {
+	err := b.updateRules(func(rules map[string]*FilterRule) error {
+		rules[rule.Pattern] = rule
+		return nil
+	})
+	if err == nil && b.config.EnableLogging {
 		b.logger.Debug("Added DNS rule", logging.String("pattern", rule.Pattern))
 	}
-	return nil
+	return err
 }
 
 // RemoveRule removes a filtering rule.
 func (b *DNSBlocker) RemoveRule(pattern string) error {
+	return b.updateRules(func(rules map[string]*FilterRule) error {
+		if _, exists := rules[pattern]; !exists {
+			return fmt.Errorf("rule for pattern %s not found", pattern)
+		}
+		delete(rules, pattern)
+		return nil
+	})
+}
+
+// ReplaceRules swaps in a new set of rules keyed by pattern. Queries being
+// answered keep the set they started with, so none sees a partial update.
+// The blocker keeps rules; callers must not modify it afterwards.
+func (b *DNSBlocker) ReplaceRules(rules map[string]*FilterRule) error {
+	for pattern, rule := range rules {
+		if rule.ID == "" {
+			return fmt.Errorf("rule ID cannot be empty for pattern %s", pattern)
+		}
+	}
+
 	b.rulesMu.Lock()
-	defer b.rulesMu.Unlock()
+	b.rules.Store(&rules)
+	b.rulesMu.Unlock()
 
-	if _, exists := b.rules[pattern]; !exists {
-		return fmt.Errorf("rule for pattern %s not found", pattern)
+	if b.config.EnableLogging {
+		b.logger.Debug("Replaced DNS rules", logging.Int("count", len(rules)))
 	}
-	delete(b.rules, pattern)
 	return nil
 }
 
 // GetAllRules returns a copy of all current rules
 func (b *DNSBlocker) GetAllRules() map[string]*FilterRule {
-	b.rulesMu.RLock()
-	defer b.rulesMu.RUnlock()
-
-	// Create a copy to avoid race conditions
-	rules := make(map[string]*FilterRule, len(b.rules))
-	for pattern, rule := range b.rules {
+	current := b.currentRules()
+	rules := make(map[string]*FilterRule, len(current))
+	for pattern, rule := range current {
 		rules[pattern] = rule
 	}
 	return rules
@@ -195,9 +382,9 @@ func (b *DNSBlocker) GetAllRules() map[string]*FilterRule {
 // ClearAllRules removes all rules
 func (b *DNSBlocker) ClearAllRules() {
 	b.rulesMu.Lock()
-	defer b.rulesMu.Unlock()
+	b.rules.Store(&map[string]*FilterRule{})
+	b.rulesMu.Unlock()
 
-	b.rules = make(map[string]*FilterRule)
 	if b.config.EnableLogging {
 		b.logger.Debug("Cleared all DNS rules")
 	}
@@ -211,34 +398,21 @@ func (b *DNSBlocker) handleDNSRequest(w dns.ResponseWriter, r *dns.Msg) {
 	q := r.Question[0]
 	domain := strings.TrimSuffix(q.Name, ".")
 
-	if b.shouldBlock(domain) {
+	if b.config.BlockEncryptedDNS && b.handleEncryptedDNS(w, r, domain) {
+		return
+	}
+
+	if rule := b.blockingRule(domain); rule != nil {
 		b.statsMu.Lock()
 		b.stats.BlockedQueries++
+		b.ruleBlocks[rule.ID]++
 		b.statsMu.Unlock()
 
 		if b.config.EnableLogging {
 			b.logger.Info("Blocked DNS query", logging.String("domain", domain))
 		}
 
-		msg := new(dns.Msg)
-		msg.SetReply(r)
-		msg.Authoritative = true
-
-		blockIPv4 := net.ParseIP(b.config.BlockIPv4)
-		blockIPv6 := net.ParseIP(b.config.BlockIPv6)
-
-		if q.Qtype == dns.TypeA && blockIPv4 != nil {
-			msg.Answer = append(msg.Answer, &dns.A{
-				Hdr: dns.RR_Header{Name: q.Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 60},
-				A:   blockIPv4,
-			})
-		} else if q.Qtype == dns.TypeAAAA && blockIPv6 != nil {
-			msg.Answer = append(msg.Answer, &dns.AAAA{
-				Hdr:  dns.RR_Header{Name: q.Name, Rrtype: dns.TypeAAAA, Class: dns.ClassINET, Ttl: 60},
-				AAAA: blockIPv6,
-			})
-		}
-		w.WriteMsg(msg)
+		w.WriteMsg(b.blockedReply(r))
 		return
 	}
 
@@ -252,16 +426,33 @@ func (b *DNSBlocker) handleDNSRequest(w dns.ResponseWriter, r *dns.Msg) {
 		b.logger.Debug("Forwarding DNS query", logging.String("domain", domain))
 	}
 
-	client := new(dns.Client)
-	var resp *dns.Msg
-	var err error
+	overTCP := isTCPClient(w)
+	resp, err := b.forwardQuery(r, overTCP)
+	if err == nil {
+		if ip, rule := b.blockedAnswer(resp); ip != nil {
+			// Counted as allowed before the answer was known
+			b.statsMu.Lock()
+			b.stats.AllowedQueries--
+			b.stats.BlockedQueries++
+			b.ruleBlocks[rule.ID]++
+			b.statsMu.Unlock()
+
+			if b.config.EnableLogging {
+				b.logger.Info("Blocked DNS answer in a blocked network",
+					logging.String("domain", domain),
+					logging.String("ip", ip.String()))
+			}
 
-	for _, upstream := range b.config.UpstreamDNS {
-		resp, _, err = client.Exchange(r, upstream)
-		if err == nil {
-			w.WriteMsg(resp)
+			w.WriteMsg(b.blockedReply(r))
 			return
 		}
+		if !overTCP {
+			// Set the TC bit if the answer exceeds what the client accepts over
+			// UDP, so it retries over TCP
+			resp.Truncate(clientUDPSize(r))
+		}
+		w.WriteMsg(resp)
+		return
 	}
 
 	b.statsMu.Lock()
@@ -299,11 +490,75 @@ func (b *DNSBlocker) handleDNSRequest(w dns.ResponseWriter, r *dns.Msg) {
 	dns.HandleFailed(w, r)
 }
 
+// forwardQuery resolves a query through the upstream servers in order.
+// Queries that arrived over TCP are forwarded over TCP. UDP answers with the
+// TC bit set are retried over TCP so large responses (DNSSEC, many records)
+// are not lost.
+func (b *DNSBlocker) forwardQuery(r *dns.Msg, overTCP bool) (*dns.Msg, error) {
+	udpClient := &dns.Client{Net: "udp"}
+	tcpClient := &dns.Client{Net: "tcp"}
+	r = b.applyECSPolicy(r)
+
+	var lastErr error
+	for _, upstream := range b.config.UpstreamDNS {
+		if overTCP {
+			resp, _, err := tcpClient.Exchange(r, upstream)
+			if err == nil {
+				return resp, nil
+			}
+			lastErr = err
+			continue
+		}
+
+		resp, _, err := udpClient.Exchange(r, upstream)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if !resp.Truncated {
+			return resp, nil
+		}
+
+		b.statsMu.Lock()
+		b.stats.TCPFallbacks++
+		b.statsMu.Unlock()
+
+		full, _, err := tcpClient.Exchange(r, upstream)
+		if err != nil {
+			// The truncated answer still lets the client retry over TCP itself
+			b.logger.Debug("TCP retry of truncated DNS response failed", logging.Err(err))
+			return resp, nil
+		}
+		return full, nil
+	}
+
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no upstream DNS servers configured")
+	}
+	return nil, lastErr
+}
+
+// isTCPClient reports whether the query arrived over TCP
+func isTCPClient(w dns.ResponseWriter) bool {
+	_, ok := w.RemoteAddr().(*net.TCPAddr)
+	return ok
+}
+
+// clientUDPSize returns the largest UDP response the client advertised
+func clientUDPSize(r *dns.Msg) int {
+	if opt := r.IsEdns0(); opt != nil && int(opt.UDPSize()) > dns.MinMsgSize {
+		return int(opt.UDPSize())
+	}
+	return dns.MinMsgSize
+}
+
 func (b *DNSBlocker) shouldBlock(domain string) bool {
-	b.rulesMu.RLock()
-	defer b.rulesMu.RUnlock()
+	return b.blockingRule(domain) != nil
+}
 
-	for pattern, rule := range b.rules {
+// blockingRule returns a block rule matching domain, or nil if none does
+func (b *DNSBlocker) blockingRule(domain string) *FilterRule {
+	for _, rule := range b.currentRules() {
 		if !rule.Enabled {
 			continue
 		}
@@ -311,12 +566,37 @@ func (b *DNSBlocker) shouldBlock(domain string) bool {
 			continue
 		}
 
-		// Simple domain matching for now
-		if strings.HasSuffix(domain, pattern) {
-			return true
+		if rule.MatchesHost(domain) {
+			return rule
 		}
 	}
-	return false
+	return nil
+}
+
+// blockedAnswer returns the first address in an upstream answer that a block
+// rule matches, and the rule, so a domain resolving into a blocked network (a
+// cidr entry) is blocked however many addresses it has. Nil when nothing is
+// blocked.
+func (b *DNSBlocker) blockedAnswer(resp *dns.Msg) (net.IP, *FilterRule) {
+	rules := b.currentRules()
+	for _, rr := range resp.Answer {
+		var ip net.IP
+		switch record := rr.(type) {
+		case *dns.A:
+			ip = record.A
+		case *dns.AAAA:
+			ip = record.AAAA
+		default:
+			continue
+		}
+
+		for _, rule := range rules {
+			if rule.Enabled && rule.Action == ActionBlock && rule.MatchesIP(ip) {
+				return ip, rule
+			}
+		}
+	}
+	return nil, nil
 }
 
 // GetStats returns current DNS blocker statistics
@@ -328,9 +608,20 @@ func (b *DNSBlocker) GetStats() DNSBlockerStats {
 	return b.stats
 }
 
+// GetRuleBlockCounts returns how many queries each rule has blocked, by rule
+// ID. Rules that have blocked nothing are left out.
+func (b *DNSBlocker) GetRuleBlockCounts() map[string]int64 {
+	b.statsMu.Lock()
+	defer b.statsMu.Unlock()
+
+	counts := make(map[string]int64, len(b.ruleBlocks))
+	for id, count := range b.ruleBlocks {
+		counts[id] = count
+	}
+	return counts
+}
+
 // GetRuleCount returns the number of active rules
 func (b *DNSBlocker) GetRuleCount() int {
-	b.rulesMu.RLock()
-	defer b.rulesMu.RUnlock()
-	return len(b.rules)
+	return len(b.currentRules())
 }
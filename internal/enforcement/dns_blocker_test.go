@@ -0,0 +1,517 @@
+package enforcement
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"parental-control/internal/logging"
+
+	"github.com/miekg/dns"
+)
+
+func TestDNSBlocker_StartReportsPortInUse(t *testing.T) {
+	// Hold the port the blocker is about to use
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to reserve UDP port: %v", err)
+	}
+	defer conn.Close()
+
+	blocker, err := NewDNSBlocker(&DNSBlockerConfig{ListenAddr: conn.LocalAddr().String()}, logging.NewDefault())
+	if err != nil {
+		t.Fatalf("Failed to create DNS blocker: %v", err)
+	}
+
+	err = blocker.Start(context.Background())
+	if err == nil {
+		blocker.Stop(context.Background())
+		t.Fatal("Expected Start to fail when the port is already in use")
+	}
+	if !errors.Is(err, ErrDNSPortInUse) {
+		t.Fatalf("Expected ErrDNSPortInUse, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "systemd-resolved") {
+		t.Errorf("Expected error to name the likely culprit, got %v", err)
+	}
+	if blocker.IsRunning() {
+		t.Error("DNS blocker should not be running after a failed start")
+	}
+}
+
+func TestResolveDNSListenAddrs(t *testing.T) {
+	addrs, err := resolveDNSListenAddrs("", "")
+	if err != nil {
+		t.Fatalf("Unexpected error for default address: %v", err)
+	}
+	if len(addrs) != 2 || addrs[0].Network != "udp4" || addrs[1].Network != "udp6" {
+		t.Fatalf("Expected dual-stack wildcard listeners, got %+v", addrs)
+	}
+
+	addrs, err = resolveDNSListenAddrs("127.0.0.1:5353", "")
+	if err != nil {
+		t.Fatalf("Unexpected error for loopback address: %v", err)
+	}
+	if len(addrs) != 1 || addrs[0].Addr != "127.0.0.1:5353" {
+		t.Fatalf("Expected a single loopback listener, got %+v", addrs)
+	}
+
+	if _, err := resolveDNSListenAddrs("dns.example.com", ""); err == nil {
+		t.Error("Expected host names to be rejected")
+	}
+
+	if _, err := resolveDNSListenAddrs("203.0.113.7", ""); err == nil || !strings.Contains(err.Error(), "not assigned") {
+		t.Errorf("Expected unassigned address to be rejected, got %v", err)
+	}
+
+	if _, err := resolveDNSListenAddrs(":53", "no-such-iface0"); err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("Expected missing interface to be rejected, got %v", err)
+	}
+}
+
+// startTruncatingUpstream runs a resolver that truncates every UDP answer and
+// only returns the full record set over TCP
+func startTruncatingUpstream(t *testing.T, records int) string {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen on UDP: %v", err)
+	}
+	listener, err := net.Listen("tcp4", conn.LocalAddr().String())
+	if err != nil {
+		conn.Close()
+		t.Fatalf("Failed to listen on TCP: %v", err)
+	}
+
+	handler := dns.HandlerFunc(func(w dns.ResponseWriter, r *dns.Msg) {
+		msg := new(dns.Msg)
+		msg.SetReply(r)
+		if !isTCPClient(w) {
+			msg.Truncated = true
+			w.WriteMsg(msg)
+			return
+		}
+		for i := 0; i < records; i++ {
+			msg.Answer = append(msg.Answer, &dns.A{
+				Hdr: dns.RR_Header{Name: r.Question[0].Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 60},
+				A:   net.IPv4(10, 0, byte(i/256), byte(i%256)),
+			})
+		}
+		w.WriteMsg(msg)
+	})
+
+	udpServer := &dns.Server{PacketConn: conn, Handler: handler}
+	tcpServer := &dns.Server{Listener: listener, Handler: handler}
+	go udpServer.ActivateAndServe()
+	go tcpServer.ActivateAndServe()
+	t.Cleanup(func() {
+		udpServer.Shutdown()
+		tcpServer.Shutdown()
+	})
+
+	return conn.LocalAddr().String()
+}
+
+func TestDNSBlocker_TruncatedResponseFallsBackToTCP(t *testing.T) {
+	upstream := startTruncatingUpstream(t, 40)
+
+	blocker, err := NewDNSBlocker(&DNSBlockerConfig{UpstreamDNS: []string{upstream}}, logging.NewDefault())
+	if err != nil {
+		t.Fatalf("Failed to create DNS blocker: %v", err)
+	}
+
+	query := new(dns.Msg)
+	query.SetQuestion("many.example.com.", dns.TypeA)
+
+	resp, err := blocker.forwardQuery(query, false)
+	if err != nil {
+		t.Fatalf("Forwarding failed: %v", err)
+	}
+	if resp.Truncated {
+		t.Error("Expected the full TCP answer, got a truncated response")
+	}
+	if len(resp.Answer) != 40 {
+		t.Errorf("Expected 40 records, got %d", len(resp.Answer))
+	}
+	if fallbacks := blocker.GetStats().TCPFallbacks; fallbacks != 1 {
+		t.Errorf("Expected 1 TCP fallback, got %d", fallbacks)
+	}
+
+	// A UDP client without EDNS0 gets the TC bit so it retries over TCP
+	resp.Truncate(clientUDPSize(query))
+	if !resp.Truncated {
+		t.Error("Expected an oversized answer to be truncated for a plain UDP client")
+	}
+}
+
+// startRecordingUpstream runs a resolver that hands each received query to the test
+func startRecordingUpstream(t *testing.T) (string, <-chan *dns.Msg) {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen on UDP: %v", err)
+	}
+
+	received := make(chan *dns.Msg, 1)
+	server := &dns.Server{PacketConn: conn, Handler: dns.HandlerFunc(func(w dns.ResponseWriter, r *dns.Msg) {
+		received <- r
+		msg := new(dns.Msg)
+		msg.SetReply(r)
+		w.WriteMsg(msg)
+	})}
+	go server.ActivateAndServe()
+	t.Cleanup(func() { server.Shutdown() })
+
+	return conn.LocalAddr().String(), received
+}
+
+func queryWithECS(subnet string) *dns.Msg {
+	query := new(dns.Msg)
+	query.SetQuestion("cdn.example.com.", dns.TypeA)
+	query.SetEdns0(dns.DefaultMsgSize, false)
+	option, _ := newECSOption(subnet)
+	query.IsEdns0().Option = append(query.IsEdns0().Option, option)
+	return query
+}
+
+func findECS(msg *dns.Msg) *dns.EDNS0_SUBNET {
+	opt := msg.IsEdns0()
+	if opt == nil {
+		return nil
+	}
+	for _, option := range opt.Option {
+		if subnet, ok := option.(*dns.EDNS0_SUBNET); ok {
+			return subnet
+		}
+	}
+	return nil
+}
+
+func TestDNSBlocker_StripsECSByDefault(t *testing.T) {
+	upstream, received := startRecordingUpstream(t)
+
+	blocker, err := NewDNSBlocker(&DNSBlockerConfig{UpstreamDNS: []string{upstream}}, logging.NewDefault())
+	if err != nil {
+		t.Fatalf("Failed to create DNS blocker: %v", err)
+	}
+
+	query := queryWithECS("192.168.1.0/24")
+	if _, err := blocker.forwardQuery(query, false); err != nil {
+		t.Fatalf("Forwarding failed: %v", err)
+	}
+
+	if ecs := findECS(<-received); ecs != nil {
+		t.Errorf("Expected ECS to be stripped upstream, got %v", ecs)
+	}
+	if findECS(query) == nil {
+		t.Error("The client's query should not be modified")
+	}
+}
+
+func TestDNSBlocker_FixedECSSubnet(t *testing.T) {
+	upstream, received := startRecordingUpstream(t)
+
+	blocker, err := NewDNSBlocker(&DNSBlockerConfig{
+		UpstreamDNS: []string{upstream},
+		ECSMode:     ECSModeFixed,
+		ECSSubnet:   "203.0.113.0/24",
+	}, logging.NewDefault())
+	if err != nil {
+		t.Fatalf("Failed to create DNS blocker: %v", err)
+	}
+
+	if _, err := blocker.forwardQuery(queryWithECS("192.168.1.0/24"), false); err != nil {
+		t.Fatalf("Forwarding failed: %v", err)
+	}
+
+	ecs := findECS(<-received)
+	if ecs == nil || !ecs.Address.Equal(net.ParseIP("203.0.113.0")) || ecs.SourceNetmask != 24 {
+		t.Errorf("Expected fixed subnet 203.0.113.0/24 upstream, got %v", ecs)
+	}
+}
+
+// startBlocker serves the blocker's handler on a local UDP port
+func startBlocker(t *testing.T, blocker *DNSBlocker) string {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen on UDP: %v", err)
+	}
+	server := &dns.Server{PacketConn: conn, Handler: dns.HandlerFunc(blocker.handleDNSRequest)}
+	go server.ActivateAndServe()
+	t.Cleanup(func() { server.Shutdown() })
+
+	return conn.LocalAddr().String()
+}
+
+func TestDNSBlocker_BlockResponse(t *testing.T) {
+	tests := []struct {
+		mode      BlockResponse
+		qtype     uint16
+		wantRcode int
+		wantIP    string
+	}{
+		{mode: "", qtype: dns.TypeA, wantRcode: dns.RcodeSuccess, wantIP: "0.0.0.0"},
+		{mode: BlockResponseSinkhole, qtype: dns.TypeAAAA, wantRcode: dns.RcodeSuccess, wantIP: "::"},
+		{mode: BlockResponseNXDomain, qtype: dns.TypeA, wantRcode: dns.RcodeNameError},
+		{mode: BlockResponseNXDomain, qtype: dns.TypeAAAA, wantRcode: dns.RcodeNameError},
+		{mode: BlockResponseRefused, qtype: dns.TypeA, wantRcode: dns.RcodeRefused},
+	}
+
+	for _, tt := range tests {
+		blocker, err := NewDNSBlocker(&DNSBlockerConfig{BlockResponse: tt.mode}, logging.NewDefault())
+		if err != nil {
+			t.Fatalf("Failed to create DNS blocker: %v", err)
+		}
+		blocker.AddRule(&FilterRule{ID: "1", Action: ActionBlock, Pattern: "blocked.example.com", MatchType: MatchDomain, Enabled: true})
+		addr := startBlocker(t, blocker)
+
+		query := new(dns.Msg)
+		query.SetQuestion("www.blocked.example.com.", tt.qtype)
+		resp, _, err := new(dns.Client).Exchange(query, addr)
+		if err != nil {
+			t.Fatalf("%q: query failed: %v", tt.mode, err)
+		}
+
+		if resp.Rcode != tt.wantRcode {
+			t.Errorf("%q: expected rcode %s, got %s", tt.mode, dns.RcodeToString[tt.wantRcode], dns.RcodeToString[resp.Rcode])
+		}
+		if tt.wantIP == "" {
+			if len(resp.Answer) != 0 {
+				t.Errorf("%q: expected no answers, got %v", tt.mode, resp.Answer)
+			}
+			continue
+		}
+		if len(resp.Answer) != 1 {
+			t.Fatalf("%q: expected one sinkhole answer, got %v", tt.mode, resp.Answer)
+		}
+		var ip net.IP
+		switch rr := resp.Answer[0].(type) {
+		case *dns.A:
+			ip = rr.A
+		case *dns.AAAA:
+			ip = rr.AAAA
+		}
+		if !ip.Equal(net.ParseIP(tt.wantIP)) {
+			t.Errorf("%q: expected sinkhole %s, got %v", tt.mode, tt.wantIP, resp.Answer[0])
+		}
+	}
+
+	if _, err := NewDNSBlocker(&DNSBlockerConfig{BlockResponse: "drop"}, logging.NewDefault()); err == nil {
+		t.Error("Expected an unknown block response to be rejected")
+	}
+}
+
+func TestDNSBlocker_BlocksAnswersInBlockedNetwork(t *testing.T) {
+	upstream := startTruncatingUpstream(t, 3)
+
+	blocker, err := NewDNSBlocker(&DNSBlockerConfig{UpstreamDNS: []string{upstream}}, logging.NewDefault())
+	if err != nil {
+		t.Fatalf("Failed to create DNS blocker: %v", err)
+	}
+	blocker.AddRule(&FilterRule{ID: "1", Action: ActionBlock, Pattern: "192.168.0.0/16", MatchType: MatchCIDR, Enabled: true})
+	addr := startBlocker(t, blocker)
+
+	query := new(dns.Msg)
+	query.SetQuestion("lan.example.com.", dns.TypeA)
+	client := new(dns.Client)
+
+	resp, _, err := client.Exchange(query, addr)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(resp.Answer) != 3 {
+		t.Errorf("Expected the upstream answer outside the blocked network, got %v", resp.Answer)
+	}
+
+	// Any resolved address inside a blocked network blocks the whole answer
+	blocker.AddRule(&FilterRule{ID: "2", Action: ActionBlock, Pattern: "10.0.0.2/31", MatchType: MatchCIDR, Enabled: true})
+	resp, _, err = client.Exchange(query, addr)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(resp.Answer) != 1 || !resp.Answer[0].(*dns.A).A.Equal(net.IPv4zero) {
+		t.Errorf("Expected the sinkhole answer, got %v", resp.Answer)
+	}
+
+	stats := blocker.GetStats()
+	if stats.BlockedQueries != 1 || stats.AllowedQueries != 1 {
+		t.Errorf("Expected 1 blocked and 1 allowed query, got %+v", stats)
+	}
+	if counts := blocker.GetRuleBlockCounts(); len(counts) != 1 || counts["2"] != 1 {
+		t.Errorf("Expected the block to be counted against rule 2, got %v", counts)
+	}
+}
+
+func TestDNSBlocker_RuleBlockCounts(t *testing.T) {
+	blocker, err := NewDNSBlocker(&DNSBlockerConfig{}, logging.NewDefault())
+	if err != nil {
+		t.Fatalf("Failed to create DNS blocker: %v", err)
+	}
+	blocker.AddRule(&FilterRule{ID: "ads", Action: ActionBlock, Pattern: "ads.example.com", MatchType: MatchDomain, Enabled: true})
+	blocker.AddRule(&FilterRule{ID: "games", Action: ActionBlock, Pattern: "games.example.com", MatchType: MatchDomain, Enabled: true})
+	addr := startBlocker(t, blocker)
+
+	client := new(dns.Client)
+	for _, name := range []string{"ads.example.com.", "cdn.ads.example.com.", "games.example.com."} {
+		query := new(dns.Msg)
+		query.SetQuestion(name, dns.TypeA)
+		if _, _, err := client.Exchange(query, addr); err != nil {
+			t.Fatalf("Query for %s failed: %v", name, err)
+		}
+	}
+
+	counts := blocker.GetRuleBlockCounts()
+	if len(counts) != 2 || counts["ads"] != 2 || counts["games"] != 1 {
+		t.Errorf("Expected 2 blocks for ads and 1 for games, got %v", counts)
+	}
+
+	// The counts returned are a copy
+	counts["ads"] = 0
+	if blocker.GetRuleBlockCounts()["ads"] != 2 {
+		t.Error("Expected changing the returned counts to leave the blocker's alone")
+	}
+}
+
+func TestDNSBlocker_BlockEncryptedDNS(t *testing.T) {
+	blocker, err := NewDNSBlocker(&DNSBlockerConfig{
+		BlockEncryptedDNS: true,
+		EncryptedDNSHosts: []string{"DoH.Example.net."},
+		EncryptedDNSIPs:   []string{"192.0.2.53", "not-an-ip"},
+	}, logging.NewDefault())
+	if err != nil {
+		t.Fatalf("Failed to create DNS blocker: %v", err)
+	}
+	attempts := make(chan BypassAttempt, 10)
+	blocker.SetBypassHandler(func(attempt BypassAttempt) { attempts <- attempt })
+	addr := startBlocker(t, blocker)
+
+	lookup := func(name string) *dns.Msg {
+		query := new(dns.Msg)
+		query.SetQuestion(dns.Fqdn(name), dns.TypeA)
+		resp, _, err := new(dns.Client).Exchange(query, addr)
+		if err != nil {
+			t.Fatalf("Query for %s failed: %v", name, err)
+		}
+		return resp
+	}
+
+	for _, name := range []string{"dns.google", "chrome.cloudflare-dns.com", "doh.example.net"} {
+		resp := lookup(name)
+		if len(resp.Answer) != 1 || !resp.Answer[0].(*dns.A).A.Equal(net.IPv4zero) {
+			t.Errorf("Expected %s to be sinkholed, got %v", name, resp)
+		}
+		select {
+		case attempt := <-attempts:
+			if attempt.Domain != name || attempt.Client != "127.0.0.1" {
+				t.Errorf("Unexpected bypass attempt: %+v", attempt)
+			}
+		case <-time.After(time.Second):
+			t.Errorf("Expected a bypass attempt for %s", name)
+		}
+	}
+
+	// Repeated lookups are blocked but only reported once per interval
+	lookup("dns.google")
+	select {
+	case attempt := <-attempts:
+		t.Errorf("Expected a repeat lookup not to be reported again: %+v", attempt)
+	default:
+	}
+
+	// Canary domains get NXDOMAIN so browsers switch DoH off themselves
+	if resp := lookup("use-application-dns.net"); resp.Rcode != dns.RcodeNameError {
+		t.Errorf("Expected NXDOMAIN for the Firefox canary domain, got %s", dns.RcodeToString[resp.Rcode])
+	}
+	select {
+	case attempt := <-attempts:
+		t.Errorf("Expected the canary lookup not to be reported: %+v", attempt)
+	default:
+	}
+
+	if stats := blocker.GetStats(); stats.BypassAttempts != 4 {
+		t.Errorf("Expected 4 bypass attempts, got %d", stats.BypassAttempts)
+	}
+
+	rules := strings.Join(flattenRules(blocker.manager.encryptedDNSRules("-A")), "\n")
+	for _, want := range []string{
+		"iptables -A OUTPUT -p tcp --dport 853",
+		"ip6tables -A OUTPUT -p udp --dport 853",
+		"iptables -A OUTPUT -d 192.0.2.53 -p tcp --dport 443",
+		"ip6tables -A OUTPUT -d 2606:4700:4700::1111 -p udp --dport 443",
+	} {
+		if !strings.Contains(rules, want) {
+			t.Errorf("Expected a rule containing %q", want)
+		}
+	}
+	if strings.Contains(rules, "not-an-ip") {
+		t.Error("Expected invalid addresses to be skipped")
+	}
+}
+
+func TestDNSBlocker_EncryptedDNSAllowedByDefault(t *testing.T) {
+	blocker, err := NewDNSBlocker(&DNSBlockerConfig{}, logging.NewDefault())
+	if err != nil {
+		t.Fatalf("Failed to create DNS blocker: %v", err)
+	}
+	if blocker.shouldBlock("dns.google") || len(blocker.manager.encryptedDNSIPs) != 0 {
+		t.Error("Expected encrypted DNS to be left alone unless enabled")
+	}
+}
+
+func TestDNSBlocker_ReplaceRules(t *testing.T) {
+	blocker, err := NewDNSBlocker(&DNSBlockerConfig{}, logging.NewDefault())
+	if err != nil {
+		t.Fatalf("Failed to create DNS blocker: %v", err)
+	}
+	blockRule := func(id, pattern string) *FilterRule {
+		return &FilterRule{ID: id, Pattern: pattern, Action: ActionBlock, MatchType: MatchDomain, Enabled: true}
+	}
+	if err := blocker.AddRule(blockRule("old", "old.example.com")); err != nil {
+		t.Fatalf("Failed to add rule: %v", err)
+	}
+
+	// Queries keep being answered while the rules are replaced; run with
+	// -race to check they never read a rule set being written
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			blocker.shouldBlock("old.example.com")
+		}
+	}()
+
+	previous := blocker.GetAllRules()
+	if err := blocker.ReplaceRules(map[string]*FilterRule{"new.example.com": blockRule("new", "new.example.com")}); err != nil {
+		t.Fatalf("ReplaceRules failed: %v", err)
+	}
+	<-done
+
+	if blocker.shouldBlock("old.example.com") || !blocker.shouldBlock("www.new.example.com") || blocker.GetRuleCount() != 1 {
+		t.Errorf("Expected only the new rules after a replace, got %v", blocker.GetAllRules())
+	}
+	if len(previous) != 1 || previous["old.example.com"] == nil {
+		t.Errorf("Expected an earlier copy of the rules to be unchanged, got %v", previous)
+	}
+
+	if err := blocker.ReplaceRules(map[string]*FilterRule{"bad.example.com": {Pattern: "bad.example.com"}}); err == nil {
+		t.Error("Expected a rule without an ID to be rejected")
+	}
+	if !blocker.shouldBlock("new.example.com") {
+		t.Error("Expected a rejected replace to keep the rules in force")
+	}
+}
+
+func flattenRules(rules [][]string) []string {
+	lines := make([]string, len(rules))
+	for i, rule := range rules {
+		lines[i] = strings.Join(rule, " ")
+	}
+	return lines
+}
@@ -0,0 +1,183 @@
+package enforcement
+
+import (
+	"net"
+	"strings"
+	"time"
+
+	"parental-control/internal/logging"
+
+	"github.com/miekg/dns"
+)
+
+// Encrypted DNS (DoH on port 443, DoT on 853) goes straight to a public
+// resolver and never reaches the local blocker. With BlockEncryptedDNS on,
+// lookups of the providers below are blocked and reported, and where the
+// platform allows it their addresses and the DoT port are blocked as well.
+// The lists cover the providers browsers and operating systems offer by
+// default; EncryptedDNSHosts and EncryptedDNSIPs extend them.
+
+// knownEncryptedDNSHosts are DoH/DoT endpoint host names. Subdomains match too.
+var knownEncryptedDNSHosts = []string{
+	"dns.google",
+	"dns.google.com",
+	"cloudflare-dns.com",
+	"one.one.one.one",
+	"1dot1dot1dot1.cloudflare-dns.com",
+	"dns.quad9.net",
+	"dns9.quad9.net",
+	"dns10.quad9.net",
+	"dns11.quad9.net",
+	"doh.opendns.com",
+	"doh.familyshield.opendns.com",
+	"dns.adguard.com",
+	"dns.adguard-dns.com",
+	"dns-family.adguard.com",
+	"dns.nextdns.io",
+	"doh.cleanbrowsing.org",
+	"doh.dns.sb",
+	"dns.alidns.com",
+	"doh.pub",
+	"dns.mullvad.net",
+	"doh.mullvad.net",
+	"freedns.controld.com",
+	"doh.libredns.gr",
+	"dns.switch.ch",
+}
+
+// knownEncryptedDNSIPs are the anycast addresses of the providers above that
+// also answer DoH/DoT directly by IP
+var knownEncryptedDNSIPs = []string{
+	"1.1.1.1", "1.0.0.1", "2606:4700:4700::1111", "2606:4700:4700::1001",
+	"8.8.8.8", "8.8.4.4", "2001:4860:4860::8888", "2001:4860:4860::8844",
+	"9.9.9.9", "149.112.112.112", "2620:fe::fe", "2620:fe::9",
+	"208.67.222.222", "208.67.220.220", "2620:119:35::35", "2620:119:53::53",
+	"94.140.14.14", "94.140.15.15", "2a10:50c0::ad1:ff", "2a10:50c0::ad2:ff",
+	"185.228.168.168", "185.228.169.168",
+	"194.242.2.2", "2a07:e340::2",
+}
+
+// encryptedDNSCanaryHosts are answered NXDOMAIN whenever encrypted DNS is
+// blocked. Firefox turns off its default DoH and Apple devices turn off iCloud
+// Private Relay when the local resolver does not resolve them, so these are
+// not bypass attempts themselves.
+var encryptedDNSCanaryHosts = []string{
+	"use-application-dns.net",
+	"mask.icloud.com",
+	"mask-h2.icloud.com",
+}
+
+// bypassReportInterval limits bypass attempt reports to one per client and
+// host in this period; DoH clients retry constantly once blocked
+const bypassReportInterval = time.Minute
+
+// BypassAttempt describes a blocked lookup of an encrypted DNS provider
+type BypassAttempt struct {
+	Domain string `json:"domain"`
+	Client string `json:"client"`
+}
+
+// EncryptedDNSIPs returns the known encrypted DNS provider addresses plus
+// extra, skipping anything that is not an IP address
+func EncryptedDNSIPs(extra []string) []net.IP {
+	var ips []net.IP
+	seen := make(map[string]bool)
+	for _, value := range append(append([]string(nil), knownEncryptedDNSIPs...), extra...) {
+		ip := net.ParseIP(strings.TrimSpace(value))
+		if ip == nil || seen[ip.String()] {
+			continue
+		}
+		seen[ip.String()] = true
+		ips = append(ips, ip)
+	}
+	return ips
+}
+
+// encryptedDNSHostList returns the known provider host names plus extra,
+// normalized for matching
+func encryptedDNSHostList(extra []string) []string {
+	hosts := make([]string, 0, len(knownEncryptedDNSHosts)+len(extra))
+	for _, host := range append(append([]string(nil), knownEncryptedDNSHosts...), extra...) {
+		if host = strings.Trim(strings.ToLower(strings.TrimSpace(host)), "."); host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
+
+// matchesHostList reports whether domain is one of hosts or a subdomain of one
+func matchesHostList(domain string, hosts []string) bool {
+	domain = strings.Trim(strings.ToLower(domain), ".")
+	for _, host := range hosts {
+		if domain == host || strings.HasSuffix(domain, "."+host) {
+			return true
+		}
+	}
+	return false
+}
+
+// shouldReportBypass reports whether an attempt by client to resolve domain
+// has not been reported within bypassReportInterval (statsMu must be held)
+func (b *DNSBlocker) shouldReportBypass(domain, client string, now time.Time) bool {
+	key := client + "|" + domain
+	if last, ok := b.bypassReported[key]; ok && now.Sub(last) < bypassReportInterval {
+		return false
+	}
+	for k, last := range b.bypassReported {
+		if now.Sub(last) >= bypassReportInterval {
+			delete(b.bypassReported, k)
+		}
+	}
+	b.bypassReported[key] = now
+	return true
+}
+
+// SetBypassHandler sets the function told about blocked lookups of encrypted
+// DNS providers. It is called from the DNS handler and should not block.
+func (b *DNSBlocker) SetBypassHandler(handler func(BypassAttempt)) {
+	b.rulesMu.Lock()
+	defer b.rulesMu.Unlock()
+	b.onBypassAttempt = handler
+}
+
+// handleEncryptedDNS answers lookups of canary and encrypted DNS provider
+// hosts, reporting whether it did
+func (b *DNSBlocker) handleEncryptedDNS(w dns.ResponseWriter, r *dns.Msg, domain string) bool {
+	if matchesHostList(domain, encryptedDNSCanaryHosts) {
+		msg := new(dns.Msg)
+		msg.SetReply(r)
+		msg.Rcode = dns.RcodeNameError
+		w.WriteMsg(msg)
+		return true
+	}
+	if !matchesHostList(domain, b.encryptedDNSHosts) {
+		return false
+	}
+
+	client := ""
+	if host, _, err := net.SplitHostPort(w.RemoteAddr().String()); err == nil {
+		client = host
+	}
+
+	b.statsMu.Lock()
+	b.stats.BlockedQueries++
+	b.stats.BypassAttempts++
+	report := b.shouldReportBypass(domain, client, time.Now())
+	b.statsMu.Unlock()
+
+	w.WriteMsg(b.blockedReply(r))
+
+	if report {
+		b.logger.Warn("Blocked encrypted DNS lookup",
+			logging.String("domain", domain),
+			logging.String("client", client))
+
+		b.rulesMu.Lock()
+		handler := b.onBypassAttempt
+		b.rulesMu.Unlock()
+		if handler != nil {
+			handler(BypassAttempt{Domain: domain, Client: client})
+		}
+	}
+	return true
+}
@@ -0,0 +1,95 @@
+package enforcement
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/miekg/dns"
+)
+
+// ECSMode controls how EDNS Client Subnet options are sent upstream.
+//
+// Forwarding the client subnet lets CDNs pick a nearby server, but it also
+// tells every upstream resolver roughly where the household is. Stripping it
+// is the private default; CDN answers may then be tuned to the upstream
+// resolver's location instead, which can make some downloads slower.
+type ECSMode string
+
+const (
+	// ECSModeStrip removes any client subnet option before forwarding
+	ECSModeStrip ECSMode = "strip"
+	// ECSModePassthrough forwards the client's option unchanged
+	ECSModePassthrough ECSMode = "passthrough"
+	// ECSModeFixed replaces the option with a configured subnet
+	ECSModeFixed ECSMode = "fixed"
+)
+
+// ParseECSMode validates an ECS mode name. Empty selects ECSModeStrip.
+func ParseECSMode(value string) (ECSMode, error) {
+	switch ECSMode(value) {
+	case "", ECSModeStrip:
+		return ECSModeStrip, nil
+	case ECSModePassthrough, ECSModeFixed:
+		return ECSMode(value), nil
+	default:
+		return "", fmt.Errorf("invalid ECS mode %q (expected strip, passthrough or fixed)", value)
+	}
+}
+
+// newECSOption builds a client subnet option for a fixed CIDR
+func newECSOption(cidr string) (*dns.EDNS0_SUBNET, error) {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ECS subnet %q: %w", cidr, err)
+	}
+
+	ones, _ := network.Mask.Size()
+	option := &dns.EDNS0_SUBNET{
+		Code:          dns.EDNS0SUBNET,
+		SourceNetmask: uint8(ones),
+		Address:       network.IP,
+	}
+	if ip4 := network.IP.To4(); ip4 != nil {
+		option.Family = 1
+		option.Address = ip4
+	} else {
+		option.Family = 2
+	}
+	return option, nil
+}
+
+// applyECSPolicy returns the query to send upstream. The client's message is
+// never modified; a copy is made whenever the option needs to change.
+func (b *DNSBlocker) applyECSPolicy(r *dns.Msg) *dns.Msg {
+	if b.config.ECSMode == ECSModePassthrough {
+		return r
+	}
+
+	opt := r.IsEdns0()
+	if opt == nil && b.ecsOption == nil {
+		return r
+	}
+
+	out := r.Copy()
+	opt = out.IsEdns0()
+	if opt != nil {
+		options := make([]dns.EDNS0, 0, len(opt.Option))
+		for _, option := range opt.Option {
+			if option.Option() != dns.EDNS0SUBNET {
+				options = append(options, option)
+			}
+		}
+		opt.Option = options
+	}
+
+	if b.ecsOption != nil {
+		if opt == nil {
+			out.SetEdns0(dns.DefaultMsgSize, false)
+			opt = out.IsEdns0()
+		}
+		option := *b.ecsOption
+		opt.Option = append(opt.Option, &option)
+	}
+
+	return out
+}
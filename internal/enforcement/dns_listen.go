@@ -0,0 +1,166 @@
+package enforcement
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// defaultDNSPort is used when a listen address does not name a port
+const defaultDNSPort = 53
+
+// dnsListenAddr is a single socket the DNS blocker binds
+type dnsListenAddr struct {
+	Network string // udp4 or udp6
+	Addr    string // host:port
+
+	// wildcard marks 0.0.0.0 / :: listeners, which may be skipped when the
+	// host has that address family disabled
+	wildcard bool
+}
+
+// resolveDNSListenAddrs expands the configured listen address and interface
+// into the concrete sockets to bind. listenAddr is a comma-separated list of
+// IPs, each optionally with a port; an empty value or a bare ":port" listens
+// on all IPv4 and IPv6 addresses. When iface is set, only that interface's
+// addresses are used and listenAddr may only supply the port.
+func resolveDNSListenAddrs(listenAddr, iface string) ([]dnsListenAddr, error) {
+	if iface != "" {
+		return resolveInterfaceListenAddrs(listenAddr, iface)
+	}
+
+	if strings.TrimSpace(listenAddr) == "" {
+		listenAddr = ":" + strconv.Itoa(defaultDNSPort)
+	}
+
+	var addrs []dnsListenAddr
+	for _, item := range strings.Split(listenAddr, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		host, port, err := splitDNSHostPort(item)
+		if err != nil {
+			return nil, err
+		}
+
+		if host == "" {
+			// Port only: dual-stack wildcard
+			addrs = append(addrs,
+				dnsListenAddr{Network: "udp4", Addr: net.JoinHostPort("0.0.0.0", port), wildcard: true},
+				dnsListenAddr{Network: "udp6", Addr: net.JoinHostPort("::", port), wildcard: true})
+			continue
+		}
+
+		ip := net.ParseIP(host)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid DNS listen address %q: not an IP address", item)
+		}
+		if !ip.IsUnspecified() {
+			if err := checkAddressAssigned(ip); err != nil {
+				return nil, err
+			}
+		}
+
+		addrs = append(addrs, dnsListenAddr{
+			Network:  ipNetwork(ip),
+			Addr:     net.JoinHostPort(ip.String(), port),
+			wildcard: ip.IsUnspecified(),
+		})
+	}
+
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("no DNS listen addresses configured")
+	}
+	return addrs, nil
+}
+
+// resolveInterfaceListenAddrs returns one listener per address of the named interface
+func resolveInterfaceListenAddrs(listenAddr, iface string) ([]dnsListenAddr, error) {
+	port := strconv.Itoa(defaultDNSPort)
+	if listenAddr = strings.TrimSpace(listenAddr); listenAddr != "" {
+		host, p, err := splitDNSHostPort(listenAddr)
+		if err != nil {
+			return nil, err
+		}
+		if host != "" && !net.ParseIP(host).IsUnspecified() {
+			return nil, fmt.Errorf("DNS listen address %q conflicts with listen interface %q; give only a port when binding to an interface", listenAddr, iface)
+		}
+		port = p
+	}
+
+	netIface, err := net.InterfaceByName(iface)
+	if err != nil {
+		return nil, fmt.Errorf("DNS listen interface %q does not exist: %w", iface, err)
+	}
+	if netIface.Flags&net.FlagUp == 0 {
+		return nil, fmt.Errorf("DNS listen interface %q is down", iface)
+	}
+
+	ifaceAddrs, err := netIface.Addrs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read addresses of interface %q: %w", iface, err)
+	}
+
+	var addrs []dnsListenAddr
+	for _, addr := range ifaceAddrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok {
+			continue
+		}
+
+		host := ipNet.IP.String()
+		if ipNet.IP.To4() == nil && ipNet.IP.IsLinkLocalUnicast() {
+			// Link-local IPv6 addresses need the zone to be bindable
+			host += "%" + iface
+		}
+		addrs = append(addrs, dnsListenAddr{
+			Network: ipNetwork(ipNet.IP),
+			Addr:    net.JoinHostPort(host, port),
+		})
+	}
+
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("DNS listen interface %q has no IP addresses", iface)
+	}
+	return addrs, nil
+}
+
+// splitDNSHostPort splits an address that may omit the port
+func splitDNSHostPort(addr string) (string, string, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		// No port given; a bare IPv6 address also lands here
+		host = strings.Trim(addr, "[]")
+		port = strconv.Itoa(defaultDNSPort)
+	}
+
+	if n, err := strconv.Atoi(port); err != nil || n < 0 || n > 65535 {
+		return "", "", fmt.Errorf("invalid DNS listen port in %q", addr)
+	}
+	return host, port, nil
+}
+
+// checkAddressAssigned verifies that a specific IP belongs to a local interface
+func checkAddressAssigned(ip net.IP) error {
+	ifaceAddrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return fmt.Errorf("failed to list local addresses: %w", err)
+	}
+
+	for _, addr := range ifaceAddrs {
+		if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.Equal(ip) {
+			return nil
+		}
+	}
+	return fmt.Errorf("DNS listen address %s is not assigned to any local interface", ip)
+}
+
+func ipNetwork(ip net.IP) string {
+	if ip.To4() != nil {
+		return "udp4"
+	}
+	return "udp6"
+}
@@ -3,6 +3,7 @@ package enforcement
 import (
 	"bytes"
 	"fmt"
+	"net"
 	"os/exec"
 	"strings"
 
@@ -13,6 +14,10 @@ import (
 // DNSManager handles system-level DNS configuration changes via iptables.
 type DNSManager struct {
 	logger logging.Logger
+
+	// encryptedDNSIPs are DoH/DoT resolvers to reject connections to; empty
+	// leaves encrypted DNS alone
+	encryptedDNSIPs []net.IP
 }
 
 // NewDNSManager creates a new DNSManager.
@@ -46,6 +51,10 @@ func (m *DNSManager) Setup() error {
 	}
 
 	m.logger.Info("Successfully set up DNS redirection.")
+
+	if len(m.encryptedDNSIPs) > 0 {
+		m.blockEncryptedDNS()
+	}
 	return nil
 }
 
@@ -58,6 +67,10 @@ func (m *DNSManager) Teardown() error {
 
 	m.logger.Info("Restoring original DNS settings by removing iptables rules...")
 
+	if len(m.encryptedDNSIPs) > 0 {
+		m.unblockEncryptedDNS()
+	}
+
 	rules := [][]string{
 		// Remove the UDP redirection rule
 		{"-t", "nat", "-D", "OUTPUT", "-p", "udp", "--dport", "53", "-m", "owner", "!", "--uid-owner", "0", "-j", "REDIRECT", "--to-ports", "53"},
@@ -84,14 +97,77 @@ func (m *DNSManager) Teardown() error {
 	return nil
 }
 
+// blockEncryptedDNS rejects DoT (port 853) everywhere and DoH (port 443) to
+// the known resolvers. Failures are logged rather than returned: DNS-level
+// blocking of the provider names still applies without these rules.
+func (m *DNSManager) blockEncryptedDNS() {
+	for _, rule := range m.encryptedDNSRules("-A") {
+		if err := m.run(rule[0], rule[1:]...); err != nil {
+			m.logger.Warn("Failed to add encrypted DNS block rule",
+				logging.String("rule", strings.Join(rule, " ")),
+				logging.Err(err))
+		}
+	}
+	m.logger.Info("Blocking encrypted DNS connections",
+		logging.Int("resolver_ips", len(m.encryptedDNSIPs)))
+}
+
+// unblockEncryptedDNS removes the rules added by blockEncryptedDNS
+func (m *DNSManager) unblockEncryptedDNS() {
+	for _, rule := range m.encryptedDNSRules("-D") {
+		if err := m.run(rule[0], rule[1:]...); err != nil {
+			m.logger.Error("Failed to remove encrypted DNS block rule",
+				logging.String("rule", strings.Join(rule, " ")),
+				logging.Err(err))
+		}
+	}
+}
+
+// encryptedDNSRules returns the iptables/ip6tables commands that add ("-A")
+// or delete ("-D") the encrypted DNS block. Like the redirect rules they
+// exempt root so the service's own upstream lookups are unaffected.
+func (m *DNSManager) encryptedDNSRules(op string) [][]string {
+	reject := func(command string, match ...string) []string {
+		rule := append([]string{command, op, "OUTPUT"}, match...)
+		return append(rule, "-m", "owner", "!", "--uid-owner", "0", "-j", "REJECT")
+	}
+
+	var rules [][]string
+	for _, command := range []string{"iptables", "ip6tables"} {
+		for _, proto := range []string{"tcp", "udp"} {
+			rules = append(rules, reject(command, "-p", proto, "--dport", "853"))
+		}
+	}
+	for _, ip := range m.encryptedDNSIPs {
+		command := "ip6tables"
+		if ip.To4() != nil {
+			command = "iptables"
+		}
+		// UDP covers DoH over HTTP/3
+		for _, proto := range []string{"tcp", "udp"} {
+			rules = append(rules, reject(command, "-d", ip.String(), "-p", proto, "--dport", "443"))
+		}
+	}
+	return rules
+}
+
 func (m *DNSManager) runIptables(args ...string) error {
-	cmd := exec.Command("iptables", args...)
+	return m.run("iptables", args...)
+}
+
+func (m *DNSManager) run(command string, args ...string) error {
+	return runCommand(command, args...)
+}
+
+// runCommand runs a firewall command, returning its stderr in the error
+func runCommand(command string, args ...string) error {
+	cmd := exec.Command(command, args...)
 
 	var stderr bytes.Buffer
 	cmd.Stderr = &stderr
 
 	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("iptables command failed: %s - %w", stderr.String(), err)
+		return fmt.Errorf("%s command failed: %s - %w", command, stderr.String(), err)
 	}
 
 	return nil
@@ -0,0 +1,242 @@
+package enforcement
+
+import (
+	"net"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// maxCachedRegexps bounds the compiled regular expression cache; it is reset
+// when full, which only happens if rules churn far beyond any real list
+const maxCachedRegexps = 4096
+
+var (
+	regexpCacheMu sync.RWMutex
+	regexpCache   = make(map[string]cachedRegexp)
+)
+
+// cachedRegexp is a compiled pattern, or the error compiling it
+type cachedRegexp struct {
+	re  *regexp.Regexp
+	err error
+}
+
+// CompileRegex compiles a regex rule pattern, caching the result so each
+// pattern is compiled once however many lookups it is matched against
+func CompileRegex(pattern string) (*regexp.Regexp, error) {
+	regexpCacheMu.RLock()
+	cached, ok := regexpCache[pattern]
+	regexpCacheMu.RUnlock()
+	if ok {
+		return cached.re, cached.err
+	}
+
+	re, err := regexp.Compile(pattern)
+
+	regexpCacheMu.Lock()
+	if len(regexpCache) >= maxCachedRegexps {
+		regexpCache = make(map[string]cachedRegexp)
+	}
+	regexpCache[pattern] = cachedRegexp{re: re, err: err}
+	regexpCacheMu.Unlock()
+
+	return re, err
+}
+
+// MatchesHost reports whether the rule's pattern matches host. Hosts and
+// patterns are compared case-insensitively and without a trailing dot; URL
+// patterns are reduced to their host first.
+//
+//   - MatchExact matches the host itself
+//   - MatchDomain matches the domain and all of its subdomains
+//   - MatchWildcard matches a glob where * is any run of characters and ? is
+//     a single character
+//   - MatchRegex matches a regular expression against the host
+//   - MatchCIDR matches an IP address host inside the network
+//   - MatchPort never matches a host; see PortRange
+func (rule *FilterRule) MatchesHost(host string) bool {
+	return MatchHost(rule.Pattern, rule.MatchType, host)
+}
+
+// MatchHost reports whether pattern, interpreted according to matchType,
+// matches host. See FilterRule.MatchesHost.
+func MatchHost(pattern string, matchType MatchType, host string) bool {
+	host = normalizeHost(host)
+	if host == "" {
+		return false
+	}
+
+	switch matchType {
+	case MatchDomain:
+		domain := PatternHost(pattern)
+		return domain != "" && (host == domain || strings.HasSuffix(host, "."+domain))
+	case MatchWildcard:
+		return matchGlob(PatternHost(pattern), host)
+	case MatchRegex:
+		re, err := CompileRegex(pattern)
+		return err == nil && re.MatchString(host)
+	case MatchCIDR:
+		ip := net.ParseIP(strings.Trim(host, "[]"))
+		return ip != nil && MatchIP(pattern, matchType, ip)
+	case MatchPort:
+		return false
+	default:
+		return host == PatternHost(pattern)
+	}
+}
+
+// PatternsOverlap reports whether some host is matched by both patterns.
+// See ComparePatterns for how regular expressions are compared.
+func PatternsOverlap(pattern1 string, type1 MatchType, pattern2 string, type2 MatchType) bool {
+	if type1 == MatchRegex || type2 == MatchRegex || type1 == MatchCIDR || type2 == MatchCIDR ||
+		type1 == MatchPort || type2 == MatchPort {
+		return ComparePatterns(pattern1, type1, pattern2, type2) != RelationDisjoint
+	}
+
+	for _, glob1 := range hostGlobs(pattern1, type1) {
+		for _, glob2 := range hostGlobs(pattern2, type2) {
+			if globsIntersect(glob1, glob2) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// PatternHost returns the host part of a URL or host pattern in normalized
+// form, dropping any scheme, credentials, port, path and query
+func PatternHost(pattern string) string {
+	host := pattern
+	if i := strings.Index(host, "://"); i >= 0 {
+		host = host[i+3:]
+	}
+	if i := strings.IndexAny(host, "/#"); i >= 0 {
+		host = host[:i]
+	}
+	if i := strings.LastIndex(host, "@"); i >= 0 {
+		host = host[i+1:]
+	}
+	if strings.Count(host, ":") == 1 {
+		host = host[:strings.Index(host, ":")]
+	}
+	return normalizeHost(host)
+}
+
+// normalizeHost lowercases host and strips a trailing dot
+func normalizeHost(host string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+}
+
+// Glob tokens: a literal byte, or one of these
+const (
+	globAnyOne = -1 // ?
+	globAnyRun = -2 // *
+)
+
+// hostGlobs expresses the hosts a pattern matches as a set of globs
+func hostGlobs(pattern string, matchType MatchType) [][]int {
+	host := PatternHost(pattern)
+	if host == "" {
+		return nil
+	}
+
+	switch matchType {
+	case MatchDomain:
+		literal := literalGlob(host)
+		return [][]int{literal, append([]int{globAnyRun, '.'}, literal...)}
+	case MatchWildcard:
+		return [][]int{compileGlob(host)}
+	default:
+		return [][]int{literalGlob(host)}
+	}
+}
+
+// literalGlob returns a glob matching exactly s
+func literalGlob(s string) []int {
+	glob := make([]int, len(s))
+	for i := 0; i < len(s); i++ {
+		glob[i] = int(s[i])
+	}
+	return glob
+}
+
+// compileGlob parses a pattern where * matches any run of characters and ?
+// matches exactly one
+func compileGlob(pattern string) []int {
+	glob := literalGlob(pattern)
+	for i, c := range glob {
+		switch c {
+		case '*':
+			glob[i] = globAnyRun
+		case '?':
+			glob[i] = globAnyOne
+		}
+	}
+	return glob
+}
+
+// matchGlob reports whether s matches pattern, where * matches any run of
+// characters and ? matches exactly one
+func matchGlob(pattern, s string) bool {
+	glob := compileGlob(pattern)
+	p, i := 0, 0
+	star, mark := -1, 0
+	for i < len(s) {
+		switch {
+		case p < len(glob) && (glob[p] == globAnyOne || glob[p] == int(s[i])):
+			p++
+			i++
+		case p < len(glob) && glob[p] == globAnyRun:
+			star, mark = p, i
+			p++
+		case star >= 0:
+			// Let the last * absorb one more character
+			p = star + 1
+			mark++
+			i = mark
+		default:
+			return false
+		}
+	}
+	for p < len(glob) && glob[p] == globAnyRun {
+		p++
+	}
+	return p == len(glob)
+}
+
+// globsIntersect reports whether some string matches both globs
+func globsIntersect(a, b []int) bool {
+	type state struct{ i, j int }
+	seen := make(map[state]bool)
+
+	var walk func(i, j int) bool
+	walk = func(i, j int) bool {
+		if i == len(a) && j == len(b) {
+			return true
+		}
+		key := state{i, j}
+		if seen[key] {
+			return false
+		}
+		seen[key] = true
+
+		// A * can match nothing, or cover whatever the other glob produces next
+		if i < len(a) && a[i] == globAnyRun {
+			if walk(i+1, j) || (j < len(b) && walk(i, j+1)) {
+				return true
+			}
+		}
+		if j < len(b) && b[j] == globAnyRun {
+			if walk(i, j+1) || (i < len(a) && walk(i+1, j)) {
+				return true
+			}
+		}
+		if i < len(a) && j < len(b) && a[i] != globAnyRun && b[j] != globAnyRun &&
+			(a[i] == b[j] || a[i] == globAnyOne || b[j] == globAnyOne) {
+			return walk(i+1, j+1)
+		}
+		return false
+	}
+	return walk(0, 0)
+}
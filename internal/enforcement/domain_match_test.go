@@ -0,0 +1,207 @@
+package enforcement
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestMatchHost(t *testing.T) {
+	tests := []struct {
+		pattern   string
+		matchType MatchType
+		host      string
+		expected  bool
+	}{
+		{"example.com", MatchExact, "example.com", true},
+		{"example.com", MatchExact, "EXAMPLE.com.", true},
+		{"https://example.com/watch?v=1", MatchExact, "example.com", true},
+		{"example.com", MatchExact, "www.example.com", false},
+		{"example.com", MatchDomain, "example.com", true},
+		{"example.com", MatchDomain, "a.b.example.com", true},
+		{"example.com", MatchDomain, "notexample.com", false},
+		{"example.com", MatchDomain, "example.com.evil.net", false},
+		{"*.example.com", MatchWildcard, "www.example.com", true},
+		{"*.example.com", MatchWildcard, "example.com", false},
+		{"*.example.com", MatchWildcard, "a.b.example.com", true},
+		{"*.example.com", MatchWildcard, "example.com.evil.com", false},
+		{"*.example.com/*", MatchWildcard, "cdn.example.com", true},
+		{"ads?.example.com", MatchWildcard, "ads1.example.com", true},
+		{"ads?.example.com", MatchWildcard, "ads.example.com", false},
+		{`^ads\d+\.example\.com$`, MatchRegex, "ads42.example.com", true},
+		{"(", MatchRegex, "example.com", false},
+		{"example.com", MatchDomain, "", false},
+		{"10.0.0.0/8", MatchCIDR, "10.20.30.40", true},
+		{"10.0.0.0/8", MatchCIDR, "11.0.0.1", false},
+		{"fd00::/8", MatchCIDR, "[fd00::1]", true},
+		{"10.0.0.0/8", MatchCIDR, "10.example.com", false},
+	}
+
+	for _, tt := range tests {
+		if got := MatchHost(tt.pattern, tt.matchType, tt.host); got != tt.expected {
+			t.Errorf("MatchHost(%q, %s, %q) = %v, want %v", tt.pattern, tt.matchType, tt.host, got, tt.expected)
+		}
+	}
+}
+
+func TestCompileRegexCache(t *testing.T) {
+	first, err := CompileRegex(`^ads\d+\.example\.com$`)
+	if err != nil {
+		t.Fatalf("Failed to compile: %v", err)
+	}
+	second, _ := CompileRegex(`^ads\d+\.example\.com$`)
+	if first != second {
+		t.Error("Expected the compiled regex to be reused")
+	}
+
+	if _, err := CompileRegex("("); err == nil {
+		t.Error("Expected an invalid regex to fail to compile")
+	}
+	if _, err := CompileRegex("("); err == nil {
+		t.Error("Expected the compile error to be cached")
+	}
+}
+
+func TestPatternsOverlap(t *testing.T) {
+	tests := []struct {
+		pattern1 string
+		type1    MatchType
+		pattern2 string
+		type2    MatchType
+		expected bool
+	}{
+		{"example.com", MatchExact, "example.com", MatchExact, true},
+		{"example.com", MatchExact, "www.example.com", MatchExact, false},
+		{"example.com", MatchDomain, "www.example.com", MatchExact, true},
+		{"ads.example.com", MatchDomain, "bads.example.com", MatchDomain, false},
+		{"*.example.com", MatchWildcard, "example.com", MatchDomain, true},
+		{"*.example.com", MatchWildcard, "example.com", MatchExact, false},
+		{"ads*.example.com", MatchWildcard, "*ads.example.com", MatchWildcard, true},
+		{"a?.example.com", MatchWildcard, "abc.example.com", MatchExact, false},
+		{"ads.example.com/*", MatchWildcard, "ads.example.com", MatchDomain, true},
+		{"^a$", MatchRegex, "^a$", MatchRegex, true},
+		{"^a$", MatchRegex, "a", MatchExact, true},
+		{"^a$", MatchRegex, "b", MatchExact, false},
+		{`^ads\d\.`, MatchRegex, "ads*.example.com", MatchWildcard, true},
+		{"10.0.0.0/8", MatchCIDR, "10.0.0.0/16", MatchCIDR, true},
+		{"10.0.0.0/8", MatchCIDR, "172.16.0.0/12", MatchCIDR, false},
+		{"6881-6889", MatchPort, "tcp/6881", MatchPort, true},
+		{"udp/80", MatchPort, "tcp/80", MatchPort, false},
+		{"tcp/1-100", MatchPort, "101-150", MatchPort, false},
+		{"80", MatchPort, "example.com", MatchDomain, false},
+	}
+
+	for _, tt := range tests {
+		got := PatternsOverlap(tt.pattern1, tt.type1, tt.pattern2, tt.type2)
+		if got != tt.expected {
+			t.Errorf("PatternsOverlap(%q %s, %q %s) = %v, want %v", tt.pattern1, tt.type1, tt.pattern2, tt.type2, got, tt.expected)
+		}
+	}
+}
+
+// fuzzHost reduces fuzz input to the characters that appear in host names
+func fuzzHost(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '.':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + ('a' - 'A')
+		}
+		return -1
+	}, s)
+}
+
+// fuzzGlob is fuzzHost that also keeps glob metacharacters
+func fuzzGlob(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '*' || r == '?' {
+			return r
+		}
+		if host := fuzzHost(string(r)); host != "" {
+			return rune(host[0])
+		}
+		return -1
+	}, s)
+}
+
+var fuzzMatchTypes = []MatchType{MatchExact, MatchDomain, MatchWildcard}
+
+func FuzzMatchHostDomain(f *testing.F) {
+	f.Add("www", "example.com")
+	f.Add("not", "example.com")
+	f.Add("a.b", "co.uk")
+
+	f.Fuzz(func(t *testing.T, label, domain string) {
+		label = strings.Trim(fuzzHost(label), ".")
+		domain = strings.Trim(fuzzHost(domain), ".")
+		if label == "" || domain == "" {
+			return
+		}
+
+		if !MatchHost(domain, MatchDomain, domain) {
+			t.Errorf("%q should match itself", domain)
+		}
+		if !MatchHost(domain, MatchDomain, label+"."+domain) {
+			t.Errorf("%q should match subdomain %q", domain, label+"."+domain)
+		}
+		if MatchHost(domain, MatchDomain, label+domain) {
+			t.Errorf("%q should not match %q", domain, label+domain)
+		}
+		if MatchHost(domain, MatchExact, label+"."+domain) {
+			t.Errorf("exact %q should not match %q", domain, label+"."+domain)
+		}
+		if !MatchHost("*."+domain, MatchWildcard, label+"."+domain) {
+			t.Errorf("*.%s should match %q", domain, label+"."+domain)
+		}
+	})
+}
+
+func FuzzMatchGlob(f *testing.F) {
+	f.Add("*.example.com", "www.example.com")
+	f.Add("a*b*c", "abbbc")
+	f.Add("??*", "a")
+	f.Add("*a*a*a*b", "aaaaaaaaaaaaaaaaaaaaaaaaaaaa")
+
+	f.Fuzz(func(t *testing.T, pattern, host string) {
+		pattern = fuzzGlob(pattern)
+		host = fuzzHost(host)
+
+		// Reference: the glob translated to a regular expression
+		expr := regexp.QuoteMeta(pattern)
+		expr = strings.ReplaceAll(expr, `\*`, ".*")
+		expr = strings.ReplaceAll(expr, `\?`, ".")
+		want := regexp.MustCompile("^" + expr + "$").MatchString(host)
+
+		if got := matchGlob(pattern, host); got != want {
+			t.Errorf("matchGlob(%q, %q) = %v, want %v", pattern, host, got, want)
+		}
+		if got := globsIntersect(compileGlob(pattern), literalGlob(host)); got != want {
+			t.Errorf("globsIntersect(%q, %q) = %v, want %v", pattern, host, got, want)
+		}
+	})
+}
+
+func FuzzPatternsOverlap(f *testing.F) {
+	f.Add("example.com", uint8(1), "www.example.com", uint8(0), "www.example.com")
+	f.Add("ads*.example.com", uint8(2), "*ads.example.com", uint8(2), "ads.example.com")
+	f.Add("ads.example.com", uint8(1), "bads.example.com", uint8(1), "bads.example.com")
+
+	f.Fuzz(func(t *testing.T, pattern1 string, type1 uint8, pattern2 string, type2 uint8, host string) {
+		pattern1, pattern2, host = fuzzGlob(pattern1), fuzzGlob(pattern2), fuzzHost(host)
+		matchType1 := fuzzMatchTypes[int(type1)%len(fuzzMatchTypes)]
+		matchType2 := fuzzMatchTypes[int(type2)%len(fuzzMatchTypes)]
+
+		overlap := PatternsOverlap(pattern1, matchType1, pattern2, matchType2)
+		if overlap != PatternsOverlap(pattern2, matchType2, pattern1, matchType1) {
+			t.Errorf("PatternsOverlap(%q %s, %q %s) is not symmetric", pattern1, matchType1, pattern2, matchType2)
+		}
+		if !overlap && MatchHost(pattern1, matchType1, host) && MatchHost(pattern2, matchType2, host) {
+			t.Errorf("%q matches %q %s and %q %s, but they are not reported to overlap",
+				host, pattern1, matchType1, pattern2, matchType2)
+		}
+		if PatternHost(pattern1) != "" && !PatternsOverlap(pattern1, matchType1, pattern1, matchType1) {
+			t.Errorf("%q %s should overlap itself", pattern1, matchType1)
+		}
+	})
+}
@@ -2,6 +2,7 @@ package enforcement
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -15,6 +16,7 @@ type EnforcementEngine struct {
 	// Core components
 	processMonitor ProcessMonitor
 	dnsBlocker     *DNSBlocker
+	portFilter     *PortFilter
 	identifier     *ProcessIdentifier
 
 	// Audit logging
@@ -42,6 +44,9 @@ type EnforcementEngine struct {
 	cancel context.CancelFunc
 
 	rules map[string]*FilterRule
+
+	// dnsUnavailable holds the reason DNS filtering was skipped at startup
+	dnsUnavailable string
 }
 
 // EnforcementConfig holds configuration for the enforcement engine
@@ -63,6 +68,27 @@ type EnforcementConfig struct {
 	// Emergency settings
 	EnableEmergencyMode bool     `json:"enable_emergency_mode"`
 	EmergencyWhitelist  []string `json:"emergency_whitelist"`
+
+	// DNS listener settings (empty values use the DNS blocker defaults)
+	DNSListenAddr      string        `json:"dns_listen_addr"`
+	DNSListenInterface string        `json:"dns_listen_interface"`
+	DNSBlockIPv4       string        `json:"dns_block_ipv4"`
+	DNSBlockIPv6       string        `json:"dns_block_ipv6"`
+	DNSBlockResponse   string        `json:"dns_block_response"`
+	DNSUpstreamServers []string      `json:"dns_upstream_servers"`
+	DNSCacheTTL        time.Duration `json:"dns_cache_ttl"`
+	DNSECSMode         string        `json:"dns_ecs_mode"`
+	DNSECSSubnet       string        `json:"dns_ecs_subnet"`
+
+	// Encrypted DNS (DoH/DoT) bypass blocking; the lists extend the built-in
+	// provider lists
+	BlockEncryptedDNS bool     `json:"block_encrypted_dns"`
+	EncryptedDNSHosts []string `json:"encrypted_dns_hosts"`
+	EncryptedDNSIPs   []string `json:"encrypted_dns_ips"`
+
+	// ContinueWithoutDNS keeps the engine running with DNS filtering disabled
+	// when the DNS port is already in use
+	ContinueWithoutDNS bool `json:"continue_without_dns"`
 }
 
 // EnforcementStats holds statistics about enforcement activities
@@ -99,13 +125,25 @@ func NewEnforcementEngine(config *EnforcementConfig, logger logging.Logger, audi
 		config.ProcessPollInterval = 5 * time.Second
 	}
 
+	cacheTTL := config.DNSCacheTTL
+	if cacheTTL == 0 {
+		cacheTTL = 300 * time.Second
+	}
 	dnsBlockerConfig := &DNSBlockerConfig{
-		ListenAddr:    ":53",
-		BlockIPv4:     "0.0.0.0",
-		BlockIPv6:     "::",
-		UpstreamDNS:   []string{"8.8.8.8:53", "1.1.1.1:53"},
-		CacheTTL:      300 * time.Second,
-		EnableLogging: config.LogAllActivity,
+		ListenAddr:      config.DNSListenAddr,
+		ListenInterface: config.DNSListenInterface,
+		BlockIPv4:       config.DNSBlockIPv4,
+		BlockIPv6:       config.DNSBlockIPv6,
+		BlockResponse:   BlockResponse(config.DNSBlockResponse),
+		UpstreamDNS:     append([]string(nil), config.DNSUpstreamServers...),
+		CacheTTL:        cacheTTL,
+		EnableLogging:   config.LogAllActivity,
+		ECSMode:         ECSMode(config.DNSECSMode),
+		ECSSubnet:       config.DNSECSSubnet,
+
+		BlockEncryptedDNS: config.BlockEncryptedDNS,
+		EncryptedDNSHosts: config.EncryptedDNSHosts,
+		EncryptedDNSIPs:   config.EncryptedDNSIPs,
 	}
 	dnsBlocker, err := NewDNSBlocker(dnsBlockerConfig, logger)
 	if err != nil {
@@ -113,12 +151,13 @@ func NewEnforcementEngine(config *EnforcementConfig, logger logging.Logger, audi
 		panic(fmt.Sprintf("failed to create dns blocker: %v", err))
 	}
 
-	return &EnforcementEngine{
+	engine := &EnforcementEngine{
 		config:         config,
 		logger:         logger,
 		auditService:   auditService,
 		processMonitor: NewLinuxProcessMonitor(config.ProcessPollInterval),
 		dnsBlocker:     dnsBlocker,
+		portFilter:     NewPortFilter(logger),
 		identifier:     NewProcessIdentifier(),
 		rules:          make(map[string]*FilterRule),
 		stats:          &EnforcementStats{},
@@ -126,6 +165,10 @@ func NewEnforcementEngine(config *EnforcementConfig, logger logging.Logger, audi
 		cancel:         cancel,
 		stopCh:         make(chan struct{}),
 	}
+	if config.BlockEncryptedDNS {
+		dnsBlocker.SetBypassHandler(engine.recordBypassAttempt)
+	}
+	return engine
 }
 
 // Start starts the enforcement engine and its components
@@ -146,8 +189,14 @@ func (ee *EnforcementEngine) Start(ctx context.Context) error {
 
 	// Start dns blocker
 	if err := ee.dnsBlocker.Start(ctx); err != nil {
-		ee.processMonitor.Stop()
-		return fmt.Errorf("failed to start dns blocker: %w", err)
+		if !errors.Is(err, ErrDNSPortInUse) || !ee.config.ContinueWithoutDNS {
+			ee.processMonitor.Stop()
+			return fmt.Errorf("failed to start dns blocker: %w", err)
+		}
+
+		ee.dnsUnavailable = err.Error()
+		ee.logger.Error("DNS FILTERING IS DISABLED: websites will NOT be blocked until the port conflict is resolved and the service restarted",
+			logging.Err(err))
 	}
 
 	ee.running = true
@@ -190,6 +239,14 @@ func (ee *EnforcementEngine) Stop(ctx context.Context) error {
 		}
 	}
 
+	// Remove port rules so blocked ports open again while the service is down
+	if ee.portFilter != nil {
+		if err := ee.portFilter.Clear(); err != nil {
+			ee.logger.Error("Error removing port rules", logging.Err(err))
+			shutdownErrors = append(shutdownErrors, fmt.Errorf("port filter shutdown failed: %w", err))
+		}
+	}
+
 	// Stop process monitor
 	if ee.processMonitor != nil {
 		if err := ee.processMonitor.Stop(); err != nil {
@@ -268,6 +325,58 @@ func (ee *EnforcementEngine) RemoveNetworkRule(ruleID string) error {
 	return nil
 }
 
+// ReplaceNetworkRules swaps the network filtering rules, keyed by pattern,
+// for a new set in one step
+func (ee *EnforcementEngine) ReplaceNetworkRules(rules map[string]*FilterRule) error {
+	if ee.dnsBlocker == nil {
+		return fmt.Errorf("dns blocker not enabled")
+	}
+
+	if err := ee.dnsBlocker.ReplaceRules(rules); err != nil {
+		ee.incrementErrorCount(fmt.Errorf("failed to replace network rules: %w", err))
+		return err
+	}
+	return nil
+}
+
+// ReplacePortRules makes the blocked destination ports exactly those of the
+// given MatchPort block rules. Rules that are not port blocks are ignored.
+func (ee *EnforcementEngine) ReplacePortRules(rules []*FilterRule) error {
+	if ee.portFilter == nil {
+		return fmt.Errorf("port filter not enabled")
+	}
+
+	var ranges []PortRange
+	for _, rule := range rules {
+		if !rule.Enabled || rule.Action != ActionBlock || rule.MatchType != MatchPort {
+			continue
+		}
+		r, err := ParsePortPattern(rule.Pattern)
+		if err != nil {
+			ee.logger.Warn("Skipping invalid port rule",
+				logging.String("rule_id", rule.ID),
+				logging.String("pattern", rule.Pattern),
+				logging.Err(err))
+			continue
+		}
+		ranges = append(ranges, r)
+	}
+
+	if err := ee.portFilter.ReplaceRanges(ranges); err != nil {
+		ee.incrementErrorCount(fmt.Errorf("failed to replace port rules: %w", err))
+		return err
+	}
+	return nil
+}
+
+// GetBlockedPorts returns the port ranges currently blocked by firewall rules
+func (ee *EnforcementEngine) GetBlockedPorts() []string {
+	if ee.portFilter == nil {
+		return []string{}
+	}
+	return ee.portFilter.AppliedRanges()
+}
+
 // GetCurrentRules returns all currently active rules from the DNS blocker
 func (ee *EnforcementEngine) GetCurrentRules() map[string]*FilterRule {
 	if ee.dnsBlocker == nil {
@@ -376,12 +485,58 @@ func (ee *EnforcementEngine) GetSystemInfo() map[string]interface{} {
 	info := make(map[string]interface{})
 	info["running"] = ee.IsRunning()
 	info["process_monitoring_enabled"] = ee.processMonitor != nil
-	info["network_filtering_enabled"] = ee.dnsBlocker != nil
+	info["network_filtering_enabled"] = ee.dnsBlocker != nil && ee.dnsBlocker.IsRunning()
+	info["blocked_ports"] = ee.GetBlockedPorts()
+	if ee.dnsUnavailable != "" {
+		info["network_filtering_error"] = ee.dnsUnavailable
+	}
 	info["config"] = ee.config
 
 	return info
 }
 
+// IsNetworkFilteringRunning reports whether the DNS blocker is answering queries
+func (ee *EnforcementEngine) IsNetworkFilteringRunning() bool {
+	return ee.dnsBlocker != nil && ee.dnsBlocker.IsRunning()
+}
+
+// GetRuleBlockCounts returns how many DNS queries each network rule has
+// blocked, by rule ID
+func (ee *EnforcementEngine) GetRuleBlockCounts() map[string]int64 {
+	if ee.dnsBlocker == nil {
+		return make(map[string]int64)
+	}
+	return ee.dnsBlocker.GetRuleBlockCounts()
+}
+
+// Problems lists the enforcement components that are not running as they
+// should. It is empty when the engine started cleanly.
+func (ee *EnforcementEngine) Problems() []string {
+	ee.runningMu.RLock()
+	running, dnsUnavailable := ee.running, ee.dnsUnavailable
+	ee.runningMu.RUnlock()
+
+	if !running {
+		return []string{"enforcement engine is not running"}
+	}
+
+	var problems []string
+	if ee.processMonitor == nil {
+		problems = append(problems, "process monitoring is not available")
+	}
+	switch {
+	case dnsUnavailable != "":
+		problems = append(problems, "DNS filtering is disabled: "+dnsUnavailable)
+	case !ee.IsNetworkFilteringRunning():
+		problems = append(problems, "DNS filtering is not running")
+	}
+	if ee.portFilter != nil && ee.portFilter.Unenforced() > 0 {
+		problems = append(problems, fmt.Sprintf("%d port rules are not enforced: %s",
+			ee.portFilter.Unenforced(), ee.portFilter.UnenforcedReason()))
+	}
+	return problems
+}
+
 // processEventHandler handles process start/stop events
 func (ee *EnforcementEngine) processEventHandler(ctx context.Context) {
 	defer ee.wg.Done()
@@ -474,6 +629,38 @@ func (ee *EnforcementEngine) applyProcessEnforcement(ctx context.Context, proces
 	ee.statsMu.Unlock()
 }
 
+// recordBypassAttempt audits a blocked lookup of an encrypted DNS provider
+func (ee *EnforcementEngine) recordBypassAttempt(attempt BypassAttempt) {
+	ee.statsMu.Lock()
+	ee.stats.RuleViolations++
+	ee.statsMu.Unlock()
+
+	if ee.auditService == nil {
+		return
+	}
+
+	// Log asynchronously; this runs on the DNS request path
+	go func() {
+		var err error
+		if bypassLogger, ok := ee.auditService.(BypassAuditLogger); ok {
+			err = bypassLogger.LogBypassAttempt(context.Background(), attempt.Domain, attempt.Client)
+		} else {
+			err = ee.auditService.LogEnforcementAction(
+				context.Background(),
+				models.ActionTypeBlock,
+				models.TargetTypeURL,
+				attempt.Domain,
+				"bypass_attempt",
+				nil,
+				map[string]interface{}{"client": attempt.Client, "reason": "encrypted DNS provider"},
+			)
+		}
+		if err != nil {
+			ee.logger.Error("Failed to log bypass attempt", logging.Err(err))
+		}
+	}()
+}
+
 // statsUpdateLoop periodically updates internal statistics
 func (ee *EnforcementEngine) statsUpdateLoop(ctx context.Context) {
 	defer ee.wg.Done()
@@ -0,0 +1,70 @@
+package enforcement
+
+import "net"
+
+// MatchesIP reports whether the rule's pattern matches a resolved IP address.
+// MatchCIDR rules match addresses inside their network and MatchExact rules
+// whose pattern is an IP address match that address; name patterns never
+// match an address.
+func (rule *FilterRule) MatchesIP(ip net.IP) bool {
+	return MatchIP(rule.Pattern, rule.MatchType, ip)
+}
+
+// MatchIP reports whether pattern, interpreted according to matchType,
+// matches ip. See FilterRule.MatchesIP.
+func MatchIP(pattern string, matchType MatchType, ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	network := patternNetwork(pattern, matchType)
+	return network != nil && network.Contains(ip)
+}
+
+// patternNetwork returns the network a cidr pattern, or an exact pattern
+// naming a single IP address, covers; nil for anything else
+func patternNetwork(pattern string, matchType MatchType) *net.IPNet {
+	switch matchType {
+	case MatchCIDR:
+		_, network, err := net.ParseCIDR(pattern)
+		if err != nil {
+			return nil
+		}
+		return network
+	case MatchExact:
+		ip := net.ParseIP(PatternHost(pattern))
+		if ip == nil {
+			return nil
+		}
+		if ip4 := ip.To4(); ip4 != nil {
+			return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}
+	}
+	return nil
+}
+
+// compareNetworks relates two patterns when either is a cidr pattern. CIDR
+// patterns match addresses rather than names, so they only share hosts with
+// other networks and exact IP addresses.
+func compareNetworks(pattern1 string, type1 MatchType, pattern2 string, type2 MatchType) PatternRelation {
+	network1, network2 := patternNetwork(pattern1, type1), patternNetwork(pattern2, type2)
+	if network1 == nil || network2 == nil {
+		return RelationDisjoint
+	}
+
+	ones1, bits1 := network1.Mask.Size()
+	ones2, bits2 := network2.Mask.Size()
+	switch {
+	case bits1 != bits2:
+		return RelationDisjoint
+	case ones1 == ones2 && network1.IP.Equal(network2.IP):
+		return RelationEqual
+	case ones1 < ones2 && network1.Contains(network2.IP):
+		return RelationSubsumes
+	case ones2 < ones1 && network2.Contains(network1.IP):
+		return RelationSubsumedBy
+	default:
+		// Two networks either nest or share no address
+		return RelationDisjoint
+	}
+}
@@ -0,0 +1,36 @@
+package enforcement
+
+import (
+	"net"
+	"testing"
+)
+
+func TestMatchIP(t *testing.T) {
+	tests := []struct {
+		pattern   string
+		matchType MatchType
+		ip        string
+		expected  bool
+	}{
+		{"10.0.0.0/8", MatchCIDR, "10.255.0.1", true},
+		{"10.0.0.0/8", MatchCIDR, "192.168.1.1", false},
+		{"192.168.1.0/24", MatchCIDR, "::ffff:192.168.1.7", true},
+		{"fd00::/8", MatchCIDR, "fd00::1", true},
+		{"fd00::/8", MatchCIDR, "10.0.0.1", false},
+		{"10.0.0.0", MatchCIDR, "10.0.0.0", false},
+		{"93.184.216.34", MatchExact, "93.184.216.34", true},
+		{"93.184.216.34", MatchExact, "93.184.216.35", false},
+		{"example.com", MatchDomain, "93.184.216.34", false},
+		{"*", MatchWildcard, "93.184.216.34", false},
+	}
+
+	for _, tt := range tests {
+		if got := MatchIP(tt.pattern, tt.matchType, net.ParseIP(tt.ip)); got != tt.expected {
+			t.Errorf("MatchIP(%q, %s, %s) = %v, want %v", tt.pattern, tt.matchType, tt.ip, got, tt.expected)
+		}
+	}
+
+	if MatchIP("10.0.0.0/8", MatchCIDR, nil) {
+		t.Error("Expected a nil IP not to match")
+	}
+}
@@ -0,0 +1,431 @@
+package enforcement
+
+import (
+	"regexp/syntax"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PatternRelation describes how the sets of hosts matched by two patterns
+// relate to each other
+type PatternRelation string
+
+const (
+	// RelationDisjoint patterns have no host in common
+	RelationDisjoint PatternRelation = "disjoint"
+	// RelationEqual patterns match exactly the same hosts
+	RelationEqual PatternRelation = "equal"
+	// RelationSubsumes means the first pattern matches every host the second
+	// does and more, so the second is redundant
+	RelationSubsumes PatternRelation = "subsumes"
+	// RelationSubsumedBy means the second pattern subsumes the first
+	RelationSubsumedBy PatternRelation = "subsumed_by"
+	// RelationOverlaps patterns share some hosts, but each also matches hosts
+	// the other does not
+	RelationOverlaps PatternRelation = "overlaps"
+)
+
+const (
+	// hostAlphabet holds the characters a normalized host is made of
+	hostAlphabet = "abcdefghijklmnopqrstuvwxyz0123456789-._"
+
+	// otherHostRune stands for every character outside the alphabet
+	otherHostRune = '\uFFFD'
+
+	// maxRelationStates bounds the pattern pairs explored; pathological
+	// regular expressions are reported as overlapping or disjoint from what
+	// was seen before the limit
+	maxRelationStates = 20000
+)
+
+// ComparePatterns reports how the hosts matched by two patterns relate, using
+// the same matching as MatchHost. Wildcard and regex patterns are compared
+// exactly by exploring both patterns as automata over host characters, so
+// "*.example.com" subsumes "mail.example.com" and merely overlaps
+// "mail.*". Invalid patterns match nothing and are disjoint from everything.
+func ComparePatterns(pattern1 string, type1 MatchType, pattern2 string, type2 MatchType) PatternRelation {
+	if relation, ok := compareSimplePatterns(pattern1, type1, pattern2, type2); ok {
+		return relation
+	}
+
+	a := newHostAutomaton(pattern1, type1)
+	b := newHostAutomaton(pattern2, type2)
+	if a == nil || b == nil {
+		return RelationDisjoint
+	}
+
+	alphabet := relationAlphabet(pattern1, type1, pattern2, type2)
+
+	// Walk both automata in lockstep over every host. The empty host never
+	// matches, so acceptance is only checked after the first character.
+	type pair struct{ a, b int }
+	start := pair{a.start(), b.start()}
+	seen := make(map[pair]bool)
+	var queue []pair
+	push := func(from pair) {
+		for _, c := range alphabet {
+			next := pair{a.step(from.a, c), b.step(from.b, c)}
+			if !seen[next] {
+				seen[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+	push(start)
+
+	var both, onlyA, onlyB bool
+	for len(queue) > 0 && len(seen) <= maxRelationStates && !(both && onlyA && onlyB) {
+		current := queue[0]
+		queue = queue[1:]
+
+		inA, inB := a.accepts(current.a), b.accepts(current.b)
+		switch {
+		case inA && inB:
+			both = true
+		case inA:
+			onlyA = true
+		case inB:
+			onlyB = true
+		}
+		push(current)
+	}
+
+	if len(queue) > 0 && !(both && onlyA && onlyB) {
+		// Gave up before seeing every host pair
+		if both {
+			return RelationOverlaps
+		}
+		return RelationDisjoint
+	}
+
+	switch {
+	case !both:
+		return RelationDisjoint
+	case !onlyA && !onlyB:
+		return RelationEqual
+	case !onlyB:
+		return RelationSubsumes
+	case !onlyA:
+		return RelationSubsumedBy
+	default:
+		return RelationOverlaps
+	}
+}
+
+// compareSimplePatterns answers for exact, domain, cidr and port patterns without
+// building automata, which keeps comparing large imported lists cheap
+func compareSimplePatterns(pattern1 string, type1 MatchType, pattern2 string, type2 MatchType) (PatternRelation, bool) {
+	simple := func(t MatchType) bool { return t == MatchExact || t == MatchDomain }
+
+	switch {
+	case type1 == MatchPort || type2 == MatchPort:
+		return comparePortPatterns(pattern1, type1, pattern2, type2), true
+	case type1 == MatchCIDR || type2 == MatchCIDR:
+		return compareNetworks(pattern1, type1, pattern2, type2), true
+	case simple(type1) && simple(type2):
+		host1, host2 := PatternHost(pattern1), PatternHost(pattern2)
+		if host1 == "" || host2 == "" {
+			return RelationDisjoint, true
+		}
+		// A domain covers an exact host, or a narrower domain, under it
+		switch {
+		case type1 == type2 && host1 == host2:
+			return RelationEqual, true
+		case type1 == MatchDomain && MatchHost(pattern1, type1, host2):
+			return RelationSubsumes, true
+		case type2 == MatchDomain && MatchHost(pattern2, type2, host1):
+			return RelationSubsumedBy, true
+		default:
+			return RelationDisjoint, true
+		}
+	case type1 == MatchExact:
+		host := PatternHost(pattern1)
+		if host == "" || !MatchHost(pattern2, type2, host) {
+			return RelationDisjoint, true
+		}
+	case type2 == MatchExact:
+		host := PatternHost(pattern2)
+		if host == "" || !MatchHost(pattern1, type1, host) {
+			return RelationDisjoint, true
+		}
+	}
+	return "", false
+}
+
+// relationAlphabet returns one character for each class of host characters
+// the patterns can tell apart
+func relationAlphabet(pattern1 string, type1 MatchType, pattern2 string, type2 MatchType) []rune {
+	set := make(map[rune]bool)
+	for _, c := range hostAlphabet {
+		set[c] = true
+	}
+	set[otherHostRune] = true
+	for _, p := range []struct {
+		pattern   string
+		matchType MatchType
+	}{{pattern1, type1}, {pattern2, type2}} {
+		if p.matchType == MatchRegex {
+			continue
+		}
+		// Globs match bytes, so each byte is its own character
+		host := PatternHost(p.pattern)
+		for i := 0; i < len(host); i++ {
+			if p.matchType != MatchWildcard || (host[i] != '*' && host[i] != '?') {
+				set[rune(host[i])] = true
+			}
+		}
+	}
+
+	alphabet := make([]rune, 0, len(set))
+	for c := range set {
+		alphabet = append(alphabet, c)
+	}
+	sort.Slice(alphabet, func(i, j int) bool { return alphabet[i] < alphabet[j] })
+	return alphabet
+}
+
+// hostAutomaton is a pattern seen as a deterministic automaton over host
+// characters, built lazily as states are reached
+type hostAutomaton interface {
+	start() int
+	step(state int, c rune) int
+	accepts(state int) bool
+}
+
+// newHostAutomaton returns the automaton for a pattern, or nil when the
+// pattern can never match
+func newHostAutomaton(pattern string, matchType MatchType) hostAutomaton {
+	if matchType == MatchRegex {
+		re, err := syntax.Parse(pattern, syntax.Perl)
+		if err != nil {
+			return nil
+		}
+		prog, err := syntax.Compile(re.Simplify())
+		if err != nil {
+			return nil
+		}
+		return &regexAutomaton{prog: prog, table: newStateTable[regexState]()}
+	}
+
+	globs := hostGlobs(pattern, matchType)
+	if len(globs) == 0 {
+		return nil
+	}
+	return &globAutomaton{globs: globs, table: newStateTable[[]globPos]()}
+}
+
+// stateTable numbers automaton states by a canonical key
+type stateTable[T any] struct {
+	ids    map[string]int
+	states []T
+}
+
+func newStateTable[T any]() stateTable[T] {
+	return stateTable[T]{ids: make(map[string]int)}
+}
+
+// intern returns the number of the state with key, adding it if it is new
+func (t *stateTable[T]) intern(key string, state T) int {
+	if id, ok := t.ids[key]; ok {
+		return id
+	}
+	t.ids[key] = len(t.states)
+	t.states = append(t.states, state)
+	return len(t.states) - 1
+}
+
+// globPos is a position in one of the globs of a glob automaton
+type globPos struct{ glob, pos int }
+
+// globAutomaton matches the union of its globs
+type globAutomaton struct {
+	globs [][]int
+	table stateTable[[]globPos]
+}
+
+func (g *globAutomaton) start() int {
+	var positions []globPos
+	for i := range g.globs {
+		positions = append(positions, globPos{i, 0})
+	}
+	return g.intern(positions)
+}
+
+func (g *globAutomaton) step(state int, c rune) int {
+	var next []globPos
+	for _, p := range g.table.states[state] {
+		glob := g.globs[p.glob]
+		if p.pos == len(glob) {
+			continue
+		}
+		switch token := glob[p.pos]; {
+		case token == globAnyRun:
+			next = append(next, p)
+		case token == globAnyOne || rune(token) == c:
+			next = append(next, globPos{p.glob, p.pos + 1})
+		}
+	}
+	return g.intern(next)
+}
+
+func (g *globAutomaton) accepts(state int) bool {
+	for _, p := range g.table.states[state] {
+		if p.pos == len(g.globs[p.glob]) {
+			return true
+		}
+	}
+	return false
+}
+
+// intern closes positions over * matching nothing and numbers the result
+func (g *globAutomaton) intern(positions []globPos) int {
+	closed := make(map[globPos]bool)
+	for _, p := range positions {
+		for {
+			closed[p] = true
+			glob := g.globs[p.glob]
+			if p.pos == len(glob) || glob[p.pos] != globAnyRun {
+				break
+			}
+			p.pos++
+		}
+	}
+
+	sorted := make([]globPos, 0, len(closed))
+	for p := range closed {
+		sorted = append(sorted, p)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].glob != sorted[j].glob {
+			return sorted[i].glob < sorted[j].glob
+		}
+		return sorted[i].pos < sorted[j].pos
+	})
+
+	var key strings.Builder
+	for _, p := range sorted {
+		key.WriteString(strconv.Itoa(p.glob))
+		key.WriteByte(':')
+		key.WriteString(strconv.Itoa(p.pos))
+		key.WriteByte(',')
+	}
+	return g.table.intern(key.String(), sorted)
+}
+
+// regexState is the threads of a regex waiting to consume the next
+// character, the character before it, and whether a match has been found
+type regexState struct {
+	pcs     []uint32
+	prev    rune
+	matched bool
+}
+
+// regexAutomaton matches hosts the way regexp.MatchString does: the host
+// matches if any part of it matches the expression
+type regexAutomaton struct {
+	prog  *syntax.Prog
+	table stateTable[regexState]
+}
+
+func (r *regexAutomaton) start() int {
+	return r.intern(regexState{prev: -1})
+}
+
+func (r *regexAutomaton) step(state int, c rune) int {
+	current := r.table.states[state]
+	if current.matched {
+		return state
+	}
+
+	consumers, matched := r.closure(current, c)
+	if matched {
+		return r.intern(regexState{matched: true})
+	}
+
+	next := regexState{prev: c}
+	for _, pc := range consumers {
+		inst := &r.prog.Inst[pc]
+		if inst.MatchRune(c) {
+			next.pcs = append(next.pcs, inst.Out)
+		}
+	}
+	return r.intern(next)
+}
+
+func (r *regexAutomaton) accepts(state int) bool {
+	current := r.table.states[state]
+	if current.matched {
+		return true
+	}
+	_, matched := r.closure(current, -1)
+	return matched
+}
+
+// closure follows the non-consuming instructions from the state's threads
+// and a new thread starting at this position, with next the following
+// character or -1 at the end of the host. It returns the threads ready to
+// consume next, and whether a match was reached.
+func (r *regexAutomaton) closure(state regexState, next rune) ([]uint32, bool) {
+	context := syntax.EmptyOpContext(state.prev, next)
+	visited := make(map[uint32]bool)
+	var consumers []uint32
+	matched := false
+
+	var follow func(pc uint32)
+	follow = func(pc uint32) {
+		if visited[pc] || matched {
+			return
+		}
+		visited[pc] = true
+
+		inst := &r.prog.Inst[pc]
+		switch inst.Op {
+		case syntax.InstAlt, syntax.InstAltMatch:
+			follow(inst.Out)
+			follow(inst.Arg)
+		case syntax.InstCapture, syntax.InstNop:
+			follow(inst.Out)
+		case syntax.InstEmptyWidth:
+			if syntax.EmptyOp(inst.Arg)&^context == 0 {
+				follow(inst.Out)
+			}
+		case syntax.InstMatch:
+			matched = true
+		case syntax.InstRune, syntax.InstRune1, syntax.InstRuneAny, syntax.InstRuneAnyNotNL:
+			consumers = append(consumers, pc)
+		}
+	}
+
+	for _, pc := range state.pcs {
+		follow(pc)
+	}
+	follow(uint32(r.prog.Start))
+
+	return consumers, matched
+}
+
+// intern numbers a regex state by its sorted, de-duplicated threads
+func (r *regexAutomaton) intern(state regexState) int {
+	if state.matched {
+		return r.table.intern("match", regexState{matched: true})
+	}
+
+	sort.Slice(state.pcs, func(i, j int) bool { return state.pcs[i] < state.pcs[j] })
+	pcs := state.pcs[:0]
+	for i, pc := range state.pcs {
+		if i == 0 || pc != state.pcs[i-1] {
+			pcs = append(pcs, pc)
+		}
+	}
+	state.pcs = pcs
+
+	var key strings.Builder
+	key.WriteString(strconv.Itoa(int(state.prev)))
+	key.WriteByte('|')
+	for _, pc := range state.pcs {
+		key.WriteString(strconv.Itoa(int(pc)))
+		key.WriteByte(',')
+	}
+	return r.table.intern(key.String(), state)
+}
@@ -0,0 +1,129 @@
+package enforcement
+
+import "testing"
+
+func TestComparePatterns(t *testing.T) {
+	tests := []struct {
+		pattern1 string
+		type1    MatchType
+		pattern2 string
+		type2    MatchType
+		expected PatternRelation
+	}{
+		// Exact and domain
+		{"example.com", MatchExact, "EXAMPLE.com.", MatchExact, RelationEqual},
+		{"example.com", MatchExact, "www.example.com", MatchExact, RelationDisjoint},
+		{"example.com", MatchDomain, "www.example.com", MatchExact, RelationSubsumes},
+		{"www.example.com", MatchExact, "example.com", MatchDomain, RelationSubsumedBy},
+		{"example.com", MatchDomain, "mail.example.com", MatchDomain, RelationSubsumes},
+		{"ads.example.com", MatchDomain, "bads.example.com", MatchDomain, RelationDisjoint},
+		{"example.com", MatchDomain, "example.com", MatchExact, RelationSubsumes},
+
+		// Wildcards against exact and domain patterns
+		{"*.example.com", MatchWildcard, "mail.example.com", MatchExact, RelationSubsumes},
+		{"*.example.com", MatchWildcard, "a.b.example.com", MatchExact, RelationSubsumes},
+		{"*.example.com", MatchWildcard, "example.com.evil.com", MatchExact, RelationDisjoint},
+		{"*.example.com", MatchWildcard, "example.com", MatchExact, RelationDisjoint},
+		{"*.example.com", MatchWildcard, "example.com", MatchDomain, RelationSubsumedBy},
+		{"*.example.com", MatchWildcard, "mail.example.com", MatchDomain, RelationSubsumes},
+		{"example.com", MatchWildcard, "example.com", MatchExact, RelationEqual},
+		{"ads.example.com/*", MatchWildcard, "ads.example.com", MatchDomain, RelationSubsumedBy},
+		{"ads?.example.com", MatchWildcard, "ads.example.com", MatchExact, RelationDisjoint},
+
+		// Wildcards against each other
+		{"*.example.com", MatchWildcard, "*.example.com", MatchWildcard, RelationEqual},
+		{"*.example.com", MatchWildcard, "*.mail.example.com", MatchWildcard, RelationSubsumes},
+		{"mail.*", MatchWildcard, "*.example.com", MatchWildcard, RelationOverlaps},
+		{"ads*.example.com", MatchWildcard, "*ads.example.com", MatchWildcard, RelationOverlaps},
+		{"*", MatchWildcard, "ads?.example.com", MatchWildcard, RelationSubsumes},
+		{"*.com", MatchWildcard, "*.net", MatchWildcard, RelationDisjoint},
+		{"a?c.com", MatchWildcard, "a*c.com", MatchWildcard, RelationSubsumedBy},
+		{"**.example.com", MatchWildcard, "*.example.com", MatchWildcard, RelationEqual},
+
+		// Regular expressions
+		{`^ads\d+\.example\.com$`, MatchRegex, "ads42.example.com", MatchExact, RelationSubsumes},
+		{`^ads\d+\.example\.com$`, MatchRegex, "ads.example.com", MatchExact, RelationDisjoint},
+		{"example", MatchRegex, "www.example.com", MatchExact, RelationSubsumes},
+		{`^ads\d+\.example\.com$`, MatchRegex, "example.com", MatchDomain, RelationSubsumedBy},
+		{`\.example\.com$`, MatchRegex, "*.example.com", MatchWildcard, RelationEqual},
+		{`example\.com$`, MatchRegex, "*.example.com", MatchWildcard, RelationSubsumes},
+		{`^ads`, MatchRegex, "*.example.com", MatchWildcard, RelationOverlaps},
+		{`^ads\d`, MatchRegex, `^ads[0-9]`, MatchRegex, RelationEqual},
+		{`^ads\d`, MatchRegex, `^ads`, MatchRegex, RelationSubsumedBy},
+		{`^a`, MatchRegex, `^b`, MatchRegex, RelationDisjoint},
+		{`\bads\b`, MatchRegex, "ads.example.com", MatchDomain, RelationSubsumes},
+		{`^[A-Z]+$`, MatchRegex, "*", MatchWildcard, RelationDisjoint},
+
+		// Networks
+		{"10.0.0.0/8", MatchCIDR, "10.1.0.0/16", MatchCIDR, RelationSubsumes},
+		{"10.0.0.0/8", MatchCIDR, "10.0.0.0/8", MatchCIDR, RelationEqual},
+		{"10.1.2.3/8", MatchCIDR, "10.0.0.0/8", MatchCIDR, RelationEqual},
+		{"10.0.0.0/8", MatchCIDR, "192.168.0.0/16", MatchCIDR, RelationDisjoint},
+		{"fd00::/8", MatchCIDR, "fd12:3456::/32", MatchCIDR, RelationSubsumes},
+		{"0.0.0.0/0", MatchCIDR, "::/0", MatchCIDR, RelationDisjoint},
+		{"10.0.0.0/8", MatchCIDR, "10.2.3.4", MatchExact, RelationSubsumes},
+		{"10.0.0.0/8", MatchCIDR, "11.2.3.4", MatchExact, RelationDisjoint},
+		{"10.0.0.0/8", MatchCIDR, "example.com", MatchDomain, RelationDisjoint},
+		{"10.0.0.0", MatchCIDR, "10.0.0.0/8", MatchCIDR, RelationDisjoint},
+
+		// Ports
+		{"6881-6889", MatchPort, "tcp/6881", MatchPort, RelationSubsumes},
+		{"udp/27015", MatchPort, "udp/27000-27050", MatchPort, RelationSubsumedBy},
+		{"udp/80", MatchPort, "tcp/80", MatchPort, RelationDisjoint},
+		{"UDP/80", MatchPort, "udp/80-80", MatchPort, RelationEqual},
+		{"tcp/1-100", MatchPort, "50-150", MatchPort, RelationOverlaps},
+		{"tcp/1-100", MatchPort, "101-150", MatchPort, RelationDisjoint},
+		{"80", MatchPort, "example.com", MatchDomain, RelationDisjoint},
+		{"0", MatchPort, "0", MatchPort, RelationDisjoint},
+
+		// Patterns that never match
+		{"(", MatchRegex, "(", MatchRegex, RelationDisjoint},
+		{"", MatchExact, "example.com", MatchDomain, RelationDisjoint},
+	}
+
+	for _, tt := range tests {
+		got := ComparePatterns(tt.pattern1, tt.type1, tt.pattern2, tt.type2)
+		if got != tt.expected {
+			t.Errorf("ComparePatterns(%q %s, %q %s) = %s, want %s", tt.pattern1, tt.type1, tt.pattern2, tt.type2, got, tt.expected)
+		}
+
+		// The relation seen from the other side
+		reverse := map[PatternRelation]PatternRelation{
+			RelationSubsumes:   RelationSubsumedBy,
+			RelationSubsumedBy: RelationSubsumes,
+		}
+		want, ok := reverse[tt.expected]
+		if !ok {
+			want = tt.expected
+		}
+		if got := ComparePatterns(tt.pattern2, tt.type2, tt.pattern1, tt.type1); got != want {
+			t.Errorf("ComparePatterns(%q %s, %q %s) = %s, want %s", tt.pattern2, tt.type2, tt.pattern1, tt.type1, got, want)
+		}
+	}
+}
+
+func FuzzComparePatterns(f *testing.F) {
+	f.Add("*.example.com", uint8(2), "mail.example.com", uint8(0), "mail.example.com")
+	f.Add("example.com", uint8(1), "*.example.com", uint8(2), "example.com")
+	f.Add("a*", uint8(2), "*b", uint8(2), "ab")
+
+	f.Fuzz(func(t *testing.T, pattern1 string, type1 uint8, pattern2 string, type2 uint8, host string) {
+		pattern1, pattern2, host = fuzzGlob(pattern1), fuzzGlob(pattern2), fuzzHost(host)
+		matchType1 := fuzzMatchTypes[int(type1)%len(fuzzMatchTypes)]
+		matchType2 := fuzzMatchTypes[int(type2)%len(fuzzMatchTypes)]
+
+		relation := ComparePatterns(pattern1, matchType1, pattern2, matchType2)
+		in1, in2 := MatchHost(pattern1, matchType1, host), MatchHost(pattern2, matchType2, host)
+
+		if (relation == RelationDisjoint) == PatternsOverlap(pattern1, matchType1, pattern2, matchType2) {
+			t.Errorf("ComparePatterns(%q %s, %q %s) = %s disagrees with PatternsOverlap", pattern1, matchType1, pattern2, matchType2, relation)
+		}
+		switch {
+		case relation == RelationDisjoint && in1 && in2,
+			relation == RelationEqual && in1 != in2,
+			relation == RelationSubsumes && in2 && !in1,
+			relation == RelationSubsumedBy && in1 && !in2:
+			t.Errorf("%q contradicts ComparePatterns(%q %s, %q %s) = %s", host, pattern1, matchType1, pattern2, matchType2, relation)
+		}
+	})
+}
@@ -0,0 +1,181 @@
+package enforcement
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"parental-control/internal/logging"
+	"parental-control/internal/privilege"
+)
+
+// PortFilter rejects outbound connections to blocked destination ports with
+// iptables and ip6tables rules. DNS blocking cannot stop programs that
+// connect by IP address, such as game servers and P2P clients; port rules
+// can. Port filtering is only supported on Linux.
+type PortFilter struct {
+	logger logging.Logger
+
+	// run executes a firewall command; replaced in tests
+	run func(command string, args ...string) error
+
+	// elevated reports whether firewall rules can be changed
+	elevated func() bool
+
+	// goos is the operating system the rules are for; replaced in tests
+	goos string
+
+	mu         sync.Mutex
+	applied    map[string]PortRange // ranges with firewall rules, by canonical pattern
+	skipped    int                  // ranges not applied
+	skipReason string               // why the skipped ranges were not applied
+}
+
+// NewPortFilter creates a port filter with no rules applied
+func NewPortFilter(logger logging.Logger) *PortFilter {
+	return &PortFilter{
+		logger:   logger,
+		run:      runCommand,
+		elevated: privilege.IsElevated,
+		goos:     runtime.GOOS,
+		applied:  make(map[string]PortRange),
+	}
+}
+
+// ReplaceRanges makes the blocked ports exactly ranges, adding rules for new
+// ranges and removing those for ranges no longer blocked. Every range is
+// attempted; the first failure is returned.
+func (f *PortFilter) ReplaceRanges(ranges []PortRange) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	desired := make(map[string]PortRange, len(ranges))
+	for _, r := range ranges {
+		desired[r.String()] = r
+	}
+
+	// iptables only exists on Linux
+	reason := ""
+	switch {
+	case f.goos != "linux":
+		reason = "port filtering is unsupported on " + f.goos
+	case !f.elevated():
+		reason = "port filtering requires elevated privileges"
+	}
+	if reason != "" {
+		if len(desired) > 0 && (f.skipped != len(desired) || f.skipReason != reason) {
+			f.logger.Warn("Port rules are not enforced: "+reason,
+				logging.Int("rules", len(desired)))
+		}
+		f.skipped, f.skipReason = len(desired), reason
+		return nil
+	}
+	f.skipped, f.skipReason = 0, ""
+
+	var firstErr error
+	for key, r := range f.applied {
+		if _, keep := desired[key]; keep {
+			continue
+		}
+		if err := f.apply("-D", r); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		// A rule that failed to delete is most likely already gone
+		delete(f.applied, key)
+		f.logger.Info("Unblocked port range", logging.String("ports", key))
+	}
+	for key, r := range desired {
+		if _, exists := f.applied[key]; exists {
+			continue
+		}
+		if err := f.apply("-A", r); err != nil {
+			// Remove whatever part of the range was added
+			f.apply("-D", r)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		f.applied[key] = r
+		f.logger.Info("Blocked port range", logging.String("ports", key))
+	}
+	return firstErr
+}
+
+// Clear removes every port rule
+func (f *PortFilter) Clear() error {
+	return f.ReplaceRanges(nil)
+}
+
+// Unenforced returns how many port ranges should be blocked but are not,
+// because the platform is unsupported or the service lacks the privileges to
+// add firewall rules
+func (f *PortFilter) Unenforced() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.skipped
+}
+
+// UnenforcedReason explains why the unenforced port ranges were not blocked.
+// It is empty when every range is enforced.
+func (f *PortFilter) UnenforcedReason() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.skipped == 0 {
+		return ""
+	}
+	return f.skipReason
+}
+
+// AppliedRanges returns the canonical patterns of the blocked port ranges
+func (f *PortFilter) AppliedRanges() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	patterns := make([]string, 0, len(f.applied))
+	for key := range f.applied {
+		patterns = append(patterns, key)
+	}
+	sort.Strings(patterns)
+	return patterns
+}
+
+// apply adds ("-A") or deletes ("-D") the rules for a range. Adding stops at
+// the first failure; deleting tries every rule.
+func (f *PortFilter) apply(op string, r PortRange) error {
+	var firstErr error
+	for _, rule := range portRangeRules(op, r) {
+		if err := f.run(rule[0], rule[1:]...); err != nil {
+			err = fmt.Errorf("failed to update port rule (%s): %w", strings.Join(rule, " "), err)
+			if op != "-D" {
+				return err
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// portRangeRules returns the iptables/ip6tables commands that add or delete
+// the block for a range. Like the DNS redirect rules they exempt root so the
+// service's own connections are unaffected.
+func portRangeRules(op string, r PortRange) [][]string {
+	ports := strconv.Itoa(r.First)
+	if r.Last != r.First {
+		ports += ":" + strconv.Itoa(r.Last)
+	}
+
+	var rules [][]string
+	for _, command := range []string{"iptables", "ip6tables"} {
+		for _, proto := range r.Protocols() {
+			rules = append(rules, []string{command, op, "OUTPUT", "-p", proto, "--dport", ports,
+				"-m", "owner", "!", "--uid-owner", "0", "-j", "REJECT"})
+		}
+	}
+	return rules
+}
@@ -0,0 +1,95 @@
+package enforcement
+
+import (
+	"strings"
+	"testing"
+
+	"parental-control/internal/logging"
+)
+
+func TestPortFilter_ReplaceRanges(t *testing.T) {
+	var commands []string
+	filter := NewPortFilter(logging.NewDefault())
+	filter.goos = "linux"
+	filter.elevated = func() bool { return true }
+	filter.run = func(command string, args ...string) error {
+		commands = append(commands, command+" "+strings.Join(args, " "))
+		return nil
+	}
+
+	udp, _ := ParsePortPattern("udp/27015")
+	p2p, _ := ParsePortPattern("6881-6889")
+	if err := filter.ReplaceRanges([]PortRange{udp, p2p}); err != nil {
+		t.Fatalf("Failed to replace ranges: %v", err)
+	}
+	// iptables and ip6tables; the range covers both protocols
+	if len(commands) != 6 {
+		t.Fatalf("Expected 6 rules to be added, got %v", commands)
+	}
+	all := strings.Join(commands, "\n")
+	for _, want := range []string{
+		"iptables -A OUTPUT -p udp --dport 27015 -m owner ! --uid-owner 0 -j REJECT",
+		"ip6tables -A OUTPUT -p tcp --dport 6881:6889",
+	} {
+		if !strings.Contains(all, want) {
+			t.Errorf("Expected a rule containing %q, got %v", want, commands)
+		}
+	}
+
+	// Unchanged ranges are left alone and removed ones deleted
+	commands = nil
+	if err := filter.ReplaceRanges([]PortRange{p2p}); err != nil {
+		t.Fatalf("Failed to replace ranges: %v", err)
+	}
+	if len(commands) != 2 || !strings.HasPrefix(commands[0], "iptables -D OUTPUT -p udp --dport 27015") {
+		t.Errorf("Expected only the UDP rules to be deleted, got %v", commands)
+	}
+	if got := filter.AppliedRanges(); len(got) != 1 || got[0] != "6881-6889" {
+		t.Errorf("Expected only 6881-6889 to be blocked, got %v", got)
+	}
+
+	commands = nil
+	if err := filter.Clear(); err != nil || len(commands) != 4 || len(filter.AppliedRanges()) != 0 {
+		t.Errorf("Expected clearing to delete the remaining rules, got %v (err %v)", commands, err)
+	}
+}
+
+func TestPortFilter_RequiresPrivileges(t *testing.T) {
+	filter := NewPortFilter(logging.NewDefault())
+	filter.goos = "linux"
+	filter.elevated = func() bool { return false }
+	filter.run = func(command string, args ...string) error {
+		t.Errorf("Expected no firewall command without privileges, got %s %v", command, args)
+		return nil
+	}
+
+	udp, _ := ParsePortPattern("udp/27015")
+	if err := filter.ReplaceRanges([]PortRange{udp}); err != nil {
+		t.Fatalf("Expected missing privileges to be reported, not returned: %v", err)
+	}
+	if filter.Unenforced() != 1 || len(filter.AppliedRanges()) != 0 {
+		t.Errorf("Expected the range to be reported unenforced, got %d", filter.Unenforced())
+	}
+}
+
+func TestPortFilter_LinuxOnly(t *testing.T) {
+	filter := NewPortFilter(logging.NewDefault())
+	filter.goos = "darwin"
+	filter.elevated = func() bool { return true }
+	filter.run = func(command string, args ...string) error {
+		t.Errorf("Expected no firewall command outside Linux, got %s %v", command, args)
+		return nil
+	}
+
+	udp, _ := ParsePortPattern("udp/27015")
+	if err := filter.ReplaceRanges([]PortRange{udp}); err != nil {
+		t.Fatalf("Expected an unsupported platform to be reported, not returned: %v", err)
+	}
+	if filter.Unenforced() != 1 || filter.UnenforcedReason() != "port filtering is unsupported on darwin" {
+		t.Errorf("Expected the range to be unenforced as unsupported, got %d (%q)", filter.Unenforced(), filter.UnenforcedReason())
+	}
+
+	if err := filter.Clear(); err != nil || filter.Unenforced() != 0 || filter.UnenforcedReason() != "" {
+		t.Errorf("Expected clearing to leave nothing unenforced, got %d (%q, err %v)", filter.Unenforced(), filter.UnenforcedReason(), err)
+	}
+}
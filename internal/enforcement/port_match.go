@@ -0,0 +1,123 @@
+package enforcement
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PortRange is the destination ports, and the protocol, a port pattern
+// blocks. Port patterns are written "[protocol/]port[-port]", e.g.
+// "udp/27015" or "6881-6889"; without a protocol both TCP and UDP match.
+type PortRange struct {
+	Protocol string // "tcp" or "udp"; empty for both
+	First    int
+	Last     int
+}
+
+// portProtocols are the protocols a port pattern may name
+var portProtocols = []string{"tcp", "udp"}
+
+// ParsePortPattern parses a port pattern such as "udp/27015", "tcp/80" or
+// "6881-6889". Ports must be between 1 and 65535 and a range must not run
+// backwards.
+func ParsePortPattern(pattern string) (PortRange, error) {
+	var r PortRange
+	ports := strings.ToLower(strings.TrimSpace(pattern))
+	if protocol, rest, ok := strings.Cut(ports, "/"); ok {
+		if protocol != "tcp" && protocol != "udp" {
+			return r, fmt.Errorf("invalid protocol %q: must be tcp or udp", protocol)
+		}
+		r.Protocol, ports = protocol, rest
+	}
+
+	first, last, isRange := strings.Cut(ports, "-")
+	var err error
+	if r.First, err = parsePort(first); err != nil {
+		return r, err
+	}
+	r.Last = r.First
+	if isRange {
+		if r.Last, err = parsePort(last); err != nil {
+			return r, err
+		}
+		if r.Last < r.First {
+			return r, fmt.Errorf("invalid port range %s: %d is below %d", ports, r.Last, r.First)
+		}
+	}
+	return r, nil
+}
+
+// parsePort parses one port number
+func parsePort(s string) (int, error) {
+	port, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid port %q", s)
+	}
+	if port < 1 || port > 65535 {
+		return 0, fmt.Errorf("port %d is out of range 1-65535", port)
+	}
+	return port, nil
+}
+
+// String returns the pattern in its canonical form, e.g. "udp/27015"
+func (r PortRange) String() string {
+	ports := strconv.Itoa(r.First)
+	if r.Last != r.First {
+		ports += "-" + strconv.Itoa(r.Last)
+	}
+	if r.Protocol == "" {
+		return ports
+	}
+	return r.Protocol + "/" + ports
+}
+
+// Protocols returns the protocols the range applies to
+func (r PortRange) Protocols() []string {
+	if r.Protocol == "" {
+		return portProtocols
+	}
+	return []string{r.Protocol}
+}
+
+// Contains reports whether a connection to port over protocol is in the range
+func (r PortRange) Contains(protocol string, port int) bool {
+	return (r.Protocol == "" || r.Protocol == strings.ToLower(protocol)) &&
+		port >= r.First && port <= r.Last
+}
+
+// comparePortPatterns relates two patterns when either is a port pattern.
+// Port patterns match connections rather than hosts, so they only share
+// anything with other port patterns: a pattern subsumes another when it
+// covers all of its protocols and ports.
+func comparePortPatterns(pattern1 string, type1 MatchType, pattern2 string, type2 MatchType) PatternRelation {
+	if type1 != MatchPort || type2 != MatchPort {
+		return RelationDisjoint
+	}
+	r1, err1 := ParsePortPattern(pattern1)
+	r2, err2 := ParsePortPattern(pattern2)
+	if err1 != nil || err2 != nil {
+		return RelationDisjoint
+	}
+
+	sameProtocol := r1.Protocol == r2.Protocol
+	if !sameProtocol && r1.Protocol != "" && r2.Protocol != "" {
+		return RelationDisjoint
+	}
+	if r1.Last < r2.First || r2.Last < r1.First {
+		return RelationDisjoint
+	}
+
+	covers1 := (r1.Protocol == "" || sameProtocol) && r1.First <= r2.First && r1.Last >= r2.Last
+	covers2 := (r2.Protocol == "" || sameProtocol) && r2.First <= r1.First && r2.Last >= r1.Last
+	switch {
+	case covers1 && covers2:
+		return RelationEqual
+	case covers1:
+		return RelationSubsumes
+	case covers2:
+		return RelationSubsumedBy
+	default:
+		return RelationOverlaps
+	}
+}
@@ -0,0 +1,46 @@
+package enforcement
+
+import "testing"
+
+func TestParsePortPattern(t *testing.T) {
+	tests := []struct {
+		pattern   string
+		expected  PortRange
+		canonical string
+	}{
+		{"udp/27015", PortRange{Protocol: "udp", First: 27015, Last: 27015}, "udp/27015"},
+		{"TCP/80", PortRange{Protocol: "tcp", First: 80, Last: 80}, "tcp/80"},
+		{"6881-6889", PortRange{First: 6881, Last: 6889}, "6881-6889"},
+		{" tcp/1-65535 ", PortRange{Protocol: "tcp", First: 1, Last: 65535}, "tcp/1-65535"},
+		{"443-443", PortRange{First: 443, Last: 443}, "443"},
+	}
+
+	for _, tt := range tests {
+		got, err := ParsePortPattern(tt.pattern)
+		if err != nil {
+			t.Errorf("ParsePortPattern(%q) failed: %v", tt.pattern, err)
+			continue
+		}
+		if got != tt.expected || got.String() != tt.canonical {
+			t.Errorf("ParsePortPattern(%q) = %+v (%s), want %+v (%s)", tt.pattern, got, got, tt.expected, tt.canonical)
+		}
+	}
+
+	for _, pattern := range []string{"", "0", "65536", "icmp/80", "udp/", "6889-6881", "80-", "http", "udp/27015/x", "-5"} {
+		if _, err := ParsePortPattern(pattern); err == nil {
+			t.Errorf("Expected %q to be rejected", pattern)
+		}
+	}
+}
+
+func TestPortRangeContains(t *testing.T) {
+	udp, _ := ParsePortPattern("udp/27015")
+	both, _ := ParsePortPattern("6881-6889")
+
+	if !udp.Contains("UDP", 27015) || udp.Contains("tcp", 27015) || udp.Contains("udp", 27016) {
+		t.Error("Expected a UDP port to match only UDP connections to it")
+	}
+	if !both.Contains("tcp", 6881) || !both.Contains("udp", 6889) || both.Contains("tcp", 6890) {
+		t.Error("Expected a range without a protocol to match both protocols across the range")
+	}
+}
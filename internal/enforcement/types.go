@@ -40,6 +40,8 @@ const (
 	MatchWildcard MatchType = "wildcard"
 	MatchRegex    MatchType = "regex"
 	MatchDomain   MatchType = "domain"
+	MatchCIDR     MatchType = "cidr"
+	MatchPort     MatchType = "port"
 )
 
 // FilterDecision represents the result of evaluating a URL
@@ -64,3 +66,10 @@ type AuditLogger interface {
 		details map[string]interface{},
 	) error
 }
+
+// BypassAuditLogger is implemented by audit loggers that record encrypted DNS
+// bypass attempts as their own event type. Other loggers get them as
+// enforcement actions.
+type BypassAuditLogger interface {
+	LogBypassAttempt(ctx context.Context, domain, client string) error
+}
@@ -163,6 +163,11 @@ func Bool(key string, value bool) Field {
 	return Field{Key: key, Value: value}
 }
 
+// Duration creates a duration field, formatted like "1.5s"
+func Duration(key string, value time.Duration) Field {
+	return Field{Key: key, Value: value}
+}
+
 // Err creates an error field
 func Err(err error) Field {
 	return Field{Key: "error", Value: err.Error()}
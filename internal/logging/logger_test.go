@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestLogLevel_String(t *testing.T) {
@@ -157,6 +158,7 @@ func TestField_String(t *testing.T) {
 		{Int("num", 42), "num=42"},
 		{Bool("flag", true), "flag=true"},
 		{Bool("flag", false), "flag=false"},
+		{Duration("elapsed", 1500*time.Millisecond), "elapsed=1.5s"},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,170 @@
+package metrics
+
+import (
+	"runtime"
+	"sort"
+	"time"
+
+	"parental-control/internal/service"
+)
+
+// namespace prefixes every application metric
+const namespace = "parental_control_"
+
+// RuntimeCollector reports Go runtime memory and goroutine counts
+func RuntimeCollector() Collector {
+	started := time.Now()
+	return CollectorFunc(func() []Family {
+		var m runtime.MemStats
+		runtime.ReadMemStats(&m)
+
+		return []Family{
+			NewFamily("go_goroutines", "Number of goroutines that currently exist.", Gauge, float64(runtime.NumGoroutine())),
+			NewFamily("go_memstats_alloc_bytes", "Number of bytes allocated and still in use.", Gauge, float64(m.Alloc)),
+			NewFamily("go_memstats_sys_bytes", "Number of bytes obtained from the system.", Gauge, float64(m.Sys)),
+			NewFamily("go_gc_cycles_total", "Number of completed GC cycles.", Counter, float64(m.NumGC)),
+			NewFamily("process_start_time_seconds", "Start time of the process since unix epoch in seconds.", Gauge, float64(started.Unix())),
+		}
+	})
+}
+
+// PerformanceCollector reports the performance monitor's latest sample:
+// resource usage and per-service throughput and error rates
+func PerformanceCollector(monitor *service.PerformanceMonitor) Collector {
+	return CollectorFunc(func() []Family {
+		current := monitor.GetCurrentMetrics()
+		if current == nil || current.Timestamp.IsZero() {
+			return nil
+		}
+
+		throughput := make(map[string]float64)
+		errorRates := make(map[string]float64)
+		for name, rate := range current.ThroughputRates {
+			throughput[name] = rate
+		}
+		for name, rate := range current.ErrorRates {
+			errorRates[name] = rate
+		}
+		if audit := current.AuditMetrics; audit != nil {
+			throughput["audit"] = audit.ThroughputPerSec
+			errorRates["audit"] = audit.FailureRate
+		}
+		if enforcement := current.EnforcementMetrics; enforcement != nil {
+			throughput["enforcement"] = enforcement.NetworkRequestsPerSec
+			errorRates["enforcement"] = enforcement.ErrorRate
+		}
+		if retention := current.RetentionMetrics; retention != nil {
+			throughput["retention"] = retention.EntriesDeletedPerSec
+		}
+
+		families := []Family{
+			NewFamily(namespace+"memory_usage_bytes", "Heap memory in use at the last performance sample.", Gauge, float64(current.MemoryUsage)),
+			NewFamily(namespace+"cpu_usage_percent", "CPU usage at the last performance sample.", Gauge, current.CPUUsage),
+			NewFamily(namespace+"performance_sample_timestamp_seconds", "Time of the last performance sample.", Gauge, float64(current.Timestamp.Unix())),
+			labelledFamily(namespace+"service_throughput_per_second", "Work items processed per second by service.", Gauge, "service", throughput),
+			labelledFamily(namespace+"service_error_rate_percent", "Failed work items as a percentage by service.", Gauge, "service", errorRates),
+		}
+		if db := current.DatabaseMetrics; db != nil {
+			connections := map[string]float64{
+				"in_use": float64(db.InUse),
+				"idle":   float64(db.Idle),
+			}
+			families = append(families,
+				labelledFamily(namespace+"database_connections", "Write pool connections by state at the last performance sample.", Gauge, "state", connections),
+				NewFamily(namespace+"database_max_open_connections", "Most connections the write pool may open.", Gauge, float64(db.MaxOpenConnections)),
+				NewFamily(namespace+"database_wait_count_total", "Times a statement waited for a free write pool connection.", Counter, float64(db.WaitCount)),
+				NewFamily(namespace+"database_wait_seconds_total", "Time spent waiting for free write pool connections.", Counter, db.WaitDuration.Seconds()),
+				NewFamily(namespace+"database_slow_queries_total", "Statements slower than database.slow_query_threshold.", Counter, float64(db.SlowQueries)))
+		}
+		return families
+	})
+}
+
+// NotificationCollector reports notification delivery counters
+func NotificationCollector(notifications *service.NotificationService) Collector {
+	return CollectorFunc(func() []Family {
+		stats := notifications.GetStats()
+		sent := map[string]float64{
+			string(service.NotificationTypeAppBlocked):  float64(stats.AppBlockingSent),
+			string(service.NotificationTypeWebBlocked):  float64(stats.WebBlockingSent),
+			string(service.NotificationTypeTimeLimit):   float64(stats.TimeLimitSent),
+			string(service.NotificationTypeSystemAlert): float64(stats.SystemAlertsSent),
+		}
+
+		return []Family{
+			labelledFamily(namespace+"notifications_sent_total", "Notifications delivered by type.", Counter, "type", sent),
+			NewFamily(namespace+"notifications_rate_limited_total", "Notifications dropped by rate limiting.", Counter, float64(stats.RateLimited)),
+			NewFamily(namespace+"notifications_coalesced_total", "Repeated notifications folded into a summary.", Counter, float64(stats.Coalesced)),
+			NewFamily(namespace+"notifications_errors_total", "Notifications that failed to reach a sink.", Counter, float64(stats.Errors)),
+		}
+	})
+}
+
+// EnforcementCollector reports enforcement engine block and error counts
+func EnforcementCollector(enforcement *service.EnforcementService) Collector {
+	return CollectorFunc(func() []Family {
+		stats := enforcement.GetStats()
+		if stats == nil {
+			return nil
+		}
+		requests := map[string]float64{
+			"blocked": float64(stats.NetworkRequestsBlocked),
+			"allowed": float64(stats.NetworkRequestsAllowed),
+		}
+
+		return []Family{
+			labelledFamily(namespace+"network_requests_total", "Network requests filtered by result.", Counter, "result", requests),
+			NewFamily(namespace+"enforcement_actions_total", "Enforcement actions taken, such as terminating a blocked process.", Counter, float64(stats.EnforcementActions)),
+			NewFamily(namespace+"rule_violations_total", "Rule violations detected.", Counter, float64(stats.RuleViolations)),
+			NewFamily(namespace+"enforcement_errors_total", "Enforcement errors.", Counter, float64(stats.ErrorCount)),
+			NewFamily(namespace+"processes_monitored", "Processes seen by the process monitor.", Gauge, float64(stats.ProcessesMonitored)),
+			NewFamily(namespace+"enforcement_response_seconds", "Average time to evaluate a request.", Gauge, stats.AverageResponseTime.Seconds()),
+		}
+	})
+}
+
+// DatabaseMaintenanceCollector reports WAL checkpoints and VACUUMs and the
+// space the last VACUUM reclaimed
+func DatabaseMaintenanceCollector(maintenance *service.DatabaseMaintenanceService) Collector {
+	return CollectorFunc(func() []Family {
+		stats := maintenance.Stats()
+		families := []Family{
+			NewFamily(namespace+"database_checkpoints_total", "WAL checkpoints run by database maintenance.", Counter, float64(stats.Checkpoints)),
+			NewFamily(namespace+"database_vacuums_total", "VACUUMs run by database maintenance.", Counter, float64(stats.Vacuums)),
+			NewFamily(namespace+"database_maintenance_deferred_total", "Maintenance runs put off while a retention run was deleting.", Counter, float64(stats.DeferredRuns)),
+			NewFamily(namespace+"database_maintenance_errors_total", "Failed checkpoints and VACUUMs.", Counter, float64(stats.Errors)),
+		}
+		if stats.LastCheckpoint != nil {
+			families = append(families,
+				NewFamily(namespace+"database_last_checkpoint_timestamp_seconds", "Time of the last WAL checkpoint.", Gauge, float64(stats.LastCheckpoint.Unix())),
+				NewFamily(namespace+"database_last_checkpoint_wal_bytes", "Size of the WAL before the last checkpoint.", Gauge, float64(stats.LastCheckpointWALBytes)))
+		}
+		if stats.LastVacuum != nil {
+			sizes := map[string]float64{
+				"before": float64(stats.LastVacuumSizeBefore),
+				"after":  float64(stats.LastVacuumSizeAfter),
+			}
+			families = append(families,
+				NewFamily(namespace+"database_last_vacuum_timestamp_seconds", "Time of the last VACUUM.", Gauge, float64(stats.LastVacuum.Unix())),
+				labelledFamily(namespace+"database_last_vacuum_size_bytes", "Database and WAL size around the last VACUUM.", Gauge, "when", sizes),
+				NewFamily(namespace+"database_last_vacuum_duration_seconds", "How long the last VACUUM took.", Gauge, stats.LastVacuumDuration.Seconds()))
+		}
+		return families
+	})
+}
+
+// labelledFamily creates a family with one sample per label value, in
+// label order
+func labelledFamily(name, help string, metricType Type, label string, values map[string]float64) Family {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	family := Family{Name: name, Help: help, Type: metricType}
+	for _, key := range keys {
+		family.Samples = append(family.Samples, Sample{Labels: map[string]string{label: key}, Value: values[key]})
+	}
+	return family
+}
@@ -0,0 +1,185 @@
+// Package metrics exposes application statistics to Prometheus.
+//
+// Collectors read the current statistics of the running services on every
+// scrape and the registry renders them in the Prometheus text exposition
+// format (version 0.0.4), the format promhttp serves by default.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// contentType is the Prometheus text exposition format
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Type is a Prometheus metric type
+type Type string
+
+const (
+	// Counter values only go up, except when the process restarts
+	Counter Type = "counter"
+	// Gauge values go up and down
+	Gauge Type = "gauge"
+)
+
+// Sample is one value of a metric family, identified by its labels
+type Sample struct {
+	Labels map[string]string
+	Value  float64
+}
+
+// Family is a named metric and its samples
+type Family struct {
+	Name    string
+	Help    string
+	Type    Type
+	Samples []Sample
+}
+
+// NewFamily creates a family holding a single unlabelled sample
+func NewFamily(name, help string, metricType Type, value float64) Family {
+	return Family{Name: name, Help: help, Type: metricType, Samples: []Sample{{Value: value}}}
+}
+
+// Collector produces metric families when the registry is scraped
+type Collector interface {
+	Collect() []Family
+}
+
+// CollectorFunc adapts a function to the Collector interface
+type CollectorFunc func() []Family
+
+// Collect calls f
+func (f CollectorFunc) Collect() []Family {
+	return f()
+}
+
+// Registry holds the collectors exposed by a metrics endpoint
+type Registry struct {
+	mu         sync.RWMutex
+	collectors []Collector
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds a collector to the registry
+func (r *Registry) Register(collector Collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors = append(r.collectors, collector)
+}
+
+// Gather collects every registered family, sorted by name. Families
+// without samples are dropped.
+func (r *Registry) Gather() []Family {
+	r.mu.RLock()
+	collectors := append([]Collector(nil), r.collectors...)
+	r.mu.RUnlock()
+
+	var families []Family
+	for _, collector := range collectors {
+		for _, family := range collector.Collect() {
+			if len(family.Samples) > 0 {
+				families = append(families, family)
+			}
+		}
+	}
+
+	sort.SliceStable(families, func(i, j int) bool { return families[i].Name < families[j].Name })
+	return families
+}
+
+// WriteText writes the gathered families in the text exposition format
+func (r *Registry) WriteText(w io.Writer) error {
+	buf := bufio.NewWriter(w)
+	for _, family := range r.Gather() {
+		if family.Help != "" {
+			fmt.Fprintf(buf, "# HELP %s %s\n", family.Name, escapeHelp(family.Help))
+		}
+		fmt.Fprintf(buf, "# TYPE %s %s\n", family.Name, family.Type)
+		for _, sample := range family.Samples {
+			buf.WriteString(family.Name)
+			writeLabels(buf, sample.Labels)
+			buf.WriteByte(' ')
+			buf.WriteString(formatValue(sample.Value))
+			buf.WriteByte('\n')
+		}
+	}
+	return buf.Flush()
+}
+
+// Handler serves the registry to Prometheus scrapers
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet && req.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", contentType)
+		if req.Method == http.MethodHead {
+			return
+		}
+		r.WriteText(w)
+	})
+}
+
+// writeLabels writes {name="value",...} with names in sorted order
+func writeLabels(buf *bufio.Writer, labels map[string]string) {
+	if len(labels) == 0 {
+		return
+	}
+
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	buf.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		fmt.Fprintf(buf, "%s=\"%s\"", name, escapeLabelValue(labels[name]))
+	}
+	buf.WriteByte('}')
+}
+
+var (
+	helpEscaper       = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelValueEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
+
+func escapeLabelValue(s string) string {
+	return labelValueEscaper.Replace(s)
+}
+
+// formatValue renders a sample value, including the special float values
+func formatValue(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
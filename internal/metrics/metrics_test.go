@@ -0,0 +1,158 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"parental-control/internal/logging"
+	"parental-control/internal/server"
+	"parental-control/internal/service"
+)
+
+func TestRegistry_WriteText(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(CollectorFunc(func() []Family {
+		return []Family{
+			labelledFamily("test_requests_total", "Requests by result.", Counter, "result",
+				map[string]float64{"blocked": 3, "allowed": 7}),
+			NewFamily("test_label_escaping", "Help with \\ and\nnewline.", Gauge, 1.5),
+			{Name: "test_empty", Type: Gauge},
+		}
+	}))
+	registry.Register(CollectorFunc(func() []Family {
+		return []Family{{
+			Name:    "test_quoted",
+			Type:    Gauge,
+			Samples: []Sample{{Labels: map[string]string{"path": `C:\"x"`}, Value: 2}},
+		}}
+	}))
+
+	var out strings.Builder
+	if err := registry.WriteText(&out); err != nil {
+		t.Fatalf("WriteText failed: %v", err)
+	}
+
+	want := `# HELP test_label_escaping Help with \\ and\nnewline.
+# TYPE test_label_escaping gauge
+test_label_escaping 1.5
+# TYPE test_quoted gauge
+test_quoted{path="C:\\\"x\""} 2
+# HELP test_requests_total Requests by result.
+# TYPE test_requests_total counter
+test_requests_total{result="allowed"} 7
+test_requests_total{result="blocked"} 3
+`
+	if out.String() != want {
+		t.Errorf("Unexpected exposition:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
+
+func TestServer_ServesMetricsWithAuth(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(RuntimeCollector())
+
+	metricsServer := NewServer(ServerConfig{
+		Host:            "127.0.0.1",
+		Port:            0,
+		Path:            "/metrics",
+		HealthCheckPath: "/health",
+		Auth:            server.MetricsAuthConfig{Mode: server.MetricsAuthBearer, Token: "a-long-scrape-token"},
+	}, registry)
+	if err := metricsServer.Start(context.Background()); err != nil {
+		t.Fatalf("Failed to start: %v", err)
+	}
+	defer metricsServer.Stop(context.Background())
+	base := "http://" + metricsServer.Address()
+
+	resp, err := http.Get(base + "/metrics")
+	if err != nil {
+		t.Fatalf("Scrape failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without a token, got %d", resp.StatusCode)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, base+"/metrics", nil)
+	req.Header.Set("Authorization", "Bearer a-long-scrape-token")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Scrape failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Fatalf("Unexpected scrape response: %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	if !strings.Contains(string(body), "# TYPE go_goroutines gauge\ngo_goroutines ") {
+		t.Errorf("Expected runtime metrics, got:\n%s", body)
+	}
+
+	resp, err = http.Get(base + "/health")
+	if err != nil {
+		t.Fatalf("Health check failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected an open health check, got %d", resp.StatusCode)
+	}
+
+	// A second server on the same address reports the conflict
+	conflicting := NewServer(ServerConfig{Host: "127.0.0.1", Port: portOf(t, metricsServer.Address()), Path: "/metrics"}, registry)
+	if err := conflicting.Start(context.Background()); err == nil {
+		conflicting.Stop(context.Background())
+		t.Error("Expected a port conflict error")
+	}
+}
+
+func portOf(t *testing.T, address string) int {
+	t.Helper()
+	_, portText, err := net.SplitHostPort(address)
+	if err != nil {
+		t.Fatalf("Bad address %q: %v", address, err)
+	}
+	port, err := strconv.Atoi(portText)
+	if err != nil {
+		t.Fatalf("Bad port %q: %v", portText, err)
+	}
+	return port
+}
+
+func TestNotificationCollector(t *testing.T) {
+	notifications := service.NewNotificationService(service.DefaultNotificationConfig(), logging.NewDefault())
+	notifications.SetEnabled(false)
+	notifications.NotifyAppBlocked(context.Background(), "game", 1, "")
+
+	families := NotificationCollector(notifications).Collect()
+	if len(families) != 4 || families[0].Name != "parental_control_notifications_sent_total" {
+		t.Fatalf("Unexpected families: %+v", families)
+	}
+	for _, sample := range families[0].Samples {
+		if sample.Value != 0 {
+			t.Errorf("Expected no notifications sent while disabled, got %+v", sample)
+		}
+	}
+	if len(families[0].Samples) != 4 || families[0].Samples[0].Labels["type"] != "app_blocked" {
+		t.Errorf("Expected one sample per notification type, got %+v", families[0].Samples)
+	}
+}
+
+func TestDatabaseMaintenanceCollector(t *testing.T) {
+	maintenance := service.NewDatabaseMaintenanceService(nil, logging.NewDefault(), service.DefaultDatabaseMaintenanceConfig())
+
+	// Nothing has run, so there is no last checkpoint or vacuum to report
+	families := DatabaseMaintenanceCollector(maintenance).Collect()
+	if len(families) != 4 || families[0].Name != "parental_control_database_checkpoints_total" {
+		t.Fatalf("Unexpected families: %+v", families)
+	}
+	for _, family := range families {
+		if family.Samples[0].Value != 0 {
+			t.Errorf("Expected %s to start at zero, got %v", family.Name, family.Samples[0].Value)
+		}
+	}
+}
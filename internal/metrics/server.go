@@ -0,0 +1,116 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"parental-control/internal/logging"
+	"parental-control/internal/server"
+)
+
+// ServerConfig configures the metrics endpoint
+type ServerConfig struct {
+	Host string
+	Port int
+	// Path serves the metrics, e.g. /metrics
+	Path string
+	// HealthCheckPath answers 200 while the process is up; empty disables it
+	HealthCheckPath string
+	// Auth protects the metrics path; the health check stays open
+	Auth server.MetricsAuthConfig
+}
+
+// Server serves a registry on its own listener, separate from the web UI
+type Server struct {
+	config   ServerConfig
+	registry *Registry
+
+	mu       sync.Mutex
+	server   *http.Server
+	listener net.Listener
+}
+
+// NewServer creates a metrics server for registry
+func NewServer(config ServerConfig, registry *Registry) *Server {
+	return &Server{config: config, registry: registry}
+}
+
+// Start binds the listener, so a port conflict is reported here, then
+// serves in the background
+func (s *Server) Start(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.server != nil {
+		return fmt.Errorf("metrics server is already running")
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(s.config.Path, server.MetricsAuthMiddleware(s.config.Auth)(s.registry.Handler()))
+	if s.config.HealthCheckPath != "" && s.config.HealthCheckPath != s.config.Path {
+		mux.HandleFunc(s.config.HealthCheckPath, func(w http.ResponseWriter, r *http.Request) {
+			server.WriteJSONResponse(w, http.StatusOK, map[string]string{"status": "ok"})
+		})
+	}
+
+	addr := net.JoinHostPort(s.config.Host, strconv.Itoa(s.config.Port))
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+
+	s.listener = listener
+	s.server = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+		WriteTimeout:      30 * time.Second,
+	}
+
+	go func(httpServer *http.Server) {
+		if err := httpServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logging.Error("Metrics server stopped unexpectedly", logging.Err(err))
+		}
+	}(s.server)
+
+	logging.Info("Metrics server started",
+		logging.String("address", listener.Addr().String()),
+		logging.String("path", s.config.Path),
+		logging.String("auth", string(s.config.Auth.Mode)))
+	return nil
+}
+
+// Stop shuts the server down, waiting for in-flight scrapes until ctx ends
+func (s *Server) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.server == nil {
+		return nil
+	}
+
+	err := s.server.Shutdown(ctx)
+	s.server = nil
+	s.listener = nil
+	if err != nil {
+		return fmt.Errorf("failed to stop metrics server: %w", err)
+	}
+	logging.Info("Metrics server stopped")
+	return nil
+}
+
+// Address returns the listening address, or "" when stopped
+func (s *Server) Address() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.listener == nil {
+		return ""
+	}
+	return s.listener.Addr().String()
+}
@@ -4,7 +4,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"regexp"
+	"sync"
 	"time"
+
+	// Zone data for time rules on systems without a zoneinfo database
+	_ "time/tzdata"
 )
 
 // Config represents a configuration key-value pair
@@ -31,6 +35,8 @@ type List struct {
 	Name        string      `json:"name" db:"name" validate:"required,max=255"`
 	Type        ListType    `json:"type" db:"type" validate:"required,oneof=whitelist blacklist"`
 	Description string      `json:"description" db:"description"`
+	Label       string      `json:"label,omitempty" db:"label" validate:"max=64"`
+	Notes       string      `json:"notes,omitempty" db:"notes"`
 	Enabled     bool        `json:"enabled" db:"enabled"`
 	CreatedAt   time.Time   `json:"created_at" db:"created_at"`
 	UpdatedAt   time.Time   `json:"updated_at" db:"updated_at"`
@@ -52,6 +58,22 @@ const (
 	PatternTypeExact    PatternType = "exact"
 	PatternTypeWildcard PatternType = "wildcard"
 	PatternTypeDomain   PatternType = "domain"
+	PatternTypeRegex    PatternType = "regex"
+	PatternTypeCIDR     PatternType = "cidr"
+	PatternTypePort     PatternType = "port"
+)
+
+// MaxLabelLength is the longest label a list or entry may have
+const MaxLabelLength = 64
+
+// EntrySource records how a list entry was added
+type EntrySource string
+
+const (
+	// EntrySourceManual entries were added one at a time by a parent
+	EntrySourceManual EntrySource = "manual"
+	// EntrySourceImport entries came from a bulk import
+	EntrySourceImport EntrySource = "import"
 )
 
 // ListEntry represents an entry in a list (executable or URL)
@@ -60,8 +82,11 @@ type ListEntry struct {
 	ListID      int         `json:"list_id" db:"list_id" validate:"required"`
 	EntryType   EntryType   `json:"entry_type" db:"entry_type" validate:"required,oneof=executable url"`
 	Pattern     string      `json:"pattern" db:"pattern" validate:"required,max=1000"`
-	PatternType PatternType `json:"pattern_type" db:"pattern_type" validate:"required,oneof=exact wildcard domain"`
+	PatternType PatternType `json:"pattern_type" db:"pattern_type" validate:"required,oneof=exact wildcard domain regex cidr port"`
 	Description string      `json:"description" db:"description"`
+	Label       string      `json:"label,omitempty" db:"label" validate:"max=64"`
+	Notes       string      `json:"notes,omitempty" db:"notes"`
+	Source      EntrySource `json:"source" db:"source"`
 	Enabled     bool        `json:"enabled" db:"enabled"`
 	CreatedAt   time.Time   `json:"created_at" db:"created_at"`
 	UpdatedAt   time.Time   `json:"updated_at" db:"updated_at"`
@@ -84,11 +109,70 @@ type TimeRule struct {
 	DaysOfWeek []int     `json:"days_of_week" db:"days_of_week" validate:"required,dive,min=0,max=6"`
 	StartTime  string    `json:"start_time" db:"start_time" validate:"required"`
 	EndTime    string    `json:"end_time" db:"end_time" validate:"required"`
+	Timezone   string    `json:"timezone,omitempty" db:"timezone"` // IANA zone, e.g. "America/Chicago"; empty is the server's zone
 	Enabled    bool      `json:"enabled" db:"enabled"`
 	CreatedAt  time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
 }
 
+// Location returns the zone the rule's window is evaluated in: Timezone, or
+// the server's local zone when it is unset or cannot be loaded
+func (tr *TimeRule) Location() *time.Location {
+	if tr.Timezone == "" {
+		return time.Local
+	}
+	if loc, ok := timeRuleLocations.Load(tr.Timezone); ok {
+		return loc.(*time.Location)
+	}
+	loc, err := time.LoadLocation(tr.Timezone)
+	if err != nil {
+		return time.Local
+	}
+	timeRuleLocations.Store(tr.Timezone, loc)
+	return loc
+}
+
+// timeRuleLocations caches loaded zones by name, as loading one reads the
+// zone database
+var timeRuleLocations sync.Map
+
+// ActiveAt reports whether an enabled rule's window contains t. The day and
+// time of day are those on the wall clock of the rule's zone, and a window
+// that ends before it starts (e.g., 22:00 to 06:00) runs past midnight.
+func (tr *TimeRule) ActiveAt(t time.Time) bool {
+	if !tr.Enabled {
+		return false
+	}
+	t = t.In(tr.Location())
+
+	dayMatches := false
+	for _, day := range tr.DaysOfWeek {
+		if day == int(t.Weekday()) {
+			dayMatches = true
+			break
+		}
+	}
+	if !dayMatches {
+		return false
+	}
+
+	start, err := time.Parse("15:04", tr.StartTime)
+	if err != nil {
+		return false
+	}
+	end, err := time.Parse("15:04", tr.EndTime)
+	if err != nil {
+		return false
+	}
+	from := start.Hour()*60 + start.Minute()
+	to := end.Hour()*60 + end.Minute()
+	minute := t.Hour()*60 + t.Minute()
+	if from <= to {
+		return minute >= from && minute <= to
+	}
+	return minute >= from || minute <= to
+}
+
 // MarshalDaysOfWeek converts the days of week slice to JSON for database storage
 func (tr *TimeRule) MarshalDaysOfWeek() (string, error) {
 	data, err := json.Marshal(tr.DaysOfWeek)
@@ -116,6 +200,18 @@ func ValidateTimeFormat(timeStr string) error {
 	return nil
 }
 
+// ValidateTimezone validates an IANA time zone name such as
+// "Europe/Berlin". Empty is valid and means the server's local zone.
+func ValidateTimezone(name string) error {
+	if name == "" {
+		return nil
+	}
+	if _, err := time.LoadLocation(name); err != nil {
+		return fmt.Errorf("unknown timezone %q", name)
+	}
+	return nil
+}
+
 // QuotaType represents the type of quota (daily, weekly, monthly)
 type QuotaType string
 
@@ -125,6 +221,17 @@ const (
 	QuotaTypeMonthly QuotaType = "monthly"
 )
 
+// QuotaCarryOver is what happens to a quota's unused time when its window rolls
+type QuotaCarryOver string
+
+const (
+	// CarryOverNone starts every window with exactly the rule's limit
+	CarryOverNone QuotaCarryOver = "none"
+	// CarryOverUnusedCapped adds the previous window's unused time to the
+	// next window, up to the rule's carry-over cap
+	CarryOverUnusedCapped QuotaCarryOver = "unused-rolls-forward-capped"
+)
+
 // QuotaRule represents a duration-based limit rule
 type QuotaRule struct {
 	ID           int       `json:"id" db:"id"`
@@ -133,8 +240,13 @@ type QuotaRule struct {
 	QuotaType    QuotaType `json:"quota_type" db:"quota_type" validate:"required,oneof=daily weekly monthly"`
 	LimitSeconds int       `json:"limit_seconds" db:"limit_seconds" validate:"required,min=1"`
 	Enabled      bool      `json:"enabled" db:"enabled"`
-	CreatedAt    time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+	// CarryOver is the policy applied when a window rolls; empty means none
+	CarryOver QuotaCarryOver `json:"carry_over" db:"carry_over" validate:"omitempty,oneof=none unused-rolls-forward-capped"`
+	// MaxCarryOverSeconds caps the time a window can hold on top of the
+	// limit; 0 caps it at one window's limit
+	MaxCarryOverSeconds int       `json:"carry_over_max_seconds" db:"carry_over_max_seconds" validate:"min=0"`
+	CreatedAt           time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at" db:"updated_at"`
 }
 
 // GetLimitDuration returns the limit as a time.Duration
@@ -142,6 +254,17 @@ func (qr *QuotaRule) GetLimitDuration() time.Duration {
 	return time.Duration(qr.LimitSeconds) * time.Second
 }
 
+// CarryOverCap returns the most unused time that may roll into a window
+func (qr *QuotaRule) CarryOverCap() int {
+	if qr.CarryOver != CarryOverUnusedCapped {
+		return 0
+	}
+	if qr.MaxCarryOverSeconds > 0 {
+		return qr.MaxCarryOverSeconds
+	}
+	return qr.LimitSeconds
+}
+
 // QuotaUsage tracks usage against quota rules
 type QuotaUsage struct {
 	ID          int       `json:"id" db:"id"`
@@ -149,8 +272,10 @@ type QuotaUsage struct {
 	PeriodStart time.Time `json:"period_start" db:"period_start" validate:"required"`
 	PeriodEnd   time.Time `json:"period_end" db:"period_end" validate:"required"`
 	UsedSeconds int       `json:"used_seconds" db:"used_seconds"`
-	CreatedAt   time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+	// CarriedSeconds is unused time rolled in from the previous window
+	CarriedSeconds int       `json:"carried_seconds" db:"carried_seconds"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
 }
 
 // GetUsedDuration returns the used time as a time.Duration
@@ -158,29 +283,39 @@ func (qu *QuotaUsage) GetUsedDuration() time.Duration {
 	return time.Duration(qu.UsedSeconds) * time.Second
 }
 
+// AllowanceSeconds returns the time available in the window: the rule's
+// limit plus any time carried in
+func (qu *QuotaUsage) AllowanceSeconds(limitSeconds int) int {
+	return limitSeconds + qu.CarriedSeconds
+}
+
 // RemainingSeconds returns the remaining seconds in the quota
 func (qu *QuotaUsage) RemainingSeconds(limitSeconds int) int {
-	remaining := limitSeconds - qu.UsedSeconds
+	remaining := qu.AllowanceSeconds(limitSeconds) - qu.UsedSeconds
 	if remaining < 0 {
 		return 0
 	}
 	return remaining
 }
 
-// ActionType represents the action taken (allow or block)
+// ActionType represents the action taken (allow or block, or an admin action)
 type ActionType string
 
 const (
 	ActionTypeAllow ActionType = "allow"
 	ActionTypeBlock ActionType = "block"
+	// ActionTypeAdminReset is an admin password reset from the command line
+	ActionTypeAdminReset ActionType = "admin_reset"
 )
 
-// TargetType represents the type of target (executable or URL)
+// TargetType represents the type of target (executable or URL, or a user
+// account for admin actions)
 type TargetType string
 
 const (
 	TargetTypeExecutable TargetType = "executable"
 	TargetTypeURL        TargetType = "url"
+	TargetTypeAccount    TargetType = "account"
 )
 
 // AuditLog represents an audit log entry
@@ -188,15 +323,84 @@ type AuditLog struct {
 	ID          int        `json:"id" db:"id"`
 	Timestamp   time.Time  `json:"timestamp" db:"timestamp"`
 	EventType   string     `json:"event_type" db:"event_type" validate:"required,max=100"`
-	TargetType  TargetType `json:"target_type" db:"target_type" validate:"required,oneof=executable url"`
+	TargetType  TargetType `json:"target_type" db:"target_type" validate:"required,oneof=executable url account"`
 	TargetValue string     `json:"target_value" db:"target_value" validate:"required,max=1000"`
-	Action      ActionType `json:"action" db:"action" validate:"required,oneof=allow block"`
+	Action      ActionType `json:"action" db:"action" validate:"required,oneof=allow block admin_reset"`
 	RuleType    string     `json:"rule_type" db:"rule_type"`
 	RuleID      *int       `json:"rule_id" db:"rule_id"`
 	Details     string     `json:"details" db:"details"`
 	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
 }
 
+// User is a stored user account. The auth package owns the password hash and
+// the encrypted TOTP secret; they are only persisted here.
+type User struct {
+	ID                int        `json:"id" db:"id"`
+	Username          string     `json:"username" db:"username"`
+	PasswordHash      string     `json:"-" db:"password_hash"`
+	Email             string     `json:"email" db:"email"`
+	IsActive          bool       `json:"is_active" db:"is_active"`
+	IsAdmin           bool       `json:"is_admin" db:"is_admin"`
+	LastLoginAt       *time.Time `json:"last_login_at" db:"last_login_at"`
+	PasswordChangedAt time.Time  `json:"password_changed_at" db:"password_changed_at"`
+	FailedAttempts    int        `json:"failed_attempts" db:"failed_attempts"`
+	LockedUntil       *time.Time `json:"locked_until" db:"locked_until"`
+	TwoFactorEnabled  bool       `json:"two_factor_enabled" db:"two_factor_enabled"`
+	TOTPSecret        string     `json:"-" db:"totp_secret"`
+	LastTOTPStep      int64      `json:"-" db:"last_totp_step"`
+	CreatedAt         time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// APIKey is a stored API key. Only the SHA-256 hash of the key is kept.
+type APIKey struct {
+	ID         int        `json:"id" db:"id"`
+	UserID     int        `json:"user_id" db:"user_id"`
+	Name       string     `json:"name" db:"name"`
+	Prefix     string     `json:"prefix" db:"prefix"`
+	KeyHash    string     `json:"-" db:"key_hash"`
+	Scope      string     `json:"scope" db:"scope"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+}
+
+// LockoutState records how often an account has been locked so graduated
+// lockouts survive a restart
+type LockoutState struct {
+	Username      string     `json:"username" db:"username"`
+	LockoutCount  int        `json:"lockout_count" db:"lockout_count"`
+	LockedUntil   *time.Time `json:"locked_until" db:"locked_until"`
+	LastLockoutAt *time.Time `json:"last_lockout_at" db:"last_lockout_at"`
+	UpdatedAt     time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// BlockListSource records the URL a list's entries are imported from. Lists
+// with a refresh interval are re-fetched periodically; the ETag and
+// Last-Modified validators make unchanged lists cheap to check.
+type BlockListSource struct {
+	ListID          int           `json:"list_id" db:"list_id"`
+	URL             string        `json:"url" db:"url"`
+	Format          string        `json:"format" db:"format"`
+	RefreshInterval time.Duration `json:"refresh_interval" db:"refresh_interval_seconds"`
+	ETag            string        `json:"etag,omitempty" db:"etag"`
+	LastModified    string        `json:"last_modified,omitempty" db:"last_modified"`
+	LastCheckedAt   *time.Time    `json:"last_checked_at,omitempty" db:"last_checked_at"`
+	LastError       string        `json:"last_error,omitempty" db:"last_error"`
+	CreatedAt       time.Time     `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time     `json:"updated_at" db:"updated_at"`
+}
+
+// DueForRefresh reports whether a periodically refreshed source should be
+// fetched again at now
+func (s *BlockListSource) DueForRefresh(now time.Time) bool {
+	if s.RefreshInterval <= 0 {
+		return false
+	}
+	return s.LastCheckedAt == nil || !now.Before(s.LastCheckedAt.Add(s.RefreshInterval))
+}
+
 // GetDetailsMap parses the details JSON into a map
 func (al *AuditLog) GetDetailsMap() (map[string]interface{}, error) {
 	if al.Details == "" {
@@ -224,6 +428,20 @@ func (al *AuditLog) SetDetailsMap(details map[string]interface{}) error {
 	return nil
 }
 
+// ProcessName returns the process the entry concerns: the target of an
+// executable entry, or the process_name detail of any other
+func (al *AuditLog) ProcessName() string {
+	if al.TargetType == TargetTypeExecutable {
+		return al.TargetValue
+	}
+	details, err := al.GetDetailsMap()
+	if err != nil {
+		return ""
+	}
+	name, _ := details["process_name"].(string)
+	return name
+}
+
 // SchemaVersion represents a database schema version
 type SchemaVersion struct {
 	Version     int       `json:"version" db:"version"`
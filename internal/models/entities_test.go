@@ -212,6 +212,38 @@ func TestValidateTimeFormat(t *testing.T) {
 	}
 }
 
+func TestValidateTimezone(t *testing.T) {
+	tests := []struct {
+		name  string
+		valid bool
+	}{
+		{"", true}, // the server's zone
+		{"UTC", true},
+		{"America/Chicago", true},
+		{"Europe/Berlin", true},
+		{"Mars/Olympus_Mons", false},
+		{"CST6", false},
+	}
+
+	for _, tt := range tests {
+		err := ValidateTimezone(tt.name)
+		if tt.valid && err != nil {
+			t.Errorf("Expected %q to be valid, got error: %v", tt.name, err)
+		}
+		if !tt.valid && err == nil {
+			t.Errorf("Expected %q to be invalid, got no error", tt.name)
+		}
+	}
+
+	rule := TimeRule{Timezone: "America/Chicago"}
+	if rule.Location().String() != "America/Chicago" {
+		t.Errorf("Expected the rule's location to be America/Chicago, got %s", rule.Location())
+	}
+	if (&TimeRule{}).Location() != time.Local {
+		t.Error("Expected a rule without a timezone to use the server's zone")
+	}
+}
+
 func TestQuotaRuleDuration(t *testing.T) {
 	rule := QuotaRule{
 		ID:           1,
@@ -367,6 +399,36 @@ func TestValidationErrors(t *testing.T) {
 	}
 }
 
+func TestArchivalPolicyCompressionValidation(t *testing.T) {
+	tests := []struct {
+		format  CompressionType
+		level   int
+		wantErr bool
+	}{
+		{"", 6, false},
+		{CompressionGzip, 9, false},
+		{CompressionGzip, 12, true},
+		{CompressionZstd, 19, false},
+		{CompressionZstd, 23, true},
+		{CompressionNone, 0, false},
+		{CompressionLz4, 1, true},
+	}
+
+	for _, tt := range tests {
+		policy := &ArchivalPolicy{
+			EnableCompression: true,
+			CompressionFormat: tt.format,
+			CompressionLevel:  tt.level,
+			ArchiveLocation:   "archives",
+			MaxArchiveSize:    1 << 20,
+			ArchiveRetention:  time.Hour,
+		}
+		if err := policy.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("format %q level %d: expected error %v, got %v", tt.format, tt.level, tt.wantErr, err)
+		}
+	}
+}
+
 func TestDefaultSearchFilters(t *testing.T) {
 	filters := DefaultSearchFilters()
 
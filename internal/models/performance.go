@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// PerformanceSnapshot is a persisted point-in-time performance sample. The
+// metrics are kept as JSON since their shape belongs to the service layer.
+type PerformanceSnapshot struct {
+	ID        int       `json:"id" db:"id"`
+	Timestamp time.Time `json:"timestamp" db:"timestamp"`
+	Metrics   string    `json:"metrics" db:"metrics"`
+}
+
+// PerformanceAlertRecord is a persisted performance alert. An alert stays
+// open while its threshold is breached, so its ID is reused across restarts.
+type PerformanceAlertRecord struct {
+	ID            string     `json:"id" db:"id"`
+	ThresholdName string     `json:"threshold_name" db:"threshold_name"`
+	Threshold     string     `json:"threshold" db:"threshold"` // JSON encoded threshold
+	Severity      string     `json:"severity" db:"severity"`
+	CurrentValue  float64    `json:"current_value" db:"current_value"`
+	Message       string     `json:"message" db:"message"`
+	TriggeredAt   time.Time  `json:"triggered_at" db:"triggered_at"`
+	Resolved      bool       `json:"resolved" db:"resolved"`
+	ResolvedAt    *time.Time `json:"resolved_at,omitempty" db:"resolved_at"`
+}
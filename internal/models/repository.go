@@ -2,9 +2,14 @@ package models
 
 import (
 	"context"
+	"errors"
 	"time"
 )
 
+// ErrDuplicateEntry is returned when a list already has an entry with the
+// same entry type and pattern
+var ErrDuplicateEntry = errors.New("entry already exists in this list")
+
 // Repository interfaces define the contract for data access
 
 // ConfigRepository handles configuration data access
@@ -31,7 +36,12 @@ type ListRepository interface {
 
 // ListEntryRepository handles list entry data access
 type ListEntryRepository interface {
+	// Create and Update return an error wrapping ErrDuplicateEntry when the
+	// list already has the entry's pattern
 	Create(ctx context.Context, entry *ListEntry) error
+	// CreateBatch inserts entries in one transaction, skipping any whose
+	// pattern already exists in the entry's list, and returns how many were inserted
+	CreateBatch(ctx context.Context, entries []ListEntry) (int, error)
 	GetByID(ctx context.Context, id int) (*ListEntry, error)
 	GetByListID(ctx context.Context, listID int) ([]ListEntry, error)
 	GetByPattern(ctx context.Context, pattern string, entryType EntryType) ([]ListEntry, error)
@@ -51,6 +61,8 @@ type TimeRuleRepository interface {
 	GetEnabled(ctx context.Context) ([]TimeRule, error)
 	GetActiveRules(ctx context.Context, now time.Time) ([]TimeRule, error)
 	Update(ctx context.Context, rule *TimeRule) error
+	// UpdateBatch updates rules in a single transaction
+	UpdateBatch(ctx context.Context, rules []TimeRule) error
 	Delete(ctx context.Context, id int) error
 	DeleteByListID(ctx context.Context, listID int) error
 	Count(ctx context.Context) (int, error)
@@ -63,6 +75,8 @@ type QuotaRuleRepository interface {
 	GetByListID(ctx context.Context, listID int) ([]QuotaRule, error)
 	GetEnabled(ctx context.Context) ([]QuotaRule, error)
 	Update(ctx context.Context, rule *QuotaRule) error
+	// UpdateBatch updates rules in a single transaction
+	UpdateBatch(ctx context.Context, rules []QuotaRule) error
 	Delete(ctx context.Context, id int) error
 	DeleteByListID(ctx context.Context, listID int) error
 	Count(ctx context.Context) (int, error)
@@ -91,10 +105,25 @@ type AuditLogRepository interface {
 	GetByTargetType(ctx context.Context, targetType TargetType, limit, offset int) ([]AuditLog, error)
 	GetTodayStats(ctx context.Context) (allows int, blocks int, err error)
 	CleanupOldLogs(ctx context.Context, before time.Time) error
+	// CleanupOldLogsFiltered deletes entries before the cutoff that match
+	// filter, oldest first and at most limit of them when limit > 0. It
+	// returns the entries deleted and the bytes they took up.
+	CleanupOldLogsFiltered(ctx context.Context, before time.Time, filter AuditLogCleanupFilter, limit int) (deleted int64, bytesFreed int64, err error)
+	// CountOldLogsFiltered counts the entries CleanupOldLogsFiltered would delete and their size
+	CountOldLogsFiltered(ctx context.Context, before time.Time, filter AuditLogCleanupFilter) (count int, bytes int64, err error)
+	// AverageEntrySize samples the most recent entries to estimate their average size in bytes
+	AverageEntrySize(ctx context.Context, sampleSize int) (int64, error)
 	Count(ctx context.Context) (int, error)
 	CountByTimeRange(ctx context.Context, start, end time.Time) (int, error)
 }
 
+// AuditLogCleanupFilter selects the audit log entries a cleanup may delete.
+// Empty lists match everything.
+type AuditLogCleanupFilter struct {
+	EventTypes []string
+	Actions    []string
+}
+
 // SchemaVersionRepository handles schema version tracking
 type SchemaVersionRepository interface {
 	GetLatestVersion(ctx context.Context) (*SchemaVersion, error)
@@ -108,6 +137,55 @@ type DashboardRepository interface {
 	GetQuotasNearLimit(ctx context.Context, threshold float64) ([]QuotaUsage, error)
 }
 
+// UserRepository persists user accounts
+type UserRepository interface {
+	GetAll(ctx context.Context) ([]User, error)
+	GetByUsername(ctx context.Context, username string) (*User, error)
+	Create(ctx context.Context, user *User) error
+	Update(ctx context.Context, user *User) error
+	Delete(ctx context.Context, id int) error
+}
+
+// APIKeyRepository persists API keys by the hash of the key
+type APIKeyRepository interface {
+	GetAll(ctx context.Context) ([]APIKey, error)
+	GetByID(ctx context.Context, id int) (*APIKey, error)
+	GetByHash(ctx context.Context, keyHash string) (*APIKey, error)
+	Create(ctx context.Context, key *APIKey) error
+	Update(ctx context.Context, key *APIKey) error
+	UpdateLastUsed(ctx context.Context, id int, usedAt time.Time) error
+}
+
+// LockoutStateRepository persists account lockout escalation state
+type LockoutStateRepository interface {
+	GetAll(ctx context.Context) ([]LockoutState, error)
+	Save(ctx context.Context, state *LockoutState) error
+	Delete(ctx context.Context, username string) error
+}
+
+// BlockListSourceRepository persists where imported block lists come from
+type BlockListSourceRepository interface {
+	GetAll(ctx context.Context) ([]BlockListSource, error)
+	GetByListID(ctx context.Context, listID int) (*BlockListSource, error)
+	Save(ctx context.Context, source *BlockListSource) error
+	Delete(ctx context.Context, listID int) error
+}
+
+// PerformanceHistoryRepository persists performance trend snapshots and
+// alerts
+type PerformanceHistoryRepository interface {
+	SaveSnapshot(ctx context.Context, snapshot *PerformanceSnapshot) error
+	// GetSnapshotsByTimeRange returns snapshots oldest first; limit <= 0 means no limit
+	GetSnapshotsByTimeRange(ctx context.Context, start, end time.Time, limit int) ([]PerformanceSnapshot, error)
+	CleanupOldSnapshots(ctx context.Context, before time.Time) error
+	// SaveAlert creates the alert or updates it by ID
+	SaveAlert(ctx context.Context, alert *PerformanceAlertRecord) error
+	// GetAlerts returns unresolved alerts and those triggered since, oldest first
+	GetAlerts(ctx context.Context, since time.Time) ([]PerformanceAlertRecord, error)
+	// CleanupOldAlerts removes resolved alerts triggered before the cutoff
+	CleanupOldAlerts(ctx context.Context, before time.Time) error
+}
+
 // RetentionPolicyRepository handles retention policy data access
 type RetentionPolicyRepository interface {
 	Create(ctx context.Context, policy *RetentionPolicy) error
@@ -170,6 +248,11 @@ type RepositoryManager struct {
 	QuotaRule            QuotaRuleRepository
 	QuotaUsage           QuotaUsageRepository
 	AuditLog             AuditLogRepository
+	User                 UserRepository
+	APIKey               APIKeyRepository
+	LockoutState         LockoutStateRepository
+	BlockListSource      BlockListSourceRepository
+	PerformanceHistory   PerformanceHistoryRepository
 	RetentionPolicy      RetentionPolicyRepository
 	RetentionExecution   RetentionExecutionRepository
 	LogRotationPolicy    LogRotationPolicyRepository
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"time"
+
+	"parental-control/internal/cron"
 )
 
 // RetentionPolicy represents a configurable log retention policy
@@ -123,6 +125,19 @@ const (
 	ExecutionStatusCancelled ExecutionStatus = "cancelled"
 )
 
+// RunSummary is a service-agnostic view of one maintenance run (retention or
+// log rotation) so both histories can be rendered the same way
+type RunSummary struct {
+	Time          time.Time       `json:"time"`
+	PolicyID      int             `json:"policy_id"`
+	Trigger       string          `json:"trigger"`
+	Status        ExecutionStatus `json:"status"`
+	ItemsAffected int64           `json:"items_affected"`
+	BytesFreed    int64           `json:"bytes_freed"`
+	Duration      time.Duration   `json:"duration"`
+	Error         string          `json:"error,omitempty"`
+}
+
 // RetentionStats represents statistics about retention operations
 type RetentionStats struct {
 	TotalPolicies       int                           `json:"total_policies"`
@@ -154,6 +169,12 @@ func (rp *RetentionPolicy) Validate() error {
 		return fmt.Errorf("policy name is required")
 	}
 
+	if rp.ExecutionSchedule != "" {
+		if _, err := cron.Parse(rp.ExecutionSchedule); err != nil {
+			return fmt.Errorf("invalid execution schedule: %w", err)
+		}
+	}
+
 	// At least one retention rule must be specified
 	if rp.TimeBasedRule == nil && rp.SizeBasedRule == nil && rp.CountBasedRule == nil {
 		return fmt.Errorf("at least one retention rule must be specified")
@@ -400,3 +421,25 @@ func (rpe *RetentionPolicyExecution) GetDetailsMap() (map[string]interface{}, er
 	err := json.Unmarshal([]byte(rpe.Details), &details)
 	return details, err
 }
+
+// Summary returns the execution as a RunSummary. Executions recorded before
+// triggers were tracked are reported as scheduled.
+func (rpe *RetentionPolicyExecution) Summary() RunSummary {
+	trigger := string(TriggerScheduled)
+	if details, err := rpe.GetDetailsMap(); err == nil {
+		if value, ok := details["trigger"].(string); ok && value != "" {
+			trigger = value
+		}
+	}
+
+	return RunSummary{
+		Time:          rpe.ExecutionTime,
+		PolicyID:      rpe.PolicyID,
+		Trigger:       trigger,
+		Status:        rpe.Status,
+		ItemsAffected: rpe.EntriesDeleted,
+		BytesFreed:    rpe.BytesFreed,
+		Duration:      rpe.Duration,
+		Error:         rpe.ErrorMessage,
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"time"
+
+	"parental-control/internal/cron"
 )
 
 // LogRotationPolicy represents a configurable log rotation policy
@@ -55,11 +57,11 @@ type TimeBasedRotation struct {
 // ArchivalPolicy defines archival and compression settings
 type ArchivalPolicy struct {
 	EnableCompression bool            `json:"enable_compression"`
-	CompressionFormat CompressionType `json:"compression_format"`
+	CompressionFormat CompressionType `json:"compression_format"` // Defaults to gzip
 	ArchiveLocation   string          `json:"archive_location"`
 	MaxArchiveSize    int64           `json:"max_archive_size"`
 	ArchiveRetention  time.Duration   `json:"archive_retention"`
-	CompressionLevel  int             `json:"compression_level"` // 1-9 for gzip
+	CompressionLevel  int             `json:"compression_level"` // 1-9 for gzip, 1-22 for zstd
 	EncryptArchives   bool            `json:"encrypt_archives"`
 }
 
@@ -126,6 +128,7 @@ type RotationStats struct {
 	TotalPolicies           int                          `json:"total_policies"`
 	ActivePolicies          int                          `json:"active_policies"`
 	LastRotationTime        time.Time                    `json:"last_rotation_time"`
+	TotalExecutions         int64                        `json:"total_executions"`
 	TotalFilesRotated       int64                        `json:"total_files_rotated"`
 	TotalBytesFreed         int64                        `json:"total_bytes_freed"`
 	TotalBytesCompressed    int64                        `json:"total_bytes_compressed"`
@@ -133,6 +136,7 @@ type RotationStats struct {
 	DiskSpaceInfo           *DiskSpaceInfo               `json:"disk_space_info"`
 	PolicyStats             map[int]*PolicyRotationStats `json:"policy_stats"`
 	RecentExecutions        []LogRotationExecution       `json:"recent_executions"`
+	RecentRuns              []RunSummary                 `json:"recent_runs"`
 	EmergencyTriggers       int64                        `json:"emergency_triggers"`
 }
 
@@ -155,6 +159,7 @@ type PolicyRotationStats struct {
 type CompressionType string
 
 const (
+	CompressionNone  CompressionType = "none"
 	CompressionGzip  CompressionType = "gzip"
 	CompressionBzip2 CompressionType = "bzip2"
 	CompressionLz4   CompressionType = "lz4"
@@ -195,14 +200,15 @@ const (
 
 // FileRotationInfo represents information about a rotated file
 type FileRotationInfo struct {
-	OriginalPath     string    `json:"original_path"`
-	RotatedPath      string    `json:"rotated_path"`
-	ArchivePath      string    `json:"archive_path,omitempty"`
-	OriginalSize     int64     `json:"original_size"`
-	CompressedSize   int64     `json:"compressed_size,omitempty"`
-	CompressionRatio float64   `json:"compression_ratio,omitempty"`
-	RotatedAt        time.Time `json:"rotated_at"`
-	Checksum         string    `json:"checksum,omitempty"`
+	OriginalPath      string          `json:"original_path"`
+	RotatedPath       string          `json:"rotated_path"`
+	ArchivePath       string          `json:"archive_path,omitempty"`
+	OriginalSize      int64           `json:"original_size"`
+	CompressedSize    int64           `json:"compressed_size,omitempty"`
+	CompressionRatio  float64         `json:"compression_ratio,omitempty"`
+	RotatedAt         time.Time       `json:"rotated_at"`
+	Checksum          string          `json:"checksum,omitempty"`
+	CompressionFormat CompressionType `json:"compression_format,omitempty"`
 }
 
 // Validation methods
@@ -213,6 +219,12 @@ func (lrp *LogRotationPolicy) Validate() error {
 		return fmt.Errorf("policy name is required")
 	}
 
+	if lrp.ExecutionSchedule != "" {
+		if _, err := cron.Parse(lrp.ExecutionSchedule); err != nil {
+			return fmt.Errorf("invalid execution schedule: %w", err)
+		}
+	}
+
 	// At least one rotation rule must be specified
 	if lrp.SizeBasedRotation == nil && lrp.TimeBasedRotation == nil {
 		return fmt.Errorf("at least one rotation rule must be specified")
@@ -294,13 +306,32 @@ func (ap *ArchivalPolicy) Validate() error {
 		return fmt.Errorf("archive_retention must be positive")
 	}
 
-	if ap.CompressionLevel < 1 || ap.CompressionLevel > 9 {
-		return fmt.Errorf("compression_level must be between 1 and 9")
+	switch ap.Format() {
+	case CompressionNone:
+	case CompressionGzip:
+		if ap.CompressionLevel < 1 || ap.CompressionLevel > 9 {
+			return fmt.Errorf("compression_level must be between 1 and 9 for gzip")
+		}
+	case CompressionZstd:
+		if ap.CompressionLevel < 1 || ap.CompressionLevel > 22 {
+			return fmt.Errorf("compression_level must be between 1 and 22 for zstd")
+		}
+	default:
+		return fmt.Errorf("unsupported compression_format %q", ap.CompressionFormat)
 	}
 
 	return nil
 }
 
+// Format returns the compression format archives are written with; an unset
+// format means gzip
+func (ap *ArchivalPolicy) Format() CompressionType {
+	if ap.CompressionFormat == "" {
+		return CompressionGzip
+	}
+	return ap.CompressionFormat
+}
+
 // Validate validates emergency cleanup config
 func (ecc *EmergencyCleanupConfig) Validate() error {
 	if ecc.DiskSpaceThreshold <= 0 || ecc.DiskSpaceThreshold >= 1 {
@@ -514,3 +545,18 @@ func (lre *LogRotationExecution) GetDetailsMap() (map[string]interface{}, error)
 	err := json.Unmarshal([]byte(lre.Details), &details)
 	return details, err
 }
+
+// Summary returns the execution as a RunSummary; rotated and deleted files
+// count as affected items
+func (lre *LogRotationExecution) Summary() RunSummary {
+	return RunSummary{
+		Time:          lre.ExecutionTime,
+		PolicyID:      lre.PolicyID,
+		Trigger:       string(lre.TriggerReason),
+		Status:        lre.Status,
+		ItemsAffected: int64(lre.FilesRotated + lre.FilesDeleted),
+		BytesFreed:    lre.BytesFreed,
+		Duration:      lre.Duration,
+		Error:         lre.ErrorMessage,
+	}
+}
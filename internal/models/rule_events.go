@@ -0,0 +1,163 @@
+package models
+
+import "context"
+
+// RuleChangeKind names what changed in a RuleChange
+type RuleChangeKind string
+
+const (
+	RuleChangeList      RuleChangeKind = "list"
+	RuleChangeEntry     RuleChangeKind = "entry"
+	RuleChangeTimeRule  RuleChangeKind = "time_rule"
+	RuleChangeQuotaRule RuleChangeKind = "quota_rule"
+)
+
+// RuleChange reports that a list, entry, time rule or quota rule was
+// created, updated or deleted
+type RuleChange struct {
+	Kind RuleChangeKind
+	ID   int // 0 for batch changes
+}
+
+// RuleChangeListener is called after each successful change. It runs on the
+// caller's goroutine, so it should not block.
+type RuleChangeListener func(ctx context.Context, change RuleChange)
+
+// NotifyRuleChanges wraps the list, entry, time rule and quota rule
+// repositories so that listener hears of every change made through them.
+// Repositories that are not set are left alone.
+func (rm *RepositoryManager) NotifyRuleChanges(listener RuleChangeListener) {
+	if rm.List != nil {
+		rm.List = &notifyingListRepository{ListRepository: rm.List, notify: listener}
+	}
+	if rm.ListEntry != nil {
+		rm.ListEntry = &notifyingListEntryRepository{ListEntryRepository: rm.ListEntry, notify: listener}
+	}
+	if rm.TimeRule != nil {
+		rm.TimeRule = &notifyingTimeRuleRepository{TimeRuleRepository: rm.TimeRule, notify: listener}
+	}
+	if rm.QuotaRule != nil {
+		rm.QuotaRule = &notifyingQuotaRuleRepository{QuotaRuleRepository: rm.QuotaRule, notify: listener}
+	}
+}
+
+// changed tells listener about a change if err is nil, and returns err
+func changed(ctx context.Context, listener RuleChangeListener, err error, kind RuleChangeKind, id int) error {
+	if err == nil {
+		listener(ctx, RuleChange{Kind: kind, ID: id})
+	}
+	return err
+}
+
+type notifyingListRepository struct {
+	ListRepository
+	notify RuleChangeListener
+}
+
+func (r *notifyingListRepository) Create(ctx context.Context, list *List) error {
+	err := r.ListRepository.Create(ctx, list)
+	return changed(ctx, r.notify, err, RuleChangeList, list.ID)
+}
+
+func (r *notifyingListRepository) Update(ctx context.Context, list *List) error {
+	err := r.ListRepository.Update(ctx, list)
+	return changed(ctx, r.notify, err, RuleChangeList, list.ID)
+}
+
+func (r *notifyingListRepository) Delete(ctx context.Context, id int) error {
+	err := r.ListRepository.Delete(ctx, id)
+	return changed(ctx, r.notify, err, RuleChangeList, id)
+}
+
+type notifyingListEntryRepository struct {
+	ListEntryRepository
+	notify RuleChangeListener
+}
+
+func (r *notifyingListEntryRepository) Create(ctx context.Context, entry *ListEntry) error {
+	err := r.ListEntryRepository.Create(ctx, entry)
+	return changed(ctx, r.notify, err, RuleChangeEntry, entry.ID)
+}
+
+func (r *notifyingListEntryRepository) CreateBatch(ctx context.Context, entries []ListEntry) (int, error) {
+	inserted, err := r.ListEntryRepository.CreateBatch(ctx, entries)
+	if inserted > 0 {
+		r.notify(ctx, RuleChange{Kind: RuleChangeEntry})
+	}
+	return inserted, err
+}
+
+func (r *notifyingListEntryRepository) Update(ctx context.Context, entry *ListEntry) error {
+	err := r.ListEntryRepository.Update(ctx, entry)
+	return changed(ctx, r.notify, err, RuleChangeEntry, entry.ID)
+}
+
+func (r *notifyingListEntryRepository) Delete(ctx context.Context, id int) error {
+	err := r.ListEntryRepository.Delete(ctx, id)
+	return changed(ctx, r.notify, err, RuleChangeEntry, id)
+}
+
+func (r *notifyingListEntryRepository) DeleteByListID(ctx context.Context, listID int) error {
+	err := r.ListEntryRepository.DeleteByListID(ctx, listID)
+	return changed(ctx, r.notify, err, RuleChangeEntry, 0)
+}
+
+type notifyingTimeRuleRepository struct {
+	TimeRuleRepository
+	notify RuleChangeListener
+}
+
+func (r *notifyingTimeRuleRepository) Create(ctx context.Context, rule *TimeRule) error {
+	err := r.TimeRuleRepository.Create(ctx, rule)
+	return changed(ctx, r.notify, err, RuleChangeTimeRule, rule.ID)
+}
+
+func (r *notifyingTimeRuleRepository) Update(ctx context.Context, rule *TimeRule) error {
+	err := r.TimeRuleRepository.Update(ctx, rule)
+	return changed(ctx, r.notify, err, RuleChangeTimeRule, rule.ID)
+}
+
+func (r *notifyingTimeRuleRepository) UpdateBatch(ctx context.Context, rules []TimeRule) error {
+	err := r.TimeRuleRepository.UpdateBatch(ctx, rules)
+	return changed(ctx, r.notify, err, RuleChangeTimeRule, 0)
+}
+
+func (r *notifyingTimeRuleRepository) Delete(ctx context.Context, id int) error {
+	err := r.TimeRuleRepository.Delete(ctx, id)
+	return changed(ctx, r.notify, err, RuleChangeTimeRule, id)
+}
+
+func (r *notifyingTimeRuleRepository) DeleteByListID(ctx context.Context, listID int) error {
+	err := r.TimeRuleRepository.DeleteByListID(ctx, listID)
+	return changed(ctx, r.notify, err, RuleChangeTimeRule, 0)
+}
+
+type notifyingQuotaRuleRepository struct {
+	QuotaRuleRepository
+	notify RuleChangeListener
+}
+
+func (r *notifyingQuotaRuleRepository) Create(ctx context.Context, rule *QuotaRule) error {
+	err := r.QuotaRuleRepository.Create(ctx, rule)
+	return changed(ctx, r.notify, err, RuleChangeQuotaRule, rule.ID)
+}
+
+func (r *notifyingQuotaRuleRepository) Update(ctx context.Context, rule *QuotaRule) error {
+	err := r.QuotaRuleRepository.Update(ctx, rule)
+	return changed(ctx, r.notify, err, RuleChangeQuotaRule, rule.ID)
+}
+
+func (r *notifyingQuotaRuleRepository) UpdateBatch(ctx context.Context, rules []QuotaRule) error {
+	err := r.QuotaRuleRepository.UpdateBatch(ctx, rules)
+	return changed(ctx, r.notify, err, RuleChangeQuotaRule, 0)
+}
+
+func (r *notifyingQuotaRuleRepository) Delete(ctx context.Context, id int) error {
+	err := r.QuotaRuleRepository.Delete(ctx, id)
+	return changed(ctx, r.notify, err, RuleChangeQuotaRule, id)
+}
+
+func (r *notifyingQuotaRuleRepository) DeleteByListID(ctx context.Context, listID int) error {
+	err := r.QuotaRuleRepository.DeleteByListID(ctx, listID)
+	return changed(ctx, r.notify, err, RuleChangeQuotaRule, 0)
+}
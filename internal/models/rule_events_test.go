@@ -0,0 +1,53 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// fakeListEntryRepository accepts creates and fails updates
+type fakeListEntryRepository struct {
+	ListEntryRepository
+}
+
+func (r *fakeListEntryRepository) Create(ctx context.Context, entry *ListEntry) error {
+	entry.ID = 7
+	return nil
+}
+
+func (r *fakeListEntryRepository) CreateBatch(ctx context.Context, entries []ListEntry) (int, error) {
+	return 0, nil
+}
+
+func (r *fakeListEntryRepository) Update(ctx context.Context, entry *ListEntry) error {
+	return errors.New("update failed")
+}
+
+func TestNotifyRuleChanges(t *testing.T) {
+	var changes []RuleChange
+	repos := &RepositoryManager{ListEntry: &fakeListEntryRepository{}}
+	repos.NotifyRuleChanges(func(ctx context.Context, change RuleChange) {
+		changes = append(changes, change)
+	})
+
+	if repos.List != nil || repos.TimeRule != nil {
+		t.Error("Expected missing repositories to stay unset")
+	}
+
+	ctx := context.Background()
+	if err := repos.ListEntry.Create(ctx, &ListEntry{}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if err := repos.ListEntry.Update(ctx, &ListEntry{ID: 7}); err == nil {
+		t.Fatal("Expected the update error to be returned")
+	}
+	if _, err := repos.ListEntry.CreateBatch(ctx, []ListEntry{{}}); err != nil {
+		t.Fatalf("CreateBatch failed: %v", err)
+	}
+
+	// Only the create changed anything
+	if len(changes) != 1 || changes[0] != (RuleChange{Kind: RuleChangeEntry, ID: 7}) {
+		t.Errorf("Expected one entry change, got %+v", changes)
+	}
+}
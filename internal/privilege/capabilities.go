@@ -0,0 +1,97 @@
+package privilege
+
+// Enforcement features that need privileges
+const (
+	// FeatureNetworkFiltering redirects DNS and blocks ports and encrypted
+	// DNS providers with iptables/ip6tables
+	FeatureNetworkFiltering = "network_filtering"
+	// FeatureDNSSinkhole answers DNS queries on port 53
+	FeatureDNSSinkhole = "dns_sinkhole"
+	// FeatureProcessControl inspects and terminates other users' processes
+	FeatureProcessControl = "process_control"
+)
+
+var allFeatures = []string{FeatureNetworkFiltering, FeatureDNSSinkhole, FeatureProcessControl}
+
+// CapabilityRequirement is one Linux capability an enforcement feature needs
+type CapabilityRequirement struct {
+	Feature    string `json:"feature"`
+	Capability string `json:"capability"`
+	Reason     string `json:"reason"`
+	// ViaHelper means a helper program such as iptables uses the capability.
+	// Helpers only inherit it from the ambient set (e.g. systemd's
+	// AmbientCapabilities=), not from file capabilities on this binary.
+	ViaHelper bool `json:"via_helper"`
+	Held      bool `json:"held"`
+
+	bit uint
+}
+
+// CapabilityReport lists the capabilities each enforcement feature needs and
+// whether the process holds them
+type CapabilityReport struct {
+	// Elevated is true when running as root (or Administrator), which holds everything
+	Elevated bool `json:"elevated"`
+	// Supported is false on platforms without capabilities, where only
+	// elevation counts
+	Supported    bool                    `json:"supported"`
+	Requirements []CapabilityRequirement `json:"requirements"`
+}
+
+// capabilityRequirements documents what each feature needs on Linux
+var capabilityRequirements = []CapabilityRequirement{
+	{Feature: FeatureNetworkFiltering, Capability: "CAP_NET_ADMIN", bit: 12, ViaHelper: true,
+		Reason: "add and remove iptables/ip6tables rules"},
+	{Feature: FeatureNetworkFiltering, Capability: "CAP_NET_RAW", bit: 13, ViaHelper: true,
+		Reason: "iptables opens raw sockets to change rules"},
+	{Feature: FeatureDNSSinkhole, Capability: "CAP_NET_BIND_SERVICE", bit: 10,
+		Reason: "listen for DNS queries on port 53"},
+	{Feature: FeatureProcessControl, Capability: "CAP_KILL", bit: 5,
+		Reason: "terminate blocked applications run by other users"},
+	{Feature: FeatureProcessControl, Capability: "CAP_SYS_PTRACE", bit: 19,
+		Reason: "read the executable path of other users' processes"},
+}
+
+// Missing returns the requirements of the given features that are not held,
+// or of every feature when none are given. Nothing is missing when elevated.
+func (r *CapabilityReport) Missing(features ...string) []CapabilityRequirement {
+	if r.Elevated {
+		return nil
+	}
+
+	var missing []CapabilityRequirement
+	if !r.Supported {
+		// Without capabilities every feature needs elevation
+		if len(features) == 0 {
+			features = allFeatures
+		}
+		for _, feature := range features {
+			missing = append(missing, CapabilityRequirement{Feature: feature, Reason: "requires administrator privileges"})
+		}
+		return missing
+	}
+
+	for _, req := range r.Requirements {
+		if !req.Held && wantsFeature(features, req.Feature) {
+			missing = append(missing, req)
+		}
+	}
+	return missing
+}
+
+// Satisfied reports whether the given features can run without elevation
+func (r *CapabilityReport) Satisfied(features ...string) bool {
+	return len(r.Missing(features...)) == 0
+}
+
+func wantsFeature(features []string, feature string) bool {
+	if len(features) == 0 {
+		return true
+	}
+	for _, f := range features {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
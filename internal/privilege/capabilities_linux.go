@@ -0,0 +1,67 @@
+package privilege
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// CheckCapabilities reports which capabilities the enforcement features need
+// and whether this process holds them, from /proc/self/status
+func (m *linuxManager) CheckCapabilities() *CapabilityReport {
+	report := &CapabilityReport{Elevated: m.IsElevated(), Supported: true}
+
+	effective, ambient, err := readCapabilitySets("/proc/self/status")
+	if err != nil {
+		// Unknown capabilities count as not held
+		effective, ambient = 0, 0
+	}
+
+	for _, req := range capabilityRequirements {
+		held := effective
+		if req.ViaHelper {
+			held = ambient
+		}
+		req.Held = report.Elevated || held&(1<<req.bit) != 0
+		report.Requirements = append(report.Requirements, req)
+	}
+	return report
+}
+
+// readCapabilitySets reads the effective and ambient capability sets from a
+// /proc/<pid>/status file
+func readCapabilitySets(path string) (effective, ambient uint64, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer file.Close()
+
+	found := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		name, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok || (name != "CapEff" && name != "CapAmb") {
+			continue
+		}
+		set, err := strconv.ParseUint(strings.TrimSpace(value), 16, 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid %s in %s: %w", name, path, err)
+		}
+		if name == "CapEff" {
+			effective = set
+		} else {
+			ambient = set
+		}
+		found++
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, 0, err
+	}
+	if found == 0 {
+		return 0, 0, fmt.Errorf("no capability sets in %s", path)
+	}
+	return effective, ambient, nil
+}
@@ -0,0 +1,85 @@
+package privilege
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadCapabilitySets(t *testing.T) {
+	status := `Name:	parental-control
+Uid:	1000	1000	1000	1000
+CapInh:	0000000000000000
+CapPrm:	0000000000003400
+CapEff:	0000000000000400
+CapBnd:	000001ffffffffff
+CapAmb:	0000000000003000
+`
+	path := filepath.Join(t.TempDir(), "status")
+	if err := os.WriteFile(path, []byte(status), 0644); err != nil {
+		t.Fatalf("Failed to write status: %v", err)
+	}
+
+	effective, ambient, err := readCapabilitySets(path)
+	if err != nil {
+		t.Fatalf("readCapabilitySets failed: %v", err)
+	}
+	if effective != 0x400 {
+		t.Errorf("Expected effective 0x400 (CAP_NET_BIND_SERVICE), got %#x", effective)
+	}
+	if ambient != 0x3000 {
+		t.Errorf("Expected ambient 0x3000 (CAP_NET_ADMIN, CAP_NET_RAW), got %#x", ambient)
+	}
+
+	if _, _, err := readCapabilitySets(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("Expected an error for a missing status file")
+	}
+}
+
+func TestCapabilityReport_Missing(t *testing.T) {
+	report := &CapabilityReport{Supported: true}
+	for _, req := range capabilityRequirements {
+		// Network filtering and the sinkhole are granted, process control is not
+		req.Held = req.Feature != FeatureProcessControl
+		report.Requirements = append(report.Requirements, req)
+	}
+
+	if !report.Satisfied(FeatureNetworkFiltering, FeatureDNSSinkhole) {
+		t.Error("Expected network filtering and DNS sinkhole to be satisfied")
+	}
+
+	missing := report.Missing(FeatureProcessControl, FeatureNetworkFiltering)
+	if len(missing) != 2 {
+		t.Fatalf("Expected 2 missing capabilities, got %v", missing)
+	}
+	for _, req := range missing {
+		if req.Feature != FeatureProcessControl {
+			t.Errorf("Unexpected missing requirement %+v", req)
+		}
+	}
+
+	report.Elevated = true
+	if !report.Satisfied() {
+		t.Error("Expected an elevated process to satisfy every feature")
+	}
+
+	unsupported := &CapabilityReport{}
+	if unsupported.Satisfied() || unsupported.Satisfied(FeatureDNSSinkhole) {
+		t.Error("Expected features to need elevation without capability support")
+	}
+}
+
+func TestLinuxManager_CheckCapabilities(t *testing.T) {
+	m := &linuxManager{config: DefaultConfig()}
+	report := m.CheckCapabilities()
+
+	if !report.Supported {
+		t.Error("Expected capabilities to be supported on Linux")
+	}
+	if len(report.Requirements) != len(capabilityRequirements) {
+		t.Errorf("Expected %d requirements, got %d", len(capabilityRequirements), len(report.Requirements))
+	}
+	if report.Elevated && !report.Satisfied() {
+		t.Error("Expected root to hold every capability")
+	}
+}
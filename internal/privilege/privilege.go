@@ -21,6 +21,7 @@ const (
 	ElevationMethodUAC
 	ElevationMethodSudo
 	ElevationMethodPkexec
+	ElevationMethodDoas
 )
 
 type Manager interface {
@@ -29,6 +30,7 @@ type Manager interface {
 	RequestElevation(ctx context.Context, reason string) error
 	RestartElevated(ctx context.Context, args []string) error
 	GetElevationMethod() ElevationMethod
+	CheckCapabilities() *CapabilityReport
 }
 
 type Config struct {
@@ -27,30 +27,34 @@ func (m *linuxManager) CanElevate() bool {
 	if m.IsElevated() {
 		return true
 	}
-	
+
 	methods := m.getAvailableMethods()
 	return len(methods) > 0
 }
 
 func (m *linuxManager) getAvailableMethods() []string {
 	var methods []string
-	
+
 	if _, err := exec.LookPath("pkexec"); err == nil {
 		methods = append(methods, "pkexec")
 	}
-	
+
 	if _, err := exec.LookPath("sudo"); err == nil {
 		methods = append(methods, "sudo")
 	}
-	
+
+	if _, err := exec.LookPath("doas"); err == nil {
+		methods = append(methods, "doas")
+	}
+
 	if _, err := exec.LookPath("gksudo"); err == nil {
 		methods = append(methods, "gksudo")
 	}
-	
+
 	if _, err := exec.LookPath("kdesudo"); err == nil {
 		methods = append(methods, "kdesudo")
 	}
-	
+
 	return methods
 }
 
@@ -60,14 +64,18 @@ func (m *linuxManager) GetElevationMethod() ElevationMethod {
 		return ElevationMethodSudo
 	case ElevationMethodPkexec:
 		return ElevationMethodPkexec
+	case ElevationMethodDoas:
+		return ElevationMethodDoas
 	default:
 		methods := m.getAvailableMethods()
 		if len(methods) > 0 {
-			switch methods[0] {
+			switch m.selectElevationMethod(methods) {
 			case "pkexec":
 				return ElevationMethodPkexec
 			case "sudo":
 				return ElevationMethodSudo
+			case "doas":
+				return ElevationMethodDoas
 			}
 		}
 		return ElevationMethodSudo
@@ -78,11 +86,11 @@ func (m *linuxManager) RequestElevation(ctx context.Context, reason string) erro
 	if m.IsElevated() {
 		return ErrAlreadyElevated
 	}
-	
+
 	if !m.CanElevate() {
 		return ErrNotSupported
 	}
-	
+
 	return m.RestartElevated(ctx, os.Args)
 }
 
@@ -90,28 +98,28 @@ func (m *linuxManager) RestartElevated(ctx context.Context, args []string) error
 	if m.IsElevated() {
 		return ErrAlreadyElevated
 	}
-	
+
 	executable, err := os.Executable()
 	if err != nil {
 		return fmt.Errorf("failed to get executable path: %w", err)
 	}
-	
+
 	resolvedExe, err := filepath.EvalSymlinks(executable)
 	if err != nil {
 		resolvedExe = executable
 	}
-	
+
 	methods := m.getAvailableMethods()
 	if len(methods) == 0 {
 		return ErrNotSupported
 	}
-	
+
 	method := m.selectElevationMethod(methods)
-	
+
 	timeout := time.Duration(m.config.TimeoutSeconds) * time.Second
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
-	
+
 	var cmd *exec.Cmd
 	switch method {
 	case "pkexec":
@@ -125,6 +133,9 @@ func (m *linuxManager) RestartElevated(ctx context.Context, args []string) error
 			allArgs := append([]string{resolvedExe}, args[1:]...)
 			cmd = exec.CommandContext(ctx, "sudo", allArgs...)
 		}
+	case "doas":
+		allArgs := append([]string{resolvedExe}, args[1:]...)
+		cmd = exec.CommandContext(ctx, "doas", allArgs...)
 	case "gksudo":
 		allArgs := append([]string{resolvedExe}, args[1:]...)
 		cmd = exec.CommandContext(ctx, "gksudo", allArgs...)
@@ -134,15 +145,15 @@ func (m *linuxManager) RestartElevated(ctx context.Context, args []string) error
 	default:
 		return ErrNotSupported
 	}
-	
+
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	cmd.Stdin = os.Stdin
-	
+
 	if method == "sudo" && isDesktopEnvironment() {
 		cmd.Env = append(os.Environ(), "SUDO_ASKPASS="+getSudoAskpassPath())
 	}
-	
+
 	err = cmd.Start()
 	if err != nil {
 		if m.config.AllowFallback && len(methods) > 1 {
@@ -150,13 +161,13 @@ func (m *linuxManager) RestartElevated(ctx context.Context, args []string) error
 		}
 		return fmt.Errorf("failed to start elevated process: %w", err)
 	}
-	
+
 	// Wait for the command to complete or timeout
 	done := make(chan error, 1)
 	go func() {
 		done <- cmd.Wait()
 	}()
-	
+
 	select {
 	case <-ctx.Done():
 		cmd.Process.Kill()
@@ -183,7 +194,7 @@ func (m *linuxManager) selectElevationMethod(methods []string) string {
 			}
 		}
 	}
-	
+
 	switch m.config.Method {
 	case ElevationMethodPkexec:
 		for _, method := range methods {
@@ -197,10 +208,16 @@ func (m *linuxManager) selectElevationMethod(methods []string) string {
 				return method
 			}
 		}
+	case ElevationMethodDoas:
+		for _, method := range methods {
+			if method == "doas" {
+				return method
+			}
+		}
 	}
-	
+
 	if isDesktopEnvironment() {
-		for _, method := range []string{"pkexec", "gksudo", "kdesudo", "sudo"} {
+		for _, method := range []string{"pkexec", "gksudo", "kdesudo", "sudo", "doas"} {
 			for _, available := range methods {
 				if method == available {
 					return method
@@ -208,7 +225,7 @@ func (m *linuxManager) selectElevationMethod(methods []string) string {
 			}
 		}
 	}
-	
+
 	return methods[0]
 }
 
@@ -217,22 +234,20 @@ func (m *linuxManager) tryFallbackMethod(ctx context.Context, args []string, met
 		if method == failedMethod {
 			continue
 		}
-		
-		originalMethod := m.config.Method
-		if method == "pkexec" {
-			m.config.Method = ElevationMethodPkexec
-		} else if method == "sudo" {
-			m.config.Method = ElevationMethodSudo
-		}
-		
+
+		// Pin this attempt to one tool, and keep it from falling back in turn
+		original := *m.config
+		m.config.PreferredElevator = method
+		m.config.AllowFallback = false
+
 		err := m.RestartElevated(ctx, args)
-		m.config.Method = originalMethod
-		
+		*m.config = original
+
 		if err == nil {
 			return nil
 		}
 	}
-	
+
 	return ErrElevationFailed
 }
 
@@ -248,13 +263,13 @@ func getSudoAskpassPath() string {
 		"/usr/bin/ksshaskpass",
 		"/usr/bin/x11-ssh-askpass",
 	}
-	
+
 	for _, path := range askpassPaths {
 		if _, err := os.Stat(path); err == nil {
 			return path
 		}
 	}
-	
+
 	return "/usr/bin/ssh-askpass"
 }
 
@@ -263,12 +278,12 @@ func createPolkitPolicy() error {
 	if err != nil {
 		return err
 	}
-	
+
 	policyDir := "/usr/share/polkit-1/actions"
 	if _, err := os.Stat(policyDir); os.IsNotExist(err) {
 		return nil
 	}
-	
+
 	policyContent := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
 <!DOCTYPE policyconfig PUBLIC "-//freedesktop//DTD PolicyKit Policy Configuration 1.0//EN"
         "http://www.freedesktop.org/standards/PolicyKit/1/policyconfig.dtd">
@@ -285,9 +300,9 @@ func createPolkitPolicy() error {
         <annotate key="org.freedesktop.policykit.exec.allow_gui">true</annotate>
     </action>
 </policyconfig>`, executable)
-	
+
 	policyPath := filepath.Join(policyDir, "com.parental-control.policy")
-	
+
 	return os.WriteFile(policyPath, []byte(policyContent), 0644)
 }
 
@@ -296,12 +311,12 @@ func checkSudoVulnerabilities() error {
 	if err != nil {
 		return nil
 	}
-	
+
 	version := string(out)
 	if strings.Contains(version, "1.8.") || strings.Contains(version, "1.9.") {
 		return fmt.Errorf("detected potentially vulnerable sudo version - ensure patches for CVE-2021-3156 are applied")
 	}
-	
+
 	return nil
 }
 
@@ -309,19 +324,19 @@ func checkPkexecVulnerabilities() error {
 	if _, err := exec.LookPath("pkexec"); err != nil {
 		return nil
 	}
-	
+
 	stat, err := os.Stat("/usr/bin/pkexec")
 	if err != nil {
 		return nil
 	}
-	
+
 	if stat.Mode()&os.ModeSetuid == 0 {
 		return nil
 	}
-	
+
 	if stat.Sys().(*syscall.Stat_t).Uid != 0 {
 		return fmt.Errorf("pkexec has incorrect ownership - potential security risk")
 	}
-	
+
 	return nil
-}
\ No newline at end of file
+}
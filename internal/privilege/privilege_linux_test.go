@@ -0,0 +1,90 @@
+package privilege
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// withElevators puts fake elevation tools, and nothing else, on PATH
+func withElevators(t *testing.T, tools ...string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	for _, tool := range tools {
+		if err := os.WriteFile(filepath.Join(dir, tool), []byte("#!/bin/sh\nexit 1\n"), 0755); err != nil {
+			t.Fatalf("Failed to create fake %s: %v", tool, err)
+		}
+	}
+	t.Setenv("PATH", dir)
+	t.Setenv("DISPLAY", "")
+	t.Setenv("WAYLAND_DISPLAY", "")
+}
+
+func TestLinuxManager_DoasOnly(t *testing.T) {
+	withElevators(t, "doas")
+
+	m := &linuxManager{config: DefaultConfig()}
+
+	if got := m.getAvailableMethods(); !reflect.DeepEqual(got, []string{"doas"}) {
+		t.Errorf("Expected only doas to be available, got %v", got)
+	}
+	if got := m.GetElevationMethod(); got != ElevationMethodDoas {
+		t.Errorf("Expected auto selection to pick doas, got %v", got)
+	}
+	if !m.CanElevate() {
+		t.Error("Expected CanElevate with doas available")
+	}
+}
+
+func TestLinuxManager_SelectElevationMethod(t *testing.T) {
+	methods := []string{"pkexec", "sudo", "doas"}
+
+	tests := []struct {
+		name      string
+		method    ElevationMethod
+		preferred string
+		want      string
+	}{
+		{name: "auto uses first available", method: ElevationMethodAuto, want: "pkexec"},
+		{name: "doas method", method: ElevationMethodDoas, want: "doas"},
+		{name: "preferred elevator wins", method: ElevationMethodSudo, preferred: "doas", want: "doas"},
+		{name: "unavailable preferred elevator", method: ElevationMethodDoas, preferred: "kdesudo", want: "doas"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withElevators(t)
+
+			config := DefaultConfig()
+			config.Method = tt.method
+			config.PreferredElevator = tt.preferred
+			m := &linuxManager{config: config}
+
+			if got := m.selectElevationMethod(methods); got != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestLinuxManager_NoElevator(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("running as root; elevation is never attempted")
+	}
+	withElevators(t)
+
+	m := &linuxManager{config: DefaultConfig()}
+
+	if m.CanElevate() {
+		t.Error("Expected CanElevate to be false without any elevator")
+	}
+	if err := m.RequestElevation(context.Background(), "test"); err != ErrNotSupported {
+		t.Errorf("Expected ErrNotSupported, got %v", err)
+	}
+	if err := m.RestartElevated(context.Background(), []string{"parental-control"}); err != ErrNotSupported {
+		t.Errorf("Expected ErrNotSupported, got %v", err)
+	}
+}
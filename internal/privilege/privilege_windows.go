@@ -122,6 +122,12 @@ func (m *windowsManager) CanElevate() bool {
 	return true
 }
 
+// CheckCapabilities reports that Windows has no capabilities to hold; the
+// enforcement features need Administrator
+func (m *windowsManager) CheckCapabilities() *CapabilityReport {
+	return &CapabilityReport{Elevated: m.IsElevated()}
+}
+
 func (m *windowsManager) GetElevationMethod() ElevationMethod {
 	return ElevationMethodUAC
 }
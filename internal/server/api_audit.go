@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"sync/atomic"
 	"time"
 
 	"parental-control/internal/logging"
@@ -12,10 +13,22 @@ import (
 	"parental-control/internal/service"
 )
 
+// Audit tail stream limits
+const (
+	defaultTailBackfill  = 50
+	maxTailBackfill      = 500
+	maxTailStreams       = 10
+	tailSubscriberBuffer = 100
+
+	// exportMaxDuration bounds how long an audit export may stream
+	exportMaxDuration = 30 * time.Minute
+)
+
 // AuditLogHandler handles audit log API endpoints
 type AuditLogHandler struct {
 	auditService *service.AuditService
 	logger       logging.Logger
+	activeTails  int32
 }
 
 // NewAuditLogHandler creates a new audit log handler
@@ -32,6 +45,8 @@ func (h *AuditLogHandler) RegisterRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("/api/v1/audit/", h.handleAuditLogDetail)
 	mux.HandleFunc("/api/v1/audit/stats", h.handleAuditStats)
 	mux.HandleFunc("/api/v1/audit/cleanup", h.handleAuditCleanup)
+	mux.HandleFunc("/api/v1/audit/export", h.handleAuditExport)
+	mux.HandleFunc("/api/v1/audit/tail", h.handleAuditTail)
 }
 
 // handleAuditLogs handles GET /api/v1/audit - get audit logs with filtering
@@ -56,15 +71,8 @@ func (h *AuditLogHandler) handleAuditLogs(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	// Prepare response
-	response := map[string]interface{}{
-		"logs":        logs,
-		"total_count": totalCount,
-		"page_size":   filters.Limit,
-		"page":        filters.Offset / max(1, filters.Limit),
-	}
-
-	h.writeJSONResponse(w, http.StatusOK, response)
+	page := PageParams{Limit: filters.Limit, Offset: filters.Offset}
+	h.writeJSONResponse(w, http.StatusOK, NewPagedResponse(logs, totalCount, page))
 }
 
 // handleAuditLogDetail handles GET /api/v1/audit/{id} - get specific audit log
@@ -127,11 +135,138 @@ func (h *AuditLogHandler) handleAuditCleanup(w http.ResponseWriter, r *http.Requ
 	h.writeJSONResponse(w, http.StatusOK, response)
 }
 
+// handleAuditExport handles GET /api/v1/audit/export - stream every audit log
+// matching the query filters as CSV or NDJSON, chosen by ?format= or the
+// Accept header. Paging parameters are ignored.
+func (h *AuditLogHandler) handleAuditExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	filters, err := h.parseAuditFilters(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("Invalid filters: %v", err))
+		return
+	}
+	filters.Limit, filters.Offset = 0, 0
+
+	format, err := service.ParseAuditExportFormat(r.URL.Query().Get("format"), r.Header.Get("Accept"))
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Exports outlast the server's write timeout
+	rc := http.NewResponseController(w)
+	rc.SetWriteDeadline(time.Now().Add(exportMaxDuration))
+
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition",
+		fmt.Sprintf(`attachment; filename="%s"`, service.AuditExportFilename(filters, format)))
+	w.Header().Set("Cache-Control", "no-store")
+
+	writer, err := service.NewAuditLogWriter(w, format)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// The status is sent with the first bytes, so a failure part way
+	// through can only be logged; the client sees a truncated file
+	if err := h.auditService.ExportAuditLogs(r.Context(), filters, writer); err != nil {
+		h.logger.Error("Audit log export failed",
+			logging.Err(err),
+			logging.Int("exported", writer.Written()))
+		return
+	}
+
+	h.logger.Info("Exported audit logs",
+		logging.String("format", string(format)),
+		logging.Int("exported", writer.Written()))
+}
+
+// handleAuditTail handles GET /api/v1/audit/tail - stream new audit logs via SSE
+func (h *AuditLogHandler) handleAuditTail(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	filters, err := h.parseAuditFilters(r)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("Invalid filters: %v", err))
+		return
+	}
+
+	backfill := defaultTailBackfill
+	if backfillStr := r.URL.Query().Get("backfill"); backfillStr != "" {
+		backfill, err = strconv.Atoi(backfillStr)
+		if err != nil || backfill < 0 || backfill > maxTailBackfill {
+			h.writeErrorResponse(w, http.StatusBadRequest,
+				fmt.Sprintf("Invalid backfill: must be between 0 and %d", maxTailBackfill))
+			return
+		}
+	}
+
+	if atomic.AddInt32(&h.activeTails, 1) > maxTailStreams {
+		atomic.AddInt32(&h.activeTails, -1)
+		h.writeErrorResponse(w, http.StatusServiceUnavailable, "Too many active audit streams")
+		return
+	}
+	defer atomic.AddInt32(&h.activeTails, -1)
+
+	// Subscribe before the backfill query so no entry falls between the two
+	sub := h.auditService.Subscribe(filters, tailSubscriberBuffer)
+	defer h.auditService.Unsubscribe(sub)
+
+	var backlog []models.AuditLog
+	if backfill > 0 {
+		backfillFilters := filters
+		backfillFilters.Limit = backfill
+		backfillFilters.Offset = 0
+		backlog, _, err = h.auditService.GetAuditLogs(r.Context(), backfillFilters)
+		if err != nil {
+			h.logger.Error("Failed to load audit tail backfill", logging.Err(err))
+			h.writeErrorResponse(w, http.StatusInternalServerError, "Failed to retrieve audit logs")
+			return
+		}
+	}
+
+	stream := startSSE(w)
+
+	// Backfill is queried newest first; replay it in chronological order
+	lastID := 0
+	for i := len(backlog) - 1; i >= 0; i-- {
+		if err := stream.event("audit", &backlog[i]); err != nil {
+			return
+		}
+		if backlog[i].ID > lastID {
+			lastID = backlog[i].ID
+		}
+	}
+	if err := stream.event("ready", map[string]interface{}{"backfill_count": len(backlog)}); err != nil {
+		return
+	}
+
+	streamSubscription(r, stream, sub, "audit tail", func(log *models.AuditLog) error {
+		// Skip entries already delivered by the backfill
+		if log.ID <= lastID {
+			return nil
+		}
+		return stream.event("audit", log)
+	})
+}
+
 // parseAuditFilters parses query parameters into audit log filters
 func (h *AuditLogHandler) parseAuditFilters(r *http.Request) (service.AuditLogFilters, error) {
+	page, err := ParsePageParams(r, 25)
+	if err != nil {
+		return service.AuditLogFilters{}, err
+	}
 	filters := service.AuditLogFilters{
-		Limit:  25, // Default page size
-		Offset: 0,  // Default offset
+		Limit:  page.Limit,
+		Offset: page.Offset,
 	}
 
 	query := r.URL.Query()
@@ -139,7 +274,7 @@ func (h *AuditLogHandler) parseAuditFilters(r *http.Request) (service.AuditLogFi
 	// Parse action filter
 	if actionStr := query.Get("action"); actionStr != "" {
 		action := models.ActionType(actionStr)
-		if action != models.ActionTypeAllow && action != models.ActionTypeBlock {
+		if action != models.ActionTypeAllow && action != models.ActionTypeBlock && action != models.ActionTypeAdminReset {
 			return filters, fmt.Errorf("invalid action: %s", actionStr)
 		}
 		filters.Action = &action
@@ -148,7 +283,7 @@ func (h *AuditLogHandler) parseAuditFilters(r *http.Request) (service.AuditLogFi
 	// Parse target type filter
 	if targetTypeStr := query.Get("target_type"); targetTypeStr != "" {
 		targetType := models.TargetType(targetTypeStr)
-		if targetType != models.TargetTypeExecutable && targetType != models.TargetTypeURL {
+		if targetType != models.TargetTypeExecutable && targetType != models.TargetTypeURL && targetType != models.TargetTypeAccount {
 			return filters, fmt.Errorf("invalid target_type: %s", targetTypeStr)
 		}
 		filters.TargetType = &targetType
@@ -159,6 +294,11 @@ func (h *AuditLogHandler) parseAuditFilters(r *http.Request) (service.AuditLogFi
 		filters.EventType = eventType
 	}
 
+	// Parse process name filter
+	if processName := query.Get("process_name"); processName != "" {
+		filters.ProcessName = processName
+	}
+
 	// Parse time range filters
 	if startTimeStr := query.Get("start_time"); startTimeStr != "" {
 		startTime, err := time.Parse(time.RFC3339, startTimeStr)
@@ -181,23 +321,6 @@ func (h *AuditLogHandler) parseAuditFilters(r *http.Request) (service.AuditLogFi
 		filters.Search = search
 	}
 
-	// Parse pagination parameters
-	if limitStr := query.Get("limit"); limitStr != "" {
-		limit, err := strconv.Atoi(limitStr)
-		if err != nil || limit <= 0 || limit > 1000 {
-			return filters, fmt.Errorf("invalid limit: must be between 1 and 1000")
-		}
-		filters.Limit = limit
-	}
-
-	if offsetStr := query.Get("offset"); offsetStr != "" {
-		offset, err := strconv.Atoi(offsetStr)
-		if err != nil || offset < 0 {
-			return filters, fmt.Errorf("invalid offset: must be non-negative")
-		}
-		filters.Offset = offset
-	}
-
 	return filters, nil
 }
 
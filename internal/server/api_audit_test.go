@@ -0,0 +1,267 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"parental-control/internal/database"
+	"parental-control/internal/logging"
+	"parental-control/internal/models"
+	"parental-control/internal/service"
+)
+
+// newAuditTailTestHandler returns an audit handler over a fresh SQLite
+// database. The audit service is not started, so entries are written and
+// published as they are logged.
+func newAuditTailTestHandler(t *testing.T) (*AuditLogHandler, *service.AuditService) {
+	t.Helper()
+
+	config := database.DefaultConfig()
+	config.Path = filepath.Join(t.TempDir(), "audit.db")
+	db, err := database.New(config)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.InitializeSchema(); err != nil {
+		t.Fatalf("Failed to initialize schema: %v", err)
+	}
+
+	repos := &models.RepositoryManager{AuditLog: database.NewAuditLogRepository(db.Connection())}
+	auditService := service.NewAuditService(repos, logging.NewDefault(), service.DefaultAuditConfig())
+	return NewAuditLogHandler(auditService, logging.NewDefault()), auditService
+}
+
+// logAuditAction logs an enforcement decision for target
+func logAuditAction(t *testing.T, auditService *service.AuditService, action models.ActionType, target string) {
+	t.Helper()
+	if err := auditService.LogEnforcementAction(context.Background(), action, models.TargetTypeURL, target, "", nil, nil); err != nil {
+		t.Fatalf("Failed to log audit entry: %v", err)
+	}
+}
+
+// sseEvent is one event read from a stream
+type sseEvent struct {
+	name string
+	data string
+}
+
+// openAuditTail opens a tail stream and returns a function reading its next event
+func openAuditTail(t *testing.T, handler *AuditLogHandler, query string) func() sseEvent {
+	t.Helper()
+
+	ts := httptest.NewServer(http.HandlerFunc(handler.handleAuditTail))
+	t.Cleanup(ts.Close)
+	resp, err := http.Get(ts.URL + "?" + query)
+	if err != nil {
+		t.Fatalf("Failed to open stream: %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", resp.StatusCode)
+	}
+
+	lines := bufio.NewScanner(resp.Body)
+	return func() sseEvent {
+		t.Helper()
+		var event sseEvent
+		for lines.Scan() {
+			line := lines.Text()
+			switch {
+			case line == "" && event.name != "":
+				return event
+			case strings.HasPrefix(line, "event: "):
+				event.name = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				event.data = strings.TrimPrefix(line, "data: ")
+			}
+		}
+		t.Fatalf("Stream ended early: %v", lines.Err())
+		return event
+	}
+}
+
+// auditEntry decodes the entry of an audit event
+func auditEntry(t *testing.T, event sseEvent) models.AuditLog {
+	t.Helper()
+	if event.name != "audit" {
+		t.Fatalf("Expected an audit event, got %q: %s", event.name, event.data)
+	}
+	var entry models.AuditLog
+	if err := json.Unmarshal([]byte(event.data), &entry); err != nil {
+		t.Fatalf("Failed to decode audit entry: %v", err)
+	}
+	return entry
+}
+
+func TestHandleAuditTail_BackfillAndFilters(t *testing.T) {
+	handler, auditService := newAuditTailTestHandler(t)
+	logAuditAction(t, auditService, models.ActionTypeBlock, "games.example.com")
+	logAuditAction(t, auditService, models.ActionTypeAllow, "video.example.com")
+	logAuditAction(t, auditService, models.ActionTypeBlock, "Video.example.com")
+	logAuditAction(t, auditService, models.ActionTypeBlock, "news.example.com")
+
+	// The search is case-insensitive in the backfill query and on live entries alike
+	next := openAuditTail(t, handler, "action=block&search=VIDEO&backfill=10")
+
+	if entry := auditEntry(t, next()); entry.TargetValue != "Video.example.com" || entry.Action != models.ActionTypeBlock {
+		t.Errorf("Expected only the blocked video entry in the backfill, got %+v", entry)
+	}
+	if event := next(); event.name != "ready" || !strings.Contains(event.data, `"backfill_count":1`) {
+		t.Fatalf("Expected the ready event after one backfilled entry, got %q: %s", event.name, event.data)
+	}
+
+	logAuditAction(t, auditService, models.ActionTypeAllow, "VIDEO.example.com")
+	logAuditAction(t, auditService, models.ActionTypeBlock, "games.example.com")
+	logAuditAction(t, auditService, models.ActionTypeBlock, "VIDEO.example.com")
+	if entry := auditEntry(t, next()); entry.TargetValue != "VIDEO.example.com" || entry.Action != models.ActionTypeBlock {
+		t.Errorf("Expected only the live blocked video entry, got %+v", entry)
+	}
+}
+
+func TestHandleAuditTail_BackfillOrder(t *testing.T) {
+	handler, auditService := newAuditTailTestHandler(t)
+	for i := 1; i <= 5; i++ {
+		logAuditAction(t, auditService, models.ActionTypeBlock, fmt.Sprintf("site%d.example.com", i))
+	}
+
+	// The newest entries are backfilled, oldest first
+	next := openAuditTail(t, handler, "backfill=3")
+	for i := 3; i <= 5; i++ {
+		if entry := auditEntry(t, next()); entry.TargetValue != fmt.Sprintf("site%d.example.com", i) {
+			t.Errorf("Expected site%d in the backfill, got %s", i, entry.TargetValue)
+		}
+	}
+	if event := next(); event.name != "ready" {
+		t.Errorf("Expected the ready event, got %q", event.name)
+	}
+}
+
+func TestHandleAuditTail_BackfillLiveOverlap(t *testing.T) {
+	handler, auditService := newAuditTailTestHandler(t)
+
+	// Entries logged while the stream opens may be both backfilled and
+	// published live; each must still arrive exactly once and in order
+	const written = 100
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 1; i <= written; i++ {
+			if err := auditService.LogEnforcementAction(context.Background(), models.ActionTypeBlock,
+				models.TargetTypeURL, fmt.Sprintf("site%d.example.com", i), "", nil, nil); err != nil {
+				t.Errorf("Failed to log audit entry: %v", err)
+				return
+			}
+		}
+	}()
+
+	next := openAuditTail(t, handler, fmt.Sprintf("backfill=%d", maxTailBackfill))
+	wg.Wait()
+	logAuditAction(t, auditService, models.ActionTypeBlock, "last.example.com")
+
+	lastID := 0
+	for {
+		event := next()
+		if event.name == "ready" {
+			continue
+		}
+		entry := auditEntry(t, event)
+		if entry.ID != lastID+1 {
+			t.Fatalf("Expected entry %d after %d, got %d", lastID+1, lastID, entry.ID)
+		}
+		lastID = entry.ID
+		if entry.TargetValue == "last.example.com" {
+			break
+		}
+	}
+	if lastID != written+1 {
+		t.Errorf("Expected %d entries, got %d", written+1, lastID)
+	}
+}
+
+// stalledWriter blocks the write at stallAt until released, like a client
+// that stopped reading
+type stalledWriter struct {
+	*httptest.ResponseRecorder
+	writes  int
+	stallAt int
+	stalled chan struct{}
+	release chan struct{}
+}
+
+func (w *stalledWriter) Write(p []byte) (int, error) {
+	w.writes++
+	if w.writes == w.stallAt {
+		close(w.stalled)
+		<-w.release
+	}
+	return w.ResponseRecorder.Write(p)
+}
+
+func TestHandleAuditTail_DropsSlowConsumer(t *testing.T) {
+	handler, auditService := newAuditTailTestHandler(t)
+
+	// The ready event is the first write; the first live entry stalls
+	w := &stalledWriter{
+		ResponseRecorder: httptest.NewRecorder(),
+		stallAt:          2,
+		stalled:          make(chan struct{}),
+		release:          make(chan struct{}),
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		handler.handleAuditTail(w, httptest.NewRequest(http.MethodGet, "/api/v1/audit/tail?backfill=0", nil))
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for auditService.SubscriberCount() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	logAuditAction(t, auditService, models.ActionTypeBlock, "first.example.com")
+	select {
+	case <-w.stalled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the stream to write the first entry")
+	}
+
+	// The stream cannot take these while its write is stuck
+	for i := 0; i <= tailSubscriberBuffer; i++ {
+		logAuditAction(t, auditService, models.ActionTypeBlock, fmt.Sprintf("site%d.example.com", i))
+	}
+	if auditService.SubscriberCount() != 0 {
+		t.Error("Expected the slow subscriber to be dropped")
+	}
+
+	close(w.release)
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the stream to end after the subscriber was dropped")
+	}
+	if body := w.Body.String(); !strings.HasSuffix(body, "event: close\ndata: {\"reason\":\"slow_consumer\"}\n\n") {
+		t.Errorf("Expected the stream to close as a slow consumer, got %q", body[max(0, len(body)-200):])
+	}
+}
+
+func TestHandleAuditTail_InvalidBackfill(t *testing.T) {
+	handler, _ := newAuditTailTestHandler(t)
+
+	for _, backfill := range []string{"-1", "x", fmt.Sprint(maxTailBackfill + 1)} {
+		rec := httptest.NewRecorder()
+		handler.handleAuditTail(rec, httptest.NewRequest(http.MethodGet, "/api/v1/audit/tail?backfill="+backfill, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for backfill %s, got %d", backfill, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"parental-control/internal/models"
+)
+
+// stubAuthRoutes serves the password reset endpoint like the auth handlers
+type stubAuthRoutes struct{}
+
+func (stubAuthRoutes) RegisterRoutes(server *Server) {
+	server.AddHandlerFunc("/api/v1/auth/password/reset", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+func TestAPIServer_MountsAuthHandlers(t *testing.T) {
+	srv := New(DefaultConfig())
+	api := NewAPIServer(models.RepositoryManager{}, true)
+	api.SetAuthHandlers(stubAuthRoutes{})
+	api.RegisterRoutes(srv)
+
+	for path, want := range map[string]int{
+		"/api/v1/auth/password/reset": http.StatusNoContent,
+		"/api/v1/ping":                http.StatusOK,
+	} {
+		rec := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != want {
+			t.Errorf("Expected %d for %s, got %d", want, path, rec.Code)
+		}
+	}
+}
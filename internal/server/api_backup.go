@@ -0,0 +1,26 @@
+package server
+
+import (
+	"net/http"
+
+	"parental-control/internal/logging"
+	"parental-control/internal/service"
+)
+
+// handleBackup handles POST /api/v1/admin/backup, which backs up the
+// database now and returns the new backup file
+func (api *APIServer) handleBackup(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		api.writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	result, err := api.backupService.Backup(r.Context(), service.BackupReasonManual)
+	if err != nil {
+		logging.Error("Manual database backup failed", logging.Err(err))
+		api.writeErrorResponse(w, http.StatusInternalServerError, "Database backup failed: "+err.Error())
+		return
+	}
+
+	api.writeJSONResponse(w, http.StatusCreated, result)
+}
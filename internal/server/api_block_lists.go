@@ -0,0 +1,115 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"time"
+
+	"parental-control/internal/service"
+)
+
+// blockListSubscribeRequest subscribes a blacklist to a published block list
+type blockListSubscribeRequest struct {
+	URL    string `json:"url"`
+	Format string `json:"format"`
+	// RefreshIntervalHours re-fetches the list periodically; 0 imports it once
+	RefreshIntervalHours int `json:"refresh_interval_hours"`
+}
+
+// handleListBlockList handles /api/v1/lists/{id}/blocklist. POST imports a
+// hosts file or AdGuard/EasyList list: a JSON body subscribes the list to a
+// URL, any other body is imported as uploaded list data in the format named
+// by the format query parameter. GET shows the list's source and DELETE stops
+// refreshing it.
+func (api *APIServer) handleListBlockList(w http.ResponseWriter, r *http.Request, listID int) {
+	if api.repos == nil || api.repos.BlockListSource == nil {
+		api.writeErrorResponse(w, http.StatusInternalServerError, "Repository not available")
+		return
+	}
+	if api.blockListService == nil {
+		api.writeErrorResponse(w, http.StatusServiceUnavailable, "Block lists not available")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		source, err := api.repos.BlockListSource.GetByListID(r.Context(), listID)
+		if err != nil {
+			api.writeErrorResponse(w, http.StatusNotFound, "List has no block list source")
+			return
+		}
+		api.writeJSONResponse(w, http.StatusOK, source)
+	case http.MethodPost:
+		api.handleImportBlockList(w, r, listID)
+	case http.MethodDelete:
+		if err := api.blockListService.Unsubscribe(r.Context(), listID); err != nil {
+			api.writeErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("Failed to remove block list source: %v", err))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		api.writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+func (api *APIServer) handleImportBlockList(w http.ResponseWriter, r *http.Request, listID int) {
+	if api.importBodyLimit > 0 {
+		SetBodyLimit(r, api.importBodyLimit)
+	}
+
+	ctx := r.Context()
+	blockLists := api.blockListService
+
+	var result *service.ImportResult
+	var err error
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "application/json" {
+		var req blockListSubscribeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			api.writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		if req.URL == "" {
+			api.writeErrorResponse(w, http.StatusBadRequest, "URL is required")
+			return
+		}
+		if req.RefreshIntervalHours < 0 {
+			api.writeErrorResponse(w, http.StatusBadRequest, "Refresh interval must not be negative")
+			return
+		}
+
+		format := service.ExportEntriesFormat(req.Format)
+		if format == "" {
+			format = service.ImportFormatHosts
+		}
+		interval := time.Duration(req.RefreshIntervalHours) * time.Hour
+		result, err = blockLists.Subscribe(ctx, listID, req.URL, format, interval)
+	} else {
+		format := service.ExportEntriesFormat(r.URL.Query().Get("format"))
+		if format == "" {
+			format = service.ImportFormatHosts
+		}
+		result, err = blockLists.Import(ctx, listID, r.Body, format, r.URL.Query().Get("label"))
+	}
+
+	if err != nil {
+		status := http.StatusBadRequest
+		var fetchErr *service.BlockListFetchError
+		if errors.As(err, &fetchErr) {
+			status = http.StatusBadGateway
+		}
+		api.writeErrorResponse(w, status, fmt.Sprintf("Failed to import block list: %v", err))
+		return
+	}
+
+	// The service reloads the rules itself when the import adds entries
+	api.writeJSONResponse(w, http.StatusOK, map[string]interface{}{
+		"added":   result.Imported,
+		"skipped": result.Duplicates + result.Invalid + result.Skipped,
+		"import":  result,
+	})
+}
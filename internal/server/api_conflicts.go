@@ -0,0 +1,65 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"parental-control/internal/logging"
+	"parental-control/internal/service"
+)
+
+// handleConflicts handles POST /api/v1/conflicts/{id}/resolve, which
+// automatically resolves a rule conflict. With ?dry_run=true the changes are
+// returned without being made. A body holding a previewed plan is only
+// applied if resolving the conflict would still make exactly those changes;
+// otherwise 409 is returned.
+func (api *APIServer) handleConflicts(w http.ResponseWriter, r *http.Request) {
+	conflictID, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/api/v1/conflicts/"), "/resolve")
+	if !ok || conflictID == "" || strings.Contains(conflictID, "/") {
+		api.writeErrorResponse(w, http.StatusNotFound, "Not found")
+		return
+	}
+	if r.Method != http.MethodPost {
+		api.writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if api.repos == nil || api.repos.TimeRule == nil || api.repos.QuotaRule == nil {
+		api.writeErrorResponse(w, http.StatusInternalServerError, "Repository not available")
+		return
+	}
+
+	ctx := r.Context()
+	validator := service.NewRuleValidationService(api.repos, logging.NewDefault())
+
+	if r.URL.Query().Get("dry_run") == "true" {
+		plan, err := validator.PreviewResolveConflict(ctx, conflictID)
+		if err != nil {
+			api.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		api.writeJSONResponse(w, http.StatusOK, plan)
+		return
+	}
+
+	var plan *service.ResolutionPlan
+	if err := json.NewDecoder(r.Body).Decode(&plan); err != nil && !errors.Is(err, io.EOF) {
+		api.writeErrorResponse(w, http.StatusBadRequest, "Invalid resolution plan")
+		return
+	}
+
+	applied, err := validator.ResolveConflict(ctx, conflictID, plan)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, service.ErrResolutionPlanStale) {
+			status = http.StatusConflict
+		}
+		api.writeErrorResponse(w, status, err.Error())
+		return
+	}
+
+	api.refreshRulesAsync(ctx)
+	api.writeJSONResponse(w, http.StatusOK, applied)
+}
@@ -3,6 +3,7 @@ package server
 import (
 	"encoding/json"
 	"net/http"
+	"strconv"
 
 	"parental-control/internal/logging"
 	"parental-control/internal/service"
@@ -20,16 +21,18 @@ func NewEnforcementAPIServer(enforcementService *service.EnforcementService) *En
 	}
 }
 
-// RegisterRoutes registers enforcement API routes
-func (api *EnforcementAPIServer) RegisterRoutes(server *Server) {
+// RegisterRoutes registers enforcement API routes. requireAdmin wraps the
+// endpoints that change enforcement state or reveal what was blocked.
+func (api *EnforcementAPIServer) RegisterRoutes(server *Server, requireAdmin Middleware) {
 	if api.enforcementService == nil {
 		logging.Warn("Enforcement service not available - skipping enforcement API routes")
 		return
 	}
 
-	server.AddHandlerFunc("/api/v1/enforcement/refresh", api.handleRefreshRules)
+	server.AddHandler("/api/v1/enforcement/refresh", requireAdmin(http.HandlerFunc(api.handleRefreshRules)))
+	server.AddHandler("/api/v1/enforcement/reload", requireAdmin(http.HandlerFunc(api.handleReloadRules)))
 	server.AddHandlerFunc("/api/v1/enforcement/stats", api.handleGetStats)
-	server.AddHandlerFunc("/api/v1/enforcement/status", api.handleGetStatus)
+	server.AddHandler("/api/v1/enforcement/status", requireAdmin(http.HandlerFunc(api.handleGetStatus)))
 }
 
 // handleRefreshRules forces an immediate rule refresh
@@ -52,6 +55,24 @@ func (api *EnforcementAPIServer) handleRefreshRules(w http.ResponseWriter, r *ht
 	})
 }
 
+// handleReloadRules re-reads every rule from the database and swaps them in
+func (api *EnforcementAPIServer) handleReloadRules(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		api.writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	if err := api.enforcementService.ReloadRules(r.Context()); err != nil {
+		api.writeErrorResponse(w, http.StatusInternalServerError, "Failed to reload rules: "+err.Error())
+		return
+	}
+
+	api.writeJSONResponse(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Rules reloaded successfully",
+	})
+}
+
 // handleGetStats returns enforcement statistics
 func (api *EnforcementAPIServer) handleGetStats(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -63,19 +84,32 @@ func (api *EnforcementAPIServer) handleGetStats(w http.ResponseWriter, r *http.R
 	api.writeJSONResponse(w, http.StatusOK, stats)
 }
 
-// handleGetStatus returns enforcement system status
+// Recent enforcement actions returned by the status endpoint
+const (
+	defaultStatusActions = 20
+	maxStatusActions     = 100
+)
+
+// handleGetStatus returns enforcement system status: whether each component
+// is running, the config, per-rule block counts and, with ?actions=N, the
+// latest N enforcement actions (default 20, at most 100)
 func (api *EnforcementAPIServer) handleGetStatus(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		api.writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
 		return
 	}
 
-	status := map[string]interface{}{
-		"running": api.enforcementService.IsRunning(),
-		"system":  api.enforcementService.GetSystemInfo(),
+	actions := defaultStatusActions
+	if raw := r.URL.Query().Get("actions"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			api.writeErrorResponse(w, http.StatusBadRequest, "actions must be a non-negative number")
+			return
+		}
+		actions = min(n, maxStatusActions)
 	}
 
-	api.writeJSONResponse(w, http.StatusOK, status)
+	api.writeJSONResponse(w, http.StatusOK, api.enforcementService.GetStatus(r.Context(), actions))
 }
 
 // Helper methods
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"parental-control/internal/service"
+)
+
+// Live event stream limits
+const (
+	maxEventStreams          = 20
+	eventSubscriberBuffer    = 100
+	eventStreamRetryInterval = 5 * time.Second
+)
+
+// eventStreamTypes are the event types a stream can ask for
+var eventStreamTypes = map[string]service.EventType{
+	string(service.EventEnforcement):  service.EventEnforcement,
+	string(service.EventNotification): service.EventNotification,
+	string(service.EventQuota):        service.EventQuota,
+}
+
+// handleEventStream handles GET /api/v1/events/stream, which pushes live
+// enforcement, notification and quota events as server-sent events. The
+// optional types parameter (comma separated) narrows the stream. Enforcement
+// and notification events name blocked sites and apps, so users who are not
+// admins only receive quota events.
+func (api *APIServer) handleEventStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		api.writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	types, err := parseEventTypes(r.URL.Query().Get("types"))
+	if err != nil {
+		api.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if api.authMiddleware != nil {
+		if user, ok := GetUserFromContext(r.Context()); !ok || !user.HasAdminRole() {
+			types = []service.EventType{service.EventQuota}
+		}
+	}
+
+	if atomic.AddInt32(&api.activeEventStreams, 1) > maxEventStreams {
+		atomic.AddInt32(&api.activeEventStreams, -1)
+		api.writeErrorResponse(w, http.StatusServiceUnavailable, "Too many active event streams")
+		return
+	}
+	defer atomic.AddInt32(&api.activeEventStreams, -1)
+
+	sub := api.eventBus.Subscribe(types, eventSubscriberBuffer)
+	defer api.eventBus.Unsubscribe(sub)
+
+	stream := startSSE(w)
+	if err := stream.retry(eventStreamRetryInterval); err != nil {
+		return
+	}
+	if err := stream.event("ready", map[string]interface{}{"types": types}); err != nil {
+		return
+	}
+
+	streamSubscription(r, stream, sub, "event stream", func(event service.Event) error {
+		return stream.event(string(event.Type), event)
+	})
+}
+
+// parseEventTypes parses a comma separated list of event types; empty means all
+func parseEventTypes(value string) ([]service.EventType, error) {
+	var types []service.EventType
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(strings.ToLower(name))
+		if name == "" {
+			continue
+		}
+		eventType, ok := eventStreamTypes[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown event type: %s", name)
+		}
+		types = append(types, eventType)
+	}
+	return types, nil
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"parental-control/internal/models"
+	"parental-control/internal/service"
+)
+
+func TestHandleEventStream(t *testing.T) {
+	bus := service.NewEventBus()
+	api := NewAPIServer(models.RepositoryManager{}, false)
+	api.SetEventBus(bus)
+	ts := httptest.NewServer(http.HandlerFunc(api.handleEventStream))
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "?types=quota")
+	if err != nil {
+		t.Fatalf("Failed to open stream: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Expected an event stream, got %q", ct)
+	}
+
+	lines := bufio.NewScanner(resp.Body)
+	readEvent := func() string {
+		t.Helper()
+		var event []string
+		for lines.Scan() {
+			if lines.Text() == "" && len(event) > 0 {
+				return strings.Join(event, "\n")
+			}
+			if lines.Text() != "" {
+				event = append(event, lines.Text())
+			}
+		}
+		t.Fatalf("Stream ended early: %v", lines.Err())
+		return ""
+	}
+
+	readEvent() // retry interval
+	if event := readEvent(); !strings.HasPrefix(event, "event: ready") {
+		t.Fatalf("Expected the ready event, got %q", event)
+	}
+
+	// Only subscribed types are delivered
+	bus.Publish(service.EventEnforcement, map[string]string{"target": "games.example.com"})
+	bus.Publish(service.EventQuota, service.QuotaChange{Change: service.QuotaChangeReset, QuotaRuleID: 4})
+	if event := readEvent(); !strings.HasPrefix(event, "event: quota") || !strings.Contains(event, `"quota_rule_id":4`) {
+		t.Errorf("Expected the quota event, got %q", event)
+	}
+
+	// Closing the connection removes the subscriber
+	resp.Body.Close()
+	deadline := time.Now().Add(2 * time.Second)
+	for bus.SubscriberCount() != 0 && time.Now().Before(deadline) {
+		bus.Publish(service.EventQuota, nil)
+		time.Sleep(10 * time.Millisecond)
+	}
+	if bus.SubscriberCount() != 0 {
+		t.Error("Expected the subscriber to be removed after disconnect")
+	}
+
+	rec := httptest.NewRecorder()
+	api.handleEventStream(rec, httptest.NewRequest(http.MethodGet, "/api/v1/events/stream?types=bogus", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown type, got %d", rec.Code)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"parental-control/internal/logging"
 	"parental-control/internal/service"
@@ -30,6 +31,7 @@ func (h *PerformanceHandler) RegisterRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("/api/v1/performance/metrics", h.handlePerformanceMetrics)
 	mux.HandleFunc("/api/v1/performance/report", h.handlePerformanceReport)
 	mux.HandleFunc("/api/v1/performance/alerts", h.handlePerformanceAlerts)
+	mux.HandleFunc("/api/v1/performance/history", h.handlePerformanceHistory)
 	mux.HandleFunc("/api/v1/performance/thresholds", h.handlePerformanceThresholds)
 	mux.HandleFunc("/api/v1/performance/thresholds/", h.handlePerformanceThresholdDetail)
 	mux.HandleFunc("/api/v1/performance/health", h.handlePerformanceHealth)
@@ -84,6 +86,66 @@ func (h *PerformanceHandler) handlePerformanceAlerts(w http.ResponseWriter, r *h
 	h.writeJSONResponse(w, http.StatusOK, response)
 }
 
+// handlePerformanceHistory handles GET /api/v1/performance/history
+// Query parameters: start and end (RFC 3339, default the last 24 hours) and
+// limit (default 1000), keeping the most recent snapshots in the range
+func (h *PerformanceHandler) handlePerformanceHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		h.writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	query := r.URL.Query()
+	end := time.Now()
+	if value := query.Get("end"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			h.writeErrorResponse(w, http.StatusBadRequest, "Invalid end time, expected RFC 3339")
+			return
+		}
+		end = parsed
+	}
+	start := end.Add(-24 * time.Hour)
+	if value := query.Get("start"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			h.writeErrorResponse(w, http.StatusBadRequest, "Invalid start time, expected RFC 3339")
+			return
+		}
+		start = parsed
+	}
+	if start.After(end) {
+		h.writeErrorResponse(w, http.StatusBadRequest, "Start time must be before end time")
+		return
+	}
+
+	limit := 1000
+	if value := query.Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			h.writeErrorResponse(w, http.StatusBadRequest, "Limit must be a positive integer")
+			return
+		}
+		limit = parsed
+	}
+
+	snapshots, err := h.performanceMonitor.GetSnapshots(r.Context(), start, end, limit)
+	if err != nil {
+		h.logger.Error("Failed to load performance history", logging.Err(err))
+		h.writeErrorResponse(w, http.StatusInternalServerError, "Failed to load performance history")
+		return
+	}
+
+	response := map[string]interface{}{
+		"snapshots": snapshots,
+		"count":     len(snapshots),
+		"start":     start,
+		"end":       end,
+	}
+
+	h.writeJSONResponse(w, http.StatusOK, response)
+}
+
 // handlePerformanceThresholds handles GET /api/v1/performance/thresholds and POST /api/v1/performance/thresholds
 func (h *PerformanceHandler) handlePerformanceThresholds(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
@@ -184,7 +246,10 @@ func (h *PerformanceHandler) createPerformanceThreshold(w http.ResponseWriter, r
 		return
 	}
 
-	h.performanceMonitor.AddThreshold(threshold)
+	if err := h.performanceMonitor.AddThreshold(threshold); err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
 	response := map[string]interface{}{
 		"message":   "Threshold added successfully",
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"parental-control/internal/logging"
+	"parental-control/internal/service"
+)
+
+// handleQuotas handles POST /api/v1/quotas/{id}/reset, which sets the time
+// used in a quota rule's current window back to zero and returns the rule's
+// status afterwards
+func (api *APIServer) handleQuotas(w http.ResponseWriter, r *http.Request) {
+	idStr, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/api/v1/quotas/"), "/reset")
+	if !ok || idStr == "" || strings.Contains(idStr, "/") {
+		api.writeErrorResponse(w, http.StatusNotFound, "Not found")
+		return
+	}
+	if r.Method != http.MethodPost {
+		api.writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	quotaRuleID, err := strconv.Atoi(idStr)
+	if err != nil {
+		api.writeErrorResponse(w, http.StatusBadRequest, "Invalid quota rule ID")
+		return
+	}
+
+	quotaService := api.quotaService()
+	if quotaService == nil {
+		api.writeErrorResponse(w, http.StatusInternalServerError, "Repository not available")
+		return
+	}
+
+	ctx := r.Context()
+	if err := quotaService.ResetQuotaUsage(ctx, quotaRuleID); err != nil {
+		api.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	status, err := quotaService.GetQuotaRuleStatus(ctx, quotaRuleID)
+	if err != nil {
+		api.writeErrorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	api.refreshRulesAsync(ctx)
+	api.writeJSONResponse(w, http.StatusOK, status)
+}
+
+// handleQuotaRemaining handles GET /api/v1/quotas/remaining?list_id=, which
+// reports the time left on each enabled quota rule of one list. Any signed-in
+// user may read it so a dashboard can show the time left without admin
+// rights; it reveals nothing beyond that list's quotas.
+func (api *APIServer) handleQuotaRemaining(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		api.writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	listID, err := strconv.Atoi(r.URL.Query().Get("list_id"))
+	if err != nil {
+		api.writeErrorResponse(w, http.StatusBadRequest, "list_id is required")
+		return
+	}
+
+	quotaService := api.quotaService()
+	if quotaService == nil {
+		api.writeErrorResponse(w, http.StatusInternalServerError, "Repository not available")
+		return
+	}
+
+	remaining, err := quotaService.GetRemaining(r.Context(), listID)
+	if err != nil {
+		api.writeErrorResponse(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	api.writeJSONResponse(w, http.StatusOK, map[string]interface{}{
+		"list_id": listID,
+		"quotas":  remaining,
+	})
+}
+
+// quotaService returns a quota service that audits, announces and publishes
+// resets, or nil if quota rules cannot be stored
+func (api *APIServer) quotaService() *service.QuotaService {
+	if api.repos == nil || api.repos.QuotaRule == nil || api.repos.QuotaUsage == nil {
+		return nil
+	}
+	quotaService := service.NewQuotaService(api.repos, logging.NewDefault())
+	if api.auditService != nil {
+		quotaService.SetAuditService(api.auditService)
+	}
+	if api.notificationService != nil {
+		quotaService.SetNotificationService(api.notificationService)
+	}
+	quotaService.SetEventBus(api.eventBus)
+	return quotaService
+}
@@ -0,0 +1,153 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"parental-control/internal/database"
+	"parental-control/internal/logging"
+	"parental-control/internal/models"
+	"parental-control/internal/service"
+)
+
+// newQuotaTestAPI returns an API server over a fresh SQLite database with
+// one list and the given quota rule on it
+func newQuotaTestAPI(t *testing.T, rule models.QuotaRule) (*APIServer, models.RepositoryManager, *models.QuotaRule, *database.DB) {
+	t.Helper()
+
+	config := database.DefaultConfig()
+	config.Path = filepath.Join(t.TempDir(), "quota.db")
+	db, err := database.New(config)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.InitializeSchema(); err != nil {
+		t.Fatalf("Failed to initialize schema: %v", err)
+	}
+
+	repos := models.RepositoryManager{
+		List:       database.NewListRepository(db.Connection()),
+		ListEntry:  database.NewListEntryRepository(db.Connection()),
+		QuotaRule:  database.NewQuotaRuleRepository(db.Connection()),
+		QuotaUsage: database.NewQuotaUsageRepository(db.Connection()),
+	}
+	ctx := context.Background()
+	list := &models.List{Name: "Games", Type: models.ListTypeBlacklist, Enabled: true}
+	if err := repos.List.Create(ctx, list); err != nil {
+		t.Fatalf("Failed to create list: %v", err)
+	}
+	rule.ListID = list.ID
+	if err := repos.QuotaRule.Create(ctx, &rule); err != nil {
+		t.Fatalf("Failed to create quota rule: %v", err)
+	}
+
+	return NewAPIServer(repos, false), repos, &rule, db
+}
+
+func TestHandleQuotaRemaining(t *testing.T) {
+	api, repos, rule, _ := newQuotaTestAPI(t, models.QuotaRule{Name: "Daily games",
+		QuotaType: models.QuotaTypeDaily, LimitSeconds: 3600, Enabled: true})
+
+	if err := service.NewQuotaService(&repos, logging.NewDefault()).TrackUsage(context.Background(), rule.ID, 600); err != nil {
+		t.Fatalf("Failed to track usage: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	api.handleQuotaRemaining(rec, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/v1/quotas/remaining?list_id=%d", rule.ListID), nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var body struct {
+		Quotas []service.QuotaRemaining `json:"quotas"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(body.Quotas) != 1 || body.Quotas[0].UsedSeconds != 600 || body.Quotas[0].RemainingSeconds != 3000 {
+		t.Errorf("Expected 600 seconds used and 3000 left, got %+v", body.Quotas)
+	}
+}
+
+func TestHandleQuotaReset(t *testing.T) {
+	api, repos, rule, _ := newQuotaTestAPI(t, models.QuotaRule{Name: "Daily games",
+		QuotaType: models.QuotaTypeDaily, LimitSeconds: 3600, Enabled: true})
+	ctx := context.Background()
+
+	if err := service.NewQuotaService(&repos, logging.NewDefault()).TrackUsage(ctx, rule.ID, 1200); err != nil {
+		t.Fatalf("Failed to track usage: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	api.handleQuotas(rec, httptest.NewRequest(http.MethodPost, fmt.Sprintf("/api/v1/quotas/%d/reset", rule.ID), nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	usages, err := repos.QuotaUsage.GetByQuotaRuleID(ctx, rule.ID)
+	if err != nil || len(usages) != 1 || usages[0].UsedSeconds != 0 {
+		t.Errorf("Expected the stored window to be reset, got %+v (%v)", usages, err)
+	}
+
+	rec = httptest.NewRecorder()
+	api.handleQuotas(rec, httptest.NewRequest(http.MethodPost, "/api/v1/quotas/999/reset", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown rule, got %d", rec.Code)
+	}
+}
+
+func TestQuotaCarryOverPersists(t *testing.T) {
+	api, repos, rule, db := newQuotaTestAPI(t, models.QuotaRule{Name: "Daily games",
+		QuotaType: models.QuotaTypeDaily, LimitSeconds: 3600, Enabled: true,
+		CarryOver: models.CarryOverUnusedCapped, MaxCarryOverSeconds: 1800})
+	ctx := context.Background()
+
+	// The rule existed yesterday, when only 600 of its 3600 seconds were used
+	now := time.Now()
+	if _, err := db.Connection().Exec(`UPDATE quota_rules SET created_at = ? WHERE id = ?`, now.AddDate(0, 0, -7), rule.ID); err != nil {
+		t.Fatalf("Failed to backdate quota rule: %v", err)
+	}
+	yesterday := now.AddDate(0, 0, -1)
+	if err := repos.QuotaUsage.Create(ctx, &models.QuotaUsage{
+		QuotaRuleID: rule.ID,
+		PeriodStart: time.Date(yesterday.Year(), yesterday.Month(), yesterday.Day(), 0, 0, 0, 0, now.Location()),
+		PeriodEnd:   time.Date(yesterday.Year(), yesterday.Month(), yesterday.Day(), 23, 59, 59, 999999999, now.Location()),
+		UsedSeconds: 600,
+	}); err != nil {
+		t.Fatalf("Failed to record yesterday's usage: %v", err)
+	}
+
+	stored, err := repos.QuotaRule.GetByID(ctx, rule.ID)
+	if err != nil || stored.CarryOver != models.CarryOverUnusedCapped || stored.MaxCarryOverSeconds != 1800 {
+		t.Fatalf("Expected the carry-over policy to round-trip, got %+v (%v)", stored, err)
+	}
+
+	// Opening today's window carries the unused time, capped at 1800
+	if err := service.NewQuotaService(&repos, logging.NewDefault()).TrackUsage(ctx, rule.ID, 300); err != nil {
+		t.Fatalf("Failed to track usage: %v", err)
+	}
+	today, err := repos.QuotaUsage.GetCurrentUsage(ctx, rule.ID, now)
+	if err != nil {
+		t.Fatalf("Failed to get today's usage: %v", err)
+	}
+	if today.CarriedSeconds != 1800 || today.UsedSeconds != 300 {
+		t.Errorf("Expected 1800 seconds carried and 300 used, got %d and %d", today.CarriedSeconds, today.UsedSeconds)
+	}
+
+	// A reset keeps the carried time
+	rec := httptest.NewRecorder()
+	api.handleQuotas(rec, httptest.NewRequest(http.MethodPost, fmt.Sprintf("/api/v1/quotas/%d/reset", rule.ID), nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if today, err = repos.QuotaUsage.GetCurrentUsage(ctx, rule.ID, now); err != nil || today.CarriedSeconds != 1800 || today.UsedSeconds != 0 {
+		t.Errorf("Expected the reset to keep 1800 carried seconds, got %+v (%v)", today, err)
+	}
+}
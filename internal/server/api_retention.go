@@ -7,6 +7,7 @@ import (
 	"strconv"
 	"time"
 
+	"parental-control/internal/cron"
 	"parental-control/internal/logging"
 	"parental-control/internal/models"
 	"parental-control/internal/service"
@@ -261,6 +262,12 @@ func (h *RetentionHandler) updateRetentionPolicy(w http.ResponseWriter, r *http.
 		return
 	}
 
+	// Validate the request
+	if err := policyRequest.Validate(); err != nil {
+		h.writeErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("Validation failed: %v", err))
+		return
+	}
+
 	// This would require implementing UpdatePolicy in the service
 	// For now, return a placeholder response
 	response := map[string]interface{}{
@@ -386,6 +393,47 @@ func (req *CreateRetentionPolicyRequest) Validate() error {
 		}
 	}
 
+	return validateExecutionSchedule(req.ExecutionSchedule)
+}
+
+// Validate validates the fields present in an update retention policy request
+func (req *UpdateRetentionPolicyRequest) Validate() error {
+	if req.Name != nil && *req.Name == "" {
+		return fmt.Errorf("name cannot be empty")
+	}
+
+	if req.TimeBasedRule != nil {
+		if err := req.TimeBasedRule.Validate(); err != nil {
+			return fmt.Errorf("time-based rule validation failed: %w", err)
+		}
+	}
+
+	if req.SizeBasedRule != nil {
+		if err := req.SizeBasedRule.Validate(); err != nil {
+			return fmt.Errorf("size-based rule validation failed: %w", err)
+		}
+	}
+
+	if req.CountBasedRule != nil {
+		if err := req.CountBasedRule.Validate(); err != nil {
+			return fmt.Errorf("count-based rule validation failed: %w", err)
+		}
+	}
+
+	if req.ExecutionSchedule != nil {
+		return validateExecutionSchedule(*req.ExecutionSchedule)
+	}
+	return nil
+}
+
+// validateExecutionSchedule checks an optional cron execution schedule
+func validateExecutionSchedule(schedule string) error {
+	if schedule == "" {
+		return nil
+	}
+	if _, err := cron.Parse(schedule); err != nil {
+		return fmt.Errorf("invalid execution_schedule: %w", err)
+	}
 	return nil
 }
 
@@ -0,0 +1,88 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"parental-control/internal/logging"
+	"parental-control/internal/service"
+)
+
+// handleRuleExport handles GET /api/v1/config/rules/export, which downloads
+// every list with its entries, time rules and quota rules as a rule bundle
+func (api *APIServer) handleRuleExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		api.writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	bundle, err := service.NewRuleBundleService(api.repos, logging.NewDefault()).Export(r.Context())
+	if err != nil {
+		api.writeErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("Failed to export rules: %v", err))
+		return
+	}
+
+	w.Header().Set("Content-Disposition",
+		fmt.Sprintf(`attachment; filename="%s"`, service.RuleBundleFilename(bundle.ExportedAt)))
+	w.Header().Set("Cache-Control", "no-store")
+	api.writeJSONResponse(w, http.StatusOK, bundle)
+}
+
+// handleRuleImport handles POST /api/v1/config/rules/import?mode=merge|replace,
+// which applies a rule bundle. A bundle with problems is rejected whole with
+// 400 and the problems listed; in replace mode the database is backed up
+// first when backups are enabled.
+func (api *APIServer) handleRuleImport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		api.writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if api.importBodyLimit > 0 {
+		SetBodyLimit(r, api.importBodyLimit)
+	}
+
+	mode, err := service.ParseRuleImportMode(r.URL.Query().Get("mode"))
+	if err != nil {
+		api.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	bundle, err := service.ReadRuleBundle(r.Body)
+	if err != nil {
+		api.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx := r.Context()
+	if mode == service.RuleImportReplace && api.backupService != nil {
+		if _, err := api.backupService.Backup(ctx, service.BackupReasonImport); err != nil {
+			api.writeErrorResponse(w, http.StatusInternalServerError, "Database backup before replacing the rules failed: "+err.Error())
+			return
+		}
+	}
+
+	result, err := service.NewRuleBundleService(api.repos, logging.NewDefault()).Import(ctx, bundle, mode)
+	var bundleErr *service.RuleBundleError
+	switch {
+	case errors.As(err, &bundleErr):
+		api.writeJSONResponse(w, http.StatusBadRequest, map[string]interface{}{
+			"error":    true,
+			"message":  "The rule bundle is invalid; nothing was imported",
+			"status":   http.StatusBadRequest,
+			"problems": bundleErr.Problems,
+		})
+		return
+	case err != nil:
+		logging.Error("Rule bundle import failed", logging.Err(err))
+		api.writeJSONResponse(w, http.StatusInternalServerError, map[string]interface{}{
+			"error":   true,
+			"message": fmt.Sprintf("Import failed part way through: %v", err),
+			"status":  http.StatusInternalServerError,
+			"result":  result,
+		})
+		return
+	}
+
+	api.writeJSONResponse(w, http.StatusOK, result)
+}
@@ -0,0 +1,38 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"parental-control/internal/logging"
+	"parental-control/internal/service"
+)
+
+// handleRuleEvaluate handles POST /api/v1/rules/evaluate, which reports
+// whether a URL, domain or process name would be allowed or blocked, and
+// which rules decided it. Nothing is enforced and quota usage is unchanged.
+func (api *APIServer) handleRuleEvaluate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		api.writeErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if api.repos == nil {
+		api.writeErrorResponse(w, http.StatusInternalServerError, "Repository not available")
+		return
+	}
+
+	var req service.EvaluationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		api.writeErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	validator := service.NewRuleValidationService(api.repos, logging.NewDefault())
+	evaluation, err := validator.EvaluateTarget(r.Context(), req)
+	if err != nil {
+		api.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	api.writeJSONResponse(w, http.StatusOK, evaluation)
+}
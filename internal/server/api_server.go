@@ -3,6 +3,7 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -24,10 +25,19 @@ const (
 
 // APIServer handles all REST API endpoints for the application
 type APIServer struct {
-	repos              *models.RepositoryManager
-	enforcementService *service.EnforcementService
-	authEnabled        bool
-	startTime          time.Time
+	repos               *models.RepositoryManager
+	enforcementService  *service.EnforcementService
+	authHandlers        RouteRegistrar
+	auditService        *service.AuditService
+	notificationService *service.NotificationService
+	authMiddleware      *AuthMiddleware
+	eventBus            *service.EventBus
+	backupService       *service.DatabaseBackupService
+	blockListService    *service.BlockListService
+	activeEventStreams  int32
+	importBodyLimit     int64
+	authEnabled         bool
+	startTime           time.Time
 }
 
 // NewAPIServer creates a new API server
@@ -44,12 +54,53 @@ func (api *APIServer) SetEnforcementService(enforcementService *service.Enforcem
 	api.enforcementService = enforcementService
 }
 
+// SetAuthHandlers sets the handlers that serve /api/v1/auth/ when
+// authentication is enabled
+func (api *APIServer) SetAuthHandlers(authHandlers RouteRegistrar) {
+	api.authHandlers = authHandlers
+}
+
+// SetAuditService sets the service that records administrative actions
+func (api *APIServer) SetAuditService(auditService *service.AuditService) {
+	api.auditService = auditService
+}
+
+// SetNotificationService sets the service that announces administrative
+// actions
+func (api *APIServer) SetNotificationService(notificationService *service.NotificationService) {
+	api.notificationService = notificationService
+}
+
+// SetAuthMiddleware sets the middleware that restricts administrative
+// endpoints to admins
+func (api *APIServer) SetAuthMiddleware(authMiddleware *AuthMiddleware) {
+	api.authMiddleware = authMiddleware
+}
+
+// SetEventBus sets the bus live events are streamed from
+func (api *APIServer) SetEventBus(eventBus *service.EventBus) {
+	api.eventBus = eventBus
+}
+
+// SetBackupService sets the service that takes database backups on request
+func (api *APIServer) SetBackupService(backupService *service.DatabaseBackupService) {
+	api.backupService = backupService
+}
+
+// SetBlockListService sets the service that imports and refreshes block
+// lists. It is shared with the background refresher so the two never fetch
+// the same list at once.
+func (api *APIServer) SetBlockListService(blockListService *service.BlockListService) {
+	api.blockListService = blockListService
+}
+
 // RegisterRoutes registers all API routes with the server
 func (api *APIServer) RegisterRoutes(server *Server) {
+	api.importBodyLimit = server.MaxImportBodyBytes()
+
 	// Initialize API servers
-	var authMiddleware *AuthMiddleware
 	if api.authEnabled {
-		authAPIServer := NewAuthAPIServer(api.repos, authMiddleware)
+		authAPIServer := NewAuthAPIServer(api.authHandlers)
 		authAPIServer.RegisterRoutes(server)
 	} else {
 		// Register a simplified API server if auth is disabled
@@ -65,10 +116,14 @@ func (api *APIServer) RegisterRoutes(server *Server) {
 	tlsAPIServer := NewTLSAPIServer(server)
 	tlsAPIServer.RegisterRoutes(server)
 
+	// Maintenance mode toggle
+	maintenanceAPIServer := NewMaintenanceAPIServer(server.Maintenance())
+	server.AddHandler(maintenanceEndpoint, api.requireAdmin(http.HandlerFunc(maintenanceAPIServer.handleMaintenance)))
+
 	// Enforcement API if available
 	if api.enforcementService != nil {
 		enforcementAPIServer := NewEnforcementAPIServer(api.enforcementService)
-		enforcementAPIServer.RegisterRoutes(server)
+		enforcementAPIServer.RegisterRoutes(server, api.requireAdmin)
 
 		// Applications API using process monitor from enforcement service
 		processMonitor := api.enforcementService.GetProcessMonitor()
@@ -83,6 +138,48 @@ func (api *APIServer) RegisterRoutes(server *Server) {
 	// Pattern for list IDs and entries - this needs more sophisticated routing but will work for now
 	server.AddHandler("/api/v1/lists/", http.HandlerFunc(api.handleListsWithID))
 	server.AddHandler("/api/v1/entries/", http.HandlerFunc(api.handleEntries))
+	// Resolving conflicts rewrites rules, and evaluation reveals them
+	server.AddHandler("/api/v1/conflicts/", api.requireAdmin(http.HandlerFunc(api.handleConflicts)))
+	server.AddHandler("/api/v1/rules/evaluate", api.requireAdmin(http.HandlerFunc(api.handleRuleEvaluate)))
+	server.AddHandler("/api/v1/quotas/", api.requireAdmin(http.HandlerFunc(api.handleQuotas)))
+	server.AddHandler("/api/v1/quotas/remaining", api.requireAuth(http.HandlerFunc(api.handleQuotaRemaining)))
+
+	server.AddHandler("/api/v1/config/rules/export", api.requireAdmin(http.HandlerFunc(api.handleRuleExport)))
+	server.AddHandler("/api/v1/config/rules/import", api.requireAdmin(http.HandlerFunc(api.handleRuleImport)))
+
+	if api.backupService != nil {
+		server.AddHandler("/api/v1/admin/backup", api.requireAdmin(http.HandlerFunc(api.handleBackup)))
+	}
+
+	if api.eventBus != nil {
+		server.AddHandler("/api/v1/events/stream", api.requireAuth(http.HandlerFunc(api.handleEventStream)))
+	}
+
+	// Audit log queries reveal browsing history, so they are admin only
+	if api.auditService != nil {
+		auditHandler := NewAuditLogHandler(api.auditService, logging.NewDefault())
+		auditMux := http.NewServeMux()
+		auditHandler.RegisterRoutes(auditMux)
+		server.AddHandler("/api/v1/audit", api.requireAdmin(auditMux))
+		server.AddHandler("/api/v1/audit/", api.requireAdmin(auditMux))
+	}
+}
+
+// requireAuth restricts a handler to signed-in users when authentication is
+// set up
+func (api *APIServer) requireAuth(handler http.Handler) http.Handler {
+	if api.authMiddleware == nil {
+		return handler
+	}
+	return api.authMiddleware.RequireAuth()(handler)
+}
+
+// requireAdmin restricts a handler to admins when authentication is set up
+func (api *APIServer) requireAdmin(handler http.Handler) http.Handler {
+	if api.authMiddleware == nil {
+		return handler
+	}
+	return api.authMiddleware.RequireAdmin()(handler)
 }
 
 // Dashboard and business logic endpoints
@@ -180,6 +277,15 @@ func (api *APIServer) handleListsWithID(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// Handle /api/v1/lists/{id}/blocklist. Subscribing makes the service
+	// fetch a URL and fill a list, so it is admin only.
+	if len(parts) > 1 && parts[1] == "blocklist" {
+		api.requireAdmin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			api.handleListBlockList(w, r, listID)
+		})).ServeHTTP(w, r)
+		return
+	}
+
 	// Handle /api/v1/lists/{id}
 	switch r.Method {
 	case http.MethodGet:
@@ -261,6 +367,8 @@ func (api *APIServer) handleCreateList(w http.ResponseWriter, r *http.Request) {
 		Name        string          `json:"name"`
 		Type        models.ListType `json:"type"`
 		Description string          `json:"description"`
+		Label       string          `json:"label"`
+		Notes       string          `json:"notes"`
 		Enabled     bool            `json:"enabled"`
 	}
 
@@ -269,6 +377,11 @@ func (api *APIServer) handleCreateList(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if len(req.Label) > models.MaxLabelLength {
+		api.writeErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("Label must be at most %d characters", models.MaxLabelLength))
+		return
+	}
+
 	if req.Name == "" {
 		api.writeErrorResponse(w, http.StatusBadRequest, "List name is required")
 		return
@@ -283,6 +396,8 @@ func (api *APIServer) handleCreateList(w http.ResponseWriter, r *http.Request) {
 		Name:        req.Name,
 		Type:        req.Type,
 		Description: req.Description,
+		Label:       req.Label,
+		Notes:       req.Notes,
 		Enabled:     req.Enabled,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
@@ -319,6 +434,8 @@ func (api *APIServer) handleUpdateList(w http.ResponseWriter, r *http.Request, l
 		Name        string          `json:"name"`
 		Type        models.ListType `json:"type"`
 		Description string          `json:"description"`
+		Label       *string         `json:"label"`
+		Notes       *string         `json:"notes"`
 		Enabled     bool            `json:"enabled"`
 	}
 
@@ -327,10 +444,21 @@ func (api *APIServer) handleUpdateList(w http.ResponseWriter, r *http.Request, l
 		return
 	}
 
-	// Update fields
+	if req.Label != nil && len(*req.Label) > models.MaxLabelLength {
+		api.writeErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("Label must be at most %d characters", models.MaxLabelLength))
+		return
+	}
+
+	// Update fields; label and notes are kept when omitted
 	existingList.Name = req.Name
 	existingList.Type = req.Type
 	existingList.Description = req.Description
+	if req.Label != nil {
+		existingList.Label = *req.Label
+	}
+	if req.Notes != nil {
+		existingList.Notes = *req.Notes
+	}
 	existingList.Enabled = req.Enabled
 	existingList.UpdatedAt = time.Now()
 
@@ -352,7 +480,14 @@ func (api *APIServer) handleDeleteList(w http.ResponseWriter, r *http.Request, l
 	}
 
 	ctx := r.Context()
-	if err := api.repos.List.Delete(ctx, listID); err != nil {
+
+	// Remove dependent rules along with the list
+	lists := service.NewListManagementService(api.repos, logging.NewDefault())
+	if err := lists.DeleteList(ctx, listID); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			api.writeErrorResponse(w, http.StatusNotFound, "List not found")
+			return
+		}
 		api.writeErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("Failed to delete list: %v", err))
 		return
 	}
@@ -404,6 +539,8 @@ func (api *APIServer) handleCreateListEntry(w http.ResponseWriter, r *http.Reque
 		Pattern     string             `json:"pattern"`
 		PatternType models.PatternType `json:"pattern_type"`
 		Description string             `json:"description"`
+		Label       string             `json:"label"`
+		Notes       string             `json:"notes"`
 		Enabled     bool               `json:"enabled"`
 	}
 
@@ -417,12 +554,25 @@ func (api *APIServer) handleCreateListEntry(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	if err := validateEntryPattern(req.Pattern, req.PatternType); err != nil {
+		api.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if len(req.Label) > models.MaxLabelLength {
+		api.writeErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("Label must be at most %d characters", models.MaxLabelLength))
+		return
+	}
+
 	entry := &models.ListEntry{
 		ListID:      listID,
 		EntryType:   req.EntryType,
 		Pattern:     req.Pattern,
 		PatternType: req.PatternType,
 		Description: req.Description,
+		Label:       req.Label,
+		Notes:       req.Notes,
+		Source:      models.EntrySourceManual,
 		Enabled:     req.Enabled,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
@@ -430,6 +580,10 @@ func (api *APIServer) handleCreateListEntry(w http.ResponseWriter, r *http.Reque
 
 	ctx := r.Context()
 	if err := api.repos.ListEntry.Create(ctx, entry); err != nil {
+		if errors.Is(err, models.ErrDuplicateEntry) {
+			api.writeErrorResponse(w, http.StatusConflict, "Entry already exists in this list")
+			return
+		}
 		api.writeErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("Failed to create entry: %v", err))
 		return
 	}
@@ -502,6 +656,8 @@ func (api *APIServer) handleUpdateEntry(w http.ResponseWriter, r *http.Request,
 		Pattern     string             `json:"pattern"`
 		PatternType models.PatternType `json:"pattern_type"`
 		Description string             `json:"description"`
+		Label       *string            `json:"label"`
+		Notes       *string            `json:"notes"`
 		Enabled     bool               `json:"enabled"`
 	}
 
@@ -510,14 +666,35 @@ func (api *APIServer) handleUpdateEntry(w http.ResponseWriter, r *http.Request,
 		return
 	}
 
+	if req.Label != nil && len(*req.Label) > models.MaxLabelLength {
+		api.writeErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("Label must be at most %d characters", models.MaxLabelLength))
+		return
+	}
+
+	if err := validateEntryPattern(req.Pattern, req.PatternType); err != nil {
+		api.writeErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Label and notes are kept when omitted
 	existingEntry.EntryType = req.EntryType
 	existingEntry.Pattern = req.Pattern
 	existingEntry.PatternType = req.PatternType
 	existingEntry.Description = req.Description
+	if req.Label != nil {
+		existingEntry.Label = *req.Label
+	}
+	if req.Notes != nil {
+		existingEntry.Notes = *req.Notes
+	}
 	existingEntry.Enabled = req.Enabled
 	existingEntry.UpdatedAt = time.Now()
 
 	if err := api.repos.ListEntry.Update(ctx, existingEntry); err != nil {
+		if errors.Is(err, models.ErrDuplicateEntry) {
+			api.writeErrorResponse(w, http.StatusConflict, "Entry already exists in this list")
+			return
+		}
 		api.writeErrorResponse(w, http.StatusInternalServerError, fmt.Sprintf("Failed to update entry: %v", err))
 		return
 	}
@@ -528,6 +705,19 @@ func (api *APIServer) handleUpdateEntry(w http.ResponseWriter, r *http.Request,
 	api.writeJSONResponse(w, http.StatusOK, existingEntry)
 }
 
+// validateEntryPattern rejects regex, cidr and port patterns that would never match
+func validateEntryPattern(pattern string, patternType models.PatternType) error {
+	switch patternType {
+	case models.PatternTypeRegex:
+		return service.ValidateRegexPattern(pattern)
+	case models.PatternTypeCIDR:
+		return service.ValidateCIDRPattern(pattern)
+	case models.PatternTypePort:
+		return service.ValidatePortPattern(pattern)
+	}
+	return nil
+}
+
 func (api *APIServer) handleDeleteEntry(w http.ResponseWriter, r *http.Request, entryID int) {
 	if api.repos == nil {
 		api.writeErrorResponse(w, http.StatusInternalServerError, "Repository not available")
@@ -14,18 +14,22 @@ import (
 // SimpleAPIServer handles simple API endpoints.
 
 type SimpleAPIServer struct {
-	repos *models.RepositoryManager
+	repos   *models.RepositoryManager
+	cookies CookieConfig
 }
 
 // NewSimpleAPIServer creates a new SimpleAPIServer.
 func NewSimpleAPIServer(repoManager *models.RepositoryManager) *SimpleAPIServer {
 	return &SimpleAPIServer{
-		repos: repoManager,
+		repos:   repoManager,
+		cookies: DefaultCookieConfig(),
 	}
 }
 
 // RegisterRoutes registers the simple API routes with the server.
 func (s *SimpleAPIServer) RegisterRoutes(server *Server) {
+	s.cookies = server.GetCookieConfig()
+
 	// Register basic ping and info endpoints
 	server.AddHandlerFunc("/api/v1/ping", s.handlePing)
 	server.AddHandlerFunc("/api/v1/info", s.handleInfo)
@@ -80,15 +84,7 @@ func (s *SimpleAPIServer) handleLogin(w http.ResponseWriter, r *http.Request) {
 	sessionToken := "mock_session_" + fmt.Sprintf("%d", time.Now().Unix())
 
 	// Set session cookie for compatibility
-	http.SetCookie(w, &http.Cookie{
-		Name:     "session_id",
-		Value:    sessionToken,
-		Path:     "/",
-		MaxAge:   int((24 * time.Hour).Seconds()),
-		HttpOnly: true,
-		Secure:   r.TLS != nil,
-		SameSite: http.SameSiteStrictMode,
-	})
+	http.SetCookie(w, s.cookies.SessionCookie(r, sessionToken, 24*time.Hour))
 
 	s.writeJSONResponse(w, http.StatusOK, map[string]interface{}{
 		"success":    true,
@@ -111,15 +107,7 @@ func (s *SimpleAPIServer) handleLogout(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Clear session cookie
-	http.SetCookie(w, &http.Cookie{
-		Name:     "session_id",
-		Value:    "",
-		Path:     "/",
-		MaxAge:   -1,
-		HttpOnly: true,
-		Secure:   r.TLS != nil,
-		SameSite: http.SameSiteStrictMode,
-	})
+	http.SetCookie(w, s.cookies.ClearSessionCookie(r))
 
 	s.writeJSONResponse(w, http.StatusOK, map[string]interface{}{
 		"success": true,
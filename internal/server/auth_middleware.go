@@ -2,6 +2,7 @@ package server
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"strings"
 
@@ -22,6 +23,29 @@ type AuthService interface {
 	GetSession(sessionID string) (AuthSession, error)
 }
 
+// APIKeyAuthService is implemented by auth services that also accept API
+// keys as bearer tokens
+type APIKeyAuthService interface {
+	IsAPIKey(token string) bool
+	AuthenticateAPIKey(key, ipAddress, userAgent string) (AuthUser, AuthAPIKey, error)
+}
+
+// AuthAPIKey represents the API key a request authenticated with
+type AuthAPIKey interface {
+	Allows(method string) bool
+}
+
+// errAPIKeyScope is returned for a valid API key whose scope does not allow
+// the request's method
+var errAPIKeyScope = &AuthError{Message: "API key scope does not allow this request"}
+
+// RouteRegistrar registers a group of routes with a server. The auth
+// package's handlers implement it, so they can be mounted without this
+// package importing auth.
+type RouteRegistrar interface {
+	RegisterRoutes(server *Server)
+}
+
 // AuthUser interface to represent authenticated user
 type AuthUser interface {
 	GetID() int
@@ -59,7 +83,9 @@ func NewAuthMiddleware(authService AuthService) *AuthMiddleware {
 	}
 }
 
-// AddPublicPath adds a path that doesn't require authentication
+// AddPublicPath adds a path that doesn't require authentication. A path
+// ending in "/" also covers the paths under it, except for "/" itself, which
+// only covers the root.
 func (am *AuthMiddleware) AddPublicPath(path string) {
 	am.publicPaths = append(am.publicPaths, path)
 }
@@ -84,7 +110,7 @@ func (am *AuthMiddleware) RequireAuth() Middleware {
 					logging.String("error", err.Error()),
 				)
 
-				WriteErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+				writeAuthFailure(w, err)
 				return
 			}
 
@@ -112,7 +138,7 @@ func (am *AuthMiddleware) RequireAdmin() Middleware {
 					logging.String("error", err.Error()),
 				)
 
-				WriteErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+				writeAuthFailure(w, err)
 				return
 			}
 
@@ -138,8 +164,33 @@ func (am *AuthMiddleware) RequireAdmin() Middleware {
 	}
 }
 
-// extractAuthFromRequest extracts authentication info from the request
+// writeAuthFailure rejects a request that failed authentication. A valid
+// API key used beyond its scope is forbidden rather than unauthenticated.
+func writeAuthFailure(w http.ResponseWriter, err error) {
+	if errors.Is(err, errAPIKeyScope) {
+		WriteErrorResponse(w, http.StatusForbidden, errAPIKeyScope.Message)
+		return
+	}
+	WriteErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+}
+
+// extractAuthFromRequest extracts authentication info from the request. A
+// bearer API key is resolved through the auth service, if it accepts keys,
+// and has no session.
 func (am *AuthMiddleware) extractAuthFromRequest(r *http.Request) (AuthUser, AuthSession, error) {
+	if keyService, ok := am.authService.(APIKeyAuthService); ok {
+		if token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "); keyService.IsAPIKey(token) {
+			user, apiKey, err := keyService.AuthenticateAPIKey(token, getClientIP(r), r.UserAgent())
+			if err != nil {
+				return nil, nil, err
+			}
+			if !apiKey.Allows(r.Method) {
+				return nil, nil, errAPIKeyScope
+			}
+			return user, nil, nil
+		}
+	}
+
 	// Try to get session from cookie first
 	sessionID := am.getSessionFromCookie(r)
 
@@ -195,7 +246,10 @@ func (am *AuthMiddleware) getSessionFromHeader(r *http.Request) string {
 // isPublicPath checks if a path is public (doesn't require authentication)
 func (am *AuthMiddleware) isPublicPath(path string) bool {
 	for _, publicPath := range am.publicPaths {
-		if path == publicPath || strings.HasPrefix(path, publicPath) {
+		if path == publicPath {
+			return true
+		}
+		if publicPath != "/" && strings.HasSuffix(publicPath, "/") && strings.HasPrefix(path, publicPath) {
 			return true
 		}
 	}
@@ -0,0 +1,134 @@
+package server
+
+import (
+	"io"
+	"net/http"
+)
+
+// Request body limits
+const (
+	// DefaultMaxBodyBytes limits request bodies unless a route allows more
+	DefaultMaxBodyBytes int64 = 1 << 20 // 1 MB
+	// DefaultMaxImportBodyBytes limits uploaded block lists, which are often
+	// hosts files of several megabytes
+	DefaultMaxImportBodyBytes int64 = 32 << 20 // 32 MB
+)
+
+// BodyLimitMiddleware limits request bodies to maxBytes as they are read, so
+// the limit also holds for chunked requests without a Content-Length header.
+// Requests that declare a larger body are refused up front. Once a handler
+// reads past the limit, whatever it responds is replaced with 413.
+//
+// A route can change the limit for its own requests with SetBodyLimit, e.g.
+// to accept large uploads, as long as it does so before reading the body.
+func BodyLimitMiddleware(maxBytes int64, refuseDeclared bool) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if body, ok := r.Body.(*limitedBody); ok {
+				// Already limited further out; this route sets its own limit
+				body.limit = maxBytes
+			} else if r.Body != nil && r.Body != http.NoBody {
+				body := &limitedBody{body: r.Body, limit: maxBytes}
+				r.Body = body
+				w = &bodyLimitWriter{ResponseWriter: w, body: body}
+			}
+
+			if refuseDeclared && r.ContentLength > maxBytes {
+				WriteErrorResponse(w, http.StatusRequestEntityTooLarge, "Request entity too large")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// SetBodyLimit changes the body limit of a request passed through
+// BodyLimitMiddleware. It has no effect once the body has been read past
+// the old limit, or on requests without a limited body.
+func SetBodyLimit(r *http.Request, maxBytes int64) {
+	if body, ok := r.Body.(*limitedBody); ok && !body.exceeded {
+		body.limit = maxBytes
+	}
+}
+
+// limitedBody returns an error once more than limit bytes are read
+type limitedBody struct {
+	body     io.ReadCloser
+	limit    int64
+	read     int64
+	exceeded bool
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.exceeded {
+		return 0, &http.MaxBytesError{Limit: b.limit}
+	}
+
+	// Read at most one byte past the limit to tell if there is more
+	if remaining := b.limit - b.read + 1; remaining < int64(len(p)) {
+		if remaining <= 0 {
+			remaining = 1
+		}
+		p = p[:remaining]
+	}
+
+	n, err := b.body.Read(p)
+	b.read += int64(n)
+	if b.read > b.limit {
+		n -= int(b.read - b.limit)
+		if n < 0 {
+			n = 0
+		}
+		b.exceeded = true
+		return n, &http.MaxBytesError{Limit: b.limit}
+	}
+	return n, err
+}
+
+func (b *limitedBody) Close() error {
+	return b.body.Close()
+}
+
+// bodyLimitWriter replaces the handler's response with 413 if the request
+// body went over its limit
+type bodyLimitWriter struct {
+	http.ResponseWriter
+	body     *limitedBody
+	replaced bool
+}
+
+func (w *bodyLimitWriter) WriteHeader(code int) {
+	if w.replaced {
+		return
+	}
+	if w.body.exceeded {
+		w.replace()
+		return
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *bodyLimitWriter) Write(data []byte) (int, error) {
+	if !w.replaced && w.body.exceeded {
+		w.replace()
+	}
+	if w.replaced {
+		return len(data), nil
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *bodyLimitWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// replace sends 413 in place of the handler's response and closes the
+// connection, since the rest of the body is not read
+func (w *bodyLimitWriter) replace() {
+	w.replaced = true
+	w.Header().Del("Content-Length")
+	w.Header().Del("Content-Disposition")
+	w.Header().Set("Connection", "close")
+	WriteErrorResponse(w.ResponseWriter, http.StatusRequestEntityTooLarge, "Request entity too large")
+}
@@ -0,0 +1,151 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// SessionCookieName is the name of the cookie carrying the session ID
+const SessionCookieName = "session_id"
+
+// CookieSecureMode controls when the Secure attribute is set on cookies
+type CookieSecureMode string
+
+const (
+	// CookieSecureAuto marks cookies Secure when the request arrived over HTTPS
+	CookieSecureAuto CookieSecureMode = "auto"
+	// CookieSecureAlways always marks cookies Secure
+	CookieSecureAlways CookieSecureMode = "always"
+	// CookieSecureNever never marks cookies Secure
+	CookieSecureNever CookieSecureMode = "never"
+)
+
+// CookieConfig holds the attributes applied to session cookies
+type CookieConfig struct {
+	// Domain attribute (empty uses the request host)
+	Domain string
+	// Path attribute
+	Path string
+	// SameSite attribute
+	SameSite http.SameSite
+	// Secure decides when the Secure attribute is set
+	Secure CookieSecureMode
+	// TrustForwardedProto honors X-Forwarded-Proto for the Secure decision
+	TrustForwardedProto bool
+	// TrustedProxies limits which peers may set X-Forwarded-Proto (empty trusts
+	// any) and are the only peers whose X-Forwarded-For the rate limiter reads
+	TrustedProxies []string
+}
+
+// DefaultCookieConfig returns cookie configuration with secure defaults
+func DefaultCookieConfig() CookieConfig {
+	return CookieConfig{
+		Path:     "/",
+		SameSite: http.SameSiteStrictMode,
+		Secure:   CookieSecureAuto,
+	}
+}
+
+// ParseSameSite converts a configuration value into an http.SameSite mode
+func ParseSameSite(value string) (http.SameSite, error) {
+	switch strings.ToLower(value) {
+	case "", "strict":
+		return http.SameSiteStrictMode, nil
+	case "lax":
+		return http.SameSiteLaxMode, nil
+	case "none":
+		return http.SameSiteNoneMode, nil
+	default:
+		return http.SameSiteDefaultMode, fmt.Errorf("invalid SameSite value: %s", value)
+	}
+}
+
+// SessionCookie builds a session cookie carrying the given value
+func (c CookieConfig) SessionCookie(r *http.Request, value string, maxAge time.Duration) *http.Cookie {
+	return c.newCookie(r, value, int(maxAge.Seconds()))
+}
+
+// ClearSessionCookie builds a cookie that removes the session cookie
+func (c CookieConfig) ClearSessionCookie(r *http.Request) *http.Cookie {
+	return c.newCookie(r, "", -1)
+}
+
+// IsSecureRequest reports whether the request reached the client over HTTPS
+func (c CookieConfig) IsSecureRequest(r *http.Request) bool {
+	if r.TLS != nil {
+		return true
+	}
+	if !c.TrustForwardedProto || !c.isTrustedProxy(r.RemoteAddr) {
+		return false
+	}
+
+	// Use the first hop when proxies append to the header
+	proto := strings.TrimSpace(strings.Split(r.Header.Get("X-Forwarded-Proto"), ",")[0])
+	return strings.EqualFold(proto, "https")
+}
+
+func (c CookieConfig) newCookie(r *http.Request, value string, maxAge int) *http.Cookie {
+	path := c.Path
+	if path == "" {
+		path = "/"
+	}
+
+	sameSite := c.SameSite
+	if sameSite == 0 {
+		sameSite = http.SameSiteStrictMode
+	}
+
+	return &http.Cookie{
+		Name:     SessionCookieName,
+		Value:    value,
+		Domain:   c.Domain,
+		Path:     path,
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   c.isSecure(r),
+		SameSite: sameSite,
+	}
+}
+
+func (c CookieConfig) isSecure(r *http.Request) bool {
+	switch c.Secure {
+	case CookieSecureAlways:
+		return true
+	case CookieSecureNever:
+		return false
+	default:
+		return c.IsSecureRequest(r)
+	}
+}
+
+func (c CookieConfig) isTrustedProxy(remoteAddr string) bool {
+	if len(c.TrustedProxies) == 0 {
+		return true
+	}
+	return proxyListContains(c.TrustedProxies, RemoteHost(remoteAddr))
+}
+
+// proxyListContains reports whether host is one of proxies, given as IPs or CIDRs
+func proxyListContains(proxies []string, host string) bool {
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+
+	for _, proxy := range proxies {
+		proxy = strings.TrimSpace(proxy)
+		if _, network, err := net.ParseCIDR(proxy); err == nil {
+			if network.Contains(ip) {
+				return true
+			}
+			continue
+		}
+		if proxyIP := net.ParseIP(proxy); proxyIP != nil && proxyIP.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
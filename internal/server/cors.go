@@ -0,0 +1,118 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSConfig controls which other origins may call the API from a browser
+type CORSConfig struct {
+	// AllowedOrigins are the origins allowed to make cross-origin requests,
+	// e.g. "https://dashboard.lan:3000". Empty disables CORS; "*" allows any
+	// origin unless AllowCredentials is set.
+	AllowedOrigins []string
+	// AllowedMethods answered to preflight requests
+	AllowedMethods []string
+	// AllowedHeaders answered to preflight requests
+	AllowedHeaders []string
+	// AllowCredentials lets cross-origin requests send the session cookie
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache a preflight answer (0 omits it)
+	MaxAge time.Duration
+}
+
+// DefaultCORSConfig returns a configuration with CORS disabled
+func DefaultCORSConfig() CORSConfig {
+	return CORSConfig{
+		AllowedOrigins: []string{},
+		AllowedMethods: []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete},
+		AllowedHeaders: []string{"Accept", "Authorization", "Content-Type", "X-Request-ID"},
+		MaxAge:         10 * time.Minute,
+	}
+}
+
+// CORSMiddlewareWithConfig answers preflight requests and adds CORS headers
+// for allowlisted origins. The request's own origin is echoed back rather
+// than "*", and a wildcard is ignored when credentials are allowed.
+// Requests from other origins get no CORS headers, so browsers block them.
+func CORSMiddlewareWithConfig(config CORSConfig) Middleware {
+	if len(config.AllowedOrigins) == 0 {
+		return func(next http.Handler) http.Handler { return next }
+	}
+
+	origins := make(map[string]bool, len(config.AllowedOrigins))
+	anyOrigin := false
+	for _, origin := range config.AllowedOrigins {
+		origin = normalizeOrigin(origin)
+		if origin == "*" {
+			anyOrigin = !config.AllowCredentials
+			continue
+		}
+		origins[origin] = true
+	}
+	methods := joinTrimmed(config.AllowedMethods)
+	headers := joinTrimmed(config.AllowedHeaders)
+	maxAge := strconv.FormatInt(int64(config.MaxAge/time.Second), 10)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Add("Vary", "Origin")
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+			if !anyOrigin && !origins[normalizeOrigin(origin)] {
+				if preflight {
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			if config.AllowCredentials {
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			}
+			if !preflight {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Add("Vary", "Access-Control-Request-Method")
+			w.Header().Add("Vary", "Access-Control-Request-Headers")
+			if methods != "" {
+				w.Header().Set("Access-Control-Allow-Methods", methods)
+			}
+			if headers != "" {
+				w.Header().Set("Access-Control-Allow-Headers", headers)
+			}
+			if config.MaxAge > 0 {
+				w.Header().Set("Access-Control-Max-Age", maxAge)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
+
+// normalizeOrigin makes origins comparable: scheme and host are case
+// insensitive and a trailing slash is not part of an origin
+func normalizeOrigin(origin string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(origin), "/"))
+}
+
+// joinTrimmed renders a list as a header value, skipping empty items
+func joinTrimmed(items []string) string {
+	trimmed := make([]string, 0, len(items))
+	for _, item := range items {
+		if item = strings.TrimSpace(item); item != "" {
+			trimmed = append(trimmed, item)
+		}
+	}
+	return strings.Join(trimmed, ", ")
+}
@@ -0,0 +1,73 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// defaultShutdownTimeout bounds Stop when the caller's context has no deadline
+const defaultShutdownTimeout = 30 * time.Second
+
+// connTracker counts the connections of the HTTP servers by state, so
+// shutdown can report how many requests it is waiting for
+type connTracker struct {
+	mu    sync.Mutex
+	conns map[net.Conn]http.ConnState
+}
+
+func newConnTracker() *connTracker {
+	return &connTracker{conns: make(map[net.Conn]http.ConnState)}
+}
+
+// track is used as http.Server.ConnState
+func (t *connTracker) track(conn net.Conn, state http.ConnState) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	switch state {
+	case http.StateHijacked, http.StateClosed:
+		delete(t.conns, conn)
+	default:
+		t.conns[conn] = state
+	}
+}
+
+// counts returns the number of connections serving a request and the number
+// of idle keep-alive connections
+func (t *connTracker) counts() (active, idle int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, state := range t.conns {
+		if state == http.StateIdle {
+			idle++
+		} else {
+			active++
+		}
+	}
+	return active, idle
+}
+
+type drainingKey struct{}
+
+// drainMiddleware lets handlers see when the server starts shutting down
+func drainMiddleware(draining <-chan struct{}) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), drainingKey{}, draining)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// Draining returns a channel that is closed when the server begins shutting
+// down. Ordinary requests are left to finish; long-lived streams should end
+// so they do not hold up shutdown. Outside a server the channel is nil and
+// never ready.
+func Draining(ctx context.Context) <-chan struct{} {
+	draining, _ := ctx.Value(drainingKey{}).(<-chan struct{})
+	return draining
+}
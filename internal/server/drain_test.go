@@ -0,0 +1,162 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+
+	config := DefaultConfig()
+	config.Port = 0
+	config.BindToLAN = false
+	config.RateLimits = nil
+
+	return New(config)
+}
+
+func serverURL(t *testing.T, srv *Server, path string) string {
+	t.Helper()
+
+	_, port, err := net.SplitHostPort(srv.GetAddress())
+	if err != nil {
+		t.Fatalf("Invalid server address %q: %v", srv.GetAddress(), err)
+	}
+	return "http://127.0.0.1:" + port + path
+}
+
+func TestServerStop_DrainsInFlightRequests(t *testing.T) {
+	srv := newTestServer(t)
+
+	started := make(chan struct{})
+	srv.AddHandlerFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(300 * time.Millisecond)
+		w.Write([]byte("done"))
+	})
+
+	if err := srv.Start(context.Background()); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+
+	type result struct {
+		status int
+		body   string
+		err    error
+	}
+	results := make(chan result, 1)
+	go func() {
+		resp, err := http.Get(serverURL(t, srv, "/slow"))
+		if err != nil {
+			results <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		results <- result{status: resp.StatusCode, body: string(body), err: err}
+	}()
+
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Slow request never reached the handler")
+	}
+
+	if active, _ := srv.conns.counts(); active != 1 {
+		t.Errorf("Expected 1 active connection before shutdown, got %d", active)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stopStart := time.Now()
+	if err := srv.Stop(ctx); err != nil {
+		t.Fatalf("Stop returned error: %v", err)
+	}
+	if elapsed := time.Since(stopStart); elapsed < 100*time.Millisecond {
+		t.Errorf("Stop returned after %v without waiting for the slow request", elapsed)
+	}
+
+	// The request finished before Stop returned
+	select {
+	case res := <-results:
+		if res.err != nil {
+			t.Fatalf("Slow request failed during shutdown: %v", res.err)
+		}
+		if res.status != http.StatusOK || res.body != "done" {
+			t.Errorf("Expected 200 \"done\", got %d %q", res.status, res.body)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Slow request did not complete")
+	}
+
+	if srv.IsRunning() {
+		t.Error("Server still running after Stop")
+	}
+}
+
+func TestServerStop_TimesOutAndEndsStreams(t *testing.T) {
+	srv := newTestServer(t)
+
+	release := make(chan struct{})
+	defer close(release)
+
+	streamStarted := make(chan struct{})
+	streamEnded := make(chan struct{})
+	srv.AddHandlerFunc("/stream", func(w http.ResponseWriter, r *http.Request) {
+		defer close(streamEnded)
+		w.WriteHeader(http.StatusOK)
+		http.NewResponseController(w).Flush()
+		close(streamStarted)
+		select {
+		case <-Draining(r.Context()):
+		case <-r.Context().Done():
+		}
+	})
+
+	hangStarted := make(chan struct{})
+	srv.AddHandlerFunc("/hang", func(w http.ResponseWriter, r *http.Request) {
+		close(hangStarted)
+		<-release
+	})
+
+	if err := srv.Start(context.Background()); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+
+	for path, started := range map[string]chan struct{}{"/stream": streamStarted, "/hang": hangStarted} {
+		go func(path string) {
+			if resp, err := http.Get(serverURL(t, srv, path)); err == nil {
+				io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+			}
+		}(path)
+		select {
+		case <-started:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Request to %s never reached the handler", path)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	if err := srv.Stop(ctx); err == nil {
+		t.Error("Expected Stop to report the request that outlived the timeout")
+	}
+
+	select {
+	case <-streamEnded:
+	default:
+		t.Error("Stream was not told to end when shutdown began")
+	}
+
+	if srv.IsRunning() {
+		t.Error("Server still running after Stop")
+	}
+}
@@ -0,0 +1,212 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"parental-control/internal/logging"
+)
+
+const (
+	// maintenanceEndpoint is always reachable so maintenance can be turned off
+	maintenanceEndpoint = "/api/v1/admin/maintenance"
+	// maintenanceRetryAfter is the Retry-After hint sent with refused writes
+	maintenanceRetryAfter = 30 * time.Second
+)
+
+// MaintenanceMode tracks whether the API is temporarily read-only
+type MaintenanceMode struct {
+	mu     sync.RWMutex
+	manual bool
+	reason string
+	since  time.Time
+
+	// Automatic windows opened by backups and migrations
+	active map[int]string
+	nextID int
+}
+
+// MaintenanceStatus describes the current maintenance state
+type MaintenanceStatus struct {
+	Enabled    bool      `json:"enabled"`
+	Manual     bool      `json:"manual"`
+	Reasons    []string  `json:"reasons,omitempty"`
+	Since      time.Time `json:"since,omitempty"`
+	Operations int       `json:"operations"`
+}
+
+// NewMaintenanceMode creates a maintenance mode tracker in the disabled state
+func NewMaintenanceMode() *MaintenanceMode {
+	return &MaintenanceMode{
+		active: make(map[int]string),
+	}
+}
+
+// Enable manually turns on maintenance mode
+func (m *MaintenanceMode) Enable(reason string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.isActiveLocked() {
+		m.since = time.Now()
+	}
+	m.manual = true
+	m.reason = reason
+
+	logging.Warn("Maintenance mode enabled", logging.String("reason", reason))
+}
+
+// Disable turns off manual maintenance mode. Automatic windows still in
+// progress keep the API read-only until they end.
+func (m *MaintenanceMode) Disable() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.manual = false
+	m.reason = ""
+
+	logging.Info("Maintenance mode disabled",
+		logging.Int("operations_in_progress", len(m.active)))
+}
+
+// Begin opens an automatic maintenance window for an operation such as a
+// backup or migration and returns a function that closes it
+func (m *MaintenanceMode) Begin(reason string) func() {
+	m.mu.Lock()
+	if !m.isActiveLocked() {
+		m.since = time.Now()
+	}
+	id := m.nextID
+	m.nextID++
+	m.active[id] = reason
+	m.mu.Unlock()
+
+	logging.Info("Maintenance window started", logging.String("reason", reason))
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			m.mu.Lock()
+			delete(m.active, id)
+			m.mu.Unlock()
+
+			logging.Info("Maintenance window ended", logging.String("reason", reason))
+		})
+	}
+}
+
+// IsActive reports whether state-changing requests are currently refused
+func (m *MaintenanceMode) IsActive() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.isActiveLocked()
+}
+
+// Status returns a snapshot of the maintenance state
+func (m *MaintenanceMode) Status() MaintenanceStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	status := MaintenanceStatus{
+		Enabled:    m.isActiveLocked(),
+		Manual:     m.manual,
+		Operations: len(m.active),
+	}
+	if m.manual && m.reason != "" {
+		status.Reasons = append(status.Reasons, m.reason)
+	}
+	for _, reason := range m.active {
+		status.Reasons = append(status.Reasons, reason)
+	}
+	if status.Enabled {
+		status.Since = m.since
+	}
+	return status
+}
+
+func (m *MaintenanceMode) isActiveLocked() bool {
+	return m.manual || len(m.active) > 0
+}
+
+// MaintenanceMiddleware refuses state-changing requests with 503 while
+// maintenance mode is active. Safe methods and exempt paths pass through.
+func MaintenanceMiddleware(maintenance *MaintenanceMode, exemptPaths ...string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !maintenance.IsActive() || isSafeMethod(r.Method) || isExemptPath(r.URL.Path, exemptPaths) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			status := maintenance.Status()
+			message := "Service is in maintenance mode; changes are temporarily disabled"
+			if len(status.Reasons) > 0 {
+				message += " (" + strings.Join(status.Reasons, ", ") + ")"
+			}
+
+			w.Header().Set("Retry-After", strconv.Itoa(int(maintenanceRetryAfter.Seconds())))
+			WriteErrorResponse(w, http.StatusServiceUnavailable, message)
+		})
+	}
+}
+
+// MaintenanceAPIServer exposes the admin endpoint for toggling maintenance
+// mode. APIServer registers it behind requireAdmin.
+type MaintenanceAPIServer struct {
+	maintenance *MaintenanceMode
+}
+
+// NewMaintenanceAPIServer creates a new maintenance API server
+func NewMaintenanceAPIServer(maintenance *MaintenanceMode) *MaintenanceAPIServer {
+	return &MaintenanceAPIServer{
+		maintenance: maintenance,
+	}
+}
+
+// handleMaintenance handles GET/POST /api/v1/admin/maintenance
+func (api *MaintenanceAPIServer) handleMaintenance(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		WriteJSONResponse(w, http.StatusOK, api.maintenance.Status())
+	case http.MethodPost:
+		var req struct {
+			Enabled bool   `json:"enabled"`
+			Reason  string `json:"reason"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			WriteErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+
+		if req.Enabled {
+			reason := req.Reason
+			if reason == "" {
+				reason = "manual maintenance"
+			}
+			api.maintenance.Enable(reason)
+		} else {
+			api.maintenance.Disable()
+		}
+
+		WriteJSONResponse(w, http.StatusOK, api.maintenance.Status())
+	default:
+		WriteErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+func isExemptPath(path string, exemptPaths []string) bool {
+	for _, exempt := range exemptPaths {
+		if path == exempt {
+			return true
+		}
+	}
+	return false
+}
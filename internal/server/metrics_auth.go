@@ -0,0 +1,78 @@
+package server
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// MetricsAuthMode selects how the metrics endpoint authenticates scrapers
+type MetricsAuthMode string
+
+const (
+	// MetricsAuthNone leaves the endpoint open, relying on the bind address
+	MetricsAuthNone MetricsAuthMode = "none"
+	// MetricsAuthBearer requires an Authorization: Bearer token
+	MetricsAuthBearer MetricsAuthMode = "bearer"
+	// MetricsAuthBasic requires HTTP basic credentials
+	MetricsAuthBasic MetricsAuthMode = "basic"
+)
+
+// MetricsAuthConfig holds the credentials accepted by the metrics endpoint
+type MetricsAuthConfig struct {
+	Mode     MetricsAuthMode
+	Token    string
+	Username string
+	Password string
+}
+
+// MetricsAuthMiddleware rejects metrics scrapes that do not carry the
+// configured credentials. With MetricsAuthNone every request passes.
+func MetricsAuthMiddleware(config MetricsAuthConfig) Middleware {
+	return func(next http.Handler) http.Handler {
+		if config.Mode == "" || config.Mode == MetricsAuthNone {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if config.authorized(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if config.Mode == MetricsAuthBasic {
+				w.Header().Set("WWW-Authenticate", `Basic realm="metrics"`)
+			} else {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="metrics"`)
+			}
+			WriteErrorResponse(w, http.StatusUnauthorized, "Authentication required")
+		})
+	}
+}
+
+func (c MetricsAuthConfig) authorized(r *http.Request) bool {
+	switch c.Mode {
+	case MetricsAuthBearer:
+		header := r.Header.Get("Authorization")
+		if !strings.HasPrefix(header, "Bearer ") || c.Token == "" {
+			return false
+		}
+		return secureEqual(strings.TrimPrefix(header, "Bearer "), c.Token)
+	case MetricsAuthBasic:
+		username, password, ok := r.BasicAuth()
+		if !ok || c.Username == "" {
+			return false
+		}
+		// Evaluate both comparisons to avoid leaking which one failed
+		userOK := secureEqual(username, c.Username)
+		passOK := secureEqual(password, c.Password)
+		return userOK && passOK
+	default:
+		return false
+	}
+}
+
+// secureEqual compares secrets in constant time
+func secureEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
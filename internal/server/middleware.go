@@ -8,7 +8,7 @@ import (
 	"net/http"
 	"runtime/debug"
 	"strings"
-	"sync"
+	"sync/atomic"
 	"time"
 
 	"parental-control/internal/logging"
@@ -54,6 +54,12 @@ func (mc *MiddlewareChain) ThenFunc(handlerFunc http.HandlerFunc) http.Handler {
 func RequestIDMiddleware() Middleware {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Keep an ID assigned further up the chain so logs stay correlated
+			if _, ok := r.Context().Value(requestIDKey).(string); ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
 			requestID := generateRequestID()
 			ctx := context.WithValue(r.Context(), requestIDKey, requestID)
 			r = r.WithContext(ctx)
@@ -105,12 +111,31 @@ func LoggingMiddleware() Middleware {
 	}
 }
 
+// recoveredPanics counts handler panics recovered by RecoveryMiddleware
+var recoveredPanics int64
+
+// RecoveredPanicCount returns the number of handler panics recovered since startup
+func RecoveredPanicCount() int64 {
+	return atomic.LoadInt64(&recoveredPanics)
+}
+
 // RecoveryMiddleware recovers from panics and returns a 500 error
 func RecoveryMiddleware() Middleware {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rw := &responseWriter{
+				ResponseWriter: w,
+				statusCode:     http.StatusOK,
+			}
+
 			defer func() {
 				if err := recover(); err != nil {
+					// Let net/http handle deliberate aborts
+					if err == http.ErrAbortHandler {
+						panic(err)
+					}
+
+					atomic.AddInt64(&recoveredPanics, 1)
 					requestID := getRequestID(r.Context())
 
 					logging.Error("HTTP request panic recovered",
@@ -121,90 +146,68 @@ func RecoveryMiddleware() Middleware {
 						logging.String("stack", string(debug.Stack())),
 					)
 
-					http.Error(w, "Internal server error", http.StatusInternalServerError)
+					// Nothing sensible can be sent once the response has started
+					if rw.wroteHeader {
+						return
+					}
+
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusInternalServerError)
+					json.NewEncoder(w).Encode(ErrorResponse{
+						Error:     http.StatusText(http.StatusInternalServerError),
+						Message:   "Internal server error",
+						Code:      http.StatusInternalServerError,
+						RequestID: requestID,
+					})
 				}
 			}()
 
-			next.ServeHTTP(w, r)
+			next.ServeHTTP(rw, r)
 		})
 	}
 }
 
-// CORSMiddleware handles Cross-Origin Resource Sharing
+// CORSMiddleware allows cross-origin requests from allowedOrigins using the
+// default methods and headers
 func CORSMiddleware(allowedOrigins []string) Middleware {
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			origin := r.Header.Get("Origin")
-
-			// Check if origin is allowed
-			if isOriginAllowed(origin, allowedOrigins) {
-				w.Header().Set("Access-Control-Allow-Origin", origin)
-			}
-
-			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
-			w.Header().Set("Access-Control-Allow-Credentials", "true")
-			w.Header().Set("Access-Control-Max-Age", "3600")
-
-			// Handle preflight requests
-			if r.Method == http.MethodOptions {
-				w.WriteHeader(http.StatusOK)
-				return
-			}
-
-			next.ServeHTTP(w, r)
-		})
-	}
+	config := DefaultCORSConfig()
+	config.AllowedOrigins = allowedOrigins
+	return CORSMiddlewareWithConfig(config)
 }
 
-// SecurityHeadersMiddleware adds security headers
+// SecurityHeadersMiddleware adds security headers using the default policy
 func SecurityHeadersMiddleware() Middleware {
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Content Security Policy
-			w.Header().Set("Content-Security-Policy",
-				"default-src 'self'; "+
-					"script-src 'self' 'unsafe-inline'; "+
-					"style-src 'self' 'unsafe-inline'; "+
-					"img-src 'self' data:; "+
-					"font-src 'self'; "+
-					"connect-src 'self'")
-
-			// Other security headers
-			w.Header().Set("X-Content-Type-Options", "nosniff")
-			w.Header().Set("X-Frame-Options", "DENY")
-			w.Header().Set("X-XSS-Protection", "1; mode=block")
-			w.Header().Set("Referrer-Policy", "strict-origin-when-cross-origin")
-
-			// Only add HSTS for HTTPS
-			if r.TLS != nil {
-				w.Header().Set("Strict-Transport-Security", "max-age=31536000; includeSubDomains")
-			}
-
-			next.ServeHTTP(w, r)
-		})
-	}
+	return SecurityHeadersMiddlewareWithConfig(DefaultSecurityHeadersConfig())
 }
 
-// RateLimitMiddleware implements rate limiting
-func RateLimitMiddleware(requestsPerMinute int) Middleware {
-	limiter := newRateLimiter(requestsPerMinute)
+// SecurityHeadersMiddlewareWithConfig adds the configured security headers.
+// HSTS is only sent on requests that arrived over HTTPS.
+func SecurityHeadersMiddlewareWithConfig(config SecurityHeadersConfig) Middleware {
+	hsts := config.hstsValue()
+	isSecure := config.IsSecure
+	if isSecure == nil {
+		isSecure = func(r *http.Request) bool { return r.TLS != nil }
+	}
 
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			clientIP := getClientIP(r)
-
-			if !limiter.Allow(clientIP) {
-				requestID := getRequestID(r.Context())
-				logging.Warn("Rate limit exceeded",
-					logging.String("request_id", requestID),
-					logging.String("client_ip", clientIP),
-					logging.String("path", r.URL.Path),
-				)
+			if config.ContentSecurityPolicy != "" {
+				w.Header().Set("Content-Security-Policy", config.ContentSecurityPolicy)
+			}
+			if config.ContentTypeNosniff {
+				w.Header().Set("X-Content-Type-Options", "nosniff")
+			}
+			if config.FrameOptions != "" {
+				w.Header().Set("X-Frame-Options", config.FrameOptions)
+			}
+			if config.ReferrerPolicy != "" {
+				w.Header().Set("Referrer-Policy", config.ReferrerPolicy)
+			}
+			w.Header().Set("X-XSS-Protection", "1; mode=block")
 
-				w.Header().Set("Retry-After", "60")
-				http.Error(w, "Rate limit exceeded", http.StatusTooManyRequests)
-				return
+			// Only add HSTS for HTTPS
+			if hsts != "" && isSecure(r) {
+				w.Header().Set("Strict-Transport-Security", hsts)
 			}
 
 			next.ServeHTTP(w, r)
@@ -269,21 +272,11 @@ func JSONMiddleware() Middleware {
 	}
 }
 
-// ContentLengthMiddleware validates request content length
+// ContentLengthMiddleware limits the request bodies of a route to maxBytes,
+// refusing requests that declare a larger body up front. Inside the server's
+// global limit it replaces that limit for the route.
 func ContentLengthMiddleware(maxBytes int64) Middleware {
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			if r.ContentLength > maxBytes {
-				http.Error(w, "Request entity too large", http.StatusRequestEntityTooLarge)
-				return
-			}
-
-			// Limit the request body reader
-			r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
-
-			next.ServeHTTP(w, r)
-		})
-	}
+	return BodyLimitMiddleware(maxBytes, true)
 }
 
 // IPWhitelistMiddleware restricts access to allowed IP ranges
@@ -348,81 +341,24 @@ type responseWriter struct {
 	http.ResponseWriter
 	statusCode   int
 	bytesWritten int
+	wroteHeader  bool
 }
 
 func (rw *responseWriter) WriteHeader(code int) {
 	rw.statusCode = code
+	rw.wroteHeader = true
 	rw.ResponseWriter.WriteHeader(code)
 }
 
 func (rw *responseWriter) Write(data []byte) (int, error) {
+	rw.wroteHeader = true
 	rw.bytesWritten += len(data)
 	return rw.ResponseWriter.Write(data)
 }
 
-// Rate limiter implementation
-type rateLimiter struct {
-	requests map[string]*clientRequests
-	mu       sync.RWMutex
-	limit    int
-}
-
-type clientRequests struct {
-	count     int
-	resetTime time.Time
-}
-
-func newRateLimiter(requestsPerMinute int) *rateLimiter {
-	rl := &rateLimiter{
-		requests: make(map[string]*clientRequests),
-		limit:    requestsPerMinute,
-	}
-
-	// Start cleanup goroutine
-	go rl.cleanup()
-
-	return rl
-}
-
-func (rl *rateLimiter) Allow(clientIP string) bool {
-	rl.mu.Lock()
-	defer rl.mu.Unlock()
-
-	now := time.Now()
-	client, exists := rl.requests[clientIP]
-
-	if !exists || now.After(client.resetTime) {
-		rl.requests[clientIP] = &clientRequests{
-			count:     1,
-			resetTime: now.Add(time.Minute),
-		}
-		return true
-	}
-
-	if client.count >= rl.limit {
-		return false
-	}
-
-	client.count++
-	return true
-}
-
-func (rl *rateLimiter) cleanup() {
-	ticker := time.NewTicker(time.Minute)
-	defer ticker.Stop()
-
-	for range ticker.C {
-		rl.mu.Lock()
-		now := time.Now()
-
-		for ip, client := range rl.requests {
-			if now.After(client.resetTime) {
-				delete(rl.requests, ip)
-			}
-		}
-
-		rl.mu.Unlock()
-	}
+// Unwrap exposes the underlying writer to http.ResponseController
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
 }
 
 // Utility functions
@@ -451,32 +387,15 @@ func getClientIP(r *http.Request) string {
 	}
 
 	// Fall back to RemoteAddr
-	host, _, err := net.SplitHostPort(r.RemoteAddr)
-	if err != nil {
-		return r.RemoteAddr
-	}
-	return host
-}
-
-func isOriginAllowed(origin string, allowedOrigins []string) bool {
-	if len(allowedOrigins) == 0 {
-		return false
-	}
-
-	for _, allowed := range allowedOrigins {
-		if allowed == "*" || allowed == origin {
-			return true
-		}
-	}
-
-	return false
+	return RemoteHost(r.RemoteAddr)
 }
 
 // ErrorResponse represents a standard API error response
 type ErrorResponse struct {
-	Error   string `json:"error"`
-	Message string `json:"message,omitempty"`
-	Code    int    `json:"code"`
+	Error     string `json:"error"`
+	Message   string `json:"message,omitempty"`
+	Code      int    `json:"code"`
+	RequestID string `json:"request_id,omitempty"`
 }
 
 // WriteErrorResponse writes a standardized error response
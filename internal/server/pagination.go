@@ -0,0 +1,75 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// MaxPageLimit caps the limit query parameter so a client cannot request an
+// unbounded number of rows
+const MaxPageLimit = 1000
+
+// PageParams is the page of a list selected by the limit and offset query
+// parameters
+type PageParams struct {
+	Limit  int
+	Offset int
+}
+
+// PagedResponse is the standard shape of a paginated list response
+type PagedResponse[T any] struct {
+	Items   []T  `json:"items"`
+	Total   int  `json:"total"`
+	Limit   int  `json:"limit"`
+	Offset  int  `json:"offset"`
+	HasMore bool `json:"has_more"`
+}
+
+// ParsePageParams reads the limit and offset query parameters. limit
+// defaults to defaultLimit and must be between 1 and MaxPageLimit; offset
+// defaults to 0 and must not be negative.
+func ParsePageParams(r *http.Request, defaultLimit int) (PageParams, error) {
+	page := PageParams{Limit: defaultLimit}
+	query := r.URL.Query()
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 || limit > MaxPageLimit {
+			return page, fmt.Errorf("invalid limit: must be between 1 and %d", MaxPageLimit)
+		}
+		page.Limit = limit
+	}
+
+	if offsetStr := query.Get("offset"); offsetStr != "" {
+		offset, err := strconv.Atoi(offsetStr)
+		if err != nil || offset < 0 {
+			return page, fmt.Errorf("invalid offset: must be non-negative")
+		}
+		page.Offset = offset
+	}
+
+	return page, nil
+}
+
+// NewPagedResponse wraps one page of items, already selected by page, out of
+// total
+func NewPagedResponse[T any](items []T, total int, page PageParams) PagedResponse[T] {
+	if items == nil {
+		items = []T{}
+	}
+	return PagedResponse[T]{
+		Items:   items,
+		Total:   total,
+		Limit:   page.Limit,
+		Offset:  page.Offset,
+		HasMore: page.Offset+len(items) < total,
+	}
+}
+
+// Paginate selects page from a complete in-memory list
+func Paginate[T any](all []T, page PageParams) PagedResponse[T] {
+	start := min(page.Offset, len(all))
+	end := min(start+page.Limit, len(all))
+	return NewPagedResponse(all[start:end], len(all), page)
+}
@@ -0,0 +1,53 @@
+package server
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParsePageParams(t *testing.T) {
+	tests := []struct {
+		query   string
+		want    PageParams
+		wantErr bool
+	}{
+		{query: "", want: PageParams{Limit: 25}},
+		{query: "?limit=10&offset=20", want: PageParams{Limit: 10, Offset: 20}},
+		{query: "?limit=1000", want: PageParams{Limit: 1000}},
+		{query: "?limit=0", wantErr: true},
+		{query: "?limit=1001", wantErr: true},
+		{query: "?limit=ten", wantErr: true},
+		{query: "?offset=-1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/api/v1/items"+tt.query, nil)
+		got, err := ParsePageParams(r, 25)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParsePageParams(%q) error = %v, wantErr %v", tt.query, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("ParsePageParams(%q) = %+v, want %+v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	all := []int{1, 2, 3, 4, 5}
+
+	page := Paginate(all, PageParams{Limit: 2, Offset: 2})
+	if len(page.Items) != 2 || page.Items[0] != 3 || page.Total != 5 || !page.HasMore {
+		t.Errorf("Unexpected middle page: %+v", page)
+	}
+
+	page = Paginate(all, PageParams{Limit: 2, Offset: 4})
+	if len(page.Items) != 1 || page.HasMore {
+		t.Errorf("Unexpected last page: %+v", page)
+	}
+
+	page = Paginate(all, PageParams{Limit: 2, Offset: 10})
+	if page.Items == nil || len(page.Items) != 0 || page.Total != 5 || page.HasMore {
+		t.Errorf("Expected an empty page past the end, got %+v", page)
+	}
+}
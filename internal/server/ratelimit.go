@@ -0,0 +1,192 @@
+package server
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"parental-control/internal/logging"
+)
+
+// RateLimitGroup limits the requests each client may make to the paths
+// under PathPrefix
+type RateLimitGroup struct {
+	// Name identifies the group in logs
+	Name string
+	// PathPrefix selects the requests the group covers
+	PathPrefix string
+	// Limit is the number of requests allowed per Window; 0 disables the group
+	Limit int
+	// Window is the period Limit applies to
+	Window time.Duration
+}
+
+// DefaultRateLimitGroups returns the limits applied when nothing is
+// configured: tight on the public authentication endpoints, loose on the
+// rest of the API
+func DefaultRateLimitGroups() []RateLimitGroup {
+	return []RateLimitGroup{
+		{Name: "auth", PathPrefix: "/api/v1/auth/", Limit: 30, Window: time.Minute},
+		{Name: "api", PathPrefix: "/api/", Limit: 600, Window: time.Minute},
+	}
+}
+
+// RateLimitMiddleware limits each client IP to limit requests per window.
+// Clients may use the whole allowance in a burst; it then refills evenly
+// over the window. Rejected requests get 429 with a Retry-After header.
+func RateLimitMiddleware(limit int, window time.Duration) Middleware {
+	return RateLimitGroupsMiddleware([]RateLimitGroup{
+		{Name: "default", PathPrefix: "/", Limit: limit, Window: window},
+	}, nil)
+}
+
+// RateLimitGroupsMiddleware applies the group with the longest matching path
+// prefix to each request, with a separate allowance per group and client IP.
+// Requests outside every group are not limited. Clients are told apart by
+// their peer address; forwarding headers count only when the peer is one of
+// trustedProxies.
+func RateLimitGroupsMiddleware(groups []RateLimitGroup, trustedProxies []string) Middleware {
+	type limitedGroup struct {
+		RateLimitGroup
+		limiter *rateLimiter
+	}
+
+	var active []limitedGroup
+	for _, group := range groups {
+		if group.Limit > 0 && group.Window > 0 {
+			active = append(active, limitedGroup{group, newRateLimiter(group.Limit, group.Window)})
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		if len(active) == 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var match *limitedGroup
+			for i := range active {
+				if strings.HasPrefix(r.URL.Path, active[i].PathPrefix) &&
+					(match == nil || len(active[i].PathPrefix) > len(match.PathPrefix)) {
+					match = &active[i]
+				}
+			}
+			if match == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			clientIP := forwardedClientIP(r, trustedProxies)
+			if ok, retryAfter := match.limiter.Allow(clientIP); !ok {
+				logging.Warn("Rate limit exceeded",
+					logging.String("request_id", getRequestID(r.Context())),
+					logging.String("client_ip", clientIP),
+					logging.String("group", match.Name),
+					logging.String("path", r.URL.Path),
+				)
+
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+				WriteErrorResponse(w, http.StatusTooManyRequests, "Rate limit exceeded")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// forwardedClientIP returns the peer address of r or, when the peer is a
+// trusted proxy, the client it forwarded the request for. Any other peer
+// could put anything in the forwarding headers.
+func forwardedClientIP(r *http.Request, trustedProxies []string) string {
+	peer := RemoteHost(r.RemoteAddr)
+	if !proxyListContains(trustedProxies, peer) {
+		return peer
+	}
+
+	// Proxies append to X-Forwarded-For, so the client is the last hop that
+	// is not a trusted proxy; earlier hops came from the client itself
+	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+		hops := strings.Split(xff, ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if i == 0 || !proxyListContains(trustedProxies, hop) {
+				return hop
+			}
+		}
+	}
+
+	if xri := strings.TrimSpace(r.Header.Get("X-Real-IP")); xri != "" {
+		return xri
+	}
+	return peer
+}
+
+// rateLimiter keeps a token bucket per client. Each bucket holds up to limit
+// tokens and refills at limit per window; a request takes one token.
+type rateLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	limit     float64
+	rate      float64 // tokens per second
+	window    time.Duration
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(limit int, window time.Duration) *rateLimiter {
+	return &rateLimiter{
+		buckets:   make(map[string]*tokenBucket),
+		limit:     float64(limit),
+		rate:      float64(limit) / window.Seconds(),
+		window:    window,
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+}
+
+// Allow takes a token from the client's bucket. When the bucket is empty it
+// returns false and how long until the next token.
+func (rl *rateLimiter) Allow(clientIP string) (bool, time.Duration) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := rl.now()
+	rl.sweep(now)
+
+	bucket, exists := rl.buckets[clientIP]
+	if !exists {
+		bucket = &tokenBucket{tokens: rl.limit, last: now}
+		rl.buckets[clientIP] = bucket
+	} else {
+		bucket.tokens = math.Min(rl.limit, bucket.tokens+now.Sub(bucket.last).Seconds()*rl.rate)
+		bucket.last = now
+	}
+
+	if bucket.tokens < 1 {
+		return false, time.Duration((1 - bucket.tokens) / rl.rate * float64(time.Second))
+	}
+	bucket.tokens--
+	return true, 0
+}
+
+// sweep forgets clients idle for a whole window, whose buckets are full again
+func (rl *rateLimiter) sweep(now time.Time) {
+	if now.Sub(rl.lastSweep) < rl.window {
+		return
+	}
+	rl.lastSweep = now
+
+	for ip, bucket := range rl.buckets {
+		if now.Sub(bucket.last) >= rl.window {
+			delete(rl.buckets, ip)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultContentSecurityPolicy is restrictive enough for the bundled web UI:
+// everything loads from the same origin and the UI cannot be framed.
+const DefaultContentSecurityPolicy = "default-src 'self'; " +
+	"script-src 'self' 'unsafe-inline'; " +
+	"style-src 'self' 'unsafe-inline'; " +
+	"img-src 'self' data:; " +
+	"font-src 'self'; " +
+	"connect-src 'self'; " +
+	"object-src 'none'; " +
+	"base-uri 'self'; " +
+	"form-action 'self'; " +
+	"frame-ancestors 'none'"
+
+// SecurityHeadersConfig controls the security headers added to responses
+type SecurityHeadersConfig struct {
+	// HSTSMaxAge for Strict-Transport-Security (0 disables HSTS)
+	HSTSMaxAge time.Duration
+	// HSTSIncludeSubdomains adds includeSubDomains to the HSTS header
+	HSTSIncludeSubdomains bool
+	// HSTSPreload adds the preload directive to the HSTS header
+	HSTSPreload bool
+	// ContentSecurityPolicy header value (empty omits the header)
+	ContentSecurityPolicy string
+	// FrameOptions is DENY, SAMEORIGIN or empty to omit X-Frame-Options
+	FrameOptions string
+	// ReferrerPolicy header value (empty omits the header)
+	ReferrerPolicy string
+	// ContentTypeNosniff sends X-Content-Type-Options: nosniff
+	ContentTypeNosniff bool
+	// IsSecure reports whether a request arrived over HTTPS; nil checks r.TLS
+	IsSecure func(r *http.Request) bool
+}
+
+// DefaultSecurityHeadersConfig returns the headers sent when nothing is configured
+func DefaultSecurityHeadersConfig() SecurityHeadersConfig {
+	return SecurityHeadersConfig{
+		HSTSMaxAge:            365 * 24 * time.Hour,
+		HSTSIncludeSubdomains: true,
+		HSTSPreload:           false,
+		ContentSecurityPolicy: DefaultContentSecurityPolicy,
+		FrameOptions:          "DENY",
+		ReferrerPolicy:        "strict-origin-when-cross-origin",
+		ContentTypeNosniff:    true,
+	}
+}
+
+// hstsValue renders the Strict-Transport-Security header value
+func (c SecurityHeadersConfig) hstsValue() string {
+	if c.HSTSMaxAge <= 0 {
+		return ""
+	}
+
+	parts := []string{fmt.Sprintf("max-age=%d", int64(c.HSTSMaxAge/time.Second))}
+	if c.HSTSIncludeSubdomains {
+		parts = append(parts, "includeSubDomains")
+	}
+	if c.HSTSPreload {
+		parts = append(parts, "preload")
+	}
+	return strings.Join(parts, "; ")
+}
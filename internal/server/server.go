@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"os"
 	"sync"
 	"time"
 
@@ -36,21 +37,43 @@ type Config struct {
 	EnableCompression bool
 	// TLS configuration
 	TLS TLSConfig
+	// Cookie attributes for session cookies
+	Cookie CookieConfig
+	// SecurityHeaders added to every response
+	SecurityHeaders SecurityHeadersConfig
+	// CORS settings for browsers calling the API from other origins
+	CORS CORSConfig
+	// RateLimits per route group and client IP
+	RateLimits []RateLimitGroup
+	// MaxBodyBytes limits request bodies unless a route allows more
+	MaxBodyBytes int64
+	// MaxImportBodyBytes limits uploaded block lists
+	MaxImportBodyBytes int64
+	// SocketPath serves the web interface on a Unix socket instead of a TCP port
+	SocketPath string
+	// SocketMode sets the socket file permissions (DefaultSocketMode if 0)
+	SocketMode os.FileMode
 }
 
 // DefaultConfig returns server configuration with sensible defaults
 func DefaultConfig() Config {
 	return Config{
-		Port:              8080,
-		BindToLAN:         true,
-		AllowedInterfaces: []string{},
-		ReadTimeout:       15 * time.Second,
-		WriteTimeout:      15 * time.Second,
-		IdleTimeout:       60 * time.Second,
-		MaxHeaderBytes:    1 << 20, // 1 MB
-		StaticFileRoot:    "./web/build",
-		EnableCompression: true,
-		TLS:               DefaultTLSConfig(),
+		Port:               8080,
+		BindToLAN:          true,
+		AllowedInterfaces:  []string{},
+		ReadTimeout:        15 * time.Second,
+		WriteTimeout:       15 * time.Second,
+		IdleTimeout:        60 * time.Second,
+		MaxHeaderBytes:     1 << 20, // 1 MB
+		StaticFileRoot:     "./web/build",
+		EnableCompression:  true,
+		TLS:                DefaultTLSConfig(),
+		Cookie:             DefaultCookieConfig(),
+		SecurityHeaders:    DefaultSecurityHeadersConfig(),
+		CORS:               DefaultCORSConfig(),
+		RateLimits:         DefaultRateLimitGroups(),
+		MaxBodyBytes:       DefaultMaxBodyBytes,
+		MaxImportBodyBytes: DefaultMaxImportBodyBytes,
 	}
 }
 
@@ -63,9 +86,12 @@ type Server struct {
 	tlsListener net.Listener
 	mux         *http.ServeMux
 	tlsManager  *TLSManager
+	maintenance *MaintenanceMode
 	mu          sync.RWMutex
 	running     bool
 	startTime   time.Time
+	conns       *connTracker
+	draining    chan struct{}
 }
 
 // HealthStatus represents the server health information
@@ -82,9 +108,12 @@ func New(config Config) *Server {
 	mux := http.NewServeMux()
 
 	server := &Server{
-		config:     config,
-		mux:        mux,
-		tlsManager: NewTLSManager(config.TLS),
+		config:      config,
+		mux:         mux,
+		tlsManager:  NewTLSManager(config.TLS),
+		maintenance: NewMaintenanceMode(),
+		conns:       newConnTracker(),
+		draining:    make(chan struct{}),
 	}
 
 	// Register built-in endpoints
@@ -102,8 +131,19 @@ func (s *Server) Start(ctx context.Context) error {
 		return fmt.Errorf("server is already running")
 	}
 
+	if s.config.SocketPath != "" && s.config.TLS.Enabled {
+		return fmt.Errorf("TLS is not supported on a Unix socket; terminate TLS at the proxy")
+	}
+
 	s.startTime = time.Now()
 
+	// A restarted server needs a fresh drain signal
+	select {
+	case <-s.draining:
+		s.draining = make(chan struct{})
+	default:
+	}
+
 	// Start HTTPS server if TLS is enabled
 	if s.config.TLS.Enabled {
 		if err := s.startHTTPSServer(); err != nil {
@@ -138,15 +178,6 @@ func (s *Server) Start(ctx context.Context) error {
 		s.Stop(context.Background()) // Fallback stop
 	}()
 
-	go func() {
-		if s.config.TLS.Enabled {
-			// Start HTTPS server if TLS is enabled
-			if err := s.startHTTPSServer(); err != nil {
-				logging.Error("HTTPS server start error", logging.Err(err))
-			}
-		}
-	}()
-
 	return nil
 }
 
@@ -163,10 +194,10 @@ func (s *Server) startHTTPSServer() error {
 		return fmt.Errorf("failed to get TLS config: %w", err)
 	}
 
-	// Create HTTPS listener
-	httpsPort := s.config.Port
-	if s.config.TLS.RedirectHTTP {
-		httpsPort = 8443 // Use different port for HTTPS when redirecting
+	// Create HTTPS listener beside the HTTP one
+	httpsPort := s.config.TLS.HTTPSPort
+	if httpsPort == 0 {
+		httpsPort = DefaultHTTPSPort
 	}
 
 	httpsAddr := fmt.Sprintf(":%d", httpsPort)
@@ -193,12 +224,13 @@ func (s *Server) startHTTPSServer() error {
 
 	// Create HTTPS server
 	s.httpsServer = &http.Server{
-		Handler:        s.mux,
+		Handler:        s.rootHandler(),
 		ReadTimeout:    s.config.ReadTimeout,
 		WriteTimeout:   s.config.WriteTimeout,
 		IdleTimeout:    s.config.IdleTimeout,
 		MaxHeaderBytes: s.config.MaxHeaderBytes,
 		TLSConfig:      tlsConfig,
+		ConnState:      s.conns.track,
 	}
 
 	// Start HTTPS server in goroutine
@@ -225,17 +257,18 @@ func (s *Server) startHTTPServer() error {
 	s.listener = listener
 
 	// Determine handler for HTTP server
-	var handler http.Handler = s.mux
+	handler := s.rootHandler()
 
-	// If TLS is enabled and redirect is configured, use redirect handler
+	// If TLS is enabled and redirect is configured, send everything but
+	// the plain HTTP endpoints to HTTPS
 	if s.config.TLS.Enabled && s.config.TLS.RedirectHTTP {
-		httpsPort := 8443
+		httpsPort := s.config.TLS.HTTPSPort
 		if s.tlsListener != nil {
 			if tcpAddr, ok := s.tlsListener.Addr().(*net.TCPAddr); ok {
 				httpsPort = tcpAddr.Port
 			}
 		}
-		handler = s.tlsManager.HTTPRedirectHandler(httpsPort)
+		handler = s.tlsManager.HTTPSRedirect(handler, httpsPort)
 	}
 
 	// Create HTTP server
@@ -245,6 +278,7 @@ func (s *Server) startHTTPServer() error {
 		WriteTimeout:   s.config.WriteTimeout,
 		IdleTimeout:    s.config.IdleTimeout,
 		MaxHeaderBytes: s.config.MaxHeaderBytes,
+		ConnState:      s.conns.track,
 	}
 
 	// Start HTTP server in goroutine
@@ -260,7 +294,10 @@ func (s *Server) startHTTPServer() error {
 	return nil
 }
 
-// Stop gracefully shuts down the HTTP and HTTPS servers
+// Stop gracefully shuts down the HTTP and HTTPS servers. It stops accepting
+// connections and waits for in-flight requests until ctx is done, or for
+// defaultShutdownTimeout if ctx has no deadline. Long-lived streams are told
+// to end through Draining. Connections still busy at the deadline are closed.
 func (s *Server) Stop(ctx context.Context) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -269,37 +306,60 @@ func (s *Server) Stop(ctx context.Context) error {
 		return nil
 	}
 
-	logging.Info("Shutting down servers")
+	active, idle := s.conns.counts()
+	logging.Info("Shutting down servers",
+		logging.Int("active_connections", active),
+		logging.Int("idle_connections", idle))
 
-	// Create shutdown context with timeout
-	shutdownCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
-	defer cancel()
+	close(s.draining)
 
-	var shutdownErrors []error
+	shutdownCtx := ctx
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		shutdownCtx, cancel = context.WithTimeout(ctx, defaultShutdownTimeout)
+		defer cancel()
+	}
 
-	// Shutdown HTTPS server if running
+	servers := map[string]*http.Server{}
 	if s.httpsServer != nil {
-		if err := s.httpsServer.Shutdown(shutdownCtx); err != nil {
-			logging.Error("HTTPS server shutdown error", logging.Err(err))
-			shutdownErrors = append(shutdownErrors, err)
-		}
-		s.httpsServer = nil
-		s.tlsListener = nil
+		servers["HTTPS"] = s.httpsServer
 	}
-
-	// Shutdown HTTP server if running
 	if s.httpServer != nil {
-		if err := s.httpServer.Shutdown(shutdownCtx); err != nil {
-			logging.Error("HTTP server shutdown error", logging.Err(err))
-			shutdownErrors = append(shutdownErrors, err)
-		}
-		s.httpServer = nil
-		s.listener = nil
+		servers["HTTP"] = s.httpServer
+	}
+
+	// Drain both servers at once so neither eats into the other's time
+	var (
+		wg             sync.WaitGroup
+		errMu          sync.Mutex
+		shutdownErrors []error
+	)
+	for name, srv := range servers {
+		wg.Add(1)
+		go func(name string, srv *http.Server) {
+			defer wg.Done()
+			if err := srv.Shutdown(shutdownCtx); err != nil {
+				logging.Error(name+" server shutdown error", logging.Err(err))
+				// Drop whatever is still running rather than leave it behind
+				srv.Close()
+				errMu.Lock()
+				shutdownErrors = append(shutdownErrors, err)
+				errMu.Unlock()
+			}
+		}(name, srv)
 	}
+	wg.Wait()
 
+	s.httpsServer = nil
+	s.tlsListener = nil
+	s.httpServer = nil
+	s.listener = nil
 	s.running = false
 
 	if len(shutdownErrors) > 0 {
+		active, _ := s.conns.counts()
+		logging.Warn("Shutdown timed out with requests in flight",
+			logging.Int("active_connections", active))
 		return fmt.Errorf("errors during server shutdown: %v", shutdownErrors)
 	}
 
@@ -349,6 +409,16 @@ func (s *Server) GetTLSInfo() (map[string]interface{}, error) {
 	return s.tlsManager.GetCertificateInfo()
 }
 
+// Maintenance returns the server's maintenance mode controller
+func (s *Server) Maintenance() *MaintenanceMode {
+	return s.maintenance
+}
+
+// GetCookieConfig returns the session cookie configuration
+func (s *Server) GetCookieConfig() CookieConfig {
+	return s.config.Cookie
+}
+
 // AddHandler adds a new HTTP handler to the server
 func (s *Server) AddHandler(pattern string, handler http.Handler) {
 	s.mux.Handle(pattern, handler)
@@ -373,10 +443,18 @@ func (s *Server) SetupStaticFileServer(fileSystem fs.FS, authMiddleware *AuthMid
 
 	// If auth is enabled, protect the static file server
 	if authMiddleware != nil {
-		// Protect all routes except for the root (which should be public)
-		// The static file server will handle redirecting to /login
+		// Protect all routes except for the root, the login page and the
+		// assets they load, so a signed-out browser can reach the login form
 		authMiddleware.AddPublicPath("/")
-		protectedStaticServer = authMiddleware.RequireAuth()(staticServer)
+		authMiddleware.AddPublicPath("/login")
+		protected := authMiddleware.RequireAuth()(staticServer)
+		protectedStaticServer = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if staticServer.isStaticAsset(r.URL.Path) {
+				staticServer.ServeHTTP(w, r)
+				return
+			}
+			protected.ServeHTTP(w, r)
+		})
 	}
 
 	// Register the static file server for all unmatched routes
@@ -392,6 +470,10 @@ func (s *Server) SetupStaticFileServer(fileSystem fs.FS, authMiddleware *AuthMid
 
 // createListener creates the appropriate network listener based on configuration
 func (s *Server) createListener() (net.Listener, error) {
+	if s.config.SocketPath != "" {
+		return s.createUnixListener()
+	}
+
 	if s.config.BindToLAN {
 		return s.createLANListener()
 	}
@@ -504,6 +586,52 @@ func (s *Server) isPrivateIP(ip net.IP) bool {
 	return false
 }
 
+// Handler returns the handler the server listens with, for serving it
+// without a listener
+func (s *Server) Handler() http.Handler {
+	return s.rootHandler()
+}
+
+// rootHandler wraps the mux so every request gets a request ID and panic recovery
+func (s *Server) rootHandler() http.Handler {
+	headers := s.config.SecurityHeaders
+	if headers.IsSecure == nil {
+		// Match the cookie logic so HSTS also works behind a TLS proxy
+		headers.IsSecure = s.config.Cookie.IsSecureRequest
+	}
+
+	return NewMiddlewareChain(
+		RequestIDMiddleware(),
+		RecoveryMiddleware(),
+		drainMiddleware(s.draining),
+		SecurityHeadersMiddlewareWithConfig(headers),
+		CORSMiddlewareWithConfig(s.config.CORS),
+		RateLimitGroupsMiddleware(s.config.RateLimits, s.config.Cookie.TrustedProxies),
+		BodyLimitMiddleware(s.MaxBodyBytes(), false),
+		MaintenanceMiddleware(s.maintenance,
+			maintenanceEndpoint,
+			"/api/v1/auth/login",
+			"/api/v1/auth/logout",
+		),
+	).Then(s.mux)
+}
+
+// MaxBodyBytes returns the global request body limit
+func (s *Server) MaxBodyBytes() int64 {
+	if s.config.MaxBodyBytes <= 0 {
+		return DefaultMaxBodyBytes
+	}
+	return s.config.MaxBodyBytes
+}
+
+// MaxImportBodyBytes returns the body limit for uploaded block lists
+func (s *Server) MaxImportBodyBytes() int64 {
+	if s.config.MaxImportBodyBytes <= 0 {
+		return DefaultMaxImportBodyBytes
+	}
+	return s.config.MaxImportBodyBytes
+}
+
 // registerBuiltinHandlers registers the server's built-in endpoints
 func (s *Server) registerBuiltinHandlers() {
 	s.mux.HandleFunc("/health", s.handleHealth)
@@ -567,6 +695,8 @@ func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
 	if s.running {
 		status["uptime"] = time.Since(s.startTime).String()
 	}
+	status["recovered_panics"] = RecoveredPanicCount()
+	status["maintenance"] = s.maintenance.Status()
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(status)
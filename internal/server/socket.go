@@ -0,0 +1,69 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+// DefaultSocketMode lets the owner and group, e.g. a reverse proxy, connect
+const DefaultSocketMode os.FileMode = 0660
+
+// localClientIP stands in for the client address of Unix socket peers, which
+// are processes on this host
+const localClientIP = "127.0.0.1"
+
+// createUnixListener listens on the configured Unix socket. A socket left
+// behind by an unclean exit is removed, but only if nothing answers on it.
+func (s *Server) createUnixListener() (net.Listener, error) {
+	path := s.config.SocketPath
+
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("socket %s is already in use", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket %s: %w", path, err)
+		}
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+
+	mode := s.config.SocketMode
+	if mode == 0 {
+		mode = DefaultSocketMode
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to set socket permissions: %w", err)
+	}
+
+	return listener, nil
+}
+
+// RemoteHost returns the host part of a request's RemoteAddr. Requests over
+// a Unix socket have no TCP address and are reported as local.
+func RemoteHost(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err == nil {
+		return host
+	}
+	if isUnixPeer(remoteAddr) {
+		return localClientIP
+	}
+	return remoteAddr
+}
+
+// isUnixPeer reports whether a RemoteAddr belongs to a Unix socket peer, which
+// is unnamed ("" or "@") or a socket path
+func isUnixPeer(remoteAddr string) bool {
+	return remoteAddr == "" || remoteAddr == "@" || strings.HasPrefix(remoteAddr, "/")
+}
@@ -0,0 +1,99 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestServer_UnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "web.sock")
+
+	// A socket left behind by a previous run is replaced
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("Failed to create stale socket: %v", err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	config := DefaultConfig()
+	config.SocketPath = path
+	config.SocketMode = 0600
+	config.RateLimits = nil
+
+	srv := New(config)
+	srv.AddHandlerFunc("/whoami", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(getClientIP(r)))
+	})
+
+	if err := srv.Start(context.Background()); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	defer srv.Stop(context.Background())
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Socket not created: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("Expected socket mode 0600, got %o", perm)
+	}
+	if srv.GetAddress() != path {
+		t.Errorf("Expected address %s, got %s", path, srv.GetAddress())
+	}
+
+	client := &http.Client{
+		Timeout: 5 * time.Second,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", path)
+			},
+		},
+	}
+
+	resp, err := client.Get("http://unix/whoami")
+	if err != nil {
+		t.Fatalf("Request over socket failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || string(body) != localClientIP {
+		t.Errorf("Expected 200 %q, got %d %q", localClientIP, resp.StatusCode, body)
+	}
+
+	// A second server must not take over a live socket
+	if err := New(config).Start(context.Background()); err == nil {
+		t.Error("Expected a second server on the same socket to fail")
+	}
+
+	if err := srv.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop returned error: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected socket to be removed on stop, got %v", err)
+	}
+}
+
+func TestRemoteHost(t *testing.T) {
+	tests := map[string]string{
+		"192.168.1.10:1234": "192.168.1.10",
+		"[::1]:8080":        "::1",
+		"@":                 localClientIP,
+		"":                  localClientIP,
+		"/run/web.sock":     localClientIP,
+		"garbage":           "garbage",
+	}
+
+	for remoteAddr, want := range tests {
+		if got := RemoteHost(remoteAddr); got != want {
+			t.Errorf("RemoteHost(%q) = %q, want %q", remoteAddr, got, want)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"parental-control/internal/logging"
+	"parental-control/internal/service"
+)
+
+// Server-sent event stream limits shared by the live streams
+const (
+	sseKeepAliveInterval = 15 * time.Second
+	sseWriteTimeout      = 10 * time.Second
+	sseMaxDuration       = 30 * time.Minute
+)
+
+// sseStream writes server-sent events, each with a bounded write deadline
+type sseStream struct {
+	w  http.ResponseWriter
+	rc *http.ResponseController
+}
+
+// startSSE sends the headers of an event stream
+func startSSE(w http.ResponseWriter) *sseStream {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	return &sseStream{w: w, rc: http.NewResponseController(w)}
+}
+
+// event writes a single event with data encoded as JSON
+func (s *sseStream) event(name string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		logging.Error("Failed to encode stream event", logging.Err(err))
+		return err
+	}
+	return s.write(fmt.Sprintf("event: %s\ndata: %s\n\n", name, payload))
+}
+
+// retry tells EventSource how soon to reconnect after the stream ends
+func (s *sseStream) retry(interval time.Duration) error {
+	return s.write(fmt.Sprintf("retry: %d\n\n", interval.Milliseconds()))
+}
+
+// keepAlive writes a comment so proxies do not time out an idle stream
+func (s *sseStream) keepAlive() error {
+	return s.write(": keep-alive\n\n")
+}
+
+func (s *sseStream) write(text string) error {
+	s.rc.SetWriteDeadline(time.Now().Add(sseWriteTimeout))
+	if _, err := fmt.Fprint(s.w, text); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
+
+// closeStream announces why the server is ending the stream
+func (s *sseStream) closeStream(reason string) {
+	s.event("close", map[string]interface{}{"reason": reason})
+}
+
+// streamSubscription writes each value sub receives with send until the
+// client goes away, the server drains, the stream reaches sseMaxDuration or
+// the subscriber falls too far behind. name identifies the stream in logs.
+func streamSubscription[T any](r *http.Request, stream *sseStream, sub *service.Subscription[T], name string, send func(T) error) {
+	keepAlive := time.NewTicker(sseKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	draining := Draining(r.Context())
+
+	deadline := time.NewTimer(sseMaxDuration)
+	defer deadline.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-draining:
+			stream.closeStream("shutdown")
+			return
+		case <-deadline.C:
+			stream.closeStream("max_duration")
+			return
+		case <-keepAlive.C:
+			if err := stream.keepAlive(); err != nil {
+				return
+			}
+		case value, ok := <-sub.Events():
+			if !ok {
+				if sub.Dropped() {
+					logging.Warn("Dropping slow "+name+" client",
+						logging.String("remote_addr", r.RemoteAddr))
+					stream.closeStream("slow_consumer")
+				}
+				return
+			}
+			if err := send(value); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"crypto/x509/pkix"
 	"encoding/pem"
 	"fmt"
+	"html"
 	"math/big"
 	"net"
 	"net/http"
@@ -43,8 +44,17 @@ type TLSConfig struct {
 	RedirectHTTP bool
 	// HTTPPort port for HTTP server (for redirects)
 	HTTPPort int
+	// HTTPSPort port for the HTTPS server; 0 uses DefaultHTTPSPort
+	HTTPSPort int
 }
 
+// DefaultHTTPSPort is the HTTPS port used when none is configured
+const DefaultHTTPSPort = 8443
+
+// PlainHTTPPaths are served over HTTP even when HTTP redirects to HTTPS, so
+// health checks and monitoring keep working without certificates
+var PlainHTTPPaths = []string{"/health", "/status"}
+
 // DefaultTLSConfig returns TLS configuration with sensible defaults
 func DefaultTLSConfig() TLSConfig {
 	return TLSConfig{
@@ -59,6 +69,7 @@ func DefaultTLSConfig() TLSConfig {
 		MinTLSVersion: tls.VersionTLS12,
 		RedirectHTTP:  false,
 		HTTPPort:      8080,
+		HTTPSPort:     DefaultHTTPSPort,
 	}
 }
 
@@ -94,8 +105,21 @@ func (tm *TLSManager) EnsureCertificates() error {
 		}
 	}
 
-	// Auto-generate certificates if enabled
+	// Auto-generate certificates if enabled, keeping a generated pair until
+	// it expires or no longer names the configured host and addresses
 	if tm.config.AutoGenerate {
+		if tm.certificatesExist() {
+			err := tm.validateCertificates()
+			if err == nil {
+				err = tm.checkSubjectNames()
+			}
+			if err == nil {
+				logging.Info("Using existing self-signed TLS certificate",
+					logging.String("cert_file", tm.getCertPath()))
+				return nil
+			}
+			logging.Info("Regenerating self-signed TLS certificate", logging.Err(err))
+		}
 		return tm.generateCertificates()
 	}
 
@@ -168,6 +192,48 @@ func (tm *TLSManager) validateCertificates() error {
 	return nil
 }
 
+// checkSubjectNames checks that the certificate covers the configured
+// hostname and IP addresses
+func (tm *TLSManager) checkSubjectNames() error {
+	cert, err := tm.loadCertificate()
+	if err != nil {
+		return err
+	}
+
+	names := []string{tm.config.Hostname}
+	for _, ip := range tm.config.IPAddresses {
+		names = append(names, ip.String())
+	}
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+		if err := cert.VerifyHostname(name); err != nil {
+			return fmt.Errorf("certificate does not cover %s", name)
+		}
+	}
+	return nil
+}
+
+// loadCertificate parses the certificate file
+func (tm *TLSManager) loadCertificate() (*x509.Certificate, error) {
+	certPEM, err := os.ReadFile(tm.getCertPath())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read certificate: %w", err)
+	}
+
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		return nil, fmt.Errorf("failed to parse certificate PEM")
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate: %w", err)
+	}
+	return cert, nil
+}
+
 // generateCertificates generates new self-signed certificates
 func (tm *TLSManager) generateCertificates() error {
 	logging.Info("Generating self-signed TLS certificates")
@@ -183,9 +249,16 @@ func (tm *TLSManager) generateCertificates() error {
 		return fmt.Errorf("failed to generate private key: %w", err)
 	}
 
+	// Browsers reject a certificate reusing the serial of one they have seen
+	// from the same issuer, so every regenerated certificate gets a new one
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return fmt.Errorf("failed to generate serial number: %w", err)
+	}
+
 	// Create certificate template
 	template := x509.Certificate{
-		SerialNumber: big.NewInt(1),
+		SerialNumber: serialNumber,
 		Subject: pkix.Name{
 			Organization:  []string{"Parental Control"},
 			Country:       []string{"US"},
@@ -236,34 +309,24 @@ func (tm *TLSManager) generateCertificates() error {
 		return fmt.Errorf("failed to create certificate: %w", err)
 	}
 
-	// Save certificate
-	certPath := tm.getCertPath()
-	certOut, err := os.Create(certPath)
-	if err != nil {
-		return fmt.Errorf("failed to create certificate file: %w", err)
-	}
-	defer certOut.Close()
-
-	if err := pem.Encode(certOut, &pem.Block{Type: "CERTIFICATE", Bytes: certDER}); err != nil {
-		return fmt.Errorf("failed to write certificate: %w", err)
-	}
-
-	// Save private key
-	keyPath := tm.getKeyPath()
-	keyOut, err := os.OpenFile(keyPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
-	if err != nil {
-		return fmt.Errorf("failed to create key file: %w", err)
-	}
-	defer keyOut.Close()
-
 	privateKeyBytes, err := x509.MarshalPKCS8PrivateKey(privateKey)
 	if err != nil {
 		return fmt.Errorf("failed to marshal private key: %w", err)
 	}
 
-	if err := pem.Encode(keyOut, &pem.Block{Type: "PRIVATE KEY", Bytes: privateKeyBytes}); err != nil {
+	// Replace the key, then the certificate, so a server starting meanwhile
+	// never reads a partly written file. A crash between the two leaves a
+	// mismatched pair, which fails validation and is regenerated.
+	certPath := tm.getCertPath()
+	keyPath := tm.getKeyPath()
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateKeyBytes})
+	if err := writeFileAtomic(keyPath, keyPEM, 0600); err != nil {
 		return fmt.Errorf("failed to write private key: %w", err)
 	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})
+	if err := writeFileAtomic(certPath, certPEM, 0644); err != nil {
+		return fmt.Errorf("failed to write certificate: %w", err)
+	}
 
 	logging.Info("TLS certificates generated successfully",
 		logging.String("cert_file", certPath),
@@ -275,6 +338,41 @@ func (tm *TLSManager) generateCertificates() error {
 	return nil
 }
 
+// writeFileAtomic replaces path with data by writing a temporary file in the
+// same directory and renaming it into place
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tempFile, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tempPath := tempFile.Name()
+
+	if err := tempFile.Chmod(perm); err != nil {
+		tempFile.Close()
+		os.Remove(tempPath)
+		return err
+	}
+	if _, err := tempFile.Write(data); err != nil {
+		tempFile.Close()
+		os.Remove(tempPath)
+		return err
+	}
+	if err := tempFile.Sync(); err != nil {
+		tempFile.Close()
+		os.Remove(tempPath)
+		return err
+	}
+	if err := tempFile.Close(); err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+	return nil
+}
+
 // getCertPath returns the path to the certificate file
 func (tm *TLSManager) getCertPath() string {
 	if tm.config.CertFile != "" {
@@ -339,19 +437,9 @@ func (tm *TLSManager) GetCertificateInfo() (map[string]interface{}, error) {
 	}
 
 	certPath := tm.getCertPath()
-	certPEM, err := os.ReadFile(certPath)
+	cert, err := tm.loadCertificate()
 	if err != nil {
-		return nil, fmt.Errorf("failed to read certificate: %w", err)
-	}
-
-	block, _ := pem.Decode(certPEM)
-	if block == nil {
-		return nil, fmt.Errorf("failed to parse certificate PEM")
-	}
-
-	cert, err := x509.ParseCertificate(block.Bytes)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse certificate: %w", err)
+		return nil, err
 	}
 
 	// Calculate fingerprint
@@ -384,6 +472,21 @@ func (tm *TLSManager) ExportCertificate() ([]byte, error) {
 	return os.ReadFile(certPath)
 }
 
+// HTTPSRedirect returns a handler that serves PlainHTTPPaths with app and
+// redirects every other request to the same path and query on httpsPort
+func (tm *TLSManager) HTTPSRedirect(app http.Handler, httpsPort int) http.Handler {
+	redirect := tm.HTTPRedirectHandler(httpsPort)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, path := range PlainHTTPPaths {
+			if r.URL.Path == path {
+				app.ServeHTTP(w, r)
+				return
+			}
+		}
+		redirect(w, r)
+	})
+}
+
 // HTTPRedirectHandler returns a handler that redirects HTTP to HTTPS
 func (tm *TLSManager) HTTPRedirectHandler(httpsPort int) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -403,10 +506,10 @@ func (tm *TLSManager) HTTPRedirectHandler(httpsPort int) http.HandlerFunc {
 			host = fmt.Sprintf("%s:%d", host, httpsPort)
 		}
 
-		httpsURL := fmt.Sprintf("https://%s%s", host, r.RequestURI)
+		httpsURL := fmt.Sprintf("https://%s%s", host, r.URL.RequestURI())
 
-		// Set security headers
-		w.Header().Set("Strict-Transport-Security", "max-age=31536000; includeSubDomains")
+		// No HSTS here: browsers ignore it over plain HTTP, and the security
+		// headers middleware sends the configured policy once on HTTPS
 		w.Header().Set("Location", httpsURL)
 
 		// Use 301 for GET requests, 308 for others to preserve method
@@ -427,7 +530,7 @@ func (tm *TLSManager) HTTPRedirectHandler(httpsPort int) http.HandlerFunc {
     <h1>Redirecting to HTTPS</h1>
     <p>Please follow <a href="%s">this link</a> to the secure version.</p>
 </body>
-</html>`, httpsURL, httpsURL)))
+</html>`, html.EscapeString(httpsURL), html.EscapeString(httpsURL))))
 	}
 }
 
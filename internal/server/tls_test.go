@@ -0,0 +1,122 @@
+package server
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestEnsureCertificates_AutoGenerate(t *testing.T) {
+	config := DefaultTLSConfig()
+	config.Enabled = true
+	config.CertDir = filepath.Join(t.TempDir(), "certs")
+	config.Hostname = "parental.lan"
+	config.IPAddresses = []net.IP{net.IPv4(127, 0, 0, 1), net.IPv4(192, 168, 1, 10)}
+
+	ensure := func(config TLSConfig) *TLSManager {
+		t.Helper()
+		tm := NewTLSManager(config)
+		if err := tm.EnsureCertificates(); err != nil {
+			t.Fatalf("EnsureCertificates failed: %v", err)
+		}
+		if _, err := tm.GetTLSConfig(); err != nil {
+			t.Fatalf("Failed to load generated certificate: %v", err)
+		}
+		return tm
+	}
+	serial := func(tm *TLSManager) string {
+		t.Helper()
+		cert, err := tm.loadCertificate()
+		if err != nil {
+			t.Fatalf("Failed to load certificate: %v", err)
+		}
+		return cert.SerialNumber.String()
+	}
+
+	tm := ensure(config)
+	first := serial(tm)
+	cert, _ := tm.loadCertificate()
+	for _, name := range []string{"parental.lan", "127.0.0.1", "192.168.1.10"} {
+		if err := cert.VerifyHostname(name); err != nil {
+			t.Errorf("Expected the certificate to cover %s: %v", name, err)
+		}
+	}
+	if want := time.Now().Add(config.ValidDuration); cert.NotAfter.Before(want.Add(-time.Minute)) || cert.NotAfter.After(want) {
+		t.Errorf("Expected the certificate to be valid until about %v, got %v", want, cert.NotAfter)
+	}
+	if info, err := os.Stat(tm.getKeyPath()); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Expected the key to be private, got %v (%v)", info.Mode(), err)
+	}
+	leftovers, _ := filepath.Glob(filepath.Join(config.CertDir, ".*.tmp"))
+	if len(leftovers) != 0 {
+		t.Errorf("Expected no temporary files, found %v", leftovers)
+	}
+
+	// A valid certificate is kept
+	if got := serial(ensure(config)); got != first {
+		t.Errorf("Expected the existing certificate to be reused")
+	}
+
+	// A new hostname needs a new certificate
+	renamed := config
+	renamed.Hostname = "controls.lan"
+	second := serial(ensure(renamed))
+	if second == first {
+		t.Errorf("Expected a new certificate for a new hostname")
+	}
+
+	// So does an expired one
+	expired := renamed
+	expired.ValidDuration = -time.Hour
+	os.Remove(tm.getCertPath())
+	expiredSerial := serial(ensure(expired))
+	if got := serial(ensure(renamed)); got == expiredSerial || got == second {
+		t.Errorf("Expected an expired certificate to be replaced")
+	}
+}
+
+func TestEnsureCertificates_NoAutoGenerate(t *testing.T) {
+	config := DefaultTLSConfig()
+	config.Enabled = true
+	config.AutoGenerate = false
+	config.CertDir = t.TempDir()
+
+	if err := NewTLSManager(config).EnsureCertificates(); err == nil {
+		t.Error("Expected an error without certificates or auto-generation")
+	}
+}
+
+func TestHTTPSRedirect(t *testing.T) {
+	app := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	handler := NewTLSManager(DefaultTLSConfig()).HTTPSRedirect(app, 8443)
+
+	tests := []struct {
+		method, target string
+		wantStatus     int
+		wantLocation   string
+	}{
+		{http.MethodGet, "/lists/3?tab=entries", http.StatusMovedPermanently, "https://parental.lan:8443/lists/3?tab=entries"},
+		{http.MethodPost, "/api/v1/lists", http.StatusPermanentRedirect, "https://parental.lan:8443/api/v1/lists"},
+		{http.MethodGet, "/health", http.StatusTeapot, ""},
+		{http.MethodGet, "/status", http.StatusTeapot, ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, "http://parental.lan:8080"+tt.target, nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != tt.wantStatus || rec.Header().Get("Location") != tt.wantLocation {
+			t.Errorf("%s %s: got %d to %q, want %d to %q", tt.method, tt.target,
+				rec.Code, rec.Header().Get("Location"), tt.wantStatus, tt.wantLocation)
+		}
+		if hsts := rec.Header().Get("Strict-Transport-Security"); hsts != "" {
+			t.Errorf("%s %s: expected no HSTS over plain HTTP, got %q", tt.method, tt.target, hsts)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"strconv"
+	"strings"
+	"time"
+
+	"parental-control/internal/database"
+	"parental-control/internal/models"
+)
+
+// AuditExportFormat is a file format audit logs can be exported in
+type AuditExportFormat string
+
+const (
+	AuditExportCSV    AuditExportFormat = "csv"
+	AuditExportNDJSON AuditExportFormat = "ndjson"
+)
+
+// auditExportFlushEvery is how many entries are written between flushes so
+// a slow export still reaches the client steadily
+const auditExportFlushEvery = 1000
+
+// auditCSVHeader names the columns of a CSV export
+var auditCSVHeader = []string{"id", "timestamp", "event_type", "target_type", "target_value",
+	"action", "rule_type", "rule_id", "process_name", "details"}
+
+// ParseAuditExportFormat picks the export format from an explicit format
+// name, falling back to an Accept header and then to CSV
+func ParseAuditExportFormat(format, accept string) (AuditExportFormat, error) {
+	switch strings.ToLower(format) {
+	case "csv":
+		return AuditExportCSV, nil
+	case "ndjson", "jsonl":
+		return AuditExportNDJSON, nil
+	case "":
+	default:
+		return "", fmt.Errorf("unsupported export format: %s", format)
+	}
+
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		switch mediaType {
+		case "text/csv":
+			return AuditExportCSV, nil
+		case "application/x-ndjson", "application/ndjson", "application/jsonl":
+			return AuditExportNDJSON, nil
+		}
+	}
+	return AuditExportCSV, nil
+}
+
+// ContentType returns the MIME type of the format
+func (f AuditExportFormat) ContentType() string {
+	if f == AuditExportNDJSON {
+		return "application/x-ndjson"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// AuditExportFilename names an export file after the time range it covers,
+// e.g. "audit-log_20240115T000000Z_20240116T000000Z.csv"
+func AuditExportFilename(filters AuditLogFilters, format AuditExportFormat) string {
+	stamp := func(t *time.Time, open string) string {
+		if t == nil {
+			return open
+		}
+		return t.UTC().Format("20060102T150405Z")
+	}
+	return fmt.Sprintf("audit-log_%s_%s.%s",
+		stamp(filters.StartTime, "start"), stamp(filters.EndTime, "now"), format)
+}
+
+// AuditLogWriter writes audit log entries to a stream one at a time as CSV
+// or newline-delimited JSON
+type AuditLogWriter struct {
+	format  AuditExportFormat
+	csv     *csv.Writer
+	json    *json.Encoder
+	written int
+}
+
+// NewAuditLogWriter creates a writer for format. CSV output starts with a
+// header row.
+func NewAuditLogWriter(w io.Writer, format AuditExportFormat) (*AuditLogWriter, error) {
+	aw := &AuditLogWriter{format: format}
+	switch format {
+	case AuditExportCSV:
+		aw.csv = csv.NewWriter(w)
+		if err := aw.csv.Write(auditCSVHeader); err != nil {
+			return nil, fmt.Errorf("failed to write CSV header: %w", err)
+		}
+	case AuditExportNDJSON:
+		aw.json = json.NewEncoder(w)
+	default:
+		return nil, fmt.Errorf("unsupported export format: %s", format)
+	}
+	return aw, nil
+}
+
+// Write writes one entry
+func (aw *AuditLogWriter) Write(log *models.AuditLog) error {
+	aw.written++
+	if aw.json != nil {
+		return aw.json.Encode(log)
+	}
+
+	ruleID := ""
+	if log.RuleID != nil {
+		ruleID = strconv.Itoa(*log.RuleID)
+	}
+	record := []string{strconv.Itoa(log.ID), log.Timestamp.UTC().Format(time.RFC3339), log.EventType,
+		string(log.TargetType), log.TargetValue, string(log.Action), log.RuleType, ruleID,
+		log.ProcessName(), log.Details}
+	if err := aw.csv.Write(record); err != nil {
+		return err
+	}
+	if aw.written%auditExportFlushEvery == 0 {
+		return aw.Flush()
+	}
+	return nil
+}
+
+// Flush writes any buffered output
+func (aw *AuditLogWriter) Flush() error {
+	if aw.csv == nil {
+		return nil
+	}
+	aw.csv.Flush()
+	return aw.csv.Error()
+}
+
+// Written returns how many entries have been written
+func (aw *AuditLogWriter) Written() int {
+	return aw.written
+}
+
+// ExportAuditLogs writes every entry matching filters to w, newest first.
+// Entries are read and written one at a time, so an export of any size uses
+// constant memory. Paging in filters is honored; clear it to export all.
+func (s *AuditService) ExportAuditLogs(ctx context.Context, filters AuditLogFilters, w *AuditLogWriter) error {
+	repo, ok := s.repos.AuditLog.(*database.AuditLogRepository)
+	if !ok {
+		return fmt.Errorf("audit log repository does not support filtered queries")
+	}
+
+	err := repo.EachByFilters(ctx, toRepositoryFilters(filters), func(log *models.AuditLog) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return w.Write(log)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to export audit logs: %w", err)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"parental-control/internal/models"
+)
+
+func TestParseAuditExportFormat(t *testing.T) {
+	tests := []struct {
+		format, accept string
+		want           AuditExportFormat
+		wantErr        bool
+	}{
+		{"", "", AuditExportCSV, false},
+		{"ndjson", "text/csv", AuditExportNDJSON, false},
+		{"CSV", "", AuditExportCSV, false},
+		{"", "application/json, application/x-ndjson;q=0.9", AuditExportNDJSON, false},
+		{"", "text/csv; charset=utf-8", AuditExportCSV, false},
+		{"xml", "", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseAuditExportFormat(tt.format, tt.accept)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseAuditExportFormat(%q, %q) = %q, %v; want %q", tt.format, tt.accept, got, err, tt.want)
+		}
+	}
+}
+
+func TestAuditLogWriter(t *testing.T) {
+	ruleID := 7
+	logs := []models.AuditLog{
+		{ID: 1, Timestamp: time.Date(2024, 1, 15, 9, 30, 0, 0, time.UTC), EventType: "enforcement_action",
+			TargetType: models.TargetTypeURL, TargetValue: "games.example.com", Action: models.ActionTypeBlock,
+			RuleType: "list", RuleID: &ruleID, Details: `{"process_name":"firefox","note":"a, b"}`},
+		{ID: 2, Timestamp: time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC), EventType: "enforcement_action",
+			TargetType: models.TargetTypeExecutable, TargetValue: "steam", Action: models.ActionTypeBlock},
+	}
+
+	var csvOut bytes.Buffer
+	writer, err := NewAuditLogWriter(&csvOut, AuditExportCSV)
+	if err != nil {
+		t.Fatalf("NewAuditLogWriter failed: %v", err)
+	}
+	for i := range logs {
+		if err := writer.Write(&logs[i]); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+	want := `id,timestamp,event_type,target_type,target_value,action,rule_type,rule_id,process_name,details
+1,2024-01-15T09:30:00Z,enforcement_action,url,games.example.com,block,list,7,firefox,"{""process_name"":""firefox"",""note"":""a, b""}"
+2,2024-01-15T09:00:00Z,enforcement_action,executable,steam,block,,,steam,
+`
+	if csvOut.String() != want {
+		t.Errorf("Unexpected CSV export:\n%s\nwant:\n%s", csvOut.String(), want)
+	}
+
+	var ndjsonOut bytes.Buffer
+	writer, err = NewAuditLogWriter(&ndjsonOut, AuditExportNDJSON)
+	if err != nil {
+		t.Fatalf("NewAuditLogWriter failed: %v", err)
+	}
+	for i := range logs {
+		if err := writer.Write(&logs[i]); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+	lines := strings.Split(strings.TrimSpace(ndjsonOut.String()), "\n")
+	if len(lines) != 2 || writer.Written() != 2 {
+		t.Fatalf("Expected 2 lines, got %q", ndjsonOut.String())
+	}
+	var decoded models.AuditLog
+	if err := json.Unmarshal([]byte(lines[1]), &decoded); err != nil || decoded.TargetValue != "steam" {
+		t.Errorf("Expected the second line to decode to the steam entry, got %+v (%v)", decoded, err)
+	}
+}
+
+func TestAuditExportFilename(t *testing.T) {
+	start := time.Date(2024, 1, 15, 0, 0, 0, 0, time.FixedZone("EST", -5*3600))
+	filters := AuditLogFilters{StartTime: &start}
+	if got := AuditExportFilename(filters, AuditExportNDJSON); got != "audit-log_20240115T050000Z_now.ndjson" {
+		t.Errorf("Unexpected filename %q", got)
+	}
+	if got := AuditExportFilename(AuditLogFilters{}, AuditExportCSV); got != "audit-log_start_now.csv" {
+		t.Errorf("Unexpected filename %q", got)
+	}
+}
@@ -32,6 +32,12 @@ type AuditService struct {
 	batchMu   sync.Mutex
 	batch     []*models.AuditLog
 	lastFlush time.Time
+
+	// Live subscribers for tail streaming
+	subscribers subscriberSet[*models.AuditLog]
+
+	// Enforcement decisions are published here as they are logged
+	events *EventBus
 }
 
 // AuditConfig holds configuration for the audit service
@@ -69,7 +75,7 @@ func DefaultAuditConfig() AuditConfig {
 		RetentionDays:     30,
 		CleanupInterval:   24 * time.Hour,
 		LogLevels:         []string{"info", "warn", "error", "critical"},
-		EnabledEventTypes: []string{"enforcement_action", "rule_change", "user_action", "system_event"},
+		EnabledEventTypes: []string{"enforcement_action", "rule_change", "user_action", "system_event", "bypass_attempt"},
 	}
 }
 
@@ -160,11 +166,49 @@ func (s *AuditService) Stop() error {
 	// Wait for all goroutines to finish
 	s.wg.Wait()
 
+	// End any live tail streams
+	s.subscribers.closeAll()
+
 	s.running = false
 	s.logger.Info("Audit service stopped")
 	return nil
 }
 
+// Flush writes buffered and batched audit entries to the database without
+// stopping the service
+func (s *AuditService) Flush(ctx context.Context) error {
+	for {
+		select {
+		case log := <-s.logBuffer:
+			if s.config.EnableBatching {
+				s.addToBatch(log)
+			} else if err := s.writeLog(ctx, log); err != nil {
+				s.logger.Error("Failed to write audit log", logging.Err(err))
+			}
+		default:
+			return s.flushBatch(ctx)
+		}
+	}
+}
+
+// SetEventBus sets the bus enforcement decisions are published to as soon as
+// they are logged, before they reach the database
+func (s *AuditService) SetEventBus(events *EventBus) {
+	s.events = events
+}
+
+// isEnforcementEvent reports whether an audit event records an enforcement
+// decision, as opposed to the notification service's own bookkeeping
+func isEnforcementEvent(req AuditEventRequest) bool {
+	switch req.EventType {
+	case "enforcement_action":
+		return req.RuleType != "notification_service"
+	case "bypass_attempt":
+		return true
+	}
+	return false
+}
+
 // LogEnforcementAction logs an enforcement action (allow/block)
 func (s *AuditService) LogEnforcementAction(ctx context.Context, action models.ActionType, targetType models.TargetType, targetValue string, ruleType string, ruleID *int, details map[string]interface{}) error {
 	return s.LogEvent(ctx, AuditEventRequest{
@@ -178,6 +222,22 @@ func (s *AuditService) LogEnforcementAction(ctx context.Context, action models.A
 	})
 }
 
+// LogBypassAttempt logs a blocked attempt to reach an encrypted DNS provider,
+// which would let a device skip filtering
+func (s *AuditService) LogBypassAttempt(ctx context.Context, domain, client string) error {
+	return s.LogEvent(ctx, AuditEventRequest{
+		EventType:   "bypass_attempt",
+		TargetType:  models.TargetTypeURL,
+		TargetValue: domain,
+		Action:      models.ActionTypeBlock,
+		RuleType:    "encrypted_dns",
+		Details: map[string]interface{}{
+			"client": client,
+			"reason": "encrypted DNS provider",
+		},
+	})
+}
+
 // LogRuleChange logs a rule configuration change
 func (s *AuditService) LogRuleChange(ctx context.Context, ruleType string, ruleID int, operation string, details map[string]interface{}) error {
 	return s.LogEvent(ctx, AuditEventRequest{
@@ -271,6 +331,10 @@ func (s *AuditService) LogEvent(ctx context.Context, req AuditEventRequest) erro
 	// Update statistics
 	s.updateStats(auditLog, time.Since(startTime))
 
+	if isEnforcementEvent(req) {
+		s.events.Publish(EventEnforcement, auditLog)
+	}
+
 	// Handle logging based on configuration
 	if s.config.EnableBuffering && s.running {
 		return s.bufferLog(ctx, auditLog)
@@ -283,25 +347,21 @@ func (s *AuditService) LogEvent(ctx context.Context, req AuditEventRequest) erro
 // GetAuditLogs retrieves audit logs with filtering
 func (s *AuditService) GetAuditLogs(ctx context.Context, filters AuditLogFilters) ([]models.AuditLog, int, error) {
 	// Convert to repository filters
-	repoFilters := database.AuditLogFilters{
-		Action:     filters.Action,
-		TargetType: filters.TargetType,
-		EventType:  filters.EventType,
-		StartTime:  filters.StartTime,
-		EndTime:    filters.EndTime,
-		Search:     filters.Search,
-		Limit:      filters.Limit,
-		Offset:     filters.Offset,
+	repoFilters := toRepositoryFilters(filters)
+
+	repo, ok := s.repos.AuditLog.(*database.AuditLogRepository)
+	if !ok {
+		return nil, 0, fmt.Errorf("audit log repository does not support filtered queries")
 	}
 
 	// Get logs
-	logs, err := s.repos.AuditLog.(*database.AuditLogRepository).GetByFilters(ctx, repoFilters)
+	logs, err := repo.GetByFilters(ctx, repoFilters)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to get audit logs: %w", err)
 	}
 
 	// Get total count for pagination
-	totalCount, err := s.getFilteredCount(ctx, filters)
+	totalCount, err := repo.CountByFilters(ctx, repoFilters)
 	if err != nil {
 		s.logger.Error("Failed to get audit log count", logging.Err(err))
 		totalCount = len(logs) // Fallback to current page count
@@ -407,6 +467,7 @@ func (s *AuditService) writeLog(ctx context.Context, log *models.AuditLog) error
 		return err
 	}
 
+	s.subscribers.publish(log)
 	return nil
 }
 
@@ -537,21 +598,30 @@ func (s *AuditService) isEventTypeEnabled(eventType string) bool {
 	return false
 }
 
-func (s *AuditService) getFilteredCount(ctx context.Context, filters AuditLogFilters) (int, error) {
-	if filters.StartTime != nil && filters.EndTime != nil {
-		return s.repos.AuditLog.CountByTimeRange(ctx, *filters.StartTime, *filters.EndTime)
-	}
-	return s.repos.AuditLog.Count(ctx)
-}
-
 // AuditLogFilters represents filtering options for audit log queries
 type AuditLogFilters struct {
-	Action     *models.ActionType `json:"action,omitempty"`
-	TargetType *models.TargetType `json:"target_type,omitempty"`
-	EventType  string             `json:"event_type,omitempty"`
-	StartTime  *time.Time         `json:"start_time,omitempty"`
-	EndTime    *time.Time         `json:"end_time,omitempty"`
-	Search     string             `json:"search,omitempty"`
-	Limit      int                `json:"limit,omitempty"`
-	Offset     int                `json:"offset,omitempty"`
+	Action      *models.ActionType `json:"action,omitempty"`
+	TargetType  *models.TargetType `json:"target_type,omitempty"`
+	EventType   string             `json:"event_type,omitempty"`
+	ProcessName string             `json:"process_name,omitempty"`
+	StartTime   *time.Time         `json:"start_time,omitempty"`
+	EndTime     *time.Time         `json:"end_time,omitempty"`
+	Search      string             `json:"search,omitempty"`
+	Limit       int                `json:"limit,omitempty"`
+	Offset      int                `json:"offset,omitempty"`
+}
+
+// toRepositoryFilters converts filters to the repository's form
+func toRepositoryFilters(filters AuditLogFilters) database.AuditLogFilters {
+	return database.AuditLogFilters{
+		Action:      filters.Action,
+		TargetType:  filters.TargetType,
+		EventType:   filters.EventType,
+		ProcessName: filters.ProcessName,
+		StartTime:   filters.StartTime,
+		EndTime:     filters.EndTime,
+		Search:      filters.Search,
+		Limit:       filters.Limit,
+		Offset:      filters.Offset,
+	}
 }
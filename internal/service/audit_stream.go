@@ -0,0 +1,53 @@
+package service
+
+import (
+	"strings"
+
+	"parental-control/internal/models"
+)
+
+// AuditSubscription receives audit log entries as they are persisted
+type AuditSubscription = Subscription[*models.AuditLog]
+
+// Subscribe registers a live subscriber for audit entries matching the given
+// filters. Pagination and time range fields of the filters are ignored.
+func (s *AuditService) Subscribe(filters AuditLogFilters, bufferSize int) *AuditSubscription {
+	return s.subscribers.subscribe(filters.Matches, bufferSize)
+}
+
+// Unsubscribe removes a live subscriber and closes its channel
+func (s *AuditService) Unsubscribe(sub *AuditSubscription) {
+	s.subscribers.unsubscribe(sub)
+}
+
+// SubscriberCount returns the number of active live subscribers
+func (s *AuditService) SubscriberCount() int {
+	return s.subscribers.count()
+}
+
+// Matches reports whether an audit entry satisfies the action, target type,
+// event type, process name and search filters
+func (f AuditLogFilters) Matches(log *models.AuditLog) bool {
+	if f.Action != nil && log.Action != *f.Action {
+		return false
+	}
+	if f.TargetType != nil && log.TargetType != *f.TargetType {
+		return false
+	}
+	if f.EventType != "" && log.EventType != f.EventType {
+		return false
+	}
+	if f.ProcessName != "" && log.ProcessName() != f.ProcessName {
+		return false
+	}
+	if f.Search != "" && !containsFold(log.TargetValue, f.Search) && !containsFold(log.Details, f.Search) {
+		return false
+	}
+	return true
+}
+
+// containsFold reports whether substr is within s, ignoring case like the
+// SQL LIKE the stored search uses
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"parental-control/internal/database"
+	"parental-control/internal/logging"
+	"parental-control/internal/models"
+)
+
+// Reasons recorded in backup file names
+const (
+	BackupReasonManual    = "manual"
+	BackupReasonRetention = "pre-retention"
+	BackupReasonImport    = "pre-import"
+)
+
+// ErrBackupsDisabled is returned for backups requested while they are disabled
+var ErrBackupsDisabled = errors.New("database backups are disabled")
+
+// BackupConfig holds configuration for database backups
+type BackupConfig struct {
+	// Enabled allows backups; when false every request is refused
+	Enabled bool
+	// Directory holds the backup files
+	Directory string
+	// Keep is how many backup files to keep, including pre-migration ones
+	Keep int
+	// RetentionThreshold backs up before retention runs estimated to delete
+	// at least this many entries; 0 disables
+	RetentionThreshold int64
+}
+
+// DefaultBackupConfig returns backup configuration with sensible defaults
+func DefaultBackupConfig() BackupConfig {
+	return BackupConfig{
+		Enabled:            true,
+		Directory:          "./data/backups",
+		Keep:               7,
+		RetentionThreshold: 10000,
+	}
+}
+
+// BackupResult describes a completed backup
+type BackupResult struct {
+	database.BackupFile
+	Duration time.Duration `json:"duration"`
+	// Pruned lists the old backups deleted to stay within Keep
+	Pruned []string `json:"pruned,omitempty"`
+}
+
+// DatabaseBackupService takes timestamped backups of the database and keeps
+// the newest few
+type DatabaseBackupService struct {
+	db     *database.DB
+	logger logging.Logger
+	config BackupConfig
+
+	// One backup at a time; they would copy the same data
+	mu sync.Mutex
+}
+
+// NewDatabaseBackupService creates a new backup service
+func NewDatabaseBackupService(db *database.DB, logger logging.Logger, config BackupConfig) *DatabaseBackupService {
+	return &DatabaseBackupService{
+		db:     db,
+		logger: logger,
+		config: config,
+	}
+}
+
+// Backup copies the database into the backup directory and deletes backups
+// beyond the configured number to keep
+func (s *DatabaseBackupService) Backup(ctx context.Context, reason string) (*BackupResult, error) {
+	if !s.config.Enabled {
+		return nil, ErrBackupsDisabled
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	start := time.Now()
+	name := database.BackupFileName(start, reason)
+	path := filepath.Join(s.config.Directory, name)
+
+	if err := s.db.Backup(ctx, path); err != nil {
+		s.logger.Error("Database backup failed",
+			logging.String("reason", reason),
+			logging.Err(err))
+		return nil, err
+	}
+
+	result := &BackupResult{
+		BackupFile: database.BackupFile{
+			Name:      name,
+			Path:      path,
+			Reason:    reason,
+			CreatedAt: start.UTC(),
+		},
+		Duration: time.Since(start),
+	}
+	if info, err := os.Stat(path); err == nil {
+		result.Size = info.Size()
+	}
+
+	// A failed prune leaves extra files but the backup itself succeeded
+	pruned, err := database.PruneBackups(s.config.Directory, s.config.Keep)
+	if err != nil {
+		s.logger.Warn("Failed to delete old backups", logging.Err(err))
+	}
+	result.Pruned = pruned
+
+	s.logger.Info("Database backed up",
+		logging.String("path", path),
+		logging.String("reason", reason),
+		logging.Int("size_bytes", int(result.Size)),
+		logging.Duration("duration", result.Duration),
+		logging.Int("pruned", len(pruned)))
+	return result, nil
+}
+
+// ListBackups returns the backups in the backup directory, newest first
+func (s *DatabaseBackupService) ListBackups() ([]database.BackupFile, error) {
+	return database.ListBackups(s.config.Directory)
+}
+
+// BeforeRetention is a RetentionPreExecutionHook that backs up the database
+// before runs estimated to delete at least the configured threshold. The run
+// is failed if the backup fails, so nothing is deleted without one.
+func (s *DatabaseBackupService) BeforeRetention(ctx context.Context, policy *models.RetentionPolicy, preview *RetentionPreview) error {
+	if !s.config.Enabled || s.config.RetentionThreshold <= 0 ||
+		preview.EstimatedDeletions < s.config.RetentionThreshold {
+		return nil
+	}
+
+	s.logger.Info("Backing up database before retention run",
+		logging.Int("policy_id", policy.ID),
+		logging.String("policy_name", policy.Name),
+		logging.Int("estimated_deletions", int(preview.EstimatedDeletions)))
+
+	if _, err := s.Backup(ctx, BackupReasonRetention); err != nil {
+		return fmt.Errorf("backup before deleting %d entries failed: %w", preview.EstimatedDeletions, err)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"parental-control/internal/database"
+	"parental-control/internal/logging"
+	"parental-control/internal/models"
+)
+
+func newTestBackupService(t *testing.T, config BackupConfig) *DatabaseBackupService {
+	t.Helper()
+
+	dir := t.TempDir()
+	dbConfig := database.DefaultConfig()
+	dbConfig.Path = filepath.Join(dir, "backup.db")
+	db, err := database.New(dbConfig)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.InitializeSchema(); err != nil {
+		t.Fatalf("Failed to initialize schema: %v", err)
+	}
+
+	config.Directory = filepath.Join(dir, "backups")
+	return NewDatabaseBackupService(db, logging.NewDefault(), config)
+}
+
+func TestDatabaseBackupService_BackupAndPrune(t *testing.T) {
+	config := DefaultBackupConfig()
+	config.Keep = 2
+	bs := newTestBackupService(t, config)
+
+	var results []*BackupResult
+	for i := 0; i < 3; i++ {
+		result, err := bs.Backup(context.Background(), BackupReasonManual)
+		if err != nil {
+			t.Fatalf("Backup failed: %v", err)
+		}
+		if result.Size == 0 || result.Reason != BackupReasonManual {
+			t.Errorf("Unexpected backup result: %+v", result)
+		}
+		results = append(results, result)
+	}
+
+	if len(results[2].Pruned) != 1 || results[2].Pruned[0] != results[0].Name {
+		t.Errorf("Expected the third backup to prune the first, got %v", results[2].Pruned)
+	}
+	backups, err := bs.ListBackups()
+	if err != nil {
+		t.Fatalf("Failed to list backups: %v", err)
+	}
+	if len(backups) != 2 || backups[0].Name != results[2].Name {
+		t.Errorf("Expected the 2 newest backups, got %+v", backups)
+	}
+}
+
+func TestDatabaseBackupService_BeforeRetention(t *testing.T) {
+	config := DefaultBackupConfig()
+	config.RetentionThreshold = 100
+	bs := newTestBackupService(t, config)
+	policy := &models.RetentionPolicy{ID: 1, Name: "old"}
+
+	if err := bs.BeforeRetention(context.Background(), policy, &RetentionPreview{EstimatedDeletions: 99}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if backups, _ := bs.ListBackups(); len(backups) != 0 {
+		t.Fatalf("Expected no backup below the threshold, got %+v", backups)
+	}
+
+	if err := bs.BeforeRetention(context.Background(), policy, &RetentionPreview{EstimatedDeletions: 100}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	backups, _ := bs.ListBackups()
+	if len(backups) != 1 || backups[0].Reason != BackupReasonRetention {
+		t.Errorf("Expected one pre-retention backup, got %+v", backups)
+	}
+
+	config.Enabled = false
+	disabled := newTestBackupService(t, config)
+	if err := disabled.BeforeRetention(context.Background(), policy, &RetentionPreview{EstimatedDeletions: 1000}); err != nil {
+		t.Errorf("Expected disabled backups not to stop retention, got %v", err)
+	}
+	if _, err := disabled.Backup(context.Background(), BackupReasonManual); err == nil {
+		t.Error("Expected manual backups to be refused when disabled")
+	}
+}
@@ -0,0 +1,363 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"parental-control/internal/logging"
+	"parental-control/internal/models"
+)
+
+const (
+	// maxBlockListSize bounds a downloaded block list
+	maxBlockListSize = 100 << 20
+
+	// BlockListCheckInterval is how often subscribed block lists are checked
+	// for a due refresh
+	BlockListCheckInterval = time.Minute
+)
+
+// BlockListFetchError is returned when a block list URL cannot be downloaded
+type BlockListFetchError struct {
+	URL string
+	Err error
+}
+
+func (e *BlockListFetchError) Error() string {
+	return fmt.Sprintf("failed to fetch block list %s: %v", e.URL, e.Err)
+}
+
+func (e *BlockListFetchError) Unwrap() error {
+	return e.Err
+}
+
+// BlockListService imports published block lists into blacklists, either
+// from uploaded data or from a URL that can be refreshed periodically
+type BlockListService struct {
+	repos   *models.RepositoryManager
+	entries *EntryManagementService
+	logger  logging.Logger
+	client  *http.Client
+
+	// onImported is called after an import adds entries, such as to reload
+	// the enforcement rules
+	onImported func(ctx context.Context)
+
+	// refreshMu serializes fetches so a list is never imported twice at once
+	refreshMu sync.Mutex
+
+	// maxSize bounds a downloaded block list
+	maxSize int64
+
+	// allowIP reports whether block lists may be fetched from an address
+	allowIP func(ip net.IP) bool
+}
+
+// NewBlockListService creates a new block list service
+func NewBlockListService(repos *models.RepositoryManager, logger logging.Logger) *BlockListService {
+	s := &BlockListService{
+		repos:   repos,
+		entries: NewEntryManagementService(repos, logger),
+		logger:  logger,
+		maxSize: maxBlockListSize,
+		allowIP: isPublicFetchIP,
+	}
+
+	// Addresses are checked as they are dialed, so a host name resolving to
+	// a local address or a redirect to one is refused as well. Lists are
+	// fetched directly, since a proxy would dial the address unchecked.
+	dialer := &net.Dialer{
+		Timeout: 30 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !s.allowIP(ip) {
+				return fmt.Errorf("block lists cannot be fetched from %s", host)
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	s.client = &http.Client{Timeout: 2 * time.Minute, Transport: transport}
+
+	return s
+}
+
+// isLocalHost reports whether a URL host names an address block lists may
+// not be fetched from. Other names are checked when they are dialed.
+func (s *BlockListService) isLocalHost(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && !s.allowIP(ip)
+}
+
+// isPublicFetchIP reports whether ip may serve block lists. Loopback,
+// link-local (which includes cloud metadata endpoints) and unspecified
+// addresses are refused so a subscription cannot probe the host itself.
+func isPublicFetchIP(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() && !ip.IsUnspecified()
+}
+
+// SetImportHook registers a function called after an import adds entries
+func (s *BlockListService) SetImportHook(fn func(ctx context.Context)) {
+	s.onImported = fn
+}
+
+// Import reads a block list from r into a blacklist. Entries already in the
+// list are skipped and counted as duplicates.
+func (s *BlockListService) Import(ctx context.Context, listID int, r io.Reader, format ExportEntriesFormat, label string) (*ImportResult, error) {
+	if err := s.checkTarget(ctx, listID, format); err != nil {
+		return nil, err
+	}
+
+	result, err := s.entries.ImportEntriesFromReader(ctx, listID, r, format, ImportOptions{Label: label})
+	if result != nil && result.Imported > 0 && s.onImported != nil {
+		s.onImported(ctx)
+	}
+	return result, err
+}
+
+// Subscribe records rawURL as the source of a blacklist and imports it now.
+// A positive refresh interval re-fetches the list periodically.
+func (s *BlockListService) Subscribe(ctx context.Context, listID int, rawURL string, format ExportEntriesFormat, refreshInterval time.Duration) (*ImportResult, error) {
+	if err := s.checkTarget(ctx, listID, format); err != nil {
+		return nil, err
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid block list URL: must be an http or https URL")
+	}
+	if host := parsed.Hostname(); s.isLocalHost(host) {
+		return nil, fmt.Errorf("invalid block list URL: %s is a local address", host)
+	}
+	if refreshInterval < 0 {
+		return nil, fmt.Errorf("refresh interval must not be negative")
+	}
+
+	source := &models.BlockListSource{ListID: listID}
+	if existing, err := s.repos.BlockListSource.GetByListID(ctx, listID); err == nil {
+		source = existing
+		if source.URL != rawURL || source.Format != string(format) {
+			// The validators belong to the old URL
+			source.ETag = ""
+			source.LastModified = ""
+		}
+	}
+	source.URL = rawURL
+	source.Format = string(format)
+	source.RefreshInterval = refreshInterval
+
+	if err := s.repos.BlockListSource.Save(ctx, source); err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Subscribed list to block list",
+		logging.Int("list_id", listID),
+		logging.String("url", rawURL),
+		logging.String("format", string(format)),
+		logging.Duration("refresh_interval", refreshInterval))
+
+	return s.Refresh(ctx, listID)
+}
+
+// Unsubscribe stops refreshing a list. Entries already imported are kept.
+func (s *BlockListService) Unsubscribe(ctx context.Context, listID int) error {
+	return s.repos.BlockListSource.Delete(ctx, listID)
+}
+
+// Refresh fetches a list's source and imports new entries. The stored ETag
+// and Last-Modified validators are sent so an unchanged list is not
+// downloaded again; the result then has NotModified set.
+func (s *BlockListService) Refresh(ctx context.Context, listID int) (*ImportResult, error) {
+	s.refreshMu.Lock()
+	defer s.refreshMu.Unlock()
+
+	source, err := s.repos.BlockListSource.GetByListID(ctx, listID)
+	if err != nil {
+		return nil, err
+	}
+
+	result, fetchErr := s.fetch(ctx, source)
+
+	now := time.Now()
+	source.LastCheckedAt = &now
+	source.LastError = ""
+	if fetchErr != nil {
+		source.LastError = fetchErr.Error()
+	}
+	if err := s.repos.BlockListSource.Save(ctx, source); err != nil {
+		s.logger.Error("Failed to save block list source",
+			logging.Err(err),
+			logging.Int("list_id", listID))
+	}
+
+	if fetchErr != nil {
+		s.logger.Warn("Block list refresh failed",
+			logging.Err(fetchErr),
+			logging.Int("list_id", listID),
+			logging.String("url", source.URL))
+		return result, fetchErr
+	}
+
+	if result.NotModified {
+		s.logger.Debug("Block list not modified",
+			logging.Int("list_id", listID),
+			logging.String("url", source.URL))
+	} else if result.Imported > 0 && s.onImported != nil {
+		s.onImported(ctx)
+	}
+
+	return result, nil
+}
+
+// RefreshDue refreshes every source whose refresh interval has elapsed.
+// A failing source is logged and does not stop the others.
+func (s *BlockListService) RefreshDue(ctx context.Context) {
+	sources, err := s.repos.BlockListSource.GetAll(ctx)
+	if err != nil {
+		s.logger.Error("Failed to load block list sources", logging.Err(err))
+		return
+	}
+
+	now := time.Now()
+	for _, source := range sources {
+		if ctx.Err() != nil {
+			return
+		}
+		if source.DueForRefresh(now) {
+			// Errors are recorded on the source and logged by Refresh
+			_, _ = s.Refresh(ctx, source.ListID)
+		}
+	}
+}
+
+// Run refreshes due block lists every interval until ctx is cancelled
+func (s *BlockListService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.RefreshDue(ctx)
+		}
+	}
+}
+
+// checkTarget checks that listID is a blacklist and format a block list format
+func (s *BlockListService) checkTarget(ctx context.Context, listID int, format ExportEntriesFormat) error {
+	switch format {
+	case ImportFormatHosts, ImportFormatAdblock, ExportFormatTXT:
+	default:
+		return fmt.Errorf("unsupported block list format: %s", format)
+	}
+
+	list, err := s.repos.List.GetByID(ctx, listID)
+	if err != nil {
+		return fmt.Errorf("invalid list ID: %w", err)
+	}
+	if list.Type != models.ListTypeBlacklist {
+		return fmt.Errorf("block lists can only be imported into a blacklist")
+	}
+	return nil
+}
+
+// fetch downloads a source with a conditional request and imports it,
+// updating the source's validators on success
+func (s *BlockListService) fetch(ctx context.Context, source *models.BlockListSource) (*ImportResult, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create block list request: %w", err)
+	}
+	if source.ETag != "" {
+		req.Header.Set("If-None-Match", source.ETag)
+	}
+	if source.LastModified != "" {
+		req.Header.Set("If-Modified-Since", source.LastModified)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, &BlockListFetchError{URL: source.URL, Err: err}
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return &ImportResult{Errors: make([]BulkCreateError, 0), NotModified: true}, nil
+	case http.StatusOK:
+	default:
+		return nil, &BlockListFetchError{URL: source.URL, Err: fmt.Errorf("server returned %s", resp.Status)}
+	}
+
+	label := ""
+	if parsed, err := url.Parse(source.URL); err == nil {
+		label = parsed.Hostname()
+		if len(label) > models.MaxLabelLength {
+			label = label[:models.MaxLabelLength]
+		}
+	}
+
+	body := &sizeLimitedReader{r: resp.Body, remaining: s.maxSize}
+	result, err := s.entries.ImportEntriesFromReader(ctx, source.ListID, body, ExportEntriesFormat(source.Format), ImportOptions{Label: label})
+	if body.exceeded {
+		return result, &BlockListFetchError{URL: source.URL, Err: fmt.Errorf("block list is larger than %d bytes", s.maxSize)}
+	}
+	if err != nil {
+		return result, err
+	}
+
+	// Only remember the validators once the whole list has been imported,
+	// so a failed import is retried in full
+	source.ETag = resp.Header.Get("ETag")
+	source.LastModified = resp.Header.Get("Last-Modified")
+
+	return result, nil
+}
+
+// errBlockListTooLarge stops an import reading past the size limit
+var errBlockListTooLarge = errors.New("block list too large")
+
+// sizeLimitedReader fails once more than remaining bytes are read, where
+// io.LimitReader would silently end a list that is cut off
+type sizeLimitedReader struct {
+	r         io.Reader
+	remaining int64
+	exceeded  bool
+}
+
+func (l *sizeLimitedReader) Read(p []byte) (int, error) {
+	if l.exceeded {
+		return 0, errBlockListTooLarge
+	}
+	// Read one byte past the limit to tell a list that ends exactly there
+	// from one that goes on
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.r.Read(p)
+	if int64(n) > l.remaining {
+		l.exceeded = true
+		return int(l.remaining), errBlockListTooLarge
+	}
+	l.remaining -= int64(n)
+	return n, err
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"parental-control/internal/logging"
+	"parental-control/internal/models"
+)
+
+func TestBlockListService_RefreshWithETag(t *testing.T) {
+	_, repos, listID := newImportTestService(t)
+	ctx := context.Background()
+
+	var requests, notModified atomic.Int32
+	body := "0.0.0.0 ads.example.com\n0.0.0.0 tracker.example.com\n"
+	etag := `"v1"`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Header.Get("If-None-Match") == etag {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		fmt.Fprint(w, body)
+	}))
+	defer server.Close()
+
+	svc := NewBlockListService(repos, logging.NewDefault())
+	svc.allowIP = func(net.IP) bool { return true }
+	var hookCalls int
+	svc.SetImportHook(func(context.Context) { hookCalls++ })
+
+	result, err := svc.Subscribe(ctx, listID, server.URL+"/hosts.txt", ImportFormatHosts, time.Hour)
+	if err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+	if result.Imported != 2 || result.NotModified {
+		t.Errorf("Expected 2 entries imported, got %+v", result)
+	}
+
+	source, err := repos.BlockListSource.GetByListID(ctx, listID)
+	if err != nil {
+		t.Fatalf("Failed to get source: %v", err)
+	}
+	if source.ETag != etag || source.LastCheckedAt == nil || source.RefreshInterval != time.Hour {
+		t.Errorf("Expected the ETag, check time and interval to be stored, got %+v", source)
+	}
+
+	// Not yet due, so nothing is fetched
+	svc.RefreshDue(ctx)
+	if requests.Load() != 1 {
+		t.Errorf("Expected no refresh before the interval, got %d requests", requests.Load())
+	}
+
+	// An unchanged list is not imported again
+	result, err = svc.Refresh(ctx, listID)
+	if err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	if !result.NotModified || notModified.Load() != 1 {
+		t.Errorf("Expected a not-modified refresh, got %+v", result)
+	}
+
+	// A changed list adds only the new entries
+	body += "0.0.0.0 new.example.com\n"
+	etag = `"v2"`
+	result, err = svc.Refresh(ctx, listID)
+	if err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	if result.Imported != 1 || result.Duplicates != 2 {
+		t.Errorf("Expected 1 new entry and 2 duplicates, got %+v", result.ImportProgress)
+	}
+	if hookCalls != 2 {
+		t.Errorf("Expected the import hook after each import that added entries, got %d calls", hookCalls)
+	}
+
+	// A failed fetch is recorded on the source
+	server.Close()
+	if _, err := svc.Refresh(ctx, listID); err == nil {
+		t.Fatal("Expected an error fetching from a closed server")
+	}
+	source, _ = repos.BlockListSource.GetByListID(ctx, listID)
+	if source.LastError == "" || source.ETag != `"v2"` {
+		t.Errorf("Expected the error to be recorded and the ETag kept, got %+v", source)
+	}
+}
+
+func TestBlockListService_RejectsOversizedList(t *testing.T) {
+	_, repos, listID := newImportTestService(t)
+	ctx := context.Background()
+
+	body := "0.0.0.0 ads.example.com\n0.0.0.0 tracker.example.com\n"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		fmt.Fprint(w, body)
+	}))
+	defer server.Close()
+
+	svc := NewBlockListService(repos, logging.NewDefault())
+	svc.allowIP = func(net.IP) bool { return true }
+	svc.maxSize = int64(len(body)) - 1
+
+	// A cut-off list must not look complete, or its ETag would keep the
+	// truncated import from ever being replaced
+	if _, err := svc.Subscribe(ctx, listID, server.URL, ImportFormatHosts, time.Hour); err == nil {
+		t.Fatal("Expected a list over the size limit to fail")
+	}
+	source, err := repos.BlockListSource.GetByListID(ctx, listID)
+	if err != nil {
+		t.Fatalf("Failed to get source: %v", err)
+	}
+	if source.ETag != "" || source.LastError == "" {
+		t.Errorf("Expected the error recorded and no ETag stored, got %+v", source)
+	}
+
+	// A list exactly at the limit is fine
+	svc.maxSize = int64(len(body))
+	if result, err := svc.Refresh(ctx, listID); err != nil || result.Imported+result.Duplicates != 2 {
+		t.Fatalf("Expected the list at the limit to import, got %+v (%v)", result, err)
+	}
+}
+
+func TestBlockListService_RejectsWhitelist(t *testing.T) {
+	_, repos, _ := newImportTestService(t)
+	ctx := context.Background()
+
+	list := &models.List{Name: "Allowed", Type: models.ListTypeWhitelist, Enabled: true}
+	if err := repos.List.Create(ctx, list); err != nil {
+		t.Fatalf("Failed to create list: %v", err)
+	}
+
+	svc := NewBlockListService(repos, logging.NewDefault())
+	if _, err := svc.Subscribe(ctx, list.ID, "https://example.com/hosts", ImportFormatHosts, 0); err == nil {
+		t.Error("Expected importing a block list into a whitelist to fail")
+	}
+	if _, err := svc.Subscribe(ctx, list.ID, "ftp://example.com/hosts", ImportFormatHosts, 0); err == nil {
+		t.Error("Expected a non-HTTP URL to be rejected")
+	}
+}
+
+func TestBlockListService_RejectsLocalAddresses(t *testing.T) {
+	_, repos, listID := newImportTestService(t)
+	ctx := context.Background()
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		fmt.Fprint(w, "0.0.0.0 ads.example.com\n")
+	}))
+	defer server.Close()
+
+	svc := NewBlockListService(repos, logging.NewDefault())
+	for _, rawURL := range []string{
+		server.URL,
+		"http://localhost/hosts",
+		"http://169.254.169.254/latest/meta-data",
+		"http://[::1]/hosts",
+		"http://0.0.0.0/hosts",
+	} {
+		if _, err := svc.Subscribe(ctx, listID, rawURL, ImportFormatHosts, 0); err == nil {
+			t.Errorf("Expected %s to be rejected", rawURL)
+		}
+	}
+
+	// Addresses are checked when dialed, so a redirect cannot reach them
+	listener, err := net.Listen("tcp", "127.0.0.2:0")
+	if err != nil {
+		t.Skipf("Cannot listen on a second loopback address: %v", err)
+	}
+	redirector := httptest.NewUnstartedServer(http.RedirectHandler(server.URL, http.StatusFound))
+	redirector.Listener.Close()
+	redirector.Listener = listener
+	redirector.Start()
+	defer redirector.Close()
+
+	svc.allowIP = func(ip net.IP) bool { return !ip.Equal(net.IPv4(127, 0, 0, 1)) }
+	if _, err := svc.Subscribe(ctx, listID, redirector.URL, ImportFormatHosts, 0); err == nil {
+		t.Error("Expected a redirect to a refused address to fail")
+	}
+	if requests.Load() != 0 {
+		t.Errorf("Expected no request to reach the local server, got %d", requests.Load())
+	}
+}
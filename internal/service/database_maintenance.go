@@ -0,0 +1,263 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"parental-control/internal/database"
+	"parental-control/internal/logging"
+	"parental-control/internal/models"
+)
+
+// DatabaseMaintenanceConfig holds configuration for WAL checkpoints and VACUUM
+type DatabaseMaintenanceConfig struct {
+	// Enabled runs the maintenance job
+	Enabled bool
+	// CheckpointInterval is how often the WAL is checkpointed, and how often
+	// a due VACUUM is considered
+	CheckpointInterval time.Duration
+	// VacuumInterval is the least time between VACUUMs; 0 never vacuums
+	VacuumInterval time.Duration
+	// VacuumMinFreePercent skips a due VACUUM while less of the file is free
+	VacuumMinFreePercent float64
+	// WindowStart and WindowEnd limit VACUUM to a time of day, as local
+	// HH:MM; empty allows any time
+	WindowStart string
+	WindowEnd   string
+}
+
+// DefaultDatabaseMaintenanceConfig returns maintenance configuration with
+// sensible defaults
+func DefaultDatabaseMaintenanceConfig() DatabaseMaintenanceConfig {
+	defaults := database.DefaultConfig()
+	return DatabaseMaintenanceConfig{
+		Enabled:              defaults.MaintenanceEnabled,
+		CheckpointInterval:   defaults.MaintenanceCheckpointInterval,
+		VacuumInterval:       defaults.MaintenanceVacuumInterval,
+		VacuumMinFreePercent: defaults.MaintenanceVacuumMinFreePercent,
+		WindowStart:          defaults.MaintenanceWindowStart,
+		WindowEnd:            defaults.MaintenanceWindowEnd,
+	}
+}
+
+// DatabaseMaintenanceStats reports what the maintenance job has done
+type DatabaseMaintenanceStats struct {
+	Checkpoints            int64      `json:"checkpoints"`
+	LastCheckpoint         *time.Time `json:"last_checkpoint,omitempty"`
+	LastCheckpointBusy     bool       `json:"last_checkpoint_busy"`
+	LastCheckpointWALBytes int64      `json:"last_checkpoint_wal_bytes"`
+
+	Vacuums               int64         `json:"vacuums"`
+	LastVacuum            *time.Time    `json:"last_vacuum,omitempty"`
+	LastVacuumSizeBefore  int64         `json:"last_vacuum_size_before"`
+	LastVacuumSizeAfter   int64         `json:"last_vacuum_size_after"`
+	LastVacuumDuration    time.Duration `json:"last_vacuum_duration"`
+	LastVacuumFreePercent float64       `json:"last_vacuum_free_percent"`
+	// LastVacuumSkipped is when a due VACUUM was last skipped because
+	// little of the file was free
+	LastVacuumSkipped *time.Time `json:"last_vacuum_skipped,omitempty"`
+
+	// DeferredRuns counts runs put off because a bulk deletion was running
+	DeferredRuns int64      `json:"deferred_runs"`
+	Errors       int64      `json:"errors"`
+	LastError    string     `json:"last_error,omitempty"`
+	LastErrorAt  *time.Time `json:"last_error_at,omitempty"`
+}
+
+// DatabaseMaintenanceService periodically truncates the WAL and, during a
+// quiet window, vacuums the database file once deletions have left enough of
+// it free. Large retention runs hold it off, so a VACUUM never competes with
+// them for the write lock.
+type DatabaseMaintenanceService struct {
+	db     *database.DB
+	logger logging.Logger
+	config DatabaseMaintenanceConfig
+
+	// Read-locked by bulk deletions and write-locked by maintenance runs
+	deletions sync.RWMutex
+
+	// When a VACUUM was last considered inside the window
+	lastVacuumCheck time.Time
+
+	stats   DatabaseMaintenanceStats
+	statsMu sync.RWMutex
+}
+
+// NewDatabaseMaintenanceService creates a new database maintenance service
+func NewDatabaseMaintenanceService(db *database.DB, logger logging.Logger, config DatabaseMaintenanceConfig) *DatabaseMaintenanceService {
+	return &DatabaseMaintenanceService{
+		db:     db,
+		logger: logger,
+		config: config,
+	}
+}
+
+// Run checkpoints the WAL every CheckpointInterval, vacuuming when one is
+// due, until ctx is cancelled
+func (s *DatabaseMaintenanceService) Run(ctx context.Context) {
+	if !s.config.Enabled || s.config.CheckpointInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.config.CheckpointInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.runOnce(ctx, now)
+		}
+	}
+}
+
+// BeginRetention is a RetentionDeletionHook that keeps maintenance from
+// starting until the policy's deletions finish. A retention run that starts
+// during a VACUUM waits for it, as its writes would anyway.
+func (s *DatabaseMaintenanceService) BeginRetention(policy *models.RetentionPolicy) func() {
+	s.deletions.RLock()
+	return s.deletions.RUnlock
+}
+
+// Stats returns a snapshot of the maintenance statistics
+func (s *DatabaseMaintenanceService) Stats() DatabaseMaintenanceStats {
+	s.statsMu.RLock()
+	defer s.statsMu.RUnlock()
+	return s.stats
+}
+
+// runOnce checkpoints the WAL and vacuums if one is due at now. Nothing runs
+// while a bulk deletion holds the lock; the next tick tries again.
+func (s *DatabaseMaintenanceService) runOnce(ctx context.Context, now time.Time) {
+	if !s.deletions.TryLock() {
+		s.statsMu.Lock()
+		s.stats.DeferredRuns++
+		s.statsMu.Unlock()
+		s.logger.Debug("Database maintenance deferred while a retention run deletes")
+		return
+	}
+	defer s.deletions.Unlock()
+
+	s.checkpoint(ctx, now)
+	if s.vacuumDue(now) {
+		s.lastVacuumCheck = now
+		s.vacuum(ctx, now)
+	}
+}
+
+// checkpoint truncates the WAL and records the result
+func (s *DatabaseMaintenanceService) checkpoint(ctx context.Context, now time.Time) {
+	result, err := s.db.Checkpoint(ctx)
+	if err != nil {
+		s.recordError(now, err)
+		return
+	}
+
+	if result.Busy {
+		s.logger.Debug("WAL checkpoint could not finish while the database was busy",
+			logging.Int("wal_frames", result.WALFrames),
+			logging.Int("checkpointed_frames", result.CheckpointedFrames))
+	}
+
+	s.statsMu.Lock()
+	defer s.statsMu.Unlock()
+	s.stats.Checkpoints++
+	s.stats.LastCheckpoint = &now
+	s.stats.LastCheckpointBusy = result.Busy
+	s.stats.LastCheckpointWALBytes = result.WALSizeBefore
+}
+
+// vacuumDue reports whether a VACUUM should be considered at now: the
+// interval has passed since the last one was considered and now is inside
+// the window
+func (s *DatabaseMaintenanceService) vacuumDue(now time.Time) bool {
+	if s.config.VacuumInterval <= 0 {
+		return false
+	}
+	if !s.lastVacuumCheck.IsZero() && now.Sub(s.lastVacuumCheck) < s.config.VacuumInterval {
+		return false
+	}
+	return inMaintenanceWindow(now, s.config.WindowStart, s.config.WindowEnd)
+}
+
+// vacuum rebuilds the database file if enough of it is free pages
+func (s *DatabaseMaintenanceService) vacuum(ctx context.Context, now time.Time) {
+	free, total, err := s.db.FreePages(ctx)
+	if err != nil {
+		s.recordError(now, err)
+		return
+	}
+
+	freePercent := 0.0
+	if total > 0 {
+		freePercent = float64(free) / float64(total) * 100
+	}
+	if freePercent < s.config.VacuumMinFreePercent {
+		s.logger.Debug("Skipping VACUUM; little of the database file is free",
+			logging.Field{Key: "free_percent", Value: freePercent},
+			logging.Field{Key: "min_free_percent", Value: s.config.VacuumMinFreePercent})
+
+		s.statsMu.Lock()
+		s.stats.LastVacuumSkipped = &now
+		s.statsMu.Unlock()
+		return
+	}
+
+	s.logger.Info("Vacuuming the database",
+		logging.Field{Key: "free_percent", Value: freePercent})
+
+	result, err := s.db.Vacuum(ctx)
+	if err != nil {
+		s.recordError(now, err)
+		return
+	}
+
+	s.logger.Info("Database vacuumed",
+		logging.Int("size_before", int(result.SizeBefore)),
+		logging.Int("size_after", int(result.SizeAfter)),
+		logging.Duration("duration", result.Duration))
+
+	s.statsMu.Lock()
+	defer s.statsMu.Unlock()
+	s.stats.Vacuums++
+	s.stats.LastVacuum = &now
+	s.stats.LastVacuumSizeBefore = result.SizeBefore
+	s.stats.LastVacuumSizeAfter = result.SizeAfter
+	s.stats.LastVacuumDuration = result.Duration
+	s.stats.LastVacuumFreePercent = freePercent
+}
+
+// recordError logs a failed maintenance step and keeps it for the stats
+func (s *DatabaseMaintenanceService) recordError(now time.Time, err error) {
+	s.logger.Error("Database maintenance failed", logging.Err(err))
+
+	s.statsMu.Lock()
+	defer s.statsMu.Unlock()
+	s.stats.Errors++
+	s.stats.LastError = err.Error()
+	s.stats.LastErrorAt = &now
+}
+
+// inMaintenanceWindow reports whether now's local time of day falls in the
+// HH:MM window [start, end), which may cross midnight. An empty or
+// unparseable window allows any time.
+func inMaintenanceWindow(now time.Time, start, end string) bool {
+	startTime, err := time.Parse("15:04", start)
+	if err != nil {
+		return true
+	}
+	endTime, err := time.Parse("15:04", end)
+	if err != nil {
+		return true
+	}
+
+	from := startTime.Hour()*60 + startTime.Minute()
+	to := endTime.Hour()*60 + endTime.Minute()
+	minute := now.Hour()*60 + now.Minute()
+	if from <= to {
+		return minute >= from && minute < to
+	}
+	return minute >= from || minute < to
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"parental-control/internal/database"
+	"parental-control/internal/logging"
+)
+
+// newTestMaintenanceService returns a maintenance service for a fresh
+// database, and the database
+func newTestMaintenanceService(t *testing.T, config DatabaseMaintenanceConfig) (*DatabaseMaintenanceService, *database.DB) {
+	t.Helper()
+
+	dbConfig := database.DefaultConfig()
+	dbConfig.Path = filepath.Join(t.TempDir(), "maintenance.db")
+	dbConfig.ReadReplica = false
+	db, err := database.New(dbConfig)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.InitializeSchema(); err != nil {
+		t.Fatalf("Failed to initialize schema: %v", err)
+	}
+
+	return NewDatabaseMaintenanceService(db, logging.NewDefault(), config), db
+}
+
+// freeSomePages fills the database and deletes it all again, leaving free
+// pages for a VACUUM to reclaim
+func freeSomePages(t *testing.T, db *database.DB) {
+	t.Helper()
+
+	value := strings.Repeat("x", 2000)
+	for i := 0; i < 300; i++ {
+		if _, err := db.Connection().Exec(`INSERT INTO config (key, value) VALUES (?, ?)`, fmt.Sprintf("filler_%d", i), value); err != nil {
+			t.Fatalf("Failed to insert data: %v", err)
+		}
+	}
+	if _, err := db.Connection().Exec(`DELETE FROM config WHERE key LIKE 'filler_%'`); err != nil {
+		t.Fatalf("Failed to delete data: %v", err)
+	}
+}
+
+func TestDatabaseMaintenanceService_CheckpointAndVacuum(t *testing.T) {
+	config := DefaultDatabaseMaintenanceConfig()
+	config.WindowStart = "02:00"
+	config.WindowEnd = "05:00"
+	ms, db := newTestMaintenanceService(t, config)
+	freeSomePages(t, db)
+
+	ctx := context.Background()
+	noon := time.Date(2026, 3, 2, 12, 0, 0, 0, time.Local)
+
+	// Outside the window only the WAL is checkpointed
+	ms.runOnce(ctx, noon)
+	stats := ms.Stats()
+	if stats.Checkpoints != 1 || stats.LastCheckpointWALBytes == 0 || stats.Vacuums != 0 {
+		t.Fatalf("Expected one checkpoint and no vacuum, got %+v", stats)
+	}
+
+	night := time.Date(2026, 3, 3, 3, 0, 0, 0, time.Local)
+	ms.runOnce(ctx, night)
+	stats = ms.Stats()
+	if stats.Vacuums != 1 || stats.LastVacuum == nil || !stats.LastVacuum.Equal(night) {
+		t.Fatalf("Expected a vacuum inside the window, got %+v", stats)
+	}
+	if stats.LastVacuumSizeAfter >= stats.LastVacuumSizeBefore {
+		t.Errorf("Expected the vacuum to shrink the database, got %d -> %d bytes",
+			stats.LastVacuumSizeBefore, stats.LastVacuumSizeAfter)
+	}
+
+	// The next night is within the vacuum interval
+	freeSomePages(t, db)
+	ms.runOnce(ctx, night.Add(24*time.Hour))
+	if stats := ms.Stats(); stats.Vacuums != 1 || stats.Checkpoints != 3 {
+		t.Errorf("Expected no second vacuum within the interval, got %+v", stats)
+	}
+
+	// Once due, a file with little free space is left alone
+	config.VacuumMinFreePercent = 100
+	ms.config = config
+	later := night.Add(config.VacuumInterval)
+	ms.runOnce(ctx, later)
+	if stats := ms.Stats(); stats.Vacuums != 1 || stats.LastVacuumSkipped == nil || !stats.LastVacuumSkipped.Equal(later) {
+		t.Errorf("Expected the vacuum to be skipped, got %+v", stats)
+	}
+}
+
+func TestDatabaseMaintenanceService_WaitsForRetention(t *testing.T) {
+	config := DefaultDatabaseMaintenanceConfig()
+	config.WindowStart = ""
+	config.WindowEnd = ""
+	ms, db := newTestMaintenanceService(t, config)
+	freeSomePages(t, db)
+
+	ctx := context.Background()
+	now := time.Now()
+
+	endDeletion := ms.BeginRetention(nil)
+	ms.runOnce(ctx, now)
+	if stats := ms.Stats(); stats.DeferredRuns != 1 || stats.Checkpoints != 0 || stats.Vacuums != 0 {
+		t.Fatalf("Expected maintenance to wait for the retention run, got %+v", stats)
+	}
+	endDeletion()
+
+	ms.runOnce(ctx, now.Add(time.Minute))
+	if stats := ms.Stats(); stats.Checkpoints != 1 || stats.Vacuums != 1 {
+		t.Errorf("Expected maintenance to run once the deletions finished, got %+v", stats)
+	}
+}
+
+func TestInMaintenanceWindow(t *testing.T) {
+	at := func(hour, minute int) time.Time {
+		return time.Date(2026, 3, 2, hour, minute, 0, 0, time.Local)
+	}
+
+	tests := []struct {
+		name       string
+		now        time.Time
+		start, end string
+		want       bool
+	}{
+		{"no window", at(12, 0), "", "", true},
+		{"inside", at(3, 0), "02:00", "05:00", true},
+		{"at the start", at(2, 0), "02:00", "05:00", true},
+		{"at the end", at(5, 0), "02:00", "05:00", false},
+		{"before", at(1, 59), "02:00", "05:00", false},
+		{"overnight late", at(23, 45), "23:00", "04:00", true},
+		{"overnight early", at(3, 59), "23:00", "04:00", true},
+		{"overnight midday", at(12, 0), "23:00", "04:00", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := inMaintenanceWindow(tt.now, tt.start, tt.end); got != tt.want {
+				t.Errorf("inMaintenanceWindow(%s, %q, %q) = %v, want %v",
+					tt.now.Format("15:04"), tt.start, tt.end, got, tt.want)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"path/filepath"
+	"sort"
 	"sync"
 	"time"
 
@@ -22,12 +23,21 @@ type EnforcementService struct {
 	// Notification service
 	notificationService *NotificationService
 
+	// Audit logging for enforcement actions
+	auditService *AuditService
+
+	// Time rules decide when port rules apply
+	timeService *TimeWindowService
+
 	// State management
 	running   bool
 	runningMu sync.RWMutex
+	lifecycle *Lifecycle
 
 	// Rule synchronization
 	syncInterval time.Duration
+	reloadMu     sync.Mutex
+	reloadCh     chan struct{} // signalled when rules change in the database
 	stopCh       chan struct{}
 	wg           sync.WaitGroup
 }
@@ -55,7 +65,11 @@ func NewEnforcementService(
 		logger:              logger,
 		config:              config,
 		notificationService: notificationService,
+		auditService:        auditService,
+		timeService:         NewTimeWindowService(repos, logger),
+		lifecycle:           NewLifecycle(logger, "enforcement"),
 		syncInterval:        10 * time.Second, // Sync rules every 10 seconds
+		reloadCh:            make(chan struct{}, 1),
 		stopCh:              make(chan struct{}),
 	}
 }
@@ -69,7 +83,12 @@ func (es *EnforcementService) Start(ctx context.Context) error {
 		return fmt.Errorf("enforcement service is already running")
 	}
 
-	es.logger.Info("Starting enforcement service")
+	es.lifecycle.Starting(
+		logging.Duration("sync_interval", es.syncInterval),
+		logging.Duration("process_poll_interval", es.config.ProcessPollInterval),
+		logging.Bool("network_filtering", es.config.EnableNetworkFiltering),
+		logging.Bool("emergency_mode", es.config.EnableEmergencyMode),
+		logging.Bool("log_all_activity", es.config.LogAllActivity))
 
 	// Start the enforcement engine
 	if err := es.engine.Start(ctx); err != nil {
@@ -77,7 +96,7 @@ func (es *EnforcementService) Start(ctx context.Context) error {
 	}
 
 	// Perform initial rule synchronization
-	if err := es.SyncRules(ctx); err != nil {
+	if err := es.ReloadRules(ctx); err != nil {
 		es.logger.Error("Initial rule synchronization failed", logging.Err(err))
 		// Don't fail startup - continue with periodic sync
 	}
@@ -88,7 +107,7 @@ func (es *EnforcementService) Start(ctx context.Context) error {
 	es.wg.Add(1)
 	go es.ruleSyncLoop(ctx)
 
-	es.logger.Info("Enforcement service started successfully")
+	es.lifecycle.Started()
 	return nil
 }
 
@@ -101,7 +120,7 @@ func (es *EnforcementService) Stop(ctx context.Context) error {
 		return nil
 	}
 
-	es.logger.Info("Stopping enforcement service")
+	es.lifecycle.Stopping()
 
 	// Signal sync loop to stop
 	close(es.stopCh)
@@ -116,10 +135,22 @@ func (es *EnforcementService) Stop(ctx context.Context) error {
 	}
 
 	es.running = false
-	es.logger.Info("Enforcement service stopped successfully")
+
+	stats := es.GetStats()
+	es.lifecycle.Stopped(stats.NetworkRequestsTotal, stats.ErrorCount,
+		logging.Int("requests_blocked", int(stats.NetworkRequestsBlocked)),
+		logging.Int("enforcement_actions", int(stats.EnforcementActions)))
 	return nil
 }
 
+// FlushAudit writes any pending enforcement audit entries
+func (es *EnforcementService) FlushAudit(ctx context.Context) error {
+	if es.auditService == nil {
+		return nil
+	}
+	return es.auditService.Flush(ctx)
+}
+
 // IsRunning returns true if the enforcement service is running
 func (es *EnforcementService) IsRunning() bool {
 	es.runningMu.RLock()
@@ -127,9 +158,15 @@ func (es *EnforcementService) IsRunning() bool {
 	return es.running
 }
 
-// SyncRules synchronizes rules from the database to the enforcement engine
-func (es *EnforcementService) SyncRules(ctx context.Context) error {
-	es.logger.Debug("Starting rule synchronization")
+// ReloadRules re-reads the rules from the database and swaps them into the
+// enforcement engine in one step, so a DNS query being answered sees either
+// the old rules or the new ones, never a mix
+func (es *EnforcementService) ReloadRules(ctx context.Context) error {
+	// Serialize reloads so an older read of the database never replaces a newer one
+	es.reloadMu.Lock()
+	defer es.reloadMu.Unlock()
+
+	es.logger.Debug("Starting rule reload")
 
 	// Get current rules from enforcement engine
 	currentRules := es.engine.GetCurrentRules()
@@ -140,36 +177,22 @@ func (es *EnforcementService) SyncRules(ctx context.Context) error {
 		return fmt.Errorf("failed to get desired rules: %w", err)
 	}
 
-	es.logger.Debug("Rule sync status",
-		logging.Int("current_rules_count", len(currentRules)),
-		logging.Int("desired_rules_count", len(desiredRules)))
-
-	var rulesAdded, rulesRemoved, rulesSkipped int
+	if err := es.engine.ReplaceNetworkRules(desiredRules); err != nil {
+		return fmt.Errorf("failed to replace network rules: %w", err)
+	}
 
-	// Add new rules that don't exist
+	var rulesAdded, rulesRemoved, rulesChanged int
 	for pattern, rule := range desiredRules {
-		if _, exists := currentRules[pattern]; !exists {
-			if err := es.engine.AddNetworkRule(rule); err != nil {
-				es.logger.Error("Failed to add network rule",
-					logging.Err(err),
-					logging.String("pattern", pattern))
-				rulesSkipped++
-				continue
-			}
+		current, exists := currentRules[pattern]
+		switch {
+		case !exists:
 			rulesAdded++
+		case current.ID != rule.ID || current.Action != rule.Action || current.MatchType != rule.MatchType:
+			rulesChanged++
 		}
 	}
-
-	// Remove rules that no longer exist in database
 	for pattern, rule := range currentRules {
 		if _, exists := desiredRules[pattern]; !exists {
-			if err := es.engine.RemoveNetworkRule(pattern); err != nil {
-				es.logger.Error("Failed to remove network rule",
-					logging.Err(err),
-					logging.String("pattern", pattern))
-				rulesSkipped++
-				continue
-			}
 			rulesRemoved++
 			es.logger.Info("Removed network rule",
 				logging.String("pattern", pattern),
@@ -178,19 +201,25 @@ func (es *EnforcementService) SyncRules(ctx context.Context) error {
 	}
 
 	// Only log at INFO level if there were actual changes
-	if rulesAdded > 0 || rulesRemoved > 0 || rulesSkipped > 0 {
-		es.logger.Info("Rule synchronization completed",
+	if rulesAdded > 0 || rulesRemoved > 0 || rulesChanged > 0 {
+		es.logger.Info("Rule reload completed",
 			logging.Int("rules_added", rulesAdded),
 			logging.Int("rules_removed", rulesRemoved),
-			logging.Int("rules_skipped", rulesSkipped),
-			logging.Int("total_current", len(currentRules)),
-			logging.Int("total_desired", len(desiredRules)))
+			logging.Int("rules_changed", rulesChanged),
+			logging.Int("total_previous", len(currentRules)),
+			logging.Int("total_current", len(desiredRules)))
 	} else {
-		// Use DEBUG level for routine sync with no changes
-		es.logger.Debug("Rule synchronization completed - no changes",
+		// Use DEBUG level for routine reloads with no changes
+		es.logger.Debug("Rule reload completed - no changes",
 			logging.Int("total_rules", len(currentRules)))
 	}
 
+	// Port rules are firewall rules rather than DNS rules
+	if err := es.reloadPortRules(ctx); err != nil {
+		es.logger.Error("Failed to enforce port rules", logging.Err(err))
+		// Don't fail the entire sync - port rules are retried on the next sync
+	}
+
 	// Also enforce executable rules
 	if err := es.enforceExecutableRules(ctx); err != nil {
 		es.logger.Error("Failed to enforce executable rules", logging.Err(err))
@@ -226,8 +255,8 @@ func (es *EnforcementService) getDesiredRulesFromDatabase(ctx context.Context) (
 
 		// Convert entries to enforcement rules
 		for _, entry := range entries {
-			if !entry.Enabled {
-				continue // Skip disabled entries
+			if !entry.Enabled || entry.PatternType == models.PatternTypePort {
+				continue // Skip disabled entries; port entries are firewall rules
 			}
 
 			rule := es.convertEntryToRule(&list, &entry)
@@ -243,10 +272,80 @@ func (es *EnforcementService) getDesiredRulesFromDatabase(ctx context.Context) (
 	return desiredRules, nil
 }
 
+// reloadPortRules blocks the ports of enabled port entries on blacklists
+// that are active under their time rules now. The periodic sync re-checks
+// the time rules, so a scheduled port block starts and ends within one sync
+// interval of its window.
+func (es *EnforcementService) reloadPortRules(ctx context.Context) error {
+	lists, err := es.repos.List.GetAll(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get lists: %w", err)
+	}
+
+	now := time.Now()
+	var rules []*enforcement.FilterRule
+	for _, list := range lists {
+		// Whitelisting a port has nothing to exempt it from
+		if !list.Enabled || list.Type != models.ListTypeBlacklist {
+			continue
+		}
+
+		entries, err := es.repos.ListEntry.GetByListID(ctx, list.ID)
+		if err != nil {
+			es.logger.Error("Failed to get entries for list",
+				logging.Err(err),
+				logging.Int("list_id", list.ID))
+			continue
+		}
+
+		var listChecked, listActive bool
+		for _, entry := range entries {
+			if !entry.Enabled || entry.PatternType != models.PatternTypePort {
+				continue
+			}
+			if !listChecked {
+				listChecked, listActive = true, true
+				if es.repos.TimeRule != nil {
+					if listActive, err = es.timeService.IsListActiveAt(ctx, list.ID, now); err != nil {
+						es.logger.Error("Failed to check time rules for list",
+							logging.Err(err),
+							logging.Int("list_id", list.ID))
+						// Block rather than let a schedule error open the ports
+						listActive = true
+					}
+				}
+			}
+			if !listActive {
+				break
+			}
+
+			if rule := es.convertEntryToRule(&list, &entry); rule != nil {
+				rules = append(rules, rule)
+			}
+		}
+	}
+
+	return es.engine.ReplacePortRules(rules)
+}
+
 // RefreshRules forces an immediate rule refresh
 func (es *EnforcementService) RefreshRules(ctx context.Context) error {
 	es.logger.Debug("Forcing immediate rule refresh")
-	return es.SyncRules(ctx)
+	return es.ReloadRules(ctx)
+}
+
+// HandleRuleChange schedules a reload after a list, entry, time rule or quota
+// rule changes. Changes arriving while a reload is pending share it, so a
+// burst of edits reloads once. Pass it to RepositoryManager.NotifyRuleChanges.
+func (es *EnforcementService) HandleRuleChange(ctx context.Context, change models.RuleChange) {
+	select {
+	case es.reloadCh <- struct{}{}:
+		es.logger.Debug("Rule reload scheduled",
+			logging.String("kind", string(change.Kind)),
+			logging.Int("id", change.ID))
+	default:
+		// A reload is already pending
+	}
 }
 
 // GetStats returns enforcement statistics
@@ -277,6 +376,89 @@ func (es *EnforcementService) GetSystemInfo() map[string]interface{} {
 	return info
 }
 
+// EnforcementStatus is a snapshot of what enforcement is doing
+type EnforcementStatus struct {
+	Running          bool                          `json:"running"`
+	Healthy          bool                          `json:"healthy"` // every enforcement component started cleanly
+	Problems         []string                      `json:"problems,omitempty"`
+	NetworkFiltering bool                          `json:"network_filtering"` // the DNS blocker is answering queries
+	EmergencyMode    bool                          `json:"emergency_mode"`
+	Config           enforcement.EnforcementConfig `json:"config"`
+	Stats            *enforcement.EnforcementStats `json:"stats"`
+	RuleCount        int                           `json:"rule_count"`
+	BlockedPorts     []string                      `json:"blocked_ports"`  // port ranges with firewall rules
+	RuleBlocks       []RuleBlockCount              `json:"rule_blocks"`    // most blocks first
+	RecentActions    []models.AuditLog             `json:"recent_actions"` // newest first
+}
+
+// RuleBlockCount is how many DNS queries a network rule has blocked since
+// enforcement started
+type RuleBlockCount struct {
+	RuleID   string `json:"rule_id"`
+	RuleName string `json:"rule_name,omitempty"` // empty once the rule is removed
+	Pattern  string `json:"pattern,omitempty"`
+	Blocks   int64  `json:"blocks"`
+}
+
+// GetStatus returns the enforcement status with up to recentActions of the
+// latest enforcement actions from the audit log
+func (es *EnforcementService) GetStatus(ctx context.Context, recentActions int) *EnforcementStatus {
+	status := &EnforcementStatus{
+		Running:       es.IsRunning(),
+		EmergencyMode: es.config.EnableEmergencyMode,
+		Config:        es.config,
+		Stats:         es.GetStats(),
+		RuleBlocks:    []RuleBlockCount{},
+		RecentActions: []models.AuditLog{},
+	}
+	if es.engine == nil {
+		status.Problems = []string{"enforcement engine is not available"}
+		return status
+	}
+
+	status.Problems = es.engine.Problems()
+	if !status.Running && len(status.Problems) == 0 {
+		status.Problems = []string{"enforcement service is not running"}
+	}
+	status.Healthy = len(status.Problems) == 0
+	status.NetworkFiltering = es.engine.IsNetworkFilteringRunning()
+
+	rulesByID := make(map[string]*enforcement.FilterRule)
+	for _, rule := range es.engine.GetCurrentRules() {
+		rulesByID[rule.ID] = rule
+	}
+	status.RuleCount = len(rulesByID)
+	status.BlockedPorts = es.engine.GetBlockedPorts()
+	for id, blocks := range es.engine.GetRuleBlockCounts() {
+		count := RuleBlockCount{RuleID: id, Blocks: blocks}
+		if rule, ok := rulesByID[id]; ok {
+			count.RuleName = rule.Name
+			count.Pattern = rule.Pattern
+		}
+		status.RuleBlocks = append(status.RuleBlocks, count)
+	}
+	sort.Slice(status.RuleBlocks, func(i, j int) bool {
+		if status.RuleBlocks[i].Blocks != status.RuleBlocks[j].Blocks {
+			return status.RuleBlocks[i].Blocks > status.RuleBlocks[j].Blocks
+		}
+		return status.RuleBlocks[i].RuleID < status.RuleBlocks[j].RuleID
+	})
+
+	if es.auditService != nil && es.repos != nil && es.repos.AuditLog != nil && recentActions > 0 {
+		logs, _, err := es.auditService.GetAuditLogs(ctx, AuditLogFilters{
+			EventType: "enforcement_action",
+			Limit:     recentActions,
+		})
+		if err != nil {
+			es.logger.Error("Failed to get recent enforcement actions", logging.Err(err))
+		} else if logs != nil {
+			status.RecentActions = logs
+		}
+	}
+
+	return status
+}
+
 // GetNotificationService returns the notification service
 func (es *EnforcementService) GetNotificationService() *NotificationService {
 	return es.notificationService
@@ -360,19 +542,6 @@ func (es *EnforcementService) convertEntryToRule(list *models.List, entry *model
 		return nil
 	}
 
-	// Determine match type based on pattern type
-	var matchType enforcement.MatchType
-	switch entry.PatternType {
-	case models.PatternTypeExact:
-		matchType = enforcement.MatchExact
-	case models.PatternTypeWildcard:
-		matchType = enforcement.MatchWildcard
-	case models.PatternTypeDomain:
-		matchType = enforcement.MatchDomain
-	default:
-		matchType = enforcement.MatchExact // Default fallback
-	}
-
 	// Generate unique rule ID and name
 	ruleID := fmt.Sprintf("rule_%d_%d", list.ID, entry.ID)
 	ruleName := fmt.Sprintf("%s_%s_%d", list.Name, entry.EntryType, entry.ID)
@@ -382,7 +551,7 @@ func (es *EnforcementService) convertEntryToRule(list *models.List, entry *model
 		Name:      ruleName,
 		Pattern:   entry.Pattern,
 		Action:    action,
-		MatchType: matchType,
+		MatchType: matchTypeFor(entry.PatternType),
 		Priority:  1, // Default priority
 		Enabled:   entry.Enabled,
 		CreatedAt: entry.CreatedAt,
@@ -390,6 +559,24 @@ func (es *EnforcementService) convertEntryToRule(list *models.List, entry *model
 	}
 }
 
+// matchTypeFor returns the enforcement match type for an entry pattern type
+func matchTypeFor(patternType models.PatternType) enforcement.MatchType {
+	switch patternType {
+	case models.PatternTypeWildcard:
+		return enforcement.MatchWildcard
+	case models.PatternTypeDomain:
+		return enforcement.MatchDomain
+	case models.PatternTypeRegex:
+		return enforcement.MatchRegex
+	case models.PatternTypeCIDR:
+		return enforcement.MatchCIDR
+	case models.PatternTypePort:
+		return enforcement.MatchPort
+	default:
+		return enforcement.MatchExact
+	}
+}
+
 // getExecutableRulesFromDatabase gets all executable entries that should be enforced
 func (es *EnforcementService) getExecutableRulesFromDatabase(ctx context.Context) ([]models.ListEntry, error) {
 	var executableEntries []models.ListEntry
@@ -455,7 +642,7 @@ func (es *EnforcementService) enforceExecutableRules(ctx context.Context) error
 	// Check each process against executable rules
 	for _, process := range processes {
 		for _, rule := range executableRules {
-			if es.processMatchesRule(process, rule) {
+			if processMatchesRule(process, rule) {
 				es.logger.Info("Process matches blocked executable rule",
 					logging.String("process", process.Name),
 					logging.Int("pid", process.PID),
@@ -494,7 +681,7 @@ func (es *EnforcementService) enforceExecutableRules(ctx context.Context) error
 }
 
 // processMatchesRule checks if a process matches an executable rule
-func (es *EnforcementService) processMatchesRule(process *enforcement.ProcessInfo, rule models.ListEntry) bool {
+func processMatchesRule(process *enforcement.ProcessInfo, rule models.ListEntry) bool {
 	switch rule.PatternType {
 	case models.PatternTypeExact:
 		// Exact match on process name or path
@@ -504,6 +691,10 @@ func (es *EnforcementService) processMatchesRule(process *enforcement.ProcessInf
 		nameMatched, _ := filepath.Match(rule.Pattern, process.Name)
 		pathMatched, _ := filepath.Match(rule.Pattern, process.Path)
 		return nameMatched || pathMatched
+	case models.PatternTypeRegex:
+		// Regex match on process name or path, compiled once per pattern
+		re, err := enforcement.CompileRegex(rule.Pattern)
+		return err == nil && (re.MatchString(process.Name) || re.MatchString(process.Path))
 	default:
 		// Default to exact match
 		return process.Name == rule.Pattern || process.Path == rule.Pattern
@@ -524,11 +715,17 @@ func (es *EnforcementService) ruleSyncLoop(ctx context.Context) {
 		case <-es.stopCh:
 			return
 		case <-ticker.C:
-			if err := es.SyncRules(ctx); err != nil {
+			if err := es.ReloadRules(ctx); err != nil {
 				es.logger.Error("Periodic rule synchronization failed",
 					logging.Err(err),
 					logging.String("sync_type", "periodic"))
 			}
+		case <-es.reloadCh:
+			if err := es.ReloadRules(ctx); err != nil {
+				es.logger.Error("Rule reload after change failed",
+					logging.Err(err),
+					logging.String("sync_type", "change"))
+			}
 		}
 	}
 }
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"parental-control/internal/enforcement"
+	"parental-control/internal/logging"
+	"parental-control/internal/models"
+)
+
+func TestHandleRuleChange_CoalescesReloads(t *testing.T) {
+	es := &EnforcementService{logger: logging.NewDefault(), reloadCh: make(chan struct{}, 1)}
+	ctx := context.Background()
+
+	for id := 1; id <= 3; id++ {
+		es.HandleRuleChange(ctx, models.RuleChange{Kind: models.RuleChangeEntry, ID: id})
+	}
+	if len(es.reloadCh) != 1 {
+		t.Fatalf("Expected a burst of changes to schedule one reload, got %d", len(es.reloadCh))
+	}
+
+	<-es.reloadCh
+	es.HandleRuleChange(ctx, models.RuleChange{Kind: models.RuleChangeList, ID: 1})
+	if len(es.reloadCh) != 1 {
+		t.Error("Expected a change after the reload started to schedule another")
+	}
+}
+
+func TestGetStatus_NotStarted(t *testing.T) {
+	config := enforcement.EnforcementConfig{EnableEmergencyMode: true}
+	es := NewEnforcementService(&models.RepositoryManager{}, logging.NewDefault(), config, nil)
+	if err := es.engine.AddNetworkRule(&enforcement.FilterRule{ID: "rule_1_1", Pattern: "games.example.com", Action: enforcement.ActionBlock, Enabled: true}); err != nil {
+		t.Fatalf("Failed to add rule: %v", err)
+	}
+
+	status := es.GetStatus(context.Background(), 10)
+	if status.Running || status.Healthy || status.NetworkFiltering {
+		t.Errorf("Expected a stopped service to be reported unhealthy, got %+v", status)
+	}
+	if len(status.Problems) != 1 || status.Problems[0] != "enforcement engine is not running" {
+		t.Errorf("Expected the stopped engine to be the problem, got %v", status.Problems)
+	}
+	if !status.EmergencyMode || status.RuleCount != 1 {
+		t.Errorf("Expected emergency mode and one rule, got %+v", status)
+	}
+	if status.RuleBlocks == nil || status.RecentActions == nil {
+		t.Error("Expected empty block counts and actions rather than nil")
+	}
+}
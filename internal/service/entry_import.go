@@ -0,0 +1,325 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"parental-control/internal/logging"
+	"parental-control/internal/models"
+)
+
+const (
+	// DefaultImportBatchSize is the number of entries inserted per transaction
+	DefaultImportBatchSize = 1000
+
+	// maxImportLineLength bounds a single line of import data
+	maxImportLineLength = 64 * 1024
+
+	// maxImportErrors caps the per-entry errors kept in an import result
+	maxImportErrors = 100
+)
+
+// hostsFileAliases are hostnames in hosts files that must never be imported
+var hostsFileAliases = map[string]bool{
+	"localhost":             true,
+	"localhost.localdomain": true,
+	"local":                 true,
+	"broadcasthost":         true,
+	"ip6-localhost":         true,
+	"ip6-loopback":          true,
+	"ip6-localnet":          true,
+	"ip6-mcastprefix":       true,
+	"ip6-allnodes":          true,
+	"ip6-allrouters":        true,
+	"ip6-allhosts":          true,
+	"0.0.0.0":               true,
+}
+
+// ImportOptions tunes a bulk import
+type ImportOptions struct {
+	// BatchSize is the number of entries inserted per transaction
+	BatchSize int
+	// Progress is called after each batch is written
+	Progress func(ImportProgress)
+	// Label is set on every imported entry, such as the block list's name
+	Label string
+}
+
+// ImportProgress reports how far an import has got
+type ImportProgress struct {
+	LinesRead  int `json:"lines_read"`
+	Imported   int `json:"imported"`
+	Duplicates int `json:"duplicates"`
+	Invalid    int `json:"invalid"`
+	// Skipped counts block list rules that cannot be expressed as entries,
+	// such as exceptions, cosmetic filters and rules with unsupported options
+	Skipped int `json:"skipped"`
+	Batches int `json:"batches"`
+}
+
+// ImportResult summarizes a completed import
+type ImportResult struct {
+	ImportProgress
+	Duration time.Duration     `json:"duration"`
+	Errors   []BulkCreateError `json:"errors,omitempty"`
+	// NotModified is set when a block list URL reported no changes since
+	// the last fetch, so nothing was imported
+	NotModified bool `json:"not_modified,omitempty"`
+}
+
+// ImportEntriesFromReader streams entries from r into a list. Entries are
+// validated and de-duplicated as they are read and written in batched
+// transactions, so large lists such as hosts files never sit in memory whole.
+// New entries are tagged with the import source; entries already in the list,
+// including manual ones, are left untouched.
+// Entries committed before a read error are kept.
+func (s *EntryManagementService) ImportEntriesFromReader(ctx context.Context, listID int, r io.Reader, format ExportEntriesFormat, opts ImportOptions) (*ImportResult, error) {
+	var parseLine func(line string, listID int) ([]CreateEntryRequest, bool)
+	switch format {
+	case ExportFormatTXT, ImportFormatHosts:
+		parseLine = func(line string, listID int) ([]CreateEntryRequest, bool) {
+			return parseTXTLine(line, listID), false
+		}
+	case ImportFormatAdblock:
+		parseLine = parseAdblockLine
+	default:
+		return nil, fmt.Errorf("unsupported import format: %s", format)
+	}
+
+	if _, err := s.repos.List.GetByID(ctx, listID); err != nil {
+		return nil, fmt.Errorf("invalid list ID: %w", err)
+	}
+
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultImportBatchSize
+	}
+
+	s.logger.Info("Importing entries",
+		logging.Int("list_id", listID),
+		logging.String("format", string(format)),
+		logging.Int("batch_size", batchSize))
+
+	start := time.Now()
+	result := &ImportResult{Errors: make([]BulkCreateError, 0)}
+	seen := make(map[string]struct{})
+	batch := make([]models.ListEntry, 0, batchSize)
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		inserted, err := s.repos.ListEntry.CreateBatch(ctx, batch)
+		if err != nil {
+			return fmt.Errorf("failed to import batch: %w", err)
+		}
+		result.Imported += inserted
+		result.Duplicates += len(batch) - inserted
+		result.Batches++
+		batch = batch[:0]
+
+		if opts.Progress != nil {
+			opts.Progress(result.ImportProgress)
+		}
+		return nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxImportLineLength)
+
+	for scanner.Scan() {
+		result.LinesRead++
+
+		requests, skipped := parseLine(scanner.Text(), listID)
+		if skipped {
+			result.Skipped++
+		}
+
+		for _, req := range requests {
+			if err := s.validatePattern(req.Pattern, req.EntryType, req.PatternType); err != nil {
+				result.Invalid++
+				if len(result.Errors) < maxImportErrors {
+					result.Errors = append(result.Errors, BulkCreateError{
+						Index:   result.LinesRead,
+						Pattern: req.Pattern,
+						Error:   err.Error(),
+					})
+				}
+				continue
+			}
+
+			key := string(req.EntryType) + "\x00" + req.Pattern
+			if _, exists := seen[key]; exists {
+				result.Duplicates++
+				continue
+			}
+			seen[key] = struct{}{}
+
+			batch = append(batch, models.ListEntry{
+				ListID:      listID,
+				EntryType:   req.EntryType,
+				Pattern:     req.Pattern,
+				PatternType: req.PatternType,
+				Description: req.Description,
+				Label:       opts.Label,
+				Source:      models.EntrySourceImport,
+				Enabled:     req.Enabled,
+			})
+			if len(batch) >= batchSize {
+				if err := flush(); err != nil {
+					return result, err
+				}
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return result, fmt.Errorf("failed to read import data at line %d: %w", result.LinesRead+1, err)
+	}
+
+	if err := flush(); err != nil {
+		return result, err
+	}
+	result.Duration = time.Since(start)
+
+	s.logger.Info("Import completed",
+		logging.Int("list_id", listID),
+		logging.Int("imported", result.Imported),
+		logging.Int("duplicates", result.Duplicates),
+		logging.Int("invalid", result.Invalid),
+		logging.Int("skipped", result.Skipped),
+		logging.String("duration", result.Duration.String()))
+
+	return result, nil
+}
+
+// parseTXTLine parses one line of a text import: a single pattern per line,
+// or a hosts file line ("0.0.0.0 ads.example.com tracker.example.com") which
+// yields a domain entry per hostname
+func parseTXTLine(line string, listID int) []CreateEntryRequest {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil // Skip empty lines and comments
+	}
+
+	fields := strings.Fields(line)
+	if len(fields) > 1 && net.ParseIP(fields[0]) != nil {
+		var entries []CreateEntryRequest
+		for _, host := range fields[1:] {
+			if strings.HasPrefix(host, "#") {
+				break // Trailing comment
+			}
+			host = strings.ToLower(strings.TrimSuffix(host, "."))
+			if hostsFileAliases[host] {
+				continue
+			}
+			entries = append(entries, CreateEntryRequest{
+				ListID:      listID,
+				EntryType:   models.EntryTypeURL,
+				Pattern:     host,
+				PatternType: models.PatternTypeDomain,
+				Enabled:     true,
+			})
+		}
+		return entries
+	}
+
+	// Try to determine if it's a URL or executable
+	entryType := models.EntryTypeExecutable
+	patternType := models.PatternTypeExact
+
+	if strings.HasPrefix(line, "http://") || strings.HasPrefix(line, "https://") || strings.Contains(line, ".") {
+		entryType = models.EntryTypeURL
+		if strings.Contains(line, "*") || strings.Contains(line, "?") {
+			patternType = models.PatternTypeWildcard
+		}
+	}
+
+	return []CreateEntryRequest{{
+		ListID:      listID,
+		EntryType:   entryType,
+		Pattern:     line,
+		PatternType: patternType,
+		Enabled:     true,
+	}}
+}
+
+// adblockAllowedOptions are the rule options that still make sense for a
+// whole-host block; rules with any other option are skipped
+var adblockAllowedOptions = map[string]bool{
+	"important": true,
+	"all":       true,
+	"document":  true,
+	"doc":       true,
+}
+
+// parseAdblockLine parses one line of an AdGuard or EasyList style list.
+// Host rules become URL entries: "||ads.example.com^" blocks the host and its
+// subdomains, "|ads.example.com^" only the host itself, and a rule with "*"
+// becomes a wildcard. Plain domains and hosts file lines are accepted as in
+// text imports. Comments yield nothing; rules this filter cannot enforce,
+// such as exceptions, cosmetic filters and URL paths, are reported as skipped.
+func parseAdblockLine(line string, listID int) ([]CreateEntryRequest, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "!") || strings.HasPrefix(line, "[") {
+		return nil, false // Skip empty lines, comments and "[Adblock Plus 2.0]" headers
+	}
+
+	// Cosmetic and scriptlet filters act on page content, and "@@" rules
+	// unblock; neither can be expressed as a block entry
+	for _, marker := range []string{"##", "#@#", "#?#", "#$#", "#%#"} {
+		if strings.Contains(line, marker) {
+			return nil, true
+		}
+	}
+	if strings.HasPrefix(line, "@@") {
+		return nil, true
+	}
+	if strings.HasPrefix(line, "#") {
+		return nil, false
+	}
+
+	if fields := strings.Fields(line); len(fields) > 1 && net.ParseIP(fields[0]) != nil {
+		return parseTXTLine(line, listID), false
+	}
+
+	rule := line
+	if i := strings.LastIndex(rule, "$"); i >= 0 {
+		for _, option := range strings.Split(rule[i+1:], ",") {
+			if !adblockAllowedOptions[strings.ToLower(strings.TrimSpace(option))] {
+				return nil, true
+			}
+		}
+		rule = rule[:i]
+	}
+
+	patternType := models.PatternTypeDomain
+	switch {
+	case strings.HasPrefix(rule, "||"):
+		rule = rule[2:]
+	case strings.HasPrefix(rule, "|"):
+		rule = rule[1:]
+		patternType = models.PatternTypeExact
+	}
+	rule = strings.TrimSuffix(strings.TrimSuffix(rule, "|"), "^")
+
+	host := strings.ToLower(strings.TrimSuffix(rule, "."))
+	if host == "" || strings.ContainsAny(host, "/:^|") || hostsFileAliases[host] {
+		return nil, true // Not a plain host rule
+	}
+	if strings.Contains(host, "*") {
+		patternType = models.PatternTypeWildcard
+	}
+
+	return []CreateEntryRequest{{
+		ListID:      listID,
+		EntryType:   models.EntryTypeURL,
+		Pattern:     host,
+		PatternType: patternType,
+		Enabled:     true,
+	}}, false
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"parental-control/internal/database"
+	"parental-control/internal/logging"
+	"parental-control/internal/models"
+)
+
+// newImportTestService returns an entry service backed by a fresh database
+// and the ID of an empty blacklist
+func newImportTestService(tb testing.TB) (*EntryManagementService, *models.RepositoryManager, int) {
+	tb.Helper()
+
+	config := database.DefaultConfig()
+	config.Path = filepath.Join(tb.TempDir(), "import.db")
+	db, err := database.New(config)
+	if err != nil {
+		tb.Fatalf("Failed to create database: %v", err)
+	}
+	tb.Cleanup(func() { db.Close() })
+	if err := db.InitializeSchema(); err != nil {
+		tb.Fatalf("Failed to initialize schema: %v", err)
+	}
+
+	repos := &models.RepositoryManager{
+		List:            database.NewListRepository(db.Connection()),
+		ListEntry:       database.NewListEntryRepository(db.Connection()),
+		BlockListSource: database.NewBlockListSourceRepository(db.Connection()),
+	}
+	list := &models.List{Name: "Imported", Type: models.ListTypeBlacklist, Enabled: true}
+	if err := repos.List.Create(context.Background(), list); err != nil {
+		tb.Fatalf("Failed to create list: %v", err)
+	}
+
+	return NewEntryManagementService(repos, logging.NewDefault()), repos, list.ID
+}
+
+// syntheticHostsFile streams a hosts file with count unique domains
+func syntheticHostsFile(count int) io.Reader {
+	pr, pw := io.Pipe()
+	go func() {
+		fmt.Fprintln(pw, "# Synthetic block list")
+		fmt.Fprintln(pw, "127.0.0.1 localhost")
+		for i := 0; i < count; i++ {
+			fmt.Fprintf(pw, "0.0.0.0 ads%d.example.com\n", i)
+		}
+		pw.Close()
+	}()
+	return pr
+}
+
+func TestImportEntriesFromReader(t *testing.T) {
+	svc, repos, listID := newImportTestService(t)
+	ctx := context.Background()
+
+	// An entry already in the list is skipped
+	existing := &models.ListEntry{ListID: listID, EntryType: models.EntryTypeURL, Pattern: "ads1.example.com", PatternType: models.PatternTypeDomain, Enabled: true}
+	if err := repos.ListEntry.Create(ctx, existing); err != nil {
+		t.Fatalf("Failed to create existing entry: %v", err)
+	}
+
+	data := strings.Join([]string{
+		"# comment",
+		"0.0.0.0 ads0.example.com ads1.example.com # trailing comment",
+		"0.0.0.0 ads0.example.com",
+		"ads2.example.com",
+		"0.0.0.0 bad_domain",
+		"",
+	}, "\n")
+
+	var progress []ImportProgress
+	result, err := svc.ImportEntriesFromReader(ctx, listID, strings.NewReader(data), ExportFormatTXT, ImportOptions{
+		BatchSize: 1,
+		Progress:  func(p ImportProgress) { progress = append(progress, p) },
+		Label:     "ads",
+	})
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+
+	if result.Imported != 2 || result.Duplicates != 2 || result.Invalid != 1 {
+		t.Errorf("Expected 2 imported, 2 duplicates, 1 invalid, got %+v", result.ImportProgress)
+	}
+	if len(result.Errors) != 1 || result.Errors[0].Index != 5 {
+		t.Errorf("Expected an error for line 5, got %+v", result.Errors)
+	}
+	if len(progress) != result.Batches || progress[len(progress)-1].Imported != 2 {
+		t.Errorf("Expected progress after each of %d batches, got %+v", result.Batches, progress)
+	}
+
+	entries, err := repos.ListEntry.GetByListID(ctx, listID)
+	if err != nil || len(entries) != 3 {
+		t.Fatalf("Expected 3 entries in the list, got %d (%v)", len(entries), err)
+	}
+
+	// The manual entry keeps its source; imported entries are tagged
+	for _, entry := range entries {
+		want, wantLabel := models.EntrySourceImport, "ads"
+		if entry.Pattern == "ads1.example.com" {
+			want, wantLabel = models.EntrySourceManual, ""
+		}
+		if entry.Source != want || entry.Label != wantLabel {
+			t.Errorf("Expected %s to have source %q and label %q, got %q and %q", entry.Pattern, want, wantLabel, entry.Source, entry.Label)
+		}
+	}
+}
+
+func TestImportEntriesFromReader_Adblock(t *testing.T) {
+	svc, repos, listID := newImportTestService(t)
+	ctx := context.Background()
+
+	data := strings.Join([]string{
+		"[Adblock Plus 2.0]",
+		"! Title: Test list",
+		"||ads.example.com^",
+		"||ADS.example.com^$important",
+		"|exact.example.com^",
+		"||cdn*.tracker.example^",
+		"0.0.0.0 hosts.example.com",
+		"plain.example.com",
+		"@@||allowed.example.com^",
+		"example.com##.banner",
+		"||media.example.com^$third-party",
+		"||example.com/ads/*",
+		"# comment",
+	}, "\n")
+
+	result, err := svc.ImportEntriesFromReader(ctx, listID, strings.NewReader(data), ImportFormatAdblock, ImportOptions{})
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if result.Imported != 5 || result.Duplicates != 1 || result.Skipped != 4 || result.Invalid != 0 {
+		t.Errorf("Expected 5 imported, 1 duplicate, 4 skipped, got %+v", result.ImportProgress)
+	}
+
+	entries, err := repos.ListEntry.GetByListID(ctx, listID)
+	if err != nil {
+		t.Fatalf("Failed to get entries: %v", err)
+	}
+	want := map[string]models.PatternType{
+		"ads.example.com":      models.PatternTypeDomain,
+		"exact.example.com":    models.PatternTypeExact,
+		"cdn*.tracker.example": models.PatternTypeWildcard,
+		"hosts.example.com":    models.PatternTypeDomain,
+		"plain.example.com":    models.PatternTypeDomain,
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("Expected %d entries, got %d", len(want), len(entries))
+	}
+	for _, entry := range entries {
+		if patternType, ok := want[entry.Pattern]; !ok || entry.PatternType != patternType || entry.EntryType != models.EntryTypeURL {
+			t.Errorf("Unexpected entry %s (%s, %s)", entry.Pattern, entry.EntryType, entry.PatternType)
+		}
+	}
+}
+
+func TestImportEntriesFromReader_LargeList(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping large import in short mode")
+	}
+
+	svc, repos, listID := newImportTestService(t)
+	ctx := context.Background()
+
+	const entries = 20000
+	result, err := svc.ImportEntriesFromReader(ctx, listID, syntheticHostsFile(entries), ExportFormatTXT, ImportOptions{BatchSize: 2500})
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if result.Imported != entries || result.Batches != 8 {
+		t.Errorf("Expected %d entries in 8 batches, got %+v", entries, result.ImportProgress)
+	}
+
+	// Re-importing the same list adds nothing
+	result, err = svc.ImportEntriesFromReader(ctx, listID, syntheticHostsFile(entries), ExportFormatTXT, ImportOptions{BatchSize: 2500})
+	if err != nil {
+		t.Fatalf("Re-import failed: %v", err)
+	}
+	if result.Imported != 0 || result.Duplicates != entries {
+		t.Errorf("Expected all %d entries to be duplicates, got %+v", entries, result.ImportProgress)
+	}
+
+	if count, _ := repos.ListEntry.CountByListID(ctx, listID); count != entries {
+		t.Errorf("Expected %d entries in the list, got %d", entries, count)
+	}
+}
+
+func BenchmarkImportEntriesFromReader(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		svc, _, listID := newImportTestService(b)
+		b.StartTimer()
+
+		if _, err := svc.ImportEntriesFromReader(context.Background(), listID, syntheticHostsFile(100000), ExportFormatTXT, ImportOptions{}); err != nil {
+			b.Fatalf("Import failed: %v", err)
+		}
+	}
+}
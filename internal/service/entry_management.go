@@ -1,14 +1,18 @@
 package service
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"net"
 	"net/url"
 	"path/filepath"
 	"regexp"
 	"strings"
 	"time"
 
+	"parental-control/internal/enforcement"
 	"parental-control/internal/logging"
 	"parental-control/internal/models"
 )
@@ -32,16 +36,35 @@ type CreateEntryRequest struct {
 	ListID      int                `json:"list_id" validate:"required"`
 	EntryType   models.EntryType   `json:"entry_type" validate:"required,oneof=executable url"`
 	Pattern     string             `json:"pattern" validate:"required,max=1000"`
-	PatternType models.PatternType `json:"pattern_type" validate:"required,oneof=exact wildcard domain"`
+	PatternType models.PatternType `json:"pattern_type" validate:"required,oneof=exact wildcard domain regex cidr port"`
 	Description string             `json:"description"`
+	Label       string             `json:"label,omitempty" validate:"max=64"`
+	Notes       string             `json:"notes,omitempty"`
 	Enabled     bool               `json:"enabled"`
+	OnConflict  ConflictAction     `json:"on_conflict,omitempty" validate:"omitempty,oneof=error skip update"`
 }
 
+// ConflictAction selects what creating an entry does when the list already
+// has an entry with the same type and pattern
+type ConflictAction string
+
+const (
+	// ConflictError rejects the new entry with models.ErrDuplicateEntry (default)
+	ConflictError ConflictAction = "error"
+	// ConflictSkip keeps the existing entry unchanged
+	ConflictSkip ConflictAction = "skip"
+	// ConflictUpdate overwrites the existing entry's pattern type, description,
+	// label, notes and enabled state
+	ConflictUpdate ConflictAction = "update"
+)
+
 // UpdateEntryRequest represents a request to update an existing entry
 type UpdateEntryRequest struct {
 	Pattern     *string             `json:"pattern,omitempty" validate:"omitempty,max=1000"`
-	PatternType *models.PatternType `json:"pattern_type,omitempty" validate:"omitempty,oneof=exact wildcard domain"`
+	PatternType *models.PatternType `json:"pattern_type,omitempty" validate:"omitempty,oneof=exact wildcard domain regex cidr port"`
 	Description *string             `json:"description,omitempty"`
+	Label       *string             `json:"label,omitempty" validate:"omitempty,max=64"`
+	Notes       *string             `json:"notes,omitempty"`
 	Enabled     *bool               `json:"enabled,omitempty"`
 }
 
@@ -49,12 +72,18 @@ type UpdateEntryRequest struct {
 type BulkCreateEntriesRequest struct {
 	ListID  int                  `json:"list_id" validate:"required"`
 	Entries []CreateEntryRequest `json:"entries" validate:"required,dive"`
+	// OnConflict applies to entries that do not set their own
+	OnConflict ConflictAction `json:"on_conflict,omitempty" validate:"omitempty,oneof=error skip update"`
 }
 
 // BulkCreateResult represents the result of a bulk create operation
 type BulkCreateResult struct {
-	SuccessCount int               `json:"success_count"`
-	FailureCount int               `json:"failure_count"`
+	SuccessCount int `json:"success_count"`
+	FailureCount int `json:"failure_count"`
+	// DuplicateCount counts entries skipped because the list already had them
+	DuplicateCount int `json:"duplicate_count"`
+	// UpdatedCount counts existing entries overwritten by ConflictUpdate
+	UpdatedCount int               `json:"updated_count"`
 	Errors       []BulkCreateError `json:"errors,omitempty"`
 	CreatedIDs   []int             `json:"created_ids"`
 }
@@ -73,10 +102,32 @@ const (
 	ExportFormatJSON ExportEntriesFormat = "json"
 	ExportFormatCSV  ExportEntriesFormat = "csv"
 	ExportFormatTXT  ExportEntriesFormat = "txt"
+
+	// ImportFormatHosts and ImportFormatAdblock are import-only block list
+	// formats: /etc/hosts style lists and AdGuard/EasyList network rules
+	ImportFormatHosts   ExportEntriesFormat = "hosts"
+	ImportFormatAdblock ExportEntriesFormat = "adblock"
 )
 
-// CreateEntry creates a new list entry with validation
+// CreateEntry creates a new list entry with validation. When the list already
+// has the pattern, req.OnConflict decides whether to fail, return the existing
+// entry or update it.
 func (s *EntryManagementService) CreateEntry(ctx context.Context, req CreateEntryRequest) (*models.ListEntry, error) {
+	entry, _, err := s.createEntry(ctx, req)
+	return entry, err
+}
+
+// entryOutcome reports what createEntry did
+type entryOutcome int
+
+const (
+	entryCreated entryOutcome = iota
+	entrySkipped
+	entryUpdated
+)
+
+// createEntry creates an entry, resolving conflicts per req.OnConflict
+func (s *EntryManagementService) createEntry(ctx context.Context, req CreateEntryRequest) (*models.ListEntry, entryOutcome, error) {
 	s.logger.Info("Creating new entry",
 		logging.Int("list_id", req.ListID),
 		logging.String("type", string(req.EntryType)),
@@ -84,35 +135,76 @@ func (s *EntryManagementService) CreateEntry(ctx context.Context, req CreateEntr
 
 	// Validate the request
 	if err := s.validateCreateEntryRequest(ctx, req); err != nil {
-		return nil, fmt.Errorf("validation failed: %w", err)
+		return nil, entryCreated, fmt.Errorf("validation failed: %w", err)
 	}
 
+	pattern := strings.TrimSpace(req.Pattern)
+
 	// Check for duplicates
-	if err := s.checkForDuplicateEntry(ctx, req.ListID, req.Pattern, req.EntryType); err != nil {
-		return nil, fmt.Errorf("duplicate check failed: %w", err)
+	existing, err := s.findExistingEntry(ctx, req.ListID, pattern, req.EntryType)
+	if err != nil {
+		return nil, entryCreated, fmt.Errorf("duplicate check failed: %w", err)
+	}
+	if existing != nil {
+		return s.resolveConflict(ctx, existing, req)
 	}
 
 	entry := &models.ListEntry{
 		ListID:      req.ListID,
 		EntryType:   req.EntryType,
-		Pattern:     strings.TrimSpace(req.Pattern),
+		Pattern:     pattern,
 		PatternType: req.PatternType,
 		Description: req.Description,
+		Label:       req.Label,
+		Notes:       req.Notes,
+		Source:      models.EntrySourceManual,
 		Enabled:     req.Enabled,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
 
 	if err := s.repos.ListEntry.Create(ctx, entry); err != nil {
+		// Another writer added the pattern since the duplicate check
+		if errors.Is(err, models.ErrDuplicateEntry) {
+			if existing, findErr := s.findExistingEntry(ctx, req.ListID, pattern, req.EntryType); findErr == nil && existing != nil {
+				return s.resolveConflict(ctx, existing, req)
+			}
+		}
 		s.logger.Error("Failed to create entry", logging.Err(err))
-		return nil, fmt.Errorf("failed to create entry: %w", err)
+		return nil, entryCreated, fmt.Errorf("failed to create entry: %w", err)
 	}
 
 	s.logger.Info("Entry created successfully",
 		logging.Int("id", entry.ID),
 		logging.String("pattern", entry.Pattern))
 
-	return entry, nil
+	return entry, entryCreated, nil
+}
+
+// resolveConflict applies req.OnConflict to an entry already in the list
+func (s *EntryManagementService) resolveConflict(ctx context.Context, existing *models.ListEntry, req CreateEntryRequest) (*models.ListEntry, entryOutcome, error) {
+	switch req.OnConflict {
+	case ConflictSkip:
+		s.logger.Info("Skipped duplicate entry",
+			logging.Int("id", existing.ID),
+			logging.String("pattern", existing.Pattern))
+		return existing, entrySkipped, nil
+	case ConflictUpdate:
+		existing.PatternType = req.PatternType
+		existing.Description = req.Description
+		existing.Label = req.Label
+		existing.Notes = req.Notes
+		existing.Enabled = req.Enabled
+		if err := s.repos.ListEntry.Update(ctx, existing); err != nil {
+			return nil, entryUpdated, fmt.Errorf("failed to update existing entry: %w", err)
+		}
+		s.logger.Info("Updated existing entry",
+			logging.Int("id", existing.ID),
+			logging.String("pattern", existing.Pattern))
+		return existing, entryUpdated, nil
+	default:
+		return nil, entryCreated, fmt.Errorf("duplicate check failed: %w: '%s'", models.ErrDuplicateEntry, existing.Pattern)
+	}
 }
 
 // GetEntry retrieves an entry by ID
@@ -135,7 +227,10 @@ func (s *EntryManagementService) UpdateEntry(ctx context.Context, id int, req Up
 		return nil, fmt.Errorf("failed to get entry: %w", err)
 	}
 
-	// Apply updates
+	// Apply updates. A new pattern is validated against the new pattern type.
+	if req.PatternType != nil {
+		entry.PatternType = *req.PatternType
+	}
 	if req.Pattern != nil {
 		pattern := strings.TrimSpace(*req.Pattern)
 		if err := s.validatePattern(pattern, entry.EntryType, entry.PatternType); err != nil {
@@ -143,18 +238,32 @@ func (s *EntryManagementService) UpdateEntry(ctx context.Context, id int, req Up
 		}
 		// Check for duplicates if pattern changed
 		if pattern != entry.Pattern {
-			if err := s.checkForDuplicateEntry(ctx, entry.ListID, pattern, entry.EntryType); err != nil {
+			existing, err := s.findExistingEntry(ctx, entry.ListID, pattern, entry.EntryType)
+			if err != nil {
 				return nil, fmt.Errorf("duplicate check failed: %w", err)
 			}
+			if existing != nil {
+				return nil, fmt.Errorf("duplicate check failed: %w: '%s'", models.ErrDuplicateEntry, pattern)
+			}
 		}
 		entry.Pattern = pattern
-	}
-	if req.PatternType != nil {
-		entry.PatternType = *req.PatternType
+	} else if req.PatternType != nil {
+		if err := s.validatePattern(entry.Pattern, entry.EntryType, entry.PatternType); err != nil {
+			return nil, fmt.Errorf("invalid pattern: %w", err)
+		}
 	}
 	if req.Description != nil {
 		entry.Description = *req.Description
 	}
+	if req.Label != nil {
+		if len(*req.Label) > models.MaxLabelLength {
+			return nil, fmt.Errorf("label must be at most %d characters", models.MaxLabelLength)
+		}
+		entry.Label = *req.Label
+	}
+	if req.Notes != nil {
+		entry.Notes = *req.Notes
+	}
 	if req.Enabled != nil {
 		entry.Enabled = *req.Enabled
 	}
@@ -231,16 +340,24 @@ func (s *EntryManagementService) BulkCreateEntries(ctx context.Context, req Bulk
 
 	for i, entryReq := range req.Entries {
 		entryReq.ListID = req.ListID // Ensure consistency
+		if entryReq.OnConflict == "" {
+			entryReq.OnConflict = req.OnConflict
+		}
 
-		entry, err := s.CreateEntry(ctx, entryReq)
-		if err != nil {
+		entry, outcome, err := s.createEntry(ctx, entryReq)
+		switch {
+		case err != nil:
 			result.FailureCount++
 			result.Errors = append(result.Errors, BulkCreateError{
 				Index:   i,
 				Pattern: entryReq.Pattern,
 				Error:   err.Error(),
 			})
-		} else {
+		case outcome == entrySkipped:
+			result.DuplicateCount++
+		case outcome == entryUpdated:
+			result.UpdatedCount++
+		default:
 			result.SuccessCount++
 			result.CreatedIDs = append(result.CreatedIDs, entry.ID)
 		}
@@ -248,35 +365,28 @@ func (s *EntryManagementService) BulkCreateEntries(ctx context.Context, req Bulk
 
 	s.logger.Info("Bulk create completed",
 		logging.Int("success", result.SuccessCount),
+		logging.Int("duplicates", result.DuplicateCount),
+		logging.Int("updated", result.UpdatedCount),
 		logging.Int("failures", result.FailureCount))
 
 	return result, nil
 }
 
-// ImportEntries imports entries from various formats
+// ImportEntries imports entries from various formats. Entries already in the
+// list or repeated in the data are skipped and counted as duplicates.
 func (s *EntryManagementService) ImportEntries(ctx context.Context, listID int, data []byte, format ExportEntriesFormat) (*BulkCreateResult, error) {
-	s.logger.Info("Importing entries",
-		logging.Int("list_id", listID),
-		logging.String("format", string(format)))
-
-	var entries []CreateEntryRequest
-	var err error
-
-	switch format {
-	case ExportFormatTXT:
-		entries, err = s.parseTXTImport(data, listID)
-	default:
-		return nil, fmt.Errorf("unsupported import format: %s", format)
-	}
-
+	imported, err := s.ImportEntriesFromReader(ctx, listID, bytes.NewReader(data), format, ImportOptions{})
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse import data: %w", err)
+		return nil, err
 	}
 
-	return s.BulkCreateEntries(ctx, BulkCreateEntriesRequest{
-		ListID:  listID,
-		Entries: entries,
-	})
+	return &BulkCreateResult{
+		SuccessCount:   imported.Imported,
+		FailureCount:   imported.Invalid,
+		DuplicateCount: imported.Duplicates,
+		Errors:         imported.Errors,
+		CreatedIDs:     make([]int, 0),
+	}, nil
 }
 
 // ExportEntries exports entries in the specified format
@@ -314,10 +424,12 @@ func (s *EntryManagementService) SearchEntries(ctx context.Context, listID int,
 			continue
 		}
 
-		// Search in pattern and description
+		// Search in pattern, description, label and notes
 		if searchTerm == "" ||
 			strings.Contains(strings.ToLower(entry.Pattern), searchTerm) ||
-			strings.Contains(strings.ToLower(entry.Description), searchTerm) {
+			strings.Contains(strings.ToLower(entry.Description), searchTerm) ||
+			strings.Contains(strings.ToLower(entry.Label), searchTerm) ||
+			strings.Contains(strings.ToLower(entry.Notes), searchTerm) {
 			filtered = append(filtered, entry)
 		}
 	}
@@ -340,10 +452,24 @@ func (s *EntryManagementService) validateCreateEntryRequest(ctx context.Context,
 	// Validate pattern type
 	if req.PatternType != models.PatternTypeExact &&
 		req.PatternType != models.PatternTypeWildcard &&
-		req.PatternType != models.PatternTypeDomain {
+		req.PatternType != models.PatternTypeDomain &&
+		req.PatternType != models.PatternTypeRegex &&
+		req.PatternType != models.PatternTypeCIDR &&
+		req.PatternType != models.PatternTypePort {
 		return fmt.Errorf("invalid pattern type: %s", req.PatternType)
 	}
 
+	if len(req.Label) > models.MaxLabelLength {
+		return fmt.Errorf("label must be at most %d characters", models.MaxLabelLength)
+	}
+
+	// Validate conflict handling
+	switch req.OnConflict {
+	case "", ConflictError, ConflictSkip, ConflictUpdate:
+	default:
+		return fmt.Errorf("invalid conflict action: %s", req.OnConflict)
+	}
+
 	// Validate pattern
 	pattern := strings.TrimSpace(req.Pattern)
 	if pattern == "" {
@@ -387,6 +513,12 @@ func (s *EntryManagementService) validateExecutablePattern(pattern string, patte
 		}
 	case models.PatternTypeDomain:
 		return fmt.Errorf("domain pattern type not supported for executables")
+	case models.PatternTypeCIDR:
+		return fmt.Errorf("cidr pattern type not supported for executables")
+	case models.PatternTypePort:
+		return fmt.Errorf("port pattern type not supported for executables")
+	case models.PatternTypeRegex:
+		return ValidateRegexPattern(pattern)
 	}
 	return nil
 }
@@ -411,60 +543,61 @@ func (s *EntryManagementService) validateURLPattern(pattern string, patternType
 		if matched, _ := regexp.MatchString(`^[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`, pattern); !matched {
 			return fmt.Errorf("invalid domain pattern")
 		}
+	case models.PatternTypeRegex:
+		return ValidateRegexPattern(pattern)
+	case models.PatternTypeCIDR:
+		return ValidateCIDRPattern(pattern)
+	case models.PatternTypePort:
+		return ValidatePortPattern(pattern)
 	}
 	return nil
 }
 
-// checkForDuplicateEntry checks if an entry with the same pattern already exists
-func (s *EntryManagementService) checkForDuplicateEntry(ctx context.Context, listID int, pattern string, entryType models.EntryType) error {
-	existing, err := s.repos.ListEntry.GetByPattern(ctx, pattern, entryType)
-	if err != nil {
-		// If no existing entry found, that's good
-		return nil
+// ValidatePortPattern checks that a port entry pattern is a destination port
+// or port range with an optional protocol, such as udp/27015 or 6881-6889
+func ValidatePortPattern(pattern string) error {
+	if _, err := enforcement.ParsePortPattern(pattern); err != nil {
+		return fmt.Errorf("invalid port pattern: %w", err)
 	}
+	return nil
+}
 
-	// Check if any existing entry belongs to the same list
-	for _, entry := range existing {
-		if entry.ListID == listID {
-			return fmt.Errorf("entry with pattern '%s' already exists in this list", pattern)
-		}
+// ValidateCIDRPattern checks that a cidr entry pattern is an IP network such
+// as 10.0.0.0/8 or fd00::/8
+func ValidateCIDRPattern(pattern string) error {
+	if _, _, err := net.ParseCIDR(pattern); err != nil {
+		return fmt.Errorf("invalid CIDR pattern: %w", err)
 	}
-
 	return nil
 }
 
-// parseTXTImport parses simple text import - one pattern per line
-func (s *EntryManagementService) parseTXTImport(data []byte, listID int) ([]CreateEntryRequest, error) {
-	lines := strings.Split(string(data), "\n")
-	entries := make([]CreateEntryRequest, 0, len(lines))
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue // Skip empty lines and comments
-		}
+// ValidateRegexPattern checks that a regex entry pattern compiles, so a bad
+// pattern is rejected when it is saved rather than silently never matching
+func ValidateRegexPattern(pattern string) error {
+	if pattern == "" {
+		return fmt.Errorf("regex pattern is empty")
+	}
+	if _, err := enforcement.CompileRegex(pattern); err != nil {
+		return fmt.Errorf("invalid regex pattern: %w", err)
+	}
+	return nil
+}
 
-		// Try to determine if it's a URL or executable
-		entryType := models.EntryTypeExecutable
-		patternType := models.PatternTypeExact
+// findExistingEntry returns the list's entry with the same pattern and type,
+// or nil when there is none
+func (s *EntryManagementService) findExistingEntry(ctx context.Context, listID int, pattern string, entryType models.EntryType) (*models.ListEntry, error) {
+	existing, err := s.repos.ListEntry.GetByPattern(ctx, pattern, entryType)
+	if err != nil {
+		return nil, err
+	}
 
-		if strings.HasPrefix(line, "http://") || strings.HasPrefix(line, "https://") || strings.Contains(line, ".") {
-			entryType = models.EntryTypeURL
-			if strings.Contains(line, "*") || strings.Contains(line, "?") {
-				patternType = models.PatternTypeWildcard
-			}
+	for i := range existing {
+		if existing[i].ListID == listID {
+			return &existing[i], nil
 		}
-
-		entries = append(entries, CreateEntryRequest{
-			ListID:      listID,
-			EntryType:   entryType,
-			Pattern:     line,
-			PatternType: patternType,
-			Enabled:     true,
-		})
 	}
 
-	return entries, nil
+	return nil, nil
 }
 
 // exportAsTXT exports entries as simple text format
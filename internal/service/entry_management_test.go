@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"parental-control/internal/models"
+)
+
+func TestBulkCreateEntries_Conflicts(t *testing.T) {
+	svc, repos, listID := newImportTestService(t)
+	ctx := context.Background()
+
+	entry := func(pattern, description string) CreateEntryRequest {
+		return CreateEntryRequest{
+			EntryType:   models.EntryTypeURL,
+			Pattern:     pattern,
+			PatternType: models.PatternTypeDomain,
+			Description: description,
+			Enabled:     true,
+		}
+	}
+
+	first := entry("ads.example.com", "first")
+	first.ListID = listID
+	if _, err := svc.CreateEntry(ctx, first); err != nil {
+		t.Fatalf("Failed to create entry: %v", err)
+	}
+
+	// A duplicate is rejected by default
+	if _, err := svc.CreateEntry(ctx, first); !errors.Is(err, models.ErrDuplicateEntry) {
+		t.Fatalf("Expected ErrDuplicateEntry, got %v", err)
+	}
+
+	result, err := svc.BulkCreateEntries(ctx, BulkCreateEntriesRequest{
+		ListID:     listID,
+		OnConflict: ConflictSkip,
+		Entries: []CreateEntryRequest{
+			entry("ads.example.com", "second"),
+			entry("tracker.example.com", ""),
+		},
+	})
+	if err != nil {
+		t.Fatalf("Bulk create failed: %v", err)
+	}
+	if result.SuccessCount != 1 || result.DuplicateCount != 1 || result.FailureCount != 0 {
+		t.Errorf("Expected 1 created and 1 duplicate, got %+v", result)
+	}
+
+	result, err = svc.BulkCreateEntries(ctx, BulkCreateEntriesRequest{
+		ListID:     listID,
+		OnConflict: ConflictUpdate,
+		Entries:    []CreateEntryRequest{entry("ads.example.com", "updated")},
+	})
+	if err != nil {
+		t.Fatalf("Bulk update failed: %v", err)
+	}
+	if result.UpdatedCount != 1 || result.SuccessCount != 0 {
+		t.Errorf("Expected 1 updated entry, got %+v", result)
+	}
+
+	entries, err := repos.ListEntry.GetByListID(ctx, listID)
+	if err != nil {
+		t.Fatalf("Failed to list entries: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(entries))
+	}
+	for _, e := range entries {
+		if e.Pattern == "ads.example.com" && e.Description != "updated" {
+			t.Errorf("Expected the existing entry to be updated, got %q", e.Description)
+		}
+	}
+}
+
+func TestCreateEntry_RegexPatterns(t *testing.T) {
+	svc, _, listID := newImportTestService(t)
+	ctx := context.Background()
+
+	// A bad regex cannot be saved
+	bad := CreateEntryRequest{ListID: listID, EntryType: models.EntryTypeURL, Pattern: `ads(\d+`, PatternType: models.PatternTypeRegex, Enabled: true}
+	if _, err := svc.CreateEntry(ctx, bad); err == nil {
+		t.Fatal("Expected an invalid regex to be rejected")
+	}
+
+	good := bad
+	good.Pattern = `^ads\d+\.example\.com$`
+	entry, err := svc.CreateEntry(ctx, good)
+	if err != nil {
+		t.Fatalf("Failed to create regex entry: %v", err)
+	}
+	if entry.PatternType != models.PatternTypeRegex {
+		t.Errorf("Expected a regex entry, got %s", entry.PatternType)
+	}
+
+	// Switching an entry to regex validates its pattern as one
+	wildcard := CreateEntryRequest{ListID: listID, EntryType: models.EntryTypeURL, Pattern: "*.example.org", PatternType: models.PatternTypeWildcard, Enabled: true}
+	created, err := svc.CreateEntry(ctx, wildcard)
+	if err != nil {
+		t.Fatalf("Failed to create wildcard entry: %v", err)
+	}
+	regex := models.PatternTypeRegex
+	if _, err := svc.UpdateEntry(ctx, created.ID, UpdateEntryRequest{PatternType: &regex}); err == nil {
+		t.Error("Expected a wildcard pattern to be rejected as a regex")
+	}
+}
+
+func TestCreateEntry_CIDRPatterns(t *testing.T) {
+	svc, _, listID := newImportTestService(t)
+	ctx := context.Background()
+
+	for _, pattern := range []string{"10.0.0.0", "10.0.0.0/33", "lan"} {
+		req := CreateEntryRequest{ListID: listID, EntryType: models.EntryTypeURL, Pattern: pattern, PatternType: models.PatternTypeCIDR, Enabled: true}
+		if _, err := svc.CreateEntry(ctx, req); err == nil {
+			t.Errorf("Expected %q to be rejected as a CIDR", pattern)
+		}
+	}
+
+	for _, pattern := range []string{"10.0.0.0/8", "fd00::/8"} {
+		req := CreateEntryRequest{ListID: listID, EntryType: models.EntryTypeURL, Pattern: pattern, PatternType: models.PatternTypeCIDR, Enabled: true}
+		if _, err := svc.CreateEntry(ctx, req); err != nil {
+			t.Errorf("Failed to create CIDR entry %q: %v", pattern, err)
+		}
+	}
+
+	executable := CreateEntryRequest{ListID: listID, EntryType: models.EntryTypeExecutable, Pattern: "10.0.0.0/8", PatternType: models.PatternTypeCIDR, Enabled: true}
+	if _, err := svc.CreateEntry(ctx, executable); err == nil {
+		t.Error("Expected a CIDR executable entry to be rejected")
+	}
+}
+
+func TestCreateEntry_PortPatterns(t *testing.T) {
+	svc, _, listID := newImportTestService(t)
+	ctx := context.Background()
+
+	for _, pattern := range []string{"0", "70000", "icmp/80", "6889-6881", "steam"} {
+		req := CreateEntryRequest{ListID: listID, EntryType: models.EntryTypeURL, Pattern: pattern, PatternType: models.PatternTypePort, Enabled: true}
+		if _, err := svc.CreateEntry(ctx, req); err == nil {
+			t.Errorf("Expected %q to be rejected as a port", pattern)
+		}
+	}
+
+	for _, pattern := range []string{"udp/27015", "6881-6889"} {
+		req := CreateEntryRequest{ListID: listID, EntryType: models.EntryTypeURL, Pattern: pattern, PatternType: models.PatternTypePort, Enabled: true}
+		if _, err := svc.CreateEntry(ctx, req); err != nil {
+			t.Errorf("Failed to create port entry %q: %v", pattern, err)
+		}
+	}
+
+	executable := CreateEntryRequest{ListID: listID, EntryType: models.EntryTypeExecutable, Pattern: "udp/27015", PatternType: models.PatternTypePort, Enabled: true}
+	if _, err := svc.CreateEntry(ctx, executable); err == nil {
+		t.Error("Expected a port executable entry to be rejected")
+	}
+}
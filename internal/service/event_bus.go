@@ -0,0 +1,76 @@
+package service
+
+import "time"
+
+// EventType names a kind of live event
+type EventType string
+
+const (
+	// EventEnforcement is a block or allow decision made by enforcement
+	EventEnforcement EventType = "enforcement"
+	// EventNotification is a notification that passed rate limiting
+	EventNotification EventType = "notification"
+	// EventQuota is a change to a quota rule or its usage
+	EventQuota EventType = "quota"
+)
+
+// Event is a live event published by a service
+type Event struct {
+	Type      EventType   `json:"type"`
+	Timestamp time.Time   `json:"timestamp"`
+	Data      interface{} `json:"data"`
+}
+
+// EventBus fans live events out to subscribers such as dashboard streams.
+// Publishing never blocks: a subscriber that falls behind is dropped.
+type EventBus struct {
+	subscribers subscriberSet[Event]
+}
+
+// NewEventBus creates an event bus without subscribers
+func NewEventBus() *EventBus {
+	return &EventBus{}
+}
+
+// EventSubscription receives events published to a bus
+type EventSubscription = Subscription[Event]
+
+// Subscribe registers a subscriber for events of the given types, or of every
+// type when none are given
+func (b *EventBus) Subscribe(types []EventType, bufferSize int) *EventSubscription {
+	var match func(Event) bool
+	if len(types) > 0 {
+		wanted := make(map[EventType]bool, len(types))
+		for _, eventType := range types {
+			wanted[eventType] = true
+		}
+		match = func(event Event) bool { return wanted[event.Type] }
+	}
+
+	return b.subscribers.subscribe(match, bufferSize)
+}
+
+// Unsubscribe removes a subscriber and closes its channel
+func (b *EventBus) Unsubscribe(sub *EventSubscription) {
+	b.subscribers.unsubscribe(sub)
+}
+
+// SubscriberCount returns the number of active subscribers
+func (b *EventBus) SubscriberCount() int {
+	return b.subscribers.count()
+}
+
+// Publish sends an event to every subscriber interested in its type. A nil
+// bus discards the event, so services can publish without checking.
+func (b *EventBus) Publish(eventType EventType, data interface{}) {
+	if b == nil {
+		return
+	}
+
+	b.subscribers.publish(Event{Type: eventType, Timestamp: time.Now(), Data: data})
+}
+
+// Close ends every subscription
+func (b *EventBus) Close() {
+	b.subscribers.closeAll()
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"parental-control/internal/models"
+)
+
+func TestEventBus(t *testing.T) {
+	bus := NewEventBus()
+	all := bus.Subscribe(nil, 10)
+	quotas := bus.Subscribe([]EventType{EventQuota}, 1)
+
+	bus.Publish(EventEnforcement, "blocked")
+	bus.Publish(EventQuota, "reset")
+
+	if event := <-all.Events(); event.Type != EventEnforcement || event.Data != "blocked" {
+		t.Errorf("Expected the enforcement event first, got %+v", event)
+	}
+	if event := <-all.Events(); event.Type != EventQuota {
+		t.Errorf("Expected the quota event second, got %+v", event)
+	}
+	if event := <-quotas.Events(); event.Data != "reset" {
+		t.Errorf("Expected only the quota event, got %+v", event)
+	}
+
+	// A subscriber whose buffer is full is dropped instead of blocking
+	bus.Publish(EventQuota, "first")
+	bus.Publish(EventQuota, "second")
+	<-quotas.Events()
+	if _, ok := <-quotas.Events(); ok || !quotas.Dropped() {
+		t.Error("Expected the slow subscriber to be dropped")
+	}
+	if bus.SubscriberCount() != 1 {
+		t.Errorf("Expected one subscriber left, got %d", bus.SubscriberCount())
+	}
+
+	bus.Close()
+	for range all.Events() {
+	}
+	if bus.SubscriberCount() != 0 {
+		t.Error("Expected Close to end every subscription")
+	}
+
+	// Publishing to no bus is a no-op
+	var none *EventBus
+	none.Publish(EventQuota, "ignored")
+}
+
+func TestQuotaService_PublishesChanges(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	rule := models.QuotaRule{ID: 1, ListID: 1, Name: "daily games", QuotaType: models.QuotaTypeDaily,
+		LimitSeconds: 3600, Enabled: true, CreatedAt: now}
+	quotaService, windows := newTestQuotaService(rule)
+	windows.Create(ctx, &models.QuotaUsage{
+		QuotaRuleID: rule.ID,
+		PeriodStart: quotaService.getPeriodStart(rule.QuotaType, now),
+		PeriodEnd:   quotaService.getPeriodEnd(rule.QuotaType, now),
+		UsedSeconds: 1200,
+	})
+
+	bus := NewEventBus()
+	sub := bus.Subscribe([]EventType{EventQuota}, 10)
+	quotaService.SetEventBus(bus)
+
+	if err := quotaService.ResetQuotaUsage(ctx, rule.ID); err != nil {
+		t.Fatalf("ResetQuotaUsage failed: %v", err)
+	}
+
+	select {
+	case event := <-sub.Events():
+		change, ok := event.Data.(QuotaChange)
+		if !ok || change.Change != QuotaChangeReset || change.Remaining == nil || change.Remaining.RemainingSeconds != 3600 {
+			t.Errorf("Expected a reset with the full hour left, got %+v", event.Data)
+		}
+	default:
+		t.Fatal("Expected the reset to be published")
+	}
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"parental-control/internal/logging"
+)
+
+// Lifecycle logs the start and stop of a background service with consistent
+// field names, so every service reports the same shape of information:
+//
+//	Starting service  service=... <config fields>
+//	Service started   service=... startup_duration=...
+//	Stopping service  service=... uptime=...
+//	Service stopped   service=... shutdown_duration=... uptime=... items_processed=... errors=... <stats fields>
+//
+// Services embed one as a field and call the four methods from Start and Stop.
+type Lifecycle struct {
+	logger logging.Logger
+	name   string
+
+	mu         sync.Mutex
+	phaseStart time.Time
+	startedAt  time.Time
+}
+
+// NewLifecycle creates a lifecycle logger for the named service
+func NewLifecycle(logger logging.Logger, name string) *Lifecycle {
+	return &Lifecycle{logger: logger, name: name}
+}
+
+// Starting logs that the service is starting with its key configuration
+func (l *Lifecycle) Starting(config ...logging.Field) {
+	l.mu.Lock()
+	l.phaseStart = time.Now()
+	l.mu.Unlock()
+
+	l.logger.Info("Starting service", l.fields(config)...)
+}
+
+// Started logs that the service is running and how long startup took
+func (l *Lifecycle) Started() {
+	l.mu.Lock()
+	now := time.Now()
+	elapsed := now.Sub(l.phaseStart)
+	l.startedAt = now
+	l.mu.Unlock()
+
+	l.logger.Info("Service started", l.fields([]logging.Field{
+		logging.Duration("startup_duration", elapsed),
+	})...)
+}
+
+// Stopping logs that the service is shutting down
+func (l *Lifecycle) Stopping() {
+	l.mu.Lock()
+	l.phaseStart = time.Now()
+	uptime := l.uptime(l.phaseStart)
+	l.mu.Unlock()
+
+	l.logger.Info("Stopping service", l.fields([]logging.Field{
+		logging.Duration("uptime", uptime),
+	})...)
+}
+
+// Stopped logs that the service has stopped with how long shutdown took,
+// the number of items it processed and errors it hit while running, and any
+// service-specific final stats
+func (l *Lifecycle) Stopped(itemsProcessed, errors int64, stats ...logging.Field) {
+	l.mu.Lock()
+	now := time.Now()
+	elapsed := now.Sub(l.phaseStart)
+	uptime := l.uptime(now)
+	l.startedAt = time.Time{}
+	l.mu.Unlock()
+
+	fields := []logging.Field{
+		logging.Duration("shutdown_duration", elapsed),
+		logging.Duration("uptime", uptime),
+		logging.Int("items_processed", int(itemsProcessed)),
+		logging.Int("errors", int(errors)),
+	}
+	l.logger.Info("Service stopped", l.fields(append(fields, stats...))...)
+}
+
+// uptime returns how long the service has been running at now
+func (l *Lifecycle) uptime(now time.Time) time.Duration {
+	if l.startedAt.IsZero() {
+		return 0
+	}
+	return now.Sub(l.startedAt)
+}
+
+// fields prefixes extra with the service name
+func (l *Lifecycle) fields(extra []logging.Field) []logging.Field {
+	return append([]logging.Field{logging.String("service", l.name)}, extra...)
+}
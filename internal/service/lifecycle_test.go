@@ -0,0 +1,39 @@
+package service
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"parental-control/internal/logging"
+)
+
+func TestLifecycle(t *testing.T) {
+	var buf bytes.Buffer
+	lifecycle := NewLifecycle(logging.New(logging.Config{Level: logging.INFO, Output: &buf}), "example")
+
+	lifecycle.Starting(logging.Duration("check_interval", time.Minute))
+	lifecycle.Started()
+	lifecycle.Stopping()
+	lifecycle.Stopped(42, 3, logging.Int("entries_deleted", 7))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Expected 4 log lines, got %d: %s", len(lines), buf.String())
+	}
+
+	expected := [][]string{
+		{"Starting service", `service="example"`, "check_interval=1m0s"},
+		{"Service started", `service="example"`, "startup_duration="},
+		{"Stopping service", `service="example"`, "uptime="},
+		{"Service stopped", `service="example"`, "shutdown_duration=", "uptime=", "items_processed=42", "errors=3", "entries_deleted=7"},
+	}
+	for i, want := range expected {
+		for _, fragment := range want {
+			if !strings.Contains(lines[i], fragment) {
+				t.Errorf("Line %d should contain %s, got: %s", i, fragment, lines[i])
+			}
+		}
+	}
+}
@@ -29,6 +29,8 @@ type CreateListRequest struct {
 	Name        string          `json:"name" validate:"required,max=255"`
 	Type        models.ListType `json:"type" validate:"required,oneof=whitelist blacklist"`
 	Description string          `json:"description"`
+	Label       string          `json:"label,omitempty" validate:"max=64"`
+	Notes       string          `json:"notes,omitempty"`
 	Enabled     bool            `json:"enabled"`
 }
 
@@ -37,6 +39,8 @@ type UpdateListRequest struct {
 	Name        *string          `json:"name,omitempty" validate:"omitempty,max=255"`
 	Type        *models.ListType `json:"type,omitempty" validate:"omitempty,oneof=whitelist blacklist"`
 	Description *string          `json:"description,omitempty"`
+	Label       *string          `json:"label,omitempty" validate:"omitempty,max=64"`
+	Notes       *string          `json:"notes,omitempty"`
 	Enabled     *bool            `json:"enabled,omitempty"`
 }
 
@@ -61,6 +65,8 @@ func (s *ListManagementService) CreateList(ctx context.Context, req CreateListRe
 		Name:        req.Name,
 		Type:        req.Type,
 		Description: req.Description,
+		Label:       req.Label,
+		Notes:       req.Notes,
 		Enabled:     req.Enabled,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
@@ -159,6 +165,15 @@ func (s *ListManagementService) UpdateList(ctx context.Context, id int, req Upda
 	if req.Description != nil {
 		list.Description = *req.Description
 	}
+	if req.Label != nil {
+		if len(*req.Label) > models.MaxLabelLength {
+			return nil, fmt.Errorf("label must be at most %d characters", models.MaxLabelLength)
+		}
+		list.Label = *req.Label
+	}
+	if req.Notes != nil {
+		list.Notes = *req.Notes
+	}
 	if req.Enabled != nil {
 		list.Enabled = *req.Enabled
 	}
@@ -174,7 +189,9 @@ func (s *ListManagementService) UpdateList(ctx context.Context, id int, req Upda
 	return list, nil
 }
 
-// DeleteList deletes a list and all its entries
+// DeleteList deletes a list and all its entries. Time and quota rules that
+// reference the list are found through the rule impact analysis and removed
+// with a warning so they cannot dangle after the list is gone.
 func (s *ListManagementService) DeleteList(ctx context.Context, id int) error {
 	s.logger.Info("Deleting list", logging.Int("id", id))
 
@@ -184,22 +201,37 @@ func (s *ListManagementService) DeleteList(ctx context.Context, id int) error {
 		return fmt.Errorf("failed to get list: %w", err)
 	}
 
+	impact, err := NewRuleValidationService(s.repos, s.logger).AnalyzeRuleImpact(ctx, "list", id, "delete")
+	if err != nil {
+		return fmt.Errorf("failed to analyze list dependents: %w", err)
+	}
+
 	// Delete all entries first
 	if err := s.repos.ListEntry.DeleteByListID(ctx, id); err != nil {
 		s.logger.Error("Failed to delete list entries", logging.Err(err))
 		return fmt.Errorf("failed to delete list entries: %w", err)
 	}
 
-	// Delete associated time rules
-	if err := s.repos.TimeRule.DeleteByListID(ctx, id); err != nil {
-		s.logger.Error("Failed to delete time rules", logging.Err(err))
-		return fmt.Errorf("failed to delete time rules: %w", err)
+	// Delete time rules that reference the list
+	for _, ruleID := range impact.AffectedTimeRules {
+		if err := s.repos.TimeRule.Delete(ctx, ruleID); err != nil && !isNotFoundError(err) {
+			s.logger.Error("Failed to delete time rule", logging.Err(err), logging.Int("time_rule_id", ruleID))
+			return fmt.Errorf("failed to delete time rule %d: %w", ruleID, err)
+		}
+		s.logger.Warn("Removed time rule referencing deleted list",
+			logging.Int("time_rule_id", ruleID),
+			logging.Int("list_id", id))
 	}
 
-	// Delete associated quota rules
-	if err := s.repos.QuotaRule.DeleteByListID(ctx, id); err != nil {
-		s.logger.Error("Failed to delete quota rules", logging.Err(err))
-		return fmt.Errorf("failed to delete quota rules: %w", err)
+	// Delete quota rules that reference the list
+	for _, ruleID := range impact.AffectedQuotaRules {
+		if err := s.repos.QuotaRule.Delete(ctx, ruleID); err != nil && !isNotFoundError(err) {
+			s.logger.Error("Failed to delete quota rule", logging.Err(err), logging.Int("quota_rule_id", ruleID))
+			return fmt.Errorf("failed to delete quota rule %d: %w", ruleID, err)
+		}
+		s.logger.Warn("Removed quota rule referencing deleted list",
+			logging.Int("quota_rule_id", ruleID),
+			logging.Int("list_id", id))
 	}
 
 	// Finally delete the list itself
@@ -210,7 +242,10 @@ func (s *ListManagementService) DeleteList(ctx context.Context, id int) error {
 
 	s.logger.Info("List deleted successfully",
 		logging.Int("id", id),
-		logging.String("name", list.Name))
+		logging.String("name", list.Name),
+		logging.Int("entries_removed", len(impact.AffectedEntries)),
+		logging.Int("time_rules_removed", len(impact.AffectedTimeRules)),
+		logging.Int("quota_rules_removed", len(impact.AffectedQuotaRules)))
 
 	return nil
 }
@@ -268,6 +303,8 @@ func (s *ListManagementService) DuplicateList(ctx context.Context, id int, newNa
 		Name:        newName,
 		Type:        sourceList.Type,
 		Description: fmt.Sprintf("Copy of %s", sourceList.Name),
+		Label:       sourceList.Label,
+		Notes:       sourceList.Notes,
 		Enabled:     false, // New lists start disabled
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
@@ -285,6 +322,9 @@ func (s *ListManagementService) DuplicateList(ctx context.Context, id int, newNa
 			Pattern:     entry.Pattern,
 			PatternType: entry.PatternType,
 			Description: entry.Description,
+			Label:       entry.Label,
+			Notes:       entry.Notes,
+			Source:      entry.Source,
 			Enabled:     entry.Enabled,
 			CreatedAt:   time.Now(),
 			UpdatedAt:   time.Now(),
@@ -313,6 +353,10 @@ func (s *ListManagementService) validateCreateListRequest(ctx context.Context, r
 		return fmt.Errorf("invalid list type: %s", req.Type)
 	}
 
+	if len(req.Label) > models.MaxLabelLength {
+		return fmt.Errorf("label must be at most %d characters", models.MaxLabelLength)
+	}
+
 	return s.validateListName(ctx, req.Name, nil)
 }
 
@@ -331,3 +375,50 @@ func (s *ListManagementService) validateListName(ctx context.Context, name strin
 
 	return fmt.Errorf("list name '%s' already exists", name)
 }
+
+// listChecker remembers which lists exist so that a run over many rules looks
+// each list up only once
+type listChecker struct {
+	repos  *models.RepositoryManager
+	logger logging.Logger
+	known  map[int]bool
+}
+
+func newListChecker(repos *models.RepositoryManager, logger logging.Logger) *listChecker {
+	return &listChecker{
+		repos:  repos,
+		logger: logger,
+		known:  make(map[int]bool),
+	}
+}
+
+// exists reports whether the list is still present. Rules pointing at a
+// missing list are logged so callers can skip them instead of failing the run.
+func (c *listChecker) exists(ctx context.Context, listID int, ruleType string, ruleID int) bool {
+	found, ok := c.known[listID]
+	if !ok {
+		_, err := c.repos.List.GetByID(ctx, listID)
+		if err != nil && !isNotFoundError(err) {
+			// Lookup failures are not evidence the list is gone
+			c.logger.Warn("Failed to verify list for rule",
+				logging.Err(err),
+				logging.Int("list_id", listID))
+			return true
+		}
+		found = err == nil
+		c.known[listID] = found
+	}
+
+	if !found {
+		c.logger.Warn("Skipping rule that references a deleted list",
+			logging.String("rule_type", ruleType),
+			logging.Int("rule_id", ruleID),
+			logging.Int("list_id", listID))
+	}
+	return found
+}
+
+// isNotFoundError reports whether a repository error means the record is missing
+func isNotFoundError(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "not found")
+}
@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"parental-control/internal/logging"
+	"parental-control/internal/models"
+)
+
+// memoryListRepo keeps lists in memory
+type memoryListRepo struct {
+	models.ListRepository
+	lists map[int]*models.List
+}
+
+func (r *memoryListRepo) GetByID(ctx context.Context, id int) (*models.List, error) {
+	list, ok := r.lists[id]
+	if !ok {
+		return nil, fmt.Errorf("list with ID %d not found", id)
+	}
+	return list, nil
+}
+
+func (r *memoryListRepo) Delete(ctx context.Context, id int) error {
+	if _, ok := r.lists[id]; !ok {
+		return fmt.Errorf("list with ID %d not found", id)
+	}
+	delete(r.lists, id)
+	return nil
+}
+
+// memoryListEntryRepo keeps entries in memory
+type memoryListEntryRepo struct {
+	models.ListEntryRepository
+	entries []models.ListEntry
+}
+
+func (r *memoryListEntryRepo) GetByListID(ctx context.Context, listID int) ([]models.ListEntry, error) {
+	var result []models.ListEntry
+	for _, entry := range r.entries {
+		if entry.ListID == listID {
+			result = append(result, entry)
+		}
+	}
+	return result, nil
+}
+
+func (r *memoryListEntryRepo) DeleteByListID(ctx context.Context, listID int) error {
+	remaining := r.entries[:0]
+	for _, entry := range r.entries {
+		if entry.ListID != listID {
+			remaining = append(remaining, entry)
+		}
+	}
+	r.entries = remaining
+	return nil
+}
+
+// memoryTimeRuleRepo keeps time rules in memory
+type memoryTimeRuleRepo struct {
+	models.TimeRuleRepository
+	rules map[int]models.TimeRule
+}
+
+func (r *memoryTimeRuleRepo) Create(ctx context.Context, rule *models.TimeRule) error {
+	rule.ID = len(r.rules) + 1
+	for r.rules[rule.ID].ID != 0 {
+		rule.ID++
+	}
+	r.rules[rule.ID] = *rule
+	return nil
+}
+
+func (r *memoryTimeRuleRepo) GetByListID(ctx context.Context, listID int) ([]models.TimeRule, error) {
+	var result []models.TimeRule
+	for _, rule := range r.rules {
+		if rule.ListID == listID {
+			result = append(result, rule)
+		}
+	}
+	return result, nil
+}
+
+func (r *memoryTimeRuleRepo) GetEnabled(ctx context.Context) ([]models.TimeRule, error) {
+	var result []models.TimeRule
+	for _, rule := range r.rules {
+		if rule.Enabled {
+			result = append(result, rule)
+		}
+	}
+	return result, nil
+}
+
+func (r *memoryTimeRuleRepo) GetByID(ctx context.Context, id int) (*models.TimeRule, error) {
+	rule, ok := r.rules[id]
+	if !ok {
+		return nil, fmt.Errorf("time rule with ID %d not found", id)
+	}
+	return &rule, nil
+}
+
+func (r *memoryTimeRuleRepo) UpdateBatch(ctx context.Context, rules []models.TimeRule) error {
+	for _, rule := range rules {
+		r.rules[rule.ID] = rule
+	}
+	return nil
+}
+
+func (r *memoryTimeRuleRepo) Delete(ctx context.Context, id int) error {
+	delete(r.rules, id)
+	return nil
+}
+
+// memoryQuotaRuleRepo keeps quota rules in memory
+type memoryQuotaRuleRepo struct {
+	models.QuotaRuleRepository
+	rules map[int]models.QuotaRule
+}
+
+func (r *memoryQuotaRuleRepo) Create(ctx context.Context, rule *models.QuotaRule) error {
+	rule.ID = len(r.rules) + 1
+	for r.rules[rule.ID].ID != 0 {
+		rule.ID++
+	}
+	r.rules[rule.ID] = *rule
+	return nil
+}
+
+func (r *memoryQuotaRuleRepo) GetByListID(ctx context.Context, listID int) ([]models.QuotaRule, error) {
+	var result []models.QuotaRule
+	for _, rule := range r.rules {
+		if rule.ListID == listID {
+			result = append(result, rule)
+		}
+	}
+	return result, nil
+}
+
+func (r *memoryQuotaRuleRepo) UpdateBatch(ctx context.Context, rules []models.QuotaRule) error {
+	for _, rule := range rules {
+		r.rules[rule.ID] = rule
+	}
+	return nil
+}
+
+func (r *memoryQuotaRuleRepo) Delete(ctx context.Context, id int) error {
+	delete(r.rules, id)
+	return nil
+}
+
+func newTestListRepos() (*models.RepositoryManager, *memoryTimeRuleRepo, *memoryQuotaRuleRepo) {
+	timeRules := &memoryTimeRuleRepo{rules: map[int]models.TimeRule{
+		1: {ID: 1, ListID: 1, Name: "school nights", Enabled: true},
+		2: {ID: 2, ListID: 2, Name: "weekends", Enabled: true},
+	}}
+	quotaRules := &memoryQuotaRuleRepo{rules: map[int]models.QuotaRule{
+		1: {ID: 1, ListID: 1, Name: "daily games", Enabled: true},
+	}}
+
+	repos := &models.RepositoryManager{
+		List: &memoryListRepo{lists: map[int]*models.List{
+			1: {ID: 1, Name: "games"},
+			2: {ID: 2, Name: "social"},
+		}},
+		ListEntry: &memoryListEntryRepo{entries: []models.ListEntry{
+			{ID: 1, ListID: 1, Pattern: "games.example.com"},
+			{ID: 2, ListID: 2, Pattern: "social.example.com"},
+		}},
+		TimeRule:  timeRules,
+		QuotaRule: quotaRules,
+	}
+	return repos, timeRules, quotaRules
+}
+
+func TestDeleteList_RemovesReferencingRules(t *testing.T) {
+	repos, timeRules, quotaRules := newTestListRepos()
+	ctx := context.Background()
+
+	if err := NewListManagementService(repos, logging.NewDefault()).DeleteList(ctx, 1); err != nil {
+		t.Fatalf("DeleteList failed: %v", err)
+	}
+
+	if _, ok := timeRules.rules[1]; ok {
+		t.Error("Time rule referencing the deleted list should be removed")
+	}
+	if _, ok := quotaRules.rules[1]; ok {
+		t.Error("Quota rule referencing the deleted list should be removed")
+	}
+	if _, ok := timeRules.rules[2]; !ok {
+		t.Error("Time rule for another list should be kept")
+	}
+
+	entries, _ := repos.ListEntry.GetByListID(ctx, 1)
+	if len(entries) != 0 {
+		t.Errorf("Expected entries of the deleted list to be removed, got %d", len(entries))
+	}
+}
+
+func TestTimeWindowService_SkipsRulesForDeletedList(t *testing.T) {
+	repos, _, _ := newTestListRepos()
+	ctx := context.Background()
+
+	// Simulate a list removed without cleaning up its rules
+	if err := repos.List.Delete(ctx, 1); err != nil {
+		t.Fatalf("Failed to delete list: %v", err)
+	}
+
+	rules, err := NewTimeWindowService(repos, logging.NewDefault()).GetEnabledRules(ctx)
+	if err != nil {
+		t.Fatalf("GetEnabledRules should not fail on a dangling rule: %v", err)
+	}
+	if len(rules) != 1 || rules[0].ListID != 2 {
+		t.Fatalf("Expected only the rule for the remaining list, got %+v", rules)
+	}
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// ErrUnknownMetricPath is returned for a metric path that does not name a
+// numeric field of SystemMetrics
+var ErrUnknownMetricPath = errors.New("unknown metric path")
+
+// errMetricUnavailable means a valid path has no value in a sample, e.g. a
+// service section that is nil or a map key that was not recorded
+var errMetricUnavailable = errors.New("metric not available")
+
+var (
+	systemMetricsType = reflect.TypeOf(SystemMetrics{})
+	durationType      = reflect.TypeOf(time.Duration(0))
+)
+
+// ValidateMetricPath checks that a dotted metric path names a numeric
+// metric. Segments are the JSON field names of SystemMetrics and its nested
+// service metrics, or map keys, e.g. "enforcement_metrics.block_rate" or
+// "response_times.login".
+func ValidateMetricPath(path string) error {
+	t := systemMetricsType
+	for _, segment := range strings.Split(path, ".") {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+
+		switch {
+		case t.Kind() == reflect.Struct:
+			field, ok := fieldByJSONName(t, segment)
+			if !ok {
+				return fmt.Errorf("%w %q: no metric %q", ErrUnknownMetricPath, path, segment)
+			}
+			t = field.Type
+		case t.Kind() == reflect.Map && t.Key().Kind() == reflect.String && segment != "":
+			t = t.Elem()
+		default:
+			return fmt.Errorf("%w %q: %q is not a metric group", ErrUnknownMetricPath, path, segment)
+		}
+	}
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if !isNumericType(t) {
+		return fmt.Errorf("%w %q: not a numeric metric", ErrUnknownMetricPath, path)
+	}
+	return nil
+}
+
+// resolveMetricPath returns the value at a dotted metric path. Durations
+// are returned in milliseconds.
+func resolveMetricPath(metrics *SystemMetrics, path string) (float64, error) {
+	v := reflect.ValueOf(metrics).Elem()
+	for _, segment := range strings.Split(path, ".") {
+		for v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return 0, errMetricUnavailable
+			}
+			v = v.Elem()
+		}
+
+		switch {
+		case v.Kind() == reflect.Struct:
+			field, ok := fieldByJSONName(v.Type(), segment)
+			if !ok {
+				return 0, fmt.Errorf("%w %q: no metric %q", ErrUnknownMetricPath, path, segment)
+			}
+			v = v.FieldByIndex(field.Index)
+		case v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String:
+			v = v.MapIndex(reflect.ValueOf(segment).Convert(v.Type().Key()))
+			if !v.IsValid() {
+				return 0, errMetricUnavailable
+			}
+		default:
+			return 0, fmt.Errorf("%w %q: %q is not a metric group", ErrUnknownMetricPath, path, segment)
+		}
+	}
+
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return 0, errMetricUnavailable
+		}
+		v = v.Elem()
+	}
+
+	switch {
+	case v.Type() == durationType:
+		return float64(time.Duration(v.Int())) / float64(time.Millisecond), nil
+	case v.CanInt():
+		return float64(v.Int()), nil
+	case v.CanUint():
+		return float64(v.Uint()), nil
+	case v.CanFloat():
+		return v.Float(), nil
+	}
+	return 0, fmt.Errorf("%w %q: not a numeric metric", ErrUnknownMetricPath, path)
+}
+
+// fieldByJSONName finds the exported field of t whose JSON name is name
+func fieldByJSONName(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		jsonName, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if jsonName == "" {
+			jsonName = field.Name
+		}
+		if jsonName == name {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+func isNumericType(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
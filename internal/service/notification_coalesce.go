@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"parental-control/internal/logging"
+)
+
+// Coalescing folds a storm of identical block notifications, such as an app
+// retrying a blocked connection, into one summary. The first notification
+// for a process (or URL) is delivered as usual and opens a window of
+// NotificationConfig.CoalesceWindow; repeats inside the window are only
+// counted, and when it closes a single digest reports how many there were.
+
+// notificationCoalescer tracks the open coalescing windows
+type notificationCoalescer struct {
+	mu      sync.Mutex
+	windows map[string]*coalesceWindow
+}
+
+// coalesceWindow is the first notification of a window and its repeats
+type coalesceWindow struct {
+	data     NotificationData
+	duration time.Duration
+	repeats  int
+	timer    *time.Timer
+}
+
+// coalesceKey groups notifications that are summarized together. Only block
+// notifications are coalesced.
+func coalesceKey(data *NotificationData) (string, bool) {
+	switch data.Type {
+	case NotificationTypeAppBlocked, NotificationTypeWebBlocked:
+		return string(data.Type) + "|" + data.ProcessName + "|" + data.URL, true
+	default:
+		return "", false
+	}
+}
+
+// coalesce reports whether data repeats a notification whose window is still
+// open, counting it if so. Otherwise it opens a window for data, which the
+// caller then delivers.
+func (ns *NotificationService) coalesce(data *NotificationData) bool {
+	window := ns.GetConfig().CoalesceWindow
+	if window <= 0 {
+		return false
+	}
+	key, ok := coalesceKey(data)
+	if !ok {
+		return false
+	}
+
+	c := &ns.coalescer
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if open, exists := c.windows[key]; exists {
+		open.repeats++
+		ns.statsMu.Lock()
+		ns.stats.Coalesced++
+		ns.statsMu.Unlock()
+		return true
+	}
+
+	if c.windows == nil {
+		c.windows = make(map[string]*coalesceWindow)
+	}
+	c.windows[key] = &coalesceWindow{
+		data:     *data,
+		duration: window,
+		timer:    time.AfterFunc(window, func() { ns.closeCoalesceWindow(key) }),
+	}
+	return false
+}
+
+// closeCoalesceWindow ends the window for key and sends its digest
+func (ns *NotificationService) closeCoalesceWindow(key string) {
+	c := &ns.coalescer
+	c.mu.Lock()
+	window, exists := c.windows[key]
+	delete(c.windows, key)
+	c.mu.Unlock()
+
+	if exists {
+		ns.sendDigest(window)
+	}
+}
+
+// flushCoalesced ends every open window now, sending their digests
+func (ns *NotificationService) flushCoalesced() {
+	c := &ns.coalescer
+	c.mu.Lock()
+	windows := c.windows
+	c.windows = nil
+	c.mu.Unlock()
+
+	for _, window := range windows {
+		window.timer.Stop()
+		ns.sendDigest(window)
+	}
+}
+
+// sendDigest delivers the summary of a window's repeats, if it had any
+func (ns *NotificationService) sendDigest(window *coalesceWindow) {
+	if window.repeats == 0 {
+		return
+	}
+
+	digest := window.data
+	times := "times"
+	if window.repeats == 1 {
+		times = "time"
+	}
+	switch digest.Type {
+	case NotificationTypeWebBlocked:
+		digest.Message = fmt.Sprintf("Access to '%s' was blocked %d more %s in the last %s.",
+			digest.URL, window.repeats, times, formatCoalesceWindow(window.duration))
+	default:
+		digest.Message = fmt.Sprintf("The application '%s' was blocked %d more %s in the last %s.",
+			digest.ProcessName, window.repeats, times, formatCoalesceWindow(window.duration))
+	}
+	digest.ProcessPID = 0
+	digest.Details = map[string]interface{}{
+		"coalesced_count":  window.repeats,
+		"coalesced_window": window.duration.String(),
+	}
+
+	if err := ns.deliverNotification(context.Background(), &digest, true); err != nil {
+		ns.logger.Warn("Failed to send coalesced notification summary",
+			logging.String("type", string(digest.Type)),
+			logging.Int("repeats", window.repeats),
+			logging.Err(err))
+	}
+}
+
+// formatCoalesceWindow renders a window as "minute", "5 minutes" or "30s"
+func formatCoalesceWindow(d time.Duration) string {
+	switch {
+	case d == time.Minute:
+		return "minute"
+	case d%time.Minute == 0:
+		return fmt.Sprintf("%d minutes", int(d/time.Minute))
+	default:
+		return d.String()
+	}
+}
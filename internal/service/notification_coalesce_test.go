@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"parental-control/internal/logging"
+)
+
+func TestNotificationService_CoalescesRepeatedBlocks(t *testing.T) {
+	config := DefaultNotificationConfig()
+	config.CoalesceWindow = 50 * time.Millisecond
+	ns := NewNotificationService(config, logging.NewDefault())
+	sink := &recordingSink{name: "recording"}
+	ns.sinks = []NotificationSink{sink}
+
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		if err := ns.NotifyAppBlocked(ctx, "chrome", 100+i, "No browsers"); err != nil {
+			t.Fatalf("Failed to notify: %v", err)
+		}
+	}
+	// A different process has its own window
+	if err := ns.NotifyAppBlocked(ctx, "game", 200, ""); err != nil {
+		t.Fatalf("Failed to notify: %v", err)
+	}
+
+	sink.mu.Lock()
+	if len(sink.delivered) != 1 || sink.delivered[0].ProcessName != "chrome" {
+		t.Fatalf("Expected only the first chrome notification before the window closes, got %+v", sink.delivered)
+	}
+	sink.mu.Unlock()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		sink.mu.Lock()
+		count := len(sink.delivered)
+		sink.mu.Unlock()
+		if count == 2 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	if len(sink.delivered) != 2 {
+		t.Fatalf("Expected a single digest after the window, got %d notifications", len(sink.delivered))
+	}
+	digest := sink.delivered[1]
+	if !strings.Contains(digest.Message, "'chrome' was blocked 4 more times") || digest.Details["coalesced_count"] != 4 {
+		t.Errorf("Unexpected digest: %+v", digest)
+	}
+	if stats := ns.GetStats(); stats.Coalesced != 4 || stats.AppBlockingSent != 2 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
+
+func TestNotificationService_StopFlushesCoalesced(t *testing.T) {
+	config := DefaultNotificationConfig()
+	config.CoalesceWindow = time.Hour
+	ns := NewNotificationService(config, logging.NewDefault())
+	sink := &recordingSink{name: "recording"}
+	ns.sinks = []NotificationSink{sink}
+
+	ctx := context.Background()
+	ns.NotifyWebBlocked(ctx, "games.example.com", "", "")
+	ns.NotifyWebBlocked(ctx, "games.example.com", "", "")
+	ns.Stop()
+
+	if len(sink.delivered) != 2 || !strings.Contains(sink.delivered[1].Message, "blocked 1 more time in the last 60 minutes") {
+		t.Errorf("Expected the pending digest on stop, got %+v", sink.delivered)
+	}
+}
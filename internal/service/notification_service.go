@@ -6,7 +6,9 @@ import (
 	"os"
 	"os/exec"
 	"os/user"
+	"runtime"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -18,91 +20,115 @@ import (
 
 // NotificationService provides cross-platform desktop notification capabilities
 type NotificationService struct {
-	config *NotificationConfig
-	logger logging.Logger
-	
+	config   *NotificationConfig
+	configMu sync.RWMutex
+	logger   logging.Logger
+
 	// State management
 	enabled   bool
 	enabledMu sync.RWMutex
-	
+
 	// Rate limiting to prevent spam
 	rateLimiter *NotificationRateLimiter
-	
+
 	// Statistics
 	stats   *NotificationStats
 	statsMu sync.RWMutex
 
 	// Audit logging (optional)
 	auditService enforcement.AuditLogger
+
+	// Delivery destinations, rebuilt with the configuration (guarded by configMu)
+	sinks []NotificationSink
+
+	// Open coalescing windows of repeated block notifications
+	coalescer notificationCoalescer
+
+	lifecycle *Lifecycle
+
+	// Notifications are published here once they pass rate limiting
+	events *EventBus
 }
 
 // NotificationConfig holds configuration for the notification service
 type NotificationConfig struct {
 	// Enable notifications
 	Enabled bool `json:"enabled" yaml:"enabled"`
-	
+
 	// App branding
 	AppName string `json:"app_name" yaml:"app_name"`
 	AppIcon string `json:"app_icon" yaml:"app_icon"`
-	
+
 	// Rate limiting
 	MaxNotificationsPerMinute int           `json:"max_notifications_per_minute" yaml:"max_notifications_per_minute"`
 	CooldownPeriod            time.Duration `json:"cooldown_period" yaml:"cooldown_period"`
-	
+
 	// Notification types to enable
-	EnableAppBlocking   bool `json:"enable_app_blocking" yaml:"enable_app_blocking"`
-	EnableWebBlocking   bool `json:"enable_web_blocking" yaml:"enable_web_blocking"`
-	EnableTimeLimit     bool `json:"enable_time_limit" yaml:"enable_time_limit"`
-	EnableSystemAlerts  bool `json:"enable_system_alerts" yaml:"enable_system_alerts"`
-	
+	EnableAppBlocking  bool `json:"enable_app_blocking" yaml:"enable_app_blocking"`
+	EnableWebBlocking  bool `json:"enable_web_blocking" yaml:"enable_web_blocking"`
+	EnableTimeLimit    bool `json:"enable_time_limit" yaml:"enable_time_limit"`
+	EnableSystemAlerts bool `json:"enable_system_alerts" yaml:"enable_system_alerts"`
+
 	// Notification behavior
-	ShowProcessDetails bool          `json:"show_process_details" yaml:"show_process_details"`
+	ShowProcessDetails  bool          `json:"show_process_details" yaml:"show_process_details"`
 	NotificationTimeout time.Duration `json:"notification_timeout" yaml:"notification_timeout"`
+
+	// CoalesceWindow, when positive, folds repeated block notifications for
+	// the same process (or URL) into one summary per window
+	CoalesceWindow time.Duration `json:"coalesce_window" yaml:"coalesce_window"`
+
+	// Sinks selects where notifications are delivered (desktop, webhook,
+	// ntfy, email); empty means desktop only
+	Sinks   []string          `json:"sinks" yaml:"sinks"`
+	Webhook WebhookSinkConfig `json:"webhook" yaml:"webhook"`
+	Ntfy    NtfySinkConfig    `json:"ntfy" yaml:"ntfy"`
+	Email   EmailSinkConfig   `json:"email" yaml:"email"`
 }
 
 // NotificationStats tracks notification statistics
 type NotificationStats struct {
-	TotalSent           int64     `json:"total_sent"`
-	AppBlockingSent     int64     `json:"app_blocking_sent"`
-	WebBlockingSent     int64     `json:"web_blocking_sent"`
-	TimeLimitSent       int64     `json:"time_limit_sent"`
-	SystemAlertsSent    int64     `json:"system_alerts_sent"`
-	RateLimited         int64     `json:"rate_limited"`
-	Errors              int64     `json:"errors"`
+	TotalSent            int64     `json:"total_sent"`
+	AppBlockingSent      int64     `json:"app_blocking_sent"`
+	WebBlockingSent      int64     `json:"web_blocking_sent"`
+	TimeLimitSent        int64     `json:"time_limit_sent"`
+	SystemAlertsSent     int64     `json:"system_alerts_sent"`
+	RateLimited          int64     `json:"rate_limited"`
+	Coalesced            int64     `json:"coalesced"`
+	Errors               int64     `json:"errors"`
 	LastNotificationTime time.Time `json:"last_notification_time"`
-	LastError           string    `json:"last_error,omitempty"`
-	LastErrorTime       time.Time `json:"last_error_time,omitempty"`
+	LastError            string    `json:"last_error,omitempty"`
+	LastErrorTime        time.Time `json:"last_error_time,omitempty"`
 }
 
 // NotificationRateLimiter implements simple rate limiting for notifications
 type NotificationRateLimiter struct {
-	maxPerMinute    int
-	cooldownPeriod  time.Duration
-	notifications   []time.Time
-	lastCooldown    map[string]time.Time
-	mu              sync.Mutex
+	maxPerMinute   int
+	cooldownPeriod time.Duration
+	notifications  []time.Time
+	lastCooldown   map[string]time.Time
+	mu             sync.Mutex
 }
 
 // NotificationType represents different types of notifications
 type NotificationType string
 
 const (
-	NotificationTypeAppBlocked    NotificationType = "app_blocked"
-	NotificationTypeWebBlocked    NotificationType = "web_blocked"
-	NotificationTypeTimeLimit     NotificationType = "time_limit"
-	NotificationTypeSystemAlert   NotificationType = "system_alert"
+	NotificationTypeAppBlocked  NotificationType = "app_blocked"
+	NotificationTypeWebBlocked  NotificationType = "web_blocked"
+	NotificationTypeTimeLimit   NotificationType = "time_limit"
+	NotificationTypeSystemAlert NotificationType = "system_alert"
 )
 
 // NotificationData contains information for creating a notification
 type NotificationData struct {
-	Type        NotificationType      `json:"type"`
-	Title       string                `json:"title"`
-	Message     string                `json:"message"`
-	Icon        string                `json:"icon,omitempty"`
-	ProcessName string                `json:"process_name,omitempty"`
-	ProcessPID  int                   `json:"process_pid,omitempty"`
-	URL         string                `json:"url,omitempty"`
-	RuleName    string                `json:"rule_name,omitempty"`
+	Type        NotificationType       `json:"type"`
+	Title       string                 `json:"title"`
+	Message     string                 `json:"message"`
+	Icon        string                 `json:"icon,omitempty"`
+	ProcessName string                 `json:"process_name,omitempty"`
+	ProcessPID  int                    `json:"process_pid,omitempty"`
+	URL         string                 `json:"url,omitempty"`
+	RuleName    string                 `json:"rule_name,omitempty"`
 	Details     map[string]interface{} `json:"details,omitempty"`
 }
 
@@ -116,27 +142,54 @@ func NewNotificationServiceWithAudit(config *NotificationConfig, logger logging.
 	if config == nil {
 		config = DefaultNotificationConfig()
 	}
-	
+
 	// Set app name for beeep library
 	if config.AppName != "" {
 		beeep.AppName = config.AppName
 	}
-	
+
 	rateLimiter := &NotificationRateLimiter{
 		maxPerMinute:   config.MaxNotificationsPerMinute,
 		cooldownPeriod: config.CooldownPeriod,
 		notifications:  make([]time.Time, 0),
 		lastCooldown:   make(map[string]time.Time),
 	}
-	
-	return &NotificationService{
+
+	ns := &NotificationService{
 		config:       config,
 		logger:       logger,
 		enabled:      config.Enabled,
 		rateLimiter:  rateLimiter,
 		stats:        &NotificationStats{},
 		auditService: auditService,
+		lifecycle:    NewLifecycle(logger, "notification"),
+	}
+	ns.sinks = ns.buildSinks(config)
+	return ns
+}
+
+// buildSinks creates the sinks config selects. An invalid selection is
+// logged and falls back to the desktop sink, so notifications still go
+// somewhere.
+func (ns *NotificationService) buildSinks(config *NotificationConfig) []NotificationSink {
+	sinks, err := NewNotificationSinks(config, ns)
+	if err != nil {
+		ns.logger.Error("Invalid notification sinks, using desktop notifications", logging.Err(err))
+		return []NotificationSink{&desktopSink{ns: ns}}
 	}
+	return sinks
+}
+
+// sinkNames lists the active sinks
+func (ns *NotificationService) sinkNames() []string {
+	ns.configMu.RLock()
+	defer ns.configMu.RUnlock()
+
+	names := make([]string, 0, len(ns.sinks))
+	for _, sink := range ns.sinks {
+		names = append(names, sink.Name())
+	}
+	return names
 }
 
 // DefaultNotificationConfig returns sensible defaults for notification configuration
@@ -168,11 +221,39 @@ func (ns *NotificationService) SetEnabled(enabled bool) {
 	ns.enabledMu.Lock()
 	defer ns.enabledMu.Unlock()
 	ns.enabled = enabled
-	
+
 	ns.logger.Info("Notification service state changed",
 		logging.Bool("enabled", enabled))
 }
 
+// Start logs the service configuration. Notifications are sent on demand,
+// so there is nothing to launch.
+func (ns *NotificationService) Start() {
+	config := ns.GetConfig()
+	ns.lifecycle.Starting(
+		logging.Bool("enabled", ns.IsEnabled()),
+		logging.Int("max_per_minute", config.MaxNotificationsPerMinute),
+		logging.Duration("cooldown_period", config.CooldownPeriod),
+		logging.Bool("app_blocking", config.EnableAppBlocking),
+		logging.Bool("web_blocking", config.EnableWebBlocking),
+		logging.Bool("time_limit", config.EnableTimeLimit),
+		logging.Bool("system_alerts", config.EnableSystemAlerts),
+		logging.Duration("coalesce_window", config.CoalesceWindow),
+		logging.String("sinks", strings.Join(ns.sinkNames(), ",")))
+	ns.lifecycle.Started()
+}
+
+// Stop sends any pending coalesced summaries and logs the final notification
+// statistics
+func (ns *NotificationService) Stop() {
+	ns.lifecycle.Stopping()
+	ns.flushCoalesced()
+	stats := ns.GetStats()
+	ns.lifecycle.Stopped(stats.TotalSent, stats.Errors,
+		logging.Int("rate_limited", int(stats.RateLimited)),
+		logging.Int("coalesced", int(stats.Coalesced)))
+}
+
 // NotifyAppBlocked sends a notification when an application is blocked
 func (ns *NotificationService) NotifyAppBlocked(ctx context.Context, processName string, pid int, ruleName string) error {
 	ns.logger.Info("NotifyAppBlocked called",
@@ -180,116 +261,132 @@ func (ns *NotificationService) NotifyAppBlocked(ctx context.Context, processName
 		logging.Int("pid", pid),
 		logging.String("rule", ruleName),
 		logging.Bool("enabled", ns.IsEnabled()),
-		logging.Bool("app_blocking_enabled", ns.config.EnableAppBlocking))
+		logging.Bool("app_blocking_enabled", ns.GetConfig().EnableAppBlocking))
 
-	if !ns.IsEnabled() || !ns.config.EnableAppBlocking {
+	if !ns.IsEnabled() || !ns.GetConfig().EnableAppBlocking {
 		ns.logger.Info("App blocking notification skipped - disabled")
 		return nil
 	}
-	
+
 	title := "Application Blocked"
 	message := fmt.Sprintf("The application '%s' has been blocked by parental controls.", processName)
-	
-	if ns.config.ShowProcessDetails && pid > 0 {
+
+	if ns.GetConfig().ShowProcessDetails && pid > 0 {
 		message = fmt.Sprintf("The application '%s' (PID: %d) has been blocked by parental controls.", processName, pid)
 	}
-	
+
 	if ruleName != "" {
 		message += fmt.Sprintf(" Rule: %s", ruleName)
 	}
-	
+
 	data := &NotificationData{
 		Type:        NotificationTypeAppBlocked,
 		Title:       title,
 		Message:     message,
-		Icon:        ns.config.AppIcon,
+		Icon:        ns.GetConfig().AppIcon,
 		ProcessName: processName,
 		ProcessPID:  pid,
 		RuleName:    ruleName,
 	}
-	
+
 	ns.logger.Info("Calling sendNotification",
 		logging.String("title", title),
 		logging.String("message", message))
-	
+
 	return ns.sendNotification(ctx, data)
 }
 
 // NotifyWebBlocked sends a notification when a website is blocked
 func (ns *NotificationService) NotifyWebBlocked(ctx context.Context, url string, processName string, ruleName string) error {
-	if !ns.IsEnabled() || !ns.config.EnableWebBlocking {
+	if !ns.IsEnabled() || !ns.GetConfig().EnableWebBlocking {
 		return nil
 	}
-	
+
 	title := "Website Blocked"
 	message := fmt.Sprintf("Access to '%s' has been blocked by parental controls.", url)
-	
+
 	if processName != "" {
 		message += fmt.Sprintf(" Application: %s", processName)
 	}
-	
+
 	if ruleName != "" {
 		message += fmt.Sprintf(" Rule: %s", ruleName)
 	}
-	
+
 	data := &NotificationData{
 		Type:        NotificationTypeWebBlocked,
 		Title:       title,
 		Message:     message,
-		Icon:        ns.config.AppIcon,
+		Icon:        ns.GetConfig().AppIcon,
 		ProcessName: processName,
 		URL:         url,
 		RuleName:    ruleName,
 	}
-	
+
 	return ns.sendNotification(ctx, data)
 }
 
 // NotifyTimeLimit sends a notification about time limits
 func (ns *NotificationService) NotifyTimeLimit(ctx context.Context, message string, details map[string]interface{}) error {
-	if !ns.IsEnabled() || !ns.config.EnableTimeLimit {
+	if !ns.IsEnabled() || !ns.GetConfig().EnableTimeLimit {
 		return nil
 	}
-	
+
 	title := "Time Limit"
-	
+
 	data := &NotificationData{
 		Type:    NotificationTypeTimeLimit,
 		Title:   title,
 		Message: message,
-		Icon:    ns.config.AppIcon,
+		Icon:    ns.GetConfig().AppIcon,
 		Details: details,
 	}
-	
+
 	return ns.sendNotification(ctx, data)
 }
 
 // NotifySystemAlert sends a system alert notification
 func (ns *NotificationService) NotifySystemAlert(ctx context.Context, title string, message string, details map[string]interface{}) error {
-	if !ns.IsEnabled() || !ns.config.EnableSystemAlerts {
+	if !ns.IsEnabled() || !ns.GetConfig().EnableSystemAlerts {
 		return nil
 	}
-	
+
 	data := &NotificationData{
 		Type:    NotificationTypeSystemAlert,
 		Title:   title,
 		Message: message,
-		Icon:    ns.config.AppIcon,
+		Icon:    ns.GetConfig().AppIcon,
 		Details: details,
 	}
-	
+
 	return ns.sendNotification(ctx, data)
 }
 
-// sendNotification sends a notification to the desktop
+// sendNotification delivers a notification to the configured sinks, unless
+// it repeats one whose coalescing window is still open
 func (ns *NotificationService) sendNotification(ctx context.Context, data *NotificationData) error {
+	if ns.coalesce(data) {
+		return nil
+	}
+	return ns.deliverNotification(ctx, data, false)
+}
+
+// deliverNotification rate limits a notification and delivers it to the
+// configured sinks. Coalesced summaries skip the per-type cooldown.
+func (ns *NotificationService) deliverNotification(ctx context.Context, data *NotificationData, summary bool) error {
 	// Check rate limiting
-	if !ns.rateLimiter.Allow(string(data.Type)) {
+	var allowed bool
+	if summary {
+		allowed = ns.rateLimiter.AllowSummary()
+	} else {
+		allowed = ns.rateLimiter.Allow(string(data.Type))
+	}
+	if !allowed {
 		ns.incrementRateLimited()
 		ns.logger.Debug("Notification rate limited",
 			logging.String("type", string(data.Type)),
 			logging.String("title", data.Title))
-		
+
 		// Log rate limiting to audit
 		if ns.auditService != nil {
 			details := map[string]interface{}{
@@ -310,24 +407,29 @@ func (ns *NotificationService) sendNotification(ctx context.Context, data *Notif
 				ns.logger.Error("Failed to log notification rate limiting", logging.Err(err))
 			}
 		}
-		
+
 		return nil // Not an error, just rate limited
 	}
-	
-	// Send the notification using beeep
-	icon := data.Icon
-	if icon == "" {
-		icon = ns.config.AppIcon
-	}
-	
-	err := ns.sendNotificationAsUser(data.Title, data.Message, icon)
+
+	ns.events.Publish(EventNotification, data)
+
+	// Deliver to every configured sink
+	ns.configMu.RLock()
+	sinks := ns.sinks
+	ns.configMu.RUnlock()
+
+	delivered, err := deliverToSinks(ctx, sinks, *data)
 	if err != nil {
+		if delivered > 0 {
+			ns.incrementNotificationSent(data.Type)
+		}
 		ns.incrementError(err)
 		ns.logger.Error("Failed to send notification",
 			logging.Err(err),
 			logging.String("type", string(data.Type)),
-			logging.String("title", data.Title))
-		
+			logging.String("title", data.Title),
+			logging.Int("delivered", delivered))
+
 		// Log notification failure to audit
 		if ns.auditService != nil {
 			details := map[string]interface{}{
@@ -350,13 +452,13 @@ func (ns *NotificationService) sendNotification(ctx context.Context, data *Notif
 				ns.logger.Error("Failed to log notification failure", logging.Err(auditErr))
 			}
 		}
-		
+
 		return fmt.Errorf("failed to send notification: %w", err)
 	}
-	
+
 	// Update statistics
 	ns.incrementNotificationSent(data.Type)
-	
+
 	// Log successful notification to audit
 	if ns.auditService != nil {
 		details := map[string]interface{}{
@@ -373,7 +475,7 @@ func (ns *NotificationService) sendNotification(ctx context.Context, data *Notif
 				details[k] = v
 			}
 		}
-		
+
 		if err := ns.auditService.LogEnforcementAction(
 			ctx,
 			models.ActionTypeAllow,
@@ -386,20 +488,25 @@ func (ns *NotificationService) sendNotification(ctx context.Context, data *Notif
 			ns.logger.Error("Failed to log notification success", logging.Err(err))
 		}
 	}
-	
+
 	ns.logger.Debug("Notification sent successfully",
 		logging.String("type", string(data.Type)),
 		logging.String("title", data.Title),
 		logging.String("process", data.ProcessName))
-	
+
 	return nil
 }
 
+// SetEventBus sets the bus notifications are published to
+func (ns *NotificationService) SetEventBus(events *EventBus) {
+	ns.events = events
+}
+
 // GetStats returns current notification statistics
 func (ns *NotificationService) GetStats() *NotificationStats {
 	ns.statsMu.RLock()
 	defer ns.statsMu.RUnlock()
-	
+
 	// Return a copy to prevent race conditions
 	stats := *ns.stats
 	return &stats
@@ -407,23 +514,33 @@ func (ns *NotificationService) GetStats() *NotificationStats {
 
 // GetConfig returns the current notification configuration
 func (ns *NotificationService) GetConfig() *NotificationConfig {
+	ns.configMu.RLock()
+	defer ns.configMu.RUnlock()
 	return ns.config
 }
 
-// UpdateConfig updates the notification configuration
+// UpdateConfig updates the notification configuration. It is safe to call
+// while notifications are being sent, such as from a configuration reload.
 func (ns *NotificationService) UpdateConfig(config *NotificationConfig) {
+	sinks := ns.buildSinks(config)
+
+	ns.configMu.Lock()
 	ns.config = config
+	ns.sinks = sinks
+	ns.configMu.Unlock()
 	ns.SetEnabled(config.Enabled)
-	
+
 	// Update app name for beeep
 	if config.AppName != "" {
 		beeep.AppName = config.AppName
 	}
-	
+
 	// Update rate limiter
+	ns.rateLimiter.mu.Lock()
 	ns.rateLimiter.maxPerMinute = config.MaxNotificationsPerMinute
 	ns.rateLimiter.cooldownPeriod = config.CooldownPeriod
-	
+	ns.rateLimiter.mu.Unlock()
+
 	ns.logger.Info("Notification configuration updated")
 }
 
@@ -431,17 +548,45 @@ func (ns *NotificationService) UpdateConfig(config *NotificationConfig) {
 func (rl *NotificationRateLimiter) Allow(notificationType string) bool {
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
-	
+
 	now := time.Now()
-	
+
 	// Check cooldown period for this specific notification type
 	if lastTime, exists := rl.lastCooldown[notificationType]; exists {
 		if now.Sub(lastTime) < rl.cooldownPeriod {
 			return false
 		}
 	}
-	
-	// Clean up old notifications (older than 1 minute)
+
+	if !rl.underLimit(now) {
+		return false
+	}
+
+	// Allow the notification
+	rl.notifications = append(rl.notifications, now)
+	rl.lastCooldown[notificationType] = now
+
+	return true
+}
+
+// AllowSummary checks a coalesced summary against the per-minute limit only.
+// Summaries already come at most once per window, and the notification that
+// opened the window has just started its type's cooldown.
+func (rl *NotificationRateLimiter) AllowSummary() bool {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := time.Now()
+	if !rl.underLimit(now) {
+		return false
+	}
+	rl.notifications = append(rl.notifications, now)
+	return true
+}
+
+// underLimit drops notifications older than a minute and reports whether
+// another is allowed (mutex must be held)
+func (rl *NotificationRateLimiter) underLimit(now time.Time) bool {
 	cutoff := now.Add(-time.Minute)
 	var recent []time.Time
 	for _, notifTime := range rl.notifications {
@@ -450,27 +595,18 @@ func (rl *NotificationRateLimiter) Allow(notificationType string) bool {
 		}
 	}
 	rl.notifications = recent
-	
-	// Check if we're under the rate limit
-	if len(rl.notifications) >= rl.maxPerMinute {
-		return false
-	}
-	
-	// Allow the notification
-	rl.notifications = append(rl.notifications, now)
-	rl.lastCooldown[notificationType] = now
-	
-	return true
+
+	return len(rl.notifications) < rl.maxPerMinute
 }
 
 // incrementNotificationSent increments the appropriate statistics counter
 func (ns *NotificationService) incrementNotificationSent(notificationType NotificationType) {
 	ns.statsMu.Lock()
 	defer ns.statsMu.Unlock()
-	
+
 	ns.stats.TotalSent++
 	ns.stats.LastNotificationTime = time.Now()
-	
+
 	switch notificationType {
 	case NotificationTypeAppBlocked:
 		ns.stats.AppBlockingSent++
@@ -494,14 +630,19 @@ func (ns *NotificationService) incrementRateLimited() {
 func (ns *NotificationService) incrementError(err error) {
 	ns.statsMu.Lock()
 	defer ns.statsMu.Unlock()
-	
+
 	ns.stats.Errors++
 	ns.stats.LastError = err.Error()
 	ns.stats.LastErrorTime = time.Now()
 }
 
 // sendNotificationAsUser sends notification in user context when running as root
+// (or as the SYSTEM service account on Windows)
 func (ns *NotificationService) sendNotificationAsUser(title, message, icon string) error {
+	if runtime.GOOS == "windows" {
+		return ns.sendNotificationToConsoleSession(title, message, icon)
+	}
+
 	currentUID := os.Getuid()
 	ns.logger.Info("Attempting to send notification",
 		logging.String("title", title),
@@ -560,20 +701,20 @@ func (ns *NotificationService) sendNotificationViaSudo(title, message, icon stri
 		name string
 		cmd  []string
 	}{
-		{"notify-send", []string{"notify-send", "--app-name=" + ns.config.AppName, "--urgency=normal", title, message}},
+		{"notify-send", []string{"notify-send", "--app-name=" + ns.GetConfig().AppName, "--urgency=normal", title, message}},
 		{"zenity", []string{"zenity", "--info", "--title=" + title, "--text=" + message, "--timeout=5"}},
 		{"xmessage", []string{"xmessage", "-center", "-timeout", "5", title + ": " + message}},
 	}
 
 	for _, method := range methods {
 		ns.logger.Info("Trying notification method", logging.String("method", method.name))
-		
+
 		// Set a timeout for the notification command
 		timeoutCtx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-		
+
 		args := append([]string{"-u", sudoUser}, method.cmd...)
 		cmd := exec.CommandContext(timeoutCtx, "sudo", args...)
-		
+
 		// Set environment for the user with X11 authorization
 		xauthFile := u.HomeDir + "/.Xauthority"
 		cmd.Env = []string{
@@ -583,17 +724,17 @@ func (ns *NotificationService) sendNotificationViaSudo(title, message, icon stri
 			"XDG_RUNTIME_DIR=/run/user/" + u.Uid,
 			"XAUTHORITY=" + xauthFile,
 		}
-		
+
 		output, err := cmd.CombinedOutput()
 		cancel()
-		
+
 		if err == nil {
-			ns.logger.Info("Notification sent successfully", 
+			ns.logger.Info("Notification sent successfully",
 				logging.String("method", method.name),
 				logging.String("output", string(output)))
 			return nil
 		}
-		
+
 		ns.logger.Info("Notification method failed, trying next",
 			logging.String("method", method.name),
 			logging.Err(err),
@@ -602,22 +743,22 @@ func (ns *NotificationService) sendNotificationViaSudo(title, message, icon stri
 
 	// Last resort: log to system and try a simple echo to the user's terminal
 	ns.logger.Info("All GUI notification methods failed, trying console notification")
-	
+
 	// Try to write to the user's terminal sessions
 	timeoutCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
-	
+
 	// Try to send a wall message to all terminals
-	wallCmd := exec.CommandContext(timeoutCtx, "sudo", "-u", sudoUser, "sh", "-c", 
-		fmt.Sprintf("echo '%s: %s' | wall 2>/dev/null || echo '%s: %s' > /dev/console 2>/dev/null || true", 
+	wallCmd := exec.CommandContext(timeoutCtx, "sudo", "-u", sudoUser, "sh", "-c",
+		fmt.Sprintf("echo '%s: %s' | wall 2>/dev/null || echo '%s: %s' > /dev/console 2>/dev/null || true",
 			title, message, title, message))
-	
+
 	output, err := wallCmd.CombinedOutput()
 	if err == nil {
 		ns.logger.Info("Console notification sent successfully", logging.String("output", string(output)))
 		return nil
 	}
-	
+
 	ns.logger.Info("Console notification also failed", logging.Err(err))
 	return fmt.Errorf("all notification methods failed")
 }
@@ -641,4 +782,4 @@ func (ns *NotificationService) findLoggedInUser() (*user.User, error) {
 	}
 
 	return nil, fmt.Errorf("no logged in user found")
-}
\ No newline at end of file
+}
@@ -0,0 +1,10 @@
+//go:build !windows
+
+package service
+
+import "fmt"
+
+// sendNotificationToConsoleSession is only used on Windows
+func (ns *NotificationService) sendNotificationToConsoleSession(title, message, icon string) error {
+	return fmt.Errorf("console session notifications are only supported on Windows")
+}
@@ -0,0 +1,252 @@
+//go:build windows
+
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+	"unicode/utf16"
+	"unsafe"
+
+	"github.com/gen2brain/beeep"
+	"parental-control/internal/logging"
+)
+
+var (
+	wtsapi32 = syscall.NewLazyDLL("wtsapi32.dll")
+	userenv  = syscall.NewLazyDLL("userenv.dll")
+
+	procWTSGetActiveConsoleSessionId = kernel32.NewProc("WTSGetActiveConsoleSessionId")
+	procProcessIdToSessionId         = kernel32.NewProc("ProcessIdToSessionId")
+	procWTSQueryUserToken            = wtsapi32.NewProc("WTSQueryUserToken")
+	procWTSSendMessageW              = wtsapi32.NewProc("WTSSendMessageW")
+	procCreateEnvironmentBlock       = userenv.NewProc("CreateEnvironmentBlock")
+	procDestroyEnvironmentBlock      = userenv.NewProc("DestroyEnvironmentBlock")
+)
+
+const (
+	// noConsoleSession is returned by WTSGetActiveConsoleSessionId when no
+	// session is attached to the console (e.g. during a user switch)
+	noConsoleSession = 0xFFFFFFFF
+
+	createNoWindow           = 0x08000000
+	createUnicodeEnvironment = 0x00000400
+
+	mbIconInformation = 0x00000040
+	mbSetForeground   = 0x00010000
+
+	// toastAppID is the AppUserModelID toasts are shown under. Windows only
+	// shows toasts for registered apps; PowerShell's is always present.
+	toastAppID = `{1AC14E77-02E7-4E5D-B744-2EB1AE5198B7}\WindowsPowerShell\v1.0\powershell.exe`
+
+	// sessionNotificationTimeout bounds each method, including helper processes
+	sessionNotificationTimeout = 10 * time.Second
+)
+
+// sendNotificationToConsoleSession shows a notification to the user at the
+// console. A service runs as SYSTEM in session 0, where beeep's toast lands
+// on a desktop nobody sees, so unless we are already in the console session
+// the notification is raised in the user's session instead.
+func (ns *NotificationService) sendNotificationToConsoleSession(title, message, icon string) error {
+	consoleSession, _, _ := procWTSGetActiveConsoleSessionId.Call()
+	ownSession, err := currentSessionID()
+	ns.logger.Info("Attempting to send notification",
+		logging.String("title", title),
+		logging.Int("console_session", int(uint32(consoleSession))),
+		logging.Int("own_session", int(ownSession)))
+
+	if uint32(consoleSession) == noConsoleSession {
+		ns.logger.Error("No active console session for notification")
+		return fmt.Errorf("no active console session")
+	}
+	sessionID := uint32(consoleSession)
+
+	// Running in the user's own session (not as a service): beeep works as is
+	if err == nil && ownSession == sessionID {
+		ns.logger.Info("Trying beeep notification")
+		err := beeep.Notify(title, message, icon)
+		if err == nil {
+			ns.logger.Info("Notification sent via beeep successfully")
+			return nil
+		}
+		ns.logger.Info("Beeep notification failed, trying session methods", logging.Err(err))
+	}
+
+	// Try multiple notification methods
+	methods := []struct {
+		name string
+		send func() error
+	}{
+		{"toast", func() error {
+			return runInSession(sessionID, powerShellCommandLine(toastScript(title, message)))
+		}},
+		{"msg", func() error {
+			ctx, cancel := context.WithTimeout(context.Background(), sessionNotificationTimeout)
+			defer cancel()
+			output, err := exec.CommandContext(ctx, "msg", strconv.FormatUint(uint64(sessionID), 10),
+				"/TIME:10", title+": "+message).CombinedOutput()
+			if err != nil {
+				return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(output)))
+			}
+			return nil
+		}},
+		{"WTSSendMessage", func() error {
+			return wtsSendMessage(sessionID, title, message)
+		}},
+	}
+
+	for _, method := range methods {
+		ns.logger.Info("Trying notification method",
+			logging.String("method", method.name),
+			logging.Int("session", int(sessionID)))
+
+		if err := method.send(); err != nil {
+			ns.logger.Info("Notification method failed, trying next",
+				logging.String("method", method.name),
+				logging.Err(err))
+			continue
+		}
+
+		ns.logger.Info("Notification sent successfully", logging.String("method", method.name))
+		return nil
+	}
+
+	return fmt.Errorf("all notification methods failed")
+}
+
+// currentSessionID returns the Terminal Services session of this process
+func currentSessionID() (uint32, error) {
+	var sessionID uint32
+	ret, _, err := procProcessIdToSessionId.Call(uintptr(os.Getpid()), uintptr(unsafe.Pointer(&sessionID)))
+	if ret == 0 {
+		return 0, err
+	}
+	return sessionID, nil
+}
+
+// runInSession starts commandLine as the user logged on to sessionID, on
+// their desktop, and waits for it to exit successfully. Requires running as
+// SYSTEM (WTSQueryUserToken needs the TCB privilege).
+func runInSession(sessionID uint32, commandLine string) error {
+	var token syscall.Token
+	if ret, _, err := procWTSQueryUserToken.Call(uintptr(sessionID), uintptr(unsafe.Pointer(&token))); ret == 0 {
+		return fmt.Errorf("WTSQueryUserToken: %w", err)
+	}
+	defer token.Close()
+
+	var env *uint16
+	if ret, _, err := procCreateEnvironmentBlock.Call(uintptr(unsafe.Pointer(&env)), uintptr(token), 0); ret == 0 {
+		return fmt.Errorf("CreateEnvironmentBlock: %w", err)
+	}
+	defer procDestroyEnvironmentBlock.Call(uintptr(unsafe.Pointer(env)))
+
+	cmdLine, err := syscall.UTF16PtrFromString(commandLine)
+	if err != nil {
+		return err
+	}
+	desktop, err := syscall.UTF16PtrFromString(`winsta0\default`)
+	if err != nil {
+		return err
+	}
+
+	si := &syscall.StartupInfo{Desktop: desktop}
+	si.Cb = uint32(unsafe.Sizeof(*si))
+	var pi syscall.ProcessInformation
+
+	if err := syscall.CreateProcessAsUser(token, nil, cmdLine, nil, nil, false,
+		createNoWindow|createUnicodeEnvironment, env, nil, si, &pi); err != nil {
+		return fmt.Errorf("CreateProcessAsUser: %w", err)
+	}
+	defer syscall.CloseHandle(pi.Process)
+	defer syscall.CloseHandle(pi.Thread)
+
+	event, err := syscall.WaitForSingleObject(pi.Process, uint32(sessionNotificationTimeout.Milliseconds()))
+	if err != nil {
+		return fmt.Errorf("waiting for notification helper: %w", err)
+	}
+	if event != syscall.WAIT_OBJECT_0 {
+		syscall.TerminateProcess(pi.Process, 1)
+		return fmt.Errorf("notification helper timed out")
+	}
+
+	var exitCode uint32
+	if err := syscall.GetExitCodeProcess(pi.Process, &exitCode); err != nil {
+		return err
+	}
+	if exitCode != 0 {
+		return fmt.Errorf("notification helper exited with code %d", exitCode)
+	}
+	return nil
+}
+
+// wtsSendMessage shows a message box in sessionID. It is a last resort: the
+// box must be dismissed, but it works whenever the session exists.
+func wtsSendMessage(sessionID uint32, title, message string) error {
+	titleUTF16 := utf16.Encode([]rune(title))
+	messageUTF16 := utf16.Encode([]rune(message))
+	if len(titleUTF16) == 0 || len(messageUTF16) == 0 {
+		return fmt.Errorf("empty title or message")
+	}
+
+	var response uint32
+	ret, _, err := procWTSSendMessageW.Call(
+		0, // WTS_CURRENT_SERVER_HANDLE
+		uintptr(sessionID),
+		uintptr(unsafe.Pointer(&titleUTF16[0])),
+		uintptr(len(titleUTF16)*2),
+		uintptr(unsafe.Pointer(&messageUTF16[0])),
+		uintptr(len(messageUTF16)*2),
+		mbIconInformation|mbSetForeground,
+		uintptr(sessionNotificationTimeout.Seconds()),
+		uintptr(unsafe.Pointer(&response)),
+		0, // don't wait for the user to respond
+	)
+	if ret == 0 {
+		return fmt.Errorf("WTSSendMessage: %w", err)
+	}
+	return nil
+}
+
+// toastScript is a PowerShell script showing title and message as a toast
+func toastScript(title, message string) string {
+	xml := `<toast><visual><binding template="ToastGeneric"><text>` + xmlEscape(title) +
+		`</text><text>` + xmlEscape(message) + `</text></binding></visual></toast>`
+
+	return strings.Join([]string{
+		`[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] | Out-Null`,
+		`[Windows.Data.Xml.Dom.XmlDocument, Windows.Data.Xml.Dom.XmlDocument, ContentType = WindowsRuntime] | Out-Null`,
+		`$xml = New-Object Windows.Data.Xml.Dom.XmlDocument`,
+		`$xml.LoadXml(` + psQuote(xml) + `)`,
+		`[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier(` + psQuote(toastAppID) +
+			`).Show([Windows.UI.Notifications.ToastNotification]::new($xml))`,
+	}, "\n")
+}
+
+// powerShellCommandLine runs script through -EncodedCommand, which sidesteps
+// command line quoting entirely
+func powerShellCommandLine(script string) string {
+	encoded := utf16.Encode([]rune(script))
+	raw := make([]byte, len(encoded)*2)
+	for i, c := range encoded {
+		raw[i*2] = byte(c)
+		raw[i*2+1] = byte(c >> 8)
+	}
+	return "powershell.exe -NoProfile -NonInteractive -WindowStyle Hidden -EncodedCommand " +
+		base64.StdEncoding.EncodeToString(raw)
+}
+
+func xmlEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;", "'", "&apos;").Replace(s)
+}
+
+// psQuote makes s a single-quoted PowerShell string literal
+func psQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,319 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Notification sink names, as used in NotificationConfig.Sinks
+const (
+	SinkDesktop = "desktop"
+	SinkWebhook = "webhook"
+	SinkNtfy    = "ntfy"
+	SinkEmail   = "email"
+)
+
+// sinkDeliveryTimeout bounds a single delivery to one sink
+const sinkDeliveryTimeout = 30 * time.Second
+
+// NotificationSink delivers notifications to one destination
+type NotificationSink interface {
+	// Name identifies the sink in logs and errors
+	Name() string
+	Deliver(ctx context.Context, data NotificationData) error
+}
+
+// WebhookSinkConfig configures the webhook sink
+type WebhookSinkConfig struct {
+	// URL receives a JSON POST per notification
+	URL string `json:"url" yaml:"url"`
+	// Headers are added to every request, e.g. for authorization
+	Headers map[string]string `json:"-" yaml:"headers"` // May hold credentials
+}
+
+// NtfySinkConfig configures the ntfy sink
+type NtfySinkConfig struct {
+	// URL of the topic, e.g. https://ntfy.sh/my-family-alerts
+	URL string `json:"url" yaml:"url"`
+	// Token is an optional access token for protected topics
+	Token string `json:"-" yaml:"token"` // Never expose in JSON
+}
+
+// EmailSinkConfig configures the e-mail sink
+type EmailSinkConfig struct {
+	To   []string `json:"to" yaml:"to"`
+	From string   `json:"from" yaml:"from"`
+
+	SMTPHost     string `json:"smtp_host" yaml:"smtp_host"`
+	SMTPPort     int    `json:"smtp_port" yaml:"smtp_port"`
+	SMTPUsername string `json:"smtp_username" yaml:"smtp_username"`
+	SMTPPassword string `json:"-" yaml:"smtp_password"` // Never expose in JSON
+}
+
+// NewNotificationSinks builds the sinks named in config.Sinks. The desktop
+// sink delivers through ns. An empty list selects the desktop sink only.
+func NewNotificationSinks(config *NotificationConfig, ns *NotificationService) ([]NotificationSink, error) {
+	names := config.Sinks
+	if len(names) == 0 {
+		names = []string{SinkDesktop}
+	}
+
+	var sinks []NotificationSink
+	seen := make(map[string]bool)
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		switch name {
+		case SinkDesktop:
+			sinks = append(sinks, &desktopSink{ns: ns})
+		case SinkWebhook:
+			if config.Webhook.URL == "" {
+				return nil, fmt.Errorf("webhook notification sink requires a URL")
+			}
+			sinks = append(sinks, NewWebhookSink(config.Webhook, config.AppName))
+		case SinkNtfy:
+			if config.Ntfy.URL == "" {
+				return nil, fmt.Errorf("ntfy notification sink requires a topic URL")
+			}
+			sinks = append(sinks, NewNtfySink(config.Ntfy))
+		case SinkEmail:
+			if len(config.Email.To) == 0 || config.Email.SMTPHost == "" {
+				return nil, fmt.Errorf("email notification sink requires recipients and an SMTP host")
+			}
+			sinks = append(sinks, NewEmailSink(config.Email, config.AppName))
+		default:
+			return nil, fmt.Errorf("unknown notification sink %q", name)
+		}
+	}
+	return sinks, nil
+}
+
+// deliverToSinks delivers data to every sink concurrently. It returns how
+// many sinks accepted it and the joined errors of those that did not.
+func deliverToSinks(ctx context.Context, sinks []NotificationSink, data NotificationData) (int, error) {
+	var (
+		mu        sync.Mutex
+		wg        sync.WaitGroup
+		delivered int
+		errs      []error
+	)
+
+	for _, sink := range sinks {
+		wg.Add(1)
+		go func(sink NotificationSink) {
+			defer wg.Done()
+
+			sinkCtx, cancel := context.WithTimeout(ctx, sinkDeliveryTimeout)
+			defer cancel()
+			err := sink.Deliver(sinkCtx, data)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", sink.Name(), err))
+				return
+			}
+			delivered++
+		}(sink)
+	}
+	wg.Wait()
+
+	return delivered, errors.Join(errs...)
+}
+
+// desktopSink shows notifications on the local desktop, in the logged-in
+// user's session when running as a system service
+type desktopSink struct {
+	ns *NotificationService
+}
+
+func (s *desktopSink) Name() string { return SinkDesktop }
+
+// Deliver shows the notification on the desktop
+func (s *desktopSink) Deliver(ctx context.Context, data NotificationData) error {
+	icon := data.Icon
+	if icon == "" {
+		icon = s.ns.GetConfig().AppIcon
+	}
+	return s.ns.sendNotificationAsUser(data.Title, data.Message, icon)
+}
+
+// WebhookSink posts notifications as JSON
+type WebhookSink struct {
+	config  WebhookSinkConfig
+	appName string
+	client  *http.Client
+}
+
+// NewWebhookSink creates a webhook sink
+func NewWebhookSink(config WebhookSinkConfig, appName string) *WebhookSink {
+	return &WebhookSink{
+		config:  config,
+		appName: appName,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (s *WebhookSink) Name() string { return SinkWebhook }
+
+// Deliver posts the notification, its app name and a timestamp to the URL
+func (s *WebhookSink) Deliver(ctx context.Context, data NotificationData) error {
+	payload, err := json.Marshal(struct {
+		NotificationData
+		App       string    `json:"app"`
+		Timestamp time.Time `json:"timestamp"`
+	}{data, s.appName, time.Now()})
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.URL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range s.config.Headers {
+		req.Header.Set(name, value)
+	}
+
+	return doSinkRequest(s.client, req, "webhook")
+}
+
+// NtfySink publishes notifications to an ntfy topic, for push
+// notifications on a phone
+type NtfySink struct {
+	config NtfySinkConfig
+	client *http.Client
+}
+
+// NewNtfySink creates an ntfy sink
+func NewNtfySink(config NtfySinkConfig) *NtfySink {
+	return &NtfySink{
+		config: config,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (s *NtfySink) Name() string { return SinkNtfy }
+
+// Deliver publishes the message to the topic, with the title and type as
+// ntfy's title and tag
+func (s *NtfySink) Deliver(ctx context.Context, data NotificationData) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.URL, strings.NewReader(data.Message))
+	if err != nil {
+		return fmt.Errorf("failed to create ntfy request: %w", err)
+	}
+	req.Header.Set("Title", data.Title)
+	req.Header.Set("Tags", string(data.Type))
+	if data.Type == NotificationTypeSystemAlert {
+		req.Header.Set("Priority", "high")
+	}
+	if s.config.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.config.Token)
+	}
+
+	return doSinkRequest(s.client, req, "ntfy")
+}
+
+// doSinkRequest sends req and fails on a non-2xx response
+func doSinkRequest(client *http.Client, req *http.Request, sink string) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send %s notification: %w", sink, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s returned %s", sink, resp.Status)
+	}
+	return nil
+}
+
+// EmailSink sends notifications over SMTP
+type EmailSink struct {
+	config  EmailSinkConfig
+	appName string
+}
+
+// NewEmailSink creates an e-mail sink
+func NewEmailSink(config EmailSinkConfig, appName string) *EmailSink {
+	return &EmailSink{config: config, appName: appName}
+}
+
+func (s *EmailSink) Name() string { return SinkEmail }
+
+// Deliver e-mails the notification to the configured recipients
+func (s *EmailSink) Deliver(ctx context.Context, data NotificationData) error {
+	port := s.config.SMTPPort
+	if port == 0 {
+		port = 587
+	}
+	addr := net.JoinHostPort(s.config.SMTPHost, strconv.Itoa(port))
+
+	var auth smtp.Auth
+	if s.config.SMTPUsername != "" {
+		auth = smtp.PlainAuth("", s.config.SMTPUsername, s.config.SMTPPassword, s.config.SMTPHost)
+	}
+
+	from := s.config.From
+	if from == "" {
+		from = s.config.SMTPUsername
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "From: %s\r\n", from)
+	fmt.Fprintf(&body, "To: %s\r\n", strings.Join(s.config.To, ", "))
+	fmt.Fprintf(&body, "Subject: [%s] %s\r\n", s.appName, data.Title)
+	body.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	fmt.Fprintf(&body, "%s\r\n\r\n", data.Message)
+	writeEmailField(&body, "Application", data.ProcessName)
+	writeEmailField(&body, "URL", data.URL)
+	writeEmailField(&body, "Rule", data.RuleName)
+
+	keys := make([]string, 0, len(data.Details))
+	for key := range data.Details {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		writeEmailField(&body, key, fmt.Sprint(data.Details[key]))
+	}
+	fmt.Fprintf(&body, "Sent at: %s\r\n", time.Now().Format(time.RFC1123))
+
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(addr, auth, from, s.config.To, []byte(body.String()))
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("failed to send notification e-mail: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("failed to send notification e-mail: %w", ctx.Err())
+	}
+}
+
+// writeEmailField writes a "name: value" line unless value is empty
+func writeEmailField(body *strings.Builder, name, value string) {
+	if value != "" {
+		fmt.Fprintf(body, "%s: %s\r\n", name, value)
+	}
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"parental-control/internal/logging"
+)
+
+// recordingSink records deliveries and fails when err is set
+type recordingSink struct {
+	name      string
+	err       error
+	mu        sync.Mutex
+	delivered []NotificationData
+}
+
+func (s *recordingSink) Name() string { return s.name }
+
+func (s *recordingSink) Deliver(ctx context.Context, data NotificationData) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.delivered = append(s.delivered, data)
+	return s.err
+}
+
+func TestNotificationService_FansOutToSinks(t *testing.T) {
+	ns := NewNotificationService(DefaultNotificationConfig(), logging.NewDefault())
+	working := &recordingSink{name: "working"}
+	failing := &recordingSink{name: "failing", err: errors.New("unreachable")}
+	ns.sinks = []NotificationSink{working, failing}
+
+	err := ns.NotifyAppBlocked(context.Background(), "game.exe", 42, "No games")
+	if err == nil || !strings.Contains(err.Error(), "failing: unreachable") {
+		t.Fatalf("Expected the failing sink's error, got %v", err)
+	}
+	if len(working.delivered) != 1 || len(failing.delivered) != 1 {
+		t.Fatalf("Expected delivery to both sinks, got %d and %d", len(working.delivered), len(failing.delivered))
+	}
+	if working.delivered[0].ProcessName != "game.exe" {
+		t.Errorf("Unexpected notification: %+v", working.delivered[0])
+	}
+
+	stats := ns.GetStats()
+	if stats.AppBlockingSent != 1 || stats.Errors != 1 {
+		t.Errorf("Expected one sent and one error, got %+v", stats)
+	}
+}
+
+func TestNewNotificationSinks(t *testing.T) {
+	ns := NewNotificationService(DefaultNotificationConfig(), logging.NewDefault())
+
+	config := DefaultNotificationConfig()
+	sinks, err := NewNotificationSinks(config, ns)
+	if err != nil || len(sinks) != 1 || sinks[0].Name() != SinkDesktop {
+		t.Fatalf("Expected the desktop sink by default, got %v (%v)", sinks, err)
+	}
+
+	config.Sinks = []string{"webhook", "ntfy"}
+	config.Webhook.URL = "http://127.0.0.1/hook"
+	config.Ntfy.URL = "https://ntfy.sh/family"
+	sinks, err = NewNotificationSinks(config, ns)
+	if err != nil || len(sinks) != 2 || sinks[0].Name() != SinkWebhook || sinks[1].Name() != SinkNtfy {
+		t.Fatalf("Expected webhook and ntfy sinks, got %v (%v)", sinks, err)
+	}
+
+	config.Sinks = []string{"email"}
+	if _, err := NewNotificationSinks(config, ns); err == nil {
+		t.Error("Expected an error for an email sink without SMTP settings")
+	}
+	config.Sinks = []string{"pager"}
+	if _, err := NewNotificationSinks(config, ns); err == nil {
+		t.Error("Expected an error for an unknown sink")
+	}
+}
+
+func TestWebhookSink_Deliver(t *testing.T) {
+	var payload map[string]interface{}
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&payload)
+	}))
+	defer server.Close()
+
+	sink := NewWebhookSink(WebhookSinkConfig{
+		URL:     server.URL,
+		Headers: map[string]string{"Authorization": "Bearer secret"},
+	}, "Parental Control")
+	err := sink.Deliver(context.Background(), NotificationData{
+		Type:    NotificationTypeWebBlocked,
+		Title:   "Website Blocked",
+		Message: "Access blocked",
+		URL:     "games.example.com",
+	})
+	if err != nil {
+		t.Fatalf("Failed to deliver: %v", err)
+	}
+
+	if authorization != "Bearer secret" {
+		t.Errorf("Expected the configured header, got %q", authorization)
+	}
+	if payload["type"] != "web_blocked" || payload["url"] != "games.example.com" || payload["app"] != "Parental Control" {
+		t.Errorf("Unexpected payload: %v", payload)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer failing.Close()
+	if err := NewWebhookSink(WebhookSinkConfig{URL: failing.URL}, "").Deliver(context.Background(), NotificationData{}); err == nil {
+		t.Error("Expected an error for a non-2xx response")
+	}
+}
+
+func TestNtfySink_Deliver(t *testing.T) {
+	var title, priority, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		title = r.Header.Get("Title")
+		priority = r.Header.Get("Priority")
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+	}))
+	defer server.Close()
+
+	sink := NewNtfySink(NtfySinkConfig{URL: server.URL + "/family"})
+	err := sink.Deliver(context.Background(), NotificationData{
+		Type:    NotificationTypeSystemAlert,
+		Title:   "Account locked",
+		Message: "The account was locked",
+	})
+	if err != nil {
+		t.Fatalf("Failed to deliver: %v", err)
+	}
+	if title != "Account locked" || priority != "high" || body != "The account was locked" {
+		t.Errorf("Unexpected ntfy request: title=%q priority=%q body=%q", title, priority, body)
+	}
+}
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"parental-control/internal/logging"
+	"parental-control/internal/models"
+)
+
+const (
+	// historyWriteTimeout bounds each write to the history store
+	historyWriteTimeout = 5 * time.Second
+	// historyPruneInterval is how often expired history is removed
+	historyPruneInterval = time.Hour
+)
+
+// SetHistoryStore persists trend data and alerts so they survive restarts.
+// It must be called before Start, which loads the stored history.
+func (pm *PerformanceMonitor) SetHistoryStore(store models.PerformanceHistoryRepository) {
+	pm.history = store
+}
+
+// GetSnapshots returns the metric snapshots taken between start and end,
+// oldest first. With a limit, the most recent snapshots in the range are
+// returned. Without a history store only in-memory trend data is searched.
+func (pm *PerformanceMonitor) GetSnapshots(ctx context.Context, start, end time.Time, limit int) ([]MetricSnapshot, error) {
+	if pm.history == nil {
+		pm.trendDataMu.RLock()
+		defer pm.trendDataMu.RUnlock()
+
+		var snapshots []MetricSnapshot
+		for _, snapshot := range pm.trendData {
+			if !snapshot.Timestamp.Before(start) && !snapshot.Timestamp.After(end) {
+				snapshots = append(snapshots, snapshot)
+			}
+		}
+		if limit > 0 && len(snapshots) > limit {
+			snapshots = snapshots[len(snapshots)-limit:]
+		}
+		return snapshots, nil
+	}
+
+	records, err := pm.history.GetSnapshotsByTimeRange(ctx, start, end, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load performance snapshots: %w", err)
+	}
+
+	snapshots := make([]MetricSnapshot, 0, len(records))
+	for _, record := range records {
+		var metrics SystemMetrics
+		if err := json.Unmarshal([]byte(record.Metrics), &metrics); err != nil {
+			pm.logger.Warn("Skipping unreadable performance snapshot",
+				logging.Int("id", record.ID), logging.Err(err))
+			continue
+		}
+		snapshots = append(snapshots, MetricSnapshot{Timestamp: record.Timestamp, Metrics: metrics})
+	}
+	return snapshots, nil
+}
+
+// loadHistory restores trend data within the retention period and alerts
+// that are still open or recent, so a breached threshold keeps its alert
+func (pm *PerformanceMonitor) loadHistory(ctx context.Context) {
+	if pm.history == nil {
+		return
+	}
+
+	now := time.Now()
+	cutoff := now.Add(-pm.config.TrendDataRetention)
+
+	snapshots, err := pm.GetSnapshots(ctx, cutoff, now, pm.maxTrendData)
+	if err != nil {
+		pm.logger.Warn("Failed to restore performance trend data", logging.Err(err))
+	} else {
+		pm.trendDataMu.Lock()
+		pm.trendData = append(snapshots, pm.trendData...)
+		pm.trendDataMu.Unlock()
+	}
+
+	records, err := pm.history.GetAlerts(ctx, cutoff)
+	if err != nil {
+		pm.logger.Warn("Failed to restore performance alerts", logging.Err(err))
+		return
+	}
+
+	alerts := make([]PerformanceAlert, 0, len(records))
+	for _, record := range records {
+		var threshold PerformanceThreshold
+		if err := json.Unmarshal([]byte(record.Threshold), &threshold); err != nil {
+			pm.logger.Warn("Skipping unreadable performance alert",
+				logging.String("alert_id", record.ID), logging.Err(err))
+			continue
+		}
+		alerts = append(alerts, PerformanceAlert{
+			ID:           record.ID,
+			Timestamp:    record.TriggeredAt,
+			Threshold:    threshold,
+			CurrentValue: record.CurrentValue,
+			Severity:     record.Severity,
+			Message:      record.Message,
+			Resolved:     record.Resolved,
+			ResolvedAt:   record.ResolvedAt,
+		})
+	}
+
+	pm.alertsMu.Lock()
+	pm.alerts = append(alerts, pm.alerts...)
+	pm.alertsMu.Unlock()
+
+	pm.logger.Info("Restored performance history",
+		logging.Int("snapshots", len(snapshots)),
+		logging.Int("alerts", len(alerts)))
+}
+
+// saveSnapshot persists a metrics sample
+func (pm *PerformanceMonitor) saveSnapshot(metrics SystemMetrics) {
+	if pm.history == nil {
+		return
+	}
+
+	data, err := json.Marshal(metrics)
+	if err != nil {
+		pm.logger.Warn("Failed to encode performance snapshot", logging.Err(err))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), historyWriteTimeout)
+	defer cancel()
+	if err := pm.history.SaveSnapshot(ctx, &models.PerformanceSnapshot{
+		Timestamp: metrics.Timestamp,
+		Metrics:   string(data),
+	}); err != nil {
+		pm.logger.Warn("Failed to persist performance snapshot", logging.Err(err))
+	}
+}
+
+// saveAlert persists a new or changed alert
+func (pm *PerformanceMonitor) saveAlert(alert PerformanceAlert) {
+	if pm.history == nil {
+		return
+	}
+
+	threshold, err := json.Marshal(alert.Threshold)
+	if err != nil {
+		pm.logger.Warn("Failed to encode performance alert", logging.Err(err))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), historyWriteTimeout)
+	defer cancel()
+	if err := pm.history.SaveAlert(ctx, &models.PerformanceAlertRecord{
+		ID:            alert.ID,
+		ThresholdName: alert.Threshold.Name,
+		Threshold:     string(threshold),
+		Severity:      alert.Severity,
+		CurrentValue:  alert.CurrentValue,
+		Message:       alert.Message,
+		TriggeredAt:   alert.Timestamp,
+		Resolved:      alert.Resolved,
+		ResolvedAt:    alert.ResolvedAt,
+	}); err != nil {
+		pm.logger.Warn("Failed to persist performance alert",
+			logging.String("alert_id", alert.ID), logging.Err(err))
+	}
+}
+
+// pruneHistory drops resolved alerts and stored snapshots older than the
+// trend data retention period, at most once per prune interval
+func (pm *PerformanceMonitor) pruneHistory(now time.Time) {
+	if pm.config.TrendDataRetention <= 0 || now.Sub(pm.lastHistoryPrune) < historyPruneInterval {
+		return
+	}
+	pm.lastHistoryPrune = now
+	cutoff := now.Add(-pm.config.TrendDataRetention)
+
+	pm.alertsMu.Lock()
+	kept := pm.alerts[:0]
+	for _, alert := range pm.alerts {
+		if !alert.Resolved || !alert.Timestamp.Before(cutoff) {
+			kept = append(kept, alert)
+		}
+	}
+	pm.alerts = kept
+	pm.alertsMu.Unlock()
+
+	if pm.history == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), historyWriteTimeout)
+	defer cancel()
+	if err := pm.history.CleanupOldSnapshots(ctx, cutoff); err != nil {
+		pm.logger.Warn("Failed to prune performance snapshots", logging.Err(err))
+	}
+	if err := pm.history.CleanupOldAlerts(ctx, cutoff); err != nil {
+		pm.logger.Warn("Failed to prune performance alerts", logging.Err(err))
+	}
+}
@@ -2,12 +2,16 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
 	"runtime"
 	"sync"
 	"time"
 
+	"parental-control/internal/database"
 	"parental-control/internal/logging"
+	"parental-control/internal/models"
 )
 
 // PerformanceMonitor provides centralized performance monitoring and metrics collection
@@ -20,24 +24,39 @@ type PerformanceMonitor struct {
 	retentionService *RetentionService
 	rotationService  *LogRotationService
 
+	// Connection pools to report on, nil to leave them out
+	database *database.DB
+
 	// Performance tracking
-	metrics   *SystemMetrics
-	metricsMu sync.RWMutex
+	metrics     *SystemMetrics
+	collections int64
+	metricsMu   sync.RWMutex
+
+	// CPU sampling baseline, only touched by the collection loop once started
+	lastCPUTime   time.Duration
+	lastCPUSample time.Time
 
 	// Thresholds and alerting
 	thresholds map[string]PerformanceThreshold
 	alerts     []PerformanceAlert
+	alertsMu   sync.RWMutex
+	alerter    PerformanceAlerter
 
 	// Service state
 	running   bool
 	runningMu sync.RWMutex
 	stopCh    chan struct{}
 	wg        sync.WaitGroup
+	lifecycle *Lifecycle
 
 	// Performance analysis
 	trendData    []MetricSnapshot
 	trendDataMu  sync.RWMutex
 	maxTrendData int
+
+	// Optional persistence of trend data and alerts
+	history          models.PerformanceHistoryRepository
+	lastHistoryPrune time.Time
 }
 
 // PerformanceConfig holds configuration for performance monitoring
@@ -50,7 +69,10 @@ type PerformanceConfig struct {
 	// Alerting settings
 	EnableAlerting      bool          `json:"enable_alerting"`
 	AlertCheckInterval  time.Duration `json:"alert_check_interval"`
-	AlertCooldownPeriod time.Duration `json:"alert_cooldown_period"`
+	AlertCooldownPeriod time.Duration `json:"alert_cooldown_period"` // Quiet period after an alert resolves
+	// AlertHysteresisPercent is how far, as a percentage of the threshold,
+	// a metric must fall back before its alert resolves
+	AlertHysteresisPercent float64 `json:"alert_hysteresis_percent"`
 
 	// Performance limits
 	MaxMemoryUsageMB    int64   `json:"max_memory_usage_mb"`
@@ -67,19 +89,20 @@ type PerformanceConfig struct {
 // DefaultPerformanceConfig returns performance monitoring configuration with sensible defaults
 func DefaultPerformanceConfig() PerformanceConfig {
 	return PerformanceConfig{
-		CollectionInterval:  30 * time.Second,
-		TrendDataRetention:  24 * time.Hour,
-		MaxTrendDataPoints:  2880, // 24 hours at 30-second intervals
-		EnableAlerting:      true,
-		AlertCheckInterval:  1 * time.Minute,
-		AlertCooldownPeriod: 5 * time.Minute,
-		MaxMemoryUsageMB:    512,
-		MaxCPUUsagePercent:  80.0,
-		MaxResponseTimeMs:   1000,
-		MaxDiskUsagePercent: 85.0,
-		EnableTrendAnalysis: true,
-		TrendAnalysisWindow: 60,  // 30 minutes at 30-second intervals
-		RegressionThreshold: 0.2, // 20% performance degradation threshold
+		CollectionInterval:     30 * time.Second,
+		TrendDataRetention:     24 * time.Hour,
+		MaxTrendDataPoints:     2880, // 24 hours at 30-second intervals
+		EnableAlerting:         true,
+		AlertCheckInterval:     1 * time.Minute,
+		AlertCooldownPeriod:    5 * time.Minute,
+		AlertHysteresisPercent: 5.0,
+		MaxMemoryUsageMB:       512,
+		MaxCPUUsagePercent:     80.0,
+		MaxResponseTimeMs:      1000,
+		MaxDiskUsagePercent:    85.0,
+		EnableTrendAnalysis:    true,
+		TrendAnalysisWindow:    60,  // 30 minutes at 30-second intervals
+		RegressionThreshold:    0.2, // 20% performance degradation threshold
 	}
 }
 
@@ -99,6 +122,7 @@ type SystemMetrics struct {
 	RetentionMetrics   *RetentionPerformanceMetrics   `json:"retention_metrics"`
 	RotationMetrics    *RotationPerformanceMetrics    `json:"rotation_metrics"`
 	SessionMetrics     *SessionPerformanceMetrics     `json:"session_metrics"`
+	DatabaseMetrics    *DatabasePerformanceMetrics    `json:"database_metrics"`
 
 	// Performance indicators
 	ResponseTimes       map[string]time.Duration `json:"response_times"`
@@ -194,6 +218,33 @@ type PerformanceReport struct {
 	HealthScore     float64            `json:"health_score"`
 }
 
+// DatabasePerformanceMetrics reports database connection pool saturation
+// from sql.DBStats. The Read fields cover the read-only reporting pool and
+// stay zero without one.
+type DatabasePerformanceMetrics struct {
+	MaxOpenConnections int           `json:"max_open_connections"`
+	OpenConnections    int           `json:"open_connections"`
+	InUse              int           `json:"in_use"`
+	Idle               int           `json:"idle"`
+	WaitCount          int64         `json:"wait_count"`
+	WaitDuration       time.Duration `json:"wait_duration"`
+	MaxIdleClosed      int64         `json:"max_idle_closed"`
+	MaxLifetimeClosed  int64         `json:"max_lifetime_closed"`
+
+	ReadOpenConnections int           `json:"read_open_connections"`
+	ReadInUse           int           `json:"read_in_use"`
+	ReadWaitCount       int64         `json:"read_wait_count"`
+	ReadWaitDuration    time.Duration `json:"read_wait_duration"`
+
+	// SlowQueries counts statements over database.slow_query_threshold
+	SlowQueries int64 `json:"slow_queries"`
+}
+
+// PerformanceAlerter sends system alerts; NotificationService implements it
+type PerformanceAlerter interface {
+	NotifySystemAlert(ctx context.Context, title string, message string, details map[string]interface{}) error
+}
+
 // NewPerformanceMonitor creates a new performance monitoring service
 func NewPerformanceMonitor(
 	config PerformanceConfig,
@@ -212,6 +263,7 @@ func NewPerformanceMonitor(
 		thresholds:       make(map[string]PerformanceThreshold),
 		alerts:           make([]PerformanceAlert, 0),
 		stopCh:           make(chan struct{}),
+		lifecycle:        NewLifecycle(logger, "performance_monitor"),
 		maxTrendData:     config.MaxTrendDataPoints,
 		trendData:        make([]MetricSnapshot, 0, config.MaxTrendDataPoints),
 	}
@@ -226,11 +278,21 @@ func (pm *PerformanceMonitor) Start(ctx context.Context) error {
 		return fmt.Errorf("performance monitor is already running")
 	}
 
-	pm.logger.Info("Starting performance monitor")
+	pm.lifecycle.Starting(
+		logging.Duration("collection_interval", pm.config.CollectionInterval),
+		logging.Bool("alerting", pm.config.EnableAlerting),
+		logging.Duration("alert_check_interval", pm.config.AlertCheckInterval),
+		logging.Bool("trend_analysis", pm.config.EnableTrendAnalysis))
 
 	// Initialize default thresholds
 	pm.initializeDefaultThresholds()
 
+	// Baseline CPU time so the first collection measures a full interval
+	pm.getCPUUsage()
+
+	// Restore trend data and open alerts from before the restart
+	pm.loadHistory(ctx)
+
 	// Start metric collection
 	pm.wg.Add(1)
 	go pm.metricsCollectionLoop(ctx)
@@ -248,7 +310,7 @@ func (pm *PerformanceMonitor) Start(ctx context.Context) error {
 	}
 
 	pm.running = true
-	pm.logger.Info("Performance monitor started successfully")
+	pm.lifecycle.Started()
 	return nil
 }
 
@@ -261,16 +323,37 @@ func (pm *PerformanceMonitor) Stop() error {
 		return nil
 	}
 
-	pm.logger.Info("Stopping performance monitor")
+	pm.lifecycle.Stopping()
 
 	close(pm.stopCh)
 	pm.wg.Wait()
 
 	pm.running = false
-	pm.logger.Info("Performance monitor stopped")
+
+	pm.metricsMu.RLock()
+	collections := pm.collections
+	pm.metricsMu.RUnlock()
+	pm.alertsMu.RLock()
+	alertsRaised := len(pm.alerts)
+	pm.alertsMu.RUnlock()
+	pm.lifecycle.Stopped(collections, 0,
+		logging.Int("alerts_raised", alertsRaised),
+		logging.Int("active_alerts", len(pm.getActiveAlerts())))
 	return nil
 }
 
+// SetAlerter sets where notifications go when an alert is raised or
+// resolves. It must be called before Start.
+func (pm *PerformanceMonitor) SetAlerter(alerter PerformanceAlerter) {
+	pm.alerter = alerter
+}
+
+// SetDatabase adds the database's connection pool statistics to the
+// collected metrics. It must be called before Start.
+func (pm *PerformanceMonitor) SetDatabase(db *database.DB) {
+	pm.database = db
+}
+
 // GetCurrentMetrics returns the current system performance metrics
 func (pm *PerformanceMonitor) GetCurrentMetrics() *SystemMetrics {
 	pm.metricsMu.RLock()
@@ -304,18 +387,25 @@ func (pm *PerformanceMonitor) GetActiveAlerts() []PerformanceAlert {
 	return pm.getActiveAlerts()
 }
 
-// AddThreshold adds a custom performance threshold
-func (pm *PerformanceMonitor) AddThreshold(threshold PerformanceThreshold) {
+// AddThreshold adds a custom performance threshold. The metric path must
+// name a numeric metric, see ValidateMetricPath.
+func (pm *PerformanceMonitor) AddThreshold(threshold PerformanceThreshold) error {
+	if err := ValidateMetricPath(threshold.MetricPath); err != nil {
+		return err
+	}
+
 	pm.thresholds[threshold.Name] = threshold
 	pm.logger.Info("Added performance threshold",
 		logging.String("name", threshold.Name),
 		logging.String("metric", threshold.MetricPath),
 		logging.Field{Key: "threshold", Value: threshold.Threshold})
+	return nil
 }
 
 // RemoveThreshold removes a performance threshold
 func (pm *PerformanceMonitor) RemoveThreshold(name string) {
 	delete(pm.thresholds, name)
+	pm.resolveAlert(name)
 	pm.logger.Info("Removed performance threshold",
 		logging.String("name", name))
 }
@@ -358,10 +448,13 @@ func (pm *PerformanceMonitor) collectMetrics() {
 	// Update current metrics
 	pm.metricsMu.Lock()
 	pm.metrics = metrics
+	pm.collections++
 	pm.metricsMu.Unlock()
 
 	// Add to trend data
 	pm.addToTrendData(*metrics)
+	pm.saveSnapshot(*metrics)
+	pm.pruneHistory(metrics.Timestamp)
 }
 
 func (pm *PerformanceMonitor) collectSystemMetrics(metrics *SystemMetrics) {
@@ -371,9 +464,12 @@ func (pm *PerformanceMonitor) collectSystemMetrics(metrics *SystemMetrics) {
 	metrics.MemoryUsage = int64(m.Alloc)
 	metrics.CPUUsage = pm.getCPUUsage()
 
-	// Get disk usage (this would be platform-specific in a real implementation)
-	metrics.DiskUsage = 0.5               // Placeholder
-	metrics.DiskFree = 1024 * 1024 * 1024 // Placeholder: 1GB
+	if disk, err := currentDiskSpace(); err != nil {
+		pm.logger.Debug("Failed to read disk usage", logging.Err(err))
+	} else {
+		metrics.DiskUsage = disk.UsagePercent * 100
+		metrics.DiskFree = disk.FreeSpace
+	}
 }
 
 func (pm *PerformanceMonitor) collectServiceMetrics(metrics *SystemMetrics) {
@@ -400,6 +496,14 @@ func (pm *PerformanceMonitor) collectServiceMetrics(metrics *SystemMetrics) {
 		}
 	}
 
+	// Collect database connection pool metrics
+	if pm.database != nil {
+		metrics.DatabaseMetrics = pm.collectDatabaseMetrics()
+		if maxOpen := metrics.DatabaseMetrics.MaxOpenConnections; maxOpen > 0 {
+			metrics.ResourceUtilization["database_connections"] = float64(metrics.DatabaseMetrics.InUse) / float64(maxOpen) * 100
+		}
+	}
+
 	// Collect rotation service metrics
 	if pm.rotationService != nil {
 		rotationStats := pm.rotationService.GetStats()
@@ -414,10 +518,60 @@ func (pm *PerformanceMonitor) collectServiceMetrics(metrics *SystemMetrics) {
 	}
 }
 
+// collectDatabaseMetrics reads the connection pool statistics
+func (pm *PerformanceMonitor) collectDatabaseMetrics() *DatabasePerformanceMetrics {
+	write := pm.database.Connection().Stats()
+	metrics := &DatabasePerformanceMetrics{
+		MaxOpenConnections: write.MaxOpenConnections,
+		OpenConnections:    write.OpenConnections,
+		InUse:              write.InUse,
+		Idle:               write.Idle,
+		WaitCount:          write.WaitCount,
+		WaitDuration:       write.WaitDuration,
+		MaxIdleClosed:      write.MaxIdleClosed,
+		MaxLifetimeClosed:  write.MaxLifetimeClosed,
+		SlowQueries:        pm.database.SlowQueries(),
+	}
+
+	if readConn := pm.database.ReadConnection(); readConn != pm.database.Connection() {
+		read := readConn.Stats()
+		metrics.ReadOpenConnections = read.OpenConnections
+		metrics.ReadInUse = read.InUse
+		metrics.ReadWaitCount = read.WaitCount
+		metrics.ReadWaitDuration = read.WaitDuration
+	}
+	return metrics
+}
+
+// getCPUUsage returns the process CPU usage since the previous call, as a
+// percentage of all cores. The first call only records a baseline and
+// returns 0.
 func (pm *PerformanceMonitor) getCPUUsage() float64 {
-	// This is a simplified CPU usage calculation
-	// In a real implementation, this would use platform-specific system calls
-	return 5.0 // Placeholder: 5% CPU usage
+	cpuTime, err := processCPUTime()
+	if err != nil {
+		pm.logger.Debug("Failed to read process CPU time", logging.Err(err))
+		return 0
+	}
+	now := time.Now()
+
+	prevCPU, prevSample := pm.lastCPUTime, pm.lastCPUSample
+	pm.lastCPUTime, pm.lastCPUSample = cpuTime, now
+	if prevSample.IsZero() {
+		return 0
+	}
+
+	wall := now.Sub(prevSample)
+	if wall <= 0 {
+		return 0
+	}
+	usage := float64(cpuTime-prevCPU) / float64(wall) / float64(runtime.NumCPU()) * 100
+	if usage < 0 {
+		return 0
+	}
+	if usage > 100 {
+		return 100
+	}
+	return usage
 }
 
 func (pm *PerformanceMonitor) calculateThroughput(total int64) float64 {
@@ -467,6 +621,14 @@ func (pm *PerformanceMonitor) addToTrendData(metrics SystemMetrics) {
 	if len(pm.trendData) > pm.maxTrendData {
 		pm.trendData = pm.trendData[1:]
 	}
+
+	// Remove data older than the retention period
+	if pm.config.TrendDataRetention > 0 {
+		cutoff := metrics.Timestamp.Add(-pm.config.TrendDataRetention)
+		for len(pm.trendData) > 0 && pm.trendData[0].Timestamp.Before(cutoff) {
+			pm.trendData = pm.trendData[1:]
+		}
+	}
 }
 
 func (pm *PerformanceMonitor) initializeDefaultThresholds() {
@@ -523,38 +685,82 @@ func (pm *PerformanceMonitor) alertingLoop(ctx context.Context) {
 		case <-pm.stopCh:
 			return
 		case <-ticker.C:
-			pm.checkThresholds()
+			pm.checkThresholds(ctx)
 		}
 	}
 }
 
-func (pm *PerformanceMonitor) checkThresholds() {
+// checkThresholds raises alerts for breached thresholds and resolves those
+// whose metric has recovered, notifying the alerter of both
+func (pm *PerformanceMonitor) checkThresholds(ctx context.Context) {
 	currentMetrics := pm.GetCurrentMetrics()
+	if currentMetrics.Timestamp.IsZero() {
+		return // Nothing collected yet
+	}
 
 	for _, threshold := range pm.thresholds {
-		value := pm.extractMetricValue(currentMetrics, threshold.MetricPath)
+		value, ok := pm.extractMetricValue(currentMetrics, threshold.MetricPath)
+		if !ok {
+			continue
+		}
 		if pm.evaluateThreshold(value, threshold) {
-			pm.triggerAlert(threshold, value)
+			if alert, raised := pm.triggerAlert(threshold, value); raised {
+				pm.notifyAlert(ctx, "Performance alert", alert.Message, alert)
+			}
+		} else if pm.hasRecovered(value, threshold) {
+			if alert, resolved := pm.resolveAlert(threshold.Name); resolved {
+				message := fmt.Sprintf("%s: recovered, current value %.2f (threshold %.2f)",
+					threshold.Description, value, threshold.Threshold)
+				pm.notifyAlert(ctx, "Performance alert resolved", message, alert)
+			}
 		}
 	}
 }
 
-func (pm *PerformanceMonitor) extractMetricValue(metrics *SystemMetrics, path string) float64 {
-	// Simple metric extraction based on path
-	// In a real implementation, this would use reflection or a more sophisticated approach
-	switch path {
-	case "memory_usage_bytes":
-		return float64(metrics.MemoryUsage)
-	case "cpu_usage_percent":
-		return metrics.CPUUsage
-	case "disk_usage_percent":
-		return metrics.DiskUsage
-	case "audit_metrics.failure_rate":
-		if metrics.AuditMetrics != nil {
-			return metrics.AuditMetrics.FailureRate
+// hasRecovered reports whether value is back past threshold by the
+// hysteresis margin, so a metric hovering around the limit does not flap
+func (pm *PerformanceMonitor) hasRecovered(value float64, threshold PerformanceThreshold) bool {
+	margin := math.Abs(threshold.Threshold) * pm.config.AlertHysteresisPercent / 100
+	switch threshold.Operator {
+	case "gt":
+		return value <= threshold.Threshold-margin
+	case "lt":
+		return value >= threshold.Threshold+margin
+	}
+	return !pm.evaluateThreshold(value, threshold)
+}
+
+// notifyAlert sends a system alert about alert, if an alerter is set
+func (pm *PerformanceMonitor) notifyAlert(ctx context.Context, title, message string, alert PerformanceAlert) {
+	if pm.alerter == nil {
+		return
+	}
+
+	details := map[string]interface{}{
+		"alert_id":  alert.ID,
+		"threshold": alert.Threshold.Name,
+		"severity":  alert.Severity,
+		"resolved":  alert.Resolved,
+	}
+	if err := pm.alerter.NotifySystemAlert(ctx, title, message, details); err != nil {
+		pm.logger.Warn("Failed to send performance alert notification",
+			logging.String("alert_id", alert.ID),
+			logging.Err(err))
+	}
+}
+
+// extractMetricValue resolves a threshold's metric path against a sample.
+// It reports false when the metric has no value, e.g. for a service that is
+// not running.
+func (pm *PerformanceMonitor) extractMetricValue(metrics *SystemMetrics, path string) (float64, bool) {
+	value, err := resolveMetricPath(metrics, path)
+	if err != nil {
+		if !errors.Is(err, errMetricUnavailable) {
+			pm.logger.Debug("Failed to resolve metric path", logging.String("path", path), logging.Err(err))
 		}
+		return 0, false
 	}
-	return 0.0
+	return value, true
 }
 
 func (pm *PerformanceMonitor) evaluateThreshold(value float64, threshold PerformanceThreshold) bool {
@@ -569,24 +775,95 @@ func (pm *PerformanceMonitor) evaluateThreshold(value float64, threshold Perform
 	return false
 }
 
-func (pm *PerformanceMonitor) triggerAlert(threshold PerformanceThreshold, currentValue float64) {
+// triggerAlert raises an alert for a breached threshold and reports whether
+// it did. While an alert for the threshold is still open it is updated
+// instead, keeping its ID, and within the cooldown period after one
+// resolves no new alert is raised.
+func (pm *PerformanceMonitor) triggerAlert(threshold PerformanceThreshold, currentValue float64) (PerformanceAlert, bool) {
+	message := fmt.Sprintf("%s: current value %.2f exceeds threshold %.2f", threshold.Description, currentValue, threshold.Threshold)
+
+	pm.alertsMu.Lock()
+	if i := pm.openAlertIndex(threshold.Name); i >= 0 {
+		pm.alerts[i].CurrentValue = currentValue
+		pm.alerts[i].Message = message
+		alert := pm.alerts[i]
+		pm.alertsMu.Unlock()
+		return alert, false
+	}
+	if resolvedAt := pm.lastResolvedAt(threshold.Name); time.Since(resolvedAt) < pm.config.AlertCooldownPeriod {
+		pm.alertsMu.Unlock()
+		pm.logger.Debug("Performance threshold breached during alert cooldown",
+			logging.String("threshold", threshold.Name),
+			logging.Duration("since_resolved", time.Since(resolvedAt)))
+		return PerformanceAlert{}, false
+	}
+
 	alert := PerformanceAlert{
 		ID:           pm.generateAlertID(threshold),
 		Timestamp:    time.Now(),
 		Threshold:    threshold,
 		CurrentValue: currentValue,
 		Severity:     threshold.Severity,
-		Message:      fmt.Sprintf("%s: current value %.2f exceeds threshold %.2f", threshold.Description, currentValue, threshold.Threshold),
+		Message:      message,
 		Resolved:     false,
 	}
-
 	pm.alerts = append(pm.alerts, alert)
+	pm.alertsMu.Unlock()
+
+	pm.saveAlert(alert)
 
 	pm.logger.Warn("Performance alert triggered",
 		logging.String("alert_id", alert.ID),
 		logging.String("threshold", threshold.Name),
 		logging.Field{Key: "current_value", Value: currentValue},
 		logging.Field{Key: "threshold_value", Value: threshold.Threshold})
+	return alert, true
+}
+
+// resolveAlert closes the open alert for a threshold, if any, and returns it
+func (pm *PerformanceMonitor) resolveAlert(thresholdName string) (PerformanceAlert, bool) {
+	pm.alertsMu.Lock()
+	i := pm.openAlertIndex(thresholdName)
+	if i < 0 {
+		pm.alertsMu.Unlock()
+		return PerformanceAlert{}, false
+	}
+	now := time.Now()
+	pm.alerts[i].Resolved = true
+	pm.alerts[i].ResolvedAt = &now
+	alert := pm.alerts[i]
+	pm.alertsMu.Unlock()
+
+	pm.saveAlert(alert)
+
+	pm.logger.Info("Performance alert resolved",
+		logging.String("alert_id", alert.ID),
+		logging.String("threshold", thresholdName),
+		logging.Duration("open_for", now.Sub(alert.Timestamp)))
+	return alert, true
+}
+
+// openAlertIndex returns the index of the unresolved alert for a threshold,
+// or -1 (alertsMu must be held)
+func (pm *PerformanceMonitor) openAlertIndex(thresholdName string) int {
+	for i := len(pm.alerts) - 1; i >= 0; i-- {
+		if pm.alerts[i].Threshold.Name == thresholdName && !pm.alerts[i].Resolved {
+			return i
+		}
+	}
+	return -1
+}
+
+// lastResolvedAt returns when the latest alert for a threshold resolved, or
+// the zero time (alertsMu must be held)
+func (pm *PerformanceMonitor) lastResolvedAt(thresholdName string) time.Time {
+	var latest time.Time
+	for _, alert := range pm.alerts {
+		if alert.Threshold.Name == thresholdName && alert.ResolvedAt != nil && alert.ResolvedAt.After(latest) {
+			latest = *alert.ResolvedAt
+		}
+	}
+	return latest
 }
 
 func (pm *PerformanceMonitor) generateAlertID(threshold PerformanceThreshold) string {
@@ -594,6 +871,9 @@ func (pm *PerformanceMonitor) generateAlertID(threshold PerformanceThreshold) st
 }
 
 func (pm *PerformanceMonitor) getActiveAlerts() []PerformanceAlert {
+	pm.alertsMu.RLock()
+	defer pm.alertsMu.RUnlock()
+
 	var activeAlerts []PerformanceAlert
 	for _, alert := range pm.alerts {
 		if !alert.Resolved {
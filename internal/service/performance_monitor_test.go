@@ -0,0 +1,311 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"parental-control/internal/database"
+	"parental-control/internal/logging"
+	"parental-control/internal/models"
+)
+
+func TestPerformanceMonitor_SystemMetrics(t *testing.T) {
+	pm := NewPerformanceMonitor(DefaultPerformanceConfig(), logging.NewDefault(), nil, nil, nil)
+
+	// The first sample has no baseline and must not spike
+	if usage := pm.getCPUUsage(); usage != 0 {
+		t.Errorf("Expected first CPU sample to be 0, got %f", usage)
+	}
+
+	// Burn some CPU so the next sample measures something
+	deadline := time.Now().Add(50 * time.Millisecond)
+	for x := 0; time.Now().Before(deadline); x++ {
+	}
+
+	pm.collectMetrics()
+	metrics := pm.GetCurrentMetrics()
+	if metrics.CPUUsage <= 0 || metrics.CPUUsage > 100 {
+		t.Errorf("Expected CPU usage in (0, 100], got %f", metrics.CPUUsage)
+	}
+	if metrics.DiskUsage < 0 || metrics.DiskUsage > 100 {
+		t.Errorf("Expected disk usage percentage in [0, 100], got %f", metrics.DiskUsage)
+	}
+	if metrics.DiskFree <= 0 {
+		t.Errorf("Expected free disk space to be reported, got %d", metrics.DiskFree)
+	}
+}
+
+func TestPerformanceMonitor_DatabaseMetrics(t *testing.T) {
+	dbConfig := database.DefaultConfig()
+	dbConfig.Path = filepath.Join(t.TempDir(), "performance.db")
+	dbConfig.MaxOpenConns = 4
+	dbConfig.SlowQueryThreshold = time.Nanosecond
+	db, err := database.New(dbConfig)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	defer db.Close()
+
+	// Hold one connection so the pool reports it in use
+	conn, err := db.Connection().Conn(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to get a connection: %v", err)
+	}
+	defer conn.Close()
+	if err := conn.PingContext(context.Background()); err != nil {
+		t.Fatalf("Ping failed: %v", err)
+	}
+	if _, err := db.ReadConnection().Exec("SELECT 1"); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+
+	pm := NewPerformanceMonitor(DefaultPerformanceConfig(), logging.NewDefault(), nil, nil, nil)
+	pm.SetDatabase(db)
+	pm.collectMetrics()
+
+	metrics := pm.GetCurrentMetrics()
+	dbMetrics := metrics.DatabaseMetrics
+	if dbMetrics == nil {
+		t.Fatal("Expected database metrics")
+	}
+	if dbMetrics.MaxOpenConnections != 4 || dbMetrics.InUse != 1 || dbMetrics.ReadOpenConnections == 0 || dbMetrics.SlowQueries == 0 {
+		t.Errorf("Unexpected database metrics: %+v", dbMetrics)
+	}
+	if got := metrics.ResourceUtilization["database_connections"]; got != 25 {
+		t.Errorf("Expected 1 of 4 connections to be 25%% utilized, got %v", got)
+	}
+
+	// Pool saturation can be alerted on like any other metric
+	if got, err := resolveMetricPath(metrics, "database_metrics.in_use"); err != nil || got != 1 {
+		t.Errorf("resolveMetricPath(database_metrics.in_use) = %v, %v; want 1", got, err)
+	}
+}
+
+// memoryPerformanceHistory is an in-memory models.PerformanceHistoryRepository
+type memoryPerformanceHistory struct {
+	snapshots []models.PerformanceSnapshot
+	alerts    map[string]models.PerformanceAlertRecord
+}
+
+func newMemoryPerformanceHistory() *memoryPerformanceHistory {
+	return &memoryPerformanceHistory{alerts: make(map[string]models.PerformanceAlertRecord)}
+}
+
+func (h *memoryPerformanceHistory) SaveSnapshot(ctx context.Context, snapshot *models.PerformanceSnapshot) error {
+	h.snapshots = append(h.snapshots, *snapshot)
+	return nil
+}
+
+func (h *memoryPerformanceHistory) GetSnapshotsByTimeRange(ctx context.Context, start, end time.Time, limit int) ([]models.PerformanceSnapshot, error) {
+	var snapshots []models.PerformanceSnapshot
+	for _, snapshot := range h.snapshots {
+		if !snapshot.Timestamp.Before(start) && !snapshot.Timestamp.After(end) {
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+	if limit > 0 && len(snapshots) > limit {
+		snapshots = snapshots[len(snapshots)-limit:]
+	}
+	return snapshots, nil
+}
+
+func (h *memoryPerformanceHistory) CleanupOldSnapshots(ctx context.Context, before time.Time) error {
+	return nil
+}
+
+func (h *memoryPerformanceHistory) SaveAlert(ctx context.Context, alert *models.PerformanceAlertRecord) error {
+	h.alerts[alert.ID] = *alert
+	return nil
+}
+
+func (h *memoryPerformanceHistory) GetAlerts(ctx context.Context, since time.Time) ([]models.PerformanceAlertRecord, error) {
+	var alerts []models.PerformanceAlertRecord
+	for _, alert := range h.alerts {
+		if !alert.Resolved || !alert.TriggeredAt.Before(since) {
+			alerts = append(alerts, alert)
+		}
+	}
+	return alerts, nil
+}
+
+func (h *memoryPerformanceHistory) CleanupOldAlerts(ctx context.Context, before time.Time) error {
+	return nil
+}
+
+func TestPerformanceMonitor_HistorySurvivesRestart(t *testing.T) {
+	config := DefaultPerformanceConfig()
+	config.EnableAlerting = false
+	config.EnableTrendAnalysis = false
+	config.CollectionInterval = time.Hour
+	config.MaxCPUUsagePercent = -1 // Always breached
+	history := newMemoryPerformanceHistory()
+
+	pm := NewPerformanceMonitor(config, logging.NewDefault(), nil, nil, nil)
+	pm.SetHistoryStore(history)
+	if err := pm.Start(context.Background()); err != nil {
+		t.Fatalf("Failed to start performance monitor: %v", err)
+	}
+	pm.collectMetrics()
+	pm.checkThresholds(context.Background())
+	pm.checkThresholds(context.Background())
+	pm.Stop()
+
+	// A threshold that stays breached keeps a single alert
+	alerts := pm.GetActiveAlerts()
+	var cpuAlert PerformanceAlert
+	count := 0
+	for _, alert := range alerts {
+		if alert.Threshold.Name == "high_cpu_usage" {
+			cpuAlert = alert
+			count++
+		}
+	}
+	if count != 1 {
+		t.Fatalf("Expected one open CPU alert, got %d", count)
+	}
+
+	restarted := NewPerformanceMonitor(config, logging.NewDefault(), nil, nil, nil)
+	restarted.SetHistoryStore(history)
+	if err := restarted.Start(context.Background()); err != nil {
+		t.Fatalf("Failed to restart performance monitor: %v", err)
+	}
+	defer restarted.Stop()
+
+	snapshots, err := restarted.GetSnapshots(context.Background(), time.Now().Add(-time.Hour), time.Now(), 0)
+	if err != nil || len(snapshots) != 1 {
+		t.Fatalf("Expected the stored snapshot after restart, got %d (%v)", len(snapshots), err)
+	}
+
+	// The still-breached threshold reuses the restored alert
+	restarted.collectMetrics()
+	restarted.checkThresholds(context.Background())
+	for _, alert := range restarted.GetActiveAlerts() {
+		if alert.Threshold.Name == "high_cpu_usage" && alert.ID != cpuAlert.ID {
+			t.Errorf("Expected restored alert ID %s, got %s", cpuAlert.ID, alert.ID)
+		}
+	}
+
+	// Recovering resolves the alert, in memory and in the store
+	restarted.RemoveThreshold("high_cpu_usage")
+	if record := history.alerts[cpuAlert.ID]; !record.Resolved || record.ResolvedAt == nil {
+		t.Errorf("Expected stored alert to be resolved, got %+v", record)
+	}
+}
+
+// recordingAlerter records system alert titles
+type recordingAlerter struct {
+	titles []string
+}
+
+func (a *recordingAlerter) NotifySystemAlert(ctx context.Context, title string, message string, details map[string]interface{}) error {
+	a.titles = append(a.titles, title)
+	return nil
+}
+
+func TestPerformanceMonitor_AlertResolution(t *testing.T) {
+	config := DefaultPerformanceConfig()
+	config.AlertCooldownPeriod = time.Hour
+	config.AlertHysteresisPercent = 10
+
+	pm := NewPerformanceMonitor(config, logging.NewDefault(), nil, nil, nil)
+	alerter := &recordingAlerter{}
+	pm.SetAlerter(alerter)
+	pm.thresholds["cpu"] = PerformanceThreshold{
+		Name: "cpu", MetricPath: "cpu_usage_percent", Threshold: 50, Operator: "gt", Severity: "warning",
+	}
+
+	check := func(cpu float64) {
+		pm.metricsMu.Lock()
+		pm.metrics = &SystemMetrics{Timestamp: time.Now(), CPUUsage: cpu}
+		pm.metricsMu.Unlock()
+		pm.checkThresholds(context.Background())
+	}
+
+	check(60)
+	if len(pm.GetActiveAlerts()) != 1 {
+		t.Fatalf("Expected an active alert after breaching the threshold")
+	}
+
+	// Within the hysteresis margin the alert stays open
+	check(48)
+	if len(pm.GetActiveAlerts()) != 1 {
+		t.Fatalf("Expected the alert to stay open within the hysteresis margin")
+	}
+
+	check(40)
+	if len(pm.GetActiveAlerts()) != 0 {
+		t.Fatalf("Expected the alert to resolve once the metric recovered")
+	}
+	if pm.alerts[0].ResolvedAt == nil {
+		t.Errorf("Expected the resolved alert to record when it resolved")
+	}
+
+	// Breaching again during the cooldown does not raise a new alert
+	check(60)
+	if len(pm.GetActiveAlerts()) != 0 {
+		t.Errorf("Expected no new alert during the cooldown period")
+	}
+
+	want := []string{"Performance alert", "Performance alert resolved"}
+	if len(alerter.titles) != len(want) || alerter.titles[0] != want[0] || alerter.titles[1] != want[1] {
+		t.Errorf("Expected notifications %v, got %v", want, alerter.titles)
+	}
+}
+
+func TestResolveMetricPath(t *testing.T) {
+	metrics := &SystemMetrics{
+		CPUUsage:           12.5,
+		MemoryUsage:        2048,
+		EnforcementMetrics: &EnforcementPerformanceMetrics{BlockRate: 30, AverageResponseTime: 1500 * time.Microsecond},
+		ResponseTimes:      map[string]time.Duration{"login": 250 * time.Millisecond},
+		ThroughputRates:    map[string]float64{"audit": 4},
+	}
+
+	tests := []struct {
+		path string
+		want float64
+	}{
+		{"cpu_usage_percent", 12.5},
+		{"memory_usage_bytes", 2048},
+		{"enforcement_metrics.block_rate", 30},
+		{"enforcement_metrics.average_response_time", 1.5},
+		{"response_times.login", 250},
+		{"throughput_rates.audit", 4},
+	}
+	for _, tt := range tests {
+		if err := ValidateMetricPath(tt.path); err != nil {
+			t.Errorf("Expected %s to be valid: %v", tt.path, err)
+		}
+		got, err := resolveMetricPath(metrics, tt.path)
+		if err != nil || got != tt.want {
+			t.Errorf("resolveMetricPath(%s) = %v, %v; want %v", tt.path, got, err, tt.want)
+		}
+	}
+
+	// Valid paths without a value in this sample
+	for _, path := range []string{"audit_metrics.failure_rate", "response_times.logout"} {
+		if err := ValidateMetricPath(path); err != nil {
+			t.Errorf("Expected %s to be valid: %v", path, err)
+		}
+		if _, err := resolveMetricPath(metrics, path); !errors.Is(err, errMetricUnavailable) {
+			t.Errorf("Expected %s to be unavailable, got %v", path, err)
+		}
+	}
+
+	for _, path := range []string{"", "cpu", "enforcement_metrics", "enforcement_metrics.nope", "cpu_usage_percent.x", "timestamp", "response_times"} {
+		if err := ValidateMetricPath(path); !errors.Is(err, ErrUnknownMetricPath) {
+			t.Errorf("Expected %q to be rejected, got %v", path, err)
+		}
+	}
+
+	pm := NewPerformanceMonitor(DefaultPerformanceConfig(), logging.NewDefault(), nil, nil, nil)
+	if err := pm.AddThreshold(PerformanceThreshold{Name: "typo", MetricPath: "enforcement_metrics.blockrate", Threshold: 1, Operator: "gt"}); err == nil {
+		t.Error("Expected AddThreshold to reject an unknown metric path")
+	}
+	if err := pm.AddThreshold(PerformanceThreshold{Name: "blocks", MetricPath: "enforcement_metrics.block_rate", Threshold: 1, Operator: "gt"}); err != nil {
+		t.Errorf("Expected AddThreshold to accept a nested metric path: %v", err)
+	}
+}
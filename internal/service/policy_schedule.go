@@ -0,0 +1,41 @@
+package service
+
+import (
+	"strings"
+	"time"
+
+	"parental-control/internal/cron"
+)
+
+// defaultPolicyInterval applies to retention and rotation policies without
+// an execution schedule
+const defaultPolicyInterval = 24 * time.Hour
+
+// nextPolicyExecution returns when a policy with the given cron schedule
+// runs next after last. Policies without a schedule run daily; an invalid
+// schedule also falls back to daily, reporting the error.
+func nextPolicyExecution(schedule string, last time.Time) (time.Time, error) {
+	if strings.TrimSpace(schedule) == "" {
+		return last.Add(defaultPolicyInterval), nil
+	}
+
+	parsed, err := cron.Parse(schedule)
+	if err != nil {
+		return last.Add(defaultPolicyInterval), err
+	}
+	return parsed.Next(last), nil
+}
+
+// scheduledRunDue reports whether a policy's scheduled run is due. The next
+// run is derived from the schedule and the last run, so a changed schedule
+// applies immediately; the stored next execution is used for a policy that
+// has not run yet. A policy with neither is not due.
+func scheduledRunDue(schedule string, lastExecuted, nextExecution, now time.Time) bool {
+	if !lastExecuted.IsZero() {
+		nextExecution, _ = nextPolicyExecution(schedule, lastExecuted)
+	}
+	if nextExecution.IsZero() {
+		return false
+	}
+	return !now.Before(nextExecution)
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"parental-control/internal/logging"
+	"parental-control/internal/models"
+)
+
+// PolicySeedConfig controls the retention and log rotation policies created
+// on first run. The policies are created disabled by default so users can
+// switch them on without authoring them from scratch.
+type PolicySeedConfig struct {
+	// Enabled seeds default policies when none exist
+	Enabled bool
+	// EnablePolicies creates the seeded policies already enabled
+	EnablePolicies bool
+	// AuditMaxAge deletes audit entries older than this
+	AuditMaxAge time.Duration
+	// AuditMaxEntries caps the number of audit entries kept
+	AuditMaxEntries int64
+	// LogFiles are the application log files to rotate
+	LogFiles []string
+	// LogMaxFileSize rotates a log file once it grows past this size
+	LogMaxFileSize int64
+	// LogRetainDuration keeps rotated log files for this long
+	LogRetainDuration time.Duration
+}
+
+// DefaultPolicySeedConfig returns the defaults: keep 90 days or one million
+// audit entries, whichever limit is reached first, and rotate the log daily
+func DefaultPolicySeedConfig() PolicySeedConfig {
+	return PolicySeedConfig{
+		Enabled:           true,
+		EnablePolicies:    false,
+		AuditMaxAge:       90 * 24 * time.Hour,
+		AuditMaxEntries:   1000000,
+		LogFiles:          []string{"logs/*.log"},
+		LogMaxFileSize:    50 * 1024 * 1024, // 50 MB
+		LogRetainDuration: 7 * 24 * time.Hour,
+	}
+}
+
+// SeedDefaultPolicies creates the default retention and log rotation
+// policies. Each kind is skipped when any policy of that kind already exists,
+// so user edits and deletions are never overwritten. It returns the number of
+// policies created.
+func SeedDefaultPolicies(ctx context.Context, repos *models.RepositoryManager, config PolicySeedConfig) (int, error) {
+	if !config.Enabled || repos == nil {
+		return 0, nil
+	}
+
+	seeded := 0
+
+	if repos.RetentionPolicy != nil {
+		count, err := repos.RetentionPolicy.Count(ctx)
+		if err != nil {
+			return seeded, fmt.Errorf("failed to count retention policies: %w", err)
+		}
+		if count == 0 {
+			policy := defaultRetentionPolicy(config)
+			if err := policy.Validate(); err != nil {
+				return seeded, fmt.Errorf("invalid default retention policy: %w", err)
+			}
+			if err := repos.RetentionPolicy.Create(ctx, policy); err != nil {
+				return seeded, fmt.Errorf("failed to seed retention policy: %w", err)
+			}
+			seeded++
+		}
+	}
+
+	if repos.LogRotationPolicy != nil && len(config.LogFiles) > 0 {
+		count, err := repos.LogRotationPolicy.Count(ctx)
+		if err != nil {
+			return seeded, fmt.Errorf("failed to count log rotation policies: %w", err)
+		}
+		if count == 0 {
+			policy := defaultLogRotationPolicy(config)
+			if err := policy.Validate(); err != nil {
+				return seeded, fmt.Errorf("invalid default log rotation policy: %w", err)
+			}
+			if err := repos.LogRotationPolicy.Create(ctx, policy); err != nil {
+				return seeded, fmt.Errorf("failed to seed log rotation policy: %w", err)
+			}
+			seeded++
+		}
+	}
+
+	return seeded, nil
+}
+
+func defaultRetentionPolicy(config PolicySeedConfig) *models.RetentionPolicy {
+	policy := &models.RetentionPolicy{
+		Name:              "Default Audit Retention",
+		Description:       fmt.Sprintf("Keep audit logs for %s or %d entries, whichever limit is reached first", config.AuditMaxAge, config.AuditMaxEntries),
+		Enabled:           config.EnablePolicies,
+		Priority:          100,
+		EventTypeFilter:   []string{},
+		ActionFilter:      []string{},
+		ExecutionSchedule: "0 2 * * *", // Daily at 2 AM
+	}
+	if config.AuditMaxAge > 0 {
+		policy.TimeBasedRule = &models.TimeBasedRetention{
+			MaxAge:      config.AuditMaxAge,
+			GracePeriod: 24 * time.Hour,
+		}
+	}
+	if config.AuditMaxEntries > 0 {
+		policy.CountBasedRule = &models.CountBasedRetention{
+			MaxCount:         config.AuditMaxEntries,
+			CleanupBatchSize: 10000,
+			CleanupStrategy:  models.CountCleanupOldest,
+		}
+	}
+	return policy
+}
+
+func defaultLogRotationPolicy(config PolicySeedConfig) *models.LogRotationPolicy {
+	policy := &models.LogRotationPolicy{
+		Name:              "Default Application Log Rotation",
+		Description:       "Rotate the application log daily or when it grows too large",
+		Enabled:           config.EnablePolicies,
+		Priority:          100,
+		TargetLogFiles:    config.LogFiles,
+		TargetLogTypes:    []string{},
+		ExecutionSchedule: "0 3 * * *", // Daily at 3 AM
+		TimeBasedRotation: &models.TimeBasedRotation{
+			RotationInterval: 24 * time.Hour,
+			RetainDuration:   config.LogRetainDuration,
+		},
+	}
+	if config.LogMaxFileSize > 0 {
+		policy.SizeBasedRotation = &models.SizeBasedRotation{
+			MaxFileSize:       config.LogMaxFileSize,
+			RotationThreshold: 0.8,
+		}
+	}
+	return policy
+}
+
+// seedDefaultPolicies creates first-run policies; failures are logged but do
+// not stop the service
+func (s *Service) seedDefaultPolicies() {
+	ctx, cancel := context.WithTimeout(s.ctx, 10*time.Second)
+	defer cancel()
+
+	seeded, err := SeedDefaultPolicies(ctx, s.repos, s.config.PolicySeed)
+	if err != nil {
+		logging.Warn("Failed to seed default retention policies", logging.Err(err))
+		return
+	}
+	if seeded > 0 {
+		logging.Info("Seeded default retention and log rotation policies",
+			logging.Int("policies", seeded),
+			logging.Bool("enabled", s.config.PolicySeed.EnablePolicies))
+	}
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"parental-control/internal/models"
+)
+
+// memoryRetentionPolicyRepo stores created retention policies in memory
+type memoryRetentionPolicyRepo struct {
+	models.RetentionPolicyRepository
+	policies []models.RetentionPolicy
+}
+
+func (r *memoryRetentionPolicyRepo) Create(ctx context.Context, policy *models.RetentionPolicy) error {
+	r.policies = append(r.policies, *policy)
+	return nil
+}
+
+func (r *memoryRetentionPolicyRepo) Count(ctx context.Context) (int, error) {
+	return len(r.policies), nil
+}
+
+// memoryLogRotationPolicyRepo stores created rotation policies in memory
+type memoryLogRotationPolicyRepo struct {
+	models.LogRotationPolicyRepository
+	policies []models.LogRotationPolicy
+}
+
+func (r *memoryLogRotationPolicyRepo) Create(ctx context.Context, policy *models.LogRotationPolicy) error {
+	r.policies = append(r.policies, *policy)
+	return nil
+}
+
+func (r *memoryLogRotationPolicyRepo) Count(ctx context.Context) (int, error) {
+	return len(r.policies), nil
+}
+
+func TestSeedDefaultPolicies(t *testing.T) {
+	retention := &memoryRetentionPolicyRepo{}
+	rotation := &memoryLogRotationPolicyRepo{}
+	repos := &models.RepositoryManager{
+		RetentionPolicy:   retention,
+		LogRotationPolicy: rotation,
+	}
+
+	config := DefaultPolicySeedConfig()
+	config.LogFiles = []string{"/var/log/parental-control.log"}
+
+	seeded, err := SeedDefaultPolicies(context.Background(), repos, config)
+	if err != nil {
+		t.Fatalf("Failed to seed policies: %v", err)
+	}
+	if seeded != 2 {
+		t.Fatalf("Expected 2 seeded policies, got %d", seeded)
+	}
+
+	policy := retention.policies[0]
+	if policy.Enabled {
+		t.Error("Expected seeded retention policy to be disabled")
+	}
+	if policy.TimeBasedRule == nil || policy.TimeBasedRule.MaxAge != 90*24*time.Hour {
+		t.Errorf("Expected 90 day time rule, got %+v", policy.TimeBasedRule)
+	}
+	if policy.CountBasedRule == nil || policy.CountBasedRule.MaxCount != 1000000 {
+		t.Errorf("Expected 1M entry count rule, got %+v", policy.CountBasedRule)
+	}
+
+	rotationPolicy := rotation.policies[0]
+	if rotationPolicy.Enabled {
+		t.Error("Expected seeded rotation policy to be disabled")
+	}
+	if len(rotationPolicy.TargetLogFiles) != 1 || rotationPolicy.TargetLogFiles[0] != "/var/log/parental-control.log" {
+		t.Errorf("Expected configured log file target, got %v", rotationPolicy.TargetLogFiles)
+	}
+
+	// Existing policies are never reseeded
+	seeded, err = SeedDefaultPolicies(context.Background(), repos, config)
+	if err != nil {
+		t.Fatalf("Failed to reseed policies: %v", err)
+	}
+	if seeded != 0 || len(retention.policies) != 1 || len(rotation.policies) != 1 {
+		t.Errorf("Expected no policies on second run, seeded %d", seeded)
+	}
+}
+
+func TestSeedDefaultPolicies_Disabled(t *testing.T) {
+	retention := &memoryRetentionPolicyRepo{}
+	repos := &models.RepositoryManager{RetentionPolicy: retention}
+
+	config := DefaultPolicySeedConfig()
+	config.Enabled = false
+
+	seeded, err := SeedDefaultPolicies(context.Background(), repos, config)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if seeded != 0 || len(retention.policies) != 0 {
+		t.Errorf("Expected nothing seeded when disabled, got %d", seeded)
+	}
+}
@@ -14,6 +14,10 @@ import (
 type QuotaService struct {
 	repos  *models.RepositoryManager
 	logger logging.Logger
+
+	auditService        *AuditService
+	notificationService *NotificationService
+	events              *EventBus
 }
 
 // NewQuotaService creates a new quota service
@@ -24,6 +28,21 @@ func NewQuotaService(repos *models.RepositoryManager, logger logging.Logger) *Qu
 	}
 }
 
+// SetAuditService sets the service that records manual quota resets
+func (s *QuotaService) SetAuditService(auditService *AuditService) {
+	s.auditService = auditService
+}
+
+// SetNotificationService sets the service that announces manual quota resets
+func (s *QuotaService) SetNotificationService(notificationService *NotificationService) {
+	s.notificationService = notificationService
+}
+
+// SetEventBus sets the bus quota rule and usage changes are published to
+func (s *QuotaService) SetEventBus(events *EventBus) {
+	s.events = events
+}
+
 // CreateQuotaRuleRequest represents a request to create a new quota rule
 type CreateQuotaRuleRequest struct {
 	ListID       int              `json:"list_id" validate:"required"`
@@ -31,6 +50,9 @@ type CreateQuotaRuleRequest struct {
 	QuotaType    models.QuotaType `json:"quota_type" validate:"required,oneof=daily weekly monthly"`
 	LimitSeconds int              `json:"limit_seconds" validate:"required,min=1"`
 	Enabled      bool             `json:"enabled"`
+	// CarryOver defaults to none
+	CarryOver           models.QuotaCarryOver `json:"carry_over,omitempty" validate:"omitempty,oneof=none unused-rolls-forward-capped"`
+	MaxCarryOverSeconds int                   `json:"carry_over_max_seconds,omitempty" validate:"min=0"`
 }
 
 // UpdateQuotaRuleRequest represents a request to update an existing quota rule
@@ -39,6 +61,9 @@ type UpdateQuotaRuleRequest struct {
 	QuotaType    *models.QuotaType `json:"quota_type,omitempty" validate:"omitempty,oneof=daily weekly monthly"`
 	LimitSeconds *int              `json:"limit_seconds,omitempty" validate:"omitempty,min=1"`
 	Enabled      *bool             `json:"enabled,omitempty"`
+	// Carry-over changes apply from the next window
+	CarryOver           *models.QuotaCarryOver `json:"carry_over,omitempty" validate:"omitempty,oneof=none unused-rolls-forward-capped"`
+	MaxCarryOverSeconds *int                   `json:"carry_over_max_seconds,omitempty" validate:"omitempty,min=0"`
 }
 
 // QuotaRuleStatus represents the current status of a quota rule
@@ -82,6 +107,38 @@ type UsageSummary struct {
 	WarningLevel  QuotaWarningLevel `json:"warning_level"`
 }
 
+// QuotaRemaining is how much of a quota rule's current window is left
+type QuotaRemaining struct {
+	QuotaRuleID      int              `json:"quota_rule_id"`
+	RuleName         string           `json:"rule_name"`
+	QuotaType        models.QuotaType `json:"quota_type"`
+	LimitSeconds     int              `json:"limit_seconds"`
+	CarriedSeconds   int              `json:"carried_seconds"`
+	UsedSeconds      int              `json:"used_seconds"`
+	RemainingSeconds int              `json:"remaining_seconds"`
+	ResetsAt         time.Time        `json:"resets_at"`
+	Exhausted        bool             `json:"exhausted"`
+}
+
+// QuotaChangeType says what changed about a quota rule
+type QuotaChangeType string
+
+const (
+	QuotaChangeCreated QuotaChangeType = "created"
+	QuotaChangeUpdated QuotaChangeType = "updated"
+	QuotaChangeDeleted QuotaChangeType = "deleted"
+	QuotaChangeUsage   QuotaChangeType = "usage"
+	QuotaChangeReset   QuotaChangeType = "reset"
+)
+
+// QuotaChange is published when a quota rule or its usage changes
+type QuotaChange struct {
+	Change      QuotaChangeType `json:"change"`
+	QuotaRuleID int             `json:"quota_rule_id"`
+	ListID      int             `json:"list_id"`
+	Remaining   *QuotaRemaining `json:"remaining,omitempty"`
+}
+
 // CreateQuotaRule creates a new quota rule with validation
 func (s *QuotaService) CreateQuotaRule(ctx context.Context, req CreateQuotaRuleRequest) (*models.QuotaRule, error) {
 	s.logger.Info("Creating new quota rule",
@@ -95,14 +152,21 @@ func (s *QuotaService) CreateQuotaRule(ctx context.Context, req CreateQuotaRuleR
 		return nil, fmt.Errorf("validation failed: %w", err)
 	}
 
+	carryOver := req.CarryOver
+	if carryOver == "" {
+		carryOver = models.CarryOverNone
+	}
+
 	rule := &models.QuotaRule{
-		ListID:       req.ListID,
-		Name:         req.Name,
-		QuotaType:    req.QuotaType,
-		LimitSeconds: req.LimitSeconds,
-		Enabled:      req.Enabled,
-		CreatedAt:    time.Now(),
-		UpdatedAt:    time.Now(),
+		ListID:              req.ListID,
+		Name:                req.Name,
+		QuotaType:           req.QuotaType,
+		LimitSeconds:        req.LimitSeconds,
+		Enabled:             req.Enabled,
+		CarryOver:           carryOver,
+		MaxCarryOverSeconds: req.MaxCarryOverSeconds,
+		CreatedAt:           time.Now(),
+		UpdatedAt:           time.Now(),
 	}
 
 	if err := s.repos.QuotaRule.Create(ctx, rule); err != nil {
@@ -114,6 +178,7 @@ func (s *QuotaService) CreateQuotaRule(ctx context.Context, req CreateQuotaRuleR
 		logging.Int("id", rule.ID),
 		logging.String("name", rule.Name))
 
+	s.publishChange(ctx, QuotaChangeCreated, rule)
 	return rule, nil
 }
 
@@ -135,29 +200,13 @@ func (s *QuotaService) GetQuotaRuleStatus(ctx context.Context, id int) (*QuotaRu
 	}
 
 	now := time.Now()
-	currentUsage, err := s.repos.QuotaUsage.GetCurrentUsage(ctx, id, now)
-	if err != nil {
-		s.logger.Error("Failed to get current usage", logging.Err(err))
-		// Create empty usage if none exists
-		currentUsage = &models.QuotaUsage{
-			QuotaRuleID: id,
-			UsedSeconds: 0,
-			PeriodStart: s.getPeriodStart(rule.QuotaType, now),
-			PeriodEnd:   s.getPeriodEnd(rule.QuotaType, now),
-			CreatedAt:   now,
-			UpdatedAt:   now,
-		}
-	}
-
-	remainingSeconds := rule.LimitSeconds - currentUsage.UsedSeconds
-	if remainingSeconds < 0 {
-		remainingSeconds = 0
-	}
+	currentUsage := s.currentUsage(ctx, rule, now)
+	allowance := currentUsage.AllowanceSeconds(rule.LimitSeconds)
 
-	remainingTime := time.Duration(remainingSeconds) * time.Second
-	isExceeded := currentUsage.UsedSeconds >= rule.LimitSeconds
+	remainingTime := time.Duration(currentUsage.RemainingSeconds(rule.LimitSeconds)) * time.Second
+	isExceeded := currentUsage.UsedSeconds >= allowance
 	nextReset := s.getNextReset(rule.QuotaType, now)
-	warningLevel := s.calculateWarningLevel(currentUsage.UsedSeconds, rule.LimitSeconds)
+	warningLevel := s.calculateWarningLevel(currentUsage.UsedSeconds, allowance)
 
 	return &QuotaRuleStatus{
 		QuotaRule:     rule,
@@ -198,6 +247,18 @@ func (s *QuotaService) UpdateQuotaRule(ctx context.Context, id int, req UpdateQu
 	if req.Enabled != nil {
 		rule.Enabled = *req.Enabled
 	}
+	if req.CarryOver != nil {
+		if err := validateCarryOver(*req.CarryOver); err != nil {
+			return nil, err
+		}
+		rule.CarryOver = *req.CarryOver
+	}
+	if req.MaxCarryOverSeconds != nil {
+		if *req.MaxCarryOverSeconds < 0 {
+			return nil, fmt.Errorf("carry-over cap cannot be negative")
+		}
+		rule.MaxCarryOverSeconds = *req.MaxCarryOverSeconds
+	}
 
 	rule.UpdatedAt = time.Now()
 
@@ -207,6 +268,7 @@ func (s *QuotaService) UpdateQuotaRule(ctx context.Context, id int, req UpdateQu
 	}
 
 	s.logger.Info("Quota rule updated successfully", logging.Int("id", id))
+	s.publishChange(ctx, QuotaChangeUpdated, rule)
 	return rule, nil
 }
 
@@ -229,6 +291,7 @@ func (s *QuotaService) DeleteQuotaRule(ctx context.Context, id int) error {
 		logging.Int("id", id),
 		logging.String("name", rule.Name))
 
+	s.publishChange(ctx, QuotaChangeDeleted, rule)
 	return nil
 }
 
@@ -240,6 +303,13 @@ func (s *QuotaService) TrackUsage(ctx context.Context, quotaRuleID int, addition
 
 	now := time.Now()
 
+	// Open the current window first so time carried in from the last one
+	// is recorded before any usage lands in it
+	rule, err := s.repos.QuotaRule.GetByID(ctx, quotaRuleID)
+	if err == nil {
+		s.currentUsage(ctx, rule, now)
+	}
+
 	if err := s.repos.QuotaUsage.UpdateUsage(ctx, quotaRuleID, additionalSeconds, now); err != nil {
 		s.logger.Error("Failed to track usage",
 			logging.Err(err),
@@ -247,6 +317,9 @@ func (s *QuotaService) TrackUsage(ctx context.Context, quotaRuleID int, addition
 		return fmt.Errorf("failed to track usage: %w", err)
 	}
 
+	if rule != nil {
+		s.publishChange(ctx, QuotaChangeUsage, rule)
+	}
 	return nil
 }
 
@@ -270,28 +343,16 @@ func (s *QuotaService) GetUsageSummary(ctx context.Context, listID int) ([]Usage
 	summaries := make([]UsageSummary, 0, len(rules))
 	now := time.Now()
 
-	for _, rule := range rules {
-		usage, err := s.repos.QuotaUsage.GetCurrentUsage(ctx, rule.ID, now)
-		if err != nil {
-			s.logger.Error("Failed to get usage for rule", logging.Err(err), logging.Int("rule_id", rule.ID))
-			// Create empty usage if none exists
-			usage = &models.QuotaUsage{
-				QuotaRuleID: rule.ID,
-				UsedSeconds: 0,
-				PeriodStart: s.getPeriodStart(rule.QuotaType, now),
-				PeriodEnd:   s.getPeriodEnd(rule.QuotaType, now),
-			}
-		}
+	for i := range rules {
+		rule := &rules[i]
+		usage := s.currentUsage(ctx, rule, now)
+		allowance := usage.AllowanceSeconds(rule.LimitSeconds)
 
-		limitDuration := rule.GetLimitDuration()
+		limitDuration := time.Duration(allowance) * time.Second
 		usedDuration := usage.GetUsedDuration()
-		remainingSeconds := rule.LimitSeconds - usage.UsedSeconds
-		if remainingSeconds < 0 {
-			remainingSeconds = 0
-		}
-		remainingTime := time.Duration(remainingSeconds) * time.Second
+		remainingTime := time.Duration(usage.RemainingSeconds(rule.LimitSeconds)) * time.Second
 
-		usagePercent := float64(usage.UsedSeconds) / float64(rule.LimitSeconds) * 100
+		usagePercent := float64(usage.UsedSeconds) / float64(allowance) * 100
 		if usagePercent > 100 {
 			usagePercent = 100
 		}
@@ -304,15 +365,78 @@ func (s *QuotaService) GetUsageSummary(ctx context.Context, listID int) ([]Usage
 			UsedDuration:  usedDuration,
 			RemainingTime: remainingTime,
 			UsagePercent:  usagePercent,
-			IsExceeded:    usage.UsedSeconds >= rule.LimitSeconds,
+			IsExceeded:    usage.UsedSeconds >= allowance,
 			NextReset:     s.getNextReset(rule.QuotaType, now),
-			WarningLevel:  s.calculateWarningLevel(usage.UsedSeconds, rule.LimitSeconds),
+			WarningLevel:  s.calculateWarningLevel(usage.UsedSeconds, allowance),
 		})
 	}
 
 	return summaries, nil
 }
 
+// GetRemaining returns the time left in the current window of each enabled
+// quota rule on a list. It only reads usage; a window with no usage yet is
+// reported as it would open, including any time carried into it.
+func (s *QuotaService) GetRemaining(ctx context.Context, listID int) ([]QuotaRemaining, error) {
+	if _, err := s.repos.List.GetByID(ctx, listID); err != nil {
+		return nil, fmt.Errorf("invalid list ID: %w", err)
+	}
+
+	rules, err := s.repos.QuotaRule.GetByListID(ctx, listID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quota rules: %w", err)
+	}
+
+	remaining := make([]QuotaRemaining, 0, len(rules))
+	now := time.Now()
+	for i := range rules {
+		rule := &rules[i]
+		if !rule.Enabled {
+			continue
+		}
+
+		remaining = append(remaining, s.remaining(ctx, rule, now))
+	}
+
+	return remaining, nil
+}
+
+// remaining reports the time left in a rule's current window without
+// opening it
+func (s *QuotaService) remaining(ctx context.Context, rule *models.QuotaRule, now time.Time) QuotaRemaining {
+	usage, err := s.repos.QuotaUsage.GetCurrentUsage(ctx, rule.ID, now)
+	if err != nil || usage == nil {
+		usage = s.newWindow(ctx, rule, now)
+	}
+
+	return QuotaRemaining{
+		QuotaRuleID:      rule.ID,
+		RuleName:         rule.Name,
+		QuotaType:        rule.QuotaType,
+		LimitSeconds:     rule.LimitSeconds,
+		CarriedSeconds:   usage.CarriedSeconds,
+		UsedSeconds:      usage.UsedSeconds,
+		RemainingSeconds: usage.RemainingSeconds(rule.LimitSeconds),
+		ResetsAt:         s.getNextReset(rule.QuotaType, now),
+		Exhausted:        usage.UsedSeconds >= usage.AllowanceSeconds(rule.LimitSeconds),
+	}
+}
+
+// publishChange announces a change to a quota rule or its usage, with the
+// time left afterwards unless the rule was deleted
+func (s *QuotaService) publishChange(ctx context.Context, change QuotaChangeType, rule *models.QuotaRule) {
+	if s.events == nil {
+		return
+	}
+
+	event := QuotaChange{Change: change, QuotaRuleID: rule.ID, ListID: rule.ListID}
+	if change != QuotaChangeDeleted {
+		remaining := s.remaining(ctx, rule, time.Now())
+		event.Remaining = &remaining
+	}
+	s.events.Publish(EventQuota, event)
+}
+
 // GetQuotasNearLimit returns quota rules that are near their limits
 func (s *QuotaService) GetQuotasNearLimit(ctx context.Context, threshold float64) ([]UsageSummary, error) {
 	// Get all enabled quota rules
@@ -323,23 +447,25 @@ func (s *QuotaService) GetQuotasNearLimit(ctx context.Context, threshold float64
 
 	nearLimit := make([]UsageSummary, 0)
 	now := time.Now()
+	checker := newListChecker(s.repos, s.logger)
 
 	for _, rule := range rules {
+		if !checker.exists(ctx, rule.ListID, "quota_rule", rule.ID) {
+			continue
+		}
+
 		usage, err := s.repos.QuotaUsage.GetCurrentUsage(ctx, rule.ID, now)
 		if err != nil {
 			continue // Skip if we can't get usage data
 		}
 
-		usagePercent := float64(usage.UsedSeconds) / float64(rule.LimitSeconds) * 100
+		allowance := usage.AllowanceSeconds(rule.LimitSeconds)
+		usagePercent := float64(usage.UsedSeconds) / float64(allowance) * 100
 
 		if usagePercent >= threshold {
-			limitDuration := rule.GetLimitDuration()
+			limitDuration := time.Duration(allowance) * time.Second
 			usedDuration := usage.GetUsedDuration()
-			remainingSeconds := rule.LimitSeconds - usage.UsedSeconds
-			if remainingSeconds < 0 {
-				remainingSeconds = 0
-			}
-			remainingTime := time.Duration(remainingSeconds) * time.Second
+			remainingTime := time.Duration(usage.RemainingSeconds(rule.LimitSeconds)) * time.Second
 
 			nearLimit = append(nearLimit, UsageSummary{
 				QuotaRuleID:   rule.ID,
@@ -349,9 +475,9 @@ func (s *QuotaService) GetQuotasNearLimit(ctx context.Context, threshold float64
 				UsedDuration:  usedDuration,
 				RemainingTime: remainingTime,
 				UsagePercent:  usagePercent,
-				IsExceeded:    usage.UsedSeconds >= rule.LimitSeconds,
+				IsExceeded:    usage.UsedSeconds >= allowance,
 				NextReset:     s.getNextReset(rule.QuotaType, now),
-				WarningLevel:  s.calculateWarningLevel(usage.UsedSeconds, rule.LimitSeconds),
+				WarningLevel:  s.calculateWarningLevel(usage.UsedSeconds, allowance),
 			})
 		}
 	}
@@ -359,31 +485,131 @@ func (s *QuotaService) GetQuotasNearLimit(ctx context.Context, threshold float64
 	return nearLimit, nil
 }
 
-// ResetQuotaUsage manually resets usage for a quota rule
+// ResetQuotaUsage manually resets the current window's usage for a quota
+// rule to zero. Time carried into the window is kept. The reset is recorded
+// in the audit log and announced as a time limit notification.
 func (s *QuotaService) ResetQuotaUsage(ctx context.Context, quotaRuleID int) error {
 	s.logger.Info("Manually resetting quota usage", logging.Int("quota_rule_id", quotaRuleID))
 
-	// Verify quota rule exists
-	if _, err := s.repos.QuotaRule.GetByID(ctx, quotaRuleID); err != nil {
+	rule, err := s.repos.QuotaRule.GetByID(ctx, quotaRuleID)
+	if err != nil {
 		return fmt.Errorf("failed to get quota rule: %w", err)
 	}
 
-	now := time.Now()
-
-	// This would require implementing a reset method in the repository
-	// For now, we'll use UpdateUsage with negative value to reset
-	currentUsage, err := s.repos.QuotaUsage.GetCurrentUsage(ctx, quotaRuleID, now)
-	if err == nil && currentUsage != nil {
-		// Reset to zero by subtracting current usage
-		if err := s.repos.QuotaUsage.UpdateUsage(ctx, quotaRuleID, -currentUsage.UsedSeconds, now); err != nil {
+	usage := s.currentUsage(ctx, rule, time.Now())
+	previousSeconds := usage.UsedSeconds
+	if usage.ID != 0 && previousSeconds != 0 {
+		usage.UsedSeconds = 0
+		usage.UpdatedAt = time.Now()
+		if err := s.repos.QuotaUsage.Update(ctx, usage); err != nil {
 			return fmt.Errorf("failed to reset quota usage: %w", err)
 		}
 	}
 
-	s.logger.Info("Quota usage reset successfully", logging.Int("quota_rule_id", quotaRuleID))
+	details := map[string]interface{}{
+		"quota_rule_name":       rule.Name,
+		"list_id":               rule.ListID,
+		"previous_used_seconds": previousSeconds,
+		"period_start":          usage.PeriodStart,
+	}
+	if s.auditService != nil {
+		if err := s.auditService.LogRuleChange(ctx, "quota_rule", quotaRuleID, "reset", details); err != nil {
+			s.logger.Warn("Failed to audit quota reset", logging.Err(err), logging.Int("quota_rule_id", quotaRuleID))
+		}
+	}
+	if s.notificationService != nil {
+		message := fmt.Sprintf("Time used for %q has been reset", rule.Name)
+		if err := s.notificationService.NotifyTimeLimit(ctx, message, details); err != nil {
+			s.logger.Warn("Failed to send quota reset notification", logging.Err(err), logging.Int("quota_rule_id", quotaRuleID))
+		}
+	}
+
+	s.publishChange(ctx, QuotaChangeReset, rule)
+	s.logger.Info("Quota usage reset successfully",
+		logging.Int("quota_rule_id", quotaRuleID),
+		logging.Int("previous_used_seconds", previousSeconds))
 	return nil
 }
 
+// currentUsage returns the usage for the window containing now, opening the
+// window if no usage has been recorded in it yet. A new window starts with
+// the time carried in from the previous one. If the window cannot be stored
+// an unsaved usage (ID 0) is returned so callers can still report on it.
+func (s *QuotaService) currentUsage(ctx context.Context, rule *models.QuotaRule, now time.Time) *models.QuotaUsage {
+	if usage, err := s.repos.QuotaUsage.GetCurrentUsage(ctx, rule.ID, now); err == nil && usage != nil {
+		return usage
+	}
+
+	usage := s.newWindow(ctx, rule, now)
+	if err := s.repos.QuotaUsage.Create(ctx, usage); err != nil {
+		s.logger.Error("Failed to open quota window", logging.Err(err), logging.Int("quota_rule_id", rule.ID))
+		usage.ID = 0
+	} else if usage.CarriedSeconds > 0 {
+		s.logger.Info("Carried unused quota time into new window",
+			logging.Int("quota_rule_id", rule.ID),
+			logging.Int("carried_seconds", usage.CarriedSeconds))
+	}
+	return usage
+}
+
+// newWindow returns an unsaved, unused window containing now, holding any
+// time carried in from the previous window
+func (s *QuotaService) newWindow(ctx context.Context, rule *models.QuotaRule, now time.Time) *models.QuotaUsage {
+	return &models.QuotaUsage{
+		QuotaRuleID:    rule.ID,
+		PeriodStart:    s.getPeriodStart(rule.QuotaType, now),
+		PeriodEnd:      s.getPeriodEnd(rule.QuotaType, now),
+		CarriedSeconds: s.carriedSeconds(ctx, rule, now),
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+}
+
+// carriedSeconds returns the unused time that rolls into the window
+// containing now under the rule's carry-over policy. Only the window just
+// before counts; because its allowance already includes what it carried,
+// the cap bounds accumulation across any number of windows.
+func (s *QuotaService) carriedSeconds(ctx context.Context, rule *models.QuotaRule, now time.Time) int {
+	maxCarry := rule.CarryOverCap()
+	if maxCarry <= 0 {
+		return 0
+	}
+
+	previousEnd := s.getPeriodStart(rule.QuotaType, now).Add(-time.Nanosecond)
+	previousStart := s.getPeriodStart(rule.QuotaType, previousEnd)
+	if rule.CreatedAt.After(previousEnd) {
+		// The rule did not exist during the previous window
+		return 0
+	}
+
+	unused := rule.LimitSeconds
+	if previous, err := s.repos.QuotaUsage.GetUsageInPeriod(ctx, rule.ID, previousStart, previousEnd); err == nil && previous != nil {
+		unused = previous.RemainingSeconds(rule.LimitSeconds)
+	}
+	return carryOverSeconds(unused, maxCarry)
+}
+
+// carryOverSeconds caps the unused time rolled into a window
+func carryOverSeconds(unused, maxCarry int) int {
+	if unused < 0 {
+		return 0
+	}
+	if unused > maxCarry {
+		return maxCarry
+	}
+	return unused
+}
+
+// validateCarryOver checks a carry-over policy name
+func validateCarryOver(carryOver models.QuotaCarryOver) error {
+	switch carryOver {
+	case models.CarryOverNone, models.CarryOverUnusedCapped:
+		return nil
+	default:
+		return fmt.Errorf("invalid carry-over policy: %s", carryOver)
+	}
+}
+
 // validateCreateQuotaRuleRequest validates a create quota rule request
 func (s *QuotaService) validateCreateQuotaRuleRequest(ctx context.Context, req CreateQuotaRuleRequest) error {
 	// Verify list exists
@@ -412,6 +638,15 @@ func (s *QuotaService) validateCreateQuotaRuleRequest(ctx context.Context, req C
 		return fmt.Errorf("limit must be at least 1 second")
 	}
 
+	if req.CarryOver != "" {
+		if err := validateCarryOver(req.CarryOver); err != nil {
+			return err
+		}
+	}
+	if req.MaxCarryOverSeconds < 0 {
+		return fmt.Errorf("carry-over cap cannot be negative")
+	}
+
 	return nil
 }
 
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"parental-control/internal/logging"
+	"parental-control/internal/models"
+)
+
+func (r *memoryQuotaRuleRepo) GetByID(ctx context.Context, id int) (*models.QuotaRule, error) {
+	rule, ok := r.rules[id]
+	if !ok {
+		return nil, fmt.Errorf("quota rule with ID %d not found", id)
+	}
+	return &rule, nil
+}
+
+// memoryQuotaWindowRepo keeps quota usage windows in memory
+type memoryQuotaWindowRepo struct {
+	models.QuotaUsageRepository
+	usages []*models.QuotaUsage
+}
+
+func (r *memoryQuotaWindowRepo) find(quotaRuleID int, t time.Time) *models.QuotaUsage {
+	for _, usage := range r.usages {
+		if usage.QuotaRuleID == quotaRuleID && !t.Before(usage.PeriodStart) && !t.After(usage.PeriodEnd) {
+			return usage
+		}
+	}
+	return nil
+}
+
+func (r *memoryQuotaWindowRepo) Create(ctx context.Context, usage *models.QuotaUsage) error {
+	usage.ID = len(r.usages) + 1
+	stored := *usage
+	r.usages = append(r.usages, &stored)
+	return nil
+}
+
+func (r *memoryQuotaWindowRepo) GetCurrentUsage(ctx context.Context, quotaRuleID int, now time.Time) (*models.QuotaUsage, error) {
+	usage := r.find(quotaRuleID, now)
+	if usage == nil {
+		return nil, fmt.Errorf("no usage for quota rule %d", quotaRuleID)
+	}
+	current := *usage
+	return &current, nil
+}
+
+func (r *memoryQuotaWindowRepo) GetUsageInPeriod(ctx context.Context, quotaRuleID int, start, end time.Time) (*models.QuotaUsage, error) {
+	return r.GetCurrentUsage(ctx, quotaRuleID, start)
+}
+
+func (r *memoryQuotaWindowRepo) Update(ctx context.Context, usage *models.QuotaUsage) error {
+	for i, stored := range r.usages {
+		if stored.ID == usage.ID {
+			updated := *usage
+			r.usages[i] = &updated
+			return nil
+		}
+	}
+	return fmt.Errorf("quota usage with ID %d not found", usage.ID)
+}
+
+func newTestQuotaService(rule models.QuotaRule) (*QuotaService, *memoryQuotaWindowRepo) {
+	windows := &memoryQuotaWindowRepo{}
+	repos := &models.RepositoryManager{
+		List:       &memoryListRepo{lists: map[int]*models.List{1: {ID: 1, Name: "games"}}},
+		QuotaRule:  &memoryQuotaRuleRepo{rules: map[int]models.QuotaRule{rule.ID: rule}},
+		QuotaUsage: windows,
+	}
+	return NewQuotaService(repos, logging.NewDefault()), windows
+}
+
+func TestQuotaCarryOver(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	yesterday := now.AddDate(0, 0, -1)
+	rule := models.QuotaRule{ID: 1, ListID: 1, Name: "daily games", QuotaType: models.QuotaTypeDaily,
+		LimitSeconds: 3600, Enabled: true, CreatedAt: now.AddDate(0, 0, -7)}
+
+	tests := []struct {
+		name          string
+		carryOver     models.QuotaCarryOver
+		maxCarry      int
+		usedYesterday int
+		carried       int // carried into yesterday
+		want          int
+	}{
+		{"no carry-over", models.CarryOverNone, 0, 600, 0, 0},
+		{"unused time rolls forward", models.CarryOverUnusedCapped, 0, 600, 0, 3000},
+		{"capped at the limit by default", models.CarryOverUnusedCapped, 0, 0, 1800, 3600},
+		{"capped at the configured maximum", models.CarryOverUnusedCapped, 900, 600, 0, 900},
+		{"overused window carries nothing", models.CarryOverUnusedCapped, 0, 4000, 0, 0},
+		{"carried time that was used up", models.CarryOverUnusedCapped, 0, 5000, 1800, 400},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := rule
+			rule.CarryOver = tt.carryOver
+			rule.MaxCarryOverSeconds = tt.maxCarry
+			quotaService, windows := newTestQuotaService(rule)
+			windows.Create(ctx, &models.QuotaUsage{
+				QuotaRuleID:    rule.ID,
+				PeriodStart:    quotaService.getPeriodStart(rule.QuotaType, yesterday),
+				PeriodEnd:      quotaService.getPeriodEnd(rule.QuotaType, yesterday),
+				UsedSeconds:    tt.usedYesterday,
+				CarriedSeconds: tt.carried,
+			})
+
+			status, err := quotaService.GetQuotaRuleStatus(ctx, rule.ID)
+			if err != nil {
+				t.Fatalf("GetQuotaRuleStatus failed: %v", err)
+			}
+			if status.CurrentUsage.CarriedSeconds != tt.want {
+				t.Errorf("Expected %d seconds carried, got %d", tt.want, status.CurrentUsage.CarriedSeconds)
+			}
+			if want := time.Duration(rule.LimitSeconds+tt.want) * time.Second; status.RemainingTime != want {
+				t.Errorf("Expected %v remaining, got %v", want, status.RemainingTime)
+			}
+			if len(windows.usages) != 2 {
+				t.Errorf("Expected the new window to be stored, got %d windows", len(windows.usages))
+			}
+		})
+	}
+}
+
+func TestQuotaCarryOver_NewRule(t *testing.T) {
+	// A rule created today had no previous window to carry from
+	quotaService, _ := newTestQuotaService(models.QuotaRule{ID: 1, ListID: 1, Name: "daily games",
+		QuotaType: models.QuotaTypeDaily, LimitSeconds: 3600, Enabled: true,
+		CarryOver: models.CarryOverUnusedCapped, CreatedAt: time.Now()})
+
+	status, err := quotaService.GetQuotaRuleStatus(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("GetQuotaRuleStatus failed: %v", err)
+	}
+	if status.CurrentUsage.CarriedSeconds != 0 {
+		t.Errorf("Expected nothing carried into a new rule's first window, got %d", status.CurrentUsage.CarriedSeconds)
+	}
+}
+
+func TestResetQuotaUsage(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	rule := models.QuotaRule{ID: 1, ListID: 1, Name: "daily games", QuotaType: models.QuotaTypeDaily,
+		LimitSeconds: 3600, Enabled: true, CreatedAt: now}
+	quotaService, windows := newTestQuotaService(rule)
+	windows.Create(ctx, &models.QuotaUsage{
+		QuotaRuleID:    rule.ID,
+		PeriodStart:    quotaService.getPeriodStart(rule.QuotaType, now),
+		PeriodEnd:      quotaService.getPeriodEnd(rule.QuotaType, now),
+		UsedSeconds:    4000,
+		CarriedSeconds: 600,
+	})
+
+	if err := quotaService.ResetQuotaUsage(ctx, rule.ID); err != nil {
+		t.Fatalf("ResetQuotaUsage failed: %v", err)
+	}
+	usage := windows.usages[0]
+	if usage.UsedSeconds != 0 || usage.CarriedSeconds != 600 {
+		t.Errorf("Expected usage reset with carried time kept, got %+v", usage)
+	}
+
+	if err := quotaService.ResetQuotaUsage(ctx, 2); err == nil {
+		t.Error("Expected resetting an unknown quota rule to fail")
+	}
+}
+
+func TestGetRemaining(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	rule := models.QuotaRule{ID: 1, ListID: 1, Name: "daily games", QuotaType: models.QuotaTypeDaily,
+		LimitSeconds: 3600, Enabled: true, CreatedAt: now}
+	quotaService, windows := newTestQuotaService(rule)
+
+	remaining, err := quotaService.GetRemaining(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetRemaining failed: %v", err)
+	}
+	if len(remaining) != 1 || remaining[0].RemainingSeconds != 3600 || remaining[0].Exhausted {
+		t.Fatalf("Expected the full hour left, got %+v", remaining)
+	}
+	if len(windows.usages) != 0 {
+		t.Error("Expected GetRemaining not to open a window")
+	}
+
+	windows.Create(ctx, &models.QuotaUsage{
+		QuotaRuleID:    rule.ID,
+		PeriodStart:    quotaService.getPeriodStart(rule.QuotaType, now),
+		PeriodEnd:      quotaService.getPeriodEnd(rule.QuotaType, now),
+		UsedSeconds:    4000,
+		CarriedSeconds: 600,
+	})
+	remaining, err = quotaService.GetRemaining(ctx, 1)
+	if err != nil {
+		t.Fatalf("GetRemaining failed: %v", err)
+	}
+	if got := remaining[0]; got.UsedSeconds != 4000 || got.RemainingSeconds != 200 || got.Exhausted {
+		t.Errorf("Expected 200 seconds left of the carried time, got %+v", got)
+	}
+	if !remaining[0].ResetsAt.After(now) {
+		t.Errorf("Expected the window to reset in the future, got %v", remaining[0].ResetsAt)
+	}
+
+	if _, err := quotaService.GetRemaining(ctx, 2); err == nil {
+		t.Error("Expected an unknown list to fail")
+	}
+}
@@ -2,7 +2,9 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -16,6 +18,8 @@ type RetentionService struct {
 	logger logging.Logger
 	config RetentionConfig
 
+	lifecycle *Lifecycle
+
 	// Execution management
 	running   bool
 	runningMu sync.RWMutex
@@ -25,6 +29,20 @@ type RetentionService struct {
 	// Scheduler
 	scheduler *RetentionScheduler
 
+	// Receives an alert when a run is aborted by the safety threshold
+	alerter RetentionAlerter
+
+	// Runs before a policy deletes anything, e.g. to back up the database
+	preExecutionHook RetentionPreExecutionHook
+
+	// Brackets a policy's deletions, e.g. to hold off database maintenance
+	deletionHook RetentionDeletionHook
+
+	// Concurrency control
+	jobSem     chan struct{}
+	inFlight   map[int]bool
+	inFlightMu sync.Mutex
+
 	// Statistics
 	stats   *RetentionServiceStats
 	statsMu sync.RWMutex
@@ -38,9 +56,10 @@ type RetentionConfig struct {
 	JobTimeout        time.Duration `json:"job_timeout"`         // Timeout for individual retention jobs
 
 	// Safety settings
-	MaxDeleteBatchSize int     `json:"max_delete_batch_size"` // Maximum entries to delete in one batch
-	SafetyThreshold    float64 `json:"safety_threshold"`      // Percentage threshold for safety checks (0.0-1.0)
-	DryRunMode         bool    `json:"dry_run_mode"`          // If true, don't actually delete anything
+	MaxDeleteBatchSize int          `json:"max_delete_batch_size"` // Maximum entries to delete in one batch
+	SafetyThreshold    float64      `json:"safety_threshold"`      // Percentage threshold for safety checks (0.0-1.0)
+	SafetyAction       SafetyAction `json:"safety_action"`         // What to do when a run would exceed the threshold
+	DryRunMode         bool         `json:"dry_run_mode"`          // If true, don't actually delete anything
 
 	// Performance settings
 	DeleteBatchSize  int           `json:"delete_batch_size"`  // Batch size for deletions
@@ -58,6 +77,7 @@ func DefaultRetentionConfig() RetentionConfig {
 		JobTimeout:          30 * time.Minute,
 		MaxDeleteBatchSize:  10000,
 		SafetyThreshold:     0.8, // Don't delete more than 80% of logs in one run
+		SafetyAction:        SafetyActionAbort,
 		DryRunMode:          false,
 		DeleteBatchSize:     1000,
 		DeleteBatchDelay:    100 * time.Millisecond,
@@ -65,6 +85,64 @@ func DefaultRetentionConfig() RetentionConfig {
 	}
 }
 
+// SafetyAction is what a retention run does when it would delete more than
+// the safety threshold allows
+type SafetyAction string
+
+const (
+	// SafetyActionAbort fails the run without deleting anything
+	SafetyActionAbort SafetyAction = "abort"
+	// SafetyActionClamp deletes the oldest entries up to the threshold and
+	// completes, leaving the rest for later runs
+	SafetyActionClamp SafetyAction = "clamp"
+	// SafetyActionAlertAndAbort fails the run and sends a system alert so the
+	// administrator knows retention is stuck
+	SafetyActionAlertAndAbort SafetyAction = "alert-and-abort"
+)
+
+// Safety outcomes recorded in the execution details
+const (
+	safetyOutcomeClamped = "clamped"
+	safetyOutcomeAborted = "aborted"
+)
+
+// RetentionAlerter sends system alerts; NotificationService implements it
+type RetentionAlerter interface {
+	NotifySystemAlert(ctx context.Context, title string, message string, details map[string]interface{}) error
+}
+
+// RetentionPreExecutionHook runs before a policy deletes anything, with the
+// estimate of what it will delete. Returning an error fails the run without
+// deleting. DatabaseBackupService.BeforeRetention implements it.
+type RetentionPreExecutionHook func(ctx context.Context, policy *models.RetentionPolicy, preview *RetentionPreview) error
+
+// RetentionDeletionHook is called before a policy starts deleting and returns
+// a function to call when it finishes.
+// DatabaseMaintenanceService.BeginRetention implements it.
+type RetentionDeletionHook func(policy *models.RetentionPolicy) func()
+
+// SafetyThresholdError reports a rule that would delete a larger share of
+// the logs than the safety threshold allows
+type SafetyThresholdError struct {
+	Rule        string
+	WouldDelete int
+	Total       int
+	Threshold   float64
+}
+
+func (e *SafetyThresholdError) Error() string {
+	return fmt.Sprintf("safety threshold exceeded: would delete %d/%d logs (%.2f%%)",
+		e.WouldDelete, e.Total, float64(e.WouldDelete)/float64(e.Total)*100)
+}
+
+// safetyResult records how the safety threshold affected a run
+type safetyResult struct {
+	outcome     string
+	wouldDelete int
+	allowed     int
+	total       int
+}
+
 // RetentionServiceStats holds statistics about retention service operations
 type RetentionServiceStats struct {
 	TotalExecutions      int64                `json:"total_executions"`
@@ -74,10 +152,17 @@ type RetentionServiceStats struct {
 	TotalBytesFreed      int64                `json:"total_bytes_freed"`
 	LastExecutionTime    time.Time            `json:"last_execution_time"`
 	AverageExecutionTime time.Duration        `json:"average_execution_time"`
+	TimedOutExecutions   int64                `json:"timed_out_executions"`
+	ClampedExecutions    int64                `json:"clamped_executions"`
+	AbortedExecutions    int64                `json:"aborted_executions"` // Aborted by the safety threshold
 	ActiveJobs           int                  `json:"active_jobs"`
 	PolicyStats          map[int]*PolicyStats `json:"policy_stats"`
+	RecentRuns           []models.RunSummary  `json:"recent_runs"`
 }
 
+// recentRunLimit is how many executions GetStats reports in RecentRuns
+const recentRunLimit = 10
+
 // PolicyStats holds statistics for a specific retention policy
 type PolicyStats struct {
 	PolicyID             int           `json:"policy_id"`
@@ -90,12 +175,20 @@ type PolicyStats struct {
 
 // NewRetentionService creates a new retention service
 func NewRetentionService(repos *models.RepositoryManager, logger logging.Logger, config RetentionConfig) *RetentionService {
+	maxJobs := config.MaxConcurrentJobs
+	if maxJobs <= 0 {
+		maxJobs = 1
+	}
+
 	return &RetentionService{
 		repos:     repos,
 		logger:    logger,
 		config:    config,
+		lifecycle: NewLifecycle(logger, "retention"),
 		stopCh:    make(chan struct{}),
 		scheduler: NewRetentionScheduler(),
+		jobSem:    make(chan struct{}, maxJobs),
+		inFlight:  make(map[int]bool),
 		stats: &RetentionServiceStats{
 			PolicyStats: make(map[int]*PolicyStats),
 		},
@@ -111,14 +204,19 @@ func (rs *RetentionService) Start(ctx context.Context) error {
 		return fmt.Errorf("retention service is already running")
 	}
 
-	rs.logger.Info("Starting retention service")
+	rs.lifecycle.Starting(
+		logging.Duration("check_interval", rs.config.CheckInterval),
+		logging.Int("max_concurrent_jobs", rs.config.MaxConcurrentJobs),
+		logging.Duration("job_timeout", rs.config.JobTimeout),
+		logging.Bool("dry_run", rs.config.DryRunMode),
+		logging.String("safety_action", string(rs.safetyAction())))
 
 	// Start the scheduler
 	rs.wg.Add(1)
 	go rs.schedulerLoop(ctx)
 
 	rs.running = true
-	rs.logger.Info("Retention service started successfully")
+	rs.lifecycle.Started()
 	return nil
 }
 
@@ -131,17 +229,43 @@ func (rs *RetentionService) Stop() error {
 		return nil
 	}
 
-	rs.logger.Info("Stopping retention service")
+	rs.lifecycle.Stopping()
 
 	// Stop the scheduler
 	close(rs.stopCh)
 	rs.wg.Wait()
 
 	rs.running = false
-	rs.logger.Info("Retention service stopped")
+
+	rs.statsMu.RLock()
+	stats := *rs.stats
+	rs.statsMu.RUnlock()
+	rs.lifecycle.Stopped(stats.TotalExecutions, stats.FailedExecutions,
+		logging.Int("entries_deleted", int(stats.TotalEntriesDeleted)),
+		logging.Int("timed_out", int(stats.TimedOutExecutions)),
+		logging.Int("safety_clamped", int(stats.ClampedExecutions)),
+		logging.Int("safety_aborted", int(stats.AbortedExecutions)))
 	return nil
 }
 
+// SetAlerter sets where alerts go when the safety threshold aborts a run
+// with SafetyActionAlertAndAbort
+func (rs *RetentionService) SetAlerter(alerter RetentionAlerter) {
+	rs.alerter = alerter
+}
+
+// SetPreExecutionHook sets the hook that runs before policies with estimated
+// deletions execute. Dry runs skip it.
+func (rs *RetentionService) SetPreExecutionHook(hook RetentionPreExecutionHook) {
+	rs.preExecutionHook = hook
+}
+
+// SetDeletionHook sets the hook that brackets each policy's deletions. Dry
+// runs skip it.
+func (rs *RetentionService) SetDeletionHook(hook RetentionDeletionHook) {
+	rs.deletionHook = hook
+}
+
 // ExecutePolicy manually executes a specific retention policy
 func (rs *RetentionService) ExecutePolicy(ctx context.Context, policyID int) (*models.RetentionPolicyExecution, error) {
 	// Get the policy
@@ -154,7 +278,7 @@ func (rs *RetentionService) ExecutePolicy(ctx context.Context, policyID int) (*m
 		return nil, fmt.Errorf("retention policy %d is disabled", policyID)
 	}
 
-	return rs.executePolicy(ctx, policy)
+	return rs.executePolicy(ctx, policy, models.TriggerManual)
 }
 
 // ExecuteAllPolicies manually executes all enabled retention policies
@@ -166,7 +290,7 @@ func (rs *RetentionService) ExecuteAllPolicies(ctx context.Context) ([]*models.R
 
 	var executions []*models.RetentionPolicyExecution
 	for _, policy := range policies {
-		execution, err := rs.executePolicy(ctx, &policy)
+		execution, err := rs.executePolicy(ctx, &policy, models.TriggerManual)
 		if err != nil {
 			rs.logger.Error("Failed to execute retention policy",
 				logging.Int("policy_id", policy.ID),
@@ -182,6 +306,8 @@ func (rs *RetentionService) ExecuteAllPolicies(ctx context.Context) ([]*models.R
 
 // GetStats returns retention service statistics
 func (rs *RetentionService) GetStats() *RetentionServiceStats {
+	recentRuns := rs.recentRuns(context.Background())
+
 	rs.statsMu.RLock()
 	defer rs.statsMu.RUnlock()
 
@@ -194,8 +320,12 @@ func (rs *RetentionService) GetStats() *RetentionServiceStats {
 		TotalBytesFreed:      rs.stats.TotalBytesFreed,
 		LastExecutionTime:    rs.stats.LastExecutionTime,
 		AverageExecutionTime: rs.stats.AverageExecutionTime,
+		TimedOutExecutions:   rs.stats.TimedOutExecutions,
+		ClampedExecutions:    rs.stats.ClampedExecutions,
+		AbortedExecutions:    rs.stats.AbortedExecutions,
 		ActiveJobs:           rs.stats.ActiveJobs,
 		PolicyStats:          make(map[int]*PolicyStats),
+		RecentRuns:           recentRuns,
 	}
 
 	// Copy policy stats
@@ -213,6 +343,25 @@ func (rs *RetentionService) GetStats() *RetentionServiceStats {
 	return stats
 }
 
+// recentRuns summarizes the latest executions recorded in the repository
+func (rs *RetentionService) recentRuns(ctx context.Context) []models.RunSummary {
+	runs := []models.RunSummary{}
+	if rs.repos == nil || rs.repos.RetentionExecution == nil {
+		return runs
+	}
+
+	executions, err := rs.repos.RetentionExecution.GetRecent(ctx, recentRunLimit)
+	if err != nil {
+		rs.logger.Warn("Failed to load recent retention executions", logging.Err(err))
+		return runs
+	}
+
+	for i := range executions {
+		runs = append(runs, executions[i].Summary())
+	}
+	return runs
+}
+
 // PreviewPolicyExecution previews what a policy execution would do without actually executing it
 func (rs *RetentionService) PreviewPolicyExecution(ctx context.Context, policyID int) (*RetentionPreview, error) {
 	policy, err := rs.repos.RetentionPolicy.GetByID(ctx, policyID)
@@ -277,9 +426,16 @@ func (rs *RetentionService) checkAndExecutePolicies(ctx context.Context) {
 
 	for _, policy := range policies {
 		if rs.shouldExecutePolicy(&policy) {
-			// Execute policy in a separate goroutine to avoid blocking
+			// Skip policies still queued or running from a previous check
+			if !rs.markInFlight(policy.ID) {
+				continue
+			}
+
+			// Execute policy in a separate goroutine to avoid blocking;
+			// executePolicy waits for a free job slot
 			go func(p models.RetentionPolicy) {
-				if _, err := rs.executePolicy(ctx, &p); err != nil {
+				defer rs.clearInFlight(p.ID)
+				if _, err := rs.executePolicy(ctx, &p, models.TriggerScheduled); err != nil {
 					rs.logger.Error("Failed to execute scheduled retention policy",
 						logging.Int("policy_id", p.ID),
 						logging.String("policy_name", p.Name),
@@ -290,16 +446,53 @@ func (rs *RetentionService) checkAndExecutePolicies(ctx context.Context) {
 	}
 }
 
+func (rs *RetentionService) markInFlight(policyID int) bool {
+	rs.inFlightMu.Lock()
+	defer rs.inFlightMu.Unlock()
+
+	if rs.inFlight[policyID] {
+		return false
+	}
+	rs.inFlight[policyID] = true
+	return true
+}
+
+func (rs *RetentionService) clearInFlight(policyID int) {
+	rs.inFlightMu.Lock()
+	defer rs.inFlightMu.Unlock()
+	delete(rs.inFlight, policyID)
+}
+
+// acquireJobSlot blocks until fewer than MaxConcurrentJobs jobs are running
+func (rs *RetentionService) acquireJobSlot(ctx context.Context) error {
+	select {
+	case rs.jobSem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-rs.stopCh:
+		return fmt.Errorf("retention service is stopping")
+	}
+}
+
+func (rs *RetentionService) releaseJobSlot() {
+	<-rs.jobSem
+}
+
 func (rs *RetentionService) shouldExecutePolicy(policy *models.RetentionPolicy) bool {
-	// Simple time-based check - in a real implementation, you'd use a proper cron parser
-	if policy.NextExecution.IsZero() {
+	if policy.LastExecuted.IsZero() && policy.NextExecution.IsZero() {
 		return true // Never executed before
 	}
 
-	return time.Now().After(policy.NextExecution)
+	return scheduledRunDue(policy.ExecutionSchedule, policy.LastExecuted, policy.NextExecution, time.Now())
 }
 
-func (rs *RetentionService) executePolicy(ctx context.Context, policy *models.RetentionPolicy) (*models.RetentionPolicyExecution, error) {
+func (rs *RetentionService) executePolicy(ctx context.Context, policy *models.RetentionPolicy, trigger models.RotationTrigger) (*models.RetentionPolicyExecution, error) {
+	if err := rs.acquireJobSlot(ctx); err != nil {
+		return nil, fmt.Errorf("failed to acquire retention job slot: %w", err)
+	}
+	defer rs.releaseJobSlot()
+
 	startTime := time.Now()
 
 	// Create execution record
@@ -331,10 +524,32 @@ func (rs *RetentionService) executePolicy(ctx context.Context, policy *models.Re
 	var totalDeleted int64
 	var totalBytesFreed int64
 	var executionError error
+	var safety safetyResult
+
+	// Bound the job; execution records are still written with the parent context
+	jobCtx := ctx
+	if rs.config.JobTimeout > 0 {
+		var cancel context.CancelFunc
+		jobCtx, cancel = context.WithTimeout(ctx, rs.config.JobTimeout)
+		defer cancel()
+	}
+
+	// Let the hook act on what the run is about to delete
+	if rs.preExecutionHook != nil && !rs.config.DryRunMode {
+		if err := rs.runPreExecutionHook(jobCtx, policy); err != nil {
+			executionError = err
+		}
+	}
+
+	// Tell the hook deletions are starting; it hears again when the run ends
+	if rs.deletionHook != nil && !rs.config.DryRunMode && executionError == nil {
+		endDeletion := rs.deletionHook(policy)
+		defer endDeletion()
+	}
 
 	// Execute each rule type
-	if policy.TimeBasedRule != nil {
-		deleted, bytesFreed, err := rs.executeTimeBasedRule(ctx, policy, policy.TimeBasedRule)
+	if policy.TimeBasedRule != nil && executionError == nil {
+		deleted, bytesFreed, err := rs.executeTimeBasedRule(jobCtx, policy, policy.TimeBasedRule, &safety)
 		if err != nil {
 			executionError = fmt.Errorf("time-based rule failed: %w", err)
 		} else {
@@ -344,7 +559,7 @@ func (rs *RetentionService) executePolicy(ctx context.Context, policy *models.Re
 	}
 
 	if policy.SizeBasedRule != nil && executionError == nil {
-		deleted, bytesFreed, err := rs.executeSizeBasedRule(ctx, policy, policy.SizeBasedRule)
+		deleted, bytesFreed, err := rs.executeSizeBasedRule(jobCtx, policy, policy.SizeBasedRule)
 		if err != nil {
 			executionError = fmt.Errorf("size-based rule failed: %w", err)
 		} else {
@@ -354,7 +569,7 @@ func (rs *RetentionService) executePolicy(ctx context.Context, policy *models.Re
 	}
 
 	if policy.CountBasedRule != nil && executionError == nil {
-		deleted, bytesFreed, err := rs.executeCountBasedRule(ctx, policy, policy.CountBasedRule)
+		deleted, bytesFreed, err := rs.executeCountBasedRule(jobCtx, policy, policy.CountBasedRule)
 		if err != nil {
 			executionError = fmt.Errorf("count-based rule failed: %w", err)
 		} else {
@@ -363,6 +578,28 @@ func (rs *RetentionService) executePolicy(ctx context.Context, policy *models.Re
 		}
 	}
 
+	// A job that ran past its deadline is recorded as failed regardless of rule results
+	timedOut := jobCtx.Err() == context.DeadlineExceeded
+	if timedOut {
+		executionError = fmt.Errorf("retention job timed out after %s", rs.config.JobTimeout)
+		rs.logger.Warn("Retention policy execution timed out",
+			logging.Int("policy_id", policy.ID),
+			logging.String("policy_name", policy.Name),
+			logging.String("timeout", rs.config.JobTimeout.String()))
+	}
+
+	var thresholdErr *SafetyThresholdError
+	if errors.As(executionError, &thresholdErr) {
+		safety = safetyResult{
+			outcome:     safetyOutcomeAborted,
+			wouldDelete: thresholdErr.WouldDelete,
+			total:       thresholdErr.Total,
+		}
+		if rs.safetyAction() == SafetyActionAlertAndAbort {
+			rs.sendSafetyAlert(ctx, policy, thresholdErr)
+		}
+	}
+
 	// Update execution record
 	execution.Duration = time.Since(startTime)
 	execution.EntriesDeleted = totalDeleted
@@ -380,6 +617,15 @@ func (rs *RetentionService) executePolicy(ctx context.Context, policy *models.Re
 		"policy_name":        policy.Name,
 		"execution_duration": execution.Duration.String(),
 		"dry_run_mode":       rs.config.DryRunMode,
+		"timed_out":          timedOut,
+		"trigger":            string(trigger),
+	}
+	if safety.outcome != "" {
+		details["safety_action"] = string(rs.safetyAction())
+		details["safety_outcome"] = safety.outcome
+		details["safety_would_delete"] = safety.wouldDelete
+		details["safety_allowed"] = safety.allowed
+		details["safety_total"] = safety.total
 	}
 	if err := execution.SetDetailsMap(details); err != nil {
 		rs.logger.Error("Failed to set execution details", logging.Err(err))
@@ -394,6 +640,17 @@ func (rs *RetentionService) executePolicy(ctx context.Context, policy *models.Re
 
 	// Update statistics
 	rs.updateStats(policy.ID, execution, executionError == nil)
+	rs.statsMu.Lock()
+	if timedOut {
+		rs.stats.TimedOutExecutions++
+	}
+	switch safety.outcome {
+	case safetyOutcomeClamped:
+		rs.stats.ClampedExecutions++
+	case safetyOutcomeAborted:
+		rs.stats.AbortedExecutions++
+	}
+	rs.statsMu.Unlock()
 
 	if executionError != nil {
 		return execution, executionError
@@ -409,12 +666,69 @@ func (rs *RetentionService) executePolicy(ctx context.Context, policy *models.Re
 	return execution, nil
 }
 
-func (rs *RetentionService) executeTimeBasedRule(ctx context.Context, policy *models.RetentionPolicy, rule *models.TimeBasedRetention) (int64, int64, error) {
+// runPreExecutionHook estimates the policy's deletions and passes them to
+// the hook when there are any
+func (rs *RetentionService) runPreExecutionHook(ctx context.Context, policy *models.RetentionPolicy) error {
+	preview, err := rs.previewPolicyExecution(ctx, policy)
+	if err != nil {
+		return fmt.Errorf("failed to estimate deletions: %w", err)
+	}
+	if preview.EstimatedDeletions == 0 {
+		return nil
+	}
+
+	if err := rs.preExecutionHook(ctx, policy, preview); err != nil {
+		rs.logger.Warn("Retention run stopped by pre-execution hook",
+			logging.Int("policy_id", policy.ID),
+			logging.String("policy_name", policy.Name),
+			logging.Int("estimated_deletions", int(preview.EstimatedDeletions)),
+			logging.Err(err))
+		return fmt.Errorf("pre-execution hook failed: %w", err)
+	}
+	return nil
+}
+
+// Size and count rules fall back to deleting matching entries older than these
+const (
+	sizeRuleCleanupAge  = 7 * 24 * time.Hour
+	countRuleCleanupAge = 30 * 24 * time.Hour
+)
+
+// entrySizeSample is how many recent entries are sampled to estimate the
+// average entry size
+const entrySizeSample = 1000
+
+// cleanupFilter returns the policy's event type and action filters
+func cleanupFilter(policy *models.RetentionPolicy) models.AuditLogCleanupFilter {
+	return models.AuditLogCleanupFilter{
+		EventTypes: policy.EventTypeFilter,
+		Actions:    policy.ActionFilter,
+	}
+}
+
+// filterDescription describes the policy's event type and action filters for
+// a preview, or returns "" when the policy is unfiltered
+func filterDescription(policy *models.RetentionPolicy) string {
+	var scopes []string
+	if len(policy.EventTypeFilter) > 0 {
+		scopes = append(scopes, "event types "+strings.Join(policy.EventTypeFilter, ", "))
+	}
+	if len(policy.ActionFilter) > 0 {
+		scopes = append(scopes, "actions "+strings.Join(policy.ActionFilter, ", "))
+	}
+	if len(scopes) == 0 {
+		return ""
+	}
+	return " (only " + strings.Join(scopes, "; ") + ")"
+}
+
+func (rs *RetentionService) executeTimeBasedRule(ctx context.Context, policy *models.RetentionPolicy, rule *models.TimeBasedRetention, safety *safetyResult) (int64, int64, error) {
 	cutoffTime := time.Now().Add(-rule.MaxAge)
+	filter := cleanupFilter(policy)
 
 	if rs.config.DryRunMode {
 		// In dry run mode, just count what would be deleted
-		count, err := rs.repos.AuditLog.CountByTimeRange(ctx, time.Time{}, cutoffTime)
+		count, bytes, err := rs.repos.AuditLog.CountOldLogsFiltered(ctx, cutoffTime, filter)
 		if err != nil {
 			return 0, 0, fmt.Errorf("failed to count logs for time-based rule: %w", err)
 		}
@@ -422,9 +736,10 @@ func (rs *RetentionService) executeTimeBasedRule(ctx context.Context, policy *mo
 		rs.logger.Info("Time-based rule dry run",
 			logging.Int("policy_id", policy.ID),
 			logging.String("cutoff_time", cutoffTime.Format(time.RFC3339)),
-			logging.Int("would_delete", count))
+			logging.Int("would_delete", count),
+			logging.Int("would_free_bytes", int(bytes)))
 
-		return int64(count), 0, nil // Assume 0 bytes freed in dry run
+		return int64(count), bytes, nil
 	}
 
 	// Apply safety threshold
@@ -433,64 +748,143 @@ func (rs *RetentionService) executeTimeBasedRule(ctx context.Context, policy *mo
 		return 0, 0, fmt.Errorf("failed to get total log count: %w", err)
 	}
 
-	deleteCount, err := rs.repos.AuditLog.CountByTimeRange(ctx, time.Time{}, cutoffTime)
+	deleteCount, _, err := rs.repos.AuditLog.CountOldLogsFiltered(ctx, cutoffTime, filter)
 	if err != nil {
 		return 0, 0, fmt.Errorf("failed to count logs for deletion: %w", err)
 	}
 
-	if float64(deleteCount)/float64(totalCount) > rs.config.SafetyThreshold {
-		return 0, 0, fmt.Errorf("safety threshold exceeded: would delete %d/%d logs (%.2f%%)",
-			deleteCount, totalCount, float64(deleteCount)/float64(totalCount)*100)
+	// Without a clamp every matching entry is deleted
+	limit := 0
+	if totalCount > 0 && float64(deleteCount)/float64(totalCount) > rs.config.SafetyThreshold {
+		thresholdErr := &SafetyThresholdError{
+			Rule:        "time_based",
+			WouldDelete: deleteCount,
+			Total:       totalCount,
+			Threshold:   rs.config.SafetyThreshold,
+		}
+		if rs.safetyAction() != SafetyActionClamp {
+			return 0, 0, thresholdErr
+		}
+
+		// Delete the oldest entries up to the threshold, keeping the newest
+		allowed := int(rs.config.SafetyThreshold * float64(totalCount))
+		*safety = safetyResult{
+			outcome:     safetyOutcomeClamped,
+			wouldDelete: deleteCount,
+			allowed:     allowed,
+			total:       totalCount,
+		}
+		rs.logger.Warn("Retention run clamped to the safety threshold",
+			logging.Int("policy_id", policy.ID),
+			logging.String("policy_name", policy.Name),
+			logging.Int("would_delete", deleteCount),
+			logging.Int("allowed", allowed),
+			logging.Int("total", totalCount))
+
+		if allowed <= 0 {
+			return 0, 0, nil
+		}
+		limit = allowed
 	}
 
 	// Perform the deletion
-	err = rs.repos.AuditLog.CleanupOldLogs(ctx, cutoffTime)
+	deleted, bytesFreed, err := rs.repos.AuditLog.CleanupOldLogsFiltered(ctx, cutoffTime, filter, limit)
 	if err != nil {
 		return 0, 0, fmt.Errorf("failed to cleanup old logs: %w", err)
 	}
 
-	return int64(deleteCount), 0, nil // TODO: Calculate actual bytes freed
+	return deleted, bytesFreed, nil
 }
 
-func (rs *RetentionService) executeSizeBasedRule(ctx context.Context, policy *models.RetentionPolicy, rule *models.SizeBasedRetention) (int64, int64, error) {
-	// This is a simplified implementation - in practice, you'd need to calculate actual sizes
-	// For now, we'll use a heuristic based on entry count
+// safetyAction returns the configured safety action, treating anything
+// unrecognized as SafetyActionAbort
+func (rs *RetentionService) safetyAction() SafetyAction {
+	switch rs.config.SafetyAction {
+	case SafetyActionClamp, SafetyActionAlertAndAbort:
+		return rs.config.SafetyAction
+	default:
+		return SafetyActionAbort
+	}
+}
 
+// sendSafetyAlert tells the administrator a run was aborted by the safety
+// threshold, so retention is not silently stuck
+func (rs *RetentionService) sendSafetyAlert(ctx context.Context, policy *models.RetentionPolicy, thresholdErr *SafetyThresholdError) {
+	if rs.alerter == nil {
+		rs.logger.Warn("Retention run aborted by the safety threshold but no alerter is configured",
+			logging.Int("policy_id", policy.ID),
+			logging.String("policy_name", policy.Name))
+		return
+	}
+
+	message := fmt.Sprintf("Retention policy %q did not run: %s. Logs will keep growing until the policy or safety threshold is changed.",
+		policy.Name, thresholdErr.Error())
+	details := map[string]interface{}{
+		"policy_id":        policy.ID,
+		"policy_name":      policy.Name,
+		"rule":             thresholdErr.Rule,
+		"would_delete":     thresholdErr.WouldDelete,
+		"total":            thresholdErr.Total,
+		"safety_threshold": thresholdErr.Threshold,
+	}
+	if err := rs.alerter.NotifySystemAlert(ctx, "Log retention aborted", message, details); err != nil {
+		rs.logger.Error("Failed to send retention safety alert",
+			logging.Int("policy_id", policy.ID),
+			logging.Err(err))
+	}
+}
+
+// estimatedLogSize estimates the audit log's total size from its entry count
+// and a sampled average entry size
+func (rs *RetentionService) estimatedLogSize(ctx context.Context) (int64, error) {
 	totalCount, err := rs.repos.AuditLog.Count(ctx)
 	if err != nil {
-		return 0, 0, fmt.Errorf("failed to get total log count: %w", err)
+		return 0, fmt.Errorf("failed to get total log count: %w", err)
 	}
 
-	// Estimate size (rough heuristic: 500 bytes per log entry)
-	estimatedSize := int64(totalCount) * 500
+	averageSize, err := rs.repos.AuditLog.AverageEntrySize(ctx, entrySizeSample)
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(totalCount) * averageSize, nil
+}
+
+func (rs *RetentionService) executeSizeBasedRule(ctx context.Context, policy *models.RetentionPolicy, rule *models.SizeBasedRetention) (int64, int64, error) {
+	estimatedSize, err := rs.estimatedLogSize(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
 
 	if estimatedSize <= rule.MaxTotalSize {
 		return 0, 0, nil // No cleanup needed
 	}
 
-	// Calculate how many entries to delete
-	excessSize := estimatedSize - rule.MaxTotalSize
-	entriesToDelete := excessSize / 500
+	// For simplicity, delete oldest entries
+	// In a real implementation, you'd implement the specific cleanup strategy
+	cutoffTime := time.Now().Add(-sizeRuleCleanupAge)
 
 	if rs.config.DryRunMode {
+		count, bytes, err := rs.repos.AuditLog.CountOldLogsFiltered(ctx, cutoffTime, cleanupFilter(policy))
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to count logs for size rule: %w", err)
+		}
+
 		rs.logger.Info("Size-based rule dry run",
 			logging.Int("policy_id", policy.ID),
 			logging.Int("estimated_size", int(estimatedSize)),
 			logging.Int("max_size", int(rule.MaxTotalSize)),
-			logging.Int("would_delete", int(entriesToDelete)))
+			logging.Int("would_delete", count))
 
-		return entriesToDelete, excessSize, nil
+		return int64(count), bytes, nil
 	}
 
-	// For simplicity, delete oldest entries
-	// In a real implementation, you'd implement the specific cleanup strategy
-	cutoffTime := time.Now().AddDate(0, 0, -7) // Delete entries older than 7 days as a fallback
-	err = rs.repos.AuditLog.CleanupOldLogs(ctx, cutoffTime)
+	deleted, bytesFreed, err := rs.repos.AuditLog.CleanupOldLogsFiltered(ctx, cutoffTime, cleanupFilter(policy), 0)
 	if err != nil {
 		return 0, 0, fmt.Errorf("failed to cleanup logs for size rule: %w", err)
 	}
 
-	return entriesToDelete, excessSize, nil
+	return deleted, bytesFreed, nil
 }
 
 func (rs *RetentionService) executeCountBasedRule(ctx context.Context, policy *models.RetentionPolicy, rule *models.CountBasedRetention) (int64, int64, error) {
@@ -503,27 +897,30 @@ func (rs *RetentionService) executeCountBasedRule(ctx context.Context, policy *m
 		return 0, 0, nil // No cleanup needed
 	}
 
-	entriesToDelete := int64(totalCount) - rule.MaxCount
+	// For simplicity, delete oldest entries
+	// In a real implementation, you'd implement the specific cleanup strategy
+	cutoffTime := time.Now().Add(-countRuleCleanupAge)
 
 	if rs.config.DryRunMode {
+		count, bytes, err := rs.repos.AuditLog.CountOldLogsFiltered(ctx, cutoffTime, cleanupFilter(policy))
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to count logs for count rule: %w", err)
+		}
+
 		rs.logger.Info("Count-based rule dry run",
 			logging.Int("policy_id", policy.ID),
 			logging.Int("total_count", totalCount),
 			logging.Int("max_count", int(rule.MaxCount)),
-			logging.Int("would_delete", int(entriesToDelete)))
+			logging.Int("would_delete", count))
 
-		return entriesToDelete, 0, nil
+		return int64(count), bytes, nil
 	}
-
-	// For simplicity, delete oldest entries
-	// In a real implementation, you'd implement the specific cleanup strategy
-	cutoffTime := time.Now().AddDate(0, 0, -30) // Delete entries older than 30 days as a fallback
-	err = rs.repos.AuditLog.CleanupOldLogs(ctx, cutoffTime)
+	deleted, bytesFreed, err := rs.repos.AuditLog.CleanupOldLogsFiltered(ctx, cutoffTime, cleanupFilter(policy), 0)
 	if err != nil {
 		return 0, 0, fmt.Errorf("failed to cleanup logs for count rule: %w", err)
 	}
 
-	return entriesToDelete, 0, nil
+	return deleted, bytesFreed, nil
 }
 
 func (rs *RetentionService) previewPolicyExecution(ctx context.Context, policy *models.RetentionPolicy) (*RetentionPreview, error) {
@@ -537,7 +934,7 @@ func (rs *RetentionService) previewPolicyExecution(ctx context.Context, policy *
 	// Preview time-based rule
 	if policy.TimeBasedRule != nil {
 		cutoffTime := time.Now().Add(-policy.TimeBasedRule.MaxAge)
-		count, err := rs.repos.AuditLog.CountByTimeRange(ctx, time.Time{}, cutoffTime)
+		count, bytes, err := rs.repos.AuditLog.CountOldLogsFiltered(ctx, cutoffTime, cleanupFilter(policy))
 		if err != nil {
 			return nil, fmt.Errorf("failed to preview time-based rule: %w", err)
 		}
@@ -546,33 +943,38 @@ func (rs *RetentionService) previewPolicyExecution(ctx context.Context, policy *
 			RuleType:           "time_based",
 			EstimatedDeletions: int64(count),
 			CutoffTime:         cutoffTime,
-			Description:        fmt.Sprintf("Delete logs older than %s", policy.TimeBasedRule.MaxAge),
+			Description:        fmt.Sprintf("Delete logs older than %s%s", policy.TimeBasedRule.MaxAge, filterDescription(policy)),
 		})
 
 		totalEstimatedDeletions += int64(count)
+		preview.EstimatedBytesFreed += bytes
 		preview.AffectedTimeRange.End = cutoffTime
 	}
 
 	// Preview size-based rule
 	if policy.SizeBasedRule != nil {
-		totalCount, err := rs.repos.AuditLog.Count(ctx)
+		estimatedSize, err := rs.estimatedLogSize(ctx)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get total count for size preview: %w", err)
+			return nil, fmt.Errorf("failed to estimate log size for size preview: %w", err)
 		}
 
-		estimatedSize := int64(totalCount) * 500 // Rough estimate
 		if estimatedSize > policy.SizeBasedRule.MaxTotalSize {
-			excessSize := estimatedSize - policy.SizeBasedRule.MaxTotalSize
-			entriesToDelete := excessSize / 500
+			cutoffTime := time.Now().Add(-sizeRuleCleanupAge)
+			count, bytes, err := rs.repos.AuditLog.CountOldLogsFiltered(ctx, cutoffTime, cleanupFilter(policy))
+			if err != nil {
+				return nil, fmt.Errorf("failed to preview size-based rule: %w", err)
+			}
 
 			preview.RuleBreakdown = append(preview.RuleBreakdown, RulePreview{
 				RuleType:           "size_based",
-				EstimatedDeletions: entriesToDelete,
-				Description:        fmt.Sprintf("Delete %d entries to stay under %d bytes", entriesToDelete, policy.SizeBasedRule.MaxTotalSize),
+				EstimatedDeletions: int64(count),
+				CutoffTime:         cutoffTime,
+				Description: fmt.Sprintf("Delete %d entries older than %s to stay under %d bytes%s",
+					count, sizeRuleCleanupAge, policy.SizeBasedRule.MaxTotalSize, filterDescription(policy)),
 			})
 
-			totalEstimatedDeletions += entriesToDelete
-			preview.EstimatedBytesFreed += excessSize
+			totalEstimatedDeletions += int64(count)
+			preview.EstimatedBytesFreed += bytes
 		}
 	}
 
@@ -584,15 +986,22 @@ func (rs *RetentionService) previewPolicyExecution(ctx context.Context, policy *
 		}
 
 		if int64(totalCount) > policy.CountBasedRule.MaxCount {
-			entriesToDelete := int64(totalCount) - policy.CountBasedRule.MaxCount
+			cutoffTime := time.Now().Add(-countRuleCleanupAge)
+			count, bytes, err := rs.repos.AuditLog.CountOldLogsFiltered(ctx, cutoffTime, cleanupFilter(policy))
+			if err != nil {
+				return nil, fmt.Errorf("failed to preview count-based rule: %w", err)
+			}
 
 			preview.RuleBreakdown = append(preview.RuleBreakdown, RulePreview{
 				RuleType:           "count_based",
-				EstimatedDeletions: entriesToDelete,
-				Description:        fmt.Sprintf("Delete %d entries to stay under %d total", entriesToDelete, policy.CountBasedRule.MaxCount),
+				EstimatedDeletions: int64(count),
+				CutoffTime:         cutoffTime,
+				Description: fmt.Sprintf("Delete %d entries older than %s to stay under %d total%s",
+					count, countRuleCleanupAge, policy.CountBasedRule.MaxCount, filterDescription(policy)),
 			})
 
-			totalEstimatedDeletions += entriesToDelete
+			totalEstimatedDeletions += int64(count)
+			preview.EstimatedBytesFreed += bytes
 		}
 	}
 
@@ -620,10 +1029,16 @@ func (rs *RetentionService) previewPolicyExecution(ctx context.Context, policy *
 }
 
 func (rs *RetentionService) updatePolicyNextExecution(ctx context.Context, policy *models.RetentionPolicy) {
-	// Simple implementation - add 24 hours to next execution
-	// In a real implementation, you'd parse the cron expression
 	policy.LastExecuted = time.Now()
-	policy.NextExecution = time.Now().Add(24 * time.Hour)
+
+	next, err := nextPolicyExecution(policy.ExecutionSchedule, policy.LastExecuted)
+	if err != nil {
+		rs.logger.Warn("Invalid retention policy schedule, running daily instead",
+			logging.Int("policy_id", policy.ID),
+			logging.String("schedule", policy.ExecutionSchedule),
+			logging.Err(err))
+	}
+	policy.NextExecution = next
 
 	if err := rs.repos.RetentionPolicy.Update(ctx, policy); err != nil {
 		rs.logger.Error("Failed to update policy execution times",
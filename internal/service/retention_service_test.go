@@ -0,0 +1,611 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"parental-control/internal/logging"
+	"parental-control/internal/models"
+)
+
+// stubRetentionPolicyRepo serves a fixed set of enabled policies
+type stubRetentionPolicyRepo struct {
+	models.RetentionPolicyRepository
+	policies []models.RetentionPolicy
+}
+
+func (r *stubRetentionPolicyRepo) GetEnabled(ctx context.Context) ([]models.RetentionPolicy, error) {
+	return r.policies, nil
+}
+
+func (r *stubRetentionPolicyRepo) Update(ctx context.Context, policy *models.RetentionPolicy) error {
+	return nil
+}
+
+// stubRetentionExecutionRepo records executions in memory
+type stubRetentionExecutionRepo struct {
+	models.RetentionExecutionRepository
+	mu         sync.Mutex
+	executions []models.RetentionPolicyExecution
+}
+
+func (r *stubRetentionExecutionRepo) Create(ctx context.Context, execution *models.RetentionPolicyExecution) error {
+	return nil
+}
+
+func (r *stubRetentionExecutionRepo) Update(ctx context.Context, execution *models.RetentionPolicyExecution) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.executions = append(r.executions, *execution)
+	return nil
+}
+
+func (r *stubRetentionExecutionRepo) GetRecent(ctx context.Context, limit int) ([]models.RetentionPolicyExecution, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var recent []models.RetentionPolicyExecution
+	for i := len(r.executions) - 1; i >= 0 && len(recent) < limit; i-- {
+		recent = append(recent, r.executions[i])
+	}
+	return recent, nil
+}
+
+// slowAuditLogRepo tracks how many cleanups run at once
+type slowAuditLogRepo struct {
+	models.AuditLogRepository
+	delay     time.Duration
+	active    int32
+	maxActive int32
+}
+
+func (r *slowAuditLogRepo) Count(ctx context.Context) (int, error) {
+	return 100, nil
+}
+
+func (r *slowAuditLogRepo) CleanupOldLogsFiltered(ctx context.Context, before time.Time, filter models.AuditLogCleanupFilter, limit int) (int64, int64, error) {
+	current := atomic.AddInt32(&r.active, 1)
+	defer atomic.AddInt32(&r.active, -1)
+
+	for {
+		max := atomic.LoadInt32(&r.maxActive)
+		if current <= max || atomic.CompareAndSwapInt32(&r.maxActive, max, current) {
+			break
+		}
+	}
+
+	select {
+	case <-time.After(r.delay):
+		return 0, 0, nil
+	case <-ctx.Done():
+		return 0, 0, ctx.Err()
+	}
+}
+
+func newTestRetentionService(policyCount int, auditRepo *slowAuditLogRepo, config RetentionConfig) (*RetentionService, *stubRetentionExecutionRepo) {
+	policies := make([]models.RetentionPolicy, policyCount)
+	for i := range policies {
+		policies[i] = models.RetentionPolicy{
+			ID:             i + 1,
+			Name:           "policy",
+			Enabled:        true,
+			CountBasedRule: &models.CountBasedRetention{MaxCount: 10},
+		}
+	}
+
+	executionRepo := &stubRetentionExecutionRepo{}
+	repos := &models.RepositoryManager{
+		AuditLog:           auditRepo,
+		RetentionPolicy:    &stubRetentionPolicyRepo{policies: policies},
+		RetentionExecution: executionRepo,
+	}
+
+	return NewRetentionService(repos, logging.NewDefault(), config), executionRepo
+}
+
+func waitForExecutions(t *testing.T, rs *RetentionService, expected int64) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if rs.GetStats().TotalExecutions >= expected {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("Timed out waiting for %d executions, got %d", expected, rs.GetStats().TotalExecutions)
+}
+
+func TestRetentionService_ConcurrencyLimit(t *testing.T) {
+	config := DefaultRetentionConfig()
+	config.MaxConcurrentJobs = 2
+	config.SafetyThreshold = 1.0
+
+	auditRepo := &slowAuditLogRepo{delay: 50 * time.Millisecond}
+	rs, _ := newTestRetentionService(6, auditRepo, config)
+
+	rs.checkAndExecutePolicies(context.Background())
+	waitForExecutions(t, rs, 6)
+
+	if max := atomic.LoadInt32(&auditRepo.maxActive); max > 2 {
+		t.Fatalf("Expected at most 2 concurrent jobs, observed %d", max)
+	}
+	if stats := rs.GetStats(); stats.SuccessfulExecutions != 6 {
+		t.Fatalf("Expected 6 successful executions, got %d", stats.SuccessfulExecutions)
+	}
+}
+
+func TestRetentionService_JobTimeout(t *testing.T) {
+	config := DefaultRetentionConfig()
+	config.MaxConcurrentJobs = 1
+	config.JobTimeout = 20 * time.Millisecond
+	config.SafetyThreshold = 1.0
+
+	auditRepo := &slowAuditLogRepo{delay: time.Second}
+	rs, executionRepo := newTestRetentionService(1, auditRepo, config)
+
+	rs.checkAndExecutePolicies(context.Background())
+	waitForExecutions(t, rs, 1)
+
+	stats := rs.GetStats()
+	if stats.FailedExecutions != 1 || stats.TimedOutExecutions != 1 {
+		t.Fatalf("Expected one failed, timed-out execution, got failed=%d timed_out=%d",
+			stats.FailedExecutions, stats.TimedOutExecutions)
+	}
+
+	executionRepo.mu.Lock()
+	defer executionRepo.mu.Unlock()
+	if len(executionRepo.executions) != 1 || executionRepo.executions[0].Status != models.ExecutionStatusFailed {
+		t.Fatalf("Expected execution recorded as failed, got %+v", executionRepo.executions)
+	}
+}
+
+func TestRetentionService_RecentRuns(t *testing.T) {
+	config := DefaultRetentionConfig()
+	config.SafetyThreshold = 1.0
+
+	rs, executionRepo := newTestRetentionService(0, &slowAuditLogRepo{}, config)
+
+	failed := models.RetentionPolicyExecution{
+		PolicyID:      1,
+		ExecutionTime: time.Now().Add(-time.Hour),
+		Status:        models.ExecutionStatusFailed,
+		Duration:      time.Second,
+		ErrorMessage:  "disk full",
+	}
+	completed := models.RetentionPolicyExecution{
+		PolicyID:       2,
+		ExecutionTime:  time.Now(),
+		Status:         models.ExecutionStatusCompleted,
+		EntriesDeleted: 42,
+		BytesFreed:     4096,
+		Duration:       2 * time.Second,
+	}
+	completed.SetDetailsMap(map[string]interface{}{"trigger": string(models.TriggerManual)})
+	executionRepo.executions = []models.RetentionPolicyExecution{failed, completed}
+
+	runs := rs.GetStats().RecentRuns
+	if len(runs) != 2 {
+		t.Fatalf("Expected 2 recent runs, got %d", len(runs))
+	}
+
+	latest := runs[0]
+	if latest.PolicyID != 2 || latest.Trigger != "manual" || latest.Status != models.ExecutionStatusCompleted ||
+		latest.ItemsAffected != 42 || latest.BytesFreed != 4096 || latest.Duration != 2*time.Second {
+		t.Errorf("Unexpected latest run summary: %+v", latest)
+	}
+	if runs[1].Trigger != "scheduled" || runs[1].Error != "disk full" {
+		t.Errorf("Unexpected failed run summary: %+v", runs[1])
+	}
+}
+
+// memAuditLogRepo holds audit log timestamps in memory; every entry counts
+// as 100 bytes
+type memAuditLogRepo struct {
+	models.AuditLogRepository
+	mu         sync.Mutex
+	timestamps []time.Time
+	filters    []models.AuditLogCleanupFilter
+}
+
+func (r *memAuditLogRepo) Count(ctx context.Context) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.timestamps), nil
+}
+
+func (r *memAuditLogRepo) CountByTimeRange(ctx context.Context, start, end time.Time) (int, error) {
+	logs, err := r.GetByTimeRange(ctx, start, end, len(r.timestamps), 0)
+	return len(logs), err
+}
+
+// GetByTimeRange returns logs newest first, like the database repository
+func (r *memAuditLogRepo) GetByTimeRange(ctx context.Context, start, end time.Time, limit, offset int) ([]models.AuditLog, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var logs []models.AuditLog
+	for _, ts := range r.timestamps {
+		if !ts.Before(start) && !ts.After(end) {
+			logs = append(logs, models.AuditLog{Timestamp: ts})
+		}
+	}
+	sort.Slice(logs, func(i, j int) bool { return logs[i].Timestamp.After(logs[j].Timestamp) })
+	if offset >= len(logs) {
+		return nil, nil
+	}
+	logs = logs[offset:]
+	if limit < len(logs) {
+		logs = logs[:limit]
+	}
+	return logs, nil
+}
+
+func (r *memAuditLogRepo) CountOldLogsFiltered(ctx context.Context, before time.Time, filter models.AuditLogCleanupFilter) (int, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.filters = append(r.filters, filter)
+
+	count := 0
+	for _, ts := range r.timestamps {
+		if ts.Before(before) {
+			count++
+		}
+	}
+	return count, int64(count) * 100, nil
+}
+
+func (r *memAuditLogRepo) AverageEntrySize(ctx context.Context, sampleSize int) (int64, error) {
+	return 100, nil
+}
+
+func (r *memAuditLogRepo) CleanupOldLogsFiltered(ctx context.Context, before time.Time, filter models.AuditLogCleanupFilter, limit int) (int64, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.filters = append(r.filters, filter)
+
+	sort.Slice(r.timestamps, func(i, j int) bool { return r.timestamps[i].Before(r.timestamps[j]) })
+	kept := r.timestamps[:0]
+	var deleted int64
+	for _, ts := range r.timestamps {
+		if ts.Before(before) && (limit <= 0 || deleted < int64(limit)) {
+			deleted++
+			continue
+		}
+		kept = append(kept, ts)
+	}
+	r.timestamps = kept
+	return deleted, deleted * 100, nil
+}
+
+// stubRetentionAlerter records system alerts
+type stubRetentionAlerter struct {
+	titles []string
+}
+
+func (a *stubRetentionAlerter) NotifySystemAlert(ctx context.Context, title string, message string, details map[string]interface{}) error {
+	a.titles = append(a.titles, title)
+	return nil
+}
+
+func TestRetentionService_SafetyAction(t *testing.T) {
+	tests := []struct {
+		action         SafetyAction
+		expectStatus   models.ExecutionStatus
+		expectOutcome  string
+		expectDeleted  int64
+		expectRemain   int
+		expectAlerts   int
+		expectClamped  int64
+		expectAborted  int64
+		expectAllowed  float64
+		expectErrorMsg bool
+	}{
+		{SafetyActionAbort, models.ExecutionStatusFailed, "aborted", 0, 100, 0, 0, 1, 0, true},
+		{"", models.ExecutionStatusFailed, "aborted", 0, 100, 0, 0, 1, 0, true},
+		{SafetyActionAlertAndAbort, models.ExecutionStatusFailed, "aborted", 0, 100, 1, 0, 1, 0, true},
+		{SafetyActionClamp, models.ExecutionStatusCompleted, "clamped", 50, 50, 0, 1, 0, 50, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.action), func(t *testing.T) {
+			// 90 of 100 entries are past the policy's max age
+			auditRepo := &memAuditLogRepo{}
+			now := time.Now()
+			for i := 0; i < 100; i++ {
+				ts := now.Add(-time.Duration(i-90) * time.Second)
+				if i < 90 {
+					ts = now.Add(-2*time.Hour - time.Duration(i)*time.Minute)
+				}
+				auditRepo.timestamps = append(auditRepo.timestamps, ts)
+			}
+
+			config := DefaultRetentionConfig()
+			config.SafetyThreshold = 0.5
+			config.SafetyAction = tt.action
+
+			executionRepo := &stubRetentionExecutionRepo{}
+			repos := &models.RepositoryManager{
+				AuditLog:           auditRepo,
+				RetentionPolicy:    &stubRetentionPolicyRepo{},
+				RetentionExecution: executionRepo,
+			}
+			rs := NewRetentionService(repos, logging.NewDefault(), config)
+			alerter := &stubRetentionAlerter{}
+			rs.SetAlerter(alerter)
+
+			policy := &models.RetentionPolicy{ID: 1, Name: "policy", Enabled: true, TimeBasedRule: &models.TimeBasedRetention{MaxAge: time.Hour}}
+			execution, err := rs.executePolicy(context.Background(), policy, models.TriggerManual)
+			if (err != nil) != tt.expectErrorMsg {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if execution.Status != tt.expectStatus || execution.EntriesDeleted != tt.expectDeleted {
+				t.Errorf("Expected %s with %d deleted, got %s with %d", tt.expectStatus, tt.expectDeleted, execution.Status, execution.EntriesDeleted)
+			}
+			if remaining, _ := auditRepo.Count(context.Background()); remaining != tt.expectRemain {
+				t.Errorf("Expected %d entries left, got %d", tt.expectRemain, remaining)
+			}
+			if len(alerter.titles) != tt.expectAlerts {
+				t.Errorf("Expected %d alerts, got %d", tt.expectAlerts, len(alerter.titles))
+			}
+
+			details, err := execution.GetDetailsMap()
+			if err != nil {
+				t.Fatalf("Failed to read details: %v", err)
+			}
+			if details["safety_outcome"] != tt.expectOutcome || details["safety_would_delete"] != float64(90) || details["safety_allowed"] != tt.expectAllowed {
+				t.Errorf("Unexpected safety details: %v", details)
+			}
+
+			stats := rs.GetStats()
+			if stats.ClampedExecutions != tt.expectClamped || stats.AbortedExecutions != tt.expectAborted {
+				t.Errorf("Expected clamped=%d aborted=%d, got clamped=%d aborted=%d",
+					tt.expectClamped, tt.expectAborted, stats.ClampedExecutions, stats.AbortedExecutions)
+			}
+		})
+	}
+}
+
+func TestRetentionService_FiltersAndBytesFreed(t *testing.T) {
+	auditRepo := &memAuditLogRepo{}
+	now := time.Now()
+	for i := 0; i < 10; i++ {
+		ts := now
+		if i < 4 {
+			ts = now.Add(-48 * time.Hour)
+		}
+		auditRepo.timestamps = append(auditRepo.timestamps, ts)
+	}
+
+	repos := &models.RepositoryManager{
+		AuditLog:           auditRepo,
+		RetentionPolicy:    &stubRetentionPolicyRepo{},
+		RetentionExecution: &stubRetentionExecutionRepo{},
+	}
+	rs := NewRetentionService(repos, logging.NewDefault(), DefaultRetentionConfig())
+
+	policy := &models.RetentionPolicy{
+		ID: 1, Name: "allow-only", Enabled: true,
+		TimeBasedRule:   &models.TimeBasedRetention{MaxAge: 24 * time.Hour},
+		EventTypeFilter: []string{"enforcement_action"},
+		ActionFilter:    []string{"allow"},
+	}
+	execution, err := rs.executePolicy(context.Background(), policy, models.TriggerManual)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if execution.EntriesDeleted != 4 || execution.BytesFreed != 400 {
+		t.Errorf("Expected 4 entries and 400 bytes freed, got %d and %d", execution.EntriesDeleted, execution.BytesFreed)
+	}
+	for _, filter := range auditRepo.filters {
+		if len(filter.EventTypes) != 1 || filter.EventTypes[0] != "enforcement_action" || len(filter.Actions) != 1 || filter.Actions[0] != "allow" {
+			t.Errorf("Expected the policy filters to reach the repository, got %+v", filter)
+		}
+	}
+	if len(auditRepo.filters) == 0 {
+		t.Error("Expected the repository to be queried with the policy filters")
+	}
+}
+
+func TestRetentionService_PreExecutionHook(t *testing.T) {
+	newService := func(config RetentionConfig) (*RetentionService, *memAuditLogRepo) {
+		auditRepo := &memAuditLogRepo{}
+		now := time.Now()
+		for i := 0; i < 10; i++ {
+			ts := now
+			if i < 4 {
+				ts = now.Add(-48 * time.Hour)
+			}
+			auditRepo.timestamps = append(auditRepo.timestamps, ts)
+		}
+		repos := &models.RepositoryManager{
+			AuditLog:           auditRepo,
+			RetentionPolicy:    &stubRetentionPolicyRepo{},
+			RetentionExecution: &stubRetentionExecutionRepo{},
+		}
+		return NewRetentionService(repos, logging.NewDefault(), config), auditRepo
+	}
+	policy := &models.RetentionPolicy{
+		ID: 1, Name: "old", Enabled: true,
+		TimeBasedRule: &models.TimeBasedRetention{MaxAge: 24 * time.Hour},
+	}
+
+	t.Run("receives the estimate", func(t *testing.T) {
+		rs, auditRepo := newService(DefaultRetentionConfig())
+		var estimated int64 = -1
+		rs.SetPreExecutionHook(func(ctx context.Context, p *models.RetentionPolicy, preview *RetentionPreview) error {
+			estimated = preview.EstimatedDeletions
+			return nil
+		})
+
+		execution, err := rs.executePolicy(context.Background(), policy, models.TriggerManual)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if estimated != 4 {
+			t.Errorf("Expected the hook to see 4 estimated deletions, got %d", estimated)
+		}
+		if execution.EntriesDeleted != 4 || len(auditRepo.timestamps) != 6 {
+			t.Errorf("Expected 4 entries deleted, got %d with %d left", execution.EntriesDeleted, len(auditRepo.timestamps))
+		}
+	})
+
+	t.Run("error fails the run", func(t *testing.T) {
+		rs, auditRepo := newService(DefaultRetentionConfig())
+		rs.SetPreExecutionHook(func(ctx context.Context, p *models.RetentionPolicy, preview *RetentionPreview) error {
+			return errors.New("backup failed")
+		})
+
+		execution, err := rs.executePolicy(context.Background(), policy, models.TriggerManual)
+		if err == nil || !strings.Contains(err.Error(), "backup failed") {
+			t.Fatalf("Expected the hook error, got %v", err)
+		}
+		if execution.Status != models.ExecutionStatusFailed || execution.EntriesDeleted != 0 || len(auditRepo.timestamps) != 10 {
+			t.Errorf("Expected a failed run that deleted nothing, got %+v with %d left", execution, len(auditRepo.timestamps))
+		}
+	})
+
+	t.Run("dry run skips the hook", func(t *testing.T) {
+		config := DefaultRetentionConfig()
+		config.DryRunMode = true
+		rs, _ := newService(config)
+		called := false
+		rs.SetPreExecutionHook(func(ctx context.Context, p *models.RetentionPolicy, preview *RetentionPreview) error {
+			called = true
+			return nil
+		})
+
+		if _, err := rs.executePolicy(context.Background(), policy, models.TriggerManual); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if called {
+			t.Error("Expected dry runs to skip the hook")
+		}
+	})
+}
+
+func TestRetentionService_DeletionHook(t *testing.T) {
+	auditRepo := &memAuditLogRepo{}
+	now := time.Now()
+	for i := 0; i < 10; i++ {
+		ts := now
+		if i < 4 {
+			ts = now.Add(-48 * time.Hour)
+		}
+		auditRepo.timestamps = append(auditRepo.timestamps, ts)
+	}
+	repos := &models.RepositoryManager{
+		AuditLog:           auditRepo,
+		RetentionPolicy:    &stubRetentionPolicyRepo{},
+		RetentionExecution: &stubRetentionExecutionRepo{},
+	}
+	rs := NewRetentionService(repos, logging.NewDefault(), DefaultRetentionConfig())
+	policy := &models.RetentionPolicy{
+		ID: 1, Name: "old", Enabled: true,
+		TimeBasedRule: &models.TimeBasedRetention{MaxAge: 24 * time.Hour},
+	}
+
+	// Record how many logs there were as the deletions began and ended
+	var seen []int
+	rs.SetDeletionHook(func(p *models.RetentionPolicy) func() {
+		seen = append(seen, len(auditRepo.timestamps))
+		return func() { seen = append(seen, len(auditRepo.timestamps)) }
+	})
+
+	if _, err := rs.executePolicy(context.Background(), policy, models.TriggerManual); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(seen) != 2 || seen[0] != 10 || seen[1] != 6 {
+		t.Errorf("Expected the hook to bracket the deletions, saw %v", seen)
+	}
+}
+
+func TestRetentionService_PreviewUsesFilters(t *testing.T) {
+	// 40 entries past the size and count rules' cutoffs, 60 recent ones
+	auditRepo := &memAuditLogRepo{}
+	now := time.Now()
+	for i := 0; i < 100; i++ {
+		ts := now.Add(-time.Duration(i) * time.Minute)
+		if i < 40 {
+			ts = now.Add(-40 * 24 * time.Hour)
+		}
+		auditRepo.timestamps = append(auditRepo.timestamps, ts)
+	}
+
+	repos := &models.RepositoryManager{AuditLog: auditRepo}
+	rs := NewRetentionService(repos, logging.NewDefault(), DefaultRetentionConfig())
+
+	policy := &models.RetentionPolicy{
+		ID: 1, Name: "allow-only",
+		SizeBasedRule:   &models.SizeBasedRetention{MaxTotalSize: 5000},
+		CountBasedRule:  &models.CountBasedRetention{MaxCount: 90},
+		EventTypeFilter: []string{"enforcement_action"},
+		ActionFilter:    []string{"allow"},
+	}
+	preview, err := rs.previewPolicyExecution(context.Background(), policy)
+	if err != nil {
+		t.Fatalf("Failed to preview policy: %v", err)
+	}
+
+	if len(preview.RuleBreakdown) != 2 {
+		t.Fatalf("Expected size and count rule previews, got %+v", preview.RuleBreakdown)
+	}
+	for _, rule := range preview.RuleBreakdown {
+		if rule.EstimatedDeletions != 40 {
+			t.Errorf("Expected %s to match the 40 old entries, got %d", rule.RuleType, rule.EstimatedDeletions)
+		}
+		if !strings.Contains(rule.Description, "only event types enforcement_action; actions allow") {
+			t.Errorf("Expected the filters in the %s description, got %q", rule.RuleType, rule.Description)
+		}
+	}
+	if preview.EstimatedBytesFreed != 8000 {
+		t.Errorf("Expected 8000 bytes freed across both rules, got %d", preview.EstimatedBytesFreed)
+	}
+	for _, filter := range auditRepo.filters {
+		if len(filter.Actions) != 1 || filter.Actions[0] != "allow" {
+			t.Errorf("Expected the preview to count with the policy filters, got %+v", filter)
+		}
+	}
+}
+
+func TestRetentionService_ShouldExecutePolicy(t *testing.T) {
+	rs := &RetentionService{}
+	now := time.Now()
+	lastNight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	tests := []struct {
+		name   string
+		policy models.RetentionPolicy
+		expect bool
+	}{
+		{"never executed", models.RetentionPolicy{ExecutionSchedule: "@daily"}, true},
+		{"ran at the last scheduled time", models.RetentionPolicy{ExecutionSchedule: "@daily", LastExecuted: lastNight}, false},
+		{"missed the last scheduled time", models.RetentionPolicy{ExecutionSchedule: "@daily", LastExecuted: lastNight.Add(-time.Minute)}, true},
+		{"every minute", models.RetentionPolicy{ExecutionSchedule: "* * * * *", LastExecuted: now.Add(-2 * time.Minute)}, true},
+		{"no schedule runs daily", models.RetentionPolicy{LastExecuted: now.Add(-23 * time.Hour)}, false},
+		{"stored next execution before the first run", models.RetentionPolicy{NextExecution: now.Add(time.Hour)}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rs.shouldExecutePolicy(&tt.policy); got != tt.expect {
+				t.Errorf("Expected %v, got %v", tt.expect, got)
+			}
+		})
+	}
+
+	next, err := nextPolicyExecution("0 2 * * *", lastNight)
+	if err != nil || !next.Equal(lastNight.Add(2*time.Hour)) {
+		t.Errorf("Expected next execution at 02:00, got %v (%v)", next, err)
+	}
+	if _, err := nextPolicyExecution("not a schedule", lastNight); err == nil {
+		t.Error("Expected an invalid schedule to be reported")
+	}
+}
@@ -3,7 +3,8 @@ package service
 import (
 	"compress/gzip"
 	"context"
-	"crypto/md5"
+	"crypto/sha256"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -12,6 +13,8 @@ import (
 	"sync"
 	"time"
 
+	"github.com/klauspost/compress/zstd"
+
 	"parental-control/internal/logging"
 	"parental-control/internal/models"
 )
@@ -32,13 +35,37 @@ type LogRotationService struct {
 	diskMonitor *DiskSpaceMonitor
 
 	// Statistics
-	stats   *models.RotationStats
-	statsMu sync.RWMutex
+	stats            *models.RotationStats
+	executions       int64
+	failedExecutions int64
+	statsMu          sync.RWMutex
+
+	lifecycle *Lifecycle
+
+	// File operation safety: one rotation at a time per file
+	fileLocks   map[string]*fileLock
+	fileLocksMu sync.Mutex
+
+	// Concurrency control
+	jobSem     chan struct{}
+	inFlight   map[int]bool
+	inFlightMu sync.Mutex
 
-	// File operation safety
-	operationMu sync.Mutex
+	// failAt lets tests interrupt a rotation after a step
+	failAt func(step rotationStep) error
 }
 
+// rotationStep names a point in a file rotation that must be safe to crash at
+type rotationStep string
+
+const (
+	stepLinked     rotationStep = "linked"     // Contents reachable under both names
+	stepReplaced   rotationStep = "replaced"   // Fresh log in place, contents under the rotated name
+	stepCompressed rotationStep = "compressed" // Archive written to the temp directory
+	stepVerified   rotationStep = "verified"   // Temp archive checked against the checksum
+	stepArchived   rotationStep = "archived"   // Archive renamed into place, source not yet removed
+)
+
 // LogRotationConfig holds configuration for the log rotation service
 type LogRotationConfig struct {
 	// Monitoring settings
@@ -106,16 +133,53 @@ type FileRotationResult struct {
 	Errors           []string                  `json:"errors"`
 }
 
+// ArchiveVerificationError reports an archive whose decompressed contents do
+// not match the checksum of the file it was made from
+type ArchiveVerificationError struct {
+	ArchivePath string
+	Expected    string
+	Actual      string
+}
+
+func (e *ArchiveVerificationError) Error() string {
+	return fmt.Sprintf("archive %s failed verification: expected checksum %s, got %s",
+		e.ArchivePath, e.Expected, e.Actual)
+}
+
+// RotationSafetyError reports a bulk rotation that would move a larger share
+// of the target files or bytes at once than the safety threshold allows
+type RotationSafetyError struct {
+	Files      int
+	TotalFiles int
+	Bytes      int64
+	TotalBytes int64
+	Threshold  float64
+}
+
+func (e *RotationSafetyError) Error() string {
+	return fmt.Sprintf("safety threshold exceeded: would rotate %d/%d files (%d/%d bytes), threshold %.0f%%",
+		e.Files, e.TotalFiles, e.Bytes, e.TotalBytes, e.Threshold*100)
+}
+
 // NewLogRotationService creates a new log rotation service
 func NewLogRotationService(repos *models.RepositoryManager, logger logging.Logger, config LogRotationConfig) *LogRotationService {
+	maxRotations := config.MaxConcurrentRotations
+	if maxRotations <= 0 {
+		maxRotations = 1
+	}
+
 	service := &LogRotationService{
-		repos:  repos,
-		logger: logger,
-		config: config,
-		stopCh: make(chan struct{}),
+		repos:     repos,
+		logger:    logger,
+		config:    config,
+		stopCh:    make(chan struct{}),
+		jobSem:    make(chan struct{}, maxRotations),
+		inFlight:  make(map[int]bool),
+		fileLocks: make(map[string]*fileLock),
 		stats: &models.RotationStats{
 			PolicyStats: make(map[int]*models.PolicyRotationStats),
 		},
+		lifecycle: NewLifecycle(logger, "log_rotation"),
 	}
 
 	// Initialize disk monitor
@@ -141,7 +205,11 @@ func (s *LogRotationService) Start(ctx context.Context) error {
 		return fmt.Errorf("log rotation service is already running")
 	}
 
-	s.logger.Info("Starting log rotation service")
+	s.lifecycle.Starting(
+		logging.Duration("check_interval", s.config.CheckInterval),
+		logging.Bool("disk_monitoring", s.config.EnableDiskMonitoring),
+		logging.Duration("disk_check_interval", s.config.DiskCheckInterval),
+		logging.Bool("dry_run", s.config.DryRunMode))
 
 	// Start main rotation loop
 	s.wg.Add(1)
@@ -154,7 +222,7 @@ func (s *LogRotationService) Start(ctx context.Context) error {
 	}
 
 	s.running = true
-	s.logger.Info("Log rotation service started successfully")
+	s.lifecycle.Started()
 	return nil
 }
 
@@ -167,21 +235,25 @@ func (s *LogRotationService) Stop() error {
 		return nil
 	}
 
-	s.logger.Info("Stopping log rotation service")
+	s.lifecycle.Stopping()
 
 	close(s.stopCh)
 	s.wg.Wait()
 
 	s.running = false
-	s.logger.Info("Log rotation service stopped")
+
+	s.statsMu.RLock()
+	executions, failed := s.executions, s.failedExecutions
+	filesRotated, bytesFreed := s.stats.TotalFilesRotated, s.stats.TotalBytesFreed
+	s.statsMu.RUnlock()
+	s.lifecycle.Stopped(executions, failed,
+		logging.Int("files_rotated", int(filesRotated)),
+		logging.Int("bytes_freed", int(bytesFreed)))
 	return nil
 }
 
 // ExecutePolicy manually executes a specific rotation policy
 func (s *LogRotationService) ExecutePolicy(ctx context.Context, policyID int) (*models.LogRotationExecution, error) {
-	s.operationMu.Lock()
-	defer s.operationMu.Unlock()
-
 	policy, err := s.repos.LogRotationPolicy.GetByID(ctx, policyID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get rotation policy: %w", err)
@@ -229,6 +301,11 @@ func (s *LogRotationService) GetStats() *models.RotationStats {
 		return s.stats
 	}
 
+	dbStats.RecentRuns = make([]models.RunSummary, 0, len(dbStats.RecentExecutions))
+	for i := range dbStats.RecentExecutions {
+		dbStats.RecentRuns = append(dbStats.RecentRuns, dbStats.RecentExecutions[i].Summary())
+	}
+
 	// Add disk space information
 	if s.config.EnableDiskMonitoring {
 		dbStats.DiskSpaceInfo = s.getCurrentDiskSpace()
@@ -291,10 +368,16 @@ func (s *LogRotationService) checkAndExecutePolicies(ctx context.Context) {
 
 	for _, policy := range policies {
 		if s.shouldExecutePolicy(&policy) {
+			// Skip policies still queued or running from a previous check
+			if !s.markInFlight(policy.ID) {
+				continue
+			}
 			trigger := s.determineTriggerReason(&policy)
 
-			// Execute policy in a separate goroutine to avoid blocking
+			// Execute policy in a separate goroutine to avoid blocking;
+			// executePolicy waits for a free rotation slot
 			go func(p models.LogRotationPolicy, t models.RotationTrigger) {
+				defer s.clearInFlight(p.ID)
 				if _, err := s.executePolicy(ctx, &p, t); err != nil {
 					s.logger.Error("Failed to execute scheduled rotation policy",
 						logging.Int("policy_id", p.ID),
@@ -307,6 +390,40 @@ func (s *LogRotationService) checkAndExecutePolicies(ctx context.Context) {
 	}
 }
 
+func (s *LogRotationService) markInFlight(policyID int) bool {
+	s.inFlightMu.Lock()
+	defer s.inFlightMu.Unlock()
+
+	if s.inFlight[policyID] {
+		return false
+	}
+	s.inFlight[policyID] = true
+	return true
+}
+
+func (s *LogRotationService) clearInFlight(policyID int) {
+	s.inFlightMu.Lock()
+	defer s.inFlightMu.Unlock()
+	delete(s.inFlight, policyID)
+}
+
+// acquireRotationSlot blocks until fewer than MaxConcurrentRotations
+// rotations are running
+func (s *LogRotationService) acquireRotationSlot(ctx context.Context) error {
+	select {
+	case s.jobSem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-s.stopCh:
+		return fmt.Errorf("log rotation service is stopping")
+	}
+}
+
+func (s *LogRotationService) releaseRotationSlot() {
+	<-s.jobSem
+}
+
 func (s *LogRotationService) checkDiskSpace(ctx context.Context) {
 	if s.diskMonitor == nil {
 		return
@@ -348,7 +465,7 @@ func (s *LogRotationService) checkDiskSpace(ctx context.Context) {
 
 func (s *LogRotationService) shouldExecutePolicy(policy *models.LogRotationPolicy) bool {
 	// Check if it's time for scheduled execution
-	if !policy.NextExecution.IsZero() && time.Now().After(policy.NextExecution) {
+	if scheduledRunDue(policy.ExecutionSchedule, policy.LastExecuted, policy.NextExecution, time.Now()) {
 		return true
 	}
 
@@ -420,6 +537,11 @@ func (s *LogRotationService) checkTimeBasedTrigger(policy *models.LogRotationPol
 }
 
 func (s *LogRotationService) executePolicy(ctx context.Context, policy *models.LogRotationPolicy, trigger models.RotationTrigger) (*models.LogRotationExecution, error) {
+	if err := s.acquireRotationSlot(ctx); err != nil {
+		return nil, fmt.Errorf("failed to acquire rotation slot: %w", err)
+	}
+	defer s.releaseRotationSlot()
+
 	startTime := time.Now()
 
 	// Create execution record
@@ -455,6 +577,11 @@ func (s *LogRotationService) executePolicy(ctx context.Context, policy *models.L
 		return execution, nil
 	}
 
+	if safetyErr := s.checkSafetyThreshold(policy, trigger, targetFiles); safetyErr != nil {
+		s.skipForSafety(ctx, policy, trigger, execution, safetyErr)
+		return execution, safetyErr
+	}
+
 	// Perform rotation
 	result, err := s.rotateFiles(ctx, policy, targetFiles)
 	if err != nil {
@@ -478,6 +605,9 @@ func (s *LogRotationService) executePolicy(ctx context.Context, policy *models.L
 		"files_processed": result.Files,
 		"dry_run_mode":    s.config.DryRunMode,
 	}
+	if policy.ArchivalPolicy != nil && policy.ArchivalPolicy.EnableCompression {
+		details["compression_format"] = string(policy.ArchivalPolicy.Format())
+	}
 	if err := execution.SetDetailsMap(details); err != nil {
 		s.logger.Error("Failed to set execution details", logging.Err(err))
 	}
@@ -502,6 +632,75 @@ func (s *LogRotationService) executePolicy(ctx context.Context, policy *models.L
 	return execution, nil
 }
 
+// checkSafetyThreshold refuses bulk rotations that would move more than
+// SafetyThreshold of the target files or bytes at once. Emergency cleanups
+// exist to free space and are never held back.
+func (s *LogRotationService) checkSafetyThreshold(policy *models.LogRotationPolicy, trigger models.RotationTrigger, files []string) *RotationSafetyError {
+	if trigger == models.TriggerEmergency {
+		return nil
+	}
+
+	safetyErr := &RotationSafetyError{TotalFiles: len(files), Threshold: s.config.SafetyThreshold}
+	for _, file := range files {
+		fileInfo, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		safetyErr.TotalBytes += fileInfo.Size()
+		if fileDueForRotation(policy, fileInfo) {
+			safetyErr.Files++
+			safetyErr.Bytes += fileInfo.Size()
+		}
+	}
+
+	// Rotating a single file is never a bulk operation
+	if safetyErr.Files <= 1 {
+		return nil
+	}
+
+	fileShare := float64(safetyErr.Files) / float64(safetyErr.TotalFiles)
+	byteShare := 0.0
+	if safetyErr.TotalBytes > 0 {
+		byteShare = float64(safetyErr.Bytes) / float64(safetyErr.TotalBytes)
+	}
+	if fileShare <= s.config.SafetyThreshold && byteShare <= s.config.SafetyThreshold {
+		return nil
+	}
+
+	return safetyErr
+}
+
+// skipForSafety records an execution that was skipped by the safety threshold
+func (s *LogRotationService) skipForSafety(ctx context.Context, policy *models.LogRotationPolicy, trigger models.RotationTrigger, execution *models.LogRotationExecution, safetyErr *RotationSafetyError) {
+	s.logger.Warn("Skipping log rotation that exceeds the safety threshold",
+		logging.Int("policy_id", policy.ID),
+		logging.String("policy_name", policy.Name),
+		logging.Int("would_rotate_files", safetyErr.Files),
+		logging.Int("total_files", safetyErr.TotalFiles),
+		logging.Field{Key: "threshold", Value: safetyErr.Threshold})
+
+	details := map[string]interface{}{
+		"policy_name":             policy.Name,
+		"trigger_reason":          string(trigger),
+		"target_patterns":         policy.TargetLogFiles,
+		"dry_run_mode":            s.config.DryRunMode,
+		"skipped_due_to_safety":   true,
+		"safety_threshold":        safetyErr.Threshold,
+		"safety_would_rotate":     safetyErr.Files,
+		"safety_total_files":      safetyErr.TotalFiles,
+		"safety_would_free_bytes": safetyErr.Bytes,
+		"safety_total_bytes":      safetyErr.TotalBytes,
+	}
+	if err := execution.SetDetailsMap(details); err != nil {
+		s.logger.Error("Failed to set execution details", logging.Err(err))
+	}
+
+	s.updateExecutionError(ctx, execution, safetyErr)
+
+	// Wait for the next scheduled run rather than retrying every check
+	s.updatePolicyNextExecution(ctx, policy)
+}
+
 func (s *LogRotationService) rotateFiles(ctx context.Context, policy *models.LogRotationPolicy, files []string) (*FileRotationResult, error) {
 	result := &FileRotationResult{
 		Files: make([]models.FileRotationInfo, 0, len(files)),
@@ -509,7 +708,10 @@ func (s *LogRotationService) rotateFiles(ctx context.Context, policy *models.Log
 	startTime := time.Now()
 
 	for _, file := range files {
+		// Scheduled, manual and emergency runs may target the same file
+		unlock := s.lockFile(file)
 		fileInfo, err := s.rotateFile(ctx, policy, file)
+		unlock()
 		if err != nil {
 			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", file, err))
 			s.logger.Error("Failed to rotate file",
@@ -538,6 +740,42 @@ func (s *LogRotationService) rotateFiles(ctx context.Context, policy *models.Log
 	return result, nil
 }
 
+// fileLock serializes rotations of one file
+type fileLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// lockFile blocks until no other rotation is working on path and returns the
+// function that releases it. Rotations of different files run concurrently,
+// up to MaxConcurrentRotations.
+func (s *LogRotationService) lockFile(path string) func() {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+
+	s.fileLocksMu.Lock()
+	lock, exists := s.fileLocks[path]
+	if !exists {
+		lock = &fileLock{}
+		s.fileLocks[path] = lock
+	}
+	lock.refs++
+	s.fileLocksMu.Unlock()
+
+	lock.mu.Lock()
+	return func() {
+		lock.mu.Unlock()
+
+		s.fileLocksMu.Lock()
+		lock.refs--
+		if lock.refs == 0 {
+			delete(s.fileLocks, path)
+		}
+		s.fileLocksMu.Unlock()
+	}
+}
+
 func (s *LogRotationService) rotateFile(ctx context.Context, policy *models.LogRotationPolicy, filePath string) (*models.FileRotationInfo, error) {
 	// Check if file exists and get info
 	fileInfo, err := os.Stat(filePath)
@@ -548,18 +786,8 @@ func (s *LogRotationService) rotateFile(ctx context.Context, policy *models.LogR
 		return nil, fmt.Errorf("failed to stat file: %w", err)
 	}
 
-	// Check size-based rotation criteria
-	if policy.SizeBasedRotation != nil {
-		if fileInfo.Size() < policy.SizeBasedRotation.MaxFileSize {
-			return nil, nil // File not large enough to rotate
-		}
-	}
-
-	// Check time-based rotation criteria
-	if policy.TimeBasedRotation != nil {
-		if time.Since(fileInfo.ModTime()) < policy.TimeBasedRotation.RotationInterval {
-			return nil, nil // File not old enough to rotate
-		}
+	if !fileDueForRotation(policy, fileInfo) {
+		return nil, nil
 	}
 
 	rotationInfo := &models.FileRotationInfo{
@@ -592,21 +820,36 @@ func (s *LogRotationService) rotateFile(ctx context.Context, policy *models.LogR
 		}
 	}
 
-	// Move the file to rotated name
-	if err := os.Rename(filePath, rotatedPath); err != nil {
-		return nil, fmt.Errorf("failed to rotate file: %w", err)
+	// Link the contents to the rotated name before swapping in a fresh log,
+	// so the log path never goes missing and the contents always survive
+	// under one of the two names
+	if err := os.Link(filePath, rotatedPath); err != nil {
+		// Hard links are unsupported here; fall back to a plain rename
+		if err := os.Rename(filePath, rotatedPath); err != nil {
+			return nil, fmt.Errorf("failed to rotate file: %w", err)
+		}
+	}
+	if err := s.checkpoint(stepLinked); err != nil {
+		return nil, err
 	}
 
-	// Create new empty file if needed
-	if _, err := os.Create(filePath); err != nil {
+	if err := replaceWithEmptyFile(filePath, fileInfo.Mode().Perm()); err != nil {
 		s.logger.Warn("Failed to create new log file",
 			logging.String("file", filePath),
 			logging.Err(err))
 	}
+	if err := s.checkpoint(stepReplaced); err != nil {
+		return nil, err
+	}
 
 	// Handle archival and compression
 	if policy.ArchivalPolicy != nil {
 		if err := s.archiveFile(ctx, policy.ArchivalPolicy, rotationInfo); err != nil {
+			// A corrupt archive fails this file; the rotated source is kept
+			var verifyErr *ArchiveVerificationError
+			if errors.As(err, &verifyErr) {
+				return nil, err
+			}
 			s.logger.Error("Failed to archive file", logging.Err(err))
 			// Don't fail the entire operation if archival fails
 		}
@@ -615,6 +858,26 @@ func (s *LogRotationService) rotateFile(ctx context.Context, policy *models.LogR
 	return rotationInfo, nil
 }
 
+// fileDueForRotation reports whether a file meets the policy's size and age
+// criteria for rotation
+func fileDueForRotation(policy *models.LogRotationPolicy, fileInfo os.FileInfo) bool {
+	// Check size-based rotation criteria
+	if policy.SizeBasedRotation != nil {
+		if fileInfo.Size() < policy.SizeBasedRotation.MaxFileSize {
+			return false // File not large enough to rotate
+		}
+	}
+
+	// Check time-based rotation criteria
+	if policy.TimeBasedRotation != nil {
+		if time.Since(fileInfo.ModTime()) < policy.TimeBasedRotation.RotationInterval {
+			return false // File not old enough to rotate
+		}
+	}
+
+	return true
+}
+
 func (s *LogRotationService) archiveFile(ctx context.Context, archivalPolicy *models.ArchivalPolicy, rotationInfo *models.FileRotationInfo) error {
 	if !archivalPolicy.EnableCompression {
 		return nil // No compression requested
@@ -626,9 +889,11 @@ func (s *LogRotationService) archiveFile(ctx context.Context, archivalPolicy *mo
 	}
 
 	// Generate archive file name
-	archiveName := filepath.Base(rotationInfo.RotatedPath) + ".gz"
+	format := archivalPolicy.Format()
+	archiveName := filepath.Base(rotationInfo.RotatedPath) + archiveExtension(format)
 	archivePath := filepath.Join(archivalPolicy.ArchiveLocation, archiveName)
 	rotationInfo.ArchivePath = archivePath
+	rotationInfo.CompressionFormat = format
 
 	if s.config.DryRunMode {
 		s.logger.Info("Dry run: would compress file",
@@ -637,17 +902,58 @@ func (s *LogRotationService) archiveFile(ctx context.Context, archivalPolicy *mo
 		return nil
 	}
 
+	// Compress into the temp directory so a crash never leaves a truncated
+	// archive at the final path
+	if err := os.MkdirAll(s.config.TempDirectory, 0755); err != nil {
+		return fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	tempFile, err := os.CreateTemp(s.config.TempDirectory, archiveName+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temp archive: %w", err)
+	}
+	tempPath := tempFile.Name()
+	tempFile.Close()
+	committed := false
+	defer func() {
+		if !committed {
+			os.Remove(tempPath)
+		}
+	}()
+
 	// Compress the file
-	compressedSize, err := s.compressFile(rotationInfo.RotatedPath, archivePath, archivalPolicy.CompressionLevel)
+	compressedSize, err := s.compressFile(rotationInfo.RotatedPath, tempPath, format, archivalPolicy.CompressionLevel)
 	if err != nil {
 		return fmt.Errorf("failed to compress file: %w", err)
 	}
+	if err := s.checkpoint(stepCompressed); err != nil {
+		return err
+	}
 
 	rotationInfo.CompressedSize = compressedSize
 	if rotationInfo.OriginalSize > 0 {
 		rotationInfo.CompressionRatio = float64(compressedSize) / float64(rotationInfo.OriginalSize)
 	}
 
+	// Verify the archive against the original before giving up the source
+	if err := s.verifyArchive(tempPath, format, rotationInfo.Checksum); err != nil {
+		s.logger.Error("Archive verification failed, keeping rotated file",
+			logging.String("source", rotationInfo.RotatedPath),
+			logging.String("archive", archivePath),
+			logging.Err(err))
+		return err
+	}
+	if err := s.checkpoint(stepVerified); err != nil {
+		return err
+	}
+
+	if err := s.moveFile(tempPath, archivePath); err != nil {
+		return fmt.Errorf("failed to move archive into place: %w", err)
+	}
+	committed = true
+	if err := s.checkpoint(stepArchived); err != nil {
+		return err
+	}
+
 	// Remove the rotated file after successful compression
 	if err := os.Remove(rotationInfo.RotatedPath); err != nil {
 		s.logger.Warn("Failed to remove rotated file after compression",
@@ -658,7 +964,7 @@ func (s *LogRotationService) archiveFile(ctx context.Context, archivalPolicy *mo
 	return nil
 }
 
-func (s *LogRotationService) compressFile(srcPath, dstPath string, compressionLevel int) (int64, error) {
+func (s *LogRotationService) compressFile(srcPath, dstPath string, format models.CompressionType, compressionLevel int) (int64, error) {
 	srcFile, err := os.Open(srcPath)
 	if err != nil {
 		return 0, fmt.Errorf("failed to open source file: %w", err)
@@ -671,21 +977,25 @@ func (s *LogRotationService) compressFile(srcPath, dstPath string, compressionLe
 	}
 	defer dstFile.Close()
 
-	// Create gzip writer with specified compression level
-	gzipWriter, err := gzip.NewWriterLevel(dstFile, compressionLevel)
+	// Create compression writer with specified compression level
+	compressor, err := newCompressionWriter(dstFile, format, compressionLevel)
 	if err != nil {
-		return 0, fmt.Errorf("failed to create gzip writer: %w", err)
+		return 0, err
 	}
-	defer gzipWriter.Close()
+	defer compressor.Close()
 
 	// Copy data with compression
 	buffer := make([]byte, s.config.IOBufferSize)
-	if _, err := io.CopyBuffer(gzipWriter, srcFile, buffer); err != nil {
+	if _, err := io.CopyBuffer(compressor, srcFile, buffer); err != nil {
 		return 0, fmt.Errorf("failed to compress file: %w", err)
 	}
 
-	if err := gzipWriter.Close(); err != nil {
-		return 0, fmt.Errorf("failed to close gzip writer: %w", err)
+	if err := compressor.Close(); err != nil {
+		return 0, fmt.Errorf("failed to close %s writer: %w", format, err)
+	}
+
+	if err := dstFile.Sync(); err != nil {
+		return 0, fmt.Errorf("failed to sync compressed file: %w", err)
 	}
 
 	// Get compressed file size
@@ -697,6 +1007,102 @@ func (s *LogRotationService) compressFile(srcPath, dstPath string, compressionLe
 	return fileInfo.Size(), nil
 }
 
+// verifyArchive decompresses the archive at path and checks its contents
+// hash to checksum
+func (s *LogRotationService) verifyArchive(path string, format models.CompressionType, checksum string) error {
+	if checksum == "" {
+		return fmt.Errorf("no checksum recorded to verify archive %s against", path)
+	}
+
+	archive, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer archive.Close()
+
+	reader, err := newDecompressionReader(archive, format)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	actual, err := checksumReader(reader)
+	if err != nil {
+		return fmt.Errorf("failed to read archive: %w", err)
+	}
+	if actual != checksum {
+		return &ArchiveVerificationError{ArchivePath: path, Expected: checksum, Actual: actual}
+	}
+
+	return nil
+}
+
+// archiveExtension returns the file extension for archives written in format
+func archiveExtension(format models.CompressionType) string {
+	switch format {
+	case models.CompressionZstd:
+		return ".zst"
+	case models.CompressionNone:
+		return ""
+	default:
+		return ".gz"
+	}
+}
+
+// nopWriteCloser passes writes through unchanged for uncompressed archives
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// newDecompressionReader wraps r in a reader that decompresses format
+func newDecompressionReader(r io.Reader, format models.CompressionType) (io.ReadCloser, error) {
+	switch format {
+	case models.CompressionGzip:
+		gzipReader, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create gzip reader: %w", err)
+		}
+		return gzipReader, nil
+	case models.CompressionZstd:
+		zstdReader, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create zstd reader: %w", err)
+		}
+		return zstdReader.IOReadCloser(), nil
+	case models.CompressionNone:
+		return io.NopCloser(r), nil
+	default:
+		return nil, fmt.Errorf("unsupported compression format %q", format)
+	}
+}
+
+// newCompressionWriter wraps w in a writer that compresses with format at
+// the given level
+func newCompressionWriter(w io.Writer, format models.CompressionType, level int) (io.WriteCloser, error) {
+	switch format {
+	case models.CompressionGzip:
+		gzipWriter, err := gzip.NewWriterLevel(w, level)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create gzip writer: %w", err)
+		}
+		return gzipWriter, nil
+	case models.CompressionZstd:
+		zstdWriter, err := zstd.NewWriter(w,
+			zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)),
+			zstd.WithEncoderConcurrency(1))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create zstd writer: %w", err)
+		}
+		return zstdWriter, nil
+	case models.CompressionNone:
+		return nopWriteCloser{w}, nil
+	default:
+		return nil, fmt.Errorf("unsupported compression format %q", format)
+	}
+}
+
 func (s *LogRotationService) performEmergencyCleanup(ctx context.Context) error {
 	s.logger.Warn("Performing emergency cleanup")
 
@@ -797,8 +1203,13 @@ func (s *LogRotationService) calculateFileChecksum(filePath string) (string, err
 	}
 	defer file.Close()
 
-	hash := md5.New()
-	if _, err := io.Copy(hash, file); err != nil {
+	return checksumReader(file)
+}
+
+// checksumReader returns the hex SHA-256 of everything read from r
+func checksumReader(r io.Reader) (string, error) {
+	hash := sha256.New()
+	if _, err := io.Copy(hash, r); err != nil {
 		return "", err
 	}
 
@@ -819,13 +1230,93 @@ func (s *LogRotationService) copyFile(src, dst string) error {
 	defer dstFile.Close()
 
 	buffer := make([]byte, s.config.IOBufferSize)
-	_, err = io.CopyBuffer(dstFile, srcFile, buffer)
-	return err
+	if _, err := io.CopyBuffer(dstFile, srcFile, buffer); err != nil {
+		return err
+	}
+	return dstFile.Sync()
 }
 
-// getCurrentDiskSpace is implemented in platform-specific files:
-// - rotation_service_unix.go (Linux/macOS)
-// - rotation_service_windows.go (Windows)
+// moveFile renames src to dst, copying through a temporary sibling of dst
+// when the two are on different filesystems
+func (s *LogRotationService) moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	tempPath := dst + ".tmp"
+	if err := s.copyFile(src, tempPath); err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+	if err := os.Rename(tempPath, dst); err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+	return os.Remove(src)
+}
+
+// replaceWithEmptyFile atomically swaps path for a new empty file with the
+// given permissions
+func replaceWithEmptyFile(path string, perm os.FileMode) error {
+	tempFile, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tempPath := tempFile.Name()
+
+	if err := tempFile.Chmod(perm); err != nil {
+		tempFile.Close()
+		os.Remove(tempPath)
+		return err
+	}
+	if err := tempFile.Close(); err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+	return nil
+}
+
+// checkpoint reports the end of a rotation step, failing if a test asked to
+// interrupt the rotation there
+func (s *LogRotationService) checkpoint(step rotationStep) error {
+	if s.failAt == nil {
+		return nil
+	}
+	return s.failAt(step)
+}
+
+// getCurrentDiskSpace returns disk space information for the working
+// directory's volume, or nil if it cannot be read
+func (s *LogRotationService) getCurrentDiskSpace() *models.DiskSpaceInfo {
+	info, err := currentDiskSpace()
+	if err != nil {
+		s.logger.Error("Failed to get disk space info", logging.Err(err))
+		return nil
+	}
+	return info
+}
+
+// newDiskSpaceInfo derives used space and the usage fraction (0-1) from a
+// volume's total and free bytes
+func newDiskSpaceInfo(totalSpace, freeSpace int64) *models.DiskSpaceInfo {
+	usedSpace := totalSpace - freeSpace
+	var usagePercent float64
+	if totalSpace > 0 {
+		usagePercent = float64(usedSpace) / float64(totalSpace)
+	}
+
+	return &models.DiskSpaceInfo{
+		TotalSpace:   totalSpace,
+		UsedSpace:    usedSpace,
+		FreeSpace:    freeSpace,
+		UsagePercent: usagePercent,
+		LastUpdated:  time.Now(),
+	}
+}
 
 func (s *LogRotationService) ensureDirectories() {
 	directories := []string{
@@ -857,9 +1348,14 @@ func (s *LogRotationService) updateExecutionError(ctx context.Context, execution
 func (s *LogRotationService) updatePolicyNextExecution(ctx context.Context, policy *models.LogRotationPolicy) {
 	policy.LastExecuted = time.Now()
 
-	// Simple implementation - add 24 hours for daily rotation
-	// In a real implementation, you'd parse the cron expression
-	policy.NextExecution = time.Now().Add(24 * time.Hour)
+	next, err := nextPolicyExecution(policy.ExecutionSchedule, policy.LastExecuted)
+	if err != nil {
+		s.logger.Warn("Invalid rotation policy schedule, running daily instead",
+			logging.Int("policy_id", policy.ID),
+			logging.String("schedule", policy.ExecutionSchedule),
+			logging.Err(err))
+	}
+	policy.NextExecution = next
 
 	if err := s.repos.LogRotationPolicy.Update(ctx, policy); err != nil {
 		s.logger.Error("Failed to update policy execution times",
@@ -873,10 +1369,13 @@ func (s *LogRotationService) updateStats(policyID int, execution *models.LogRota
 	defer s.statsMu.Unlock()
 
 	// Update global stats
+	s.executions++
 	if success {
 		s.stats.TotalFilesRotated += int64(execution.FilesRotated)
 		s.stats.TotalBytesFreed += execution.BytesFreed
 		s.stats.TotalBytesCompressed += execution.BytesCompressed
+	} else {
+		s.failedExecutions++
 	}
 
 	s.stats.LastRotationTime = execution.ExecutionTime
@@ -0,0 +1,463 @@
+package service
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+
+	"parental-control/internal/logging"
+	"parental-control/internal/models"
+)
+
+// stubRotationExecutionRepo serves fixed rotation stats
+type stubRotationExecutionRepo struct {
+	models.LogRotationExecutionRepository
+	executions []models.LogRotationExecution
+}
+
+func (r *stubRotationExecutionRepo) GetStats(ctx context.Context) (*models.RotationStats, error) {
+	return &models.RotationStats{
+		PolicyStats:      make(map[int]*models.PolicyRotationStats),
+		RecentExecutions: r.executions,
+	}, nil
+}
+
+func TestLogRotationService_RecentRuns(t *testing.T) {
+	config := DefaultLogRotationConfig()
+	config.TempDirectory = t.TempDir()
+	config.ArchiveDirectory = t.TempDir()
+	config.EnableDiskMonitoring = false
+
+	executionRepo := &stubRotationExecutionRepo{
+		executions: []models.LogRotationExecution{
+			{
+				PolicyID:      3,
+				ExecutionTime: time.Now(),
+				Status:        models.ExecutionStatusCompleted,
+				TriggerReason: models.TriggerSize,
+				FilesRotated:  2,
+				FilesDeleted:  1,
+				BytesFreed:    1 << 20,
+				Duration:      500 * time.Millisecond,
+			},
+		},
+	}
+	repos := &models.RepositoryManager{LogRotationExecution: executionRepo}
+	s := NewLogRotationService(repos, logging.NewDefault(), config)
+
+	stats := s.GetStats()
+	if len(stats.RecentExecutions) != 1 {
+		t.Fatalf("Expected recent executions to be kept, got %d", len(stats.RecentExecutions))
+	}
+	if len(stats.RecentRuns) != 1 {
+		t.Fatalf("Expected 1 recent run, got %d", len(stats.RecentRuns))
+	}
+
+	run := stats.RecentRuns[0]
+	if run.PolicyID != 3 || run.Trigger != "size_limit" || run.Status != models.ExecutionStatusCompleted ||
+		run.ItemsAffected != 3 || run.BytesFreed != 1<<20 || run.Duration != 500*time.Millisecond {
+		t.Errorf("Unexpected run summary: %+v", run)
+	}
+}
+
+func TestLogRotationService_ArchiveFormats(t *testing.T) {
+	content := bytes.Repeat([]byte("blocked example.com\n"), 500)
+
+	tests := []struct {
+		format    models.CompressionType
+		extension string
+		decode    func(io.Reader) (io.Reader, error)
+	}{
+		{models.CompressionGzip, ".gz", func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) }},
+		{models.CompressionZstd, ".zst", func(r io.Reader) (io.Reader, error) { return zstd.NewReader(r) }},
+		{models.CompressionNone, "", func(r io.Reader) (io.Reader, error) { return r, nil }},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			config := DefaultLogRotationConfig()
+			config.TempDirectory = t.TempDir()
+			config.ArchiveDirectory = t.TempDir()
+			config.EnableDiskMonitoring = false
+			s := NewLogRotationService(&models.RepositoryManager{}, logging.NewDefault(), config)
+
+			rotatedPath := filepath.Join(t.TempDir(), "app.log.1")
+			if err := os.WriteFile(rotatedPath, content, 0644); err != nil {
+				t.Fatalf("Failed to write rotated file: %v", err)
+			}
+
+			policy := &models.ArchivalPolicy{
+				EnableCompression: true,
+				CompressionFormat: tt.format,
+				CompressionLevel:  3,
+				ArchiveLocation:   config.ArchiveDirectory,
+			}
+			checksum, err := s.calculateFileChecksum(rotatedPath)
+			if err != nil {
+				t.Fatalf("Failed to checksum rotated file: %v", err)
+			}
+			info := &models.FileRotationInfo{RotatedPath: rotatedPath, OriginalSize: int64(len(content)), Checksum: checksum}
+			if err := s.archiveFile(context.Background(), policy, info); err != nil {
+				t.Fatalf("Failed to archive file: %v", err)
+			}
+
+			if info.CompressionFormat != tt.format || info.ArchivePath != filepath.Join(config.ArchiveDirectory, "app.log.1"+tt.extension) {
+				t.Errorf("Unexpected archive info: %+v", info)
+			}
+
+			archive, err := os.Open(info.ArchivePath)
+			if err != nil {
+				t.Fatalf("Failed to open archive: %v", err)
+			}
+			defer archive.Close()
+			reader, err := tt.decode(archive)
+			if err != nil {
+				t.Fatalf("Failed to read archive: %v", err)
+			}
+			decoded, err := io.ReadAll(reader)
+			if err != nil || !bytes.Equal(decoded, content) {
+				t.Errorf("Expected the archive to round trip, got %d bytes (%v)", len(decoded), err)
+			}
+			if _, err := os.Stat(rotatedPath); !os.IsNotExist(err) {
+				t.Errorf("Expected the verified source to be removed, got %v", err)
+			}
+		})
+	}
+}
+
+func TestLogRotationService_ArchiveVerificationMismatch(t *testing.T) {
+	config := DefaultLogRotationConfig()
+	config.TempDirectory = t.TempDir()
+	config.ArchiveDirectory = t.TempDir()
+	config.EnableDiskMonitoring = false
+	s := NewLogRotationService(&models.RepositoryManager{}, logging.NewDefault(), config)
+
+	rotatedPath := filepath.Join(t.TempDir(), "app.log.1")
+	if err := os.WriteFile(rotatedPath, []byte("allowed example.com\n"), 0644); err != nil {
+		t.Fatalf("Failed to write rotated file: %v", err)
+	}
+
+	policy := &models.ArchivalPolicy{
+		EnableCompression: true,
+		CompressionFormat: models.CompressionGzip,
+		CompressionLevel:  6,
+		ArchiveLocation:   config.ArchiveDirectory,
+	}
+	// The recorded checksum belongs to different contents
+	checksum, _ := checksumReader(bytes.NewReader([]byte("something else")))
+	info := &models.FileRotationInfo{RotatedPath: rotatedPath, Checksum: checksum}
+
+	err := s.archiveFile(context.Background(), policy, info)
+	var verifyErr *ArchiveVerificationError
+	if !errors.As(err, &verifyErr) {
+		t.Fatalf("Expected a verification error, got %v", err)
+	}
+	if _, err := os.Stat(rotatedPath); err != nil {
+		t.Errorf("Expected the rotated source to be kept, got %v", err)
+	}
+	if _, err := os.Stat(info.ArchivePath); !os.IsNotExist(err) {
+		t.Errorf("Expected the unverified archive to be removed, got %v", err)
+	}
+}
+
+func TestLogRotationService_CrashSafeRotation(t *testing.T) {
+	content := bytes.Repeat([]byte("blocked example.com\n"), 200)
+	errCrash := errors.New("crash")
+
+	// An empty step runs the rotation to completion
+	for _, step := range []rotationStep{stepLinked, stepReplaced, stepCompressed, stepVerified, stepArchived, ""} {
+		name := string(step)
+		if name == "" {
+			name = "completed"
+		}
+		t.Run(name, func(t *testing.T) {
+			config := DefaultLogRotationConfig()
+			config.TempDirectory = t.TempDir()
+			config.ArchiveDirectory = t.TempDir()
+			config.EnableDiskMonitoring = false
+			config.BackupOriginals = false
+			s := NewLogRotationService(&models.RepositoryManager{}, logging.NewDefault(), config)
+			s.failAt = func(at rotationStep) error {
+				if at == step {
+					return errCrash
+				}
+				return nil
+			}
+
+			logDir := t.TempDir()
+			logPath := filepath.Join(logDir, "app.log")
+			if err := os.WriteFile(logPath, content, 0640); err != nil {
+				t.Fatalf("Failed to write log: %v", err)
+			}
+
+			policy := &models.LogRotationPolicy{
+				ArchivalPolicy: &models.ArchivalPolicy{
+					EnableCompression: true,
+					CompressionFormat: models.CompressionGzip,
+					CompressionLevel:  6,
+					ArchiveLocation:   config.ArchiveDirectory,
+				},
+			}
+			s.rotateFile(context.Background(), policy, logPath)
+
+			// The log path always exists
+			if _, err := os.Stat(logPath); err != nil {
+				t.Fatalf("Expected the log file to exist, got %v", err)
+			}
+
+			// Anything at a final archive path is complete
+			archives, _ := filepath.Glob(filepath.Join(config.ArchiveDirectory, "*"))
+			archived := false
+			for _, archive := range archives {
+				file, err := os.Open(archive)
+				if err != nil {
+					t.Fatalf("Failed to open archive: %v", err)
+				}
+				reader, err := gzip.NewReader(file)
+				if err != nil {
+					t.Fatalf("Expected a complete archive at %s: %v", archive, err)
+				}
+				decoded, err := io.ReadAll(reader)
+				file.Close()
+				if err != nil || !bytes.Equal(decoded, content) {
+					t.Fatalf("Expected a complete archive at %s, got %d bytes (%v)", archive, len(decoded), err)
+				}
+				archived = true
+			}
+
+			// The contents survive in the log, the rotated file or the archive
+			rotated, _ := filepath.Glob(filepath.Join(logDir, "app.log.*"))
+			survived := archived
+			for _, path := range append(rotated, logPath) {
+				if data, err := os.ReadFile(path); err == nil && bytes.Equal(data, content) {
+					survived = true
+				}
+			}
+			if !survived {
+				t.Error("Expected the log contents to survive the interrupted rotation")
+			}
+
+			if step == "" {
+				if data, _ := os.ReadFile(logPath); len(data) != 0 || len(rotated) != 0 || !archived {
+					t.Errorf("Expected a fresh log and only the archive, got %d bytes and rotated files %v", len(data), rotated)
+				}
+				if info, err := os.Stat(logPath); err == nil && info.Mode().Perm() != 0640 {
+					t.Errorf("Expected the fresh log to keep its permissions, got %v", info.Mode().Perm())
+				}
+			}
+		})
+	}
+}
+
+// stubRotationPolicyRepo serves a fixed set of policies
+type stubRotationPolicyRepo struct {
+	models.LogRotationPolicyRepository
+	policies []models.LogRotationPolicy
+}
+
+func (r *stubRotationPolicyRepo) GetByPriority(ctx context.Context) ([]models.LogRotationPolicy, error) {
+	return r.policies, nil
+}
+
+func (r *stubRotationPolicyRepo) Update(ctx context.Context, policy *models.LogRotationPolicy) error {
+	return nil
+}
+
+// slowRotationExecutionRepo tracks how many executions run at once and
+// records the finished ones
+type slowRotationExecutionRepo struct {
+	models.LogRotationExecutionRepository
+	delay      time.Duration
+	active     int32
+	maxActive  int32
+	mu         sync.Mutex
+	executions []models.LogRotationExecution
+}
+
+func (r *slowRotationExecutionRepo) Create(ctx context.Context, execution *models.LogRotationExecution) error {
+	current := atomic.AddInt32(&r.active, 1)
+	for {
+		max := atomic.LoadInt32(&r.maxActive)
+		if current <= max || atomic.CompareAndSwapInt32(&r.maxActive, max, current) {
+			break
+		}
+	}
+	time.Sleep(r.delay)
+	return nil
+}
+
+func (r *slowRotationExecutionRepo) Update(ctx context.Context, execution *models.LogRotationExecution) error {
+	atomic.AddInt32(&r.active, -1)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.executions = append(r.executions, *execution)
+	return nil
+}
+
+func (r *slowRotationExecutionRepo) finished() []models.LogRotationExecution {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]models.LogRotationExecution(nil), r.executions...)
+}
+
+func TestLogRotationService_ConcurrencyLimit(t *testing.T) {
+	config := DefaultLogRotationConfig()
+	config.TempDirectory = t.TempDir()
+	config.ArchiveDirectory = t.TempDir()
+	config.EnableDiskMonitoring = false
+	config.MaxConcurrentRotations = 2
+
+	policies := make([]models.LogRotationPolicy, 6)
+	for i := range policies {
+		policies[i] = models.LogRotationPolicy{
+			ID:             i + 1,
+			Name:           "policy",
+			Enabled:        true,
+			TargetLogFiles: []string{filepath.Join(t.TempDir(), "*.log")},
+			NextExecution:  time.Now().Add(-time.Minute),
+		}
+	}
+	executionRepo := &slowRotationExecutionRepo{delay: 50 * time.Millisecond}
+	repos := &models.RepositoryManager{
+		LogRotationPolicy:    &stubRotationPolicyRepo{policies: policies},
+		LogRotationExecution: executionRepo,
+	}
+	s := NewLogRotationService(repos, logging.NewDefault(), config)
+
+	s.checkAndExecutePolicies(context.Background())
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(executionRepo.finished()) < 6 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if finished := len(executionRepo.finished()); finished != 6 {
+		t.Fatalf("Expected 6 executions, got %d", finished)
+	}
+	if max := atomic.LoadInt32(&executionRepo.maxActive); max > 2 {
+		t.Fatalf("Expected at most 2 concurrent rotations, observed %d", max)
+	}
+}
+
+func TestLogRotationService_FileLocks(t *testing.T) {
+	config := DefaultLogRotationConfig()
+	config.EnableDiskMonitoring = false
+	s := NewLogRotationService(&models.RepositoryManager{}, logging.NewDefault(), config)
+
+	dir := t.TempDir()
+	unlockA := s.lockFile(filepath.Join(dir, "a.log"))
+
+	// Another file is not held up by the rotation of a.log
+	locked := make(chan func())
+	go func() { locked <- s.lockFile(filepath.Join(dir, "b.log")) }()
+	select {
+	case unlockB := <-locked:
+		unlockB()
+	case <-time.After(time.Second):
+		t.Fatal("Expected b.log to be lockable while a.log is rotating")
+	}
+
+	// The same file waits, however its path is spelled
+	go func() { locked <- s.lockFile(filepath.Join(dir, ".", "a.log")) }()
+	select {
+	case <-locked:
+		t.Fatal("Expected a.log to stay locked")
+	case <-time.After(50 * time.Millisecond):
+	}
+	unlockA()
+	select {
+	case unlockA = <-locked:
+		unlockA()
+	case <-time.After(time.Second):
+		t.Fatal("Expected a.log to be lockable once released")
+	}
+
+	s.fileLocksMu.Lock()
+	defer s.fileLocksMu.Unlock()
+	if len(s.fileLocks) != 0 {
+		t.Errorf("Expected released locks to be forgotten, got %d", len(s.fileLocks))
+	}
+}
+
+func TestLogRotationService_SafetyThreshold(t *testing.T) {
+	tests := []struct {
+		name        string
+		trigger     models.RotationTrigger
+		largeFiles  int
+		expectSkip  bool
+		expectFiles int
+	}{
+		{"bulk rotation skipped", models.TriggerScheduled, 3, true, 0},
+		{"within threshold", models.TriggerScheduled, 1, false, 1},
+		{"emergency ignores threshold", models.TriggerEmergency, 3, false, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultLogRotationConfig()
+			config.TempDirectory = t.TempDir()
+			config.ArchiveDirectory = t.TempDir()
+			config.EnableDiskMonitoring = false
+			config.BackupOriginals = false
+			config.SafetyThreshold = 0.5
+
+			// Large files are due for rotation, small ones are not
+			logDir := t.TempDir()
+			for i := 0; i < 3; i++ {
+				size := 10
+				if i < tt.largeFiles {
+					size = 1000
+				}
+				path := filepath.Join(logDir, fmt.Sprintf("app%d.log", i))
+				if err := os.WriteFile(path, bytes.Repeat([]byte("x"), size), 0644); err != nil {
+					t.Fatalf("Failed to write log: %v", err)
+				}
+			}
+
+			executionRepo := &slowRotationExecutionRepo{}
+			repos := &models.RepositoryManager{
+				LogRotationPolicy:    &stubRotationPolicyRepo{},
+				LogRotationExecution: executionRepo,
+			}
+			s := NewLogRotationService(repos, logging.NewDefault(), config)
+
+			policy := &models.LogRotationPolicy{
+				ID:                1,
+				Name:              "policy",
+				Enabled:           true,
+				TargetLogFiles:    []string{filepath.Join(logDir, "*.log")},
+				SizeBasedRotation: &models.SizeBasedRotation{MaxFileSize: 500},
+			}
+			execution, err := s.executePolicy(context.Background(), policy, tt.trigger)
+
+			var safetyErr *RotationSafetyError
+			if errors.As(err, &safetyErr) != tt.expectSkip {
+				t.Fatalf("Expected skip %v, got %v", tt.expectSkip, err)
+			}
+			if execution.FilesRotated != tt.expectFiles {
+				t.Errorf("Expected %d files rotated, got %d", tt.expectFiles, execution.FilesRotated)
+			}
+
+			details, err := execution.GetDetailsMap()
+			if err != nil {
+				t.Fatalf("Failed to read details: %v", err)
+			}
+			if skipped, _ := details["skipped_due_to_safety"].(bool); skipped != tt.expectSkip {
+				t.Errorf("Expected skipped_due_to_safety %v, got %v", tt.expectSkip, details)
+			}
+			if tt.expectSkip && execution.Status != models.ExecutionStatusFailed {
+				t.Errorf("Expected the skipped execution to be recorded as failed, got %s", execution.Status)
+			}
+		})
+	}
+}
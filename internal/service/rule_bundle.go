@@ -0,0 +1,650 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"parental-control/internal/logging"
+	"parental-control/internal/models"
+)
+
+// RuleBundleFormat identifies rule bundle files
+const RuleBundleFormat = "parental-control-rules"
+
+// RuleBundleVersion is the bundle schema this release writes and reads. Bump
+// it when older releases would misread a bundle, so that they refuse it.
+const RuleBundleVersion = 1
+
+// ErrIncompatibleRuleBundle is returned for files that are not rule bundles
+// or were written by a newer, incompatible release
+var ErrIncompatibleRuleBundle = errors.New("incompatible rule bundle")
+
+// RuleBundle is a portable copy of the rule configuration: every list with
+// its entries, time rules and quota rules. Lists are matched by name, so a
+// bundle holds no database IDs and can be imported on another machine.
+type RuleBundle struct {
+	Format     string           `json:"format"`
+	Version    int              `json:"version"`
+	ExportedAt time.Time        `json:"exported_at"`
+	Lists      []RuleBundleList `json:"lists"`
+}
+
+// RuleBundleList is a list in a rule bundle
+type RuleBundleList struct {
+	Name        string                `json:"name"`
+	Type        models.ListType       `json:"type"`
+	Description string                `json:"description,omitempty"`
+	Label       string                `json:"label,omitempty"`
+	Notes       string                `json:"notes,omitempty"`
+	Enabled     bool                  `json:"enabled"`
+	Entries     []RuleBundleEntry     `json:"entries"`
+	TimeRules   []RuleBundleTimeRule  `json:"time_rules,omitempty"`
+	QuotaRules  []RuleBundleQuotaRule `json:"quota_rules,omitempty"`
+}
+
+// RuleBundleEntry is a list entry in a rule bundle
+type RuleBundleEntry struct {
+	EntryType   models.EntryType   `json:"entry_type"`
+	Pattern     string             `json:"pattern"`
+	PatternType models.PatternType `json:"pattern_type"`
+	Description string             `json:"description,omitempty"`
+	Label       string             `json:"label,omitempty"`
+	Notes       string             `json:"notes,omitempty"`
+	Source      models.EntrySource `json:"source,omitempty"`
+	Enabled     bool               `json:"enabled"`
+}
+
+// RuleBundleTimeRule is a time rule in a rule bundle
+type RuleBundleTimeRule struct {
+	Name       string          `json:"name"`
+	RuleType   models.RuleType `json:"rule_type"`
+	DaysOfWeek []int           `json:"days_of_week"`
+	StartTime  string          `json:"start_time"`
+	EndTime    string          `json:"end_time"`
+	Timezone   string          `json:"timezone,omitempty"`
+	Enabled    bool            `json:"enabled"`
+}
+
+// RuleBundleQuotaRule is a quota rule in a rule bundle
+type RuleBundleQuotaRule struct {
+	Name                string                `json:"name"`
+	QuotaType           models.QuotaType      `json:"quota_type"`
+	LimitSeconds        int                   `json:"limit_seconds"`
+	CarryOver           models.QuotaCarryOver `json:"carry_over,omitempty"`
+	MaxCarryOverSeconds int                   `json:"carry_over_max_seconds,omitempty"`
+	Enabled             bool                  `json:"enabled"`
+}
+
+// RuleBundleFilename names an exported bundle after when it was taken
+func RuleBundleFilename(exportedAt time.Time) string {
+	return fmt.Sprintf("parental-control-rules_%s.json", exportedAt.UTC().Format("20060102T150405Z"))
+}
+
+// ReadRuleBundle decodes a rule bundle and checks that this release can
+// import it
+func ReadRuleBundle(r io.Reader) (*RuleBundle, error) {
+	var bundle RuleBundle
+	if err := json.NewDecoder(r).Decode(&bundle); err != nil {
+		return nil, fmt.Errorf("invalid rule bundle: %w", err)
+	}
+
+	switch {
+	case bundle.Format != RuleBundleFormat:
+		return nil, fmt.Errorf("%w: not a rule bundle (format %q)", ErrIncompatibleRuleBundle, bundle.Format)
+	case bundle.Version < 1:
+		return nil, fmt.Errorf("%w: missing bundle version", ErrIncompatibleRuleBundle)
+	case bundle.Version > RuleBundleVersion:
+		return nil, fmt.Errorf("%w: bundle version %d is newer than this release supports (%d)",
+			ErrIncompatibleRuleBundle, bundle.Version, RuleBundleVersion)
+	}
+	return &bundle, nil
+}
+
+// RuleImportMode says what happens to the existing configuration on import
+type RuleImportMode string
+
+const (
+	// RuleImportMerge adds the bundle's lists, entries and rules to the
+	// existing ones. Lists with the same name are merged; rules whose names
+	// are already taken keep their current settings.
+	RuleImportMerge RuleImportMode = "merge"
+	// RuleImportReplace deletes every existing list, with its entries and
+	// rules, before importing
+	RuleImportReplace RuleImportMode = "replace"
+)
+
+// ParseRuleImportMode parses an import mode, defaulting to merge
+func ParseRuleImportMode(mode string) (RuleImportMode, error) {
+	switch RuleImportMode(strings.ToLower(mode)) {
+	case "", RuleImportMerge:
+		return RuleImportMerge, nil
+	case RuleImportReplace:
+		return RuleImportReplace, nil
+	default:
+		return "", fmt.Errorf("invalid import mode %q (expected merge or replace)", mode)
+	}
+}
+
+// RuleBundleError lists the problems that stopped a bundle from being
+// imported. Nothing is changed when a bundle has problems.
+type RuleBundleError struct {
+	Problems []string
+}
+
+func (e *RuleBundleError) Error() string {
+	return "invalid rule bundle: " + strings.Join(e.Problems, "; ")
+}
+
+// RuleImportSkip is part of a bundle that was not imported because it
+// conflicts with the existing configuration
+type RuleImportSkip struct {
+	List     string `json:"list"`
+	RuleType string `json:"rule_type"` // "list", "time_rule", "quota_rule"
+	Name     string `json:"name,omitempty"`
+	Reason   string `json:"reason"`
+}
+
+// RuleImportResult summarizes a bundle import
+type RuleImportResult struct {
+	Mode         RuleImportMode `json:"mode"`
+	ListsCreated int            `json:"lists_created"`
+	ListsMerged  int            `json:"lists_merged"`
+	ListsDeleted int            `json:"lists_deleted"`
+	// EntriesSkipped counts entries whose pattern was already in the list
+	EntriesCreated    int              `json:"entries_created"`
+	EntriesSkipped    int              `json:"entries_skipped"`
+	TimeRulesCreated  int              `json:"time_rules_created"`
+	QuotaRulesCreated int              `json:"quota_rules_created"`
+	Skipped           []RuleImportSkip `json:"skipped,omitempty"`
+	// Validation reports conflicts in the configuration after the import
+	Validation *ValidationResult `json:"validation,omitempty"`
+	Duration   time.Duration     `json:"duration"`
+}
+
+// RuleBundleService exports and imports the rule configuration as a bundle
+type RuleBundleService struct {
+	repos        *models.RepositoryManager
+	listService  *ListManagementService
+	entryService *EntryManagementService
+	timeService  *TimeWindowService
+	quotaService *QuotaService
+	logger       logging.Logger
+}
+
+// NewRuleBundleService creates a new rule bundle service
+func NewRuleBundleService(repos *models.RepositoryManager, logger logging.Logger) *RuleBundleService {
+	return &RuleBundleService{
+		repos:        repos,
+		listService:  NewListManagementService(repos, logger),
+		entryService: NewEntryManagementService(repos, logger),
+		timeService:  NewTimeWindowService(repos, logger),
+		quotaService: NewQuotaService(repos, logger),
+		logger:       logger,
+	}
+}
+
+// Export copies every list with its entries, time rules and quota rules into
+// a bundle. Time and quota rules are left out when they are not stored.
+func (s *RuleBundleService) Export(ctx context.Context) (*RuleBundle, error) {
+	lists, err := s.repos.List.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lists: %w", err)
+	}
+
+	bundle := &RuleBundle{
+		Format:     RuleBundleFormat,
+		Version:    RuleBundleVersion,
+		ExportedAt: time.Now().UTC(),
+		Lists:      make([]RuleBundleList, 0, len(lists)),
+	}
+	entryCount := 0
+
+	for _, list := range lists {
+		bundled := RuleBundleList{
+			Name:        list.Name,
+			Type:        list.Type,
+			Description: list.Description,
+			Label:       list.Label,
+			Notes:       list.Notes,
+			Enabled:     list.Enabled,
+		}
+
+		entries, err := s.repos.ListEntry.GetByListID(ctx, list.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get entries for list %q: %w", list.Name, err)
+		}
+		bundled.Entries = make([]RuleBundleEntry, 0, len(entries))
+		for _, entry := range entries {
+			bundled.Entries = append(bundled.Entries, RuleBundleEntry{
+				EntryType:   entry.EntryType,
+				Pattern:     entry.Pattern,
+				PatternType: entry.PatternType,
+				Description: entry.Description,
+				Label:       entry.Label,
+				Notes:       entry.Notes,
+				Source:      entry.Source,
+				Enabled:     entry.Enabled,
+			})
+		}
+		entryCount += len(entries)
+
+		if s.repos.TimeRule != nil {
+			rules, err := s.repos.TimeRule.GetByListID(ctx, list.ID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get time rules for list %q: %w", list.Name, err)
+			}
+			for _, rule := range rules {
+				bundled.TimeRules = append(bundled.TimeRules, RuleBundleTimeRule{
+					Name:       rule.Name,
+					RuleType:   rule.RuleType,
+					DaysOfWeek: rule.DaysOfWeek,
+					StartTime:  rule.StartTime,
+					EndTime:    rule.EndTime,
+					Timezone:   rule.Timezone,
+					Enabled:    rule.Enabled,
+				})
+			}
+		}
+
+		if s.repos.QuotaRule != nil {
+			rules, err := s.repos.QuotaRule.GetByListID(ctx, list.ID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get quota rules for list %q: %w", list.Name, err)
+			}
+			for _, rule := range rules {
+				bundled.QuotaRules = append(bundled.QuotaRules, RuleBundleQuotaRule{
+					Name:                rule.Name,
+					QuotaType:           rule.QuotaType,
+					LimitSeconds:        rule.LimitSeconds,
+					CarryOver:           rule.CarryOver,
+					MaxCarryOverSeconds: rule.MaxCarryOverSeconds,
+					Enabled:             rule.Enabled,
+				})
+			}
+		}
+
+		bundle.Lists = append(bundle.Lists, bundled)
+	}
+
+	s.logger.Info("Rule configuration exported",
+		logging.Int("lists", len(bundle.Lists)),
+		logging.Int("entries", entryCount))
+
+	return bundle, nil
+}
+
+// Import validates a bundle and applies it. Nothing is changed if any part of
+// the bundle is invalid; the problems are returned as a *RuleBundleError.
+// Parts that conflict with the existing configuration in merge mode are
+// skipped and reported, and the resulting configuration is checked with the
+// RuleValidationService. An error part way through leaves what was imported
+// before it; the result says how far the import got.
+func (s *RuleBundleService) Import(ctx context.Context, bundle *RuleBundle, mode RuleImportMode) (*RuleImportResult, error) {
+	start := time.Now()
+	result := &RuleImportResult{Mode: mode}
+
+	if problems := s.validateBundle(bundle); len(problems) > 0 {
+		return nil, &RuleBundleError{Problems: problems}
+	}
+
+	s.logger.Info("Importing rule bundle",
+		logging.String("mode", string(mode)),
+		logging.Int("lists", len(bundle.Lists)))
+
+	if mode == RuleImportReplace {
+		existing, err := s.repos.List.GetAll(ctx)
+		if err != nil {
+			return result, fmt.Errorf("failed to get lists: %w", err)
+		}
+		for _, list := range existing {
+			if err := s.listService.DeleteList(ctx, list.ID); err != nil {
+				return result, fmt.Errorf("failed to delete list %q: %w", list.Name, err)
+			}
+			result.ListsDeleted++
+		}
+	}
+
+	for i := range bundle.Lists {
+		if err := s.importList(ctx, &bundle.Lists[i], mode, result); err != nil {
+			return result, err
+		}
+	}
+
+	validation, err := NewRuleValidationService(s.repos, s.logger).ValidateAllRules(ctx)
+	if err != nil {
+		s.logger.Warn("Failed to validate imported rules", logging.Err(err))
+	}
+	result.Validation = validation
+	result.Duration = time.Since(start)
+
+	s.logger.Info("Rule bundle imported",
+		logging.String("mode", string(mode)),
+		logging.Int("lists_created", result.ListsCreated),
+		logging.Int("lists_merged", result.ListsMerged),
+		logging.Int("lists_deleted", result.ListsDeleted),
+		logging.Int("entries_created", result.EntriesCreated),
+		logging.Int("skipped", len(result.Skipped)),
+		logging.Duration("duration", result.Duration))
+
+	return result, nil
+}
+
+// importList creates or merges one list of a bundle
+func (s *RuleBundleService) importList(ctx context.Context, bundled *RuleBundleList, mode RuleImportMode, result *RuleImportResult) error {
+	var list *models.List
+	if mode == RuleImportMerge {
+		if existing, err := s.repos.List.GetByName(ctx, bundled.Name); err == nil {
+			if existing.Type != bundled.Type {
+				result.Skipped = append(result.Skipped, RuleImportSkip{
+					List:     bundled.Name,
+					RuleType: "list",
+					Reason:   fmt.Sprintf("an existing %s has this name; the bundle's list is a %s", existing.Type, bundled.Type),
+				})
+				return nil
+			}
+			list = existing
+			result.ListsMerged++
+		}
+	}
+
+	if list == nil {
+		created, err := s.listService.CreateList(ctx, CreateListRequest{
+			Name:        bundled.Name,
+			Type:        bundled.Type,
+			Description: bundled.Description,
+			Label:       bundled.Label,
+			Notes:       bundled.Notes,
+			Enabled:     bundled.Enabled,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create list %q: %w", bundled.Name, err)
+		}
+		list = created
+		result.ListsCreated++
+	}
+
+	// Patterns already in the list are skipped by CreateBatch
+	now := time.Now()
+	entries := make([]models.ListEntry, 0, min(len(bundled.Entries), DefaultImportBatchSize))
+	flush := func() error {
+		inserted, err := s.repos.ListEntry.CreateBatch(ctx, entries)
+		if err != nil {
+			return fmt.Errorf("failed to import entries into list %q: %w", bundled.Name, err)
+		}
+		result.EntriesCreated += inserted
+		result.EntriesSkipped += len(entries) - inserted
+		entries = entries[:0]
+		return nil
+	}
+	for _, entry := range bundled.Entries {
+		source := entry.Source
+		if source == "" {
+			source = models.EntrySourceManual
+		}
+		entries = append(entries, models.ListEntry{
+			ListID:      list.ID,
+			EntryType:   entry.EntryType,
+			Pattern:     strings.TrimSpace(entry.Pattern),
+			PatternType: entry.PatternType,
+			Description: entry.Description,
+			Label:       entry.Label,
+			Notes:       entry.Notes,
+			Source:      source,
+			Enabled:     entry.Enabled,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		})
+		if len(entries) == DefaultImportBatchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if len(entries) > 0 {
+		if err := flush(); err != nil {
+			return err
+		}
+	}
+
+	if err := s.importTimeRules(ctx, list, bundled, result); err != nil {
+		return err
+	}
+	return s.importQuotaRules(ctx, list, bundled, result)
+}
+
+// importTimeRules creates a bundled list's time rules, skipping names the
+// list already uses
+func (s *RuleBundleService) importTimeRules(ctx context.Context, list *models.List, bundled *RuleBundleList, result *RuleImportResult) error {
+	if len(bundled.TimeRules) == 0 {
+		return nil
+	}
+	if s.repos.TimeRule == nil {
+		for _, rule := range bundled.TimeRules {
+			result.Skipped = append(result.Skipped, RuleImportSkip{
+				List: list.Name, RuleType: "time_rule", Name: rule.Name,
+				Reason: "time rules are not stored by this installation",
+			})
+		}
+		return nil
+	}
+
+	existing, err := s.repos.TimeRule.GetByListID(ctx, list.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get time rules for list %q: %w", list.Name, err)
+	}
+	taken := make(map[string]bool, len(existing))
+	for _, rule := range existing {
+		taken[rule.Name] = true
+	}
+
+	for _, rule := range bundled.TimeRules {
+		if taken[rule.Name] {
+			result.Skipped = append(result.Skipped, RuleImportSkip{
+				List: list.Name, RuleType: "time_rule", Name: rule.Name,
+				Reason: "the list already has a time rule with this name; it was kept",
+			})
+			continue
+		}
+		if _, err := s.timeService.CreateTimeRule(ctx, CreateTimeRuleRequest{
+			ListID:     list.ID,
+			Name:       rule.Name,
+			RuleType:   rule.RuleType,
+			DaysOfWeek: rule.DaysOfWeek,
+			StartTime:  rule.StartTime,
+			EndTime:    rule.EndTime,
+			Timezone:   rule.Timezone,
+			Enabled:    rule.Enabled,
+		}); err != nil {
+			return fmt.Errorf("failed to create time rule %q in list %q: %w", rule.Name, list.Name, err)
+		}
+		result.TimeRulesCreated++
+	}
+	return nil
+}
+
+// importQuotaRules creates a bundled list's quota rules, skipping names the
+// list already uses
+func (s *RuleBundleService) importQuotaRules(ctx context.Context, list *models.List, bundled *RuleBundleList, result *RuleImportResult) error {
+	if len(bundled.QuotaRules) == 0 {
+		return nil
+	}
+	if s.repos.QuotaRule == nil {
+		for _, rule := range bundled.QuotaRules {
+			result.Skipped = append(result.Skipped, RuleImportSkip{
+				List: list.Name, RuleType: "quota_rule", Name: rule.Name,
+				Reason: "quota rules are not stored by this installation",
+			})
+		}
+		return nil
+	}
+
+	existing, err := s.repos.QuotaRule.GetByListID(ctx, list.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get quota rules for list %q: %w", list.Name, err)
+	}
+	taken := make(map[string]bool, len(existing))
+	for _, rule := range existing {
+		taken[rule.Name] = true
+	}
+
+	for _, rule := range bundled.QuotaRules {
+		if taken[rule.Name] {
+			result.Skipped = append(result.Skipped, RuleImportSkip{
+				List: list.Name, RuleType: "quota_rule", Name: rule.Name,
+				Reason: "the list already has a quota rule with this name; it was kept",
+			})
+			continue
+		}
+		if _, err := s.quotaService.CreateQuotaRule(ctx, CreateQuotaRuleRequest{
+			ListID:              list.ID,
+			Name:                rule.Name,
+			QuotaType:           rule.QuotaType,
+			LimitSeconds:        rule.LimitSeconds,
+			CarryOver:           rule.CarryOver,
+			MaxCarryOverSeconds: rule.MaxCarryOverSeconds,
+			Enabled:             rule.Enabled,
+		}); err != nil {
+			return fmt.Errorf("failed to create quota rule %q in list %q: %w", rule.Name, list.Name, err)
+		}
+		result.QuotaRulesCreated++
+	}
+	return nil
+}
+
+// validateBundle checks every list, entry and rule in a bundle without
+// touching the database and returns the problems found, at most
+// maxImportErrors of them
+func (s *RuleBundleService) validateBundle(bundle *RuleBundle) []string {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		if len(problems) < maxImportErrors {
+			problems = append(problems, fmt.Sprintf(format, args...))
+		}
+	}
+
+	listNames := make(map[string]bool, len(bundle.Lists))
+	for i, list := range bundle.Lists {
+		name := strings.TrimSpace(list.Name)
+		where := fmt.Sprintf("list %q", list.Name)
+		switch {
+		case name == "":
+			where = fmt.Sprintf("list %d", i+1)
+			add("%s: name is required", where)
+		case listNames[name]:
+			add("%s: more than one list has this name", where)
+		}
+		listNames[name] = true
+
+		if list.Type != models.ListTypeWhitelist && list.Type != models.ListTypeBlacklist {
+			add("%s: invalid list type: %s", where, list.Type)
+		}
+		if len(list.Label) > models.MaxLabelLength {
+			add("%s: label must be at most %d characters", where, models.MaxLabelLength)
+		}
+
+		for j, entry := range list.Entries {
+			if err := s.validateEntry(entry); err != nil {
+				add("%s entry %d (%q): %v", where, j+1, entry.Pattern, err)
+			}
+		}
+
+		ruleNames := make(map[string]bool, len(list.TimeRules))
+		for _, rule := range list.TimeRules {
+			if err := s.validateTimeRule(rule); err != nil {
+				add("%s time rule %q: %v", where, rule.Name, err)
+			} else if ruleNames[rule.Name] {
+				add("%s time rule %q: more than one time rule has this name", where, rule.Name)
+			}
+			ruleNames[rule.Name] = true
+		}
+
+		ruleNames = make(map[string]bool, len(list.QuotaRules))
+		for _, rule := range list.QuotaRules {
+			if err := validateBundleQuotaRule(rule); err != nil {
+				add("%s quota rule %q: %v", where, rule.Name, err)
+			} else if ruleNames[rule.Name] {
+				add("%s quota rule %q: more than one quota rule has this name", where, rule.Name)
+			}
+			ruleNames[rule.Name] = true
+		}
+	}
+	return problems
+}
+
+// validateEntry applies the checks made when an entry is created by hand
+func (s *RuleBundleService) validateEntry(entry RuleBundleEntry) error {
+	if entry.EntryType != models.EntryTypeExecutable && entry.EntryType != models.EntryTypeURL {
+		return fmt.Errorf("invalid entry type: %s", entry.EntryType)
+	}
+
+	switch entry.PatternType {
+	case models.PatternTypeExact, models.PatternTypeWildcard, models.PatternTypeDomain,
+		models.PatternTypeRegex, models.PatternTypeCIDR, models.PatternTypePort:
+	default:
+		return fmt.Errorf("invalid pattern type: %s", entry.PatternType)
+	}
+
+	if len(entry.Label) > models.MaxLabelLength {
+		return fmt.Errorf("label must be at most %d characters", models.MaxLabelLength)
+	}
+
+	pattern := strings.TrimSpace(entry.Pattern)
+	if pattern == "" {
+		return fmt.Errorf("pattern is required")
+	}
+	return s.entryService.validatePattern(pattern, entry.EntryType, entry.PatternType)
+}
+
+// validateTimeRule applies the checks made when a time rule is created,
+// except for name uniqueness within the list
+func (s *RuleBundleService) validateTimeRule(rule RuleBundleTimeRule) error {
+	if strings.TrimSpace(rule.Name) == "" {
+		return fmt.Errorf("name is required")
+	}
+	if rule.RuleType != models.RuleTypeAllowDuring && rule.RuleType != models.RuleTypeBlockDuring {
+		return fmt.Errorf("invalid rule type: %s", rule.RuleType)
+	}
+	if err := s.timeService.validateDaysOfWeek(rule.DaysOfWeek); err != nil {
+		return err
+	}
+	if err := models.ValidateTimeFormat(rule.StartTime); err != nil {
+		return fmt.Errorf("invalid start time: %w", err)
+	}
+	if err := models.ValidateTimeFormat(rule.EndTime); err != nil {
+		return fmt.Errorf("invalid end time: %w", err)
+	}
+	if err := models.ValidateTimezone(rule.Timezone); err != nil {
+		return fmt.Errorf("invalid timezone: %w", err)
+	}
+	return nil
+}
+
+// validateBundleQuotaRule applies the checks made when a quota rule is
+// created, except for name uniqueness within the list
+func validateBundleQuotaRule(rule RuleBundleQuotaRule) error {
+	if strings.TrimSpace(rule.Name) == "" {
+		return fmt.Errorf("name is required")
+	}
+	if rule.QuotaType != models.QuotaTypeDaily &&
+		rule.QuotaType != models.QuotaTypeWeekly &&
+		rule.QuotaType != models.QuotaTypeMonthly {
+		return fmt.Errorf("invalid quota type: %s", rule.QuotaType)
+	}
+	if rule.LimitSeconds < 1 {
+		return fmt.Errorf("limit must be at least 1 second")
+	}
+	if rule.CarryOver != "" {
+		if err := validateCarryOver(rule.CarryOver); err != nil {
+			return err
+		}
+	}
+	if rule.MaxCarryOverSeconds < 0 {
+		return fmt.Errorf("carry-over cap cannot be negative")
+	}
+	return nil
+}
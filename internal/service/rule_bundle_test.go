@@ -0,0 +1,192 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"parental-control/internal/logging"
+	"parental-control/internal/models"
+)
+
+// newBundleTestService returns a bundle service backed by a fresh database,
+// with time and quota rules kept in memory, and the ID of its empty
+// "Imported" blacklist
+func newBundleTestService(t *testing.T) (*RuleBundleService, *models.RepositoryManager, int) {
+	t.Helper()
+
+	_, repos, listID := newImportTestService(t)
+	repos.TimeRule = &memoryTimeRuleRepo{rules: map[int]models.TimeRule{}}
+	repos.QuotaRule = &memoryQuotaRuleRepo{rules: map[int]models.QuotaRule{}}
+	return NewRuleBundleService(repos, logging.NewDefault()), repos, listID
+}
+
+func TestRuleBundle_ExportImportRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	source, repos, listID := newBundleTestService(t)
+
+	for _, pattern := range []string{"ads.example.com", "tracker.example.net"} {
+		entry := &models.ListEntry{ListID: listID, EntryType: models.EntryTypeURL, Pattern: pattern,
+			PatternType: models.PatternTypeDomain, Source: models.EntrySourceImport, Enabled: true}
+		if err := repos.ListEntry.Create(ctx, entry); err != nil {
+			t.Fatalf("Failed to create entry: %v", err)
+		}
+	}
+	repos.TimeRule.Create(ctx, &models.TimeRule{ListID: listID, Name: "bedtime", RuleType: models.RuleTypeBlockDuring,
+		DaysOfWeek: []int{0, 1, 2, 3, 4}, StartTime: "21:00", EndTime: "07:00", Timezone: "America/Chicago", Enabled: true})
+	repos.QuotaRule.Create(ctx, &models.QuotaRule{ListID: listID, Name: "daily", QuotaType: models.QuotaTypeDaily,
+		LimitSeconds: 3600, CarryOver: models.CarryOverUnusedCapped, MaxCarryOverSeconds: 1800, Enabled: true})
+
+	exported, err := source.Export(ctx)
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(exported); err != nil {
+		t.Fatalf("Failed to encode bundle: %v", err)
+	}
+	bundle, err := ReadRuleBundle(&buf)
+	if err != nil {
+		t.Fatalf("Failed to read bundle: %v", err)
+	}
+
+	// Replacing removes the target's own list
+	target, _, _ := newBundleTestService(t)
+	result, err := target.Import(ctx, bundle, RuleImportReplace)
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if result.ListsDeleted != 1 || result.ListsCreated != 1 || result.EntriesCreated != 2 ||
+		result.TimeRulesCreated != 1 || result.QuotaRulesCreated != 1 || len(result.Skipped) != 0 {
+		t.Errorf("Unexpected import result: %+v", result)
+	}
+	if result.Validation == nil {
+		t.Error("Expected the imported rules to be validated")
+	}
+
+	reexported, err := target.Export(ctx)
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if !reflect.DeepEqual(reexported.Lists, exported.Lists) {
+		t.Errorf("Round trip changed the rules:\n%+v\nexpected:\n%+v", reexported.Lists, exported.Lists)
+	}
+}
+
+func TestRuleBundle_MergeReportsConflicts(t *testing.T) {
+	ctx := context.Background()
+	bundles, repos, listID := newBundleTestService(t)
+
+	repos.ListEntry.Create(ctx, &models.ListEntry{ListID: listID, EntryType: models.EntryTypeURL,
+		Pattern: "ads.example.com", PatternType: models.PatternTypeDomain, Enabled: true})
+	repos.TimeRule.Create(ctx, &models.TimeRule{ListID: listID, Name: "bedtime", RuleType: models.RuleTypeBlockDuring,
+		DaysOfWeek: []int{5, 6}, StartTime: "23:00", EndTime: "08:00", Enabled: true})
+	if _, err := bundles.listService.CreateList(ctx, CreateListRequest{Name: "Homework", Type: models.ListTypeBlacklist}); err != nil {
+		t.Fatalf("Failed to create list: %v", err)
+	}
+
+	bundle := &RuleBundle{Format: RuleBundleFormat, Version: RuleBundleVersion, Lists: []RuleBundleList{
+		{
+			Name: "Imported", Type: models.ListTypeBlacklist, Enabled: true,
+			Entries: []RuleBundleEntry{
+				{EntryType: models.EntryTypeURL, Pattern: "ads.example.com", PatternType: models.PatternTypeDomain, Enabled: true},
+				{EntryType: models.EntryTypeURL, Pattern: "games.example.com", PatternType: models.PatternTypeDomain, Enabled: true},
+			},
+			TimeRules: []RuleBundleTimeRule{
+				{Name: "bedtime", RuleType: models.RuleTypeBlockDuring, DaysOfWeek: []int{0}, StartTime: "20:00", EndTime: "06:00"},
+				{Name: "school", RuleType: models.RuleTypeBlockDuring, DaysOfWeek: []int{1, 2}, StartTime: "08:00", EndTime: "15:00"},
+			},
+		},
+		{Name: "Homework", Type: models.ListTypeWhitelist},
+		{Name: "Streaming", Type: models.ListTypeBlacklist, Entries: []RuleBundleEntry{
+			{EntryType: models.EntryTypeExecutable, Pattern: "vlc", PatternType: models.PatternTypeExact, Enabled: true},
+		}},
+	}}
+
+	result, err := bundles.Import(ctx, bundle, RuleImportMerge)
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if result.ListsMerged != 1 || result.ListsCreated != 1 || result.ListsDeleted != 0 ||
+		result.EntriesCreated != 2 || result.EntriesSkipped != 1 || result.TimeRulesCreated != 1 {
+		t.Errorf("Unexpected import result: %+v", result)
+	}
+
+	skipped := make(map[string]bool)
+	for _, skip := range result.Skipped {
+		skipped[skip.RuleType+":"+skip.List+"/"+skip.Name] = true
+	}
+	if len(result.Skipped) != 2 || !skipped["time_rule:Imported/bedtime"] || !skipped["list:Homework/"] {
+		t.Errorf("Expected the bedtime rule and the Homework list to be skipped, got %+v", result.Skipped)
+	}
+
+	// The existing rule keeps its settings
+	rules, _ := repos.TimeRule.GetByListID(ctx, listID)
+	for _, rule := range rules {
+		if rule.Name == "bedtime" && rule.StartTime != "23:00" {
+			t.Errorf("Expected the existing bedtime rule to be kept, got %+v", rule)
+		}
+	}
+}
+
+func TestRuleBundle_InvalidBundleChangesNothing(t *testing.T) {
+	ctx := context.Background()
+	bundles, repos, _ := newBundleTestService(t)
+
+	bundle := &RuleBundle{Format: RuleBundleFormat, Version: RuleBundleVersion, Lists: []RuleBundleList{
+		{Name: "Games", Type: models.ListTypeBlacklist, Entries: []RuleBundleEntry{
+			{EntryType: models.EntryTypeURL, Pattern: "games.example.com", PatternType: models.PatternTypeDomain},
+			{EntryType: models.EntryTypeURL, Pattern: "([", PatternType: models.PatternTypeRegex},
+		}, QuotaRules: []RuleBundleQuotaRule{
+			{Name: "daily", QuotaType: models.QuotaTypeDaily, LimitSeconds: 0},
+		}},
+		{Name: "Games", Type: "greylist"},
+	}}
+
+	_, err := bundles.Import(ctx, bundle, RuleImportReplace)
+	var bundleErr *RuleBundleError
+	if !errors.As(err, &bundleErr) {
+		t.Fatalf("Expected a RuleBundleError, got %v", err)
+	}
+	want := []string{"entry 2", "quota rule \"daily\"", "more than one list", "invalid list type"}
+	for _, fragment := range want {
+		if !strings.Contains(err.Error(), fragment) {
+			t.Errorf("Expected a problem mentioning %q, got %v", fragment, bundleErr.Problems)
+		}
+	}
+
+	lists, _ := repos.List.GetAll(ctx)
+	if len(lists) != 1 || lists[0].Name != "Imported" {
+		t.Errorf("Expected the existing list to be untouched, got %+v", lists)
+	}
+}
+
+func TestReadRuleBundle(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr error
+	}{
+		{"current", `{"format":"parental-control-rules","version":1,"lists":[]}`, nil},
+		{"newer", `{"format":"parental-control-rules","version":2,"lists":[]}`, ErrIncompatibleRuleBundle},
+		{"unversioned", `{"format":"parental-control-rules","lists":[]}`, ErrIncompatibleRuleBundle},
+		{"other file", `{"lists":[]}`, ErrIncompatibleRuleBundle},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ReadRuleBundle(strings.NewReader(tt.input))
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	if _, err := ReadRuleBundle(strings.NewReader("not json")); err == nil {
+		t.Error("Expected malformed JSON to be rejected")
+	}
+}
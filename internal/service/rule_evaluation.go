@@ -0,0 +1,238 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"parental-control/internal/enforcement"
+	"parental-control/internal/models"
+)
+
+// EvaluationDecision is the outcome of evaluating a target against the rules
+type EvaluationDecision string
+
+const (
+	DecisionAllow EvaluationDecision = "allow"
+	DecisionBlock EvaluationDecision = "block"
+)
+
+// EvaluationRequest asks how the current rules treat a URL, domain or
+// process name
+type EvaluationRequest struct {
+	Target string           `json:"target"`
+	Type   models.EntryType `json:"type,omitempty"` // url or executable; guessed from the target when empty
+	At     *time.Time       `json:"at,omitempty"`   // when to evaluate time rules and quotas; defaults to now
+}
+
+// EvaluationStep is one entry that matched the target, and whether it took
+// part in the decision
+type EvaluationStep struct {
+	ListID      int                      `json:"list_id"`
+	ListName    string                   `json:"list_name"`
+	ListType    models.ListType          `json:"list_type"`
+	EntryID     int                      `json:"entry_id"`
+	Pattern     string                   `json:"pattern"`
+	PatternType models.PatternType       `json:"pattern_type"`
+	Action      enforcement.FilterAction `json:"action"`
+	Applied     bool                     `json:"applied"`
+	Reason      string                   `json:"reason,omitempty"` // why the entry was skipped or how it acts
+}
+
+// RuleEvaluation is the decision for a target with every matching entry
+// considered, in list order
+type RuleEvaluation struct {
+	Target      string             `json:"target"`
+	Type        models.EntryType   `json:"type"`
+	Host        string             `json:"host,omitempty"`
+	At          time.Time          `json:"at"`
+	Decision    EvaluationDecision `json:"decision"`
+	Reason      string             `json:"reason"`
+	WinningRule *EvaluationStep    `json:"winning_rule,omitempty"`
+	Trace       []EvaluationStep   `json:"trace"`
+}
+
+// listState is whether a list's entries apply at the evaluated time
+type listState struct {
+	active bool
+	reason string
+}
+
+// EvaluateTarget works out whether a target would be allowed or blocked,
+// without enforcing anything or touching quota usage. URLs and domains are
+// matched as the DNS blocker matches them: any applying block entry blocks,
+// and of entries with the same pattern only the one on the last list applies.
+// Executable entries end matching processes whatever their list type. Lists
+// outside their time rules do not apply, nor do whitelists whose quota is used
+// up.
+func (s *RuleValidationService) EvaluateTarget(ctx context.Context, req EvaluationRequest) (*RuleEvaluation, error) {
+	target := strings.TrimSpace(req.Target)
+	if target == "" {
+		return nil, fmt.Errorf("target is required")
+	}
+
+	entryType := req.Type
+	if entryType == "" {
+		entryType = guessEntryType(target)
+	}
+	if entryType != models.EntryTypeURL && entryType != models.EntryTypeExecutable {
+		return nil, fmt.Errorf("invalid target type %q: must be url or executable", entryType)
+	}
+
+	at := time.Now()
+	if req.At != nil {
+		at = *req.At
+	}
+
+	evaluation := &RuleEvaluation{
+		Target: target,
+		Type:   entryType,
+		At:     at,
+		Trace:  []EvaluationStep{},
+	}
+	if entryType == models.EntryTypeURL {
+		evaluation.Host = enforcement.PatternHost(target)
+		if evaluation.Host == "" {
+			return nil, fmt.Errorf("target %q has no host", target)
+		}
+	}
+
+	lists, err := s.repos.List.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lists: %w", err)
+	}
+
+	process := &enforcement.ProcessInfo{Name: target, Path: target}
+	applied := make(map[string]int) // pattern to the trace step applying it
+	var described []string          // each trace step's entry and list, for the reason
+	for _, list := range lists {
+		entries, err := s.repos.ListEntry.GetByListID(ctx, list.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get entries for list %d: %w", list.ID, err)
+		}
+
+		var state *listState
+		for _, entry := range entries {
+			if entry.EntryType != entryType {
+				continue
+			}
+			if entryType == models.EntryTypeURL && !enforcement.MatchHost(entry.Pattern, matchTypeFor(entry.PatternType), evaluation.Host) {
+				continue
+			}
+			if entryType == models.EntryTypeExecutable && !processMatchesRule(process, entry) {
+				continue
+			}
+
+			step := EvaluationStep{
+				ListID:      list.ID,
+				ListName:    list.Name,
+				ListType:    list.Type,
+				EntryID:     entry.ID,
+				Pattern:     entry.Pattern,
+				PatternType: entry.PatternType,
+				Action:      enforcement.ActionBlock,
+			}
+			if list.Type == models.ListTypeWhitelist && entryType == models.EntryTypeURL {
+				step.Action = enforcement.ActionAllow
+			}
+
+			switch {
+			case !list.Enabled:
+				step.Reason = "list is disabled"
+			case !entry.Enabled:
+				step.Reason = "entry is disabled"
+			default:
+				if state == nil {
+					if state, err = s.listStateAt(ctx, &list, at); err != nil {
+						return nil, err
+					}
+				}
+				step.Applied = state.active
+				step.Reason = state.reason
+				if step.Applied && list.Type == models.ListTypeWhitelist && entryType == models.EntryTypeExecutable {
+					step.Reason = "executable entries end matching processes on any list"
+				}
+			}
+
+			if step.Applied && entryType == models.EntryTypeURL {
+				if previous, ok := applied[entry.Pattern]; ok {
+					evaluation.Trace[previous].Applied = false
+					evaluation.Trace[previous].Reason = fmt.Sprintf("replaced by the same pattern on %s", describeList(&list))
+				}
+				applied[entry.Pattern] = len(evaluation.Trace)
+			}
+			evaluation.Trace = append(evaluation.Trace, step)
+			described = append(described, fmt.Sprintf("%s on %s", describeEntry(entry), describeList(&list)))
+		}
+	}
+
+	evaluation.Decision = DecisionAllow
+	evaluation.Reason = "no rule blocks this target"
+	for i, step := range evaluation.Trace {
+		if !step.Applied {
+			continue
+		}
+		if step.Action == enforcement.ActionBlock {
+			evaluation.Decision = DecisionBlock
+			evaluation.WinningRule = &evaluation.Trace[i]
+			evaluation.Reason = "blocked by " + described[i]
+			break
+		}
+		if evaluation.WinningRule == nil {
+			evaluation.WinningRule = &evaluation.Trace[i]
+			evaluation.Reason = "allowed by " + described[i]
+		}
+	}
+
+	return evaluation, nil
+}
+
+// listStateAt reports whether a list's entries apply at a time given its
+// time and quota rules. Quota usage is only read.
+func (s *RuleValidationService) listStateAt(ctx context.Context, list *models.List, at time.Time) (*listState, error) {
+	if s.repos.TimeRule != nil {
+		active, err := s.timeService.IsListActiveAt(ctx, list.ID, at)
+		if err != nil {
+			return nil, err
+		}
+		if !active {
+			return &listState{reason: "list is outside its time rules"}, nil
+		}
+	}
+
+	// A used up quota ends a whitelist's allowance; a blacklist blocks anyway
+	if list.Type != models.ListTypeWhitelist || s.repos.QuotaRule == nil || s.repos.QuotaUsage == nil {
+		return &listState{active: true}, nil
+	}
+	quotaRules, err := s.repos.QuotaRule.GetByListID(ctx, list.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quota rules: %w", err)
+	}
+	for _, rule := range quotaRules {
+		if !rule.Enabled {
+			continue
+		}
+		usage, err := s.repos.QuotaUsage.GetCurrentUsage(ctx, rule.ID, at)
+		if err != nil {
+			continue // No usage recorded for this period
+		}
+		if usage.UsedSeconds >= usage.AllowanceSeconds(rule.LimitSeconds) {
+			return &listState{reason: fmt.Sprintf("quota '%s' is used up", rule.Name)}, nil
+		}
+	}
+	return &listState{active: true}, nil
+}
+
+// guessEntryType treats targets that look like a program (an .exe, a path
+// or a bare name) as executables, and anything else as a URL or domain
+func guessEntryType(target string) models.EntryType {
+	if strings.Contains(target, "://") {
+		return models.EntryTypeURL
+	}
+	if strings.HasSuffix(strings.ToLower(target), ".exe") || strings.Contains(target, `\`) ||
+		strings.HasPrefix(target, "/") || !strings.Contains(target, ".") {
+		return models.EntryTypeExecutable
+	}
+	return models.EntryTypeURL
+}
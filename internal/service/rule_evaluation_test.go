@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"parental-control/internal/logging"
+	"parental-control/internal/models"
+)
+
+// memoryQuotaUsageRepo serves fixed quota usage and fails on any write
+type memoryQuotaUsageRepo struct {
+	models.QuotaUsageRepository
+	used   map[int]int // quota rule ID to seconds used
+	writes int
+}
+
+func (r *memoryQuotaUsageRepo) GetCurrentUsage(ctx context.Context, quotaRuleID int, now time.Time) (*models.QuotaUsage, error) {
+	used, ok := r.used[quotaRuleID]
+	if !ok {
+		return nil, fmt.Errorf("no usage for quota rule %d", quotaRuleID)
+	}
+	return &models.QuotaUsage{QuotaRuleID: quotaRuleID, UsedSeconds: used}, nil
+}
+
+func (r *memoryQuotaUsageRepo) UpdateUsage(ctx context.Context, quotaRuleID int, additionalSeconds int, now time.Time) error {
+	r.writes++
+	return nil
+}
+
+func TestEvaluateTarget(t *testing.T) {
+	_, repos, blockedID := newImportTestService(t)
+	ctx := context.Background()
+
+	// Lists are applied in name order: Allowed, Imported, Zeta
+	newList := func(name string, listType models.ListType) int {
+		list := &models.List{Name: name, Type: listType, Enabled: true}
+		if err := repos.List.Create(ctx, list); err != nil {
+			t.Fatalf("Failed to create list: %v", err)
+		}
+		return list.ID
+	}
+	allowedID := newList("Allowed", models.ListTypeWhitelist)
+	zetaID := newList("Zeta", models.ListTypeBlacklist)
+
+	entries := []models.ListEntry{
+		{ListID: blockedID, EntryType: models.EntryTypeURL, Pattern: "games.example.com", PatternType: models.PatternTypeExact, Enabled: true},
+		{ListID: blockedID, EntryType: models.EntryTypeExecutable, Pattern: "steam", PatternType: models.PatternTypeExact, Enabled: true},
+		{ListID: blockedID, EntryType: models.EntryTypeURL, Pattern: "news.example.com", PatternType: models.PatternTypeDomain, Enabled: false},
+		{ListID: allowedID, EntryType: models.EntryTypeURL, Pattern: "games.example.com", PatternType: models.PatternTypeExact, Enabled: true},
+		{ListID: allowedID, EntryType: models.EntryTypeURL, Pattern: "news.example.com", PatternType: models.PatternTypeDomain, Enabled: true},
+		{ListID: zetaID, EntryType: models.EntryTypeURL, Pattern: "*.example.org", PatternType: models.PatternTypeWildcard, Enabled: true},
+	}
+	for i := range entries {
+		if err := repos.ListEntry.Create(ctx, &entries[i]); err != nil {
+			t.Fatalf("Failed to create entry: %v", err)
+		}
+	}
+
+	// Zeta only applies 08:00-09:00 UTC on Mondays; Allowed has a daily quota
+	repos.TimeRule = &memoryTimeRuleRepo{rules: map[int]models.TimeRule{
+		1: {ID: 1, ListID: zetaID, Name: "mornings", RuleType: models.RuleTypeAllowDuring,
+			DaysOfWeek: []int{int(time.Monday)}, StartTime: "08:00", EndTime: "09:00", Timezone: "UTC", Enabled: true},
+	}}
+	repos.QuotaRule = &memoryQuotaRuleRepo{rules: map[int]models.QuotaRule{
+		1: {ID: 1, ListID: allowedID, Name: "daily news", QuotaType: models.QuotaTypeDaily, LimitSeconds: 3600, Enabled: true},
+	}}
+	usage := &memoryQuotaUsageRepo{used: map[int]int{1: 600}}
+	repos.QuotaUsage = usage
+
+	validator := NewRuleValidationService(repos, logging.NewDefault())
+	monday := time.Date(2024, time.January, 15, 8, 30, 0, 0, time.UTC)
+	evaluate := func(target string, at time.Time) *RuleEvaluation {
+		t.Helper()
+		evaluation, err := validator.EvaluateTarget(ctx, EvaluationRequest{Target: target, At: &at})
+		if err != nil {
+			t.Fatalf("EvaluateTarget(%q) failed: %v", target, err)
+		}
+		return evaluation
+	}
+
+	// The later list's entry for the same pattern replaces the whitelist's
+	evaluation := evaluate("https://games.example.com/play", monday)
+	if evaluation.Decision != DecisionBlock || evaluation.Host != "games.example.com" {
+		t.Fatalf("Expected games.example.com to be blocked, got %+v", evaluation)
+	}
+	if evaluation.WinningRule == nil || evaluation.WinningRule.ListID != blockedID {
+		t.Errorf("Expected the Imported entry to win, got %+v", evaluation.WinningRule)
+	}
+	if len(evaluation.Trace) != 2 || evaluation.Trace[0].Applied || evaluation.Trace[0].Reason != "replaced by the same pattern on 'Imported'" {
+		t.Errorf("Expected the Allowed entry to be traced as replaced, got %+v", evaluation.Trace)
+	}
+
+	// A disabled block entry is traced but does not apply
+	evaluation = evaluate("www.news.example.com", monday)
+	if evaluation.Decision != DecisionAllow || evaluation.WinningRule == nil || evaluation.WinningRule.ListID != allowedID {
+		t.Errorf("Expected news to be allowed by the Allowed list, got %+v", evaluation)
+	}
+	if len(evaluation.Trace) != 2 || evaluation.Trace[1].Reason != "entry is disabled" {
+		t.Errorf("Expected the disabled entry in the trace, got %+v", evaluation.Trace)
+	}
+
+	// A used up quota ends the whitelist's allowance
+	usage.used[1] = 3600
+	evaluation = evaluate("www.news.example.com", monday)
+	if evaluation.WinningRule != nil || evaluation.Trace[0].Applied || evaluation.Trace[0].Reason != "quota 'daily news' is used up" {
+		t.Errorf("Expected the Allowed entry to be skipped for its quota, got %+v", evaluation)
+	}
+	if usage.writes != 0 {
+		t.Errorf("Expected quota usage to be left alone, got %d writes", usage.writes)
+	}
+
+	// Time rules decide whether a list applies
+	if evaluation = evaluate("cdn.example.org", monday); evaluation.Decision != DecisionBlock {
+		t.Errorf("Expected cdn.example.org to be blocked on Monday morning, got %+v", evaluation)
+	}
+	evaluation = evaluate("cdn.example.org", monday.Add(2*time.Hour))
+	if evaluation.Decision != DecisionAllow || evaluation.Trace[0].Reason != "list is outside its time rules" {
+		t.Errorf("Expected cdn.example.org to be allowed outside the time rule, got %+v", evaluation)
+	}
+
+	// Bare names are evaluated as executables
+	evaluation = evaluate("steam", monday)
+	if evaluation.Type != models.EntryTypeExecutable || evaluation.Decision != DecisionBlock {
+		t.Errorf("Expected steam to be blocked as an executable, got %+v", evaluation)
+	}
+
+	evaluation = evaluate("unlisted.example.net", monday)
+	if evaluation.Decision != DecisionAllow || evaluation.WinningRule != nil || len(evaluation.Trace) != 0 {
+		t.Errorf("Expected an unlisted site to be allowed without a rule, got %+v", evaluation)
+	}
+
+	if _, err := validator.EvaluateTarget(ctx, EvaluationRequest{Target: " "}); err == nil {
+		t.Error("Expected an empty target to be rejected")
+	}
+	if _, err := validator.EvaluateTarget(ctx, EvaluationRequest{Target: "example.com", Type: "app"}); err == nil {
+		t.Error("Expected an unknown target type to be rejected")
+	}
+}
+
+func TestGuessEntryType(t *testing.T) {
+	tests := []struct {
+		target   string
+		expected models.EntryType
+	}{
+		{"youtube.com", models.EntryTypeURL},
+		{"https://www.youtube.com/watch?v=1", models.EntryTypeURL},
+		{"steam", models.EntryTypeExecutable},
+		{"Minecraft.exe", models.EntryTypeExecutable},
+		{`C:\Games\game.bin`, models.EntryTypeExecutable},
+		{"/usr/bin/steam", models.EntryTypeExecutable},
+	}
+
+	for _, tt := range tests {
+		if got := guessEntryType(tt.target); got != tt.expected {
+			t.Errorf("guessEntryType(%q) = %s, want %s", tt.target, got, tt.expected)
+		}
+	}
+}
@@ -2,10 +2,16 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
+	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
+	"parental-control/internal/enforcement"
 	"parental-control/internal/logging"
 	"parental-control/internal/models"
 )
@@ -60,6 +66,10 @@ type RuleConflict struct {
 	AffectedRules  []ConflictedRule `json:"affected_rules"`
 	Suggestions    []string         `json:"suggestions"`
 	AutoResolvable bool             `json:"auto_resolvable"`
+	// Relationship is set on entry pattern conflicts: "equal" or
+	// "subsumes" when one entry is redundant, "overlaps" when the patterns
+	// only share some sites
+	Relationship enforcement.PatternRelation `json:"relationship,omitempty"`
 }
 
 // ConflictedRule represents a rule involved in a conflict
@@ -194,43 +204,148 @@ func (s *RuleValidationService) DetectConflictingEntries(ctx context.Context, li
 				continue
 			}
 
-			if s.patternsOverlap(entry1.Pattern, entry1.PatternType, entry2.Pattern, entry2.PatternType) {
-				conflict := RuleConflict{
-					ID:          fmt.Sprintf("entry_overlap_%d_%d", entry1.ID, entry2.ID),
-					Type:        ConflictTypeWarning,
-					Severity:    SeverityMedium,
-					Title:       "Overlapping Entry Patterns",
-					Description: fmt.Sprintf("Entries '%s' and '%s' have overlapping patterns", entry1.Pattern, entry2.Pattern),
-					AffectedRules: []ConflictedRule{
-						{RuleType: "entry", RuleID: entry1.ID, RuleName: entry1.Pattern},
-						{RuleType: "entry", RuleID: entry2.ID, RuleName: entry2.Pattern},
-					},
-					Suggestions: []string{
-						"Consider using more specific patterns",
-						"Merge overlapping entries if they serve the same purpose",
-					},
-					AutoResolvable: false,
+			relation := s.patternRelation(entry1.Pattern, entry1.PatternType, entry2.Pattern, entry2.PatternType)
+			if relation == enforcement.RelationDisjoint {
+				continue
+			}
+
+			// Report a subsumed entry second, as the one to remove
+			broader, narrower := entry1, entry2
+			if relation == enforcement.RelationSubsumedBy {
+				broader, narrower = entry2, entry1
+				relation = enforcement.RelationSubsumes
+			}
+
+			conflict := RuleConflict{
+				ID:   fmt.Sprintf("entry_overlap_%d_%d", entry1.ID, entry2.ID),
+				Type: ConflictTypeWarning,
+				AffectedRules: []ConflictedRule{
+					{RuleType: "entry", RuleID: broader.ID, RuleName: broader.Pattern},
+					{RuleType: "entry", RuleID: narrower.ID, RuleName: narrower.Pattern},
+				},
+				AutoResolvable: false,
+				Relationship:   relation,
+			}
+			switch relation {
+			case enforcement.RelationEqual:
+				conflict.Severity = SeverityLow
+				conflict.Title = "Duplicate Entry Patterns"
+				conflict.Description = fmt.Sprintf("Entries %s and %s match exactly the same sites", describeEntry(broader), describeEntry(narrower))
+				conflict.Suggestions = []string{"Remove one of the entries; they are redundant"}
+			case enforcement.RelationSubsumes:
+				conflict.Severity = SeverityLow
+				conflict.Title = "Redundant Entry Pattern"
+				conflict.Description = fmt.Sprintf("Entry %s already matches every site matched by %s", describeEntry(broader), describeEntry(narrower))
+				conflict.Suggestions = []string{fmt.Sprintf("Remove the redundant entry '%s'", narrower.Pattern)}
+			default:
+				conflict.Severity = SeverityMedium
+				conflict.Title = "Overlapping Entry Patterns"
+				conflict.Description = fmt.Sprintf("Entries %s and %s have overlapping patterns", describeEntry(entry1), describeEntry(entry2))
+				conflict.Suggestions = []string{
+					"Narrow one of the patterns so they no longer overlap",
+					"Merge overlapping entries if they serve the same purpose",
+				}
+			}
+			for _, entry := range []models.ListEntry{broader, narrower} {
+				if entry.Notes != "" {
+					conflict.Suggestions = append(conflict.Suggestions, fmt.Sprintf("Notes on '%s': %s", entry.Pattern, entry.Notes))
 				}
-				conflicts = append(conflicts, conflict)
 			}
+			conflicts = append(conflicts, conflict)
 		}
 	}
 
 	return conflicts, nil
 }
 
-// ResolveConflict attempts to automatically resolve a conflict
-func (s *RuleValidationService) ResolveConflict(ctx context.Context, conflictID string) error {
+// ErrResolutionPlanStale is returned when a resolution plan no longer
+// matches what resolving the conflict would do, because its rules changed
+// after the plan was previewed
+var ErrResolutionPlanStale = errors.New("conflict has changed since the resolution plan was previewed")
+
+// ResolutionPlan lists the changes automatically resolving a conflict makes
+type ResolutionPlan struct {
+	ConflictID string             `json:"conflict_id"`
+	Changes    []ResolutionChange `json:"changes"`
+}
+
+// ResolutionChange is one rule change in a resolution plan
+type ResolutionChange struct {
+	RuleType    string `json:"rule_type"` // "time_rule", "quota_rule"
+	RuleID      int    `json:"rule_id"`
+	RuleName    string `json:"rule_name"`
+	Action      string `json:"action"` // "disable", "trim"
+	Description string `json:"description"`
+	// OldWindow and NewWindow are set on trims, as HH:MM-HH:MM
+	OldWindow string `json:"old_window,omitempty"`
+	NewWindow string `json:"new_window,omitempty"`
+}
+
+// conflictResolution is a plan together with the updated rules that carry it out
+type conflictResolution struct {
+	plan       *ResolutionPlan
+	timeRules  []models.TimeRule
+	quotaRules []models.QuotaRule
+}
+
+// PreviewResolveConflict returns the changes ResolveConflict would make for a
+// conflict without making them
+func (s *RuleValidationService) PreviewResolveConflict(ctx context.Context, conflictID string) (*ResolutionPlan, error) {
+	resolution, err := s.planResolution(ctx, conflictID)
+	if err != nil {
+		return nil, err
+	}
+	return resolution.plan, nil
+}
+
+// ResolveConflict attempts to automatically resolve a conflict and returns
+// the changes it made. When plan is a previewed plan, the conflict is only
+// resolved if it would still make exactly those changes; otherwise
+// ErrResolutionPlanStale is returned and nothing is changed.
+func (s *RuleValidationService) ResolveConflict(ctx context.Context, conflictID string, plan *ResolutionPlan) (*ResolutionPlan, error) {
 	s.logger.Info("Attempting to resolve conflict", logging.String("conflict_id", conflictID))
 
+	resolution, err := s.planResolution(ctx, conflictID)
+	if err != nil {
+		return nil, err
+	}
+	if plan != nil && !reflect.DeepEqual(plan, resolution.plan) {
+		return nil, ErrResolutionPlanStale
+	}
+
+	// Each resolution changes one kind of rule, in one transaction
+	if len(resolution.timeRules) > 0 {
+		if err := s.repos.TimeRule.UpdateBatch(ctx, resolution.timeRules); err != nil {
+			return nil, fmt.Errorf("failed to update time rules: %w", err)
+		}
+	}
+	if len(resolution.quotaRules) > 0 {
+		if err := s.repos.QuotaRule.UpdateBatch(ctx, resolution.quotaRules); err != nil {
+			return nil, fmt.Errorf("failed to update quota rules: %w", err)
+		}
+	}
+
+	for _, change := range resolution.plan.Changes {
+		s.logger.Info("Resolved conflict",
+			logging.String("conflict_id", conflictID),
+			logging.String("rule_type", change.RuleType),
+			logging.Int("rule_id", change.RuleID),
+			logging.String("action", change.Action),
+			logging.String("change", change.Description))
+	}
+	return resolution.plan, nil
+}
+
+// planResolution works out how to resolve a conflict from its ID
+func (s *RuleValidationService) planResolution(ctx context.Context, conflictID string) (*conflictResolution, error) {
 	// Parse conflict ID to determine resolution strategy
 	if strings.HasPrefix(conflictID, "time_overlap_") {
-		return s.resolveTimeOverlapConflict(ctx, conflictID)
+		return s.planTimeOverlapResolution(ctx, conflictID)
 	} else if strings.HasPrefix(conflictID, "quota_duplicate_") {
-		return s.resolveQuotaDuplicateConflict(ctx, conflictID)
+		return s.planQuotaDuplicateResolution(ctx, conflictID)
 	}
 
-	return fmt.Errorf("no automatic resolution available for conflict: %s", conflictID)
+	return nil, fmt.Errorf("no automatic resolution available for conflict: %s", conflictID)
 }
 
 // validateListRules validates all rules for a specific list
@@ -269,6 +384,9 @@ func (s *RuleValidationService) validateListRules(ctx context.Context, listID in
 
 // detectTimeRuleConflicts detects conflicts between time rules
 func (s *RuleValidationService) detectTimeRuleConflicts(ctx context.Context, listID int) []RuleConflict {
+	if s.repos.TimeRule == nil {
+		return []RuleConflict{}
+	}
 	rules, err := s.repos.TimeRule.GetByListID(ctx, listID)
 	if err != nil {
 		return []RuleConflict{}
@@ -284,6 +402,19 @@ func (s *RuleValidationService) detectTimeRuleConflicts(ctx context.Context, lis
 
 			// Check for opposing rule types with overlapping schedules
 			if rule1.RuleType != rule2.RuleType && s.scheduleOverlap(&rule1, &rule2) {
+				suggestions := []string{
+					"Adjust time ranges to avoid overlap",
+					"Change one rule type to match the other",
+					"Disable one of the conflicting rules",
+				}
+				allow, block := allowAndBlockRules(&rule1, &rule2)
+				start, end, trimErr := trimTimeWindow(allow, block)
+				if trimErr == nil {
+					suggestions = append([]string{
+						fmt.Sprintf("Trim '%s' to %s-%s so it no longer overlaps '%s'", allow.Name, start, end, block.Name),
+					}, suggestions...)
+				}
+
 				conflict := RuleConflict{
 					ID:          fmt.Sprintf("time_overlap_%d_%d", rule1.ID, rule2.ID),
 					Type:        ConflictTypeHard,
@@ -294,12 +425,8 @@ func (s *RuleValidationService) detectTimeRuleConflicts(ctx context.Context, lis
 						{RuleType: "time_rule", RuleID: rule1.ID, RuleName: rule1.Name, ListID: listID},
 						{RuleType: "time_rule", RuleID: rule2.ID, RuleName: rule2.Name, ListID: listID},
 					},
-					Suggestions: []string{
-						"Adjust time ranges to avoid overlap",
-						"Change one rule type to match the other",
-						"Disable one of the conflicting rules",
-					},
-					AutoResolvable: false,
+					Suggestions:    suggestions,
+					AutoResolvable: trimErr == nil,
 				}
 				conflicts = append(conflicts, conflict)
 			}
@@ -311,6 +438,9 @@ func (s *RuleValidationService) detectTimeRuleConflicts(ctx context.Context, lis
 
 // detectQuotaRuleConflicts detects conflicts between quota rules
 func (s *RuleValidationService) detectQuotaRuleConflicts(ctx context.Context, listID int) []RuleConflict {
+	if s.repos.QuotaRule == nil {
+		return []RuleConflict{}
+	}
 	rules, err := s.repos.QuotaRule.GetByListID(ctx, listID)
 	if err != nil {
 		return []RuleConflict{}
@@ -376,7 +506,7 @@ func (s *RuleValidationService) detectLogicalInconsistencies(ctx context.Context
 			Type:        ConflictTypeWarning,
 			Severity:    SeverityLow,
 			Title:       "Empty List",
-			Description: fmt.Sprintf("List '%s' is enabled but contains no entries", list.Name),
+			Description: fmt.Sprintf("List %s is enabled but contains no entries", describeList(list)),
 			AffectedRules: []ConflictedRule{
 				{RuleType: "list", RuleID: list.ID, RuleName: list.Name, ListID: listID},
 			},
@@ -386,117 +516,176 @@ func (s *RuleValidationService) detectLogicalInconsistencies(ctx context.Context
 			},
 			AutoResolvable: false,
 		}
+		if list.Notes != "" {
+			conflict.Suggestions = append(conflict.Suggestions, fmt.Sprintf("List notes: %s", list.Notes))
+		}
 		conflicts = append(conflicts, conflict)
 	}
 
 	return conflicts
 }
 
-// detectSystemWideConflicts detects conflicts across the entire system
+// detectSystemWideConflicts detects conflicts across the entire system: the
+// same pattern whitelisted on one enabled list and blacklisted on another
 func (s *RuleValidationService) detectSystemWideConflicts(ctx context.Context, lists []models.List) []RuleConflict {
 	conflicts := make([]RuleConflict, 0)
 
-	// Check for conflicting list types with overlapping entries
-	whitelists := make([]models.List, 0)
-	blacklists := make([]models.List, 0)
+	// Whitelisted entries by normalized pattern, each with the position of its
+	// list, which decides which entry enforcement applies
+	type listedEntry struct {
+		list  *models.List
+		order int
+		entry models.ListEntry
+	}
+	whitelisted := make(map[string][]listedEntry)
+	var blacklisted []listedEntry
 
-	for _, list := range lists {
+	for i := range lists {
+		list := &lists[i]
 		if !list.Enabled {
 			continue
 		}
-		if list.Type == models.ListTypeWhitelist {
-			whitelists = append(whitelists, list)
-		} else {
-			blacklists = append(blacklists, list)
+		entries, err := s.repos.ListEntry.GetByListID(ctx, list.ID)
+		if err != nil {
+			s.logger.Warn("Failed to get entries for collision check", logging.Int("list_id", list.ID), logging.Err(err))
+			continue
+		}
+		for _, entry := range entries {
+			if !entry.Enabled {
+				continue
+			}
+			listed := listedEntry{list: list, order: i, entry: entry}
+			if list.Type == models.ListTypeWhitelist {
+				key := entryCollisionKey(entry)
+				whitelisted[key] = append(whitelisted[key], listed)
+			} else {
+				blacklisted = append(blacklisted, listed)
+			}
 		}
 	}
 
-	// This is a simplified check - a full implementation would need to compare actual entries
-	if len(whitelists) > 0 && len(blacklists) > 0 {
-		conflict := RuleConflict{
-			ID:          "system_whitelist_blacklist_conflict",
-			Type:        ConflictTypeWarning,
-			Severity:    SeverityMedium,
-			Title:       "Mixed List Types",
-			Description: "Both whitelist and blacklist types are active, which may cause confusion",
-			Suggestions: []string{
-				"Consider using only one list type for consistency",
-				"Clearly document the intended behavior",
-			},
-			AutoResolvable: false,
+	for _, black := range blacklisted {
+		for _, white := range whitelisted[entryCollisionKey(black.entry)] {
+			blackWins, reason := blacklistEntryWins(white.entry, white.order, black.entry, black.order)
+			winner, loser := black, white
+			if !blackWins {
+				winner, loser = white, black
+			}
+
+			conflicts = append(conflicts, RuleConflict{
+				ID:       fmt.Sprintf("list_collision_%d_%d", white.entry.ID, black.entry.ID),
+				Type:     ConflictTypeHard,
+				Severity: SeverityHigh,
+				Title:    "Entry Both Allowed and Blocked",
+				Description: fmt.Sprintf("Entry %s is whitelisted on %s and blacklisted on %s",
+					describeEntry(white.entry), describeList(white.list), describeList(black.list)),
+				AffectedRules: []ConflictedRule{
+					{RuleType: "entry", RuleID: white.entry.ID, RuleName: white.entry.Pattern, ListID: white.list.ID, ListName: white.list.Name},
+					{RuleType: "entry", RuleID: black.entry.ID, RuleName: black.entry.Pattern, ListID: black.list.ID, ListName: black.list.Name},
+				},
+				Suggestions: []string{
+					fmt.Sprintf("The entry on %s wins: %s", describeList(winner.list), reason),
+					fmt.Sprintf("Remove '%s' from %s if %s should decide", winner.entry.Pattern, describeList(winner.list), describeList(loser.list)),
+				},
+				AutoResolvable: false,
+			})
 		}
-		conflicts = append(conflicts, conflict)
 	}
 
 	return conflicts
 }
 
-// Helper methods
-
-func (s *RuleValidationService) allConflictsAreWarnings(conflicts []RuleConflict) bool {
-	for _, conflict := range conflicts {
-		if conflict.Type == ConflictTypeHard {
-			return false
+// entryCollisionKey normalizes an entry so the same site or program is
+// recognized however its pattern was written
+func entryCollisionKey(entry models.ListEntry) string {
+	pattern := strings.TrimSpace(entry.Pattern)
+	if entry.EntryType == models.EntryTypeURL {
+		switch entry.PatternType {
+		case models.PatternTypeExact, models.PatternTypeDomain:
+			pattern = enforcement.PatternHost(pattern)
+		case models.PatternTypeWildcard:
+			pattern = strings.TrimSuffix(strings.ToLower(pattern), ".")
+		case models.PatternTypeCIDR:
+			if _, network, err := net.ParseCIDR(pattern); err == nil {
+				pattern = network.String()
+			}
+		case models.PatternTypePort:
+			if ports, err := enforcement.ParsePortPattern(pattern); err == nil {
+				pattern = ports.String()
+			}
 		}
 	}
-	return true
+	return fmt.Sprintf("%s|%s|%s", entry.EntryType, entry.PatternType, pattern)
 }
 
-func (s *RuleValidationService) patternsOverlap(pattern1 string, type1 models.PatternType, pattern2 string, type2 models.PatternType) bool {
-	// Simplified overlap detection - a full implementation would need more sophisticated pattern matching
-	if type1 == models.PatternTypeExact && type2 == models.PatternTypeExact {
-		return pattern1 == pattern2
+// blacklistEntryWins reports whether a blacklisted entry takes effect over a
+// colliding whitelisted one, and why, following how rules are enforced:
+// executable entries all stop matching programs whatever their list, and
+// network rules are keyed by pattern with lists applied in order, so for the
+// same pattern text the later list replaces the earlier one. Differently
+// written patterns both load, and a matching block rule always blocks.
+func blacklistEntryWins(white models.ListEntry, whiteOrder int, black models.ListEntry, blackOrder int) (bool, string) {
+	switch {
+	case black.EntryType == models.EntryTypeExecutable:
+		return true, "whitelisting does not exempt a program from a blacklist"
+	case white.Pattern == black.Pattern && whiteOrder > blackOrder:
+		return false, "its list is applied after the blacklist, replacing the blocking rule"
+	case white.Pattern == black.Pattern:
+		return true, "its list is applied after the whitelist, replacing the allowing rule"
+	default:
+		return true, "a matching block rule blocks the site even when it is also allowed"
 	}
+}
 
-	// For wildcard patterns, check if one pattern could match the other
-	if type1 == models.PatternTypeWildcard || type2 == models.PatternTypeWildcard {
-		// This is a simplified check - real implementation would need proper wildcard matching
-		return strings.Contains(pattern1, pattern2) || strings.Contains(pattern2, pattern1)
-	}
+// Helper methods
 
-	return false
+// describeList names a list in validation messages, with its label if set
+func describeList(list *models.List) string {
+	if list.Label == "" {
+		return fmt.Sprintf("'%s'", list.Name)
+	}
+	return fmt.Sprintf("'%s' (%s)", list.Name, list.Label)
 }
 
-func (s *RuleValidationService) scheduleOverlap(rule1, rule2 *models.TimeRule) bool {
-	// Check if rules share any days
-	dayOverlap := false
-	for _, day1 := range rule1.DaysOfWeek {
-		for _, day2 := range rule2.DaysOfWeek {
-			if day1 == day2 {
-				dayOverlap = true
-				break
-			}
-		}
-		if dayOverlap {
-			break
-		}
+// describeEntry names an entry in validation messages with its label and
+// whether it was imported, so parents can tell why it was added
+func describeEntry(entry models.ListEntry) string {
+	var details []string
+	if entry.Label != "" {
+		details = append(details, entry.Label)
 	}
-
-	if !dayOverlap {
-		return false
+	if entry.Source == models.EntrySourceImport {
+		details = append(details, "imported")
+	}
+	if len(details) == 0 {
+		return fmt.Sprintf("'%s'", entry.Pattern)
 	}
+	return fmt.Sprintf("'%s' (%s)", entry.Pattern, strings.Join(details, ", "))
+}
 
-	// Check if time ranges overlap (simplified)
-	return s.timeRangesOverlap(rule1.StartTime, rule1.EndTime, rule2.StartTime, rule2.EndTime)
+func (s *RuleValidationService) allConflictsAreWarnings(conflicts []RuleConflict) bool {
+	for _, conflict := range conflicts {
+		if conflict.Type == ConflictTypeHard {
+			return false
+		}
+	}
+	return true
 }
 
-func (s *RuleValidationService) timeRangesOverlap(start1, end1, start2, end2 string) bool {
-	// Parse times
-	s1, _ := time.Parse("15:04", start1)
-	e1, _ := time.Parse("15:04", end1)
-	s2, _ := time.Parse("15:04", start2)
-	e2, _ := time.Parse("15:04", end2)
+// patternsOverlap reports whether some host is matched by both patterns,
+// using the same matching as DNS enforcement
+func (s *RuleValidationService) patternsOverlap(pattern1 string, type1 models.PatternType, pattern2 string, type2 models.PatternType) bool {
+	return enforcement.PatternsOverlap(pattern1, matchTypeFor(type1), pattern2, matchTypeFor(type2))
+}
 
-	// Handle overnight ranges
-	if start1 > end1 {
-		e1 = e1.Add(24 * time.Hour)
-	}
-	if start2 > end2 {
-		e2 = e2.Add(24 * time.Hour)
-	}
+// patternRelation reports whether one pattern makes the other redundant or
+// they merely overlap, using the same matching as DNS enforcement
+func (s *RuleValidationService) patternRelation(pattern1 string, type1 models.PatternType, pattern2 string, type2 models.PatternType) enforcement.PatternRelation {
+	return enforcement.ComparePatterns(pattern1, matchTypeFor(type1), pattern2, matchTypeFor(type2))
+}
 
-	// Check overlap
-	return s1.Before(e2) && s2.Before(e1)
+func (s *RuleValidationService) scheduleOverlap(rule1, rule2 *models.TimeRule) bool {
+	return timeRulesOverlap(rule1, rule2, time.Now())
 }
 
 // Impact analysis methods (simplified implementations)
@@ -504,18 +693,28 @@ func (s *RuleValidationService) timeRangesOverlap(start1, end1, start2, end2 str
 func (s *RuleValidationService) analyzeListImpact(ctx context.Context, listID int, operation string, analysis *RuleImpactAnalysis) (*RuleImpactAnalysis, error) {
 	analysis.AffectedLists = append(analysis.AffectedLists, listID)
 
-	// Get affected entries, time rules, and quota rules
-	entries, _ := s.repos.ListEntry.GetByListID(ctx, listID)
+	// Get affected entries, time rules, and quota rules. Repositories that are
+	// not configured have no dependents to report.
+	var entries []models.ListEntry
+	if s.repos.ListEntry != nil {
+		entries, _ = s.repos.ListEntry.GetByListID(ctx, listID)
+	}
 	for _, entry := range entries {
 		analysis.AffectedEntries = append(analysis.AffectedEntries, entry.ID)
 	}
 
-	timeRules, _ := s.repos.TimeRule.GetByListID(ctx, listID)
+	var timeRules []models.TimeRule
+	if s.repos.TimeRule != nil {
+		timeRules, _ = s.repos.TimeRule.GetByListID(ctx, listID)
+	}
 	for _, rule := range timeRules {
 		analysis.AffectedTimeRules = append(analysis.AffectedTimeRules, rule.ID)
 	}
 
-	quotaRules, _ := s.repos.QuotaRule.GetByListID(ctx, listID)
+	var quotaRules []models.QuotaRule
+	if s.repos.QuotaRule != nil {
+		quotaRules, _ = s.repos.QuotaRule.GetByListID(ctx, listID)
+	}
 	for _, rule := range quotaRules {
 		analysis.AffectedQuotaRules = append(analysis.AffectedQuotaRules, rule.ID)
 	}
@@ -579,16 +778,172 @@ func (s *RuleValidationService) analyzeEntryImpact(ctx context.Context, entryID
 	return analysis, nil
 }
 
-// Conflict resolution methods (simplified)
+// Conflict resolution methods
+
+// planTimeOverlapResolution trims the allow_during rule of a conflicting
+// pair so its window no longer overlaps the block_during rule. Block rules win
+// while both are active, so the allow rule is the lower priority one.
+func (s *RuleValidationService) planTimeOverlapResolution(ctx context.Context, conflictID string) (*conflictResolution, error) {
+	var id1, id2 int
+	if _, err := fmt.Sscanf(strings.TrimPrefix(conflictID, "time_overlap_"), "%d_%d", &id1, &id2); err != nil {
+		return nil, fmt.Errorf("invalid time overlap conflict ID: %s", conflictID)
+	}
+
+	rule1, err := s.repos.TimeRule.GetByID(ctx, id1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get time rule %d: %w", id1, err)
+	}
+	rule2, err := s.repos.TimeRule.GetByID(ctx, id2)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get time rule %d: %w", id2, err)
+	}
+	if !rule1.Enabled || !rule2.Enabled || rule1.RuleType == rule2.RuleType || !s.scheduleOverlap(rule1, rule2) {
+		return nil, fmt.Errorf("time rules '%s' and '%s' no longer conflict", rule1.Name, rule2.Name)
+	}
+
+	allow, block := allowAndBlockRules(rule1, rule2)
+	start, end, err := trimTimeWindow(allow, block)
+	if err != nil {
+		return nil, err
+	}
+
+	trimmed := *allow
+	trimmed.StartTime, trimmed.EndTime = start, end
+	return &conflictResolution{
+		plan: &ResolutionPlan{
+			ConflictID: conflictID,
+			Changes: []ResolutionChange{{
+				RuleType:    "time_rule",
+				RuleID:      allow.ID,
+				RuleName:    allow.Name,
+				Action:      "trim",
+				Description: fmt.Sprintf("Trim '%s' to %s-%s so it no longer overlaps '%s'", allow.Name, start, end, block.Name),
+				OldWindow:   allow.StartTime + "-" + allow.EndTime,
+				NewWindow:   start + "-" + end,
+			}},
+		},
+		timeRules: []models.TimeRule{trimmed},
+	}, nil
+}
+
+// planQuotaDuplicateResolution keeps the most restrictive of a list's
+// enabled quota rules of one type and disables the rest. Duplicates share a
+// quota period, so the rule with the lowest limit is the most restrictive.
+func (s *RuleValidationService) planQuotaDuplicateResolution(ctx context.Context, conflictID string) (*conflictResolution, error) {
+	rest := strings.TrimPrefix(conflictID, "quota_duplicate_")
+	sep := strings.LastIndex(rest, "_")
+	if sep < 0 {
+		return nil, fmt.Errorf("invalid quota duplicate conflict ID: %s", conflictID)
+	}
+	quotaType := models.QuotaType(rest[:sep])
+	listID, err := strconv.Atoi(rest[sep+1:])
+	if err != nil {
+		return nil, fmt.Errorf("invalid quota duplicate conflict ID: %s", conflictID)
+	}
 
-func (s *RuleValidationService) resolveTimeOverlapConflict(ctx context.Context, conflictID string) error {
-	// This would implement automatic resolution logic for time overlaps
-	// For now, just return not implemented
-	return fmt.Errorf("automatic resolution for time overlap conflicts not yet implemented")
+	rules, err := s.repos.QuotaRule.GetByListID(ctx, listID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quota rules: %w", err)
+	}
+
+	var duplicates []models.QuotaRule
+	for _, rule := range rules {
+		if rule.Enabled && rule.QuotaType == quotaType {
+			duplicates = append(duplicates, rule)
+		}
+	}
+	if len(duplicates) < 2 {
+		return nil, fmt.Errorf("list %d no longer has duplicate %s quota rules", listID, quotaType)
+	}
+
+	sort.Slice(duplicates, func(i, j int) bool {
+		if duplicates[i].LimitSeconds != duplicates[j].LimitSeconds {
+			return duplicates[i].LimitSeconds < duplicates[j].LimitSeconds
+		}
+		return duplicates[i].ID < duplicates[j].ID
+	})
+
+	kept, disabled := duplicates[0], duplicates[1:]
+	resolution := &conflictResolution{
+		plan:       &ResolutionPlan{ConflictID: conflictID},
+		quotaRules: disabled,
+	}
+	for i := range disabled {
+		disabled[i].Enabled = false
+		resolution.plan.Changes = append(resolution.plan.Changes, ResolutionChange{
+			RuleType: "quota_rule",
+			RuleID:   disabled[i].ID,
+			RuleName: disabled[i].Name,
+			Action:   "disable",
+			Description: fmt.Sprintf("Disable '%s' (%s limit %s) in favor of '%s' (%s limit %s)",
+				disabled[i].Name, quotaType, disabled[i].GetLimitDuration(), kept.Name, quotaType, kept.GetLimitDuration()),
+		})
+	}
+	return resolution, nil
+}
+
+// allowAndBlockRules orders a pair of opposing time rules as the
+// allow_during rule, then the block_during rule
+func allowAndBlockRules(rule1, rule2 *models.TimeRule) (allow, block *models.TimeRule) {
+	if rule1.RuleType == models.RuleTypeAllowDuring {
+		return rule1, rule2
+	}
+	return rule2, rule1
+}
+
+// trimTimeWindow returns keep's window with cut's window removed. Windows are
+// inclusive to the minute, as in timeRangesOverlap, and may wrap past
+// midnight. What is left must be one range: a window cut in the middle would
+// need two rules, and one cut away entirely is better disabled.
+func trimTimeWindow(keep, cut *models.TimeRule) (string, string, error) {
+	if keep.Location().String() != cut.Location().String() {
+		return "", "", fmt.Errorf("time rules '%s' and '%s' are in different timezones", keep.Name, cut.Name)
+	}
+	keepSpans, ok := daySpans(keep.StartTime, keep.EndTime)
+	if !ok {
+		return "", "", fmt.Errorf("time rule '%s' has an invalid window", keep.Name)
+	}
+	cutSpans, ok := daySpans(cut.StartTime, cut.EndTime)
+	if !ok {
+		return "", "", fmt.Errorf("time rule '%s' has an invalid window", cut.Name)
+	}
+
+	const day = 24 * 60
+	var minutes [day]bool
+	for _, span := range keepSpans {
+		for m := span[0]; m <= span[1]; m++ {
+			minutes[m] = true
+		}
+	}
+	for _, span := range cutSpans {
+		for m := span[0]; m <= span[1]; m++ {
+			minutes[m] = false
+		}
+	}
+
+	// Count the ranges left, each starting where a minute follows a gap
+	start, ranges := -1, 0
+	for m := 0; m < day; m++ {
+		if minutes[m] && !minutes[(m+day-1)%day] {
+			start = m
+			ranges++
+		}
+	}
+	switch {
+	case ranges == 0:
+		return "", "", fmt.Errorf("time rule '%s' lies within '%s'; disable one of them instead", keep.Name, cut.Name)
+	case ranges > 1:
+		return "", "", fmt.Errorf("trimming time rule '%s' around '%s' would split it in two", keep.Name, cut.Name)
+	}
+
+	end := start
+	for minutes[(end+1)%day] {
+		end = (end + 1) % day
+	}
+	return formatMinuteOfDay(start), formatMinuteOfDay(end), nil
 }
 
-func (s *RuleValidationService) resolveQuotaDuplicateConflict(ctx context.Context, conflictID string) error {
-	// This would implement automatic resolution logic for quota duplicates
-	// For now, just return not implemented
-	return fmt.Errorf("automatic resolution for quota duplicate conflicts not yet implemented")
+// formatMinuteOfDay formats a minute of the day as HH:MM
+func formatMinuteOfDay(minute int) string {
+	return fmt.Sprintf("%02d:%02d", minute/60, minute%60)
 }
@@ -0,0 +1,345 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"parental-control/internal/enforcement"
+	"parental-control/internal/logging"
+	"parental-control/internal/models"
+)
+
+func TestRuleValidationMessagesUseMetadata(t *testing.T) {
+	_, repos, listID := newImportTestService(t)
+	ctx := context.Background()
+	validator := NewRuleValidationService(repos, logging.NewDefault())
+
+	list, err := repos.List.GetByID(ctx, listID)
+	if err != nil {
+		t.Fatalf("Failed to get list: %v", err)
+	}
+	list.Label = "ads"
+	list.Notes = "Filled from the weekly block list refresh"
+	if err := repos.List.Update(ctx, list); err != nil {
+		t.Fatalf("Failed to update list: %v", err)
+	}
+
+	conflicts := validator.detectLogicalInconsistencies(ctx, listID)
+	if len(conflicts) != 1 {
+		t.Fatalf("Expected an empty list warning, got %d conflicts", len(conflicts))
+	}
+	if !strings.Contains(conflicts[0].Description, "'Imported' (ads)") {
+		t.Errorf("Expected the list label in the description, got %q", conflicts[0].Description)
+	}
+	if !strings.Contains(strings.Join(conflicts[0].Suggestions, "\n"), list.Notes) {
+		t.Errorf("Expected the list notes in the suggestions, got %v", conflicts[0].Suggestions)
+	}
+
+	entries := []models.ListEntry{
+		{ListID: listID, EntryType: models.EntryTypeURL, Pattern: "ads.example.com/*", PatternType: models.PatternTypeWildcard, Label: "trackers", Notes: "Blocks every ad path", Enabled: true},
+		{ListID: listID, EntryType: models.EntryTypeURL, Pattern: "ads.example.com", PatternType: models.PatternTypeDomain, Source: models.EntrySourceImport, Enabled: true},
+	}
+	if _, err := repos.ListEntry.CreateBatch(ctx, entries); err != nil {
+		t.Fatalf("Failed to create entries: %v", err)
+	}
+
+	overlaps, err := validator.DetectConflictingEntries(ctx, listID)
+	if err != nil {
+		t.Fatalf("Failed to detect conflicts: %v", err)
+	}
+	if len(overlaps) != 1 {
+		t.Fatalf("Expected one overlap, got %d", len(overlaps))
+	}
+	description := overlaps[0].Description
+	if !strings.Contains(description, "'ads.example.com/*' (trackers)") || !strings.Contains(description, "'ads.example.com' (imported)") {
+		t.Errorf("Expected labels and sources in the description, got %q", description)
+	}
+	if !strings.Contains(strings.Join(overlaps[0].Suggestions, "\n"), "Blocks every ad path") {
+		t.Errorf("Expected entry notes in the suggestions, got %v", overlaps[0].Suggestions)
+	}
+}
+
+func TestRuleValidationPatternsOverlap(t *testing.T) {
+	validator := NewRuleValidationService(nil, logging.NewDefault())
+
+	tests := []struct {
+		pattern1 string
+		type1    models.PatternType
+		pattern2 string
+		type2    models.PatternType
+		expected bool
+	}{
+		// Substrings are not overlaps
+		{"ads.example.com", models.PatternTypeDomain, "bads.example.com", models.PatternTypeWildcard, false},
+		{"*.example.com", models.PatternTypeWildcard, "example.com.evil.net", models.PatternTypeExact, false},
+		{"*.example.com", models.PatternTypeWildcard, "www.example.com", models.PatternTypeExact, true},
+		{"example.com", models.PatternTypeDomain, "cdn.example.com", models.PatternTypeExact, true},
+	}
+
+	for _, tt := range tests {
+		got := validator.patternsOverlap(tt.pattern1, tt.type1, tt.pattern2, tt.type2)
+		if got != tt.expected {
+			t.Errorf("patternsOverlap(%q, %q) = %v, want %v", tt.pattern1, tt.pattern2, got, tt.expected)
+		}
+	}
+}
+
+func TestDetectConflictingEntriesRelationship(t *testing.T) {
+	_, repos, listID := newImportTestService(t)
+	ctx := context.Background()
+	validator := NewRuleValidationService(repos, logging.NewDefault())
+
+	entries := []models.ListEntry{
+		{ListID: listID, EntryType: models.EntryTypeURL, Pattern: "mail.example.com", PatternType: models.PatternTypeExact, Enabled: true},
+		{ListID: listID, EntryType: models.EntryTypeURL, Pattern: "*.example.com", PatternType: models.PatternTypeWildcard, Enabled: true},
+		{ListID: listID, EntryType: models.EntryTypeURL, Pattern: "mail.*", PatternType: models.PatternTypeWildcard, Enabled: true},
+		{ListID: listID, EntryType: models.EntryTypeURL, Pattern: "other.net", PatternType: models.PatternTypeExact, Enabled: true},
+		{ListID: listID, EntryType: models.EntryTypeURL, Pattern: "10.1.0.0/16", PatternType: models.PatternTypeCIDR, Enabled: true},
+		{ListID: listID, EntryType: models.EntryTypeURL, Pattern: "10.0.0.0/8", PatternType: models.PatternTypeCIDR, Enabled: true},
+		{ListID: listID, EntryType: models.EntryTypeURL, Pattern: "192.168.0.0/16", PatternType: models.PatternTypeCIDR, Enabled: true},
+	}
+	if _, err := repos.ListEntry.CreateBatch(ctx, entries); err != nil {
+		t.Fatalf("Failed to create entries: %v", err)
+	}
+
+	conflicts, err := validator.DetectConflictingEntries(ctx, listID)
+	if err != nil {
+		t.Fatalf("Failed to detect conflicts: %v", err)
+	}
+
+	// Keyed by the broader pattern, then the narrower or overlapping one
+	got := make(map[string]enforcement.PatternRelation)
+	for _, conflict := range conflicts {
+		got[conflict.AffectedRules[0].RuleName+" "+conflict.AffectedRules[1].RuleName] = conflict.Relationship
+	}
+	want := map[string]enforcement.PatternRelation{
+		"*.example.com mail.example.com": enforcement.RelationSubsumes,
+		"mail.* mail.example.com":        enforcement.RelationSubsumes,
+		"*.example.com mail.*":           enforcement.RelationOverlaps,
+		"10.0.0.0/8 10.1.0.0/16":         enforcement.RelationSubsumes,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d conflicts, got %v", len(want), got)
+	}
+	for pair, relation := range want {
+		if got[pair] != relation {
+			t.Errorf("Expected %s to be %q, got %q", pair, relation, got[pair])
+		}
+	}
+
+	for _, conflict := range conflicts {
+		if conflict.Relationship == enforcement.RelationSubsumes &&
+			!strings.Contains(strings.Join(conflict.Suggestions, "\n"), "Remove the redundant entry '"+conflict.AffectedRules[1].RuleName+"'") {
+			t.Errorf("Expected a suggestion to remove the redundant entry, got %v", conflict.Suggestions)
+		}
+	}
+}
+
+func TestResolveConflict_QuotaDuplicate(t *testing.T) {
+	repos, _, quotaRules := newTestListRepos()
+	ctx := context.Background()
+	validator := NewRuleValidationService(repos, logging.NewDefault())
+
+	quotaRules.rules = map[int]models.QuotaRule{
+		1: {ID: 1, ListID: 1, Name: "games", QuotaType: models.QuotaTypeDaily, LimitSeconds: 7200, Enabled: true},
+		2: {ID: 2, ListID: 1, Name: "games (school)", QuotaType: models.QuotaTypeDaily, LimitSeconds: 3600, Enabled: true},
+		3: {ID: 3, ListID: 1, Name: "games (old)", QuotaType: models.QuotaTypeDaily, LimitSeconds: 5400, Enabled: true},
+		4: {ID: 4, ListID: 1, Name: "games weekly", QuotaType: models.QuotaTypeWeekly, LimitSeconds: 36000, Enabled: true},
+	}
+
+	conflicts := validator.detectQuotaRuleConflicts(ctx, 1)
+	if len(conflicts) != 1 || !conflicts[0].AutoResolvable {
+		t.Fatalf("Expected one auto-resolvable duplicate conflict, got %+v", conflicts)
+	}
+
+	// The preview lists the rules to disable without changing them
+	plan, err := validator.PreviewResolveConflict(ctx, conflicts[0].ID)
+	if err != nil {
+		t.Fatalf("PreviewResolveConflict failed: %v", err)
+	}
+	if len(plan.Changes) != 2 || plan.Changes[0].RuleID != 3 || plan.Changes[1].RuleID != 1 || plan.Changes[0].Action != "disable" {
+		t.Errorf("Expected rules 3 and 1 to be disabled, got %+v", plan.Changes)
+	}
+	if !quotaRules.rules[1].Enabled || !quotaRules.rules[3].Enabled {
+		t.Error("Expected the preview not to change any rule")
+	}
+
+	applied, err := validator.ResolveConflict(ctx, conflicts[0].ID, plan)
+	if err != nil {
+		t.Fatalf("ResolveConflict failed: %v", err)
+	}
+	if !reflect.DeepEqual(applied, plan) {
+		t.Errorf("Expected the previewed plan to be applied, got %+v", applied)
+	}
+
+	for id, enabled := range map[int]bool{1: false, 2: true, 3: false, 4: true} {
+		if quotaRules.rules[id].Enabled != enabled {
+			t.Errorf("Expected rule %d enabled=%v, got %v", id, enabled, quotaRules.rules[id].Enabled)
+		}
+	}
+	if conflicts := validator.detectQuotaRuleConflicts(ctx, 1); len(conflicts) != 0 {
+		t.Errorf("Expected no conflicts after resolving, got %+v", conflicts)
+	}
+	if _, err := validator.ResolveConflict(ctx, "quota_duplicate_daily_1", nil); err == nil {
+		t.Error("Expected resolving an already resolved conflict to fail")
+	}
+}
+
+func TestResolveConflict_TimeOverlap(t *testing.T) {
+	repos, timeRules, _ := newTestListRepos()
+	ctx := context.Background()
+	validator := NewRuleValidationService(repos, logging.NewDefault())
+
+	weekdays := []int{1, 2, 3, 4, 5}
+	timeRules.rules = map[int]models.TimeRule{
+		1: {ID: 1, ListID: 1, Name: "homework", RuleType: models.RuleTypeBlockDuring, DaysOfWeek: weekdays, StartTime: "16:00", EndTime: "18:00", Enabled: true},
+		2: {ID: 2, ListID: 1, Name: "afternoons", RuleType: models.RuleTypeAllowDuring, DaysOfWeek: weekdays, StartTime: "15:00", EndTime: "17:00", Enabled: true},
+	}
+
+	conflicts := validator.detectTimeRuleConflicts(ctx, 1)
+	if len(conflicts) != 1 || !conflicts[0].AutoResolvable {
+		t.Fatalf("Expected one auto-resolvable overlap, got %+v", conflicts)
+	}
+	if want := "Trim 'afternoons' to 15:00-15:59 so it no longer overlaps 'homework'"; conflicts[0].Suggestions[0] != want {
+		t.Errorf("Expected the trim to be offered first, got %v", conflicts[0].Suggestions)
+	}
+
+	plan, err := validator.PreviewResolveConflict(ctx, conflicts[0].ID)
+	if err != nil {
+		t.Fatalf("PreviewResolveConflict failed: %v", err)
+	}
+	if len(plan.Changes) != 1 || plan.Changes[0].OldWindow != "15:00-17:00" || plan.Changes[0].NewWindow != "15:00-15:59" {
+		t.Errorf("Expected a trim of 'afternoons', got %+v", plan.Changes)
+	}
+
+	// A plan previewed before the rules changed is refused
+	changed := timeRules.rules[1]
+	changed.StartTime = "16:30"
+	timeRules.rules[1] = changed
+	if _, err := validator.ResolveConflict(ctx, conflicts[0].ID, plan); !errors.Is(err, ErrResolutionPlanStale) {
+		t.Fatalf("Expected ErrResolutionPlanStale, got %v", err)
+	}
+	if timeRules.rules[2].EndTime != "17:00" {
+		t.Error("Expected a stale plan not to change any rule")
+	}
+	changed.StartTime = "16:00"
+	timeRules.rules[1] = changed
+
+	if _, err := validator.ResolveConflict(ctx, conflicts[0].ID, nil); err != nil {
+		t.Fatalf("ResolveConflict failed: %v", err)
+	}
+	allow, block := timeRules.rules[2], timeRules.rules[1]
+	if allow.StartTime != "15:00" || allow.EndTime != "15:59" {
+		t.Errorf("Expected the allow rule trimmed to 15:00-15:59, got %s-%s", allow.StartTime, allow.EndTime)
+	}
+	if block.StartTime != "16:00" || block.EndTime != "18:00" {
+		t.Errorf("Expected the block rule unchanged, got %s-%s", block.StartTime, block.EndTime)
+	}
+	if conflicts := validator.detectTimeRuleConflicts(ctx, 1); len(conflicts) != 0 {
+		t.Errorf("Expected no conflicts after resolving, got %+v", conflicts)
+	}
+}
+
+func TestTrimTimeWindow(t *testing.T) {
+	tests := []struct {
+		keep, cut  [2]string
+		start, end string
+		ok         bool
+	}{
+		{keep: [2]string{"15:00", "17:00"}, cut: [2]string{"16:00", "18:00"}, start: "15:00", end: "15:59", ok: true},
+		{keep: [2]string{"17:00", "21:00"}, cut: [2]string{"16:00", "18:00"}, start: "18:01", end: "21:00", ok: true},
+		{keep: [2]string{"20:00", "07:00"}, cut: [2]string{"22:00", "08:00"}, start: "20:00", end: "21:59", ok: true},
+		{keep: [2]string{"06:00", "23:00"}, cut: [2]string{"22:00", "07:00"}, start: "07:01", end: "21:59", ok: true},
+		{keep: [2]string{"09:00", "17:00"}, cut: [2]string{"12:00", "13:00"}},
+		{keep: [2]string{"12:00", "13:00"}, cut: [2]string{"09:00", "17:00"}},
+	}
+
+	for _, tt := range tests {
+		keep := &models.TimeRule{Name: "keep", StartTime: tt.keep[0], EndTime: tt.keep[1]}
+		cut := &models.TimeRule{Name: "cut", StartTime: tt.cut[0], EndTime: tt.cut[1]}
+		start, end, err := trimTimeWindow(keep, cut)
+		if (err == nil) != tt.ok {
+			t.Errorf("trimTimeWindow(%v, %v) error = %v, want ok=%v", tt.keep, tt.cut, err, tt.ok)
+			continue
+		}
+		if tt.ok && (start != tt.start || end != tt.end) {
+			t.Errorf("trimTimeWindow(%v, %v) = %s-%s, want %s-%s", tt.keep, tt.cut, start, end, tt.start, tt.end)
+		}
+	}
+}
+
+func TestDetectSystemWideConflicts_EntryCollisions(t *testing.T) {
+	_, repos, blockedID := newImportTestService(t)
+	ctx := context.Background()
+	validator := NewRuleValidationService(repos, logging.NewDefault())
+
+	// Lists are applied in name order: Allowed, Imported, Off, Zeta
+	newList := func(name string, listType models.ListType, enabled bool) int {
+		list := &models.List{Name: name, Type: listType, Enabled: true}
+		if err := repos.List.Create(ctx, list); err != nil {
+			t.Fatalf("Failed to create list: %v", err)
+		}
+		if !enabled {
+			list.Enabled = false
+			if err := repos.List.Update(ctx, list); err != nil {
+				t.Fatalf("Failed to disable list: %v", err)
+			}
+		}
+		return list.ID
+	}
+	allowedID := newList("Allowed", models.ListTypeWhitelist, true)
+	offID := newList("Off", models.ListTypeWhitelist, false)
+	zetaID := newList("Zeta", models.ListTypeWhitelist, true)
+
+	entries := []models.ListEntry{
+		{ListID: blockedID, EntryType: models.EntryTypeURL, Pattern: "games.example.com", PatternType: models.PatternTypeExact, Enabled: true},
+		{ListID: blockedID, EntryType: models.EntryTypeURL, Pattern: "social.example.com", PatternType: models.PatternTypeDomain, Enabled: true},
+		{ListID: blockedID, EntryType: models.EntryTypeExecutable, Pattern: "steam", PatternType: models.PatternTypeExact, Enabled: true},
+		{ListID: blockedID, EntryType: models.EntryTypeURL, Pattern: "video.example.com", PatternType: models.PatternTypeDomain, Enabled: true},
+		{ListID: allowedID, EntryType: models.EntryTypeURL, Pattern: "games.example.com", PatternType: models.PatternTypeExact, Enabled: true},
+		{ListID: allowedID, EntryType: models.EntryTypeExecutable, Pattern: "steam", PatternType: models.PatternTypeExact, Enabled: true},
+		{ListID: allowedID, EntryType: models.EntryTypeURL, Pattern: "video.example.com", PatternType: models.PatternTypeExact, Enabled: true},
+		{ListID: offID, EntryType: models.EntryTypeURL, Pattern: "games.example.com", PatternType: models.PatternTypeExact, Enabled: true},
+		{ListID: zetaID, EntryType: models.EntryTypeURL, Pattern: "games.example.com", PatternType: models.PatternTypeExact, Enabled: true},
+		{ListID: zetaID, EntryType: models.EntryTypeURL, Pattern: "Social.Example.com.", PatternType: models.PatternTypeDomain, Enabled: true},
+	}
+	for i := range entries {
+		if err := repos.ListEntry.Create(ctx, &entries[i]); err != nil {
+			t.Fatalf("Failed to create entry: %v", err)
+		}
+	}
+
+	lists, err := repos.List.GetAll(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get lists: %v", err)
+	}
+	conflicts := validator.detectSystemWideConflicts(ctx, lists)
+
+	// Keyed by the whitelisting list and pattern, with the winning list
+	got := make(map[string]string)
+	for _, conflict := range conflicts {
+		if len(conflict.AffectedRules) != 2 || conflict.AffectedRules[1].ListID != blockedID {
+			t.Errorf("Expected the whitelisted and blacklisted entries, got %+v", conflict.AffectedRules)
+			continue
+		}
+		white := conflict.AffectedRules[0]
+		got[white.ListName+" "+white.RuleName] = conflict.Suggestions[0]
+	}
+	want := map[string]string{
+		"Allowed games.example.com": "The entry on 'Imported' wins",
+		"Allowed steam":             "The entry on 'Imported' wins",
+		"Zeta games.example.com":    "The entry on 'Zeta' wins",
+		"Zeta Social.Example.com.":  "The entry on 'Imported' wins",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d collisions, got %v", len(want), got)
+	}
+	for key, winner := range want {
+		if !strings.HasPrefix(got[key], winner) {
+			t.Errorf("Expected %s: %q, got %q", key, winner, got[key])
+		}
+	}
+}
@@ -67,6 +67,12 @@ type Config struct {
 	EnforcementEnabled bool
 	// NotificationConfig for notification service
 	NotificationConfig NotificationConfig
+	// PolicySeed controls the retention policies created on first run
+	PolicySeed PolicySeedConfig
+	// Backup controls database backups
+	Backup BackupConfig
+	// Maintenance controls WAL checkpoints and VACUUM
+	Maintenance DatabaseMaintenanceConfig
 }
 
 // DefaultConfig returns a service configuration with sensible defaults
@@ -100,6 +106,9 @@ func DefaultConfig() Config {
 			ShowProcessDetails:        true,
 			NotificationTimeout:       5 * time.Second,
 		},
+		PolicySeed:  DefaultPolicySeedConfig(),
+		Backup:      DefaultBackupConfig(),
+		Maintenance: DefaultDatabaseMaintenanceConfig(),
 	}
 }
 
@@ -117,6 +126,26 @@ type Service struct {
 	startTime          time.Time
 	errors             []error
 	errorsMu           sync.RWMutex
+
+	// Notification audit logging, flushed before enforcement stops
+	auditService *AuditService
+
+	// Database backups, nil when disabled
+	backupService *DatabaseBackupService
+
+	// WAL checkpoints and VACUUM, nil when disabled
+	maintenanceService *DatabaseMaintenanceService
+
+	// Live events for dashboard streams
+	eventBus *EventBus
+
+	// Block list imports, shared by the API and the background refresher
+	blockListService *BlockListService
+
+	// Background routines (health checks, maintenance schedulers) run on
+	// their own context so they can stop before enforcement does
+	backgroundCtx    context.Context
+	backgroundCancel context.CancelFunc
 }
 
 // New creates a new service instance with the given configuration
@@ -124,11 +153,12 @@ func New(config Config) *Service {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	return &Service{
-		config: config,
-		state:  StateStopped,
-		ctx:    ctx,
-		cancel: cancel,
-		errors: make([]error, 0),
+		config:   config,
+		state:    StateStopped,
+		ctx:      ctx,
+		cancel:   cancel,
+		errors:   make([]error, 0),
+		eventBus: NewEventBus(),
 	}
 }
 
@@ -152,12 +182,23 @@ func (s *Service) Start() error {
 		return err
 	}
 
+	if s.config.Backup.Enabled {
+		s.backupService = NewDatabaseBackupService(s.db, logging.NewDefault(), s.config.Backup)
+	}
+	if s.config.Maintenance.Enabled {
+		s.maintenanceService = NewDatabaseMaintenanceService(s.db, logging.NewDefault(), s.config.Maintenance)
+	}
+
+	s.seedDefaultPolicies()
+
 	if err := s.initializeEnforcementService(); err != nil {
 		s.addError(fmt.Errorf("enforcement service initialization failed: %w", err))
 		s.setState(StateError)
 		return err
 	}
 
+	s.initializeBlockListService()
+
 	if err := s.writePIDFile(); err != nil {
 		s.addError(fmt.Errorf("PID file creation failed: %w", err))
 		s.setState(StateError)
@@ -168,7 +209,12 @@ func (s *Service) Start() error {
 	s.setupSignalHandling()
 
 	// Start health check routine
-	go s.healthCheckRoutine()
+	s.backgroundCtx, s.backgroundCancel = context.WithCancel(s.ctx)
+	go s.healthCheckRoutine(s.backgroundCtx)
+	go s.blockListRefreshRoutine(s.backgroundCtx)
+	if s.maintenanceService != nil {
+		go s.maintenanceService.Run(s.backgroundCtx)
+	}
 
 	s.setState(StateRunning)
 	logging.Info("Service started successfully",
@@ -178,19 +224,37 @@ func (s *Service) Start() error {
 	return nil
 }
 
-// Stop gracefully shuts down the service
+// Stop gracefully shuts down the service. Background routines stop first and
+// enforcement last, so protection lasts as long as possible and its final
+// events are still written before the database closes.
 func (s *Service) Stop(ctx context.Context) error {
 	s.setState(StateStopping)
 	logging.Info("Stopping Parental Control Service")
 
-	// Cancel context to signal all goroutines to stop
-	s.cancel()
-
 	// Create a timeout context for shutdown
 	shutdownCtx, shutdownCancel := context.WithTimeout(ctx, s.config.ShutdownTimeout)
 	defer shutdownCancel()
 
-	// Cleanup in reverse order of initialization
+	if err := s.StopSchedulers(shutdownCtx); err != nil {
+		logging.Error("Error stopping background routines", logging.Err(err))
+	}
+	if err := s.FlushAudit(shutdownCtx); err != nil {
+		logging.Error("Error flushing audit logs", logging.Err(err))
+	}
+	if err := s.StopEnforcement(shutdownCtx); err != nil {
+		logging.Error("Error stopping enforcement service", logging.Err(err))
+	}
+	if s.notificationService != nil {
+		s.notificationService.Stop()
+	}
+
+	// End live event streams
+	s.eventBus.Close()
+
+	// Cancel context to signal all remaining goroutines to stop
+	s.cancel()
+
+	// Release storage last
 	s.cleanup(shutdownCtx)
 
 	s.setState(StateStopped)
@@ -199,6 +263,42 @@ func (s *Service) Stop(ctx context.Context) error {
 	return nil
 }
 
+// StopSchedulers stops health checks and other background routines that do
+// not provide protection
+func (s *Service) StopSchedulers(ctx context.Context) error {
+	if s.backgroundCancel != nil {
+		s.backgroundCancel()
+	}
+	return nil
+}
+
+// FlushAudit writes pending audit entries while the database is still open
+func (s *Service) FlushAudit(ctx context.Context) error {
+	var errs []error
+	if s.auditService != nil {
+		if err := s.auditService.Flush(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if s.enforcementService != nil {
+		if err := s.enforcementService.FlushAudit(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to flush audit logs: %v", errs)
+	}
+	return nil
+}
+
+// StopEnforcement stops the enforcement service and DNS filtering
+func (s *Service) StopEnforcement(ctx context.Context) error {
+	if s.enforcementService == nil {
+		return nil
+	}
+	return s.enforcementService.Stop(ctx)
+}
+
 // Restart stops and then starts the service
 func (s *Service) Restart() error {
 	logging.Info("Restarting service")
@@ -248,6 +348,10 @@ func (s *Service) GetStatus() map[string]interface{} {
 		}
 	}
 
+	if s.maintenanceService != nil {
+		status["database_maintenance"] = s.maintenanceService.Stats()
+	}
+
 	if s.enforcementService != nil {
 		status["enforcement"] = map[string]interface{}{
 			"running": s.enforcementService.IsRunning(),
@@ -264,11 +368,51 @@ func (s *Service) GetRepositoryManager() *models.RepositoryManager {
 	return s.repos
 }
 
+// GetDatabase returns the database connection wrapper
+func (s *Service) GetDatabase() *database.DB {
+	return s.db
+}
+
 // GetEnforcementService returns the enforcement service for use by API servers
 func (s *Service) GetEnforcementService() *EnforcementService {
 	return s.enforcementService
 }
 
+// GetNotificationService returns the notification service, or nil before
+// the service has started
+func (s *Service) GetNotificationService() *NotificationService {
+	return s.notificationService
+}
+
+// GetBackupService returns the database backup service, or nil when
+// backups are disabled or before the service has started
+func (s *Service) GetBackupService() *DatabaseBackupService {
+	return s.backupService
+}
+
+// GetDatabaseMaintenanceService returns the database maintenance service,
+// or nil when maintenance is disabled or before the service has started
+func (s *Service) GetDatabaseMaintenanceService() *DatabaseMaintenanceService {
+	return s.maintenanceService
+}
+
+// GetBlockListService returns the block list service, or nil before the
+// service has started
+func (s *Service) GetBlockListService() *BlockListService {
+	return s.blockListService
+}
+
+// GetEventBus returns the bus services publish live events to
+func (s *Service) GetEventBus() *EventBus {
+	return s.eventBus
+}
+
+// GetAuditService returns the audit service, or nil before the service has
+// started
+func (s *Service) GetAuditService() *AuditService {
+	return s.auditService
+}
+
 // IsHealthy performs a health check and returns the result
 func (s *Service) IsHealthy() error {
 	if s.getState() != StateRunning {
@@ -294,15 +438,10 @@ func (s *Service) Wait() {
 func (s *Service) initializeDatabase() error {
 	logging.Info("Initializing database connection")
 
-	db, err := database.New(s.config.DatabaseConfig)
+	// Retries for a while in case the data volume is not mounted yet
+	db, err := database.Open(s.ctx, s.config.DatabaseConfig)
 	if err != nil {
-		return fmt.Errorf("failed to create database connection: %w", err)
-	}
-
-	logging.Info("Initializing database schema")
-	if err := db.InitializeSchema(); err != nil {
-		db.Close()
-		return fmt.Errorf("failed to initialize database schema: %w", err)
+		return fmt.Errorf("failed to open database: %w", err)
 	}
 
 	s.db = db
@@ -320,9 +459,22 @@ func (s *Service) initializeRepositories() error {
 
 	// Initialize actual repository implementations
 	s.repos = &models.RepositoryManager{
-		List:      database.NewListRepository(dbConn),
-		ListEntry: database.NewListEntryRepository(dbConn),
-		AuditLog:  database.NewAuditLogRepository(dbConn),
+		List:         database.NewListRepository(dbConn),
+		ListEntry:    database.NewListEntryRepository(dbConn),
+		AuditLog:     database.NewAuditLogRepositoryWithReader(dbConn, s.db.ReadConnection()),
+		User:         database.NewUserRepository(dbConn),
+		APIKey:       database.NewAPIKeyRepository(dbConn),
+		LockoutState: database.NewLockoutStateRepository(dbConn),
+		TimeRule:     database.NewTimeRuleRepository(dbConn),
+		QuotaRule:    database.NewQuotaRuleRepository(dbConn),
+		QuotaUsage:   database.NewQuotaUsageRepository(dbConn),
+
+		BlockListSource:      database.NewBlockListSourceRepository(dbConn),
+		RetentionPolicy:      database.NewRetentionPolicyRepository(dbConn),
+		RetentionExecution:   database.NewRetentionExecutionRepository(dbConn),
+		LogRotationPolicy:    database.NewLogRotationPolicyRepository(dbConn),
+		LogRotationExecution: database.NewLogRotationExecutionRepository(dbConn),
+		PerformanceHistory:   database.NewPerformanceHistoryRepository(dbConn),
 		// Other repositories will be added as needed
 	}
 
@@ -351,6 +503,9 @@ func (s *Service) initializeEnforcementService() error {
 		s.notificationService,
 	)
 
+	// Reload enforcement rules whenever they change in the database
+	s.repos.NotifyRuleChanges(s.enforcementService.HandleRuleChange)
+
 	if err := s.enforcementService.Start(s.ctx); err != nil {
 		return fmt.Errorf("failed to start enforcement service: %w", err)
 	}
@@ -369,26 +524,8 @@ func (s *Service) initializeNotificationService() error {
 		logging.Bool("source_app_blocking", s.config.NotificationConfig.EnableAppBlocking),
 		logging.String("source_app_name", s.config.NotificationConfig.AppName))
 
-	// Convert service config to notification config
-	notificationConfig := &NotificationConfig{
-		Enabled:                   s.config.NotificationConfig.Enabled,
-		AppName:                   s.config.NotificationConfig.AppName,
-		AppIcon:                   s.config.NotificationConfig.AppIcon,
-		MaxNotificationsPerMinute: s.config.NotificationConfig.MaxNotificationsPerMinute,
-		CooldownPeriod:            s.config.NotificationConfig.CooldownPeriod,
-		EnableAppBlocking:         s.config.NotificationConfig.EnableAppBlocking,
-		EnableWebBlocking:         s.config.NotificationConfig.EnableWebBlocking,
-		EnableTimeLimit:           s.config.NotificationConfig.EnableTimeLimit,
-		EnableSystemAlerts:        s.config.NotificationConfig.EnableSystemAlerts,
-		ShowProcessDetails:        s.config.NotificationConfig.ShowProcessDetails,
-		NotificationTimeout:       s.config.NotificationConfig.NotificationTimeout,
-	}
-
-	// Log the converted configuration
-	logging.Info("Converted notification config",
-		logging.Bool("enabled", notificationConfig.Enabled),
-		logging.Bool("app_blocking", notificationConfig.EnableAppBlocking),
-		logging.String("app_name", notificationConfig.AppName))
+	// Copy so later changes to the service config don't leak into the running service
+	notificationConfig := s.config.NotificationConfig
 
 	// Create audit service for notifications
 	auditConfig := AuditConfig{
@@ -398,12 +535,12 @@ func (s *Service) initializeNotificationService() error {
 		FlushInterval:   15 * time.Second,
 		EnableBuffering: true,
 	}
-	auditService := NewAuditService(s.repos, logging.NewDefault(), auditConfig)
+	s.auditService = NewAuditService(s.repos, logging.NewDefault(), auditConfig)
+	s.auditService.SetEventBus(s.eventBus)
 
-	s.notificationService = NewNotificationServiceWithAudit(notificationConfig, logging.NewDefault(), auditService)
-
-	logging.Info("Notification service initialized successfully",
-		logging.Bool("enabled", notificationConfig.Enabled))
+	s.notificationService = NewNotificationServiceWithAudit(&notificationConfig, logging.NewDefault(), s.auditService)
+	s.notificationService.SetEventBus(s.eventBus)
+	s.notificationService.Start()
 	return nil
 }
 
@@ -451,13 +588,13 @@ func (s *Service) setupSignalHandling() {
 }
 
 // healthCheckRoutine runs periodic health checks
-func (s *Service) healthCheckRoutine() {
+func (s *Service) healthCheckRoutine(ctx context.Context) {
 	ticker := time.NewTicker(s.config.HealthCheckInterval)
 	defer ticker.Stop()
 
 	for {
 		select {
-		case <-s.ctx.Done():
+		case <-ctx.Done():
 			return
 		case <-ticker.C:
 			if err := s.IsHealthy(); err != nil {
@@ -468,15 +605,31 @@ func (s *Service) healthCheckRoutine() {
 	}
 }
 
+// initializeBlockListService creates the block list service, which reloads
+// the enforcement rules when an import adds entries
+func (s *Service) initializeBlockListService() {
+	s.blockListService = NewBlockListService(s.repos, logging.NewDefault())
+	if s.enforcementService != nil {
+		s.blockListService.SetImportHook(func(ctx context.Context) {
+			if err := s.enforcementService.RefreshRules(ctx); err != nil {
+				logging.Error("Failed to refresh rules after block list import", logging.Err(err))
+			}
+		})
+	}
+}
+
+// blockListRefreshRoutine re-fetches subscribed block lists as they fall due
+func (s *Service) blockListRefreshRoutine(ctx context.Context) {
+	s.blockListService.Run(ctx, BlockListCheckInterval)
+}
+
 // cleanup performs cleanup tasks during shutdown
 func (s *Service) cleanup(ctx context.Context) {
 	logging.Info("Performing cleanup tasks")
 
-	// Stop enforcement service first
-	if s.enforcementService != nil {
-		if err := s.enforcementService.Stop(ctx); err != nil {
-			logging.Error("Error stopping enforcement service", logging.Err(err))
-		}
+	// Record anything logged while enforcement was stopping
+	if err := s.FlushAudit(ctx); err != nil {
+		logging.Error("Error flushing audit logs", logging.Err(err))
 	}
 
 	// Close database connection
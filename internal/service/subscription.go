@@ -0,0 +1,107 @@
+package service
+
+import "sync"
+
+// defaultSubscriberBuffer is the buffer of a subscription created with none
+const defaultSubscriberBuffer = 100
+
+// Subscription receives values published to a live stream, such as audit
+// entries or event bus events
+type Subscription[T any] struct {
+	match   func(T) bool
+	events  chan T
+	dropped bool
+	once    sync.Once
+}
+
+// Events returns the channel of published values. The channel is closed when
+// the subscription ends, either by unsubscribing or because the subscriber
+// fell too far behind.
+func (sub *Subscription[T]) Events() <-chan T {
+	return sub.events
+}
+
+// Dropped reports whether the subscription was closed because its buffer filled up
+func (sub *Subscription[T]) Dropped() bool {
+	return sub.dropped
+}
+
+func (sub *Subscription[T]) close() {
+	sub.once.Do(func() {
+		close(sub.events)
+	})
+}
+
+// subscriberSet fans published values out to live subscriptions. Publishing
+// never blocks: a subscriber that falls behind is dropped. The zero value is
+// ready to use.
+type subscriberSet[T any] struct {
+	mu   sync.Mutex
+	subs map[*Subscription[T]]struct{}
+}
+
+// subscribe registers a subscriber for the values match accepts, or for
+// every value when match is nil
+func (s *subscriberSet[T]) subscribe(match func(T) bool, bufferSize int) *Subscription[T] {
+	if bufferSize <= 0 {
+		bufferSize = defaultSubscriberBuffer
+	}
+
+	sub := &Subscription[T]{match: match, events: make(chan T, bufferSize)}
+
+	s.mu.Lock()
+	if s.subs == nil {
+		s.subs = make(map[*Subscription[T]]struct{})
+	}
+	s.subs[sub] = struct{}{}
+	s.mu.Unlock()
+
+	return sub
+}
+
+// unsubscribe removes a subscriber and closes its channel
+func (s *subscriberSet[T]) unsubscribe(sub *Subscription[T]) {
+	s.mu.Lock()
+	delete(s.subs, sub)
+	s.mu.Unlock()
+
+	sub.close()
+}
+
+// count returns the number of active subscribers
+func (s *subscriberSet[T]) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.subs)
+}
+
+// publish sends value to every subscriber that accepts it
+func (s *subscriberSet[T]) publish(value T) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for sub := range s.subs {
+		if sub.match != nil && !sub.match(value) {
+			continue
+		}
+
+		select {
+		case sub.events <- value:
+		default:
+			sub.dropped = true
+			delete(s.subs, sub)
+			sub.close()
+		}
+	}
+}
+
+// closeAll ends every subscription
+func (s *subscriberSet[T]) closeAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for sub := range s.subs {
+		delete(s.subs, sub)
+		sub.close()
+	}
+}
@@ -0,0 +1,33 @@
+//go:build !windows
+
+package service
+
+import (
+	"fmt"
+	"syscall"
+	"time"
+
+	"parental-control/internal/models"
+)
+
+// currentDiskSpace returns disk space information for the volume holding
+// the working directory on Unix-like systems
+func currentDiskSpace() (*models.DiskSpaceInfo, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(".", &stat); err != nil {
+		return nil, fmt.Errorf("statfs failed: %w", err)
+	}
+
+	totalSpace := int64(stat.Blocks) * int64(stat.Bsize)
+	freeSpace := int64(stat.Bavail) * int64(stat.Bsize)
+	return newDiskSpaceInfo(totalSpace, freeSpace), nil
+}
+
+// processCPUTime returns the user and system CPU time used by this process
+func processCPUTime() (time.Duration, error) {
+	var usage syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &usage); err != nil {
+		return 0, fmt.Errorf("getrusage failed: %w", err)
+	}
+	return time.Duration(usage.Utime.Nano() + usage.Stime.Nano()), nil
+}
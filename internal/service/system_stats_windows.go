@@ -0,0 +1,61 @@
+//go:build windows
+
+package service
+
+import (
+	"fmt"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"parental-control/internal/models"
+)
+
+var (
+	kernel32         = syscall.NewLazyDLL("kernel32.dll")
+	getDiskFreeSpace = kernel32.NewProc("GetDiskFreeSpaceExW")
+)
+
+// currentDiskSpace returns disk space information for the volume holding
+// the working directory on Windows
+func currentDiskSpace() (*models.DiskSpaceInfo, error) {
+	pwd, err := syscall.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current directory: %w", err)
+	}
+
+	// Convert to UTF16 for Windows API
+	pwdUTF16, err := syscall.UTF16PtrFromString(pwd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert path to UTF16: %w", err)
+	}
+
+	var freeBytesAvailable, totalBytes, totalFreeBytes uint64
+
+	// Call GetDiskFreeSpaceEx
+	ret, _, err := getDiskFreeSpace.Call(
+		uintptr(unsafe.Pointer(pwdUTF16)),
+		uintptr(unsafe.Pointer(&freeBytesAvailable)),
+		uintptr(unsafe.Pointer(&totalBytes)),
+		uintptr(unsafe.Pointer(&totalFreeBytes)),
+	)
+	if ret == 0 {
+		return nil, fmt.Errorf("GetDiskFreeSpaceEx failed: %w", err)
+	}
+
+	return newDiskSpaceInfo(int64(totalBytes), int64(freeBytesAvailable)), nil
+}
+
+// processCPUTime returns the kernel and user CPU time used by this process
+func processCPUTime() (time.Duration, error) {
+	var creation, exit, kernel, user syscall.Filetime
+	if err := syscall.GetProcessTimes(syscall.Handle(^uintptr(0)), &creation, &exit, &kernel, &user); err != nil {
+		return 0, fmt.Errorf("GetProcessTimes failed: %w", err)
+	}
+	return filetimeDuration(kernel) + filetimeDuration(user), nil
+}
+
+// filetimeDuration converts a FILETIME interval, counted in 100ns units
+func filetimeDuration(ft syscall.Filetime) time.Duration {
+	return time.Duration(uint64(ft.HighDateTime)<<32|uint64(ft.LowDateTime)) * 100
+}
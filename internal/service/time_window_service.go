@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -32,6 +33,7 @@ type CreateTimeRuleRequest struct {
 	DaysOfWeek []int           `json:"days_of_week" validate:"required,dive,min=0,max=6"`
 	StartTime  string          `json:"start_time" validate:"required"`
 	EndTime    string          `json:"end_time" validate:"required"`
+	Timezone   string          `json:"timezone,omitempty"` // IANA zone; empty is the server's zone
 	Enabled    bool            `json:"enabled"`
 }
 
@@ -42,6 +44,7 @@ type UpdateTimeRuleRequest struct {
 	DaysOfWeek []int            `json:"days_of_week,omitempty" validate:"omitempty,dive,min=0,max=6"`
 	StartTime  *string          `json:"start_time,omitempty" validate:"omitempty"`
 	EndTime    *string          `json:"end_time,omitempty" validate:"omitempty"`
+	Timezone   *string          `json:"timezone,omitempty"`
 	Enabled    *bool            `json:"enabled,omitempty"`
 }
 
@@ -86,6 +89,7 @@ func (s *TimeWindowService) CreateTimeRule(ctx context.Context, req CreateTimeRu
 		DaysOfWeek: req.DaysOfWeek,
 		StartTime:  req.StartTime,
 		EndTime:    req.EndTime,
+		Timezone:   req.Timezone,
 		Enabled:    req.Enabled,
 		CreatedAt:  time.Now(),
 		UpdatedAt:  time.Now(),
@@ -172,6 +176,13 @@ func (s *TimeWindowService) UpdateTimeRule(ctx context.Context, id int, req Upda
 		rule.EndTime = *req.EndTime
 	}
 
+	if req.Timezone != nil {
+		if err := models.ValidateTimezone(*req.Timezone); err != nil {
+			return nil, fmt.Errorf("invalid timezone: %w", err)
+		}
+		rule.Timezone = *req.Timezone
+	}
+
 	// Validate time range after potential updates
 	if req.StartTime != nil || req.EndTime != nil {
 		if err := s.validateTimeRange(rule.StartTime, rule.EndTime); err != nil {
@@ -237,47 +248,43 @@ func (s *TimeWindowService) ToggleTimeRuleEnabled(ctx context.Context, id int) (
 	return rule, nil
 }
 
-// GetActiveRules returns all currently active time rules
+// GetActiveRules returns all currently active time rules. Rules whose list
+// no longer exists are skipped with a warning.
 func (s *TimeWindowService) GetActiveRules(ctx context.Context) ([]models.TimeRule, error) {
 	now := time.Now()
-	return s.repos.TimeRule.GetActiveRules(ctx, now)
+	rules, err := s.repos.TimeRule.GetActiveRules(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+	return s.withExistingLists(ctx, rules), nil
 }
 
-// GetEnabledRules returns all enabled time rules
+// GetEnabledRules returns all enabled time rules. Rules whose list no longer
+// exists are skipped with a warning.
 func (s *TimeWindowService) GetEnabledRules(ctx context.Context) ([]models.TimeRule, error) {
-	return s.repos.TimeRule.GetEnabled(ctx)
-}
-
-// IsRuleActiveAt checks if a time rule is active at a specific time
-func (s *TimeWindowService) IsRuleActiveAt(rule *models.TimeRule, t time.Time) bool {
-	if !rule.Enabled {
-		return false
+	rules, err := s.repos.TimeRule.GetEnabled(ctx)
+	if err != nil {
+		return nil, err
 	}
+	return s.withExistingLists(ctx, rules), nil
+}
 
-	// Check day of week (0 = Sunday, 1 = Monday, etc.)
-	dayOfWeek := int(t.Weekday())
-	dayMatches := false
-	for _, day := range rule.DaysOfWeek {
-		if day == dayOfWeek {
-			dayMatches = true
-			break
+// withExistingLists drops rules that reference deleted lists
+func (s *TimeWindowService) withExistingLists(ctx context.Context, rules []models.TimeRule) []models.TimeRule {
+	checker := newListChecker(s.repos, s.logger)
+	valid := make([]models.TimeRule, 0, len(rules))
+	for _, rule := range rules {
+		if checker.exists(ctx, rule.ListID, "time_rule", rule.ID) {
+			valid = append(valid, rule)
 		}
 	}
+	return valid
+}
 
-	if !dayMatches {
-		return false
-	}
-
-	// Check time of day
-	currentTime := t.Format("15:04")
-
-	// Handle overnight rules (e.g., 22:00 to 06:00)
-	if rule.StartTime > rule.EndTime {
-		return currentTime >= rule.StartTime || currentTime <= rule.EndTime
-	}
-
-	// Normal rules (e.g., 09:00 to 17:00)
-	return currentTime >= rule.StartTime && currentTime <= rule.EndTime
+// IsRuleActiveAt checks if a time rule is active at a specific time. The
+// day and time of day are those on the wall clock of the rule's zone.
+func (s *TimeWindowService) IsRuleActiveAt(rule *models.TimeRule, t time.Time) bool {
+	return rule.ActiveAt(t)
 }
 
 // IsListActiveAt checks if a list should be active based on its time rules
@@ -450,6 +457,10 @@ func (s *TimeWindowService) validateCreateTimeRuleRequest(ctx context.Context, r
 		return fmt.Errorf("invalid end time: %w", err)
 	}
 
+	if err := models.ValidateTimezone(req.Timezone); err != nil {
+		return fmt.Errorf("invalid timezone: %w", err)
+	}
+
 	// Validate time range
 	return s.validateTimeRange(req.StartTime, req.EndTime)
 }
@@ -502,6 +513,22 @@ func (s *TimeWindowService) validateTimeRange(startTime, endTime string) error {
 
 // rulesOverlap checks if two time rules have overlapping schedules
 func (s *TimeWindowService) rulesOverlap(rule1, rule2 *models.TimeRule) bool {
+	return timeRulesOverlap(rule1, rule2, time.Now())
+}
+
+// overlapHorizon is how far ahead rules in different zones are compared; a
+// year covers every daylight saving time combination of the two zones
+const overlapHorizon = 371 // days
+
+// timeRulesOverlap reports whether two rules are ever active at the same
+// moment. Rules in the same zone are compared by weekday and wall clock time.
+// Rules in different zones are compared as instants over the year from
+// from, as their offset from each other changes with daylight saving time.
+func timeRulesOverlap(rule1, rule2 *models.TimeRule, from time.Time) bool {
+	if rule1.Location().String() != rule2.Location().String() {
+		return windowsOverlap(ruleWindows(rule1, from), ruleWindows(rule2, from))
+	}
+
 	// Check if they share any days
 	dayOverlap := false
 	for _, day1 := range rule1.DaysOfWeek {
@@ -521,39 +548,117 @@ func (s *TimeWindowService) rulesOverlap(rule1, rule2 *models.TimeRule) bool {
 	}
 
 	// Check if time ranges overlap
-	return s.timeRangesOverlap(rule1.StartTime, rule1.EndTime, rule2.StartTime, rule2.EndTime)
+	return timeRangesOverlap(rule1.StartTime, rule1.EndTime, rule2.StartTime, rule2.EndTime)
 }
 
-// timeRangesOverlap checks if two time ranges overlap
-func (s *TimeWindowService) timeRangesOverlap(start1, end1, start2, end2 string) bool {
-	// Parse times
-	s1, _ := time.Parse("15:04", start1)
-	e1, _ := time.Parse("15:04", end1)
-	s2, _ := time.Parse("15:04", start2)
-	e2, _ := time.Parse("15:04", end2)
+// ruleWindows returns the instants a rule is active over overlapHorizon days
+// from from, as inclusive [first, last] pairs sorted by start. Wall clock
+// times skipped by a daylight saving time change are moved forward, as by
+// time.Date.
+func ruleWindows(rule *models.TimeRule, from time.Time) [][2]time.Time {
+	spans, ok := daySpans(rule.StartTime, rule.EndTime)
+	if !ok {
+		return nil
+	}
+
+	loc := rule.Location()
+	first := from.In(loc).AddDate(0, 0, -1)
+	var windows [][2]time.Time
+	for i := 0; i <= overlapHorizon; i++ {
+		day := first.AddDate(0, 0, i)
+		if !containsDay(rule.DaysOfWeek, int(day.Weekday())) {
+			continue
+		}
+		for _, span := range spans {
+			start := time.Date(day.Year(), day.Month(), day.Day(), span[0]/60, span[0]%60, 0, 0, loc)
+			end := time.Date(day.Year(), day.Month(), day.Day(), span[1]/60, span[1]%60, 59, 0, loc)
+			windows = append(windows, [2]time.Time{start, end})
+		}
+	}
+	sort.Slice(windows, func(i, j int) bool { return windows[i][0].Before(windows[j][0]) })
+	return windows
+}
 
-	// Handle overnight ranges
-	if start1 > end1 {
-		e1 = e1.Add(24 * time.Hour)
+// windowsOverlap reports whether any windows of two sorted lists intersect
+func windowsOverlap(a, b [][2]time.Time) bool {
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		if !a[i][0].After(b[j][1]) && !b[j][0].After(a[i][1]) {
+			return true
+		}
+		if a[i][1].Before(b[j][1]) {
+			i++
+		} else {
+			j++
+		}
 	}
-	if start2 > end2 {
-		e2 = e2.Add(24 * time.Hour)
+	return false
+}
+
+// containsDay reports whether days holds day
+func containsDay(days []int, day int) bool {
+	for _, d := range days {
+		if d == day {
+			return true
+		}
 	}
+	return false
+}
 
-	// Check overlap
-	return s1.Before(e2) && s2.Before(e1)
+// timeRangesOverlap reports whether two HH:MM ranges share a minute. Ranges
+// include both ends, and a range whose start is after its end runs overnight,
+// matching IsRuleActiveAt. Unparseable times never overlap.
+func timeRangesOverlap(start1, end1, start2, end2 string) bool {
+	spans1, ok1 := daySpans(start1, end1)
+	spans2, ok2 := daySpans(start2, end2)
+	if !ok1 || !ok2 {
+		return false
+	}
+
+	for _, a := range spans1 {
+		for _, b := range spans2 {
+			if a[0] <= b[1] && b[0] <= a[1] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// daySpans splits an HH:MM range into inclusive minute-of-day spans, two for
+// an overnight range
+func daySpans(start, end string) ([][2]int, bool) {
+	startTime, err := time.Parse("15:04", start)
+	if err != nil {
+		return nil, false
+	}
+	endTime, err := time.Parse("15:04", end)
+	if err != nil {
+		return nil, false
+	}
+
+	from := startTime.Hour()*60 + startTime.Minute()
+	to := endTime.Hour()*60 + endTime.Minute()
+	if from <= to {
+		return [][2]int{{from, to}}, true
+	}
+	return [][2]int{{from, 24*60 - 1}, {0, to}}, true
 }
 
 // calculateNextStateChanges calculates when a rule will next activate/deactivate
 func (s *TimeWindowService) calculateNextStateChanges(rule *models.TimeRule, from time.Time) (*time.Time, *time.Time) {
 	// This is a simplified version - a full implementation would need to handle
-	// complex cases like overnight rules, timezone changes, etc.
+	// complex cases like overnight rules. Times are on the wall clock of the
+	// rule's zone, so they follow its daylight saving time changes.
 
 	var nextActivation, nextDeactivation *time.Time
 
+	loc := rule.Location()
+	local := from.In(loc)
+
 	// Look ahead up to 7 days
 	for i := 0; i < 7; i++ {
-		checkTime := from.AddDate(0, 0, i)
+		checkTime := local.AddDate(0, 0, i)
 		dayOfWeek := int(checkTime.Weekday())
 
 		// Check if this day is in the rule's days
@@ -574,12 +679,12 @@ func (s *TimeWindowService) calculateNextStateChanges(rule *models.TimeRule, fro
 		endTime, _ := time.Parse("15:04", rule.EndTime)
 
 		activationTime := time.Date(checkTime.Year(), checkTime.Month(), checkTime.Day(),
-			startTime.Hour(), startTime.Minute(), 0, 0, checkTime.Location())
+			startTime.Hour(), startTime.Minute(), 0, 0, loc)
 		deactivationTime := time.Date(checkTime.Year(), checkTime.Month(), checkTime.Day(),
-			endTime.Hour(), endTime.Minute(), 0, 0, checkTime.Location())
+			endTime.Hour(), endTime.Minute(), 0, 0, loc)
 
 		// Adjust for overnight rules
-		if rule.StartTime > rule.EndTime {
+		if endTime.Before(startTime) {
 			deactivationTime = deactivationTime.AddDate(0, 0, 1)
 		}
 
@@ -0,0 +1,215 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"parental-control/internal/logging"
+	"parental-control/internal/models"
+)
+
+func TestTimeRangesOverlap(t *testing.T) {
+	tests := []struct {
+		start1, end1, start2, end2 string
+		expected                   bool
+	}{
+		{"09:00", "17:00", "12:00", "13:00", true},
+		{"09:00", "12:00", "13:00", "17:00", false},
+		{"09:00", "12:00", "12:00", "17:00", true}, // both include 12:00
+		{"22:00", "02:00", "01:00", "03:00", true}, // overnight wraps past midnight
+		{"22:00", "06:00", "23:00", "01:00", true},
+		{"22:00", "06:00", "07:00", "21:00", false},
+		{"12:00", "12:00", "12:00", "12:00", true},
+		{"12:00", "12:00", "12:01", "11:59", false},
+		{"bad", "12:00", "00:00", "23:59", false},
+		{"9:00", "22:00", "21:00", "23:00", true}, // compared as times, not strings
+	}
+
+	for _, tt := range tests {
+		got := timeRangesOverlap(tt.start1, tt.end1, tt.start2, tt.end2)
+		if got != tt.expected {
+			t.Errorf("timeRangesOverlap(%s-%s, %s-%s) = %v, want %v", tt.start1, tt.end1, tt.start2, tt.end2, got, tt.expected)
+		}
+	}
+}
+
+// TestTimeRangesOverlap_MidnightWrap covers each pairing of overnight and
+// daytime ranges, in both orders, as these decide hard time rule conflicts
+func TestTimeRangesOverlap_MidnightWrap(t *testing.T) {
+	tests := []struct {
+		name                       string
+		start1, end1, start2, end2 string
+		expected                   bool
+	}{
+		// overnight and overnight always share midnight
+		{"both wrap", "21:00", "02:00", "23:00", "01:00", true},
+		{"both wrap, touching midnight only", "23:59", "00:00", "22:00", "00:00", true},
+		// overnight and daytime
+		{"wrap with early range", "21:00", "02:00", "01:00", "03:00", true},
+		{"wrap with late range", "21:00", "02:00", "20:00", "21:00", true},
+		{"wrap with range in the gap", "21:00", "02:00", "02:01", "20:59", false},
+		{"wrap with range at its end", "21:00", "02:00", "02:00", "05:00", true},
+		{"wrap with single-digit hours", "21:00", "2:00", "1:00", "3:00", true},
+		// daytime and daytime
+		{"disjoint days", "09:00", "12:00", "12:01", "17:00", false},
+		{"nested days", "09:00", "17:00", "10:00", "11:00", true},
+		{"single-digit hour before a later one", "9:00", "10:00", "22:00", "23:00", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := timeRangesOverlap(tt.start1, tt.end1, tt.start2, tt.end2); got != tt.expected {
+				t.Errorf("timeRangesOverlap(%s-%s, %s-%s) = %v, want %v", tt.start1, tt.end1, tt.start2, tt.end2, got, tt.expected)
+			}
+			if got := timeRangesOverlap(tt.start2, tt.end2, tt.start1, tt.end1); got != tt.expected {
+				t.Errorf("timeRangesOverlap(%s-%s, %s-%s) = %v, want %v", tt.start2, tt.end2, tt.start1, tt.end1, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestIsRuleActiveAt_SingleDigitHour(t *testing.T) {
+	timeService := NewTimeWindowService(nil, logging.NewDefault())
+	day := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	rule := &models.TimeRule{Enabled: true, DaysOfWeek: []int{int(day.Weekday())}, StartTime: "9:00", EndTime: "17:00", Timezone: "UTC"}
+
+	if !timeService.IsRuleActiveAt(rule, day.Add(12*time.Hour)) {
+		t.Error("Expected 9:00-17:00 to be active at noon")
+	}
+	if timeService.IsRuleActiveAt(rule, day.Add(20*time.Hour)) {
+		t.Error("Expected 9:00-17:00 to be inactive at 20:00")
+	}
+}
+
+// FuzzTimeRangesOverlap checks timeRangesOverlap against the minutes
+// IsRuleActiveAt treats as active for each range
+func FuzzTimeRangesOverlap(f *testing.F) {
+	f.Add(uint16(22*60), uint16(2*60), uint16(60), uint16(3*60))
+	f.Add(uint16(9*60), uint16(17*60), uint16(17*60), uint16(18*60))
+	f.Add(uint16(0), uint16(0), uint16(23*60+59), uint16(0))
+
+	timeService := NewTimeWindowService(nil, logging.NewDefault())
+	day := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	clock := func(minute uint16) string {
+		minute %= 24 * 60
+		return fmt.Sprintf("%02d:%02d", minute/60, minute%60)
+	}
+
+	f.Fuzz(func(t *testing.T, start1, end1, start2, end2 uint16) {
+		rule1 := &models.TimeRule{Enabled: true, DaysOfWeek: []int{int(day.Weekday())}, StartTime: clock(start1), EndTime: clock(end1), Timezone: "UTC"}
+		rule2 := &models.TimeRule{Enabled: true, DaysOfWeek: []int{int(day.Weekday())}, StartTime: clock(start2), EndTime: clock(end2), Timezone: "UTC"}
+
+		want := false
+		for minute := 0; minute < 24*60 && !want; minute++ {
+			at := day.Add(time.Duration(minute) * time.Minute)
+			want = timeService.IsRuleActiveAt(rule1, at) && timeService.IsRuleActiveAt(rule2, at)
+		}
+
+		got := timeRangesOverlap(rule1.StartTime, rule1.EndTime, rule2.StartTime, rule2.EndTime)
+		if got != want {
+			t.Errorf("timeRangesOverlap(%s-%s, %s-%s) = %v, want %v", rule1.StartTime, rule1.EndTime, rule2.StartTime, rule2.EndTime, got, want)
+		}
+		if got != timeRangesOverlap(rule2.StartTime, rule2.EndTime, rule1.StartTime, rule1.EndTime) {
+			t.Errorf("timeRangesOverlap(%s-%s, %s-%s) is not symmetric", rule1.StartTime, rule1.EndTime, rule2.StartTime, rule2.EndTime)
+		}
+		if !timeRangesOverlap(rule1.StartTime, rule1.EndTime, rule1.StartTime, rule1.EndTime) {
+			t.Errorf("%s-%s should overlap itself", rule1.StartTime, rule1.EndTime)
+		}
+	})
+}
+
+func TestIsRuleActiveAt_Timezone(t *testing.T) {
+	timeService := NewTimeWindowService(nil, logging.NewDefault())
+	chicago, err := time.LoadLocation("America/Chicago")
+	if err != nil {
+		t.Fatalf("Failed to load zone: %v", err)
+	}
+
+	// 08:00-09:00 in Chicago, every day
+	rule := &models.TimeRule{
+		Enabled:    true,
+		DaysOfWeek: []int{0, 1, 2, 3, 4, 5, 6},
+		StartTime:  "08:00",
+		EndTime:    "09:00",
+		Timezone:   "America/Chicago",
+	}
+
+	tests := []struct {
+		at       time.Time
+		expected bool
+	}{
+		{time.Date(2024, time.January, 15, 14, 30, 0, 0, time.UTC), true},  // CST, UTC-6
+		{time.Date(2024, time.January, 15, 13, 30, 0, 0, time.UTC), false}, // 07:30 CST
+		{time.Date(2024, time.July, 15, 13, 30, 0, 0, time.UTC), true},     // CDT, UTC-5
+		{time.Date(2024, time.July, 15, 14, 30, 0, 0, time.UTC), false},    // 09:30 CDT
+		{time.Date(2024, time.March, 10, 8, 30, 0, 0, chicago), true},      // the day clocks go forward
+		{time.Date(2024, time.November, 3, 8, 30, 0, 0, chicago), true},    // the day clocks go back
+	}
+
+	for _, tt := range tests {
+		if got := timeService.IsRuleActiveAt(rule, tt.at); got != tt.expected {
+			t.Errorf("IsRuleActiveAt(%s) = %v, want %v", tt.at.Format(time.RFC3339), got, tt.expected)
+		}
+	}
+
+	// Sunday 23:30 in Chicago is Monday in UTC
+	rule.DaysOfWeek = []int{int(time.Sunday)}
+	rule.StartTime, rule.EndTime = "23:00", "23:59"
+	if !timeService.IsRuleActiveAt(rule, time.Date(2024, time.January, 15, 5, 30, 0, 0, time.UTC)) {
+		t.Error("Expected the weekday to be taken in the rule's zone")
+	}
+}
+
+func TestTimeRulesOverlap_Timezones(t *testing.T) {
+	everyDay := []int{0, 1, 2, 3, 4, 5, 6}
+	newRule := func(start, end, zone string) *models.TimeRule {
+		return &models.TimeRule{Enabled: true, DaysOfWeek: everyDay, StartTime: start, EndTime: end, Timezone: zone}
+	}
+	from := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		rule1, rule2 *models.TimeRule
+		expected     bool
+	}{
+		{
+			"same wall clock in different zones",
+			newRule("09:00", "10:00", "America/New_York"), newRule("09:00", "10:00", "Europe/London"),
+			false,
+		},
+		{
+			"same instant in different zones",
+			newRule("09:00", "10:00", "America/New_York"), newRule("14:00", "15:00", "Europe/London"),
+			true,
+		},
+		{
+			// 13:00-13:59 UTC while the US is on daylight saving time and
+			// London is not, which happens for a few weeks each year
+			"overlap only between daylight saving time changes",
+			newRule("09:00", "09:59", "America/New_York"), newRule("13:00", "13:59", "Europe/London"),
+			true,
+		},
+		{
+			"overnight window across zones",
+			newRule("22:00", "02:00", "America/Los_Angeles"), newRule("07:00", "08:00", "UTC"),
+			true,
+		},
+		{
+			"same zone compared by wall clock",
+			newRule("09:00", "10:00", "Europe/Berlin"), newRule("10:00", "11:00", "Europe/Berlin"),
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := timeRulesOverlap(tt.rule1, tt.rule2, from); got != tt.expected {
+				t.Errorf("timeRulesOverlap = %v, want %v", got, tt.expected)
+			}
+			if got := timeRulesOverlap(tt.rule2, tt.rule1, from); got != tt.expected {
+				t.Errorf("timeRulesOverlap reversed = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
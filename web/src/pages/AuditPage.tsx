@@ -113,10 +113,10 @@ function AuditPage() {
         offset: page * rowsPerPage,
       });
       
-      if (Array.isArray(auditData)) {
-        setAuditLogs(auditData);
-        setTotalCount(auditData.length); // In a real implementation, this would come from the API
-        updateStats(auditData);
+      if (Array.isArray(auditData?.items)) {
+        setAuditLogs(auditData.items);
+        setTotalCount(auditData.total);
+        updateStats(auditData.items);
       } else {
         setAuditLogs([]);
         setError('Received unexpected data format from server');
@@ -330,6 +330,7 @@ function AuditPage() {
       case 'access_attempt':
         return <Security fontSize="small" />;
       case 'rule_violation':
+      case 'bypass_attempt':
         return <Warning fontSize="small" />;
       case 'system_event':
         return <Info fontSize="small" />;
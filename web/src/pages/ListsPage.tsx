@@ -879,7 +879,13 @@ function ListsPage() {
                   ? "e.g., 'youtube.com', 'facebook.com'"
                   : entryForm.pattern_type === 'wildcard'
                     ? "e.g., '*.example.com', '*social*'"
-                    : "e.g., 'https://example.com/path'"
+                    : entryForm.pattern_type === 'regex'
+                      ? "e.g., '^ads[0-9]+\\.example\\.com$'"
+                      : entryForm.pattern_type === 'cidr'
+                        ? "e.g., '10.0.0.0/8', '192.168.1.0/24'"
+                        : entryForm.pattern_type === 'port'
+                          ? "e.g., 'udp/27015', '6881-6889'"
+                          : "e.g., 'https://example.com/path'"
               }
             />
           )}
@@ -894,6 +900,9 @@ function ListsPage() {
                 <MenuItem value="exact">Exact Match</MenuItem>
                 <MenuItem value="wildcard">Wildcard</MenuItem>
                 <MenuItem value="domain">Domain</MenuItem>
+                <MenuItem value="regex">Regular Expression</MenuItem>
+                <MenuItem value="cidr">IP Range (CIDR)</MenuItem>
+                <MenuItem value="port">Port / Protocol</MenuItem>
               </Select>
             </FormControl>
           )}
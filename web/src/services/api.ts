@@ -20,6 +20,7 @@ import {
   LoginResponse,
   SearchFilters,
   AuditLogFilters,
+  PagedResponse,
   Config,
   ApplicationInfo,
   ApplicationDiscoveryResponse
@@ -298,7 +299,7 @@ class ApiClient {
   }
 
   // Audit Logs API
-  public async getAuditLogs(filters?: AuditLogFilters): Promise<AuditLog[]> {
+  public async getAuditLogs(filters?: AuditLogFilters): Promise<PagedResponse<AuditLog>> {
     const params = new URLSearchParams();
     if (filters) {
       Object.entries(filters).forEach(([key, value]) => {
@@ -311,7 +312,7 @@ class ApiClient {
     const query = params.toString();
     const endpoint = query ? `/api/v1/audit?${query}` : '/api/v1/audit';
     
-    return this.request<AuditLog[]>(endpoint);
+    return this.request<PagedResponse<AuditLog>>(endpoint);
   }
 
   // Configuration API
@@ -26,7 +26,8 @@ export interface Config {
 
 export type ListType = 'whitelist' | 'blacklist';
 export type EntryType = 'executable' | 'url';
-export type PatternType = 'exact' | 'wildcard' | 'domain';
+export type PatternType = 'exact' | 'wildcard' | 'domain' | 'regex' | 'cidr' | 'port';
+export type EntrySource = 'manual' | 'import';
 export type RuleType = 'allow_during' | 'block_during';
 export type QuotaType = 'daily' | 'weekly' | 'monthly';
 export type ActionType = 'allow' | 'block';
@@ -37,6 +38,8 @@ export interface List {
   name: string;
   type: ListType;
   description: string;
+  label?: string;
+  notes?: string;
   enabled: boolean;
   created_at: string;
   updated_at: string;
@@ -50,6 +53,9 @@ export interface ListEntry {
   pattern: string;
   pattern_type: PatternType;
   description: string;
+  label?: string;
+  notes?: string;
+  source: EntrySource;
   enabled: boolean;
   created_at: string;
   updated_at: string;
@@ -63,6 +69,7 @@ export interface TimeRule {
   days_of_week: number[];
   start_time: string;
   end_time: string;
+  timezone?: string;
   enabled: boolean;
   created_at: string;
   updated_at: string;
@@ -129,6 +136,8 @@ export interface CreateListRequest {
   name: string;
   type: ListType;
   description: string;
+  label?: string;
+  notes?: string;
   enabled: boolean;
 }
 
@@ -142,6 +151,8 @@ export interface CreateListEntryRequest {
   pattern: string;
   pattern_type: PatternType;
   description: string;
+  label?: string;
+  notes?: string;
   enabled: boolean;
 }
 
@@ -156,6 +167,7 @@ export interface CreateTimeRuleRequest {
   days_of_week: number[];
   start_time: string;
   end_time: string;
+  timezone?: string;
   enabled: boolean;
 }
 
@@ -198,6 +210,14 @@ export interface PaginationParams {
   offset?: number;
 }
 
+export interface PagedResponse<T> {
+  items: T[];
+  total: number;
+  limit: number;
+  offset: number;
+  has_more: boolean;
+}
+
 export interface SearchFilters extends PaginationParams {
   enabled?: boolean;
   list_type?: ListType;